@@ -1,47 +1,44 @@
 package admin
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/dcrauwels/goqueue/api"
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
-	"github.com/google/uuid"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/dcrauwels/goqueue/validate"
 )
 
 type configReader interface {
 	GetSecret() string
 	GetEnv() string
 	GeneratePublicID() string
+	GetPasswordPolicy() strutils.PasswordPolicy
+	GetPasswordHasher() auth.PasswordHasher
 }
 
-type databaseQueryer interface {
-	CreateUser(context.Context, database.CreateUserParams) (database.User, error)
-	SetUserIsAdminByID(context.Context, database.SetUserIsAdminByIDParams) (database.User, error)
-	GetUserByID(context.Context, uuid.UUID) (database.User, error)
-}
-
-func AdminCreateUser(w http.ResponseWriter, r *http.Request, cfg configReader, db databaseQueryer) {
+func AdminCreateUser(w http.ResponseWriter, r *http.Request, cfg configReader, db storage.Store) {
 	// used for making an admin auth user
 	// 1. check dev env (there is no point checking isAdmin)
 	env := cfg.GetEnv()
 	if env != "dev" {
-		jsonutils.WriteError(w, 403, errors.New("endpoint not approached in DEV enrironment"), "incorrect environment")
+		jsonutils.WriteError(w, r, 403, errors.New("endpoint not approached in DEV enrironment"), "incorrect environment")
 		return
 	}
 
 	// 2. get req params: email & password
 	request := api.UsersPOSTRequestParameters{}
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&request)
+	err := (&validate.Validator{}).Decode(w, r, &request, validate.MaxBodyBytes) // the rules of users refer to nothing
 	if err != nil {
-		jsonutils.WriteError(w, 400, err, "incorrect json request structure")
+		jsonutils.WriteError(w, r, validate.Status(err), err, "incorrect json request structure")
 		return
 	}
-	hashedPassword, err := api.ProcessUsersParameters(w, request)
+	hashedPassword, err := api.ProcessUsersParameters(w, r, request, cfg.GetPasswordPolicy(), cfg.GetPasswordHasher())
 	if err != nil {
 		return //already calls jsonutils.WriteError
 	}
@@ -54,24 +51,46 @@ func AdminCreateUser(w http.ResponseWriter, r *http.Request, cfg configReader, d
 		FullName:       request.FullName,
 		PublicID:       pid,
 	}
-	createdUser, err := db.CreateUser(r.Context(), queryCreateParams)
-	if err != nil {
-		jsonutils.WriteError(w, 500, err, "error querying database for user creation")
-		return
-	}
+	var adminUser database.User
+	err = db.InTx(r.Context(), func(q storage.Store) error {
+		createdUser, err := q.CreateUser(r.Context(), queryCreateParams)
+		if err != nil {
+			return err
+		}
 
-	// 4. set user admin
-	queryAdminParams := database.SetUserIsAdminByIDParams{
-		ID:      createdUser.ID,
-		IsAdmin: true,
-	}
-	adminUser, err := db.SetUserIsAdminByID(r.Context(), queryAdminParams)
+		// 4. set user admin, in the same transaction so that a failure does not leave a user without admin rights
+		queryAdminParams := database.SetUserIsAdminByIDParams{
+			ID:      createdUser.ID,
+			IsAdmin: true,
+		}
+		adminUser, err = q.SetUserIsAdminByID(r.Context(), queryAdminParams)
+		if err != nil {
+			return err
+		}
+
+		// 5. audit log. The endpoint requires no authentication (dev only), so there is no actor
+		before := api.UsersResponseParameters{}
+		before.Populate(createdUser)
+		after := api.UsersResponseParameters{}
+		after.Populate(adminUser)
+		for _, e := range []audit.Event{
+			{Action: audit.ActionUserCreate, Before: nil, After: before},
+			{Action: audit.ActionUserPromote, Before: before, After: after},
+		} {
+			e.EntityType, e.EntityPublicID = audit.EntityUser, adminUser.PublicID
+			e.RequestID, e.IPAddress = api.RequestIDFromContext(r.Context()), strutils.GetIPFromRequest(r)
+			if _, err = audit.Record(r.Context(), q, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		jsonutils.WriteError(w, 500, err, "error querying database for setting IsAdmin")
+		jsonutils.WriteError(w, r, 500, err, "error querying database for creating admin user")
 		return
 	}
 
-	// 5. response
+	// 6. response
 	response := api.UsersResponseParameters{
 		ID:        adminUser.ID,
 		CreatedAt: adminUser.CreatedAt,
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dcrauwels/goqueue/api"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/jaevor/go-nanoid"
+)
+
+// failingSetAdminStore fails every SetUserIsAdminByID, including those in transactions
+type failingSetAdminStore struct {
+	storage.Store
+}
+
+func (s failingSetAdminStore) InTx(ctx context.Context, fn func(q storage.Store) error) error {
+	return s.Store.InTx(ctx, func(q storage.Store) error {
+		return fn(failingSetAdminStore{q})
+	})
+}
+
+func (s failingSetAdminStore) SetUserIsAdminByID(context.Context, database.SetUserIsAdminByIDParams) (database.User, error) {
+	return database.User{}, errors.New("SetUserIsAdminByID failed")
+}
+
+func TestAdminCreateUser(t *testing.T) {
+	pidGenerator, err := nanoid.Standard(12)
+	if err != nil {
+		t.Fatalf(`nanoid.Standard: %v`, err)
+	}
+	store := storage.NewMemory()
+	cfg := api.ApiConfig{
+		DB:                store,
+		Env:               "dev",
+		PublicIDGenerator: pidGenerator,
+		PublicIDLength:    12,
+		PasswordPolicy:    strutils.DefaultPasswordPolicy(),
+		PasswordHasher:    auth.PasswordHasher{BcryptCost: 4},
+	}
+	body, _ := json.Marshal(api.UsersPOSTRequestParameters{Email: "admin@example.org", Password: "correct horse battery staple", FullName: "Admin"})
+	createAdmin := func(db storage.Store) int {
+		w := httptest.NewRecorder()
+		AdminCreateUser(w, httptest.NewRequest("POST", "/admin/users", bytes.NewReader(body)), cfg, db)
+		return w.Code
+	}
+
+	// a failure to promote the user leaves no user behind
+	if status := createAdmin(failingSetAdminStore{store}); status != http.StatusInternalServerError {
+		t.Fatalf(`AdminCreateUser returned %d with a failing SetUserIsAdminByID, expected 500`, status)
+	}
+	if _, err := store.GetUserByEmail(context.Background(), "admin@example.org"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetUserByEmail returned %v after a failed AdminCreateUser, expected sql.ErrNoRows`, err)
+	}
+
+	// which allows creating it again
+	if status := createAdmin(store); status != http.StatusOK {
+		t.Fatalf(`AdminCreateUser returned %d, expected 200`, status)
+	}
+	user, err := store.GetUserByEmail(context.Background(), "admin@example.org")
+	if err != nil || !user.IsAdmin {
+		t.Errorf(`GetUserByEmail returned %+v, %v, expected an admin user`, user, err)
+	}
+}
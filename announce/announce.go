@@ -0,0 +1,172 @@
+// Package announce composes what display screens say when a visitor is called, e.g. "Ticket 12, please go to desk
+// F1", in each language of the location: as plain text and as SSML, so a text-to-speech engine on the display
+// device can speak it. Announcements are made from templates with placeholders for the ticket number, the desk and
+// the purpose; every language in Builtin has a template of its own.
+package announce
+
+import (
+	"errors"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dcrauwels/goqueue/i18n"
+)
+
+// placeholders in templates
+const (
+	PlaceholderTicket  = "{ticket}"
+	PlaceholderDesk    = "{desk}"
+	PlaceholderPurpose = "{purpose}"
+)
+
+// DefaultLanguage is announced when a location has no templates
+const DefaultLanguage = "en"
+
+// Builtin holds the templates of the languages that need none to be configured, keyed by language
+var Builtin = map[string]string{
+	"de": "Nummer {ticket}, bitte zu Schalter {desk}.",
+	"en": "Ticket {ticket}, please go to desk {desk}.",
+	"es": "Número {ticket}, acuda al puesto {desk}.",
+	"fr": "Numéro {ticket}, veuillez vous présenter au guichet {desk}.",
+	"nl": "Nummer {ticket}, gaat u naar balie {desk}.",
+}
+
+var (
+	ErrInvalidLanguage = errors.New("language must be a language tag like en or pt-BR")
+	ErrNoBuiltin       = errors.New("language has no built-in template, so a template is required")
+	ErrInvalidTemplate = errors.New("template can only contain the placeholders {ticket}, {desk} and {purpose} and must contain {ticket}")
+)
+
+var placeholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+type Template struct {
+	Language string // language tag, e.g. nl or pt-BR
+	Text     string // empty for the built-in template of the language
+}
+
+func builtin(language string) (string, bool) {
+	// the built-in template of language, or of its primary language: nl-BE is announced like nl
+	if text, ok := Builtin[strings.ToLower(language)]; ok {
+		return text, true
+	}
+	primary, _, _ := strings.Cut(language, "-")
+	text, ok := Builtin[strings.ToLower(primary)]
+	return text, ok
+}
+
+func (t Template) text() (string, error) {
+	if t.Text == "" {
+		if text, ok := builtin(t.Language); ok {
+			return text, nil
+		}
+		return "", ErrNoBuiltin
+	}
+	return t.Text, nil
+}
+
+func Validate(t Template) error {
+	// reports whether t can be announced: a valid language tag, and a known template
+	if !i18n.ValidTag(t.Language) {
+		return ErrInvalidLanguage
+	}
+	text, err := t.text()
+	if err != nil {
+		return err
+	}
+	rest := placeholder.ReplaceAllStringFunc(text, func(p string) string {
+		if p == PlaceholderTicket || p == PlaceholderDesk || p == PlaceholderPurpose {
+			return ""
+		}
+		return "{"
+	})
+	if strings.ContainsAny(rest, "{}") || !strings.Contains(text, PlaceholderTicket) {
+		return ErrInvalidTemplate
+	}
+	return nil
+}
+
+type Call struct {
+	Ticket              int32             // daily ticket number
+	Desk                string            // name of the desk, e.g. F1
+	Purpose             string            // name of the purpose
+	PurposeTranslations map[string]string // name of the purpose in other languages, keyed by language tag
+}
+
+func (c Call) in(language string) Call {
+	// the call with the name of the purpose in language, if it has a translation that fits
+	tags := slices.Sorted(maps.Keys(c.PurposeTranslations))
+	if tag, ok := i18n.Match(language, tags); ok {
+		c.Purpose = c.PurposeTranslations[tag]
+	}
+	return c
+}
+
+type Announcement struct {
+	Language string `json:"language"`
+	Text     string `json:"text"` // for screens and engines without SSML support
+	SSML     string `json:"ssml"` // a complete SSML 1.1 document
+}
+
+func Render(templates []Template, call Call) []Announcement {
+	/*
+		Announces call once in the language of each template, in the order of templates, with the name of the purpose
+		in that language where it has one. Templates that do not Validate are left out; without any, call is announced
+		in DefaultLanguage.
+	*/
+	var announcements []Announcement
+	for _, t := range templates {
+		if Validate(t) != nil {
+			continue
+		}
+		text, _ := t.text()
+		c := call.in(t.Language)
+		announcements = append(announcements, Announcement{Language: t.Language, Text: plain(text, c), SSML: ssml(t.Language, text, c)})
+	}
+	if len(announcements) == 0 {
+		text, _ := builtin(DefaultLanguage)
+		c := call.in(DefaultLanguage)
+		announcements = append(announcements, Announcement{Language: DefaultLanguage, Text: plain(text, c), SSML: ssml(DefaultLanguage, text, c)})
+	}
+	return announcements
+}
+
+func plain(text string, call Call) string {
+	return strings.NewReplacer(
+		PlaceholderTicket, strconv.Itoa(int(call.Ticket)),
+		PlaceholderDesk, call.Desk,
+		PlaceholderPurpose, call.Purpose,
+	).Replace(text)
+}
+
+func ssml(language, text string, call Call) string {
+	/*
+		The template as SSML. The ticket number is read as a number ("twelve", not "one two"), and short desk names
+		that mix letters and digits, like F1, are spelled out, which engines otherwise tend to read as a word.
+	*/
+	desk := escape(call.Desk)
+	if isNumber(call.Desk) {
+		desk = `<say-as interpret-as="cardinal">` + desk + `</say-as>`
+	} else if len([]rune(call.Desk)) <= 4 && strings.ContainsFunc(call.Desk, unicode.IsDigit) {
+		desk = `<say-as interpret-as="characters">` + desk + `</say-as>`
+	}
+	body := strings.NewReplacer(
+		PlaceholderTicket, `<say-as interpret-as="cardinal">`+strconv.Itoa(int(call.Ticket))+`</say-as>`,
+		PlaceholderDesk, desk,
+		PlaceholderPurpose, escape(call.Purpose),
+	).Replace(escape(text)) // escaping leaves the placeholders as they are
+	return `<speak version="1.1" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="` + escape(language) + `">` + body + `</speak>`
+}
+
+func isNumber(s string) bool {
+	return s != "" && !strings.ContainsFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+func escape(s string) string {
+	return xmlEscaper.Replace(s)
+}
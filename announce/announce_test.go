@@ -0,0 +1,69 @@
+package announce
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		template Template
+		want     error
+	}{
+		{Template{Language: "nl"}, nil},
+		{Template{Language: "nl-BE"}, nil},
+		{Template{Language: "pt-BR", Text: "Senha {ticket}, guichê {desk}."}, nil},
+		{Template{Language: "en", Text: "{purpose}: ticket {ticket} to {desk}"}, nil},
+		{Template{Language: "pt"}, ErrNoBuiltin},
+		{Template{Language: "English"}, ErrInvalidLanguage},
+		{Template{Language: ""}, ErrInvalidLanguage},
+		{Template{Language: "en", Text: "Ticket {number} to {desk}"}, ErrInvalidTemplate},
+		{Template{Language: "en", Text: "Ticket {ticket} to {desk"}, ErrInvalidTemplate},
+		{Template{Language: "en", Text: "Please go to desk {desk}"}, ErrInvalidTemplate},
+	} {
+		if err := Validate(tt.template); err != tt.want {
+			t.Errorf(`Validate(%+v) returned %v, expected %v`, tt.template, err, tt.want)
+		}
+	}
+	for language := range Builtin {
+		if err := Validate(Template{Language: language}); err != nil {
+			t.Errorf(`built-in template of %s is invalid: %v`, language, err)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	call := Call{Ticket: 12, Desk: "F1", Purpose: "Passports & ID"}
+	got := Render([]Template{{Language: "nl"}, {Language: "xx"}, {Language: "en", Text: "{purpose}: ticket {ticket}, desk {desk}."}}, call)
+	if len(got) != 2 || got[0].Language != "nl" || got[1].Language != "en" {
+		t.Fatalf(`Render returned %+v, expected announcements in nl and en`, got)
+	}
+	if got[0].Text != "Nummer 12, gaat u naar balie F1." || got[1].Text != "Passports & ID: ticket 12, desk F1." {
+		t.Errorf(`Render returned texts %q and %q`, got[0].Text, got[1].Text)
+	}
+	wantSSML := `<speak version="1.1" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="en">Passports &amp; ID: ticket <say-as interpret-as="cardinal">12</say-as>, desk <say-as interpret-as="characters">F1</say-as>.</speak>`
+	if got[1].SSML != wantSSML {
+		t.Errorf(`Render returned SSML %s, expected %s`, got[1].SSML, wantSSML)
+	}
+	for _, a := range got {
+		if err := xml.Unmarshal([]byte(a.SSML), new(struct{})); err != nil {
+			t.Errorf(`SSML %s is not well-formed: %v`, a.SSML, err)
+		}
+	}
+
+	// desks named by a number are read as one, long names as they are
+	if a := Render(nil, Call{Ticket: 3, Desk: "7"}); len(a) != 1 || a[0].Language != DefaultLanguage || !strings.Contains(a[0].SSML, `desk <say-as interpret-as="cardinal">7</say-as>`) {
+		t.Errorf(`Render without templates returned %+v, expected an English announcement`, a)
+	}
+	if a := Render(nil, Call{Ticket: 3, Desk: "Reception 2"}); !strings.Contains(a[0].SSML, "desk Reception 2.") {
+		t.Errorf(`Render returned %s, expected the desk name as it is`, a[0].SSML)
+	}
+
+	// the purpose in the language of the template, if it has a name in that language
+	call.PurposeTranslations = map[string]string{"nl": "Paspoorten"}
+	got = Render([]Template{{Language: "nl-BE", Text: "{purpose}: nummer {ticket}"}, {Language: "de", Text: "{purpose}: Nummer {ticket}"}}, call)
+	if got[0].Text != "Paspoorten: nummer 12" || got[1].Text != "Passports & ID: Nummer 12" {
+		t.Errorf(`Render with purpose translations returned texts %q and %q`, got[0].Text, got[1].Text)
+	}
+}
@@ -5,22 +5,42 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
-	"time"
+	"strings"
 
+	"github.com/dcrauwels/goqueue/audit"
 	"github.com/dcrauwels/goqueue/auth"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/mailer"
+	"github.com/dcrauwels/goqueue/notify"
+	"github.com/dcrauwels/goqueue/retention"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
 	"github.com/google/uuid"
 )
 
 type ApiConfig struct {
-	DB                   *database.Queries
-	Secret               string
-	Env                  string
-	AccessTokenDuration  int
-	RefreshTokenDuration int
-	PublicIDGenerator    func() string
-	PublicIDLength       int
+	DB                         storage.Store
+	Secret                     string
+	Env                        string
+	AccessTokenDuration        int
+	RefreshTokenDuration       int
+	PublicIDGenerator          func() string
+	PublicIDLength             int
+	AccountLoginLimiter        auth.LoginLimiter
+	IPLoginLimiter             auth.LoginLimiter
+	Mailer                     mailer.Sender
+	PublicBaseURL              string
+	StatusPageURL              string // the visitor's public ID is appended to it. Empty for PublicBaseURL + "/visitors/"
+	InvitationTokenDuration    int
+	PasswordResetTokenDuration int
+	PasswordPolicy             strutils.PasswordPolicy
+	PasswordHasher             auth.PasswordHasher
+	TrustedOrigins             []string
+	RequireIfMatch             bool // PUT requests must send the ETag of the version they change
+	IdempotencyKeyDuration     int  // hours responses to requests with an Idempotency-Key are replayed for
+	RetentionPolicy            retention.Policy
+	Notifier                   *notify.Notifier // nil to not notify visitors
 }
 
 func (cfg *ApiConfig) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
@@ -39,6 +59,76 @@ func (cfg ApiConfig) GeneratePublicID() string {
 	return cfg.PublicIDGenerator()
 }
 
+func (cfg ApiConfig) GetPasswordPolicy() strutils.PasswordPolicy {
+	return cfg.PasswordPolicy
+}
+
+func (cfg ApiConfig) GetPasswordHasher() auth.PasswordHasher {
+	return cfg.PasswordHasher
+}
+
+type contextKey string
+
+const requestIDContextKey contextKey = "requestID"
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	/*
+		Middleware that gives every request an ID, taken from the X-Request-ID header if a proxy in front of goqueue
+		already set a sane one and generated otherwise. The ID is echoed in the X-Request-ID response header and stored
+		in the request context for audit events, see RequestIDFromContext.
+	*/
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !isValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := context.WithValue(r.Context(), requestIDContextKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func isValidRequestID(requestID string) bool {
+	// the ID ends up in logs and in the audit table, so only short IDs of a conservative character set are accepted
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+	for _, c := range requestID {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func notFound(e *jsonutils.Error, err error) error {
+	// err with the code of e if it is sql.ErrNoRows, so that a 404 tells which of several lookups found nothing
+	if errors.Is(err, sql.ErrNoRows) {
+		return e.Wrap(err)
+	}
+	return err
+}
+
+func (cfg *ApiConfig) recordAudit(r *http.Request, q storage.Store, actorPublicID, action, entityType, entityPublicID string, before, after any) error {
+	// appends an audit event for a change made while handling r. Pass the q of the transaction making the change (cfg.DB.InTx)
+	_, err := audit.Record(r.Context(), q, audit.Event{
+		ActorPublicID:  actorPublicID,
+		Action:         action,
+		EntityType:     entityType,
+		EntityPublicID: entityPublicID,
+		Before:         before,
+		After:          after,
+		RequestID:      RequestIDFromContext(r.Context()),
+		IPAddress:      strutils.GetIPFromRequest(r),
+	})
+	return err
+}
+
 func (cfg *ApiConfig) CreateUser(w http.ResponseWriter, r *http.Request) {
 	cfg.HandlerPostUsers(w, r)
 
@@ -49,146 +139,149 @@ func (cfg *ApiConfig) AuthUserMiddleware(next http.Handler) http.Handler {
 		Middleware for user authentication. Note: user is meant in the sense of a /api/users return value here: an employee with an account to summon visitors.
 		Returns a handler.	As this returns a handler, no errors are returned. Instead, they are printed to stdout and
 		sent to the writer as a response with corresponding HTTP status code.
+		Authentication is taken from an "Authorization: Bearer" header or from the user_access_token cookie. If that cookie
+		is missing or no longer valid and a user_refresh_token cookie is sent along, the session is rotated and new cookies
+		are set. Cookie authenticated requests with unsafe methods are checked for CSRF; bearer requests are exempt, as
+		browsers never add that header by themselves. Requests without any credentials are passed on with explicitly empty
+		authentication, so auth.UserFromContext responds 401 where needed.
 	*/
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 1. check for access token cookie
+		// 0. start from explicitly empty authentication. This is to prevent an attacker sending a request with the context keys manually set
 		const usertype string = "user"
-		accessTokenCookie, accessErr := r.Cookie("user_access_token")
-		if accessErr != nil { // implicitly this means the error returned is http.ErrNoCookie, as request.Cookie can only return that specific error
-			// 1.1 if no access token cookie, check for refresh token cookie
-			refreshTokenCookie, refreshErr := r.Cookie("user_refresh_token")
-			// 1.2 if refresh token cookie present: reissue access token cookie
-			if refreshErr == nil { // if accessErr != nil && refreshErr == nil (both errors are guaranteed to be http.ErrNoCookie so this just means
-				// there is no cookie with the "access_token" name but there is a "refresh_token" cookie)
-
-				// 1.3 rotate refresh token
-				rotatedRefreshToken, err := auth.RotateRefreshToken(cfg.DB, w, r, refreshTokenCookie) // note that auth.RotateRefreshToken() does a lot of heavy lifting here and checks token validity
-				if err != nil {
-					// this error is thrown if there is an issue with the refresh token provided in the corresponding cookie. auth.RotateRefreshToken() already calls jsonutils.WriteError()
-					auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-					return
-				}
-
-				// 1.4 make a new JWT (access token) based on refresh token
-				newAccessToken, err := auth.MakeJWT(rotatedRefreshToken.UserPublicID, "user", cfg.Secret, cfg.AccessTokenDuration)
-				if err != nil {
-					// if this fails, there is a problem with issueing access tokens in general, which is very fundamental
-					auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-					jsonutils.WriteError(w, http.StatusInternalServerError, err, "error making new JWT (MakeJWT in AuthUserMiddleware)")
-					return
-				}
+		ctx := context.WithValue(r.Context(), auth.UserIDContextKey, "")
+		ctx = context.WithValue(ctx, auth.SessionPublicIDContextKey, "")
+		r = r.WithContext(ctx)
 
-				// 1.5 Set new cookies
-				auth.SetAuthCookies(w, newAccessToken, rotatedRefreshToken.Token, "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-
-				// 1.6 pass on to next handler
-				upid := rotatedRefreshToken.UserPublicID
-				ctx := r.Context()
-				ctx = context.WithValue(ctx, auth.UserPublicIDContextKey, upid)
-				next.ServeHTTP(w, r.WithContext(ctx))
+		// 1. check for bearer token. If present, cookies are ignored altogether
+		if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			bearerToken, _ := auth.GetBearerToken(r.Header)
+			claims, err := auth.ValidateJWTClaims(bearerToken, cfg.GetSecret())
+			if err != nil {
+				jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "bearer token invalid or expired, use POST /api/refresh")
+				return
+			} else if claims.UserType != usertype {
+				jsonutils.WriteError(w, r, http.StatusBadRequest, auth.ErrWrongUserType, "bearer token usertype is not user")
 				return
-			} else { // so if accessErr != nil && refreshErr != nil, meaning no cookie was found for either access or refresh token
-				// 1.7 pass on to next handler with explicitly empty authentication
-				ctx := r.Context()
-				ctx = context.WithValue(ctx, auth.UserPublicIDContextKey, "") // this is to prevent an attacker sending a request without the cookie but with the UserPublicIDContextKey manually set
-				next.ServeHTTP(w, r.WithContext(ctx))
 			}
+			cfg.serveAuthenticatedUser(w, r, next, claims.Subject, claims.SessionID)
+			return
 		}
 
-		// 2. if accessErr == nil ... so we can deal with a functioning access token
-		// 2.1 first check if we have a refresh token
+		// 2. cookie authentication: unsafe methods must pass the CSRF checks first
+		accessTokenCookie, accessErr := r.Cookie("user_access_token")
+		hasAccessToken := accessErr == nil && accessTokenCookie.Value != "" // logging out sets empty cookies rather than deleting them
 		refreshTokenCookie, refreshErr := r.Cookie("user_refresh_token")
-		if refreshErr != nil { // no refresh token cookie found
-			auth.SetAuthCookies(w, "", "", usertype, cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-			jsonutils.WriteError(w, http.StatusUnauthorized, refreshErr, "access token but no refresh token found. Nulling auth cookies.")
-			return
+		hasRefreshToken := refreshErr == nil && refreshTokenCookie.Value != ""
+		if (hasAccessToken || hasRefreshToken) && !cfg.checkCSRF(w, r) {
+			return // cfg.checkCSRF already writes an error response
 		}
 
-		// 2.2 then validate access token
-		userPublicID, ut, err := auth.ValidateJWT(accessTokenCookie.Value, cfg.GetSecret())
-		// 2.2.1 sanity check
-		if ut != usertype {
-			// unexpected state: access token usertype does not match access token cookie name > clear cookies and send to login
-			jsonutils.WriteError(w, http.StatusBadRequest, errors.New("access token usertype does not match expectation from cookie name"), "access token usertype does not match cookie name")
-			return
-		}
-		// 2.2.2 if the access token is invalid auth.ValidateJWT will return an error
-		if err != nil {
-			// 2.2.3 get the refresh token
-			rotatedRefreshToken, err := auth.RotateRefreshToken(cfg.DB, w, r, refreshTokenCookie)
-			if err != nil { // in other words: if we have a refresh token cookie but the corresponding token is not working
-				// if refresh token is broken, best to redirect to login
-				auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+		// 3. check for access token cookie
+		if hasAccessToken {
+			claims, err := auth.ValidateJWTClaims(accessTokenCookie.Value, cfg.GetSecret())
+			if err == nil {
+				// 3.1 sanity check
+				if claims.UserType != usertype {
+					// unexpected state: access token usertype does not match access token cookie name
+					jsonutils.WriteError(w, r, http.StatusBadRequest, auth.ErrWrongUserType, "access token usertype does not match cookie name")
+					return
+				}
+				// 3.2 valid access token: pass on to next handler
+				cfg.serveAuthenticatedUser(w, r, next, claims.Subject, claims.SessionID)
 				return
 			}
+			// 3.3 an invalid or expired access token is treated as absent, so the refresh token below gets a chance
+		}
 
-			// 2.2.4 no error at 2.2.3 means we have a valid refresh token > make a new access token based on refresh token
-			newAccessToken, err := auth.MakeJWT(rotatedRefreshToken.UserPublicID, "user", cfg.Secret, cfg.AccessTokenDuration)
+		// 4. check for refresh token cookie. Note that its path restricts the browser to sending it to /api/refresh only
+		if hasRefreshToken {
+			session, _, err := cfg.refreshSession(w, r, refreshTokenCookie)
 			if err != nil {
-				// this should probably not redirect anywhere and just cancel the whole ordeal - this is pretty fundamental
-				jsonutils.WriteError(w, http.StatusInternalServerError, err, "error making new JWT (auth.MakeJWT at 2.2.4 in api.AuthUserMiddleware)")
-				return
+				return // cfg.refreshSession already clears the cookies and writes an error response
 			}
-
-			// 2.2.5 Set new cookies
-			auth.SetAuthCookies(w, newAccessToken, rotatedRefreshToken.Token, "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-
-			// 2.2.6 retry same request (redirect to original path. note that this time we will have proper cookies so 2.2 should trigger)
-			upid := rotatedRefreshToken.UserPublicID
-			ctx := r.Context()
-			ctx = context.WithValue(ctx, auth.UserIDContextKey, upid)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			cfg.serveAuthenticatedUser(w, r, next, session.UserPublicID, session.PublicID)
 			return
+		}
 
+		// 5. access token that can no longer be used and no refresh token: the client should call POST /api/refresh
+		if hasAccessToken {
+			jsonutils.WriteError(w, r, http.StatusUnauthorized, errors.New("auth: access token invalid or expired"), "access token invalid or expired, use POST /api/refresh")
+			return
 		}
 
-		// 2.3 rotate refresh token
-		rotatedRefreshToken, err := auth.RotateRefreshToken(cfg.DB, w, r, refreshTokenCookie) // note that auth.RotateRefreshToken calls WriteError on its own
-		if err != nil {
-			if err == auth.ErrRefreshTokenInvalid {
-				// unexpected state: valid access token but invalid refresh token > reset both cookies and redirect to /api/login
-				auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-				return
-			} else { // bit redundant but for clarity
-				// should we panic here? probably not
-				return // in this case we had an error querying the database. Don't want to redirect because it indicates a much bigger problem.
-			}
+		// 6. no credentials at all: pass on with empty authentication
+		next.ServeHTTP(w, r)
+	})
+}
 
-		}
+func (cfg *ApiConfig) serveAuthenticatedUser(w http.ResponseWriter, r *http.Request, next http.Handler, userPublicID, sessionPublicID string) {
+	/*
+		Final step of AuthUserMiddleware: checks that the session has not been ended and that the user is still active,
+		then passes the user and session public IDs on to the next handler through the request context.
+	*/
 
-		// 2.4 set rotated refresh token to cookie
-		http.SetCookie(w, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    rotatedRefreshToken.Token,
-			Path:     "/api/refresh",
-			Expires:  time.Now().Add(time.Duration(cfg.RefreshTokenDuration) * 24 * time.Hour), // note that cfg.RefreshTokenDuration denotes duration in days
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteStrictMode,
-		})
-
-		// 2.5 check if user is active
-		var upid string
-		user, err := cfg.DB.GetUserByPublicID(r.Context(), userPublicID)
-		if errors.Is(err, sql.ErrNoRows) { // unexpected state: a non-existing user is specified in the access token jwt
+	// 1. check if session is still active. Access tokens issued before session management carry no session ID
+	if sessionPublicID != "" {
+		session, err := cfg.DB.GetRefreshTokensByPublicID(r.Context(), sessionPublicID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && (session.RevokedAt.Valid || session.UserPublicID != userPublicID)) {
 			auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-			jsonutils.WriteError(w, http.StatusUnauthorized, err, "non-existing user specified in access token JWT. Note that this error should never occur.")
+			jsonutils.WriteError(w, r, http.StatusUnauthorized, auth.ErrSessionEnded, "session has been ended, please log in again")
 			return
 		} else if err != nil {
-			auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetUserByID in AuthUserMiddleware)")
-			return
-		}
-		if !user.IsActive { // NYI
-			auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
-			jsonutils.WriteJSON(w, http.StatusForbidden, "user account described in authentication cookies is not active")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetRefreshTokensByPublicID in AuthUserMiddleware)")
 			return
 		}
+	}
 
-		// 3. modify context to take ID and pass into next handler
-		upid = userPublicID
-		ctx := r.Context()
-		ctx = context.WithValue(ctx, auth.UserIDContextKey, upid)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	// 2. check if user is active
+	user, err := cfg.DB.GetUserByPublicID(r.Context(), userPublicID)
+	if errors.Is(err, sql.ErrNoRows) { // unexpected state: a non-existing user is specified in the access token jwt
+		auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "non-existing user specified in access token JWT. Note that this error should never occur.")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetUserByPublicID in AuthUserMiddleware)")
+		return
+	}
+	if !user.IsActive {
+		auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserInactive, "user account described in authentication cookies is not active")
+		return
+	}
+
+	// 3. modify context to take IDs and pass into next handler
+	ctx := context.WithValue(r.Context(), auth.UserIDContextKey, userPublicID)
+	ctx = context.WithValue(ctx, auth.SessionPublicIDContextKey, sessionPublicID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (cfg *ApiConfig) refreshSession(w http.ResponseWriter, r *http.Request, refreshTokenCookie *http.Cookie) (database.RefreshToken, string, error) {
+	/*
+		Rotates the refresh token from refreshTokenCookie, makes a new access token for the same session and sets both
+		cookies. Returns the rotated session and the new access token. On failure the auth cookies are cleared and an
+		error response is written, so callers only need to return.
+	*/
+
+	// 1. rotate refresh token
+	session, err := auth.RotateRefreshToken(cfg.DB, r, refreshTokenCookie, cfg.RefreshTokenDuration)
+	if errors.Is(err, auth.ErrRefreshTokenInvalid) {
+		auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "refresh token invalid, expired or revoked: please log in again")
+		return session, "", err
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error rotating refresh token (RotateRefreshToken in refreshSession)")
+		return session, "", err
+	}
+
+	// 2. make a new JWT (access token) for the session
+	accessToken, err := auth.MakeSessionJWT(session.UserPublicID, session.PublicID, "user", cfg.Secret, cfg.AccessTokenDuration)
+	if err != nil {
+		// if this fails, there is a problem with issueing access tokens in general, which is very fundamental
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error making new JWT (MakeSessionJWT in refreshSession)")
+		return session, "", err
+	}
+
+	// 3. set new cookies
+	auth.SetAuthCookies(w, accessToken, session.Token, "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+	return session, accessToken, nil
 }
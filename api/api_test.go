@@ -1,7 +1,1461 @@
 package api
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
 
-func TestCreateUser(t *testing.T) {
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/mailer"
+	"github.com/dcrauwels/goqueue/migrate"
+	"github.com/dcrauwels/goqueue/notify"
+	"github.com/dcrauwels/goqueue/retention"
+	"github.com/dcrauwels/goqueue/schedule"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/dcrauwels/goqueue/webhook"
+	"github.com/jaevor/go-nanoid"
+)
 
+const testPassword = "correct horse battery staple"
+
+func newTestServer(t *testing.T) (*ApiConfig, *httptest.Server) {
+	// serves the /api endpoints on an in-memory store
+	t.Helper()
+	pidGenerator, err := nanoid.Standard(12)
+	if err != nil {
+		t.Fatalf(`nanoid.Standard: %v`, err)
+	}
+	cfg := &ApiConfig{
+		DB:                         storage.NewMemory(),
+		Secret:                     "test secret",
+		Env:                        "dev",
+		AccessTokenDuration:        5,
+		RefreshTokenDuration:       1,
+		PublicIDGenerator:          pidGenerator,
+		PublicIDLength:             12,
+		AccountLoginLimiter:        auth.NewMemoryLoginLimiter(auth.LoginLimiterPolicy{MaxAttempts: 100}),
+		IPLoginLimiter:             auth.NewMemoryLoginLimiter(auth.LoginLimiterPolicy{MaxAttempts: 100}),
+		Mailer:                     mailer.NewWriterSender(io.Discard),
+		PublicBaseURL:              "http://localhost:8080",
+		InvitationTokenDuration:    72,
+		PasswordResetTokenDuration: 60,
+		PasswordPolicy:             strutils.DefaultPasswordPolicy(),
+		PasswordHasher:             auth.PasswordHasher{BcryptCost: 4},
+		IdempotencyKeyDuration:     24,
+	}
+	mux := http.NewServeMux()
+	cfg.RegisterRoutes(mux)
+	srv := httptest.NewServer(RequestIDMiddleware(mux))
+	t.Cleanup(srv.Close)
+	return cfg, srv
+}
+
+func createTestUser(t *testing.T, cfg *ApiConfig, isAdmin bool) database.User {
+	t.Helper()
+	hashedPassword, err := cfg.PasswordHasher.Hash(testPassword)
+	if err != nil {
+		t.Fatalf(`Hash: %v`, err)
+	}
+	user, err := cfg.DB.CreateUser(context.Background(), database.CreateUserParams{
+		PublicID:       cfg.PublicIDGenerator(),
+		Email:          cfg.PublicIDGenerator() + "@example.org",
+		HashedPassword: hashedPassword,
+		FullName:       "Test User",
+	})
+	if err != nil {
+		t.Fatalf(`CreateUser: %v`, err)
+	}
+	if isAdmin {
+		user, err = cfg.DB.SetUserIsAdminByID(context.Background(), database.SetUserIsAdminByIDParams{ID: user.ID, IsAdmin: true})
+		if err != nil {
+			t.Fatalf(`SetUserIsAdminByID: %v`, err)
+		}
+	}
+	return user
+}
+
+func doJSON(t *testing.T, srv *httptest.Server, method, path, accessToken string, body any, wantStatus int, response any) http.Header {
+	// sends body as JSON, authenticated with a bearer token if accessToken is set, and decodes the response into response
+	t.Helper()
+	return doJSONWithHeader(t, srv, method, path, accessToken, nil, body, wantStatus, response)
+}
+
+func doJSONWithHeader(t *testing.T, srv *httptest.Server, method, path, accessToken string, header http.Header, body any, wantStatus int, response any) http.Header {
+	// doJSON with extra request headers
+	t.Helper()
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf(`json.Marshal: %v`, err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, srv.URL+path, reqBody)
+	if err != nil {
+		t.Fatalf(`http.NewRequest: %v`, err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf(`%s %s: %v`, method, path, err)
+	}
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	if res.StatusCode != wantStatus {
+		t.Fatalf(`%s %s returned %d (%s), expected %d`, method, path, res.StatusCode, data, wantStatus)
+	}
+	if response != nil {
+		if err := json.Unmarshal(data, response); err != nil {
+			t.Fatalf(`%s %s returned invalid JSON %s: %v`, method, path, data, err)
+		}
+	}
+	return res.Header
+}
+
+func login(t *testing.T, srv *httptest.Server, user database.User) string {
+	t.Helper()
+	response := userResponseParameters{}
+	doJSON(t, srv, "POST", "/api/login", "", loginRequestParameters{Email: user.Email, Password: testPassword}, http.StatusOK, &response)
+	return response.UserAccessToken
+}
+
+func createTestLocation(t *testing.T, srv *httptest.Server, adminToken string) LocationsResponseParameters {
+	t.Helper()
+	location := LocationsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/locations", adminToken, LocationsRequestParameters{Name: "Head office"}, http.StatusCreated, &location)
+	return location
+}
+
+func TestVisitors(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+
+	// visitors register without authentication and get consecutive ticket numbers
+	for i, name := range []string{"Alice", "Bob"} {
+		visitor := VisitorsResponseParameters{}
+		doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: name, PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+		if visitor.DailyTicketNumber != int32(i+1) {
+			t.Errorf(`POST /api/visitors returned ticket number %d, expected %d`, visitor.DailyTicketNumber, i+1)
+		}
+	}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Carol", PurposePublicID: "unknownpurps"}, http.StatusNotFound, nil)
+
+	// listing visitors requires a user
+	doJSON(t, srv, "GET", "/api/visitors", "", nil, http.StatusUnauthorized, nil)
+	visitors := []VisitorsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/visitors?purpose="+purpose.PublicID+"&status=0", adminToken, nil, http.StatusOK, &visitors)
+	if len(visitors) != 2 || visitors[0].Name.String != "Alice" || visitors[1].Name.String != "Bob" {
+		t.Errorf(`GET /api/visitors returned %+v, expected Alice and Bob in order of arrival`, visitors)
+	}
+	doJSON(t, srv, "GET", "/api/visitors?status=1", adminToken, nil, http.StatusOK, &visitors)
+	if len(visitors) != 0 {
+		t.Errorf(`GET /api/visitors?status=1 returned %d visitors, expected none`, len(visitors))
+	}
+}
+
+func TestVisitorsETag(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	path := "/api/visitors/" + visitor.PublicID
+
+	// a PUT with the ETag of a version that was changed in the meantime changes nothing
+	etag := doJSON(t, srv, "GET", path, "", nil, http.StatusOK, nil).Get("ETag")
+	ifMatch := http.Header{"If-Match": {etag}}
+	newETag := doJSONWithHeader(t, srv, "PUT", path, adminToken, ifMatch, VisitorsPutRequestParameters{Name: "Alicia", PurposePublicID: purpose.PublicID}, http.StatusOK, nil).Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Errorf(`PUT %s returned ETag %q, expected a new one`, path, newETag)
+	}
+	doJSONWithHeader(t, srv, "PUT", path, adminToken, ifMatch, VisitorsPutRequestParameters{Name: "Ali", PurposePublicID: purpose.PublicID}, http.StatusPreconditionFailed, nil)
+	doJSON(t, srv, "GET", path, "", nil, http.StatusOK, &visitor)
+	if visitor.Name.String != "Alicia" {
+		t.Errorf(`GET %s returned name %q, expected Alicia`, path, visitor.Name.String)
+	}
+
+	// If-Match is optional, unless required
+	cfg.RequireIfMatch = true
+	doJSON(t, srv, "PUT", path, adminToken, VisitorsPutRequestParameters{Name: "Ali", PurposePublicID: purpose.PublicID}, http.StatusPreconditionRequired, nil)
+	doJSONWithHeader(t, srv, "PUT", path, adminToken, http.Header{"If-Match": {newETag}}, VisitorsPutRequestParameters{Name: "Ali", PurposePublicID: purpose.PublicID}, http.StatusOK, nil)
+}
+
+func TestPostVisitorsIdempotency(t *testing.T) {
+	// a kiosk retrying a registration gets the same ticket instead of a second one
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+
+	store := cfg.DB
+	cfg.DB = failingCreateVisitorStore{store}
+	request := VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}
+	key := http.Header{"Idempotency-Key": {"kiosk-1-0001"}}
+	doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusInternalServerError, nil)
+	cfg.DB = store
+
+	// the failed attempt is not replayed, the retries after the first success are
+	first, retry := VisitorsResponseParameters{}, VisitorsResponseParameters{}
+	header := doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusCreated, &first)
+	if header.Get("Idempotent-Replayed") != "" {
+		t.Errorf(`POST /api/visitors was replayed, expected it to be handled`)
+	}
+	header = doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusCreated, &retry)
+	if header.Get("Idempotent-Replayed") != "true" || retry.PublicID != first.PublicID || retry.DailyTicketNumber != 1 {
+		t.Errorf(`retried POST /api/visitors returned %+v (replayed: %q), expected %+v`, retry, header.Get("Idempotent-Replayed"), first)
+	}
+
+	// the same key with another body is refused, other keys are independent
+	doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, VisitorsPostRequestParameters{Name: "Bob", PurposePublicID: purpose.PublicID}, http.StatusUnprocessableEntity, nil)
+	doJSONWithHeader(t, srv, "POST", "/api/visitors", "", http.Header{"Idempotency-Key": {"kiosk-1-0002"}}, request, http.StatusCreated, &retry)
+	if retry.DailyTicketNumber != 2 {
+		t.Errorf(`POST /api/visitors with a new key returned ticket number %d, expected 2`, retry.DailyTicketNumber)
+	}
+	doJSONWithHeader(t, srv, "POST", "/api/visitors", "", http.Header{"Idempotency-Key": {strings.Repeat("k", 256)}}, request, http.StatusBadRequest, nil)
+}
+
+func TestPostVisitorsIdempotencyReplayHeaders(t *testing.T) {
+	// a replayed error is the same problem document, in the same language
+	_, srv := newTestServer(t)
+	request := VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: "unknownpurps"}
+	key := http.Header{"Idempotency-Key": {"kiosk-1-0001"}, "Accept-Language": {"nl"}}
+	first := doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusNotFound, nil)
+	retry := doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusNotFound, nil)
+	if retry.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf(`retried POST /api/visitors was handled again, expected a replay`)
+	}
+	for _, name := range []string{"Content-Type", "Content-Language"} {
+		if retry.Get(name) != first.Get(name) || first.Get(name) == "" {
+			t.Errorf(`replayed POST /api/visitors has %s %q, expected %q`, name, retry.Get(name), first.Get(name))
+		}
+	}
+}
+
+func TestIdempotencyKeyLease(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+	request := VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}
+	body, _ := json.Marshal(request)
+	hash := sha256.Sum256(body)
+
+	// a key claimed by a request that crashed is in flight until its lease runs out
+	now := time.Now()
+	claim := database.CreateIdempotencyKeyParams{
+		Scope:          "POST /api/visitors",
+		IdempotencyKey: "kiosk-1-0001",
+		RequestHash:    hex.EncodeToString(hash[:]),
+		CreatedAt:      now.Add(-time.Minute),
+		ExpiresAt:      now.Add(time.Hour),
+		LockedUntil:    sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	}
+	if _, err := cfg.DB.CreateIdempotencyKey(context.Background(), claim); err != nil {
+		t.Fatalf(`CreateIdempotencyKey: %v`, err)
+	}
+	key := http.Header{"Idempotency-Key": {claim.IdempotencyKey}}
+	doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusConflict, nil)
+
+	claim.IdempotencyKey = "kiosk-1-0002"
+	claim.LockedUntil.Time = now.Add(-time.Second)
+	if _, err := cfg.DB.CreateIdempotencyKey(context.Background(), claim); err != nil {
+		t.Fatalf(`CreateIdempotencyKey: %v`, err)
+	}
+	key = http.Header{"Idempotency-Key": {claim.IdempotencyKey}}
+	doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusCreated, nil)
+	header := doJSONWithHeader(t, srv, "POST", "/api/visitors", "", key, request, http.StatusCreated, nil)
+	if header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf(`retried POST /api/visitors was handled again, expected a replay`)
+	}
+
+	// a handler that panics releases its key right away
+	handler := cfg.IdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	req := httptest.NewRequest("POST", "/api/visitors", bytes.NewReader(body))
+	req.Header.Set("Idempotency-Key", "kiosk-1-0003")
+	func() {
+		defer func() { recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	if _, err := cfg.DB.GetIdempotencyKey(context.Background(), database.GetIdempotencyKeyParams{Scope: "POST /api/visitors", IdempotencyKey: "kiosk-1-0003"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetIdempotencyKey after a panic returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func TestPostRetention(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	userToken := login(t, srv, createTestUser(t, cfg, false))
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	cfg.RetentionPolicy = retention.Policy{AnonymizeAfterDays: 1}
+	doJSON(t, srv, "POST", "/api/retention", userToken, nil, http.StatusForbidden, nil)
+	report := retention.Report{}
+	doJSON(t, srv, "POST", "/api/retention?dry_run=true", adminToken, nil, http.StatusOK, &report)
+	if !report.DryRun || report.AnonymizeBefore == nil || report.DeleteBefore != nil {
+		t.Errorf(`POST /api/retention?dry_run=true returned %+v`, report)
+	}
+	doJSON(t, srv, "POST", "/api/retention", adminToken, nil, http.StatusOK, &report)
+	if report.DryRun {
+		t.Errorf(`POST /api/retention returned a dry run`)
+	}
+
+	// only the real run is in the audit log
+	events := []AuditEventsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/audit?action=visitor.retention", adminToken, nil, http.StatusOK, &events)
+	if len(events) != 1 {
+		t.Errorf(`GET /api/audit returned %d retention events, expected 1`, len(events))
+	}
+}
+
+func TestVisitorExportAndErase(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	userToken := login(t, srv, createTestUser(t, cfg, false))
+	admin := createTestUser(t, cfg, true)
+	adminToken := login(t, srv, admin)
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	doJSON(t, srv, "POST", "/api/servicelogs", adminToken, ServicelogsPOSTRequestParameters{
+		VisitorPublicID: visitor.PublicID,
+		UserPublicID:    admin.PublicID,
+		DeskPublicID:    desk.PublicID,
+	}, http.StatusCreated, nil)
+
+	// export
+	path := "/api/visitors/" + visitor.PublicID
+	doJSON(t, srv, "GET", path+"/export", userToken, nil, http.StatusForbidden, nil)
+	doJSON(t, srv, "GET", "/api/visitors/"+cfg.PublicIDGenerator()+"/export", adminToken, nil, http.StatusNotFound, nil)
+	export := VisitorExportResponseParameters{}
+	doJSON(t, srv, "GET", path+"/export", adminToken, nil, http.StatusOK, &export)
+	if export.Visitor.Name.String != "Alice" || len(export.ServiceLogs) != 1 || len(export.AuditEvents) != 1 || export.AuditEvents[0].Action != "visitor.create" {
+		t.Errorf(`GET %v/export returned %+v`, path, export)
+	}
+
+	// erasure
+	doJSON(t, srv, "POST", path+"/erase", userToken, nil, http.StatusForbidden, nil)
+	erased := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", path+"/erase", adminToken, nil, http.StatusOK, &erased)
+	if erased.Name.Valid || erased.DailyTicketNumber != visitor.DailyTicketNumber || erased.PurposePublicID != purpose.PublicID {
+		t.Errorf(`POST %v/erase returned %+v; expected the visitor without name`, path, erased)
+	}
+	doJSON(t, srv, "GET", path+"/export", adminToken, nil, http.StatusOK, &export)
+	if export.Visitor.Name.Valid || len(export.ServiceLogs) != 1 || len(export.AuditEvents) != 2 || export.AuditEvents[1].Action != "visitor.erase" || export.AuditEvents[1].ActorPublicID != admin.PublicID {
+		t.Errorf(`GET %v/export after erasure returned %+v`, path, export)
+	}
+}
+
+// failingCreateVisitorStore fails every CreateVisitor, including those in transactions
+type failingCreateVisitorStore struct {
+	storage.Store
+}
+
+func (s failingCreateVisitorStore) InTx(ctx context.Context, fn func(q storage.Store) error) error {
+	return s.Store.InTx(ctx, func(q storage.Store) error {
+		return fn(failingCreateVisitorStore{q})
+	})
+}
+
+func (s failingCreateVisitorStore) CreateVisitor(context.Context, database.CreateVisitorParams) (database.Visitor, error) {
+	return database.Visitor{}, errors.New("CreateVisitor failed")
+}
+
+func TestPostVisitorsRollback(t *testing.T) {
+	// a visitor that could not be created does not use up a ticket number
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+
+	store := cfg.DB
+	cfg.DB = failingCreateVisitorStore{store}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusInternalServerError, nil)
+	cfg.DB = store
+
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	if visitor.DailyTicketNumber != 1 {
+		t.Errorf(`POST /api/visitors returned ticket number %d after a failed attempt, expected 1`, visitor.DailyTicketNumber)
+	}
+}
+
+func TestLocations(t *testing.T) {
+	// users other than admins only work with the queues of the locations they are assigned to
+	cfg, srv := newTestServer(t)
+	user := createTestUser(t, cfg, false)
+	userToken := login(t, srv, user)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	doJSON(t, srv, "POST", "/api/locations", userToken, LocationsRequestParameters{Name: "Branch"}, http.StatusForbidden, nil)
+	office, branch := createTestLocation(t, srv, adminToken), LocationsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/locations", adminToken, LocationsRequestParameters{Name: "Branch"}, http.StatusCreated, &branch)
+
+	// each location has its own purposes, desks and ticket numbers
+	purposes, desks, visitors := map[string]PurposesResponseParameters{}, map[string]DesksResponseParameters{}, map[string]VisitorsResponseParameters{}
+	for _, location := range []LocationsResponseParameters{office, branch} {
+		purpose, desk, visitor := PurposesResponseParameters{}, DesksResponseParameters{}, VisitorsResponseParameters{}
+		doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+		doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+		doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+		if visitor.LocationPublicID != location.PublicID || visitor.DailyTicketNumber != 1 {
+			t.Errorf(`POST /api/visitors returned %+v, expected ticket number 1 at location %v`, visitor, location.PublicID)
+		}
+		purposes[location.PublicID], desks[location.PublicID], visitors[location.PublicID] = purpose, desk, visitor
+	}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F2", LocationPublicID: "unknownlocat"}, http.StatusBadRequest, nil)
+
+	// assigning the user to the office
+	path := "/api/users/" + user.PublicID + "/locations"
+	doJSON(t, srv, "PUT", path, userToken, UserLocationsRequestParameters{LocationPublicIDs: []string{office.PublicID, branch.PublicID}}, http.StatusForbidden, nil)
+	doJSON(t, srv, "PUT", path, adminToken, UserLocationsRequestParameters{LocationPublicIDs: []string{"unknownlocat"}}, http.StatusBadRequest, nil)
+	doJSON(t, srv, "PUT", path, adminToken, UserLocationsRequestParameters{LocationPublicIDs: []string{office.PublicID}}, http.StatusOK, nil)
+	locations := []LocationsResponseParameters{}
+	doJSON(t, srv, "GET", path, userToken, nil, http.StatusOK, &locations)
+	if len(locations) != 1 || locations[0].PublicID != office.PublicID {
+		t.Errorf(`GET %s returned %+v, expected the office`, path, locations)
+	}
+
+	// lists only show the office to the user, and every location to admins
+	list := []VisitorsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/visitors", userToken, nil, http.StatusOK, &list)
+	if len(list) != 1 || list[0].PublicID != visitors[office.PublicID].PublicID {
+		t.Errorf(`GET /api/visitors returned %+v to the user, expected the visitor at the office`, list)
+	}
+	doJSON(t, srv, "GET", "/api/visitors?location="+branch.PublicID, userToken, nil, http.StatusOK, &list)
+	if len(list) != 0 {
+		t.Errorf(`GET /api/visitors?location= returned %+v to the user, expected no visitors at the branch`, list)
+	}
+	doJSON(t, srv, "GET", "/api/visitors", adminToken, nil, http.StatusOK, &list)
+	if len(list) != 2 {
+		t.Errorf(`GET /api/visitors returned %d visitors to the admin, expected 2`, len(list))
+	}
+	deskList := []DesksResponseParameters{}
+	doJSON(t, srv, "GET", "/api/desks?location="+branch.PublicID, adminToken, nil, http.StatusOK, &deskList)
+	if len(deskList) != 1 || deskList[0].PublicID != desks[branch.PublicID].PublicID {
+		t.Errorf(`GET /api/desks?location= returned %+v, expected the desk at the branch`, deskList)
+	}
+
+	// calling visitors
+	call := func(desk, visitor string) ServicelogsPOSTRequestParameters {
+		return ServicelogsPOSTRequestParameters{VisitorPublicID: visitor, UserPublicID: user.PublicID, DeskPublicID: desk}
+	}
+	doJSON(t, srv, "POST", "/api/servicelogs", userToken, call(desks[office.PublicID].PublicID, visitors[branch.PublicID].PublicID), http.StatusBadRequest, nil)
+	doJSON(t, srv, "POST", "/api/servicelogs", userToken, call(desks[branch.PublicID].PublicID, visitors[branch.PublicID].PublicID), http.StatusForbidden, nil)
+	serviceLog := ServicelogsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/servicelogs", userToken, call(desks[office.PublicID].PublicID, visitors[office.PublicID].PublicID), http.StatusCreated, &serviceLog)
+	if serviceLog.LocationPublicID != office.PublicID {
+		t.Errorf(`POST /api/servicelogs returned location %v, expected the office`, serviceLog.LocationPublicID)
+	}
+	branchLog := ServicelogsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/servicelogs", adminToken, call(desks[branch.PublicID].PublicID, visitors[branch.PublicID].PublicID), http.StatusCreated, &branchLog)
+	doJSON(t, srv, "GET", "/api/servicelogs/"+serviceLog.PublicID, userToken, nil, http.StatusOK, nil)
+	doJSON(t, srv, "GET", "/api/servicelogs/"+serviceLog.PublicID, "", nil, http.StatusUnauthorized, nil)
+	doJSON(t, srv, "GET", "/api/servicelogs/"+branchLog.PublicID, userToken, nil, http.StatusForbidden, nil)
+	doJSON(t, srv, "GET", "/api/servicelogs/"+branchLog.PublicID, adminToken, nil, http.StatusOK, nil)
+
+	// changing visitors
+	branchVisitor := visitors[branch.PublicID]
+	request := VisitorsPutRequestParameters{Name: "Alice", PurposePublicID: purposes[branch.PublicID].PublicID, Status: 1}
+	doJSON(t, srv, "PUT", "/api/visitors/"+branchVisitor.PublicID, userToken, request, http.StatusForbidden, nil)
+	request.PurposePublicID = purposes[office.PublicID].PublicID
+	doJSON(t, srv, "PUT", "/api/visitors/"+branchVisitor.PublicID, adminToken, request, http.StatusBadRequest, nil)
+}
+
+func TestOpeningHours(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	userToken := login(t, srv, createTestUser(t, cfg, false))
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	doJSON(t, srv, "POST", "/api/locations", adminToken, LocationsRequestParameters{Name: "Branch", TimeZone: "Mars/Olympus_Mons"}, http.StatusBadRequest, nil)
+	location := createTestLocation(t, srv, adminToken)
+	if location.TimeZone != "UTC" {
+		t.Errorf(`POST /api/locations returned time zone %q, expected UTC by default`, location.TimeZone)
+	}
+	passports, permits := PurposesResponseParameters{}, PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &passports)
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "permits", LocationPublicID: location.PublicID, DailyCapacity: sql.NullInt32{Int32: 1, Valid: true}}, http.StatusOK, &permits)
+	visitor := func(purpose PurposesResponseParameters, wantStatus int) QueueClosedResponseParameters {
+		t.Helper()
+		response := QueueClosedResponseParameters{}
+		doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, wantStatus, &response)
+		return response
+	}
+
+	// passports are only issued tomorrow, the rest of the location has no opening hours and is always open
+	now := time.Now().UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	path := "/api/locations/" + location.PublicID + "/opening-hours"
+	request := OpeningHoursRequestParameters{PurposePublicID: passports.PublicID, Weekday: int32(tomorrow.Weekday()), OpensAt: "09:00", ClosesAt: "17:00"}
+	doJSON(t, srv, "POST", path, userToken, request, http.StatusForbidden, nil)
+	doJSON(t, srv, "POST", path, adminToken, OpeningHoursRequestParameters{Weekday: 1, OpensAt: "9:00", ClosesAt: "17:00"}, http.StatusBadRequest, nil)
+	doJSON(t, srv, "POST", path, adminToken, OpeningHoursRequestParameters{Weekday: 1, OpensAt: "17:00", ClosesAt: "09:00"}, http.StatusBadRequest, nil)
+	doJSON(t, srv, "POST", path, adminToken, OpeningHoursRequestParameters{PurposePublicID: "unknownpurpo", Weekday: 1, OpensAt: "09:00", ClosesAt: "17:00"}, http.StatusBadRequest, nil)
+	openingHour := OpeningHoursResponseParameters{}
+	doJSON(t, srv, "POST", path, adminToken, request, http.StatusCreated, &openingHour)
+	if openingHour.OpensAt != "09:00" || openingHour.ClosesAt != "17:00" || openingHour.PurposePublicID.String != passports.PublicID {
+		t.Errorf(`POST %s returned %+v`, path, openingHour)
+	}
+	openingHours := []OpeningHoursResponseParameters{}
+	doJSON(t, srv, "GET", path, "", nil, http.StatusOK, &openingHours)
+	if len(openingHours) != 1 || openingHours[0].PublicID != openingHour.PublicID {
+		t.Errorf(`GET %s returned %+v, expected the opening hours just created`, path, openingHours)
+	}
+
+	closed := visitor(passports, http.StatusConflict)
+	if want := tomorrow.Add(9 * time.Hour); closed.NextOpeningAt == nil || !closed.NextOpeningAt.Equal(want) {
+		t.Errorf(`POST /api/visitors returned %+v when closed, expected the next opening at %v`, closed, want)
+	}
+	doJSON(t, srv, "DELETE", path+"/"+openingHour.PublicID, adminToken, nil, http.StatusOK, nil)
+	doJSON(t, srv, "DELETE", path+"/"+openingHour.PublicID, adminToken, nil, http.StatusNotFound, nil)
+	visitor(passports, http.StatusCreated)
+
+	// permits are issued once a day
+	visitor(permits, http.StatusCreated)
+	full := visitor(permits, http.StatusConflict)
+	if full.NextOpeningAt == nil || !full.NextOpeningAt.Equal(tomorrow) {
+		t.Errorf(`POST /api/visitors returned %+v when full, expected the next opening at %v`, full, tomorrow)
+	}
+
+	// the whole location is closed on holidays
+	path = "/api/locations/" + location.PublicID + "/holidays"
+	holiday := HolidaysResponseParameters{}
+	doJSON(t, srv, "POST", path, adminToken, HolidaysRequestParameters{Date: "tomorrow", Name: "Closed"}, http.StatusBadRequest, nil)
+	doJSON(t, srv, "POST", path, adminToken, HolidaysRequestParameters{Date: now.Format(time.DateOnly), Name: "Closed"}, http.StatusCreated, &holiday)
+	doJSON(t, srv, "POST", path, adminToken, HolidaysRequestParameters{Date: now.Format(time.DateOnly), Name: "Closed again"}, http.StatusConflict, nil)
+	holidays := []HolidaysResponseParameters{}
+	doJSON(t, srv, "GET", path, "", nil, http.StatusOK, &holidays)
+	if len(holidays) != 1 || holidays[0].Date != now.Format(time.DateOnly) {
+		t.Errorf(`GET %s returned %+v, expected today`, path, holidays)
+	}
+	closed = visitor(passports, http.StatusConflict)
+	if closed.NextOpeningAt == nil || !closed.NextOpeningAt.Equal(tomorrow) {
+		t.Errorf(`POST /api/visitors returned %+v on a holiday, expected the next opening at %v`, closed, tomorrow)
+	}
+	doJSON(t, srv, "DELETE", path+"/"+holiday.PublicID, adminToken, nil, http.StatusOK, nil)
+	visitor(passports, http.StatusCreated)
+}
+
+func TestWebhooks(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	userToken := login(t, srv, createTestUser(t, cfg, false))
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+
+	// a receiver that checks the signature of what it is sent
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if !webhook.Verify("receiver secret", timestamp, body, r.Header.Get(webhook.HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		bodies = append(bodies, body)
+	}))
+	defer receiver.Close()
+
+	request := WebhooksRequestParameters{URL: receiver.URL, EventType: webhook.EventVisitorCreated, Secret: "receiver secret"}
+	doJSON(t, srv, "POST", "/api/webhooks", userToken, request, http.StatusForbidden, nil)
+	doJSON(t, srv, "POST", "/api/webhooks", adminToken, WebhooksRequestParameters{URL: "ftp://example.org", EventType: webhook.EventVisitorCreated}, http.StatusBadRequest, nil)
+	response := jsonutils.Problem{}
+	doJSON(t, srv, "POST", "/api/webhooks", adminToken, map[string]any{"url": receiver.URL, "event_type": "visitor.left", "active": true}, http.StatusBadRequest, &response)
+	codes := map[string]string{}
+	for _, f := range response.Errors {
+		codes[f.Field] = f.Code
+	}
+	if len(codes) != 2 || codes["event_type"] != "invalid_choice" || codes["active"] != "unknown_field" {
+		t.Errorf(`POST /api/webhooks returned %+v, expected event_type to be invalid and active unknown`, response)
+	}
+	var eventTypes []string
+	for _, f := range response.Errors {
+		if f.Field == "event_type" {
+			eventTypes = f.Allowed
+		}
+	}
+	if !slices.Equal(eventTypes, webhook.EventTypes) {
+		t.Errorf(`POST /api/webhooks allowed event types %v, expected %v`, eventTypes, webhook.EventTypes)
+	}
+	generated := WebhooksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/webhooks", adminToken, WebhooksRequestParameters{URL: receiver.URL, EventType: webhook.EventVisitorServed}, http.StatusCreated, &generated)
+	if len(generated.Secret) != 64 {
+		t.Errorf(`POST /api/webhooks without secret returned secret %q, expected a generated one`, generated.Secret)
+	}
+	subscription := WebhooksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/webhooks", adminToken, request, http.StatusCreated, &subscription)
+
+	// the secret is only shown once, and never audited
+	subscriptions := []WebhooksResponseParameters{}
+	doJSON(t, srv, "GET", "/api/webhooks", adminToken, nil, http.StatusOK, &subscriptions)
+	if len(subscriptions) != 2 || subscriptions[1].PublicID != subscription.PublicID || subscriptions[1].Secret != "" {
+		t.Errorf(`GET /api/webhooks returned %+v, expected both webhooks without secret`, subscriptions)
+	}
+	events := []AuditEventsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/audit?entity_public_id="+subscription.PublicID, adminToken, nil, http.StatusOK, &events)
+	if len(events) != 1 || events[0].Action != "webhook.create" || strings.Contains(string(events[0].Diff), "receiver secret") {
+		t.Errorf(`GET /api/audit returned %+v, expected webhook.create without the secret`, events)
+	}
+
+	// a new visitor is delivered to the subscription to visitor.created only, without its name
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, nil)
+	if n, err := webhook.NewDispatcher(cfg.DB, webhook.DefaultPolicy()).RunOnce(context.Background(), time.Now()); err != nil || n != 1 {
+		t.Fatalf(`RunOnce returned %d, %v; expected one delivery`, n, err)
+	}
+	if len(bodies) != 1 || !strings.Contains(string(bodies[0]), `"type":"visitor.created"`) || strings.Contains(string(bodies[0]), "Alice") {
+		t.Errorf(`receiver got %q, expected a visitor.created event without the name of the visitor`, bodies)
+	}
+	path := "/api/webhooks/" + subscription.PublicID + "/deliveries"
+	deliveries := []WebhookDeliveriesResponseParameters{}
+	doJSON(t, srv, "GET", path+"?limit=0", adminToken, nil, http.StatusBadRequest, nil)
+	doJSON(t, srv, "GET", path, adminToken, nil, http.StatusOK, &deliveries)
+	if len(deliveries) != 1 || deliveries[0].Status != webhook.StatusDelivered || deliveries[0].ResponseStatus.Int32 != http.StatusOK {
+		t.Fatalf(`GET %s returned %+v, expected one delivered delivery`, path, deliveries)
+	}
+
+	// redelivering sends the same event again
+	redelivery := WebhookDeliveriesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/webhooks/deliveries/"+deliveries[0].PublicID+"/redeliver", adminToken, nil, http.StatusAccepted, &redelivery)
+	if redelivery.Status != webhook.StatusPending || redelivery.Attempts != 0 {
+		t.Errorf(`POST redeliver returned %+v, expected a pending delivery`, redelivery)
+	}
+	if _, err := webhook.NewDispatcher(cfg.DB, webhook.DefaultPolicy()).RunOnce(context.Background(), time.Now()); err != nil {
+		t.Fatalf(`RunOnce: %v`, err)
+	}
+	if len(bodies) != 2 || !bytes.Equal(bodies[0], bodies[1]) {
+		t.Errorf(`receiver got %q, expected the same event twice`, bodies)
+	}
+	doJSON(t, srv, "POST", "/api/webhooks/deliveries/unknowndeliv/redeliver", adminToken, nil, http.StatusNotFound, nil)
+
+	doJSON(t, srv, "DELETE", "/api/webhooks/"+subscription.PublicID, adminToken, nil, http.StatusOK, nil)
+	doJSON(t, srv, "GET", "/api/webhooks/"+subscription.PublicID, adminToken, nil, http.StatusNotFound, nil)
+	doJSON(t, srv, "GET", path, adminToken, nil, http.StatusNotFound, nil)
+}
+
+// notificationRecorder is a notify.Provider that passes what it is sent on to a channel
+type notificationRecorder chan notify.Message
+
+func (c notificationRecorder) Send(ctx context.Context, msg notify.Message) error {
+	c <- msg
+	return nil
+}
+
+func TestNotifications(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	admin := createTestUser(t, cfg, true)
+	adminToken := login(t, srv, admin)
+	sent := make(notificationRecorder, 10)
+	cfg.Notifier = &notify.Notifier{Email: sent, SMS: sent, Threshold: 0}
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+
+	// contact details are optional, but must be valid when given
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID, PhoneNumber: "0201234567"}, http.StatusBadRequest, nil)
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID, Email: "alice"}, http.StatusBadRequest, nil)
+	alice, bob := VisitorsResponseParameters{}, VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID, PhoneNumber: "+31201234567"}, http.StatusCreated, &alice)
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Bob", PurposePublicID: purpose.PublicID, Email: "Bob <bob@example.org>"}, http.StatusCreated, &bob)
+	if alice.PhoneNumber.String != "+31201234567" || alice.Email.Valid || bob.Email.String != "bob@example.org" {
+		t.Errorf(`POST /api/visitors returned %+v and %+v, expected their contact details`, alice, bob)
+	}
+
+	// calling Alice tells her which desk to go to and Bob that he is next
+	doJSON(t, srv, "POST", "/api/servicelogs", adminToken, ServicelogsPOSTRequestParameters{
+		VisitorPublicID: alice.PublicID,
+		UserPublicID:    admin.PublicID,
+		DeskPublicID:    desk.PublicID,
+	}, http.StatusCreated, nil)
+	received := map[string]notify.Message{}
+	for range 2 {
+		select {
+		case msg := <-sent:
+			received[msg.To] = msg
+		case <-time.After(5 * time.Second):
+			t.Fatalf(`received %+v, expected two notifications`, received)
+		}
+	}
+	if msg := received["+31201234567"]; msg.Channel != notify.ChannelSMS || !strings.Contains(msg.Body, "desk F1") {
+		t.Errorf(`Alice was sent %+v, expected a text message about desk F1`, msg)
+	}
+	if msg := received["bob@example.org"]; msg.Channel != notify.ChannelEmail || !strings.Contains(msg.Body, "you are next") {
+		t.Errorf(`Bob was sent %+v, expected an email that he is next`, msg)
+	}
+	if v, err := cfg.DB.GetVisitorsByPublicID(context.Background(), bob.PublicID); err != nil || !v.NotifiedAt.Valid {
+		t.Errorf(`GetVisitorsByPublicID returned %+v, %v; expected Bob to be marked as notified`, v, err)
+	}
+
+	// contact details stay out of the audit log
+	events := []AuditEventsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/audit?entity_public_id="+bob.PublicID, adminToken, nil, http.StatusOK, &events)
+	if len(events) != 1 || strings.Contains(string(events[0].Diff), "bob@example.org") {
+		t.Errorf(`GET /api/audit returned %+v, expected visitor.create without the email address`, events)
+	}
+
+	// and out of what anyone with the public ID of a visitor gets, unless it is a user of the location of the visitor
+	path := "/api/visitors/" + alice.PublicID
+	for _, token := range []string{"", login(t, srv, createTestUser(t, cfg, false))} {
+		public := map[string]any{}
+		doJSON(t, srv, "GET", path, token, nil, http.StatusOK, &public)
+		for _, field := range []string{"phone_number", "email", "notified_at"} {
+			if _, ok := public[field]; ok || public["public_id"] != alice.PublicID {
+				t.Errorf(`GET %s returned %v, expected the visitor without %s`, path, public, field)
+			}
+		}
+	}
+	alice = VisitorsResponseParameters{}
+	doJSON(t, srv, "GET", path, adminToken, nil, http.StatusOK, &alice)
+	if alice.PhoneNumber.String != "+31201234567" {
+		t.Errorf(`GET %s returned %+v, expected the phone number of Alice`, path, alice)
+	}
+}
+
+func TestTickets(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	admin := createTestUser(t, cfg, true)
+	adminToken := login(t, srv, admin)
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+
+	// the layout: text, and a logo that must be an image
+	layoutPath := "/api/purposes/" + purpose.PublicID + "/ticket-layout"
+	doJSON(t, srv, "GET", layoutPath, "", nil, http.StatusNotFound, nil)
+	doJSON(t, srv, "PUT", layoutPath, adminToken, TicketLayoutRequestParameters{Header: "City Hall", Logo: []byte("not an image")}, http.StatusBadRequest, nil)
+	var logo bytes.Buffer
+	png.Encode(&logo, image.NewGray(image.Rect(0, 0, 20, 10)))
+	layout := TicketLayoutResponseParameters{}
+	doJSON(t, srv, "PUT", layoutPath, adminToken, TicketLayoutRequestParameters{Header: "City Hall", Footer: "Thank you", Logo: logo.Bytes()}, http.StatusOK, &layout)
+	doJSON(t, srv, "GET", layoutPath, "", nil, http.StatusOK, &layout)
+	if layout.Header != "City Hall" || !bytes.Equal(layout.Logo, logo.Bytes()) {
+		t.Errorf(`GET ticket-layout returned %+v, expected the layout just set`, layout)
+	}
+	events := []AuditEventsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/audit?action=purpose.set_ticket_layout", adminToken, nil, http.StatusOK, &events)
+	if len(events) != 1 || !strings.Contains(string(events[0].Diff), "has_logo") {
+		t.Errorf(`GET /api/audit returned %+v, expected purpose.set_ticket_layout without the logo`, events)
+	}
+
+	// the ticket in both formats
+	getTicket := func(query string, wantStatus int) (string, []byte) {
+		resp, err := srv.Client().Get(srv.URL + "/api/visitors/" + visitor.PublicID + "/ticket" + query)
+		if err != nil {
+			t.Fatalf(`GET ticket: %v`, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != wantStatus {
+			t.Fatalf(`GET ticket%s returned status %d, expected %d`, query, resp.StatusCode, wantStatus)
+		}
+		return resp.Header.Get("Content-Type"), body
+	}
+	if contentType, body := getTicket("", http.StatusOK); contentType != "application/pdf" || !bytes.HasPrefix(body, []byte("%PDF-")) {
+		t.Errorf(`GET ticket returned %s, expected a PDF`, contentType)
+	}
+	contentType, body := getTicket("?format=escpos&paper_width=58", http.StatusOK)
+	if contentType != "application/octet-stream" || !bytes.Contains(body, []byte("City Hall\n")) || !bytes.Contains(body, []byte("passports\n")) {
+		t.Errorf(`GET ticket?format=escpos returned %s %q, expected ESC/POS with the layout and the purpose`, contentType, body)
+	}
+	if bytes.Contains(body, []byte("Estimated wait")) {
+		t.Errorf(`GET ticket returned an estimated wait, expected none before the purpose has had two calls`)
+	}
+	getTicket("?format=png", http.StatusBadRequest)
+	getTicket("?paper_width=70", http.StatusBadRequest)
+
+	// once two visitors have been called, the next one is told how long they will wait
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+	called, next := visitor, VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Bob", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Carol", PurposePublicID: purpose.PublicID}, http.StatusCreated, &next)
+	for _, v := range []string{called.PublicID, visitor.PublicID} {
+		doJSON(t, srv, "POST", "/api/servicelogs", adminToken, ServicelogsPOSTRequestParameters{VisitorPublicID: v, UserPublicID: admin.PublicID, DeskPublicID: desk.PublicID}, http.StatusCreated, nil)
+	}
+	visitor = next
+	if _, body := getTicket("?format=escpos", http.StatusOK); !bytes.Contains(body, []byte("Estimated wait: about 1 minute\n")) {
+		t.Errorf(`GET ticket returned %q, expected an estimated wait`, body)
+	}
+}
+
+func TestLocalDayNearMidnight(t *testing.T) {
+	// the day of a location starts at its local midnight, which is not the one of UTC
+	cfg, srv := newTestServer(t)
+	admin := createTestUser(t, cfg, true)
+	adminToken := login(t, srv, admin)
+	now := time.Now().UTC()
+	offset := (24 - now.Hour()) % 24 // hours ahead of UTC for which it is just past midnight
+	if offset == 0 {
+		offset = 1
+	} else if offset > 14 {
+		offset -= 24
+	}
+	zone := fmt.Sprintf("Etc/GMT%+d", -offset) // the signs of the Etc zones are the other way round
+	tz, err := time.LoadLocation(zone)
+	if err != nil {
+		t.Fatalf(`time.LoadLocation: %v`, err)
+	}
+	dayStart := schedule.DayStart(now, tz)
+	location := LocationsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/locations", adminToken, LocationsRequestParameters{Name: "Head office", TimeZone: zone}, http.StatusCreated, &location)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID, DailyCapacity: sql.NullInt32{Int32: 1, Valid: true}}, http.StatusOK, &purpose)
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+
+	// a visitor of before midnight does not count towards the capacity of today
+	yesterday, err := cfg.DB.CreateVisitor(context.Background(), database.CreateVisitorParams{
+		PublicID:          "yesterday000",
+		PurposePublicID:   purpose.PublicID,
+		DailyTicketNumber: 1,
+		LocationPublicID:  location.PublicID,
+		CreatedAt:         dayStart.Add(-30 * time.Minute).UTC(),
+	})
+	if err != nil {
+		t.Fatalf(`CreateVisitor: %v`, err)
+	}
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Bob", PurposePublicID: purpose.PublicID}, http.StatusConflict, nil)
+
+	// only the calls since midnight tell how long Alice will wait
+	sinceMidnight := now.Sub(dayStart)
+	calls := []time.Time{dayStart.Add(-20 * time.Minute), dayStart.Add(sinceMidnight / 3), dayStart.Add(2 * sinceMidnight / 3)}
+	for i, calledAt := range calls {
+		_, err := cfg.DB.CreateServiceLogs(context.Background(), database.CreateServiceLogsParams{
+			PublicID:         fmt.Sprintf("call%08d", i),
+			VisitorPublicID:  yesterday.PublicID,
+			UserPublicID:     admin.PublicID,
+			DeskPublicID:     desk.PublicID,
+			LocationPublicID: location.PublicID,
+			CreatedAt:        calledAt.UTC(),
+		})
+		if err != nil {
+			t.Fatalf(`CreateServiceLogs: %v`, err)
+		}
+	}
+	waiting, err := cfg.DB.GetVisitorsByPublicID(context.Background(), visitor.PublicID)
+	if err != nil {
+		t.Fatalf(`GetVisitorsByPublicID: %v`, err)
+	}
+	want := calls[2].Truncate(time.Microsecond).Sub(calls[1].Truncate(time.Microsecond))
+	if wait, err := estimatedWait(context.Background(), cfg.DB, waiting, tz); err != nil || wait != want {
+		t.Errorf(`estimatedWait in %s returned %v, %v; expected %v`, zone, wait, err, want)
+	}
+}
+
+func TestVisitorQRCode(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+
+	// the response to POST /api/visitors links to the status page
+	visitor := VisitorsPostResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	if visitor.StatusURL != "http://localhost:8080/visitors/"+visitor.PublicID || !strings.HasPrefix(visitor.QRCodeSVG, "<svg ") {
+		t.Errorf(`POST /api/visitors returned status_url %q and qr_code_svg %.20q, expected the status page and its QR code`, visitor.StatusURL, visitor.QRCodeSVG)
+	}
+	cfg.StatusPageURL = "https://status.example.org/s/"
+	if got := cfg.visitorStatusURL(visitor.PublicID); got != "https://status.example.org/s/"+visitor.PublicID {
+		t.Errorf(`visitorStatusURL returned %q, expected it to start with StatusPageURL`, got)
+	}
+
+	getQRCode := func(path string, wantStatus int) (string, []byte) {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf(`GET %s: %v`, path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != wantStatus {
+			t.Fatalf(`GET %s returned status %d, expected %d`, path, resp.StatusCode, wantStatus)
+		}
+		return resp.Header.Get("Content-Type"), body
+	}
+	contentType, body := getQRCode("/api/visitors/"+visitor.PublicID+"/qrcode?scale=2", http.StatusOK)
+	img, err := png.Decode(bytes.NewReader(body))
+	if contentType != "image/png" || err != nil {
+		t.Fatalf(`GET qrcode returned %s (%v), expected a PNG image`, contentType, err)
+	}
+	code, _ := cfg.visitorQRCode(visitor.PublicID)
+	if size := img.Bounds().Dx(); size != (code.Size+8)*2 {
+		t.Errorf(`GET qrcode?scale=2 returned an image %d pixels wide, expected %d`, size, (code.Size+8)*2)
+	}
+	if contentType, body := getQRCode("/api/visitors/"+visitor.PublicID+"/qrcode?format=svg", http.StatusOK); contentType != "image/svg+xml" || !bytes.HasPrefix(body, []byte("<svg ")) {
+		t.Errorf(`GET qrcode?format=svg returned %s %.20q, expected an SVG image`, contentType, body)
+	}
+	getQRCode("/api/visitors/"+visitor.PublicID+"/qrcode?format=gif", http.StatusBadRequest)
+	getQRCode("/api/visitors/"+visitor.PublicID+"/qrcode?scale=0", http.StatusBadRequest)
+	getQRCode("/api/visitors/"+strings.Repeat("x", cfg.PublicIDLength)+"/qrcode", http.StatusNotFound)
+}
+
+func TestAnnouncements(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	admin := createTestUser(t, cfg, true)
+	adminToken := login(t, srv, admin)
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "passports", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "12", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+
+	// templates: the built-in Dutch one for the location, and English for the purpose
+	templatesPath := "/api/locations/" + location.PublicID + "/announcement-templates"
+	doJSON(t, srv, "POST", templatesPath, adminToken, AnnouncementTemplatesRequestParameters{Language: "nl"}, http.StatusCreated, nil)
+	doJSON(t, srv, "POST", templatesPath, adminToken, AnnouncementTemplatesRequestParameters{Language: "nl"}, http.StatusConflict, nil)
+	doJSON(t, srv, "POST", templatesPath, adminToken, AnnouncementTemplatesRequestParameters{Language: "xx"}, http.StatusBadRequest, nil)
+	doJSON(t, srv, "POST", templatesPath, adminToken, AnnouncementTemplatesRequestParameters{Language: "en", Template: "Desk {desk}"}, http.StatusBadRequest, nil)
+	template := AnnouncementTemplatesResponseParameters{}
+	doJSON(t, srv, "POST", templatesPath, adminToken, AnnouncementTemplatesRequestParameters{PurposePublicID: purpose.PublicID, Language: "en", Template: "{purpose}: number {ticket} to desk {desk}."}, http.StatusCreated, &template)
+	templates := []AnnouncementTemplatesResponseParameters{}
+	doJSON(t, srv, "GET", templatesPath, "", nil, http.StatusOK, &templates)
+	if len(templates) != 2 || templates[1].PublicID != template.PublicID {
+		t.Errorf(`GET announcement-templates returned %+v, expected the two templates created`, templates)
+	}
+
+	// a called visitor is shown and announced in both languages
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	display := DisplayResponseParameters{}
+	doJSON(t, srv, "GET", "/api/locations/"+location.PublicID+"/display", "", nil, http.StatusOK, &display)
+	if len(display.Calls) != 0 {
+		t.Errorf(`GET display returned %+v, expected no calls before a visitor is called`, display.Calls)
+	}
+	doJSON(t, srv, "POST", "/api/servicelogs", adminToken, ServicelogsPOSTRequestParameters{VisitorPublicID: visitor.PublicID, UserPublicID: admin.PublicID, DeskPublicID: desk.PublicID}, http.StatusCreated, nil)
+	doJSON(t, srv, "GET", "/api/locations/"+location.PublicID+"/display", "", nil, http.StatusOK, &display)
+	if len(display.Calls) != 1 || len(display.Calls[0].Announcements) != 2 {
+		t.Fatalf(`GET display returned %+v, expected one call announced in two languages`, display.Calls)
+	}
+	call := display.Calls[0]
+	if call.DeskName != "12" || call.Announcements[0].Text != "Nummer 1, gaat u naar balie 12." || call.Announcements[1].Text != "passports: number 1 to desk 12." {
+		t.Errorf(`GET display returned %+v, expected the call rendered with the templates`, call)
+	}
+	if !strings.Contains(call.Announcements[1].SSML, `xml:lang="en"`) {
+		t.Errorf(`GET display returned SSML %q, expected it in English`, call.Announcements[1].SSML)
+	}
+
+	// deleting the purpose template leaves the location template
+	doJSON(t, srv, "DELETE", templatesPath+"/"+template.PublicID, "", nil, http.StatusUnauthorized, nil)
+	doJSON(t, srv, "DELETE", templatesPath+"/"+template.PublicID, adminToken, nil, http.StatusOK, nil)
+	doJSON(t, srv, "DELETE", templatesPath+"/"+template.PublicID, adminToken, nil, http.StatusNotFound, nil)
+	doJSON(t, srv, "GET", "/api/locations/"+location.PublicID+"/display", "", nil, http.StatusOK, &display)
+	if len(display.Calls) != 1 || len(display.Calls[0].Announcements) != 1 || display.Calls[0].Announcements[0].Language != "nl" {
+		t.Errorf(`GET display returned %+v, expected the call announced in Dutch only`, display.Calls)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	userToken := login(t, srv, createTestUser(t, cfg, false))
+
+	// problem details with a stable code, and a title in the language asked for
+	response := jsonutils.Problem{}
+	header := doJSONWithHeader(t, srv, "POST", "/api/desks", userToken, http.Header{"Accept-Language": {"nl-BE, en;q=0.5"}}, DesksPostRequestParameters{Name: "F1"}, http.StatusForbidden, &response)
+	if response.Code != "not_admin" || response.Title != "Hiervoor zijn beheerdersrechten nodig." || header.Get("Content-Language") != "nl" {
+		t.Errorf(`POST /api/desks returned %+v in %q, expected not_admin in Dutch`, response, header.Get("Content-Language"))
+	}
+	if response.Type != jsonutils.ProblemTypeBase+"not_admin" || response.Status != http.StatusForbidden || response.Instance == "" || response.Instance != header.Get("X-Request-ID") || header.Get("Content-Type") != "application/problem+json" {
+		t.Errorf(`POST /api/desks returned %+v with headers %v, expected problem details of the request`, response, header)
+	}
+	doJSONWithHeader(t, srv, "GET", "/api/purposes/unknownpurp", "", http.Header{"Accept-Language": {"tr"}}, nil, http.StatusBadRequest, &response)
+	if response.Code != "invalid_public_id" || response.Title != "The ID in the address has the wrong length." {
+		t.Errorf(`GET /api/purposes returned %+v, expected invalid_public_id in English`, response)
+	}
+
+	// which resource was not found
+	unknownID := strings.Repeat("x", cfg.PublicIDLength)
+	doJSON(t, srv, "GET", "/api/purposes/"+unknownID, "", nil, http.StatusNotFound, &response)
+	if response.Code != "purpose_not_found" || response.Detail == "" {
+		t.Errorf(`GET /api/purposes returned %+v, expected purpose_not_found`, response)
+	}
+	doJSON(t, srv, "GET", "/api/visitors/"+unknownID+"/ticket", "", nil, http.StatusNotFound, &response)
+	if response.Code != "visitor_not_found" {
+		t.Errorf(`GET ticket returned %+v, expected visitor_not_found`, response)
+	}
+
+	// the invalid fields of a request body
+	response = jsonutils.Problem{}
+	location := createTestLocation(t, srv, adminToken)
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "Paspoorten", LocationPublicID: location.PublicID, DailyCapacity: sql.NullInt32{Int32: -1, Valid: true}}, http.StatusBadRequest, &response)
+	if response.Code != "validation_failed" || len(response.Errors) != 1 || response.Errors[0].Field != "daily_capacity" || response.Errors[0].Code != "invalid_daily_capacity" || response.Errors[0].Detail == "" {
+		t.Errorf(`POST /api/purposes returned %+v, expected daily_capacity to be invalid`, response)
+	}
+	response = jsonutils.Problem{}
+	doJSON(t, srv, "POST", "/api/locations", adminToken, map[string]any{"name": 42}, http.StatusBadRequest, &response)
+	if response.Code != "invalid_json" || len(response.Errors) != 1 || response.Errors[0].Field != "name" || response.Errors[0].Code != "invalid_type" {
+		t.Errorf(`POST /api/locations returned %+v, expected name to have the wrong type`, response)
+	}
+
+	// codes by status for errors without one, and details of the server only in development
+	response = jsonutils.Problem{}
+	req, _ := http.NewRequest("POST", srv.URL+"/api/locations", strings.NewReader("{"))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf(`POST /api/locations: %v`, err)
+	}
+	json.NewDecoder(res.Body).Decode(&response)
+	res.Body.Close()
+	if response.Code != "invalid_json" || response.Debug != "" {
+		t.Errorf(`POST /api/locations with invalid JSON returned %+v, expected invalid_json without debug details`, response)
+	}
+	jsonutils.Debug = true
+	defer func() { jsonutils.Debug = false }()
+	doJSON(t, srv, "GET", "/api/purposes/"+unknownID, "", nil, http.StatusNotFound, &response)
+	if response.Code != "purpose_not_found" || !strings.Contains(response.Debug, "no rows") {
+		t.Errorf(`GET /api/purposes returned %+v, expected purpose_not_found with debug details`, response)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "Paspoorten", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	unknownID := strings.Repeat("x", cfg.PublicIDLength)
+
+	// every invalid field in one response
+	response := jsonutils.Problem{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: " ", LocationPublicID: unknownID}, http.StatusBadRequest, &response)
+	codes := map[string]string{}
+	for _, f := range response.Errors {
+		codes[f.Field] = f.Code
+	}
+	if response.Code != "validation_failed" || len(codes) != 2 || codes["name"] != "required" || codes["location_public_id"] != "location_not_found" {
+		t.Errorf(`POST /api/desks returned %+v, expected name and location_public_id to be invalid`, response)
+	}
+	response = jsonutils.Problem{}
+	doJSONWithHeader(t, srv, "POST", "/api/locations", adminToken, http.Header{"Accept-Language": {"nl"}}, LocationsRequestParameters{Name: strings.Repeat("x", 129)}, http.StatusBadRequest, &response)
+	if len(response.Errors) != 1 || response.Errors[0].Code != "too_long" || response.Errors[0].Limit == nil || *response.Errors[0].Limit != 128 || response.Errors[0].Detail != "Mag hoogstens 128 tekens lang zijn." {
+		t.Errorf(`POST /api/locations returned %+v, expected name to be too long in Dutch`, response)
+	}
+
+	// nonexistent purposes, unknown fields and oversized bodies
+	response = jsonutils.Problem{}
+	doJSON(t, srv, "PUT", "/api/visitors/"+visitor.PublicID, adminToken, VisitorsPutRequestParameters{PurposePublicID: unknownID}, http.StatusBadRequest, &response)
+	if len(response.Errors) != 1 || response.Errors[0].Field != "purpose_public_id" || response.Errors[0].Code != "purpose_not_found" {
+		t.Errorf(`PUT /api/visitors returned %+v, expected purpose_public_id to be unknown`, response)
+	}
+	response = jsonutils.Problem{}
+	doJSON(t, srv, "PUT", "/api/visitors/"+visitor.PublicID, adminToken, map[string]any{"name": "ok", "purpose_public_id": purpose.PublicID, "status": 7, "is_admin": true}, http.StatusBadRequest, &response)
+	codes = map[string]string{}
+	for _, f := range response.Errors {
+		codes[f.Field] = f.Code
+	}
+	if len(codes) != 2 || codes["status"] != "invalid_choice" || codes["is_admin"] != "unknown_field" {
+		t.Errorf(`PUT /api/visitors returned %+v, expected status to be invalid and is_admin unknown`, response)
+	}
+	response = jsonutils.Problem{}
+	doJSON(t, srv, "POST", "/api/visitors", "", map[string]any{"purpose_public_id": purpose.PublicID, "status": 3}, http.StatusBadRequest, &response)
+	if len(response.Errors) != 1 || response.Errors[0].Field != "status" || response.Errors[0].Code != "unknown_field" {
+		t.Errorf(`POST /api/visitors returned %+v, expected status to be unknown`, response)
+	}
+	response = jsonutils.Problem{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: strings.Repeat("x", 100<<10), PurposePublicID: purpose.PublicID}, http.StatusRequestEntityTooLarge, &response)
+	if response.Code != "body_too_large" {
+		t.Errorf(`POST /api/visitors returned %+v, expected body_too_large`, response)
+	}
+}
+
+func TestPurposeTranslations(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	admin := createTestUser(t, cfg, true)
+	adminToken := login(t, srv, admin)
+	location := createTestLocation(t, srv, adminToken)
+	purpose := PurposesResponseParameters{}
+	doJSON(t, srv, "POST", "/api/purposes", adminToken, PurposesRequestParameters{PurposeName: "Paspoorten", LocationPublicID: location.PublicID}, http.StatusOK, &purpose)
+
+	translationsPath := "/api/purposes/" + purpose.PublicID + "/translations"
+	doJSON(t, srv, "PUT", translationsPath, adminToken, PurposeTranslationsRequestParameters{Translations: map[string]string{"EN": "Passports"}}, http.StatusBadRequest, nil)
+	doJSON(t, srv, "PUT", translationsPath, adminToken, PurposeTranslationsRequestParameters{Translations: map[string]string{"de": " "}}, http.StatusBadRequest, nil)
+	header := doJSON(t, srv, "PUT", translationsPath, adminToken, PurposeTranslationsRequestParameters{Translations: map[string]string{"en": "Passports", "de": "Reisepässe"}}, http.StatusOK, &purpose)
+	if len(purpose.Translations) != 2 || header.Get("ETag") != jsonutils.ETag(purpose.UpdatedAt) {
+		t.Errorf(`PUT translations returned %+v, expected the purpose with both translations and a new ETag`, purpose)
+	}
+	purposes := []PurposesResponseParameters{}
+	doJSON(t, srv, "GET", "/api/purposes?location="+location.PublicID, "", nil, http.StatusOK, &purposes)
+	if len(purposes) != 1 || purposes[0].Translations["de"] != "Reisepässe" {
+		t.Errorf(`GET /api/purposes returned %+v, expected the translations`, purposes)
+	}
+	events := []AuditEventsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/audit?action=purpose.set_translations", adminToken, nil, http.StatusOK, &events)
+	if len(events) != 1 || events[0].EntityPublicID != purpose.PublicID {
+		t.Errorf(`GET /api/audit returned %+v, expected purpose.set_translations`, events)
+	}
+
+	// visitors read the purpose, and their ticket, in their own language
+	visitor := VisitorsResponseParameters{}
+	doJSON(t, srv, "POST", "/api/visitors", "", VisitorsPostRequestParameters{Name: "Alice", PurposePublicID: purpose.PublicID}, http.StatusCreated, &visitor)
+	req, _ := http.NewRequest("GET", srv.URL+"/api/visitors/"+visitor.PublicID+"/ticket?format=escpos", nil)
+	req.Header.Set("Accept-Language", "de-CH")
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf(`GET ticket: %v`, err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if !bytes.Contains(body, []byte("Reisep\xe4sse\n")) || !bytes.Contains(body, []byte("Scannen, um Ihre Wartezeit zu verfolgen")) || res.Header.Get("Content-Language") != "de" {
+		t.Errorf(`GET ticket in German returned %q, expected the purpose and captions in German`, body)
+	}
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+	doJSON(t, srv, "POST", "/api/servicelogs", adminToken, ServicelogsPOSTRequestParameters{VisitorPublicID: visitor.PublicID, UserPublicID: admin.PublicID, DeskPublicID: desk.PublicID}, http.StatusCreated, nil)
+	doJSON(t, srv, "POST", "/api/locations/"+location.PublicID+"/announcement-templates", adminToken, AnnouncementTemplatesRequestParameters{Language: "en", Template: "{purpose}: ticket {ticket}"}, http.StatusCreated, nil)
+	display := DisplayResponseParameters{}
+	doJSONWithHeader(t, srv, "GET", "/api/locations/"+location.PublicID+"/display", "", http.Header{"Accept-Language": {"nl"}}, nil, http.StatusOK, &display)
+	if len(display.Calls) != 1 || display.Calls[0].PurposeName != "Paspoorten" || display.Calls[0].Announcements[0].Text != "Passports: ticket 1" {
+		t.Errorf(`GET display returned %+v, expected the purpose in Dutch and announced in English`, display.Calls)
+	}
+}
+
+func TestProcessPassword(t *testing.T) {
+	// passwords are as long as the hashing algorithm allows, unless the policy is stricter
+	password := strings.Repeat("a1", 50) // 100 bytes
+	argon2id := auth.PasswordHasher{Algorithm: auth.PasswordAlgorithmArgon2id, Argon2Time: 1, Argon2Memory: 1024}
+	bcrypt := auth.PasswordHasher{Algorithm: auth.PasswordAlgorithmBcrypt, BcryptCost: 4}
+	tests := []struct {
+		name     string
+		hasher   auth.PasswordHasher
+		maxBytes int
+		status   int // 0 if the password is accepted
+	}{
+		{"argon2id", argon2id, 0, 0},
+		{"bcrypt", bcrypt, 0, http.StatusBadRequest},
+		{"argon2id with a policy of 64 bytes", argon2id, 64, http.StatusBadRequest},
+		{"bcrypt with a policy of 128 bytes", bcrypt, 128, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		policy := strutils.DefaultPasswordPolicy()
+		policy.MaxBytes = tt.maxBytes
+		w := httptest.NewRecorder()
+		hashedPassword, err := ProcessPassword(w, httptest.NewRequest("POST", "/api/users", nil), password, policy, tt.hasher)
+		if tt.status == 0 && (err != nil || tt.hasher.Check(hashedPassword, password) != nil) {
+			t.Errorf(`ProcessPassword with %s returned %q, %v; expected a hash of the password`, tt.name, hashedPassword, err)
+		} else if tt.status != 0 && (err == nil || w.Code != tt.status) {
+			t.Errorf(`ProcessPassword with %s returned %v with status %d; expected status %d`, tt.name, err, w.Code, tt.status)
+		}
+	}
+}
+
+func TestDesks(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	userToken := login(t, srv, createTestUser(t, cfg, false))
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+
+	request := DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}
+	doJSON(t, srv, "POST", "/api/desks", userToken, request, http.StatusForbidden, nil)
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, request, http.StatusCreated, &desk)
+
+	doJSON(t, srv, "PUT", "/api/desks/"+desk.PublicID, adminToken, DesksPutRequestParameters{Name: "F2", IsActive: true}, http.StatusOK, &desk)
+	if desk.Name != "F2" {
+		t.Errorf(`PUT /api/desks returned name %q, expected F2`, desk.Name)
+	}
+	doJSON(t, srv, "GET", "/api/desks/unknowndesks", "", nil, http.StatusNotFound, nil)
+
+	// both changes are in the audit log
+	events := []AuditEventsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/audit?entity_public_id="+desk.PublicID, adminToken, nil, http.StatusOK, &events)
+	if len(events) != 2 || events[0].Action != "desk.create" || events[1].Action != "desk.update" {
+		t.Errorf(`GET /api/audit returned %+v, expected desk.create and desk.update`, events)
+	}
+}
+
+func TestDesksETag(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	desk := DesksResponseParameters{}
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F1", LocationPublicID: location.PublicID}, http.StatusCreated, &desk)
+	path := "/api/desks/" + desk.PublicID
+
+	// polling an unchanged desk
+	etag := doJSON(t, srv, "GET", path, "", nil, http.StatusOK, nil).Get("ETag")
+	if etag == "" {
+		t.Fatalf(`GET %s returned no ETag`, path)
+	}
+	doJSONWithHeader(t, srv, "GET", path, "", http.Header{"If-None-Match": {etag}}, nil, http.StatusNotModified, nil)
+
+	// the first of two supervisors editing the same version wins, the second one has to get the desk again
+	ifMatch := http.Header{"If-Match": {etag}}
+	newETag := doJSONWithHeader(t, srv, "PUT", path, adminToken, ifMatch, DesksPutRequestParameters{Name: "F2", IsActive: true}, http.StatusOK, &desk).Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Errorf(`PUT %s returned ETag %q, expected a new one`, path, newETag)
+	}
+	doJSONWithHeader(t, srv, "PUT", path, adminToken, ifMatch, DesksPutRequestParameters{Name: "F3", IsActive: true}, http.StatusPreconditionFailed, nil)
+	doJSONWithHeader(t, srv, "GET", path, "", http.Header{"If-None-Match": {etag}}, nil, http.StatusOK, &desk)
+	if desk.Name != "F2" {
+		t.Errorf(`GET %s returned name %q, expected F2`, path, desk.Name)
+	}
+
+	// If-Match is optional, unless required
+	doJSON(t, srv, "PUT", path, adminToken, DesksPutRequestParameters{Name: "F4", IsActive: true}, http.StatusOK, nil)
+	cfg.RequireIfMatch = true
+	doJSON(t, srv, "PUT", path, adminToken, DesksPutRequestParameters{Name: "F5", IsActive: true}, http.StatusPreconditionRequired, nil)
+	doJSONWithHeader(t, srv, "PUT", path, adminToken, http.Header{"If-Match": {"*"}}, DesksPutRequestParameters{Name: "F5", IsActive: true}, http.StatusOK, nil)
+
+	// lists have an ETag too, which changes with the desks
+	listETag := doJSON(t, srv, "GET", "/api/desks", adminToken, nil, http.StatusOK, nil).Get("ETag")
+	doJSONWithHeader(t, srv, "GET", "/api/desks", adminToken, http.Header{"If-None-Match": {listETag}}, nil, http.StatusNotModified, nil)
+	doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: "F6", LocationPublicID: location.PublicID}, http.StatusCreated, nil)
+	doJSONWithHeader(t, srv, "GET", "/api/desks", adminToken, http.Header{"If-None-Match": {listETag}}, nil, http.StatusOK, nil)
+}
+
+func TestDesksPagination(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	location := createTestLocation(t, srv, adminToken)
+	for _, name := range []string{"F3", "F1", "F2"} {
+		doJSON(t, srv, "POST", "/api/desks", adminToken, DesksPostRequestParameters{Name: name, LocationPublicID: location.PublicID}, http.StatusCreated, nil)
+	}
+
+	// follow the Link headers through pages of two, in descending name order
+	names := []string{}
+	path := "/api/desks?limit=2&sort=-name&total=true"
+	for path != "" {
+		desks := []DesksResponseParameters{}
+		header := doJSON(t, srv, "GET", path, adminToken, nil, http.StatusOK, &desks)
+		if total := header.Get("X-Total-Count"); total != "3" {
+			t.Errorf(`GET %s returned X-Total-Count %q, expected 3`, path, total)
+		}
+		for _, d := range desks {
+			names = append(names, d.Name)
+		}
+		path = ""
+		if link := header.Get("Link"); link != "" {
+			path = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+	if strings.Join(names, ",") != "F3,F2,F1" {
+		t.Errorf(`GET /api/desks pages returned %v, expected F3, F2 and F1`, names)
+	}
+
+	doJSON(t, srv, "GET", "/api/desks?sort=description", adminToken, nil, http.StatusBadRequest, nil)
+	doJSON(t, srv, "GET", "/api/desks?limit=1000", adminToken, nil, http.StatusBadRequest, nil)
+}
+
+func TestUnlockUser(t *testing.T) {
+	// the account limiter of several instances keeps its counters in the store, next to the transaction of the unlock
+	db, engine, err := storage.OpenDB("sqlite:" + filepath.Join(t.TempDir(), "goqueue.db"))
+	if err != nil {
+		t.Fatalf(`OpenDB: %v`, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, engine)
+	if err != nil {
+		t.Fatalf(`migrate.New: %v`, err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf(`Up: %v`, err)
+	}
+	stores := map[string]storage.Store{"memory": storage.NewMemory(), "sqlite": storage.New(db, engine)}
+
+	for name, store := range stores {
+		cfg, srv := newTestServer(t)
+		cfg.DB = store
+		cfg.AccountLoginLimiter = auth.NewPostgresLoginLimiter(store, auth.LoginLimiterPolicy{MaxAttempts: 2, LockoutDuration: time.Hour})
+		adminToken := login(t, srv, createTestUser(t, cfg, true))
+		user := createTestUser(t, cfg, false)
+
+		// two failures lock the account out, until an admin unlocks it
+		for range 2 {
+			doJSON(t, srv, "POST", "/api/login", "", loginRequestParameters{Email: user.Email, Password: "wrong password"}, http.StatusUnauthorized, nil)
+		}
+		doJSON(t, srv, "POST", "/api/login", "", loginRequestParameters{Email: user.Email, Password: testPassword}, http.StatusTooManyRequests, nil)
+		response := UsersResponseParameters{}
+		doJSON(t, srv, "POST", "/api/users/"+user.PublicID+"/unlock", adminToken, nil, http.StatusOK, &response)
+		if response.PublicID != user.PublicID {
+			t.Errorf(`%s: POST /api/users/{id}/unlock returned %+v, expected user %s`, name, response, user.PublicID)
+		}
+		login(t, srv, user)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	user := createTestUser(t, cfg, false)
+	login(t, srv, user)
+	accessToken := login(t, srv, user)
+
+	sessions := []SessionsResponseParameters{}
+	doJSON(t, srv, "GET", "/api/sessions", accessToken, nil, http.StatusOK, &sessions)
+	if len(sessions) != 2 || !sessions[0].IsCurrent || sessions[1].IsCurrent {
+		t.Fatalf(`GET /api/sessions returned %+v, expected the current session first and one other session`, sessions)
+	}
+
+	// ending the other session leaves the current one working
+	doJSON(t, srv, "DELETE", "/api/sessions/"+sessions[1].PublicID, accessToken, nil, http.StatusOK, nil)
+	doJSON(t, srv, "GET", "/api/sessions", accessToken, nil, http.StatusOK, &sessions)
+	if len(sessions) != 1 {
+		t.Errorf(`GET /api/sessions returned %d sessions after ending one, expected 1`, len(sessions))
+	}
+
+	// ending the current session ends its access token as well
+	doJSON(t, srv, "DELETE", "/api/sessions/"+sessions[0].PublicID, accessToken, nil, http.StatusOK, nil)
+	doJSON(t, srv, "GET", "/api/sessions", accessToken, nil, http.StatusUnauthorized, nil)
+}
+
+// mailRecorder is a mailer.Sender that passes what it is sent on to a channel
+type mailRecorder chan mailer.Message
+
+func (c mailRecorder) Send(ctx context.Context, msg mailer.Message) error {
+	c <- msg
+	return nil
+}
+
+func (c mailRecorder) token(t *testing.T) string {
+	// the token in the link of the last mail sent
+	t.Helper()
+	msg := <-c
+	_, token, found := strings.Cut(msg.Body, "?token=")
+	if !found {
+		t.Fatalf(`mail %q has no token link`, msg.Body)
+	}
+	token, _, _ = strings.Cut(token, "\n")
+	unescaped, err := url.QueryUnescape(token)
+	if err != nil {
+		t.Fatalf(`url.QueryUnescape(%q): %v`, token, err)
+	}
+	return unescaped
+}
+
+// userTokenRecorder records the user tokens created
+type userTokenRecorder struct {
+	storage.Store
+	created chan database.CreateUserTokenParams
+}
+
+func (s userTokenRecorder) CreateUserToken(ctx context.Context, arg database.CreateUserTokenParams) (database.UserToken, error) {
+	s.created <- arg
+	return s.Store.CreateUserToken(ctx, arg)
+}
+
+func TestInvitations(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	adminToken := login(t, srv, createTestUser(t, cfg, true))
+	sent := make(mailRecorder, 10)
+	cfg.Mailer = sent
+	created := make(chan database.CreateUserTokenParams, 10)
+	cfg.DB = userTokenRecorder{cfg.DB, created}
+
+	// the invited user chooses a password with the token in the mail, and can log in with it
+	invited := UsersResponseParameters{}
+	doJSON(t, srv, "POST", "/api/invitations", adminToken, InvitationsPOSTRequestParameters{Email: "invited@example.org", FullName: "Invited User"}, http.StatusCreated, &invited)
+	if params := <-created; params.ExpiresAt.Location() != time.UTC || params.ExpiresAt.Sub(time.Now()) < 71*time.Hour {
+		t.Errorf(`POST /api/invitations created a token expiring at %v, expected 72 hours from now in UTC`, params.ExpiresAt)
+	}
+	token := sent.token(t)
+	accepted := UsersResponseParameters{}
+	doJSON(t, srv, "POST", "/api/invitations/accept", "", UserTokenRedeemRequestParameters{Token: token, Password: testPassword}, http.StatusOK, &accepted)
+	if accepted.PublicID != invited.PublicID {
+		t.Errorf(`POST /api/invitations/accept returned %+v, expected the invited user %s`, accepted, invited.PublicID)
+	}
+	login(t, srv, database.User{Email: "invited@example.org"})
+
+	// tokens work only once
+	response := jsonutils.Problem{}
+	doJSON(t, srv, "POST", "/api/invitations/accept", "", UserTokenRedeemRequestParameters{Token: token, Password: "another " + testPassword}, http.StatusBadRequest, &response)
+	if response.Code != jsonutils.CodeUserTokenInvalid {
+		t.Errorf(`POST /api/invitations/accept with a used token returned %+v, expected user_token_invalid`, response)
+	}
+
+	// and only in time
+	cfg.InvitationTokenDuration = 0
+	doJSON(t, srv, "POST", "/api/invitations", adminToken, InvitationsPOSTRequestParameters{Email: "late@example.org"}, http.StatusCreated, nil)
+	<-created
+	doJSON(t, srv, "POST", "/api/invitations/accept", "", UserTokenRedeemRequestParameters{Token: sent.token(t), Password: testPassword}, http.StatusBadRequest, &response)
+	if response.Code != jsonutils.CodeUserTokenInvalid {
+		t.Errorf(`POST /api/invitations/accept with an expired token returned %+v, expected user_token_invalid`, response)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	cfg, srv := newTestServer(t)
+	user := createTestUser(t, cfg, false)
+	accessToken := login(t, srv, user)
+	sent := make(mailRecorder, 10)
+	cfg.Mailer = sent
+	created := make(chan database.CreateUserTokenParams, 10)
+	cfg.DB = userTokenRecorder{cfg.DB, created}
+
+	// unknown addresses get the same response, but no mail
+	doJSON(t, srv, "POST", "/api/password-reset", "", PasswordResetPOSTRequestParameters{Email: "nobody@example.org"}, http.StatusAccepted, nil)
+	if len(sent) != 0 {
+		t.Errorf(`POST /api/password-reset for an unknown address sent a mail`)
+	}
+
+	// the new password works and ends every session
+	doJSON(t, srv, "POST", "/api/password-reset", "", PasswordResetPOSTRequestParameters{Email: user.Email}, http.StatusAccepted, nil)
+	if params := <-created; params.ExpiresAt.Location() != time.UTC || params.ExpiresAt.Sub(time.Now()) < 59*time.Minute {
+		t.Errorf(`POST /api/password-reset created a token expiring at %v, expected an hour from now in UTC`, params.ExpiresAt)
+	}
+	token := sent.token(t)
+	newPassword := "another " + testPassword
+	doJSON(t, srv, "POST", "/api/password-reset/confirm", "", UserTokenRedeemRequestParameters{Token: token, Password: newPassword}, http.StatusOK, nil)
+	doJSON(t, srv, "GET", "/api/sessions", accessToken, nil, http.StatusUnauthorized, nil)
+	doJSON(t, srv, "POST", "/api/login", "", loginRequestParameters{Email: user.Email, Password: newPassword}, http.StatusOK, nil)
+
+	// tokens work only once
+	response := jsonutils.Problem{}
+	doJSON(t, srv, "POST", "/api/password-reset/confirm", "", UserTokenRedeemRequestParameters{Token: token, Password: testPassword}, http.StatusBadRequest, &response)
+	if response.Code != jsonutils.CodeUserTokenInvalid {
+		t.Errorf(`POST /api/password-reset/confirm with a used token returned %+v, expected user_token_invalid`, response)
+	}
+
+	// and only in time
+	cfg.PasswordResetTokenDuration = 0
+	doJSON(t, srv, "POST", "/api/password-reset", "", PasswordResetPOSTRequestParameters{Email: user.Email}, http.StatusAccepted, nil)
+	<-created
+	doJSON(t, srv, "POST", "/api/password-reset/confirm", "", UserTokenRedeemRequestParameters{Token: sent.token(t), Password: testPassword}, http.StatusBadRequest, &response)
+	if response.Code != jsonutils.CodeUserTokenInvalid {
+		t.Errorf(`POST /api/password-reset/confirm with an expired token returned %+v, expected user_token_invalid`, response)
+	}
+	doJSON(t, srv, "POST", "/api/login", "", loginRequestParameters{Email: user.Email, Password: newPassword}, http.StatusOK, nil)
 }
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dcrauwels/goqueue/announce"
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/schedule"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/google/uuid"
+)
+
+var ErrAnnouncementTemplateExists = jsonutils.NewError(jsonutils.CodeAnnouncementTemplateExists, "location or purpose already has an announcement template in this language")
+
+var ErrAnnouncementTemplateNotFound = jsonutils.NewError(jsonutils.CodeAnnouncementTemplateNotFound, "announcement template not found")
+
+const displayCalls = 10 // calls shown on display screens at most
+
+type AnnouncementTemplatesRequestParameters struct {
+	PurposePublicID string `json:"purpose_public_id" validate:"ref=purpose"` // empty for the template of the whole location
+	Language        string `json:"language" validate:"required"`             // language tag, e.g. nl or pt-BR
+	Template        string `json:"template" validate:"max=1000"`             // empty for the built-in template of the language
+}
+
+type AnnouncementTemplatesResponseParameters struct {
+	ID               uuid.UUID      `json:"id"`
+	PublicID         string         `json:"public_id"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	LocationPublicID string         `json:"location_public_id"`
+	PurposePublicID  sql.NullString `json:"purpose_public_id"`
+	Language         string         `json:"language"`
+	Template         string         `json:"template"`
+}
+
+func (atrp *AnnouncementTemplatesResponseParameters) Populate(a database.AnnouncementTemplate) {
+	atrp.ID = a.ID
+	atrp.PublicID = a.PublicID
+	atrp.CreatedAt = a.CreatedAt
+	atrp.UpdatedAt = a.UpdatedAt
+	atrp.LocationPublicID = a.LocationPublicID
+	atrp.PurposePublicID = a.PurposePublicID
+	atrp.Language = a.Language
+	atrp.Template = a.Template
+}
+
+// DisplayCallResponseParameters is a visitor called to a desk, as shown and announced by display screens
+type DisplayCallResponseParameters struct {
+	ServiceLogPublicID string                  `json:"service_log_public_id"`
+	CalledAt           time.Time               `json:"called_at"`
+	DailyTicketNumber  int32                   `json:"daily_ticket_number"`
+	DeskPublicID       string                  `json:"desk_public_id"`
+	DeskName           string                  `json:"desk_name"`
+	PurposePublicID    string                  `json:"purpose_public_id"`
+	PurposeName        string                  `json:"purpose_name"`
+	Announcements      []announce.Announcement `json:"announcements"`
+}
+
+type DisplayResponseParameters struct {
+	LocationPublicID string                          `json:"location_public_id"`
+	Calls            []DisplayCallResponseParameters `json:"calls"` // most recent first
+}
+
+func announcementTemplates(templates []database.AnnouncementTemplate, purposePublicID string) []announce.Template {
+	/*
+		The templates visitors of the purpose are announced with, from the templates of its location: one per language,
+		the template of the purpose itself replacing that of the location. Languages are in the order their first
+		template was created.
+	*/
+	var selected []announce.Template
+	index := map[string]int{}
+	for _, t := range templates {
+		if t.PurposePublicID.Valid && t.PurposePublicID.String != purposePublicID {
+			continue
+		}
+		i, ok := index[t.Language]
+		if !ok {
+			index[t.Language] = len(selected)
+			selected = append(selected, announce.Template{Language: t.Language, Text: t.Template})
+		} else if t.PurposePublicID.Valid {
+			selected[i].Text = t.Template
+		}
+	}
+	return selected
+}
+
+func callAnnouncements(templates []database.AnnouncementTemplate, visitor database.Visitor, desk database.Desk, purpose database.Purpose, translations map[string]string) []announce.Announcement {
+	// the announcements of visitor being called to desk, given the templates of their location and the translations of their purpose
+	call := announce.Call{Ticket: visitor.DailyTicketNumber, Desk: desk.Name, Purpose: purpose.PurposeName, PurposeTranslations: translations}
+	return announce.Render(announcementTemplates(templates, purpose.PublicID), call)
+}
+
+func serviceLogAnnouncements(ctx context.Context, q storage.Store, serviceLog database.ServiceLog) ([]announce.Announcement, error) {
+	// the announcements of the visitor of serviceLog being called to its desk
+	visitor, err := q.GetVisitorsByPublicID(ctx, serviceLog.VisitorPublicID)
+	if err != nil {
+		return nil, err
+	}
+	desk, err := q.GetDesksByPublicID(ctx, serviceLog.DeskPublicID)
+	if err != nil {
+		return nil, err
+	}
+	purpose, err := q.GetPurposesByPublicID(ctx, visitor.PurposePublicID)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := q.GetPurposeTranslationsByPurposePublicID(ctx, purpose.PublicID)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := q.GetAnnouncementTemplatesByLocationPublicID(ctx, visitor.LocationPublicID)
+	if err != nil {
+		return nil, err
+	}
+	return callAnnouncements(templates, visitor, desk, purpose, purposeTranslations(translations)), nil
+}
+
+// GET /api/locations/{location_public_id}/announcement-templates
+func (cfg *ApiConfig) HandlerGetAnnouncementTemplates(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required)
+	// 1. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. run queries GetLocationByPublicID and GetAnnouncementTemplatesByLocationPublicID
+	if _, err := cfg.DB.GetLocationByPublicID(r.Context(), lpid); errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocationByPublicID in HandlerGetAnnouncementTemplates)")
+		return
+	}
+	templates, err := cfg.DB.GetAnnouncementTemplatesByLocationPublicID(r.Context(), lpid)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetAnnouncementTemplatesByLocationPublicID in HandlerGetAnnouncementTemplates)")
+		return
+	}
+
+	// 3. return result
+	response := make([]AnnouncementTemplatesResponseParameters, len(templates))
+	for i, a := range templates {
+		response[i].Populate(a)
+	}
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
+
+// POST /api/locations/{location_public_id}/announcement-templates (admin only)
+func (cfg *ApiConfig) HandlerPostAnnouncementTemplates(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. get request data: purpose, language and template
+	request := AnnouncementTemplatesRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	if err := announce.Validate(announce.Template{Language: request.Language, Text: request.Template}); err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	// 4. run query CreateAnnouncementTemplate. a location or purpose has one template per language
+	response := AnnouncementTemplatesResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		if _, err := q.GetLocationByPublicID(r.Context(), lpid); err != nil {
+			return err
+		}
+		if request.PurposePublicID != "" {
+			purpose, err := q.GetPurposesByPublicID(r.Context(), request.PurposePublicID)
+			if errors.Is(err, sql.ErrNoRows) || (err == nil && purpose.LocationPublicID != lpid) {
+				return ErrLocationMismatch
+			} else if err != nil {
+				return err
+			}
+		}
+		templates, err := q.GetAnnouncementTemplatesByLocationPublicID(r.Context(), lpid)
+		if err != nil {
+			return err
+		}
+		for _, t := range templates {
+			if t.Language == request.Language && t.PurposePublicID.String == request.PurposePublicID {
+				return ErrAnnouncementTemplateExists
+			}
+		}
+		template, err := q.CreateAnnouncementTemplate(r.Context(), database.CreateAnnouncementTemplateParams{
+			PublicID:         cfg.PublicIDGenerator(),
+			LocationPublicID: lpid,
+			PurposePublicID:  strutils.QueryParameterToNullString(request.PurposePublicID),
+			Language:         request.Language,
+			Template:         request.Template,
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(template)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionAnnouncementTemplateCreate, audit.EntityAnnouncementTemplate, template.PublicID, nil, response)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if errors.Is(err, ErrLocationMismatch) {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "purpose_public_id does not identify a purpose of this location")
+		return
+	} else if errors.Is(err, ErrAnnouncementTemplateExists) {
+		jsonutils.WriteError(w, r, http.StatusConflict, err, "location or purpose already has an announcement template in this language: delete it first")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateAnnouncementTemplate in HandlerPostAnnouncementTemplates)")
+		return
+	}
+
+	// 5. return result
+	jsonutils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// DELETE /api/locations/{location_public_id}/announcement-templates/{announcement_template_public_id} (admin only)
+func (cfg *ApiConfig) HandlerDeleteAnnouncementTemplatesByPublicID(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path values
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	atpid, err := strutils.GetPublicIDFromPathValue("announcement_template_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. run query DeleteAnnouncementTemplateByPublicID
+	response := AnnouncementTemplatesResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		template, err := q.GetAnnouncementTemplateByPublicID(r.Context(), atpid)
+		if err != nil {
+			return err
+		} else if template.LocationPublicID != lpid {
+			return sql.ErrNoRows
+		}
+		if err := q.DeleteAnnouncementTemplateByPublicID(r.Context(), atpid); err != nil {
+			return err
+		}
+		response.Populate(template)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionAnnouncementTemplateDelete, audit.EntityAnnouncementTemplate, template.PublicID, response, nil)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrAnnouncementTemplateNotFound.Wrap(err), "no announcement templates found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (DeleteAnnouncementTemplateByPublicID in HandlerDeleteAnnouncementTemplatesByPublicID)")
+		return
+	}
+
+	// 4. return the deleted template
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GET /api/locations/{location_public_id}/display
+func (cfg *ApiConfig) HandlerGetDisplay(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required: display screens in the waiting room poll this, with If-None-Match)
+	// 1. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. run queries: the calls of today that are still active, with their desks, purposes and announcements
+	location, err := cfg.DB.GetLocationByPublicID(r.Context(), lpid)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocationByPublicID in HandlerGetDisplay)")
+		return
+	}
+	tz, err := time.LoadLocation(location.TimeZone)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "location has an unknown time zone")
+		return
+	}
+	templates, err := cfg.DB.GetAnnouncementTemplatesByLocationPublicID(r.Context(), lpid)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetAnnouncementTemplatesByLocationPublicID in HandlerGetDisplay)")
+		return
+	}
+	serviceLogs, err := cfg.DB.GetActiveServiceLogsByLocationPublicID(r.Context(), database.GetActiveServiceLogsByLocationPublicIDParams{
+		LocationPublicID: lpid,
+		CalledAt:         schedule.DayStart(time.Now(), tz).UTC(),
+		Limit:            displayCalls,
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetActiveServiceLogsByLocationPublicID in HandlerGetDisplay)")
+		return
+	}
+	response := DisplayResponseParameters{LocationPublicID: lpid, Calls: make([]DisplayCallResponseParameters, len(serviceLogs))}
+	for i, serviceLog := range serviceLogs {
+		visitor, err := cfg.DB.GetVisitorsByPublicID(r.Context(), serviceLog.VisitorPublicID)
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetVisitorsByPublicID in HandlerGetDisplay)")
+			return
+		}
+		desk, err := cfg.DB.GetDesksByPublicID(r.Context(), serviceLog.DeskPublicID)
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetDesksByPublicID in HandlerGetDisplay)")
+			return
+		}
+		purpose, err := cfg.DB.GetPurposesByPublicID(r.Context(), visitor.PurposePublicID)
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposesByPublicID in HandlerGetDisplay)")
+			return
+		}
+		translations, err := cfg.DB.GetPurposeTranslationsByPurposePublicID(r.Context(), purpose.PublicID)
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposeTranslationsByPurposePublicID in HandlerGetDisplay)")
+			return
+		}
+		purposeNames := purposeTranslations(translations)
+		response.Calls[i] = DisplayCallResponseParameters{
+			ServiceLogPublicID: serviceLog.PublicID,
+			CalledAt:           serviceLog.CalledAt,
+			DailyTicketNumber:  visitor.DailyTicketNumber,
+			DeskPublicID:       desk.PublicID,
+			DeskName:           desk.Name,
+			PurposePublicID:    purpose.PublicID,
+			PurposeName:        localizedPurposeName(purpose, purposeNames, r.Header.Get("Accept-Language")),
+			Announcements:      callAnnouncements(templates, visitor, desk, purpose, purposeNames),
+		}
+	}
+
+	// 3. return result
+	w.Header().Add("Vary", "Accept-Language")
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/google/uuid"
+)
+
+type AuditEventsResponseParameters struct {
+	ID             uuid.UUID       `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ActorPublicID  string          `json:"actor_public_id"`
+	Action         string          `json:"action"`
+	EntityType     string          `json:"entity_type"`
+	EntityPublicID string          `json:"entity_public_id"`
+	Diff           json.RawMessage `json:"diff"`
+	RequestID      string          `json:"request_id"`
+	IPAddress      string          `json:"ip_address"`
+}
+
+func (arp *AuditEventsResponseParameters) Populate(e database.AuditEvent) {
+	arp.ID = e.ID
+	arp.CreatedAt = e.CreatedAt
+	arp.ActorPublicID = e.ActorPublicID
+	arp.Action = e.Action
+	arp.EntityType = e.EntityType
+	arp.EntityPublicID = e.EntityPublicID
+	arp.Diff = e.Diff
+	arp.RequestID = e.RequestID
+	arp.IPAddress = e.IpAddress
+}
+
+// GET /api/audit (admin only)
+func (cfg *ApiConfig) HandlerGetAudit(w http.ResponseWriter, r *http.Request) {
+	/*
+		Lists audit events, oldest first. Can be filtered with the actor_public_id, action, entity_type, entity_public_id,
+		start_date and end_date query parameters.
+	*/
+
+	// 1. check for admin status in accessing user
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "non-admin user tried to request GET /api/audit")
+		return
+	}
+
+	// 2. get query parameters
+	q := r.URL.Query()
+	params := database.ListAuditEventsParams{
+		ActorPublicID:  strutils.QueryParameterToNullString(q.Get("actor_public_id")),
+		Action:         strutils.QueryParameterToNullString(q.Get("action")),
+		EntityType:     strutils.QueryParameterToNullString(q.Get("entity_type")),
+		EntityPublicID: strutils.QueryParameterToNullString(q.Get("entity_public_id")),
+	}
+	t, err := strutils.QueryParameterToNullTime(q.Get("start_date"))
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid start_date query parameter")
+		return
+	}
+	params.StartDate = t
+	t, err = strutils.QueryParameterToNullTime(q.Get("end_date"))
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid end_date query parameter")
+		return
+	}
+	params.EndDate = t
+
+	// 3. run query
+	events, err := cfg.DB.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (ListAuditEvents in HandlerGetAudit)")
+		return
+	}
+
+	// 4. write response
+	response := make([]AuditEventsResponseParameters, len(events))
+	for i, e := range events {
+		response[i].Populate(e)
+	}
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
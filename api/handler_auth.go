@@ -2,22 +2,26 @@ package api
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/dcrauwels/goqueue/audit"
 	"github.com/dcrauwels/goqueue/auth"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
 	"github.com/dcrauwels/goqueue/strutils"
 	"github.com/google/uuid"
 )
 
 type loginRequestParameters struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 type userResponseParameters struct {
@@ -54,6 +58,9 @@ type refreshTokenResponseParameters struct {
 	UserPublicID string       `json:"user_public_id"`
 	ExpiresAt    time.Time    `json:"expires_at"`
 	RevokedAt    sql.NullTime `json:"revoked_at"`
+	LastUsedAt   time.Time    `json:"last_used_at"`
+	UserAgent    string       `json:"user_agent"`
+	IPAddress    string       `json:"ip_address"`
 }
 
 func (rp *refreshTokenResponseParameters) Populate(token database.RefreshToken) {
@@ -64,12 +71,15 @@ func (rp *refreshTokenResponseParameters) Populate(token database.RefreshToken)
 	rp.UserPublicID = token.UserPublicID
 	rp.ExpiresAt = token.ExpiresAt
 	rp.RevokedAt = token.RevokedAt
+	rp.LastUsedAt = token.LastUsedAt
+	rp.UserAgent = token.UserAgent
+	rp.IPAddress = token.IpAddress
 }
 
 func (cfg *ApiConfig) HandlerGetRefreshTokens(w http.ResponseWriter, r *http.Request) { // GET /api/refresh
 	// 1. authenticate: only dev environment
 	if cfg.Env != "dev" {
-		jsonutils.WriteError(w, http.StatusMethodNotAllowed, fmt.Errorf("user tried to access GET /api/refresh from incorrect environment"), "GET not allowed for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("user tried to access GET /api/refresh from incorrect environment"), "GET not allowed for this endpoint")
 		return
 	}
 
@@ -87,10 +97,10 @@ func (cfg *ApiConfig) HandlerGetRefreshTokens(w http.ResponseWriter, r *http.Req
 	}
 
 	if errors.Is(err, sql.ErrNoRows) || len(refreshTokens) == 0 {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "no rows found when querying database (GetRefreshTokens in HandlerGetRefreshTokens)")
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrSessionNotFound.Wrap(err), "no refresh tokens found")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetRefreshRokens in HandlerGetRefreshTokens)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetRefreshRokens in HandlerGetRefreshTokens)")
 		return
 	}
 
@@ -105,49 +115,65 @@ func (cfg *ApiConfig) HandlerGetRefreshTokens(w http.ResponseWriter, r *http.Req
 func (cfg *ApiConfig) HandlerLoginUser(w http.ResponseWriter, r *http.Request) { // POST /api/login
 	// for authenticating USERS, not VISITORS
 	// 1. get request content (email: string, password: string)
-	decoder := json.NewDecoder(r.Body)
 	reqParams := loginRequestParameters{}
-	err := decoder.Decode(&reqParams)
+	if !cfg.readRequest(w, r, &reqParams) {
+		return
+	}
+
+	// 2. check for too many failed attempts, both for the client IP and for the account. The attempt counts as failed
+	// for the account up front, so that parallel guesses cannot all get in before the first of them fails
+	accountKey := auth.LoginAccountKey(reqParams.Email)
+	ipKey := auth.LoginIPKey(strutils.GetIPFromRequest(r))
+	wait, err := cfg.checkLoginLimiters(r, accountKey, ipKey)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "JSON formatting invalid")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error checking login attempts (checkLoginLimiters in HandlerLoginUser)")
+		return
+	} else if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		jsonutils.WriteError(w, r, http.StatusTooManyRequests, auth.ErrLoginThrottled, "too many failed login attempts, please try again later")
 		return
 	}
 
-	// 2. validate login credentials
+	// 2.1 validate login credentials. Unknown emails count as failed attempts as well, so they cannot be told apart
 	user, err := cfg.DB.GetUserByEmail(r.Context(), reqParams.Email)
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "email or password incorrect")
+		cfg.failLoginLimiters(r, ipKey)
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "email or password incorrect")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetUserByEmail in HandlerLoginUser)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetUserByEmail in HandlerLoginUser)")
 		return
 	}
-	err = auth.CheckPasswordHash(user.HashedPassword, reqParams.Password)
+	err = cfg.PasswordHasher.Check(user.HashedPassword, reqParams.Password)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "email or password incorrect")
+		cfg.failLoginLimiters(r, ipKey)
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "email or password incorrect")
 		return
 	}
-	// 2.1 check if refresh token already exists
-	_, err = cfg.DB.GetRefreshTokensByUserPublicID(r.Context(), user.PublicID)
-	if err == nil {
-		jsonutils.WriteError(w, http.StatusSeeOther, err, "user already logged in, use /api/refresh endpoint instead")
-		return
-	} else if !errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetRefreshTokensByUserID in HandlerLoginUser)")
-		return
+	// 2.1.1 upgrade the stored hash if it was made with an outdated algorithm or parameters. Failing to do so is not fatal
+	if cfg.PasswordHasher.NeedsRehash(user.HashedPassword) {
+		hashedPassword, err := cfg.PasswordHasher.Hash(reqParams.Password)
+		if err == nil {
+			_, err = cfg.DB.SetUserPasswordByPublicID(r.Context(), database.SetUserPasswordByPublicIDParams{
+				PublicID:       user.PublicID,
+				HashedPassword: hashedPassword,
+			})
+		}
+		if err != nil {
+			log.Printf("error rehashing password for user %s: %v", user.PublicID, err)
+		}
 	}
-
-	// 3. generate access token
-	userAccessToken, err := auth.MakeJWT(user.PublicID, "user", cfg.Secret, cfg.AccessTokenDuration)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error creating access token (in HandlerLoginUser)")
-		return
+	// 2.2 successful login clears the failed attempts for this account, including this one (but not for the IP)
+	if cfg.AccountLoginLimiter != nil {
+		if err = cfg.AccountLoginLimiter.Reset(r.Context(), accountKey); err != nil {
+			log.Printf("error resetting login attempts for %s: %v", accountKey, err)
+		}
 	}
 
-	// 4. query for refresh token
+	// 3. create a new session (refresh token). Users can be logged in on several devices at once, see GET /api/sessions
 	newRefreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error creating refresh token (in HandlerLoginUser)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error creating refresh token (in HandlerLoginUser)")
 		return
 	}
 	queryParams := database.CreateRefreshTokenParams{
@@ -155,142 +181,185 @@ func (cfg *ApiConfig) HandlerLoginUser(w http.ResponseWriter, r *http.Request) {
 		PublicID:     cfg.PublicIDGenerator(),
 		UserPublicID: user.PublicID,
 		ExpiresAt:    time.Now().Add(time.Hour * 24 * time.Duration(cfg.RefreshTokenDuration)), // 14 days
+		UserAgent:    r.UserAgent(),
+		IpAddress:    strutils.GetIPFromRequest(r),
+	}
+	session, err := cfg.DB.CreateRefreshToken(r.Context(), queryParams)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateRefreshToken in HandlerLoginUser)")
+		return
 	}
-	_, err = cfg.DB.CreateRefreshToken(r.Context(), queryParams)
+
+	// 4. generate access token for this session
+	userAccessToken, err := auth.MakeSessionJWT(user.PublicID, session.PublicID, "user", cfg.Secret, cfg.AccessTokenDuration)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (CreateRefreshToken in HandlerLoginUser)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error creating access token (in HandlerLoginUser)")
 		return
 	}
 
-	// 5. return access token to user
+	// 5. write cookies. These are headers, so they have to be set before the response body is written
+	auth.SetAuthCookies(w, userAccessToken, newRefreshToken, "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+	// 5.1 CSRF token for cookie authenticated requests, see GET /api/csrf
+	csrfToken, err := auth.MakeCSRFToken(cfg.Secret)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error creating CSRF token (MakeCSRFToken in HandlerLoginUser)")
+		return
+	}
+	auth.SetCSRFCookie(w, csrfToken, cfg.RefreshTokenDuration)
+
+	// 6. return access token to user
 	respParams := userResponseParameters{}
 	respParams.populate(user, userAccessToken, newRefreshToken)
-
 	jsonutils.WriteJSON(w, http.StatusOK, respParams)
+}
 
-	// 6. write cookies
-	auth.SetAuthCookies(w, userAccessToken, newRefreshToken, "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+func (cfg *ApiConfig) checkLoginLimiters(r *http.Request, accountKey, ipKey string) (time.Duration, error) {
+	/*
+		Returns the wait imposed by the IP login limiter or, if there is none, by the account login limiter. If neither
+		imposes a wait the attempt is registered with the account limiter (see LoginLimiter.Attempt), so an IP that is
+		blocked cannot lock an account out. Limiters that are not configured (nil) never impose a wait.
+	*/
+	if cfg.IPLoginLimiter != nil {
+		wait, err := cfg.IPLoginLimiter.Check(r.Context(), ipKey)
+		if err != nil || wait > 0 {
+			return wait, err
+		}
+	}
+	if cfg.AccountLoginLimiter != nil {
+		return cfg.AccountLoginLimiter.Attempt(r.Context(), accountKey)
+	}
+	return 0, nil
+}
 
+func (cfg *ApiConfig) failLoginLimiters(r *http.Request, ipKey string) {
+	/*
+		Registers a failed login attempt with the IP limiter; checkLoginLimiters already registered it with the account
+		limiter. Errors are only logged: the login is refused either way.
+	*/
+	if cfg.IPLoginLimiter != nil {
+		if _, err := cfg.IPLoginLimiter.Fail(r.Context(), ipKey); err != nil {
+			log.Printf("error registering failed login attempt for %s: %v", ipKey, err)
+		}
+	}
 }
 
-func (cfg *ApiConfig) HandlerRefreshUser(w http.ResponseWriter, r *http.Request) { // POST /api/refresh
-	// for getting USERS a new access token based on a valid refresh token
-	// 1. get user from context
-	user, err := auth.UserFromContext(w, r, cfg.DB)
-	if err != nil { // error handling
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access /api/logout")
+func (cfg *ApiConfig) HandlerUnlockUser(w http.ResponseWriter, r *http.Request) { // POST /api/users/{user_public_id}/unlock
+	/*
+		Lifts a login lockout for a single user account. Admin only. Note that this only clears the account counter:
+		lockouts on the client IP expire on their own.
+	*/
+
+	// 1. authenticate from context: admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "non-admin users are not allowed to unlock user accounts")
 		return
 	}
 
-	// 2. validate refresh token through DB query
-	validRefreshTokens, err := cfg.DB.GetRefreshTokensByUserPublicID(r.Context(), user.PublicID)
+	// 2. get target user from URI
+	pid, err := strutils.GetPublicIDFromPathValue("user_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	user, err := cfg.DB.GetUserByPublicID(r.Context(), pid)
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "refresh token not found")
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrUserNotFound.Wrap(err), "user not found")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetRefreshTokensByUserID in HandlerRefreshUser)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetUserByPublicID in HandlerUnlockUser)")
 		return
 	}
-	// 2.1 if more than one valid refresh token is available: rotate fully (revoke all, return new token)
-	if len(validRefreshTokens) != 1 {
-		_, err = cfg.DB.RevokeRefreshTokenByUserPublicID(r.Context(), user.PublicID)
-		if err != nil {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (RevokeRefreshTokensByUserID in HandlerRefreshUsers)")
-			return
-		}
-		jsonutils.WriteError(w, http.StatusUnauthorized, errors.New("auth: user has more than one valid refresh token"), "too many refresh tokens found")
+
+	// 3. record the unlock, then reset the account limiter. The limiter writes through a store of its own, so it cannot
+	// take part in the transaction: resetting inside it would wait for the lock the transaction holds
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionUserUnlock, audit.EntityUser, user.PublicID, nil, nil)
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error writing audit log (recordAudit in HandlerUnlockUser)")
 		return
 	}
-
-	// 2.2 define single refresh token
-	refreshToken := validRefreshTokens[0]
-
-	// 2.3 check token expiration. UserID check should be superfluous (as the query should also test for this) but why not
-	if user.PublicID != refreshToken.UserPublicID || refreshToken.ExpiresAt.After(time.Now()) {
-		_, err = cfg.DB.RevokeRefreshTokenByToken(r.Context(), refreshToken.Token)
-		if err != nil {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (RevokeRefreshTokenByToken in HandlerRefreshUsers)")
+	if cfg.AccountLoginLimiter != nil {
+		if err = cfg.AccountLoginLimiter.Reset(r.Context(), auth.LoginAccountKey(user.Email)); err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error resetting login attempts (Reset in HandlerUnlockUser)")
 			return
 		}
-		jsonutils.WriteError(w, http.StatusUnauthorized, errors.New("auth: refresh token not valid"), "invalid refresh token provided in context")
-		return
 	}
 
-	// 2.4 rotate refresh token
+	// 4. write response
+	response := UsersResponseParameters{}
+	response.Populate(user)
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (cfg *ApiConfig) HandlerRefreshUser(w http.ResponseWriter, r *http.Request) { // POST /api/refresh
+	/*
+		For getting USERS a new access token based on a valid refresh token. Not wrapped in AuthUserMiddleware: the
+		refresh token cookie is the only credential needed, and the access token is usually expired at this point anyway.
+		The refresh token is rotated, so the session (identified by its public ID) continues with a new token.
+	*/
+
+	// 1. get refresh token from cookie
 	refreshTokenCookie, err := r.Cookie("user_refresh_token")
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "user_refresh_token cookie not found")
+	if err != nil || refreshTokenCookie.Value == "" {
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, auth.ErrRefreshTokenInvalid, "user_refresh_token cookie not found")
 		return
 	}
-	newRefreshToken, err := auth.RotateRefreshToken(cfg.DB, w, r, refreshTokenCookie)
+
+	// 2. rotate refresh token and set new cookies
+	session, userAccessToken, err := cfg.refreshSession(w, r, refreshTokenCookie)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error rotating refresh token (RotateRefreshToken in HandlerRefreshUser)")
-		return
+		return // cfg.refreshSession already writes an error response
 	}
 
-	// 3. generate access token
-	userAccessToken, err := auth.MakeJWT(user.PublicID, "user", cfg.Secret, cfg.AccessTokenDuration)
+	// 3. check if user is active
+	user, err := cfg.DB.GetUserByPublicID(r.Context(), session.UserPublicID)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error creating access token")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetUserByPublicID in HandlerRefreshUser)")
+		return
+	} else if !user.IsActive {
+		auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserInactive, "user account is not active")
 		return
 	}
 
-	// 4. return access token to user > Not sure this is correct
-	respParams := userResponseParameters{
-		ID:               user.ID,
-		PublicID:         user.PublicID,
-		CreatedAt:        user.CreatedAt,
-		UpdatedAt:        user.UpdatedAt,
-		Email:            user.Email,
-		FullName:         user.FullName,
-		IsAdmin:          user.IsAdmin,
-		IsActive:         user.IsActive,
-		UserAccessToken:  userAccessToken,
-		UserRefreshToken: newRefreshToken.Token,
-	}
+	// 4. return access token to user
+	respParams := userResponseParameters{}
+	respParams.populate(user, userAccessToken, session.Token)
 	jsonutils.WriteJSON(w, http.StatusOK, respParams)
-
-	// 5. set cookies for user
-	auth.SetAuthCookies(w, userAccessToken, newRefreshToken.Token, "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
 }
 
-func (cfg *ApiConfig) HandlerLogoutUser(w http.ResponseWriter, r *http.Request) {
-	// for revoking USER refresh token
+func (cfg *ApiConfig) HandlerLogoutUser(w http.ResponseWriter, r *http.Request) { // POST /api/logout
+	// for revoking USER refresh token of the current session. Other sessions stay logged in, see DELETE /api/sessions/{session_public_id}
 	// 1. get user from context
 	user, err := auth.UserFromContext(w, r, cfg.DB)
-	if err != nil { // error handling
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access /api/logout")
-		return
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
 	}
 
 	// 2. query to revoke refresh token
-	_, err = cfg.DB.RevokeRefreshTokenByUserPublicID(r.Context(), user.PublicID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) { // highly unexpected state
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no refresh token found for this user")
-			auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration) // so remove all cookies just in case
-			return
-		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database")
-			return
+	if sessionPublicID := auth.SessionPublicIDFromContext(r); sessionPublicID != "" {
+		_, err = cfg.DB.RevokeRefreshTokenByPublicID(r.Context(), sessionPublicID)
+		if errors.Is(err, sql.ErrNoRows) { // session already expired: nothing left to revoke
+			err = nil
 		}
+	} else {
+		// access tokens issued before session management do not identify their session, so all sessions are ended
+		_, err = cfg.DB.RevokeRefreshTokenByUserPublicID(r.Context(), user.PublicID)
+	}
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (RevokeRefreshToken in HandlerLogoutUser)")
+		return
 	}
 
 	// 3. empty cookies
 	auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
 	// 3.1 and send response with empty access token
-	respParams := userResponseParameters{
-		ID:               user.ID,
-		PublicID:         user.PublicID,
-		CreatedAt:        user.CreatedAt,
-		UpdatedAt:        user.UpdatedAt,
-		Email:            user.Email,
-		FullName:         user.FullName,
-		IsAdmin:          user.IsAdmin,
-		IsActive:         user.IsActive,
-		UserAccessToken:  "",
-		UserRefreshToken: "",
-	}
+	respParams := userResponseParameters{}
+	respParams.populate(user, "", "")
 	jsonutils.WriteJSON(w, http.StatusOK, respParams)
 
 }
@@ -303,14 +372,14 @@ func (cfg *ApiConfig) HandlerRevokeRefreshToken(w http.ResponseWriter, r *http.R
 	// 1. Get userID from URI
 	pathUserPublicID, err := strutils.GetPublicIDFromPathValue("user_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	// 2. Authenticate from context: get user and check if admin (and if URI == accessing user, redirect to /api/logout)
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access POST /api/revoke")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access POST /api/revoke")
 		return
 	} else if accessingUser.PublicID == pathUserPublicID { // in this case the user is sending a revoke request for themselves, which should be a logout instead
 		jsonutils.WriteJSON(w, http.StatusBadRequest, "user is trying to revoke self - this is done through POST /api/logout")
@@ -321,13 +390,21 @@ func (cfg *ApiConfig) HandlerRevokeRefreshToken(w http.ResponseWriter, r *http.R
 	}
 
 	// 3. query database cfg.DB.RevokeRefreshTokenByUserID
-	revokedTokens, err := cfg.DB.RevokeRefreshTokenByUserPublicID(r.Context(), pathUserPublicID)
+	var revokedTokens []database.RefreshToken
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		var err error
+		revokedTokens, err = q.RevokeRefreshTokenByUserPublicID(r.Context(), pathUserPublicID)
+		if err != nil {
+			return err
+		}
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionSessionRevokeAll, audit.EntityUser, pathUserPublicID, nil, map[string]int{"revoked_sessions": len(revokedTokens)})
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no valid refresh tokens found for this user")
+			jsonutils.WriteError(w, r, http.StatusNotFound, ErrSessionNotFound.Wrap(err), "no valid refresh tokens found for this user")
 			return
 		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying datatabase (RevokeRefreshTokenByUserID in HandlerRevokeRefreshToken)")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying datatabase (RevokeRefreshTokenByUserID in HandlerRevokeRefreshToken)")
 			return
 		}
 	}
@@ -349,21 +426,29 @@ func (cfg *ApiConfig) HandlerRevokeAllRefreshTokens(w http.ResponseWriter, r *ht
 	// 1. Authenticate from context: get user and check if admin
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access POST /api/revoke")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access POST /api/revoke")
 		return
 	} else if !accessingUser.IsAdmin {
-		jsonutils.WriteError(w, http.StatusForbidden, err, "non-admin users are not allowed to send POST requests to /api/revoke")
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "non-admin users are not allowed to send POST requests to /api/revoke")
 		return
 	}
 
 	// 2. query database cfg.DB.RevokeRefreshTokens
-	revokedTokens, err := cfg.DB.RevokeRefreshTokens(r.Context())
+	var revokedTokens []database.RefreshToken
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		var err error
+		revokedTokens, err = q.RevokeRefreshTokens(r.Context())
+		if err != nil {
+			return err
+		}
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionSessionRevokeAll, audit.EntitySession, "", nil, map[string]int{"revoked_sessions": len(revokedTokens)})
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no valid refresh tokens found")
+			jsonutils.WriteError(w, r, http.StatusNotFound, ErrSessionNotFound.Wrap(err), "no valid refresh tokens found")
 			return
 		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying datatabase (RevokeRefreshTokens in HandlerRevokeRefreshToken)")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying datatabase (RevokeRefreshTokens in HandlerRevokeRefreshToken)")
 			return
 		}
 	}
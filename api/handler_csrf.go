@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+type CSRFResponseParameters struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+// GET /api/csrf
+func (cfg *ApiConfig) HandlerGetCSRFToken(w http.ResponseWriter, r *http.Request) {
+	/*
+		Issues a CSRF token, both in the csrf_token cookie and in the response body. Clients authenticating with cookies
+		send it back in the X-CSRF-Token header on every POST, PUT, PATCH and DELETE request. POST /api/login issues a
+		token as well, so this is only needed when the cookie was lost.
+	*/
+
+	// 1. make token
+	token, err := auth.MakeCSRFToken(cfg.Secret)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error creating CSRF token (MakeCSRFToken in HandlerGetCSRFToken)")
+		return
+	}
+
+	// 2. set cookie and write response
+	auth.SetCSRFCookie(w, token, cfg.RefreshTokenDuration)
+	jsonutils.WriteJSON(w, http.StatusOK, CSRFResponseParameters{CSRFToken: token})
+}
+
+func (cfg *ApiConfig) checkCSRF(w http.ResponseWriter, r *http.Request) bool {
+	/*
+		Protects cookie authenticated requests against cross-site request forgery: unsafe methods need an allowed
+		Origin (or Referer) and a valid double-submitted CSRF token. Writes a 403 response and returns false if the
+		request is refused.
+	*/
+	if auth.IsSafeMethod(r.Method) {
+		return true
+	}
+	if err := auth.CheckOrigin(r, cfg.TrustedOrigins); err != nil {
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "request origin not allowed")
+		return false
+	}
+	if err := auth.CheckCSRFToken(r, cfg.Secret); err != nil {
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "CSRF token missing or invalid: send the csrf_token cookie value in the X-CSRF-Token header, see GET /api/csrf")
+		return false
+	}
+	return true
+}
@@ -2,34 +2,39 @@ package api
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"net/http"
 
+	"github.com/dcrauwels/goqueue/audit"
 	"github.com/dcrauwels/goqueue/auth"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
 	"github.com/dcrauwels/goqueue/strutils"
 	"github.com/google/uuid"
 )
 
+var ErrDeskNotFound = jsonutils.NewError(jsonutils.CodeDeskNotFound, "desk not found")
+
 type DesksPostRequestParameters struct {
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
+	Name             string         `json:"name" validate:"required,max=64"`
+	Description      sql.NullString `json:"description" validate:"max=1024"`
+	LocationPublicID string         `json:"location_public_id" validate:"required,ref=location"`
 }
 
 type DesksPutRequestParameters struct {
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
+	Name        string         `json:"name" validate:"required,max=64"`
+	Description sql.NullString `json:"description" validate:"max=1024"`
 	IsActive    bool           `json:"is_active"`
 }
 
 type DesksResponseParameters struct {
-	ID          uuid.UUID      `json:"id"`
-	Description sql.NullString `json:"description"`
-	IsActive    bool           `json:"is_active"`
-	PublicID    string         `json:"public_id"`
-	Name        string         `json:"name"`
+	ID               uuid.UUID      `json:"id"`
+	Description      sql.NullString `json:"description"`
+	IsActive         bool           `json:"is_active"`
+	PublicID         string         `json:"public_id"`
+	Name             string         `json:"name"`
+	LocationPublicID string         `json:"location_public_id"`
 }
 
 func (drp *DesksResponseParameters) Populate(d database.Desk) {
@@ -38,6 +43,7 @@ func (drp *DesksResponseParameters) Populate(d database.Desk) {
 	drp.IsActive = d.IsActive
 	drp.PublicID = d.PublicID
 	drp.Name = d.Name
+	drp.LocationPublicID = d.LocationPublicID
 }
 
 // POST /api/desks
@@ -45,23 +51,20 @@ func (cfg *ApiConfig) HandlerPostDesks(w http.ResponseWriter, r *http.Request) {
 	// 1. check auth -> admin only
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required for this endpoint")
 		return
 	} else if !accessingUser.IsActive {
-		jsonutils.WriteError(w, http.StatusForbidden, auth.ErrUserInactive, "user not active")
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserInactive, "user not active")
 		return
 
 	} else if !accessingUser.IsAdmin {
-		jsonutils.WriteError(w, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
 		return
 	}
 
 	// 2. get request data
 	req := DesksPostRequestParameters{}
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&req)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "JSON formatting invalid")
+	if !cfg.readRequest(w, r, &req) {
 		return
 	}
 
@@ -70,20 +73,33 @@ func (cfg *ApiConfig) HandlerPostDesks(w http.ResponseWriter, r *http.Request) {
 
 	// 4. run query CreateDesks
 	queryParams := database.CreateDesksParams{
-		PublicID:    dpid,
-		Name:        req.Name,
-		Description: req.Description,
+		PublicID:         dpid,
+		Name:             req.Name,
+		Description:      req.Description,
+		LocationPublicID: req.LocationPublicID,
 	}
 
-	result, err := cfg.DB.CreateDesks(r.Context(), queryParams)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (CreateDesks in HandlerPostDesks)")
+	response := DesksResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		if _, err := q.GetLocationByPublicID(r.Context(), req.LocationPublicID); err != nil {
+			return err
+		}
+		result, err := q.CreateDesks(r.Context(), queryParams)
+		if err != nil {
+			return err
+		}
+		response.Populate(result)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionDeskCreate, audit.EntityDesk, result.PublicID, nil, response)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "location_public_id does not identify a location")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateDesks in HandlerPostDesks)")
 		return
 	}
 
 	// 5. return result
-	response := DesksResponseParameters{}
-	response.Populate(result)
 	jsonutils.WriteJSON(w, http.StatusCreated, response)
 }
 
@@ -92,50 +108,88 @@ func (cfg *ApiConfig) HandlerPutDesksByPublicID(w http.ResponseWriter, r *http.R
 	// 1. check auth -> admin only
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required for this endpoint")
 		return
 	} else if !accessingUser.IsAdmin {
-		jsonutils.WriteError(w, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	} else if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, "If-Match header with the ETag of the desk required for this endpoint")
 		return
 	}
 
 	// 2. get path value
 	dpid, err := strutils.GetPublicIDFromPathValue("desk_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	// 3. get request body
 	request := DesksPutRequestParameters{}
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&request)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "JSON formatting invalid")
+	if !cfg.readRequest(w, r, &request) {
 		return
 	}
 
-	// 4. run query SetDesksByPublicID
+	// 4. run query SetDesksByPublicID. with an If-Match header, only if the desk is still the version the client has
 	queryParams := database.SetDesksByPublicIDParams{
 		PublicID:    dpid,
 		Name:        request.Name,
 		Description: request.Description,
 		IsActive:    request.IsActive,
 	}
-	desk, err := cfg.DB.SetDesksByPublicID(r.Context(), queryParams)
+	before, response := DesksResponseParameters{}, DesksResponseParameters{}
+	etag := ""
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		oldDesk, err := q.GetDesksByPublicID(r.Context(), dpid)
+		if err != nil {
+			return err
+		}
+		if !jsonutils.IfMatch(r, jsonutils.ETag(oldDesk.UpdatedAt)) {
+			return jsonutils.ErrPreconditionFailed
+		}
+		if r.Header.Get("If-Match") != "" {
+			// also when another request changes the desk between the two queries
+			queryParams.IfUpdatedAt = sql.NullTime{Time: oldDesk.UpdatedAt, Valid: true}
+		}
+		before.Populate(oldDesk)
+		desk, err := q.SetDesksByPublicID(r.Context(), queryParams)
+		if errors.Is(err, sql.ErrNoRows) && queryParams.IfUpdatedAt.Valid {
+			return jsonutils.ErrPreconditionFailed
+		} else if err != nil {
+			return err
+		}
+		etag = jsonutils.ETag(desk.UpdatedAt)
+		response.Populate(desk)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionDeskUpdate, audit.EntityDesk, desk.PublicID, before, response)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no desks found at specified public id")
+			jsonutils.WriteError(w, r, http.StatusNotFound, ErrDeskNotFound.Wrap(err), "no desks found at specified public id")
+			return
+		} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+			jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "desk was changed by someone else: get it again and retry with its current ETag")
 			return
 		}
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (SetDesksByPublicID in HandlerPutDesksByPublicID)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (SetDesksByPublicID in HandlerPutDesksByPublicID)")
 		return
 	}
 
 	// 5. return result
-	response := DesksResponseParameters{}
-	response.Populate(desk)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, etag, response)
+}
+
+// the columns GET /api/desks can be sorted by, the first one being the default
+var deskSorts = []string{"name", "created_at", "updated_at"}
+
+func deskCursor(d database.Desk, sort string) strutils.Cursor {
+	switch sort {
+	case "created_at":
+		return strutils.Cursor{Time: d.CreatedAt, PublicID: d.PublicID}
+	case "updated_at":
+		return strutils.Cursor{Time: d.UpdatedAt, PublicID: d.PublicID}
+	}
+	return strutils.Cursor{Text: d.Name, PublicID: d.PublicID}
 }
 
 // GET /api/desks
@@ -143,38 +197,65 @@ func (cfg *ApiConfig) HandlerGetDesks(w http.ResponseWriter, r *http.Request) {
 	// 1. check auth
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required for this endpoint")
 		return
 	} else if !accessingUser.IsActive {
-		jsonutils.WriteError(w, http.StatusForbidden, err, "accessing user not active")
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "accessing user not active")
 		return
 	}
 
 	// 2. get query parameters
-
-	params, err := strutils.QueryParameterToNullBool(r.URL.Query().Get("is_active"))
+	isActive, err := strutils.QueryParameterToNullBool(r.URL.Query().Get("is_active"))
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "invalid query parameter for is_active: only booleans are accepted")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid query parameter for is_active: only booleans are accepted")
 		return
 	}
+	page, err := strutils.QueryParametersToPage(r, deskSorts)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	location := strutils.QueryParameterToNullString(r.URL.Query().Get("location"))
 
-	// 3. run query ListDesks
-	desks, err := cfg.DB.ListDesks(r.Context(), params)
+	// 3. run query ListDesks. users other than admins only see the desks of their own locations
+	desks, err := cfg.DB.ListDesks(r.Context(), database.ListDesksParams{
+		IsActive:         isActive,
+		LocationPublicID: location,
+		MemberPublicID:   auth.LocationMemberFilter(accessingUser),
+		AfterPublicID:    page.AfterPublicID(),
+		Sort:             page.Sort,
+		Descending:       page.Descending,
+		AfterText:        page.AfterText(),
+		AfterTime:        page.AfterTime(),
+		RowLimit:         page.RowLimit(),
+	})
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no rows found (ListDesks in HandlerGetDesks)")
-		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (ListDesks in HandlerGetDesks)")
-		}
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (ListDesks in HandlerGetDesks)")
 		return
 	}
+	desks, next := strutils.NextPage(page, desks, deskCursor)
+	var total sql.NullInt64
+	if page.Total {
+		total.Int64, err = cfg.DB.CountDesks(r.Context(), database.CountDesksParams{
+			IsActive:         isActive,
+			LocationPublicID: location,
+			MemberPublicID:   auth.LocationMemberFilter(accessingUser),
+		})
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CountDesks in HandlerGetDesks)")
+			return
+		}
+		total.Valid = true
+	}
 
 	// 4. return result
 	response := make([]DesksResponseParameters, len(desks))
 	for i, d := range desks {
 		response[i].Populate(d)
 	}
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	strutils.SetPageHeaders(w, r, next, total)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
 
 }
 
@@ -183,7 +264,7 @@ func (cfg *ApiConfig) HandlerGetDesksByPublicID(w http.ResponseWriter, r *http.R
 	// 1. get path value
 	dpid, err := strutils.GetPublicIDFromPathValue("desk_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
@@ -191,9 +272,9 @@ func (cfg *ApiConfig) HandlerGetDesksByPublicID(w http.ResponseWriter, r *http.R
 	desk, err := cfg.DB.GetDesksByPublicID(r.Context(), dpid)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no rows found (GetDesksByPublicID in HandlerGetDesksByPublicID)")
+			jsonutils.WriteError(w, r, http.StatusNotFound, ErrDeskNotFound.Wrap(err), "no desks found at specified public id")
 		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetDesksByPublicID in HandlerGetDesksByPublicID)")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetDesksByPublicID in HandlerGetDesksByPublicID)")
 		}
 		return
 	}
@@ -201,5 +282,5 @@ func (cfg *ApiConfig) HandlerGetDesksByPublicID(w http.ResponseWriter, r *http.R
 	// 3. return result
 	response := DesksResponseParameters{}
 	response.Populate(desk)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(desk.UpdatedAt), response)
 }
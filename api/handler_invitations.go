@@ -0,0 +1,273 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/mailer"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+)
+
+type InvitationsPOSTRequestParameters struct {
+	Email    string `json:"email" validate:"required,max=254"`
+	FullName string `json:"full_name" validate:"max=128"`
+}
+
+type PasswordResetPOSTRequestParameters struct {
+	Email string `json:"email" validate:"required"`
+}
+
+type UserTokenRedeemRequestParameters struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (cfg *ApiConfig) issueUserToken(ctx context.Context, user database.User, kind string, validFor time.Duration) (string, error) {
+	/*
+		Creates a single-use token of the given kind for user and returns the raw token. Any earlier unused tokens of
+		the same kind for this user are invalidated, so only the most recent link works.
+	*/
+	err := cfg.DB.InvalidateUserTokens(ctx, database.InvalidateUserTokensParams{
+		UserPublicID: user.PublicID,
+		Kind:         kind,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	token, tokenHash, err := auth.MakeUserToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = cfg.DB.CreateUserToken(ctx, database.CreateUserTokenParams{
+		PublicID:     cfg.PublicIDGenerator(),
+		UserPublicID: user.PublicID,
+		Kind:         kind,
+		TokenHash:    tokenHash,
+		ExpiresAt:    time.Now().UTC().Add(validFor), // compared with NOW() in a column without time zone
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (cfg *ApiConfig) userTokenLink(path, token string) string {
+	// builds the link sent to the user, e.g. https://queue.example.org/reset-password?token=...
+	return fmt.Sprintf("%s%s?token=%s", cfg.PublicBaseURL, path, url.QueryEscape(token))
+}
+
+// POST /api/invitations (admin only)
+func (cfg *ApiConfig) HandlerPostInvitations(w http.ResponseWriter, r *http.Request) {
+	/*
+		Creates a new (non-admin) user account without a known password and mails an invitation link to the user.
+		The account can only be logged into after the invitation is accepted at POST /api/invitations/accept.
+	*/
+
+	// 1. check for admin status in accessing user
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, ErrNotAdmin, "non-admin user tried to request POST /api/invitations")
+		return
+	}
+
+	// 2. get request data
+	request := InvitationsPOSTRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	if err = strutils.ValidateEmail(request.Email); err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("email", err), "email formatting invalid: please use jdoe@provider.tld")
+		return
+	}
+
+	// 3. create user with a random password nobody knows
+	placeholderPassword, err := auth.MakeRefreshToken()
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error creating placeholder password (in HandlerPostInvitations)")
+		return
+	}
+	hashedPassword, err := cfg.PasswordHasher.Hash(placeholderPassword)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "password could not be hashed.")
+		return
+	}
+	var createdUser database.User
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		var err error
+		createdUser, err = q.CreateUser(r.Context(), database.CreateUserParams{
+			PublicID:       cfg.PublicIDGenerator(),
+			Email:          request.Email,
+			HashedPassword: hashedPassword,
+			FullName:       request.FullName,
+		})
+		if err != nil {
+			return err
+		}
+		after := UsersResponseParameters{}
+		after.Populate(createdUser)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionUserInvite, audit.EntityUser, createdUser.PublicID, nil, after)
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateUser in HandlerPostInvitations)")
+		return
+	}
+
+	// 4. issue invitation token and mail it
+	token, err := cfg.issueUserToken(r.Context(), createdUser, auth.UserTokenKindInvitation, time.Duration(cfg.InvitationTokenDuration)*time.Hour)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error creating invitation token (issueUserToken in HandlerPostInvitations)")
+		return
+	}
+	err = cfg.Mailer.Send(r.Context(), mailer.Message{
+		To:      createdUser.Email,
+		Subject: "You have been invited to goqueue",
+		Body: fmt.Sprintf("Hello %s,\n\nAn account has been created for you. Please choose a password within %d hours using the link below:\n\n%s\n",
+			createdUser.FullName, cfg.InvitationTokenDuration, cfg.userTokenLink("/accept-invitation", token)),
+	})
+	if err != nil {
+		// the account exists at this point, so an admin can still send a password reset instead
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "user created but invitation could not be sent (Send in HandlerPostInvitations)")
+		return
+	}
+
+	// 5. write response
+	response := UsersResponseParameters{}
+	response.Populate(createdUser)
+	jsonutils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// POST /api/invitations/accept (no auth required, token in body)
+func (cfg *ApiConfig) HandlerAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	cfg.redeemUserToken(w, r, auth.UserTokenKindInvitation)
+}
+
+// POST /api/password-reset (no auth required)
+func (cfg *ApiConfig) HandlerPostPasswordReset(w http.ResponseWriter, r *http.Request) {
+	/*
+		Mails a password reset link to the user with the provided email address. Always responds with 202 Accepted,
+		whether or not the address belongs to an (active) account, so the endpoint cannot be used to look up accounts.
+	*/
+	const acceptedMessage = "if an active account exists for this email address, a password reset link has been sent"
+
+	// 1. get request data
+	request := PasswordResetPOSTRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+
+	// 2. look up user
+	user, err := cfg.DB.GetUserByEmail(r.Context(), request.Email)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !user.IsActive) {
+		jsonutils.WriteJSON(w, http.StatusAccepted, acceptedMessage)
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetUserByEmail in HandlerPostPasswordReset)")
+		return
+	}
+
+	// 3. issue reset token and mail it
+	token, err := cfg.issueUserToken(r.Context(), user, auth.UserTokenKindPasswordReset, time.Duration(cfg.PasswordResetTokenDuration)*time.Minute)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error creating password reset token (issueUserToken in HandlerPostPasswordReset)")
+		return
+	}
+	err = cfg.Mailer.Send(r.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your goqueue password",
+		Body: fmt.Sprintf("Hello %s,\n\nA password reset was requested for your account. Use the link below within %d minutes to choose a new password:\n\n%s\n\nIf you did not request this, you can ignore this message.\n",
+			user.FullName, cfg.PasswordResetTokenDuration, cfg.userTokenLink("/reset-password", token)),
+	})
+	if err != nil {
+		log.Printf("error sending password reset mail to user %s: %v", user.PublicID, err)
+	}
+
+	// 4. write response
+	jsonutils.WriteJSON(w, http.StatusAccepted, acceptedMessage)
+}
+
+// POST /api/password-reset/confirm (no auth required, token in body)
+func (cfg *ApiConfig) HandlerConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	cfg.redeemUserToken(w, r, auth.UserTokenKindPasswordReset)
+}
+
+func (cfg *ApiConfig) redeemUserToken(w http.ResponseWriter, r *http.Request, kind string) {
+	/*
+		Shared logic for accepting invitations and confirming password resets: consumes a single-use token of the given
+		kind, sets the new password and revokes all existing refresh tokens for the user, logging out every session.
+	*/
+
+	// 1. get request data
+	request := UserTokenRedeemRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+
+	// 2. check new password before using up the token
+	hashedPassword, err := ProcessPassword(w, r, request.Password, cfg.PasswordPolicy, cfg.PasswordHasher) // already calls jsonutils.WriteError
+	if err != nil {
+		return
+	}
+
+	// 3. consume token, set password and revoke all refresh tokens in one transaction, so a failure leaves the token usable
+	action := audit.ActionUserPasswordReset
+	if kind == auth.UserTokenKindInvitation {
+		action = audit.ActionUserAcceptInvite
+	}
+	var user database.User
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		userToken, err := q.ConsumeUserToken(r.Context(), database.ConsumeUserTokenParams{
+			TokenHash: auth.HashUserToken(request.Token),
+			Kind:      kind,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.ErrUserTokenInvalid
+		} else if err != nil {
+			return err
+		}
+		user, err = q.SetUserPasswordByPublicID(r.Context(), database.SetUserPasswordByPublicIDParams{
+			PublicID:       userToken.UserPublicID,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+		revokedTokens, err := q.RevokeRefreshTokenByUserPublicID(r.Context(), user.PublicID)
+		if err != nil {
+			return err
+		}
+		return cfg.recordAudit(r, q, user.PublicID, action, audit.EntityUser, user.PublicID, nil, map[string]int{"revoked_sessions": len(revokedTokens)})
+	})
+	if errors.Is(err, auth.ErrUserTokenInvalid) {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "token is invalid, expired or already used")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (redeemUserToken)")
+		return
+	}
+
+	// 3.1 a user that was locked out can log in right away with the new password
+	if cfg.AccountLoginLimiter != nil {
+		if err = cfg.AccountLoginLimiter.Reset(r.Context(), auth.LoginAccountKey(user.Email)); err != nil {
+			log.Printf("error resetting login attempts for user %s: %v", user.PublicID, err)
+		}
+	}
+
+	// 4. write response
+	response := UsersResponseParameters{}
+	response.Populate(user)
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,356 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/google/uuid"
+)
+
+var ErrLocationMismatch = jsonutils.NewError(jsonutils.CodeLocationMismatch, "referenced entities belong to different locations")
+
+var ErrLocationNotFound = jsonutils.NewError(jsonutils.CodeLocationNotFound, "location not found")
+
+var ErrInvalidTimeZone = jsonutils.NewError(jsonutils.CodeInvalidTimeZone, "time zone is not a known IANA time zone name")
+
+type LocationsRequestParameters struct {
+	Name               string `json:"name" validate:"required,max=128"`
+	TimeZone           string `json:"time_zone" validate:"max=64"`                    // IANA name like Europe/Amsterdam, UTC if empty
+	StopIssuingMinutes int32  `json:"stop_issuing_minutes" validate:"min=0,max=1440"` // no tickets are issued in the last minutes before closing
+}
+
+func (lrp *LocationsRequestParameters) validate() error {
+	// fills in the default time zone and checks it. The closing rule is checked by its validate tag
+	if lrp.TimeZone == "" {
+		lrp.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(lrp.TimeZone); err != nil || lrp.TimeZone == "Local" {
+		return jsonutils.InvalidField("time_zone", ErrInvalidTimeZone)
+	}
+	return nil
+}
+
+type LocationsResponseParameters struct {
+	ID                 uuid.UUID `json:"id"`
+	PublicID           string    `json:"public_id"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+	Name               string    `json:"name"`
+	TimeZone           string    `json:"time_zone"`
+	StopIssuingMinutes int32     `json:"stop_issuing_minutes"`
+}
+
+func (lrp *LocationsResponseParameters) Populate(l database.Location) {
+	lrp.ID = l.ID
+	lrp.PublicID = l.PublicID
+	lrp.CreatedAt = l.CreatedAt
+	lrp.UpdatedAt = l.UpdatedAt
+	lrp.Name = l.Name
+	lrp.TimeZone = l.TimeZone
+	lrp.StopIssuingMinutes = l.StopIssuingMinutes
+}
+
+type UserLocationsRequestParameters struct {
+	LocationPublicIDs []string `json:"location_public_ids" validate:"ref=location"`
+}
+
+// the state of a user's location assignments as recorded in the audit log
+type userLocationsAuditState struct {
+	LocationPublicIDs []string `json:"location_public_ids"`
+}
+
+// POST /api/locations (admin only)
+func (cfg *ApiConfig) HandlerPostLocations(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get request data
+	request := LocationsRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	if err := request.validate(); err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "time_zone must be an IANA time zone name")
+		return
+	}
+
+	// 3. run query CreateLocation
+	response := LocationsResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		location, err := q.CreateLocation(r.Context(), database.CreateLocationParams{
+			PublicID:           cfg.PublicIDGenerator(),
+			Name:               request.Name,
+			TimeZone:           request.TimeZone,
+			StopIssuingMinutes: request.StopIssuingMinutes,
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(location)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionLocationCreate, audit.EntityLocation, location.PublicID, nil, response)
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateLocation in HandlerPostLocations)")
+		return
+	}
+
+	// 4. return result
+	jsonutils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// PUT /api/locations/{location_public_id} (admin only)
+func (cfg *ApiConfig) HandlerPutLocationsByPublicID(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	} else if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, "If-Match header with the ETag of the location required for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. get request body
+	request := LocationsRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	if err := request.validate(); err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "time_zone must be an IANA time zone name")
+		return
+	}
+
+	// 4. run query SetLocationByPublicID. with an If-Match header, only if the location is still the version the client has
+	before, response := LocationsResponseParameters{}, LocationsResponseParameters{}
+	etag := ""
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		oldLocation, err := q.GetLocationByPublicID(r.Context(), lpid)
+		if err != nil {
+			return err
+		}
+		if !jsonutils.IfMatch(r, jsonutils.ETag(oldLocation.UpdatedAt)) {
+			return jsonutils.ErrPreconditionFailed
+		}
+		before.Populate(oldLocation)
+		location, err := q.SetLocationByPublicID(r.Context(), database.SetLocationByPublicIDParams{
+			PublicID:           lpid,
+			Name:               request.Name,
+			TimeZone:           request.TimeZone,
+			StopIssuingMinutes: request.StopIssuingMinutes,
+		})
+		if err != nil {
+			return err
+		}
+		etag = jsonutils.ETag(location.UpdatedAt)
+		response.Populate(location)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionLocationUpdate, audit.EntityLocation, location.PublicID, before, response)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+		jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "location was changed by someone else: get it again and retry with its current ETag")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (SetLocationByPublicID in HandlerPutLocationsByPublicID)")
+		return
+	}
+
+	// 5. return result
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, etag, response)
+}
+
+// GET /api/locations
+func (cfg *ApiConfig) HandlerGetLocations(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required: kiosks need the locations to pick from)
+	// 1. run query GetLocations
+	locations, err := cfg.DB.GetLocations(r.Context())
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocations in HandlerGetLocations)")
+		return
+	}
+
+	// 2. return result
+	response := make([]LocationsResponseParameters, len(locations))
+	for i, l := range locations {
+		response[i].Populate(l)
+	}
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
+
+// GET /api/locations/{location_public_id}
+func (cfg *ApiConfig) HandlerGetLocationsByPublicID(w http.ResponseWriter, r *http.Request) {
+	// 1. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. run query GetLocationByPublicID
+	location, err := cfg.DB.GetLocationByPublicID(r.Context(), lpid)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocationByPublicID in HandlerGetLocationsByPublicID)")
+		return
+	}
+
+	// 3. return result
+	response := LocationsResponseParameters{}
+	response.Populate(location)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(location.UpdatedAt), response)
+}
+
+// GET /api/users/{user_public_id}/locations
+func (cfg *ApiConfig) HandlerGetUserLocations(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin or the user themselves
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	}
+	upid, err := strutils.GetPublicIDFromPathValue("user_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	if !accessingUser.IsAdmin && accessingUser.PublicID != upid {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "only admins can see the locations of other users")
+		return
+	}
+
+	// 2. run query GetLocationsByUserPublicID
+	var locations []database.Location
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		if _, err := q.GetUserByPublicID(r.Context(), upid); err != nil {
+			return err
+		}
+		locations, err = q.GetLocationsByUserPublicID(r.Context(), upid)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrUserNotFound.Wrap(err), "no users found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocationsByUserPublicID in HandlerGetUserLocations)")
+		return
+	}
+
+	// 3. return result
+	response := make([]LocationsResponseParameters, len(locations))
+	for i, l := range locations {
+		response[i].Populate(l)
+	}
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
+
+// PUT /api/users/{user_public_id}/locations (admin only)
+func (cfg *ApiConfig) HandlerPutUserLocations(w http.ResponseWriter, r *http.Request) {
+	/*
+		Replaces the locations a user is assigned to with the ones in the request. Admins have access to every location
+		regardless of their assignments.
+	*/
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value and request body
+	upid, err := strutils.GetPublicIDFromPathValue("user_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	request := UserLocationsRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+
+	// 3. replace the assignments
+	var locations []database.Location
+	var unknownLocation error
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		if _, err := q.GetUserByPublicID(r.Context(), upid); err != nil {
+			return err
+		}
+		oldLocations, err := q.GetLocationsByUserPublicID(r.Context(), upid)
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteUserLocations(r.Context(), upid); err != nil {
+			return err
+		}
+		for _, lpid := range request.LocationPublicIDs {
+			if _, err := q.GetLocationByPublicID(r.Context(), lpid); errors.Is(err, sql.ErrNoRows) {
+				unknownLocation = err
+				return err
+			} else if err != nil {
+				return err
+			}
+			err = q.AddUserLocation(r.Context(), database.AddUserLocationParams{UserPublicID: upid, LocationPublicID: lpid})
+			if err != nil {
+				return err
+			}
+		}
+		locations, err = q.GetLocationsByUserPublicID(r.Context(), upid)
+		if err != nil {
+			return err
+		}
+		before, after := userLocationsAuditState{}, userLocationsAuditState{}
+		for _, l := range oldLocations {
+			before.LocationPublicIDs = append(before.LocationPublicIDs, l.PublicID)
+		}
+		for _, l := range locations {
+			after.LocationPublicIDs = append(after.LocationPublicIDs, l.PublicID)
+		}
+		slices.Sort(before.LocationPublicIDs)
+		slices.Sort(after.LocationPublicIDs)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionUserSetLocations, audit.EntityUser, upid, before, after)
+	})
+	if unknownLocation != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, unknownLocation, "location_public_ids contains an unknown location")
+		return
+	} else if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrUserNotFound.Wrap(err), "no users found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (HandlerPutUserLocations)")
+		return
+	}
+
+	// 4. return result
+	response := make([]LocationsResponseParameters, len(locations))
+	for i, l := range locations {
+		response[i].Populate(l)
+	}
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/schedule"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrQueueClosed         = jsonutils.NewError(jsonutils.CodeQueueClosed, "queue is closed")
+	ErrQueueFull           = jsonutils.NewError(jsonutils.CodeQueueFull, "daily capacity of purpose reached")
+	ErrHolidayExists       = jsonutils.NewError(jsonutils.CodeHolidayExists, "location already has a holiday on this date")
+	ErrOpeningHourNotFound = jsonutils.NewError(jsonutils.CodeOpeningHourNotFound, "opening hour not found")
+	ErrHolidayNotFound     = jsonutils.NewError(jsonutils.CodeHolidayNotFound, "holiday not found")
+)
+
+type OpeningHoursRequestParameters struct {
+	PurposePublicID string `json:"purpose_public_id" validate:"ref=purpose"` // empty for the opening hours of the whole location
+	Weekday         int32  `json:"weekday" validate:"min=0,max=6"`           // 0 is Sunday
+	OpensAt         string `json:"opens_at" validate:"required"`             // HH:MM in the time zone of the location
+	ClosesAt        string `json:"closes_at" validate:"required"`            // HH:MM, 24:00 for midnight
+}
+
+type OpeningHoursResponseParameters struct {
+	ID               uuid.UUID      `json:"id"`
+	PublicID         string         `json:"public_id"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	LocationPublicID string         `json:"location_public_id"`
+	PurposePublicID  sql.NullString `json:"purpose_public_id"`
+	Weekday          int32          `json:"weekday"`
+	OpensAt          string         `json:"opens_at"`
+	ClosesAt         string         `json:"closes_at"`
+}
+
+func (ohrp *OpeningHoursResponseParameters) Populate(o database.OpeningHour) {
+	ohrp.ID = o.ID
+	ohrp.PublicID = o.PublicID
+	ohrp.CreatedAt = o.CreatedAt
+	ohrp.UpdatedAt = o.UpdatedAt
+	ohrp.LocationPublicID = o.LocationPublicID
+	ohrp.PurposePublicID = o.PurposePublicID
+	ohrp.Weekday = o.Weekday
+	ohrp.OpensAt = schedule.FormatClock(int(o.OpensAt))
+	ohrp.ClosesAt = schedule.FormatClock(int(o.ClosesAt))
+}
+
+type HolidaysRequestParameters struct {
+	Date string `json:"date" validate:"required"` // YYYY-MM-DD
+	Name string `json:"name" validate:"max=128"`
+}
+
+type HolidaysResponseParameters struct {
+	ID               uuid.UUID `json:"id"`
+	PublicID         string    `json:"public_id"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	LocationPublicID string    `json:"location_public_id"`
+	Date             string    `json:"date"`
+	Name             string    `json:"name"`
+}
+
+func (hrp *HolidaysResponseParameters) Populate(h database.Holiday) {
+	hrp.ID = h.ID
+	hrp.PublicID = h.PublicID
+	hrp.CreatedAt = h.CreatedAt
+	hrp.UpdatedAt = h.UpdatedAt
+	hrp.LocationPublicID = h.LocationPublicID
+	hrp.Date = h.HolidayDate.Format(time.DateOnly)
+	hrp.Name = h.Name
+}
+
+// QueueClosedResponseParameters is the body of the 409 response to POST /api/visitors when no ticket can be issued
+type QueueClosedResponseParameters struct {
+	jsonutils.Problem
+	NextOpeningAt *time.Time `json:"next_opening_at"` // null if the queue does not open within a year
+}
+
+func queueCalendar(ctx context.Context, q storage.Store, purpose database.Purpose) (schedule.Calendar, error) {
+	/*
+		The calendar tickets for purpose are issued by. Opening hours of the purpose itself replace those of its
+		location, and a location without any opening hours is always open. Holidays and the closing rule of the location
+		apply to all of its purposes.
+	*/
+	location, err := q.GetLocationByPublicID(ctx, purpose.LocationPublicID)
+	if err != nil {
+		return schedule.Calendar{}, err
+	}
+	tz, err := time.LoadLocation(location.TimeZone)
+	if err != nil {
+		return schedule.Calendar{}, err
+	}
+	calendar := schedule.Calendar{Location: tz, StopBeforeClose: time.Duration(location.StopIssuingMinutes) * time.Minute}
+
+	openingHours, err := q.GetOpeningHoursByLocationPublicID(ctx, location.PublicID)
+	if err != nil {
+		return schedule.Calendar{}, err
+	}
+	var locationPeriods, purposePeriods []schedule.Period
+	for _, o := range openingHours {
+		period := schedule.Period{Weekday: time.Weekday(o.Weekday), Opens: int(o.OpensAt), Closes: int(o.ClosesAt)}
+		if !o.PurposePublicID.Valid {
+			locationPeriods = append(locationPeriods, period)
+		} else if o.PurposePublicID.String == purpose.PublicID {
+			purposePeriods = append(purposePeriods, period)
+		}
+	}
+	calendar.Periods = locationPeriods
+	if len(purposePeriods) > 0 {
+		calendar.Periods = purposePeriods
+	}
+
+	holidays, err := q.GetHolidaysByLocationPublicID(ctx, location.PublicID)
+	if err != nil {
+		return schedule.Calendar{}, err
+	}
+	for _, h := range holidays {
+		calendar.Holidays = append(calendar.Holidays, h.HolidayDate)
+	}
+	return calendar, nil
+}
+
+func writeQueueClosed(w http.ResponseWriter, r *http.Request, err error, msg string, calendar schedule.Calendar, from time.Time) {
+	// writes a 409 response that tells the visitor when tickets are issued again
+	response := QueueClosedResponseParameters{Problem: jsonutils.NewProblem(w, r, http.StatusConflict, err, msg)}
+	if next, ok := calendar.NextOpening(from); ok {
+		response.NextOpeningAt = &next
+	}
+	jsonutils.WriteProblem(w, http.StatusConflict, response)
+}
+
+// GET /api/locations/{location_public_id}/opening-hours
+func (cfg *ApiConfig) HandlerGetOpeningHours(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required: kiosks show the opening hours)
+	// 1. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. run queries GetLocationByPublicID and GetOpeningHoursByLocationPublicID
+	if _, err := cfg.DB.GetLocationByPublicID(r.Context(), lpid); errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocationByPublicID in HandlerGetOpeningHours)")
+		return
+	}
+	openingHours, err := cfg.DB.GetOpeningHoursByLocationPublicID(r.Context(), lpid)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetOpeningHoursByLocationPublicID in HandlerGetOpeningHours)")
+		return
+	}
+
+	// 3. return result
+	response := make([]OpeningHoursResponseParameters, len(openingHours))
+	for i, o := range openingHours {
+		response[i].Populate(o)
+	}
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
+
+// POST /api/locations/{location_public_id}/opening-hours (admin only)
+func (cfg *ApiConfig) HandlerPostOpeningHours(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. get request data: weekday and times of day
+	request := OpeningHoursRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	opensAt, err := schedule.ParseClock(request.OpensAt)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("opens_at", err), "opens_at must be a time of day formatted as HH:MM")
+		return
+	}
+	closesAt, err := schedule.ParseClock(request.ClosesAt)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("closes_at", err), "closes_at must be a time of day formatted as HH:MM")
+		return
+	}
+	if request.Weekday < 0 || request.Weekday > 6 || closesAt <= opensAt {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, nil, "weekday must be 0 (Sunday) to 6 and closes_at must be after opens_at")
+		return
+	}
+
+	// 4. run query CreateOpeningHour. opening hours of a purpose must be in the location of that purpose
+	response := OpeningHoursResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		if _, err := q.GetLocationByPublicID(r.Context(), lpid); err != nil {
+			return err
+		}
+		if request.PurposePublicID != "" {
+			purpose, err := q.GetPurposesByPublicID(r.Context(), request.PurposePublicID)
+			if errors.Is(err, sql.ErrNoRows) || (err == nil && purpose.LocationPublicID != lpid) {
+				return ErrLocationMismatch
+			} else if err != nil {
+				return err
+			}
+		}
+		openingHour, err := q.CreateOpeningHour(r.Context(), database.CreateOpeningHourParams{
+			PublicID:         cfg.PublicIDGenerator(),
+			LocationPublicID: lpid,
+			PurposePublicID:  strutils.QueryParameterToNullString(request.PurposePublicID),
+			Weekday:          request.Weekday,
+			OpensAt:          int32(opensAt),
+			ClosesAt:         int32(closesAt),
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(openingHour)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionOpeningHourCreate, audit.EntityOpeningHour, openingHour.PublicID, nil, response)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if errors.Is(err, ErrLocationMismatch) {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "purpose_public_id does not identify a purpose of this location")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateOpeningHour in HandlerPostOpeningHours)")
+		return
+	}
+
+	// 5. return result
+	jsonutils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// DELETE /api/locations/{location_public_id}/opening-hours/{opening_hour_public_id} (admin only)
+func (cfg *ApiConfig) HandlerDeleteOpeningHoursByPublicID(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path values
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	ohpid, err := strutils.GetPublicIDFromPathValue("opening_hour_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. run query DeleteOpeningHourByPublicID
+	response := OpeningHoursResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		openingHour, err := q.GetOpeningHourByPublicID(r.Context(), ohpid)
+		if err != nil {
+			return err
+		} else if openingHour.LocationPublicID != lpid {
+			return sql.ErrNoRows
+		}
+		if err := q.DeleteOpeningHourByPublicID(r.Context(), ohpid); err != nil {
+			return err
+		}
+		response.Populate(openingHour)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionOpeningHourDelete, audit.EntityOpeningHour, openingHour.PublicID, response, nil)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrOpeningHourNotFound.Wrap(err), "no opening hours found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (DeleteOpeningHourByPublicID in HandlerDeleteOpeningHoursByPublicID)")
+		return
+	}
+
+	// 4. return the deleted opening hours
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GET /api/locations/{location_public_id}/holidays
+func (cfg *ApiConfig) HandlerGetHolidays(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required: kiosks show the days the location is closed)
+	// 1. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. run queries GetLocationByPublicID and GetHolidaysByLocationPublicID
+	if _, err := cfg.DB.GetLocationByPublicID(r.Context(), lpid); errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocationByPublicID in HandlerGetHolidays)")
+		return
+	}
+	holidays, err := cfg.DB.GetHolidaysByLocationPublicID(r.Context(), lpid)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetHolidaysByLocationPublicID in HandlerGetHolidays)")
+		return
+	}
+
+	// 3. return result
+	response := make([]HolidaysResponseParameters, len(holidays))
+	for i, h := range holidays {
+		response[i].Populate(h)
+	}
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
+
+// POST /api/locations/{location_public_id}/holidays (admin only)
+func (cfg *ApiConfig) HandlerPostHolidays(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. get request data: date and name
+	request := HolidaysRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	date, err := time.Parse(time.DateOnly, request.Date)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("date", err), "date must be formatted as YYYY-MM-DD")
+		return
+	}
+
+	// 4. run query CreateHoliday. a location has at most one holiday per date
+	response := HolidaysResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		if _, err := q.GetLocationByPublicID(r.Context(), lpid); err != nil {
+			return err
+		}
+		holidays, err := q.GetHolidaysByLocationPublicID(r.Context(), lpid)
+		if err != nil {
+			return err
+		}
+		for _, h := range holidays {
+			if h.HolidayDate.Format(time.DateOnly) == request.Date {
+				return ErrHolidayExists
+			}
+		}
+		holiday, err := q.CreateHoliday(r.Context(), database.CreateHolidayParams{
+			PublicID:         cfg.PublicIDGenerator(),
+			LocationPublicID: lpid,
+			HolidayDate:      date,
+			Name:             request.Name,
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(holiday)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionHolidayCreate, audit.EntityHoliday, holiday.PublicID, nil, response)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrLocationNotFound.Wrap(err), "no locations found at specified public id")
+		return
+	} else if errors.Is(err, ErrHolidayExists) {
+		jsonutils.WriteError(w, r, http.StatusConflict, err, "location already has a holiday on this date")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateHoliday in HandlerPostHolidays)")
+		return
+	}
+
+	// 5. return result
+	jsonutils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// DELETE /api/locations/{location_public_id}/holidays/{holiday_public_id} (admin only)
+func (cfg *ApiConfig) HandlerDeleteHolidaysByPublicID(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path values
+	lpid, err := strutils.GetPublicIDFromPathValue("location_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	hpid, err := strutils.GetPublicIDFromPathValue("holiday_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. run query DeleteHolidayByPublicID
+	response := HolidaysResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		holiday, err := q.GetHolidayByPublicID(r.Context(), hpid)
+		if err != nil {
+			return err
+		} else if holiday.LocationPublicID != lpid {
+			return sql.ErrNoRows
+		}
+		if err := q.DeleteHolidayByPublicID(r.Context(), hpid); err != nil {
+			return err
+		}
+		response.Populate(holiday)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionHolidayDelete, audit.EntityHoliday, holiday.PublicID, response, nil)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrHolidayNotFound.Wrap(err), "no holidays found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (DeleteHolidayByPublicID in HandlerDeleteHolidaysByPublicID)")
+		return
+	}
+
+	// 4. return the deleted holiday
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
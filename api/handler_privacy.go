@@ -0,0 +1,141 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+)
+
+// VisitorExportResponseParameters is everything goqueue keeps about a single visitor, for data subject access requests.
+type VisitorExportResponseParameters struct {
+	ExportedAt  time.Time                       `json:"exported_at"`
+	Visitor     VisitorsResponseParameters      `json:"visitor"`
+	ServiceLogs []ServicelogsResponseParameters `json:"service_logs"`
+	AuditEvents []AuditEventsResponseParameters `json:"audit_events"`
+}
+
+// GET /api/visitors/{visitor_public_id}/export (admin only)
+func (cfg *ApiConfig) HandlerGetVisitorExport(w http.ResponseWriter, r *http.Request) {
+	/*
+		Exports the visitor together with its service logs and the audit events about it as one JSON document. The
+		rows are read in a single transaction, so that they are consistent with each other.
+	*/
+
+	// 1. get target visitor from URI
+	pvid, err := strutils.GetPublicIDFromPathValue("visitor_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. check for admin status in accessing user
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "non-admin user tried to request GET /api/visitors/{visitor_public_id}/export")
+		return
+	}
+
+	// 3. run queries
+	var visitor database.Visitor
+	var serviceLogs []database.ServiceLog
+	var events []database.AuditEvent
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		var err error
+		visitor, err = q.GetVisitorsByPublicID(r.Context(), pvid)
+		if err != nil {
+			return err
+		}
+		serviceLogs, err = q.GetServiceLogsByVisitorPublicID(r.Context(), pvid)
+		if err != nil {
+			return err
+		}
+		events, err = q.ListAuditEvents(r.Context(), database.ListAuditEventsParams{
+			EntityType:     sql.NullString{String: audit.EntityVisitor, Valid: true},
+			EntityPublicID: sql.NullString{String: pvid, Valid: true},
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrVisitorNotFound.Wrap(err), "visitor not found")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (HandlerGetVisitorExport)")
+		return
+	}
+
+	// 4. write response
+	response := VisitorExportResponseParameters{
+		ExportedAt:  time.Now(),
+		ServiceLogs: make([]ServicelogsResponseParameters, len(serviceLogs)),
+		AuditEvents: make([]AuditEventsResponseParameters, len(events)),
+	}
+	response.Visitor.Populate(visitor)
+	for i, sl := range serviceLogs {
+		response.ServiceLogs[i].Populate(sl)
+	}
+	for i, e := range events {
+		response.AuditEvents[i].Populate(e)
+	}
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
+
+// POST /api/visitors/{visitor_public_id}/erase (admin only)
+func (cfg *ApiConfig) HandlerPostVisitorErase(w http.ResponseWriter, r *http.Request) {
+	/*
+		Erases the personal data of a visitor: its name and contact details. The visitor itself is kept, like its
+		service logs, so that ticket numbers, purposes and waiting times still count in statistics. The erasure is
+		recorded in the audit log, which never contained those in the first place. Erasing a visitor twice is harmless.
+	*/
+
+	// 1. get target visitor from URI
+	pvid, err := strutils.GetPublicIDFromPathValue("visitor_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. check for admin status in accessing user
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "non-admin user tried to request POST /api/visitors/{visitor_public_id}/erase")
+		return
+	}
+
+	// 3. run query, recording it in the audit log
+	var erasedVisitor database.Visitor
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		oldVisitor, err := q.GetVisitorsByPublicID(r.Context(), pvid)
+		if err != nil {
+			return err
+		}
+		erasedVisitor, err = q.AnonymizeVisitorByPublicID(r.Context(), pvid)
+		if err != nil {
+			return err
+		}
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionVisitorErase, audit.EntityVisitor, pvid, visitorAuditState(oldVisitor), visitorAuditState(erasedVisitor))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrVisitorNotFound.Wrap(err), "visitor not found")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (AnonymizeVisitorByPublicID in HandlerPostVisitorErase)")
+		return
+	}
+
+	// 4. write response
+	response := VisitorsResponseParameters{}
+	response.Populate(erasedVisitor)
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
@@ -2,31 +2,41 @@ package api
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/dcrauwels/goqueue/audit"
 	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/i18n"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
 	"github.com/dcrauwels/goqueue/strutils"
 	"github.com/google/uuid"
 )
 
 type PurposesRequestParameters struct {
-	PurposeName     string        `json:"purpose_name"`
-	ParentPurposeID uuid.NullUUID `json:"parent_purpose_id"`
+	PurposeName      string        `json:"purpose_name" validate:"required,max=128"`
+	ParentPurposeID  uuid.NullUUID `json:"parent_purpose_id"`
+	LocationPublicID string        `json:"location_public_id" validate:"ref=location"` // only read by POST: purposes cannot move to another location
+	DailyCapacity    sql.NullInt32 `json:"daily_capacity"`                             // tickets issued per day at most, null for no limit
 }
 
 type PurposesResponseParameters struct {
-	ID              uuid.UUID     `json:"id"`
-	PublicID        string        `json:"public_id"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
-	PurposeName     string        `json:"purpose_name"`
-	ParentPurposeID uuid.NullUUID `json:"parent_purpose_id"`
+	ID               uuid.UUID         `json:"id"`
+	PublicID         string            `json:"public_id"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	PurposeName      string            `json:"purpose_name"`
+	ParentPurposeID  uuid.NullUUID     `json:"parent_purpose_id"`
+	LocationPublicID string            `json:"location_public_id"`
+	DailyCapacity    sql.NullInt32     `json:"daily_capacity"`
+	Translations     map[string]string `json:"translations,omitempty"` // purpose_name in other languages, keyed by language tag
 }
 
 func (prp *PurposesResponseParameters) Populate(p database.Purpose) {
@@ -36,9 +46,48 @@ func (prp *PurposesResponseParameters) Populate(p database.Purpose) {
 	prp.UpdatedAt = p.UpdatedAt
 	prp.PurposeName = p.PurposeName
 	prp.ParentPurposeID = p.ParentPurposeID
+	prp.LocationPublicID = p.LocationPublicID
+	prp.DailyCapacity = p.DailyCapacity
 }
 
-var ErrNotAdmin = errors.New("user does not have admin status")
+var ErrNotAdmin = jsonutils.NewError(jsonutils.CodeNotAdmin, "user does not have admin status")
+
+var ErrPurposeNotFound = jsonutils.NewError(jsonutils.CodePurposeNotFound, "purpose not found")
+
+var ErrInvalidDailyCapacity = jsonutils.NewError(jsonutils.CodeInvalidDailyCapacity, "daily capacity cannot be negative")
+
+var ErrInvalidTranslation = jsonutils.NewError(jsonutils.CodeInvalidTranslation, "translations must be keyed by language tags like nl or pt-BR and cannot be empty")
+
+type PurposeTranslationsRequestParameters struct {
+	Translations map[string]string `json:"translations"` // replaces all translations of the purpose
+}
+
+func purposeTranslations(translations []database.PurposeTranslation) map[string]string {
+	// the translations of a purpose keyed by language, nil if it has none
+	var result map[string]string
+	for _, t := range translations {
+		if result == nil {
+			result = map[string]string{}
+		}
+		result[t.Language] = t.Name
+	}
+	return result
+}
+
+func localizedPurposeName(purpose database.Purpose, translations map[string]string, acceptLanguage string) string {
+	// the name of purpose in the language asked for by an Accept-Language header, purpose_name if it has no translation in that language
+	if tag, ok := i18n.Match(acceptLanguage, slices.Sorted(maps.Keys(translations))); ok {
+		return translations[tag]
+	}
+	return purpose.PurposeName
+}
+
+func validateDailyCapacity(c sql.NullInt32) error {
+	if c.Valid && c.Int32 < 0 {
+		return jsonutils.InvalidField("daily_capacity", ErrInvalidDailyCapacity)
+	}
+	return nil
+}
 
 // Helper function that handles the common logic
 func handlePurposeOperation[T any](
@@ -47,38 +96,85 @@ func handlePurposeOperation[T any](
 	r *http.Request,
 	operation string, // http operation name (POST, PUT, GET etc.) for error messages
 	requestPtr *T, // pointer to request parameter struct (like PurposesPutRequestParameters etc.)
-	dbQuery func() (database.Purpose, error), // function to execute the database query, so either cfg.DB.CreatePurpose() or cfg.DB.SetPurpose()
+	targetPublicID string, // public ID of the purpose being changed, empty when creating one
+	dbQuery func(q storage.Store, ifUpdatedAt sql.NullTime) (database.Purpose, error), // function to execute the database query, so either q.CreatePurpose() or q.SetPurpose(). ifUpdatedAt is the version of the purpose the If-Match header asks for
 ) {
 	/*
-		This function provides a template for PUT and POST operations to the /api/purposes endpoint.
+		This function provides a template for PUT and POST operations to the /api/purposes endpoint. The query runs in a
+		transaction together with writing the audit event. Changes to an existing purpose honour If-Match.
 	*/
 	// 1. auth for access: user, isadmin
 	user, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, fmt.Sprintf("user authorization is required to request %s /api/purposes", operation))
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, fmt.Sprintf("user authorization is required to request %s /api/purposes", operation))
 		return
 	} else if !user.IsAdmin {
-		jsonutils.WriteError(w, http.StatusForbidden, ErrNotAdmin, fmt.Sprintf("non-admin user tried to request %s /api/purposes", operation))
+		jsonutils.WriteError(w, r, http.StatusForbidden, ErrNotAdmin, fmt.Sprintf("non-admin user tried to request %s /api/purposes", operation))
+		return
+	} else if targetPublicID != "" && cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, fmt.Sprintf("If-Match header with the ETag of the purpose required to request %s /api/purposes", operation))
 		return
 	}
 
 	// 2. read request (delegated to caller)
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(requestPtr)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, fmt.Sprintf("user provided invalid JSON in a request to %s /api/purposes", operation))
+	if !cfg.readRequest(w, r, requestPtr) {
 		return
 	}
 
-	// 3. query database (delegated to caller)
-	result, err := dbQuery()
-	if errors.Is(err, sql.ErrNoRows) {
+	// 3. query database (delegated to caller) and record the change in the audit log
+	response := PurposesResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		var before any // nil for creations
+		var ifUpdatedAt sql.NullTime
+		action := audit.ActionPurposeCreate
+		if targetPublicID != "" {
+			oldPurpose, err := q.GetPurposesByPublicID(r.Context(), targetPublicID)
+			if err != nil {
+				return err
+			}
+			if !jsonutils.IfMatch(r, jsonutils.ETag(oldPurpose.UpdatedAt)) {
+				return jsonutils.ErrPreconditionFailed
+			}
+			if r.Header.Get("If-Match") != "" {
+				ifUpdatedAt = sql.NullTime{Time: oldPurpose.UpdatedAt, Valid: true}
+			}
+			beforeResponse := PurposesResponseParameters{}
+			beforeResponse.Populate(oldPurpose)
+			before, action = beforeResponse, audit.ActionPurposeUpdate
+		}
+		result, err := dbQuery(q, ifUpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) && ifUpdatedAt.Valid {
+			return jsonutils.ErrPreconditionFailed // changed by another request in the meantime
+		} else if err != nil {
+			return err
+		}
+		if result.ParentPurposeID.Valid {
+			parent, err := q.GetPurposesByID(r.Context(), result.ParentPurposeID.UUID)
+			if err != nil {
+				return err
+			} else if parent.LocationPublicID != result.LocationPublicID {
+				return ErrLocationMismatch
+			}
+		}
+		response.Populate(result)
+		return cfg.recordAudit(r, q, user.PublicID, action, audit.EntityPurpose, result.PublicID, before, response)
+	})
+	if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+		jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, fmt.Sprintf("purpose was changed by someone else when requesting %s /api/purposes: get it again and retry with its current ETag", operation))
+		return
+	} else if errors.Is(err, ErrLocationMismatch) {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, fmt.Sprintf("parent purpose belongs to another location when requesting %s /api/purposes", operation))
+		return
+	} else if errors.Is(err, ErrInvalidDailyCapacity) {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, fmt.Sprintf("daily_capacity cannot be negative when requesting %s /api/purposes", operation))
+		return
+	} else if errors.Is(err, sql.ErrNoRows) {
 		switch operation {
 		case "POST":
-			jsonutils.WriteError(w, http.StatusBadRequest, err, "user provided invalid parent_purpose_id when requesting POST /api/purposes")
+			jsonutils.WriteError(w, r, http.StatusBadRequest, err, "user provided invalid location_public_id or parent_purpose_id when requesting POST /api/purposes")
 			return
 		case "PUT":
-			jsonutils.WriteError(w, http.StatusBadRequest, err, "user provided invalid public_id or parent_purpose_id when requesting PUT /api/purposes")
+			jsonutils.WriteError(w, r, http.StatusBadRequest, err, "user provided invalid public_id or parent_purpose_id when requesting PUT /api/purposes")
 			return
 		}
 	} else if err != nil {
@@ -90,14 +186,12 @@ func handlePurposeOperation[T any](
 			dbFuncName = "SetPurpose"
 		}
 
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, fmt.Sprintf("error querying database (%s)", dbFuncName))
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, fmt.Sprintf("error querying database (%s)", dbFuncName))
 		return
 	}
 
 	// 4. write response
-	response := PurposesResponseParameters{}
-	response.Populate(result)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(response.UpdatedAt), response)
 }
 
 // POST /api/purposes (admin only)
@@ -106,14 +200,23 @@ func (cfg *ApiConfig) HandlerPostPurposes(w http.ResponseWriter, r *http.Request
 
 	handlePurposeOperation(cfg, w, r, "POST",
 		request,
+		"",
 		// Database operation function
-		func() (database.Purpose, error) {
+		func(q storage.Store, _ sql.NullTime) (database.Purpose, error) {
+			if err := validateDailyCapacity(request.DailyCapacity); err != nil {
+				return database.Purpose{}, err
+			}
+			if _, err := q.GetLocationByPublicID(r.Context(), request.LocationPublicID); err != nil {
+				return database.Purpose{}, err
+			}
 			queryParams := database.CreatePurposeParams{
-				PublicID:        cfg.PublicIDGenerator(),
-				PurposeName:     request.PurposeName,
-				ParentPurposeID: request.ParentPurposeID,
+				PublicID:         cfg.PublicIDGenerator(),
+				PurposeName:      request.PurposeName,
+				ParentPurposeID:  request.ParentPurposeID,
+				LocationPublicID: request.LocationPublicID,
+				DailyCapacity:    request.DailyCapacity,
 			}
-			return cfg.DB.CreatePurpose(r.Context(), queryParams)
+			return q.CreatePurpose(r.Context(), queryParams)
 		},
 	)
 }
@@ -125,65 +228,190 @@ func (cfg *ApiConfig) HandlerPutPurposesByID(w http.ResponseWriter, r *http.Requ
 	// retrieve request ID
 	ppid, err := strutils.GetPublicIDFromPathValue("purpose_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	handlePurposeOperation(cfg, w, r, "PUT",
 		// Decoder function
 		request,
+		ppid,
 		// Database operation function
-		func() (database.Purpose, error) {
+		func(q storage.Store, ifUpdatedAt sql.NullTime) (database.Purpose, error) {
+			if err := validateDailyCapacity(request.DailyCapacity); err != nil {
+				return database.Purpose{}, err
+			}
 			queryParams := database.SetPurposeByPublicIDParams{
 				PublicID:        ppid,
 				PurposeName:     request.PurposeName,
 				ParentPurposeID: request.ParentPurposeID,
+				DailyCapacity:   request.DailyCapacity,
+				IfUpdatedAt:     ifUpdatedAt,
 			}
-			return cfg.DB.SetPurposeByPublicID(r.Context(), queryParams)
+			return q.SetPurposeByPublicID(r.Context(), queryParams)
 		},
 	)
 }
 
-func (cfg *ApiConfig) HandlerGetPurposes(w http.ResponseWriter, r *http.Request) { // GET /api/purposes
+func (cfg *ApiConfig) HandlerGetPurposes(w http.ResponseWriter, r *http.Request) { // GET /api/purposes[?location=]
 	// (no authentication or request body required)
-	// 1. run query
-	purposes, err := cfg.DB.GetPurposes(r.Context())
+	// 1. run query, for a single location if one is asked for
+	var purposes []database.Purpose
+	var err error
+	if location := r.URL.Query().Get("location"); location != "" {
+		purposes, err = cfg.DB.GetPurposesByLocationPublicID(r.Context(), location)
+	} else {
+		purposes, err = cfg.DB.GetPurposes(r.Context())
+	}
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "no purposes found in database when requesting GET /api/purposes")
+		jsonutils.WriteError(w, r, http.StatusNotFound, err, "no purposes found in database when requesting GET /api/purposes")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetPurposes)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposes)")
 		return
 	}
 
+	translations, err := cfg.DB.GetPurposeTranslations(r.Context())
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposeTranslations)")
+		return
+	}
+	byPurpose := map[string][]database.PurposeTranslation{}
+	for _, t := range translations {
+		byPurpose[t.PurposePublicID] = append(byPurpose[t.PurposePublicID], t)
+	}
+
 	// 2. write response
 	response := make([]PurposesResponseParameters, len(purposes))
 	for i, u := range purposes {
 		response[i].Populate(u)
+		response[i].Translations = purposeTranslations(byPurpose[u.PublicID])
 	}
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
 }
 
 func (cfg *ApiConfig) HandlerGetPurposesByID(w http.ResponseWriter, r *http.Request) { // GEt /api/purposes/{purpose_public_id}
 	// 1. get purpose ID from endpoint path value
 	ppid, err := strutils.GetPublicIDFromPathValue("purpose_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	// 2. run query
 	purpose, err := cfg.DB.GetPurposesByPublicID(r.Context(), ppid)
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "no purposes found in database when requesting GET /api/purpose/{purpose_id}")
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrPurposeNotFound.Wrap(err), "no purposes found at specified public id")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetPurposesByID)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposesByID)")
+		return
+	}
+
+	translations, err := cfg.DB.GetPurposeTranslationsByPurposePublicID(r.Context(), ppid)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposeTranslationsByPurposePublicID)")
 		return
 	}
 
 	// 3. write response
 	var response PurposesResponseParameters
 	response.Populate(purpose)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	response.Translations = purposeTranslations(translations)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(purpose.UpdatedAt), response)
+}
+
+// PUT /api/purposes/{purpose_public_id}/translations (admin only)
+func (cfg *ApiConfig) HandlerPutPurposeTranslations(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	} else if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, "If-Match header with the ETag of the purpose required for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	ppid, err := strutils.GetPublicIDFromPathValue("purpose_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. get request data: the translations, keyed by language tag
+	request := PurposeTranslationsRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	for language, name := range request.Translations {
+		if !i18n.ValidTag(language) || strings.TrimSpace(name) == "" {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("translations."+language, ErrInvalidTranslation), "invalid translation for language "+language)
+			return
+		}
+	}
+
+	// 4. replace the translations. The purpose itself is touched as well, so its ETag changes. With an If-Match header,
+	// only if the purpose is still the version the client has
+	response := PurposesResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		purpose, err := q.GetPurposesByPublicID(r.Context(), ppid)
+		if err != nil {
+			return err
+		}
+		if !jsonutils.IfMatch(r, jsonutils.ETag(purpose.UpdatedAt)) {
+			return jsonutils.ErrPreconditionFailed
+		}
+		oldTranslations, err := q.GetPurposeTranslationsByPurposePublicID(r.Context(), ppid)
+		if err != nil {
+			return err
+		}
+		before := PurposesResponseParameters{}
+		before.Populate(purpose)
+		before.Translations = purposeTranslations(oldTranslations)
+
+		if err := q.DeletePurposeTranslationsByPurposePublicID(r.Context(), ppid); err != nil {
+			return err
+		}
+		var translations []database.PurposeTranslation
+		for _, language := range slices.Sorted(maps.Keys(request.Translations)) {
+			t, err := q.CreatePurposeTranslation(r.Context(), database.CreatePurposeTranslationParams{
+				PurposePublicID: ppid,
+				Language:        language,
+				Name:            strings.TrimSpace(request.Translations[language]),
+			})
+			if err != nil {
+				return err
+			}
+			translations = append(translations, t)
+		}
+		purpose, err = q.SetPurposeByPublicID(r.Context(), database.SetPurposeByPublicIDParams{
+			PublicID:        purpose.PublicID,
+			PurposeName:     purpose.PurposeName,
+			ParentPurposeID: purpose.ParentPurposeID,
+			DailyCapacity:   purpose.DailyCapacity,
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(purpose)
+		response.Translations = purposeTranslations(translations)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionPurposeSetTranslations, audit.EntityPurpose, ppid, before, response)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrPurposeNotFound.Wrap(err), "no purposes found at specified public id")
+		return
+	} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+		jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "purpose was changed by someone else: get it again and retry with its current ETag")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreatePurposeTranslation in HandlerPutPurposeTranslations)")
+		return
+	}
+
+	// 5. return the purpose with its translations
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(response.UpdatedAt), response)
 }
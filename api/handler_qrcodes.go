@@ -0,0 +1,89 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/qrcode"
+	"github.com/dcrauwels/goqueue/strutils"
+)
+
+const defaultQRCodeScale = 8 // pixels per module
+
+func (cfg *ApiConfig) visitorStatusURL(visitorPublicID string) string {
+	// the page a visitor follows their turn on, which the QR codes for them link to
+	base := cfg.StatusPageURL
+	if base == "" {
+		base = cfg.PublicBaseURL + "/visitors/"
+	}
+	return base + url.PathEscape(visitorPublicID)
+}
+
+func (cfg *ApiConfig) visitorQRCode(visitorPublicID string) (*qrcode.Code, error) {
+	// medium error correction, like on printed tickets, survives a creased ticket or a scratched screen
+	return qrcode.Encode([]byte(cfg.visitorStatusURL(visitorPublicID)), qrcode.Medium)
+}
+
+// GET /api/visitors/{visitor_public_id}/qrcode[?format=png|svg][&scale=]
+func (cfg *ApiConfig) HandlerGetVisitorQRCode(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required, like GET /api/visitors/{visitor_public_id})
+	// 1. get visitor ID from endpoint and the image format from the query
+	pvid, err := strutils.GetPublicIDFromPathValue("visitor_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "png"
+	} else if format != "png" && format != "svg" {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, nil, "query parameter 'format' takes png or svg")
+		return
+	}
+	scale := defaultQRCodeScale
+	if s := r.URL.Query().Get("scale"); s != "" {
+		scale, err = strconv.Atoi(s)
+		if err != nil || scale < 1 || scale > qrcode.MaxScale {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'scale' takes integers from 1 to "+strconv.Itoa(qrcode.MaxScale))
+			return
+		}
+	}
+
+	// 2. the visitor must exist, so unknown IDs get no code
+	_, err = cfg.DB.GetVisitorsByPublicID(r.Context(), pvid)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrVisitorNotFound.Wrap(err), "visitor not found in database")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetVisitorsByPublicID in HandlerGetVisitorQRCode)")
+		return
+	}
+
+	// 3. render and write response
+	code, err := cfg.visitorQRCode(pvid)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error encoding QR code")
+		return
+	}
+	var body []byte
+	contentType := "image/svg+xml"
+	if format == "png" {
+		body, err = code.PNG(scale)
+		contentType = "image/png"
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error rendering QR code")
+			return
+		}
+	} else {
+		body = code.SVG(scale)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400") // the status URL of a visitor does not change
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
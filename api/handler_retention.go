@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/retention"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+)
+
+// POST /api/retention (admin only)
+func (cfg *ApiConfig) HandlerPostRetention(w http.ResponseWriter, r *http.Request) {
+	/*
+		Applies the visitor retention policy right away, instead of waiting for the scheduled run. With the dry_run=true
+		query parameter nothing is changed and the response reports what would have been.
+	*/
+
+	// 1. check for admin status in accessing user
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "non-admin user tried to request POST /api/retention")
+		return
+	}
+
+	// 2. get query parameters
+	dryRun, err := strutils.QueryParameterToNullBool(r.URL.Query().Get("dry_run"))
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid query parameter for dry_run: only booleans are accepted")
+		return
+	}
+
+	// 3. run retention policy, recording it in the audit log
+	report, err := retention.Run(r.Context(), cfg.DB, cfg.RetentionPolicy, time.Now().UTC(), dryRun.Bool, func(q storage.Store, report retention.Report) error {
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionVisitorRetention, audit.EntityVisitor, "", nil, report)
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (retention.Run in HandlerPostRetention)")
+		return
+	}
+
+	// 4. return report
+	jsonutils.WriteJSON(w, http.StatusOK, report)
+}
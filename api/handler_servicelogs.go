@@ -1,23 +1,30 @@
 package api
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"time"
 
+	"github.com/dcrauwels/goqueue/announce"
+	"github.com/dcrauwels/goqueue/audit"
 	"github.com/dcrauwels/goqueue/auth"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/notify"
+	"github.com/dcrauwels/goqueue/storage"
 	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/dcrauwels/goqueue/webhook"
 	"github.com/google/uuid"
 )
 
+var ErrServiceLogNotFound = jsonutils.NewError(jsonutils.CodeServiceLogNotFound, "service log not found")
+
 type ServicelogsPOSTRequestParameters struct {
-	VisitorPublicID string `json:"visitor_public_id"`
-	UserPublicID    string `json:"user_public_id"`
-	DeskPublicID    string `json:"desk_public_id"`
+	VisitorPublicID string `json:"visitor_public_id" validate:"required,ref=visitor"`
+	UserPublicID    string `json:"user_public_id" validate:"required,ref=user"`
+	DeskPublicID    string `json:"desk_public_id" validate:"required,ref=desk"`
 }
 
 type ServicelogsPUTRequestParameters struct {
@@ -26,15 +33,22 @@ type ServicelogsPUTRequestParameters struct {
 }
 
 type ServicelogsResponseParameters struct {
-	ID              uuid.UUID `json:"id"`
-	PublicID        string    `json:"public_id"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	VisitorPublicID string    `json:"visitor_public_id"`
-	UserPublicID    string    `json:"user_public_id"`
-	DeskPublicID    string    `json:"desk_public_id"`
-	CalledAt        time.Time `json:"called_at"`
-	IsActive        bool      `json:"is_active"`
+	ID               uuid.UUID `json:"id"`
+	PublicID         string    `json:"public_id"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	VisitorPublicID  string    `json:"visitor_public_id"`
+	UserPublicID     string    `json:"user_public_id"`
+	DeskPublicID     string    `json:"desk_public_id"`
+	CalledAt         time.Time `json:"called_at"`
+	IsActive         bool      `json:"is_active"`
+	LocationPublicID string    `json:"location_public_id"`
+}
+
+// ServicelogsWebhookData is the data of visitor.called and visitor.served webhook events
+type ServicelogsWebhookData struct {
+	ServiceLog    ServicelogsResponseParameters `json:"service_log"`
+	Announcements []announce.Announcement       `json:"announcements,omitempty"` // visitor.called only: what display screens say
 }
 
 func (slrp *ServicelogsResponseParameters) Populate(sl database.ServiceLog) {
@@ -47,45 +61,148 @@ func (slrp *ServicelogsResponseParameters) Populate(sl database.ServiceLog) {
 	slrp.DeskPublicID = sl.DeskPublicID
 	slrp.CalledAt = sl.CalledAt
 	slrp.IsActive = sl.IsActive
+	slrp.LocationPublicID = sl.LocationPublicID
+}
+
+func serviceLogLocation(r *http.Request, q storage.Store, user database.User, visitorPublicID, deskPublicID string) (string, error) {
+	/*
+		Returns the location a service log of the visitor at the desk belongs to: the location of the desk, which must
+		be the location the visitor is waiting in. The user making the change must be assigned to it.
+	*/
+	desk, err := q.GetDesksByPublicID(r.Context(), deskPublicID)
+	if err != nil {
+		return "", notFound(ErrDeskNotFound, err)
+	}
+	visitor, err := q.GetVisitorsByPublicID(r.Context(), visitorPublicID)
+	if err != nil {
+		return "", notFound(auth.ErrVisitorNotFound, err)
+	} else if visitor.LocationPublicID != desk.LocationPublicID {
+		return "", ErrLocationMismatch
+	}
+	if ok, err := auth.UserInLocation(r.Context(), q, user, desk.LocationPublicID); err != nil {
+		return "", err
+	} else if !ok {
+		return "", auth.ErrUserNotInLocation
+	}
+	return desk.LocationPublicID, nil
+}
+
+func (cfg *ApiConfig) calledNotifications(r *http.Request, q storage.Store, serviceLog database.ServiceLog) ([]notify.Message, error) {
+	/*
+		Returns the notifications for a new service log: the called visitor is told which desk to go to and the
+		visitors behind them in the queue of their purpose whose turn has come near are told so. None if
+		notifications are disabled.
+	*/
+	if cfg.Notifier == nil {
+		return nil, nil
+	}
+	visitor, err := q.GetVisitorsByPublicID(r.Context(), serviceLog.VisitorPublicID)
+	if err != nil {
+		return nil, err
+	}
+	desk, err := q.GetDesksByPublicID(r.Context(), serviceLog.DeskPublicID)
+	if err != nil {
+		return nil, err
+	}
+	near, err := cfg.Notifier.QueueMoved(r.Context(), q, visitor.PurposePublicID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return append(cfg.Notifier.Called(visitor, desk), near...), nil
 }
 
 func handleServiceLogOperation[T any](
+	cfg *ApiConfig,
 	w http.ResponseWriter,
 	r *http.Request,
 	operation string,
 	requestPtr *T,
-	dbQuery func() (database.ServiceLog, error),
+	accessingUser database.User, // for location access and the audit log
+	targetPublicID string, // public ID of the service log being changed, empty when creating one
+	dbQuery func(q storage.Store) (database.ServiceLog, error),
 ) {
 
 	// 1. read request
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(requestPtr)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "invalid JSON request")
+	if !cfg.readRequest(w, r, requestPtr) {
 		return
 	}
 
-	// 2. query DB
-	serviceLog, err := dbQuery()
+	// 2. query DB and record the change in the audit log, in one transaction
+	response := ServicelogsResponseParameters{}
+	var notifications []notify.Message
+	err := cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		var before any // nil for creations
+		action := audit.ActionServiceLogCreate
+		event := webhook.EventVisitorCalled
+		if targetPublicID != "" {
+			oldServiceLog, err := q.GetServiceLogsByPublicID(r.Context(), targetPublicID)
+			if err != nil {
+				return notFound(ErrServiceLogNotFound, err)
+			}
+			if ok, err := auth.UserInLocation(r.Context(), q, accessingUser, oldServiceLog.LocationPublicID); err != nil {
+				return err
+			} else if !ok {
+				return auth.ErrUserNotInLocation
+			}
+			if !jsonutils.IfMatch(r, jsonutils.ETag(oldServiceLog.UpdatedAt)) {
+				return jsonutils.ErrPreconditionFailed
+			}
+			beforeResponse := ServicelogsResponseParameters{}
+			beforeResponse.Populate(oldServiceLog)
+			before, action = beforeResponse, audit.ActionServiceLogUpdate
+			event = ""
+			if oldServiceLog.IsActive {
+				event = webhook.EventVisitorServed // if the update ends the service
+			}
+		}
+		serviceLog, err := dbQuery(q)
+		if err != nil {
+			return err
+		}
+		response.Populate(serviceLog)
+		if err := cfg.recordAudit(r, q, accessingUser.PublicID, action, audit.EntityServiceLog, serviceLog.PublicID, before, response); err != nil {
+			return err
+		}
+		if targetPublicID == "" {
+			if notifications, err = cfg.calledNotifications(r, q, serviceLog); err != nil {
+				return err
+			}
+		}
+		if event == "" || (event == webhook.EventVisitorServed && serviceLog.IsActive) {
+			return nil
+		}
+		data := ServicelogsWebhookData{ServiceLog: response}
+		if event == webhook.EventVisitorCalled {
+			if data.Announcements, err = serviceLogAnnouncements(r.Context(), q, serviceLog); err != nil {
+				return err
+			}
+		}
+		return cfg.enqueueWebhooks(r, q, event, data)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no service logs found at the provided public ID")
+			jsonutils.WriteError(w, r, http.StatusNotFound, err, "service log, visitor or desk not found")
+		} else if errors.Is(err, auth.ErrUserNotInLocation) {
+			jsonutils.WriteError(w, r, http.StatusForbidden, err, "user is not assigned to the location of this service log")
+		} else if errors.Is(err, ErrLocationMismatch) {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, err, "visitor and desk belong to different locations")
+		} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+			jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "service log was changed by someone else: get it again and retry with its current ETag")
 		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (handleServiceLogOperation)")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (handleServiceLogOperation)")
 		}
 		return
 	}
 
-	// 3. write response
-	response := ServicelogsResponseParameters{}
-	response.Populate(serviceLog)
-	var statusCode int
+	// 3. notify visitors once the change has committed and write response
+	if len(notifications) > 0 {
+		go cfg.Notifier.Send(context.WithoutCancel(r.Context()), notifications)
+	}
 	if operation == "POST" {
-		statusCode = http.StatusCreated
+		jsonutils.WriteJSON(w, http.StatusCreated, response)
 	} else {
-		statusCode = http.StatusOK
+		jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(response.UpdatedAt), response)
 	}
-	jsonutils.WriteJSON(w, statusCode, response)
 
 }
 
@@ -94,28 +211,37 @@ func (cfg *ApiConfig) HandlerPostServicelogs(w http.ResponseWriter, r *http.Requ
 	// 1. auth
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication is required for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication is required for this endpoint")
 		return
 	} else if !accessingUser.IsActive {
-		jsonutils.WriteError(w, http.StatusForbidden, err, "accessing user account is inactive")
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "accessing user account is inactive")
 		return
 	}
 
 	// 2. handleServiceLogOperation
 	request := &ServicelogsPOSTRequestParameters{}
 	handleServiceLogOperation(
+		cfg,
 		w,
 		r,
 		"POST",
 		request,
-		func() (database.ServiceLog, error) {
+		accessingUser,
+		"",
+		func(q storage.Store) (database.ServiceLog, error) {
+			location, err := serviceLogLocation(r, q, accessingUser, request.VisitorPublicID, request.DeskPublicID)
+			if err != nil {
+				return database.ServiceLog{}, err
+			}
 			query := database.CreateServiceLogsParams{
-				PublicID:        cfg.PublicIDGenerator(),
-				UserPublicID:    request.UserPublicID,
-				VisitorPublicID: request.VisitorPublicID,
-				DeskPublicID:    request.DeskPublicID,
+				PublicID:         cfg.PublicIDGenerator(),
+				UserPublicID:     request.UserPublicID,
+				VisitorPublicID:  request.VisitorPublicID,
+				DeskPublicID:     request.DeskPublicID,
+				LocationPublicID: location,
+				CreatedAt:        time.Now().UTC(),
 			}
-			return cfg.DB.CreateServiceLogs(r.Context(), query)
+			return q.CreateServiceLogs(r.Context(), query)
 		},
 	)
 }
@@ -125,10 +251,15 @@ func (cfg *ApiConfig) HandlerPutServicelogsByID(w http.ResponseWriter, r *http.R
 	// 1. auth
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication is required for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication is required for this endpoint")
 		return
 	} else if !accessingUser.IsActive {
-		jsonutils.WriteError(w, http.StatusForbidden, err, "accessing user account is inactive")
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "accessing user account is inactive")
+		return
+	}
+
+	if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, "If-Match header with the ETag of the service log required for this endpoint")
 		return
 	}
 
@@ -136,36 +267,57 @@ func (cfg *ApiConfig) HandlerPutServicelogsByID(w http.ResponseWriter, r *http.R
 	request := &ServicelogsPUTRequestParameters{}
 	slpid, err := strutils.GetPublicIDFromPathValue("servicelog_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "invalid service log path")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid service log path")
 		return
 	}
 	handleServiceLogOperation(
+		cfg,
 		w,
 		r,
 		"PUT",
 		request,
-		func() (database.ServiceLog, error) {
+		accessingUser,
+		slpid,
+		func(q storage.Store) (database.ServiceLog, error) {
+			location, err := serviceLogLocation(r, q, accessingUser, request.VisitorPublicID, request.DeskPublicID)
+			if err != nil {
+				return database.ServiceLog{}, err
+			}
 			query := database.SetServiceLogsByPublicIDParams{
-				PublicID:        slpid,
-				VisitorPublicID: request.VisitorPublicID,
-				UserPublicID:    request.UserPublicID,
-				DeskPublicID:    request.DeskPublicID,
-				IsActive:        request.IsActive,
+				PublicID:         slpid,
+				VisitorPublicID:  request.VisitorPublicID,
+				UserPublicID:     request.UserPublicID,
+				DeskPublicID:     request.DeskPublicID,
+				IsActive:         request.IsActive,
+				LocationPublicID: location,
 			}
-			return cfg.DB.SetServiceLogsByPublicID(r.Context(), query)
+			return q.SetServiceLogsByPublicID(r.Context(), query)
 		},
 	)
 }
 
+// the columns GET /api/servicelogs can be sorted by, the first one being the default
+var serviceLogSorts = []string{"created_at", "called_at", "updated_at"}
+
+func serviceLogCursor(s database.ServiceLog, sort string) strutils.Cursor {
+	switch sort {
+	case "called_at":
+		return strutils.Cursor{Time: s.CalledAt, PublicID: s.PublicID}
+	case "updated_at":
+		return strutils.Cursor{Time: s.UpdatedAt, PublicID: s.PublicID}
+	}
+	return strutils.Cursor{Time: s.CreatedAt, PublicID: s.PublicID}
+}
+
 // GET /api/servicelogs (user only)
 func (cfg *ApiConfig) HandlerGetServicelogs(w http.ResponseWriter, r *http.Request) {
 	// 1. get user auth?
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authorisation is required for this endpoint")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authorisation is required for this endpoint")
 		return
 	} else if !accessingUser.IsActive {
-		jsonutils.WriteError(w, http.StatusForbidden, err, "accessing user account is inactive")
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "accessing user account is inactive")
 		return
 	}
 
@@ -175,32 +327,55 @@ func (cfg *ApiConfig) HandlerGetServicelogs(w http.ResponseWriter, r *http.Reque
 	params.UserPublicID = strutils.QueryParameterToNullString(q.Get("user_public_id"))
 	params.VisitorPublicID = strutils.QueryParameterToNullString(q.Get("visitor_public_id"))
 	params.DeskPublicID = strutils.QueryParameterToNullString(q.Get("desk_public_id"))
+	params.LocationPublicID = strutils.QueryParameterToNullString(q.Get("location"))
+	params.MemberPublicID = auth.LocationMemberFilter(accessingUser) // users other than admins only see their own locations
 
 	var t sql.NullTime
 	t, err = strutils.QueryParameterToNullTime(q.Get("start_date"))
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "query parameter 'start_date' takes ISO 8601 format (YYYY-MM-DD)")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'start_date' takes ISO 8601 format (YYYY-MM-DD)")
 		return
 	}
 	params.StartDate = t
 
 	t, err = strutils.QueryParameterToNullTime(q.Get("end_date"))
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "query parameter 'end_date' takes ISO 8601 format (YYYY-MM-DD)")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'end_date' takes ISO 8601 format (YYYY-MM-DD)")
 		return
 	}
 	params.EndDate = t
 
+	page, err := strutils.QueryParametersToPage(r, serviceLogSorts)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+	params.AfterPublicID, params.AfterTime = page.AfterPublicID(), page.AfterTime()
+	params.Sort, params.Descending, params.RowLimit = page.Sort, page.Descending, page.RowLimit()
+
 	// 3. run query
 	serviceLogs, err := cfg.DB.ListServiceLogs(r.Context(), params)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no rows found for the provided query parameters")
-			return
-		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (ListServiceLogs in HandlerGetServiceLogs)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (ListServiceLogs in HandlerGetServiceLogs)")
+		return
+	}
+	serviceLogs, next := strutils.NextPage(page, serviceLogs, serviceLogCursor)
+	var total sql.NullInt64
+	if page.Total {
+		total.Int64, err = cfg.DB.CountServiceLogs(r.Context(), database.CountServiceLogsParams{
+			UserPublicID:     params.UserPublicID,
+			VisitorPublicID:  params.VisitorPublicID,
+			DeskPublicID:     params.DeskPublicID,
+			StartDate:        params.StartDate,
+			EndDate:          params.EndDate,
+			LocationPublicID: params.LocationPublicID,
+			MemberPublicID:   params.MemberPublicID,
+		})
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CountServiceLogs in HandlerGetServiceLogs)")
 			return
 		}
+		total.Valid = true
 	}
 
 	// 4. write response
@@ -208,31 +383,45 @@ func (cfg *ApiConfig) HandlerGetServicelogs(w http.ResponseWriter, r *http.Reque
 	for i, s := range serviceLogs {
 		response[i].Populate(s)
 	}
+	strutils.SetPageHeaders(w, r, next, total)
 	jsonutils.WriteJSON(w, http.StatusOK, response)
 }
 
 // GET /api/servicelogs/{servicelog_public_id}
 func (cfg *ApiConfig) HandlerGetServicelogsByPublicID(w http.ResponseWriter, r *http.Request) {
-	// 1. get path publicid
+	// 1. authenticate from context: users of the location of the service log only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	}
+
+	// 2. get path publicid
 	slpid, err := strutils.GetPublicIDFromPathValue("servicelog_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "invalid service log path")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid service log path")
 		return
 	}
 
-	// 2. run query
+	// 3. run query
 	servicelog, err := cfg.DB.GetServiceLogsByPublicID(r.Context(), slpid)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no rows found at provided public id")
+			jsonutils.WriteError(w, r, http.StatusNotFound, ErrServiceLogNotFound.Wrap(err), "no service logs found at specified public id")
 		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetServiceLogsByPublicID in HandlerGetServiceLogsByPublicID)")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetServiceLogsByPublicID in HandlerGetServiceLogsByPublicID)")
 		}
 		return
 	}
+	if ok, err := auth.UserInLocation(r.Context(), cfg.DB, accessingUser, servicelog.LocationPublicID); err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (UserInLocation in HandlerGetServiceLogsByPublicID)")
+		return
+	} else if !ok {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotInLocation, "user is not assigned to the location of this service log")
+		return
+	}
 
-	// 3. write response
+	// 4. write response
 	response := ServicelogsResponseParameters{}
 	response.Populate(servicelog)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(servicelog.UpdatedAt), response)
 }
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+)
+
+var ErrSessionNotFound = jsonutils.NewError(jsonutils.CodeSessionNotFound, "session not found")
+
+type SessionsResponseParameters struct {
+	PublicID     string    `json:"public_id"`
+	UserPublicID string    `json:"user_public_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastUsedAt   time.Time `json:"last_used_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	UserAgent    string    `json:"user_agent"`
+	IPAddress    string    `json:"ip_address"`
+	IsCurrent    bool      `json:"is_current"`
+}
+
+func (rp *SessionsResponseParameters) Populate(token database.RefreshToken) {
+	// note that the token itself is never part of the response
+	rp.PublicID = token.PublicID
+	rp.UserPublicID = token.UserPublicID
+	rp.CreatedAt = token.CreatedAt
+	rp.LastUsedAt = token.LastUsedAt
+	rp.ExpiresAt = token.ExpiresAt
+	rp.UserAgent = token.UserAgent
+	rp.IPAddress = token.IpAddress
+}
+
+// GET /api/sessions
+func (cfg *ApiConfig) HandlerGetSessions(w http.ResponseWriter, r *http.Request) {
+	/*
+		Lists the active sessions (unexpired, unrevoked refresh tokens) of the accessing user, most recently used first.
+		Admins can list the sessions of any user with the user_public_id query parameter.
+	*/
+
+	// 1. authenticate from context
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	}
+
+	// 2. get target user from query parameters (defaults to accessing user)
+	userPublicID := r.URL.Query().Get("user_public_id")
+	if userPublicID == "" {
+		userPublicID = accessingUser.PublicID
+	} else if userPublicID != accessingUser.PublicID && !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "non-admin users can only list their own sessions")
+		return
+	}
+
+	// 3. run query
+	sessions, err := cfg.DB.GetRefreshTokensByUserPublicID(r.Context(), userPublicID)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetRefreshTokensByUserPublicID in HandlerGetSessions)")
+		return
+	}
+
+	// 4. write response
+	currentSessionPublicID := auth.SessionPublicIDFromContext(r)
+	response := make([]SessionsResponseParameters, len(sessions))
+	for i, s := range sessions {
+		response[i].Populate(s)
+		response[i].IsCurrent = currentSessionPublicID != "" && s.PublicID == currentSessionPublicID
+	}
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DELETE /api/sessions/{session_public_id}
+func (cfg *ApiConfig) HandlerDeleteSessionsByPublicID(w http.ResponseWriter, r *http.Request) {
+	/*
+		Ends a single session by revoking its refresh token. Access tokens issued for the session stop working right away
+		as well, see AuthUserMiddleware. Users can end their own sessions, admins can end the sessions of any user.
+	*/
+
+	// 1. authenticate from context
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	}
+
+	// 2. get session from URI
+	pid, err := strutils.GetPublicIDFromPathValue("session_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	session, err := cfg.DB.GetRefreshTokensByPublicID(r.Context(), pid)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrSessionNotFound.Wrap(err), "session not found")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetRefreshTokensByPublicID in HandlerDeleteSessionsByPublicID)")
+		return
+	}
+
+	// 3. check authorization: own session or admin
+	if session.UserPublicID != accessingUser.PublicID && !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "non-admin users can only end their own sessions")
+		return
+	}
+
+	// 4. revoke session
+	before := SessionsResponseParameters{}
+	before.Populate(session)
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		var err error
+		session, err = q.RevokeRefreshTokenByPublicID(r.Context(), pid)
+		if err != nil {
+			return err
+		}
+		after := SessionsResponseParameters{}
+		after.Populate(session)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionSessionRevoke, audit.EntitySession, session.PublicID, before, after)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrSessionNotFound.Wrap(err), "session already ended or expired")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (RevokeRefreshTokenByPublicID in HandlerDeleteSessionsByPublicID)")
+		return
+	}
+
+	// 5. ending the current session is a logout, so the cookies are cleared as well
+	response := SessionsResponseParameters{}
+	response.Populate(session)
+	if session.PublicID == auth.SessionPublicIDFromContext(r) {
+		response.IsCurrent = true
+		auth.SetAuthCookies(w, "", "", "user", cfg.AccessTokenDuration, cfg.RefreshTokenDuration)
+	}
+
+	// 6. write response
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // logos may be GIF, JPEG or PNG
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/i18n"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/schedule"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/dcrauwels/goqueue/ticket"
+)
+
+// limits on ticket layouts, which are printed on small receipts
+const (
+	maxTicketTextLength = 500       // characters of the header and of the footer
+	maxLogoBytes        = 256 << 10 // size of the logo file
+	maxLogoDimension    = 2000      // pixels in either direction
+)
+
+var (
+	ErrTicketTextTooLong    = fmt.Errorf("ticket header and footer cannot be longer than %d characters", maxTicketTextLength)
+	ErrInvalidLogo          = jsonutils.NewError(jsonutils.CodeInvalidLogo, "logo must be a GIF, JPEG or PNG image")
+	ErrTicketLayoutNotFound = jsonutils.NewError(jsonutils.CodeTicketLayoutNotFound, "purpose has no ticket layout")
+	ErrLogoTooLarge         = fmt.Errorf("logo cannot be larger than %d KiB or %dx%d pixels", maxLogoBytes>>10, maxLogoDimension, maxLogoDimension)
+)
+
+type TicketLayoutRequestParameters struct {
+	Header string `json:"header"` // may span several lines
+	Footer string `json:"footer"`
+	Logo   []byte `json:"logo"` // base64 encoded image file, null for none
+}
+
+type TicketLayoutResponseParameters struct {
+	PurposePublicID string    `json:"purpose_public_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Header          string    `json:"header"`
+	Footer          string    `json:"footer"`
+	Logo            []byte    `json:"logo"`
+}
+
+func (tlrp *TicketLayoutResponseParameters) Populate(t database.TicketLayout) {
+	tlrp.PurposePublicID = t.PurposePublicID
+	tlrp.CreatedAt = t.CreatedAt
+	tlrp.UpdatedAt = t.UpdatedAt
+	tlrp.Header = t.Header
+	tlrp.Footer = t.Footer
+	tlrp.Logo = t.Logo
+}
+
+// ticketLayoutAuditState is a ticket layout as recorded in the audit log, which has no use for the image itself
+type ticketLayoutAuditState struct {
+	PurposePublicID string `json:"purpose_public_id"`
+	Header          string `json:"header"`
+	Footer          string `json:"footer"`
+	HasLogo         bool   `json:"has_logo"`
+}
+
+func newTicketLayoutAuditState(t database.TicketLayout) ticketLayoutAuditState {
+	return ticketLayoutAuditState{PurposePublicID: t.PurposePublicID, Header: t.Header, Footer: t.Footer, HasLogo: t.Logo != nil}
+}
+
+func validateTicketLayout(request TicketLayoutRequestParameters) error {
+	if len([]rune(request.Header)) > maxTicketTextLength || len([]rune(request.Footer)) > maxTicketTextLength {
+		return ErrTicketTextTooLong
+	}
+	if request.Logo == nil {
+		return nil
+	}
+	if len(request.Logo) > maxLogoBytes {
+		return ErrLogoTooLarge
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(request.Logo))
+	if err != nil {
+		return ErrInvalidLogo
+	} else if config.Width > maxLogoDimension || config.Height > maxLogoDimension {
+		return ErrLogoTooLarge
+	}
+	return nil
+}
+
+func estimatedWait(ctx context.Context, q storage.Store, visitor database.Visitor, tz *time.Location) (time.Duration, error) {
+	/*
+		How long visitor will still have to wait, zero if that cannot be told. The estimate is the number of visitors
+		ahead in the queue of the purpose, plus one, times the mean time between the last calls of that purpose today.
+		Visitors who have been called or are no longer waiting have no wait.
+	*/
+	if visitor.Status != 0 {
+		return 0, nil
+	}
+	serviceLogs, err := q.GetServiceLogsByVisitorPublicID(ctx, visitor.PublicID)
+	if err != nil {
+		return 0, err
+	} else if len(serviceLogs) > 0 {
+		return 0, nil
+	}
+
+	callTimes, err := q.GetCallTimesByPurposePublicID(ctx, database.GetCallTimesByPurposePublicIDParams{
+		PurposePublicID: visitor.PurposePublicID,
+		CalledAt:        schedule.DayStart(time.Now(), tz).UTC(),
+		Limit:           10,
+	})
+	if err != nil {
+		return 0, err
+	} else if len(callTimes) < 2 {
+		return 0, nil
+	}
+	meanGap := callTimes[0].Sub(callTimes[len(callTimes)-1]) / time.Duration(len(callTimes)-1) // newest first
+
+	ahead, err := q.CountQueuedVisitorsAhead(ctx, database.CountQueuedVisitorsAheadParams{
+		PurposePublicID: visitor.PurposePublicID,
+		WaitingSince:    visitor.WaitingSince,
+		PublicID:        visitor.PublicID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ahead+1) * meanGap, nil
+}
+
+// GET /api/visitors/{visitor_public_id}/ticket[?format=pdf|escpos][&paper_width=58|80]
+func (cfg *ApiConfig) HandlerGetVisitorTicket(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required, like GET /api/visitors/{visitor_public_id}: the kiosk prints the ticket it just issued)
+	// 1. get visitor ID from endpoint and the format from the query
+	pvid, err := strutils.GetPublicIDFromPathValue("visitor_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "pdf"
+	} else if format != "pdf" && format != "escpos" {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, nil, "query parameter 'format' takes pdf or escpos")
+		return
+	}
+	paperWidth := ticket.Paper80
+	if s := r.URL.Query().Get("paper_width"); s != "" {
+		paperWidth, err = strconv.Atoi(s)
+		if err != nil || (paperWidth != ticket.Paper58 && paperWidth != ticket.Paper80) {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'paper_width' takes 58 or 80")
+			return
+		}
+	}
+
+	// 2. gather the ticket: visitor, purpose, location, layout and the estimated wait
+	visitor, err := cfg.DB.GetVisitorsByPublicID(r.Context(), pvid)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrVisitorNotFound.Wrap(err), "visitor not found in database")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetVisitorsByPublicID in HandlerGetVisitorTicket)")
+		return
+	}
+	purpose, err := cfg.DB.GetPurposesByPublicID(r.Context(), visitor.PurposePublicID)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposesByPublicID in HandlerGetVisitorTicket)")
+		return
+	}
+	location, err := cfg.DB.GetLocationByPublicID(r.Context(), visitor.LocationPublicID)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetLocationByPublicID in HandlerGetVisitorTicket)")
+		return
+	}
+	tz, err := time.LoadLocation(location.TimeZone)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "location has an unknown time zone")
+		return
+	}
+	translations, err := cfg.DB.GetPurposeTranslationsByPurposePublicID(r.Context(), purpose.PublicID)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposeTranslationsByPurposePublicID in HandlerGetVisitorTicket)")
+		return
+	}
+	t := ticket.Ticket{
+		Number:    visitor.DailyTicketNumber,
+		Purpose:   localizedPurposeName(purpose, purposeTranslations(translations), r.Header.Get("Accept-Language")),
+		IssuedAt:  visitor.CreatedAt.In(tz),
+		StatusURL: cfg.visitorStatusURL(visitor.PublicID),
+		Language:  i18n.Negotiate(r.Header.Get("Accept-Language")),
+	}
+	t.EstimatedWait, err = estimatedWait(r.Context(), cfg.DB, visitor, tz)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (estimatedWait in HandlerGetVisitorTicket)")
+		return
+	}
+	layout, err := cfg.DB.GetTicketLayoutByPurposePublicID(r.Context(), purpose.PublicID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) { // a purpose without a layout gets a ticket without header, footer and logo
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetTicketLayoutByPurposePublicID in HandlerGetVisitorTicket)")
+		return
+	}
+	t.Header, t.Footer = layout.Header, layout.Footer
+	if layout.Logo != nil {
+		t.Logo, _, err = image.Decode(bytes.NewReader(layout.Logo))
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "logo of the ticket layout cannot be decoded")
+			return
+		}
+	}
+
+	// 3. render and write response
+	var body []byte
+	contentType := "application/pdf"
+	if format == "escpos" {
+		body, err = t.ESCPOS(paperWidth)
+		contentType = "application/octet-stream"
+	} else {
+		body, err = t.PDF(paperWidth)
+	}
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error rendering ticket")
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"ticket-%d.%s\"", visitor.DailyTicketNumber, format))
+	w.Header().Set("Cache-Control", "no-store") // the estimated wait changes
+	w.Header().Set("Content-Language", t.Language)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// GET /api/purposes/{purpose_public_id}/ticket-layout
+func (cfg *ApiConfig) HandlerGetTicketLayout(w http.ResponseWriter, r *http.Request) {
+	// (no authentication required)
+	// 1. get purpose ID from endpoint path value
+	ppid, err := strutils.GetPublicIDFromPathValue("purpose_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 2. run query
+	layout, err := cfg.DB.GetTicketLayoutByPurposePublicID(r.Context(), ppid)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrTicketLayoutNotFound.Wrap(err), "purpose has no ticket layout")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetTicketLayoutByPurposePublicID)")
+		return
+	}
+
+	// 3. write response
+	var response TicketLayoutResponseParameters
+	response.Populate(layout)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(layout.UpdatedAt), response)
+}
+
+// PUT /api/purposes/{purpose_public_id}/ticket-layout (admin only)
+func (cfg *ApiConfig) HandlerPutTicketLayout(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	ppid, err := strutils.GetPublicIDFromPathValue("purpose_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. get request data: header, footer and logo
+	request := TicketLayoutRequestParameters{}
+	if !cfg.readRequestLimited(w, r, &request, maxLogoBytes*2) { // base64 takes a third more than the logo itself
+		return
+	}
+	if err := validateTicketLayout(request); err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	// 4. run query UpsertTicketLayout and record the change in the audit log. changing an existing layout honours
+	// If-Match, while a new layout has no version an If-Match header could match
+	response := TicketLayoutResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		if _, err := q.GetPurposesByPublicID(r.Context(), ppid); err != nil {
+			return err
+		}
+		var before any // nil if the purpose had no layout yet
+		if oldLayout, err := q.GetTicketLayoutByPurposePublicID(r.Context(), ppid); err == nil {
+			if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+				return jsonutils.ErrPreconditionRequired
+			} else if !jsonutils.IfMatch(r, jsonutils.ETag(oldLayout.UpdatedAt)) {
+				return jsonutils.ErrPreconditionFailed
+			}
+			before = newTicketLayoutAuditState(oldLayout)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		} else if r.Header.Get("If-Match") != "" {
+			return jsonutils.ErrPreconditionFailed
+		}
+		layout, err := q.UpsertTicketLayout(r.Context(), database.UpsertTicketLayoutParams{
+			PurposePublicID: ppid,
+			Header:          request.Header,
+			Footer:          request.Footer,
+			Logo:            request.Logo,
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(layout)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionPurposeSetTicketLayout, audit.EntityPurpose, ppid, before, newTicketLayoutAuditState(layout))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrPurposeNotFound.Wrap(err), "no purposes found at specified public id")
+		return
+	} else if errors.Is(err, jsonutils.ErrPreconditionRequired) {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, err, "If-Match header with the ETag of the ticket layout required to change it")
+		return
+	} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+		jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "ticket layout was changed by someone else: get it again and retry with its current ETag")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (UpsertTicketLayout in HandlerPutTicketLayout)")
+		return
+	}
+
+	// 5. return result
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(response.UpdatedAt), response)
+}
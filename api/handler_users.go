@@ -2,27 +2,28 @@ package api
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"time"
 
+	"github.com/dcrauwels/goqueue/audit"
 	"github.com/dcrauwels/goqueue/auth"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
 	"github.com/dcrauwels/goqueue/strutils"
 	"github.com/google/uuid"
 )
 
 type UsersPOSTRequestParameters struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	FullName string `json:"full_name"`
+	Email    string `json:"email" validate:"required,max=254"`
+	Password string `json:"password" validate:"required"`
+	FullName string `json:"full_name" validate:"max=128"`
 }
 
 type UsersPOSTAdminRequestParameters struct {
-	Email    string `json:"email"`
-	FullName string `json:"full_name"`
+	Email    string `json:"email" validate:"required,max=254"`
+	FullName string `json:"full_name" validate:"max=128"`
 	IsAdmin  bool   `json:"is_admin"`
 	IsActive bool   `json:"is_active"`
 }
@@ -49,27 +50,40 @@ func (urp *UsersResponseParameters) Populate(u database.User) {
 	urp.IsActive = u.IsActive
 }
 
-func ProcessUsersParameters(w http.ResponseWriter, request UsersPOSTRequestParameters) (string, error) {
+func ProcessUsersParameters(w http.ResponseWriter, r *http.Request, request UsersPOSTRequestParameters, policy strutils.PasswordPolicy, hasher auth.PasswordHasher) (string, error) {
 	/*
 		This function checks if the parameters in request (email, password and full name) are valid for use in an INSERT query to the users table.
-		Returns a hashed password (using auth.HashPassword) and an error. If the function fails, an empty string is returned instead.
+		Returns a hashed password (using hasher) and an error. If the function fails, an empty string is returned instead.
 	*/
 
 	//email valid
 	if err := strutils.ValidateEmail(request.Email); err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "email formatting invalid: please use jdoe@provider.tld")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("email", err), "email formatting invalid: please use jdoe@provider.tld")
 		return "", err
 	}
-	//password valid (aA0)
-	if err := strutils.ValidatePassword(request.Password); err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "password formatting invalid: please use lowercase, uppercase and/or numeric, between 8 and 30 characters.")
+
+	return ProcessPassword(w, r, request.Password, policy, hasher)
+}
+
+func ProcessPassword(w http.ResponseWriter, r *http.Request, password string, policy strutils.PasswordPolicy, hasher auth.PasswordHasher) (string, error) {
+	/*
+		Checks a new password against the password policy and hashes it. Returns the hashed password and an error. If the function fails,
+		an empty string is returned and an error response has already been written.
+	*/
+	// password valid according to policy. The hasher limits the length as well (bcrypt: 72 bytes, argon2id: 1024)
+	if policy.MaxBytes == 0 {
+		policy.MaxBytes = hasher.MaxPasswordBytes()
+	}
+	policy.MaxBytes = min(policy.MaxBytes, hasher.MaxPasswordBytes())
+	if err := policy.Validate(password); err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("password", err), "password invalid: "+err.Error())
 		return "", err
 	}
 
 	// hash password
-	hashedPassword, err := auth.HashPassword(request.Password)
+	hashedPassword, err := hasher.Hash(password)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "password could not be hashed.")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "password could not be hashed.")
 		return "", err
 	}
 
@@ -84,21 +98,18 @@ func (cfg *ApiConfig) HandlerPostUsers(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		return
 	} else if !accessingUser.IsAdmin {
-		jsonutils.WriteError(w, http.StatusUnauthorized, ErrNotAdmin, "non-admin user tried to request POST /api/users")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, ErrNotAdmin, "non-admin user tried to request POST /api/users")
 		return
 	}
 
 	// 2. get request data
-	decoder := json.NewDecoder(r.Body)
 	reqParams := UsersPOSTRequestParameters{}
-	err = decoder.Decode(&reqParams)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "JSON formatting invalid")
+	if !cfg.readRequest(w, r, &reqParams) {
 		return
 	}
 
 	// 3. check request for validity & hash password
-	hashedPassword, err := ProcessUsersParameters(w, reqParams) // this function already calls jsonutils.WriteError, no need to do so here
+	hashedPassword, err := ProcessUsersParameters(w, r, reqParams, cfg.PasswordPolicy, cfg.PasswordHasher) // this function already calls jsonutils.WriteError, no need to do so here
 	if err != nil {
 		return
 	}
@@ -113,16 +124,21 @@ func (cfg *ApiConfig) HandlerPostUsers(w http.ResponseWriter, r *http.Request) {
 		HashedPassword: hashedPassword,
 		FullName:       reqParams.FullName,
 	}
-	createdUser, err := cfg.DB.CreateUser(r.Context(), queryParams)
+	response := UsersResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		createdUser, err := q.CreateUser(r.Context(), queryParams)
+		if err != nil {
+			return err
+		}
+		response.Populate(createdUser)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionUserCreate, audit.EntityUser, createdUser.PublicID, nil, response)
+	})
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "could not query database to create user.")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "could not query database to create user.")
 		return
 	}
 
 	// return response 201
-	response := UsersResponseParameters{}
-	response.Populate(createdUser)
-
 	jsonutils.WriteJSON(w, http.StatusCreated, response)
 
 }
@@ -138,44 +154,63 @@ func (cfg *ApiConfig) HandlerPutUsers(w http.ResponseWriter, r *http.Request) {
 	// 1. get accessing user from context
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access PUT /api/users")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access PUT /api/users")
+		return
+	} else if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, "If-Match header with the ETag of the user required to access PUT /api/users")
 		return
 	}
 
 	// 2. get request data
-	decoder := json.NewDecoder(r.Body)
 	reqParams := UsersPOSTRequestParameters{}
-	err = decoder.Decode(&reqParams)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "JSON formatting invalid")
+	if !cfg.readRequest(w, r, &reqParams) {
 		return
 	}
 
 	// 3. check for validity and prep hashed password
-	hashedPassword, err := ProcessUsersParameters(w, reqParams)
+	hashedPassword, err := ProcessUsersParameters(w, r, reqParams, cfg.PasswordPolicy, cfg.PasswordHasher)
 	if err != nil {
 		return
 	}
 
-	// 4. run query
+	// 4. run query, with an If-Match header only if the user is still the version the client has
 	queryParams := database.SetUserEmailPasswordByIDParams{
 		ID:             accessingUser.ID,
 		Email:          reqParams.Email,
 		HashedPassword: hashedPassword,
 	}
-	updatedUser, err := cfg.DB.SetUserEmailPasswordByID(r.Context(), queryParams)
+	before, response := UsersResponseParameters{}, UsersResponseParameters{}
+	etag := ""
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		oldUser, err := q.GetUserByPublicID(r.Context(), accessingUser.PublicID)
+		if err != nil {
+			return err
+		}
+		if !jsonutils.IfMatch(r, jsonutils.ETag(oldUser.UpdatedAt)) {
+			return jsonutils.ErrPreconditionFailed
+		}
+		before.Populate(oldUser)
+		updatedUser, err := q.SetUserEmailPasswordByID(r.Context(), queryParams)
+		if err != nil {
+			return err
+		}
+		etag = jsonutils.ETag(updatedUser.UpdatedAt)
+		response.Populate(updatedUser)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionUserUpdate, audit.EntityUser, updatedUser.PublicID, before, response)
+	})
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "user does not exist. How did you do this?")
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrUserNotFound.Wrap(err), "user does not exist. How did you do this?")
+		return
+	} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+		jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "user was changed by someone else: get it again and retry with its current ETag")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database")
 		return
 	}
 
 	// 5. write response
-	response := UsersResponseParameters{}
-	response.Populate(updatedUser)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, etag, response)
 
 }
 
@@ -187,38 +222,38 @@ func (cfg *ApiConfig) HandlerPutUsersByID(w http.ResponseWriter, r *http.Request
 	// 1. retrieve accessing user
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access PUT /api/users")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access PUT /api/users")
+		return
+	} else if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, "If-Match header with the ETag of the user required to access PUT /api/users")
 		return
 	}
 
 	// 2. retrieve target user from uri
 	pid, err := strutils.GetPublicIDFromPathValue("user_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	// 3. retrieve request data
 	request := UsersPOSTAdminRequestParameters{}
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&request)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "invalid json request structure")
+	if !cfg.readRequest(w, r, &request) {
 		return
 	}
 
 	// 4. auth: either IsAdmin or userID match
 	if !accessingUser.IsAdmin {
 		if accessingUser.PublicID != pid {
-			jsonutils.WriteError(w, http.StatusForbidden, errors.New("user not authorized to send a PUT request to this endpoint"), "this user account is not authorized to send a PUT request to this endpoint")
+			jsonutils.WriteError(w, r, http.StatusForbidden, errors.New("user not authorized to send a PUT request to this endpoint"), "this user account is not authorized to send a PUT request to this endpoint")
 			return
 		} else if (request.IsAdmin && !accessingUser.IsAdmin) || (request.IsActive != accessingUser.IsActive) { // non-admins cannot set themselves to admin obviously or (de)activate themselves
-			jsonutils.WriteError(w, http.StatusForbidden, errors.New("user not authorized to edit these fields on own account"), "this user account cannot edit their own admin or activity status")
+			jsonutils.WriteError(w, r, http.StatusForbidden, errors.New("user not authorized to edit these fields on own account"), "this user account cannot edit their own admin or activity status")
 			return
 		}
 	}
 
-	// 5. run query
+	// 5. run query, with an If-Match header only if the user is still the version the client has
 	queryParams := database.SetUserByPublicIDParams{
 		PublicID: pid,
 		Email:    request.Email,
@@ -226,19 +261,51 @@ func (cfg *ApiConfig) HandlerPutUsersByID(w http.ResponseWriter, r *http.Request
 		IsAdmin:  request.IsAdmin,
 		IsActive: request.IsActive,
 	}
-	updatedUser, err := cfg.DB.SetUserByPublicID(r.Context(), queryParams)
+	before, response := UsersResponseParameters{}, UsersResponseParameters{}
+	etag := ""
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		oldUser, err := q.GetUserByPublicID(r.Context(), pid)
+		if err != nil {
+			return err
+		}
+		if !jsonutils.IfMatch(r, jsonutils.ETag(oldUser.UpdatedAt)) {
+			return jsonutils.ErrPreconditionFailed
+		}
+		before.Populate(oldUser)
+		updatedUser, err := q.SetUserByPublicID(r.Context(), queryParams)
+		if err != nil {
+			return err
+		}
+		etag = jsonutils.ETag(updatedUser.UpdatedAt)
+		response.Populate(updatedUser)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionUserUpdate, audit.EntityUser, updatedUser.PublicID, before, response)
+	})
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "user not found")
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrUserNotFound.Wrap(err), "user not found")
+		return
+	} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+		jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "user was changed by someone else: get it again and retry with its current ETag")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database")
 		return
 	}
 
 	// 5. write response
-	response := UsersResponseParameters{}
-	response.Populate(updatedUser)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, etag, response)
+}
+
+// the columns GET /api/users can be sorted by, the first one being the default
+var userSorts = []string{"created_at", "email", "full_name"}
+
+func userCursor(u database.User, sort string) strutils.Cursor {
+	switch sort {
+	case "email":
+		return strutils.Cursor{Text: u.Email, PublicID: u.PublicID}
+	case "full_name":
+		return strutils.Cursor{Text: u.FullName, PublicID: u.PublicID}
+	}
+	return strutils.Cursor{Time: u.CreatedAt, PublicID: u.PublicID}
 }
 
 func (cfg *ApiConfig) HandlerGetUsers(w http.ResponseWriter, r *http.Request) { // GET /api/users
@@ -248,28 +315,64 @@ func (cfg *ApiConfig) HandlerGetUsers(w http.ResponseWriter, r *http.Request) {
 	// 1. check if accessing user is admin
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access GET /api/users")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access GET /api/users")
 		return
 	} else if !accessingUser.IsAdmin {
-		jsonutils.WriteError(w, http.StatusForbidden, err, "GET /api/users is only accessible to admin level users")
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "GET /api/users is only accessible to admin level users")
 		return
 	}
 
-	// 2. run query
-	users, err := cfg.DB.GetUsers(r.Context())
-	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "no users found")
+	// 2. get query parameters
+	q := r.URL.Query()
+	filters := database.CountUsersParams{}
+	filters.IsActive, err = strutils.QueryParameterToNullBool(q.Get("is_active"))
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid query parameter for is_active: only booleans are accepted")
 		return
-	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database")
+	}
+	filters.IsAdmin, err = strutils.QueryParameterToNullBool(q.Get("is_admin"))
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "invalid query parameter for is_admin: only booleans are accepted")
+		return
+	}
+	page, err := strutils.QueryParametersToPage(r, userSorts)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, err.Error())
 		return
 	}
 
-	// 3. write response
+	// 3. run query
+	users, err := cfg.DB.ListUsers(r.Context(), database.ListUsersParams{
+		IsActive:      filters.IsActive,
+		IsAdmin:       filters.IsAdmin,
+		AfterPublicID: page.AfterPublicID(),
+		Sort:          page.Sort,
+		Descending:    page.Descending,
+		AfterTime:     page.AfterTime(),
+		AfterText:     page.AfterText(),
+		RowLimit:      page.RowLimit(),
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database")
+		return
+	}
+	users, next := strutils.NextPage(page, users, userCursor)
+	var total sql.NullInt64
+	if page.Total {
+		total.Int64, err = cfg.DB.CountUsers(r.Context(), filters)
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database")
+			return
+		}
+		total.Valid = true
+	}
+
+	// 4. write response
 	response := make([]UsersResponseParameters, len(users))
 	for i, u := range users {
 		response[i].Populate(u)
 	}
+	strutils.SetPageHeaders(w, r, next, total)
 	jsonutils.WriteJSON(w, http.StatusOK, response)
 }
 
@@ -282,44 +385,44 @@ func (cfg *ApiConfig) HandlerGetUsersByID(w http.ResponseWriter, r *http.Request
 	// 1. check authentication from context
 	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if errors.Is(err, auth.ErrNoIDInContext) {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access this endpoint")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access this endpoint")
 		return
 	} else if err != nil {
 		return // any other err than auth.ErrNoIDInContext already sends a json.WriteError so no additional error writing is needed
 	}
 	// 1.1 sanity checks
 	if !accessingUser.IsActive {
-		jsonutils.WriteError(w, http.StatusForbidden, err, "accessing user account is inactive")
+		jsonutils.WriteError(w, r, http.StatusForbidden, err, "accessing user account is inactive")
 		return
 	}
 
 	// 2. get user ID from request uri
 	pid, err := strutils.GetPublicIDFromPathValue("user_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	// 3. check for validity
 	if len(pid) != cfg.PublicIDLength {
-		jsonutils.WriteError(w, http.StatusBadRequest, errors.New("incorrect public ID length"), "invalid public ID length provided in endpoint")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, errors.New("incorrect public ID length"), "invalid public ID length provided in endpoint")
 		return
 	}
 
 	// 4. run query
 	user, err := cfg.DB.GetUserByPublicID(r.Context(), pid)
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "user not found")
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrUserNotFound.Wrap(err), "user not found")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database(GetUserByPublicID in HandlerGetUsersByID)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database(GetUserByPublicID in HandlerGetUsersByID)")
 		return
 	}
 
 	// 5. write response
 	response := UsersResponseParameters{}
 	response.Populate(user)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(user.UpdatedAt), response)
 }
 
 // not entirely sure how I want to go about this function yet
@@ -338,7 +441,7 @@ func (cfg *ApiConfig) HandlerGetUsersByID(w http.ResponseWriter, r *http.Request
 	decoder := json.NewDecoder(r.Body)
 	err = decoder.Decode(&reqParams)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect json request provided")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect json request provided")
 	}
 
 	// 3. run query DeleteUserByID
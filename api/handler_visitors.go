@@ -2,31 +2,39 @@ package api
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
+	"net/mail"
 	"time"
 
+	"github.com/dcrauwels/goqueue/audit"
 	"github.com/dcrauwels/goqueue/auth"
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/schedule"
+	"github.com/dcrauwels/goqueue/storage"
 	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/dcrauwels/goqueue/webhook"
 	"github.com/google/uuid"
 )
 
 type VisitorsPostRequestParameters struct {
-	Name            string `json:"name"`
-	PurposePublicID string `json:"purpose_public_id"`
+	Name            string `json:"name" validate:"max=128"`
+	PurposePublicID string `json:"purpose_public_id" validate:"required"` // an unknown purpose is a 404, see HandlerPostVisitors
+	PhoneNumber     string `json:"phone_number"`                          // optional, for notifications by SMS. International format, e.g. +31201234567
+	Email           string `json:"email"`                                 // optional, for notifications by email
 }
 
 type VisitorsPutRequestParameters struct {
 	PublicID        string `json:"public_id"`
-	Name            string `json:"name"`
-	PurposePublicID string `json:"purpose_public_id"`
-	Status          int32  `json:"status"`
+	Name            string `json:"name" validate:"max=128"`
+	PurposePublicID string `json:"purpose_public_id" validate:"required,ref=purpose"`
+	Status          int32  `json:"status" validate:"oneof=0 1 2 3 4"`
 }
 
-type VisitorsResponseParameters struct {
+// VisitorsPublicResponseParameters is a visitor as anyone with its public ID gets it: without the contact details
+type VisitorsPublicResponseParameters struct {
 	ID                uuid.UUID      `json:"id"`
 	PublicID          string         `json:"public_id"`
 	CreatedAt         time.Time      `json:"created_at"`
@@ -36,18 +44,58 @@ type VisitorsResponseParameters struct {
 	PurposePublicID   string         `json:"purpose_public_id"`
 	Status            int32          `json:"status"`
 	DailyTicketNumber int32          `json:"daily_ticket_number"`
+	LocationPublicID  string         `json:"location_public_id"`
+}
+
+func (vprp *VisitorsPublicResponseParameters) Populate(v database.Visitor) {
+	vprp.ID = v.ID
+	vprp.PublicID = v.PublicID
+	vprp.CreatedAt = v.CreatedAt
+	vprp.UpdatedAt = v.UpdatedAt
+	vprp.WaitingSince = v.WaitingSince
+	vprp.Name = v.Name
+	vprp.PurposePublicID = v.PurposePublicID
+	vprp.Status = v.Status
+	vprp.DailyTicketNumber = v.DailyTicketNumber
+	vprp.LocationPublicID = v.LocationPublicID
+}
+
+// VisitorsResponseParameters is a visitor as users of its location and the visitor who just registered get it
+type VisitorsResponseParameters struct {
+	VisitorsPublicResponseParameters
+	PhoneNumber sql.NullString `json:"phone_number"`
+	Email       sql.NullString `json:"email"`
+	NotifiedAt  sql.NullTime   `json:"notified_at"`
 }
 
 func (vrp *VisitorsResponseParameters) Populate(v database.Visitor) {
-	vrp.ID = v.ID
-	vrp.PublicID = v.PublicID
-	vrp.CreatedAt = v.CreatedAt
-	vrp.UpdatedAt = v.UpdatedAt
-	vrp.WaitingSince = v.WaitingSince
-	vrp.Name = v.Name
-	vrp.PurposePublicID = v.PurposePublicID
-	vrp.Status = v.Status
-	vrp.DailyTicketNumber = v.DailyTicketNumber
+	vrp.VisitorsPublicResponseParameters.Populate(v)
+	vrp.PhoneNumber = v.PhoneNumber
+	vrp.Email = v.Email
+	vrp.NotifiedAt = v.NotifiedAt
+}
+
+// VisitorsPostResponseParameters is the response to POST /api/visitors: the visitor and how they follow their turn
+type VisitorsPostResponseParameters struct {
+	VisitorsResponseParameters
+	StatusURL string `json:"status_url"`  // the visitor's status page
+	QRCodeSVG string `json:"qr_code_svg"` // SVG image of a QR code of status_url, to show on the kiosk screen
+}
+
+// VisitorsWebhookData is the data of visitor.created webhook events. Like the audit log, it leaves out the name and
+// contact details
+type VisitorsWebhookData struct {
+	Visitor VisitorsResponseParameters `json:"visitor"`
+}
+
+func visitorAuditState(v database.Visitor) VisitorsResponseParameters {
+	// visitor names and contact details are personal data, so they are kept out of the audit log, which cannot be altered afterwards
+	state := VisitorsResponseParameters{}
+	state.Populate(v)
+	state.Name = sql.NullString{}
+	state.PhoneNumber = sql.NullString{}
+	state.Email = sql.NullString{}
+	return state
 }
 
 // POST /api/visitors no auth required
@@ -55,52 +103,107 @@ func (cfg *ApiConfig) HandlerPostVisitors(w http.ResponseWriter, r *http.Request
 	/* function for sending a POST request to CREATE a single visitor from scratch
 	in context the visitor accesses a website, enters his name and purpose and gets a number*/
 
-	// 1. get request data: name, purpose
-	decoder := json.NewDecoder(r.Body)
+	// 1. get request data: name, purpose and optional contact details for notifications
 	request := VisitorsPostRequestParameters{}
-	err := decoder.Decode(&request)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "JSON formatting invalid")
+	if !cfg.readRequest(w, r, &request) {
 		return
 	}
+	if request.PhoneNumber != "" {
+		if err := strutils.ValidatePhoneNumber(request.PhoneNumber); err != nil {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("phone_number", err), "phone number formatting invalid: please use international format, e.g. +31201234567")
+			return
+		}
+	}
+	if request.Email != "" {
+		address, err := mail.ParseAddress(request.Email)
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("email", strutils.ErrInvalidEmail.Wrap(err)), "email formatting invalid: please use jdoe@provider.tld")
+			return
+		}
+		request.Email = address.Address
+	}
 
 	// 2. check purpose for validity
 	purpose, err := cfg.DB.GetPurposesByPublicID(r.Context(), request.PurposePublicID)
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "purpose not found in database, please register first")
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrPurposeNotFound.Wrap(err), "purpose not found in database, please register first")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetPurposesByPublicID in HandlerPostVisitors)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetPurposesByPublicID in HandlerPostVisitors)")
 		return
 	}
 
-	// 3. query DB: UpdateTicketCounter
-	dtn, err := cfg.DB.UpdateTicketCounter(r.Context())
+	// 3. check the opening hours and holidays of the purpose, in the time zone of its location
+	now := time.Now()
+	calendar, err := queueCalendar(r.Context(), cfg.DB, purpose)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (UpdateTicketCounter in HandlerPostVisitors)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (queueCalendar in HandlerPostVisitors)")
 		return
 	}
-
-	// 4. create public ID
-	pid := cfg.PublicIDGenerator()
-
-	// 5. query DB: CreateVisitor
-	queryParams := database.CreateVisitorParams{
-		PublicID:          pid,
-		Name:              strutils.InitNullString(request.Name), // name is currently nullable.
-		PurposePublicID:   purpose.PublicID,
-		DailyTicketNumber: dtn,
+	if !calendar.IsOpen(now) {
+		writeQueueClosed(w, r, ErrQueueClosed, "no tickets are issued for this purpose at this time", calendar, now)
+		return
 	}
 
-	createdVisitor, err := cfg.DB.CreateVisitor(r.Context(), queryParams)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (CreateVisitor in HandlerPostVisitors)")
+	// 4. query DB: UpdateTicketCounter and CreateVisitor in one transaction, so a failed insert does not burn a ticket number.
+	// the visitor joins the queue of the location of its purpose, which numbers its tickets separately per local date.
+	// UpdateTicketCounter locks the counter row, which also keeps concurrent requests from exceeding the daily capacity
+	var createdVisitor database.Visitor
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		dtn, err := q.UpdateTicketCounter(r.Context(), database.UpdateTicketCounterParams{
+			LocationPublicID: purpose.LocationPublicID,
+			CounterDate:      schedule.Date(now, calendar.Location),
+		})
+		if err != nil {
+			return err
+		}
+		if purpose.DailyCapacity.Valid {
+			issued, err := q.CountVisitorsByPurposePublicIDSince(r.Context(), database.CountVisitorsByPurposePublicIDSinceParams{
+				PurposePublicID: purpose.PublicID,
+				CreatedAt:       schedule.DayStart(now, calendar.Location).UTC(),
+			})
+			if err != nil {
+				return err
+			} else if issued >= int64(purpose.DailyCapacity.Int32) {
+				return ErrQueueFull
+			}
+		}
+		createdVisitor, err = q.CreateVisitor(r.Context(), database.CreateVisitorParams{
+			PublicID:          cfg.PublicIDGenerator(),
+			Name:              strutils.InitNullString(request.Name), // name is currently nullable.
+			PurposePublicID:   purpose.PublicID,
+			DailyTicketNumber: dtn,
+			LocationPublicID:  purpose.LocationPublicID,
+			PhoneNumber:       sql.NullString{String: request.PhoneNumber, Valid: request.PhoneNumber != ""},
+			Email:             sql.NullString{String: request.Email, Valid: request.Email != ""},
+			CreatedAt:         now.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := cfg.recordAudit(r, q, "", audit.ActionVisitorCreate, audit.EntityVisitor, createdVisitor.PublicID, nil, visitorAuditState(createdVisitor)); err != nil {
+			return err
+		}
+		return cfg.enqueueWebhooks(r, q, webhook.EventVisitorCreated, VisitorsWebhookData{Visitor: visitorAuditState(createdVisitor)})
+	})
+	if errors.Is(err, ErrQueueFull) {
+		// tickets are issued again from the first opening of the next day
+		tomorrow := schedule.DayStart(now.In(calendar.Location).AddDate(0, 0, 1), calendar.Location)
+		writeQueueClosed(w, r, err, "all tickets for this purpose have been issued today", calendar, tomorrow)
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (UpdateTicketCounter, CreateVisitor in HandlerPostVisitors)")
 		return
 	}
 
-	// 6. return response 201
-	response := VisitorsResponseParameters{}
+	// 5. return response 201 with the status page and its QR code
+	response := VisitorsPostResponseParameters{StatusURL: cfg.visitorStatusURL(createdVisitor.PublicID)}
 	response.Populate(createdVisitor)
+	if code, err := cfg.visitorQRCode(createdVisitor.PublicID); err == nil {
+		response.QRCodeSVG = string(code.SVG(defaultQRCodeScale))
+	} else {
+		log.Printf("Error encoding QR code of visitor %s: %v", createdVisitor.PublicID, err) // the visitor exists, so the request still succeeds
+	}
 	jsonutils.WriteJSON(w, http.StatusCreated, response)
 }
 
@@ -114,40 +217,75 @@ func (cfg *ApiConfig) HandlerPutVisitorsByPublicID(w http.ResponseWriter, r *htt
 	// 1. get target visitor from URI
 	pvid, err := strutils.GetPublicIDFromPathValue("visitor_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	// 2. get user authentication from context
-	_, err = auth.UserFromContext(w, r, cfg.DB) // I don't need information about the user itself, just whether a user ID is present in the request context.
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access PUT /api/visitors")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access PUT /api/visitors")
+		return
+	} else if cfg.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		jsonutils.WriteError(w, r, http.StatusPreconditionRequired, jsonutils.ErrPreconditionRequired, "If-Match header with the ETag of the visitor required to access PUT /api/visitors")
 		return
 	}
 
 	// 3. PUT request
-	decoder := json.NewDecoder(r.Body)
 	request := VisitorsPutRequestParameters{}
-	err = decoder.Decode(&request)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "JSON formatting invalid")
+	if !cfg.readRequest(w, r, &request) {
 		return
 	}
 
-	// 4. run query
+	// 4. run query, with an If-Match header only if the visitor is still the version the client has
 	queryParams := database.SetVisitorByPublicIDParams{
 		PublicID:        pvid,
 		Name:            strutils.InitNullString(request.Name),
 		PurposePublicID: request.PurposePublicID,
 		Status:          request.Status,
 	}
-	updatedVisitor, err := cfg.DB.SetVisitorByPublicID(r.Context(), queryParams)
+	var updatedVisitor database.Visitor
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		oldVisitor, err := q.GetVisitorsByPublicID(r.Context(), pvid)
+		if err != nil {
+			return notFound(auth.ErrVisitorNotFound, err)
+		}
+		if ok, err := auth.UserInLocation(r.Context(), q, accessingUser, oldVisitor.LocationPublicID); err != nil {
+			return err
+		} else if !ok {
+			return auth.ErrUserNotInLocation
+		}
+		if !jsonutils.IfMatch(r, jsonutils.ETag(oldVisitor.UpdatedAt)) {
+			return jsonutils.ErrPreconditionFailed
+		}
+		// a visitor stays in the queue of its location, so its new purpose must be from the same location
+		purpose, err := q.GetPurposesByPublicID(r.Context(), request.PurposePublicID)
+		if err != nil {
+			return notFound(ErrPurposeNotFound, err)
+		} else if purpose.LocationPublicID != oldVisitor.LocationPublicID {
+			return ErrLocationMismatch
+		}
+		updatedVisitor, err = q.SetVisitorByPublicID(r.Context(), queryParams)
+		if err != nil {
+			return err
+		}
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionVisitorUpdate, audit.EntityVisitor, updatedVisitor.PublicID, visitorAuditState(oldVisitor), visitorAuditState(updatedVisitor))
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "updated visitor does not exist in database")
+			jsonutils.WriteError(w, r, http.StatusNotFound, err, "updated visitor or its purpose does not exist in database")
+			return
+		} else if errors.Is(err, auth.ErrUserNotInLocation) {
+			jsonutils.WriteError(w, r, http.StatusForbidden, err, "user is not assigned to the location of this visitor")
+			return
+		} else if errors.Is(err, ErrLocationMismatch) {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, err, "purpose belongs to another location than the visitor")
+			return
+		} else if errors.Is(err, jsonutils.ErrPreconditionFailed) {
+			jsonutils.WriteError(w, r, http.StatusPreconditionFailed, err, "visitor was changed by someone else: get it again and retry with its current ETag")
 			return
 		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (SetVisitorByID)")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (SetVisitorByID)")
 			return
 		}
 	}
@@ -156,15 +294,30 @@ func (cfg *ApiConfig) HandlerPutVisitorsByPublicID(w http.ResponseWriter, r *htt
 	response := VisitorsResponseParameters{}
 	response.Populate(updatedVisitor)
 
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, jsonutils.ETag(updatedVisitor.UpdatedAt), response)
+}
+
+// the columns GET /api/visitors can be sorted by, the first one being the default
+var visitorSorts = []string{"waiting_since", "created_at", "updated_at", "daily_ticket_number"}
+
+func visitorCursor(v database.Visitor, sort string) strutils.Cursor {
+	switch sort {
+	case "created_at":
+		return strutils.Cursor{Time: v.CreatedAt, PublicID: v.PublicID}
+	case "updated_at":
+		return strutils.Cursor{Time: v.UpdatedAt, PublicID: v.PublicID}
+	case "daily_ticket_number":
+		return strutils.Cursor{Int: v.DailyTicketNumber, PublicID: v.PublicID}
+	}
+	return strutils.Cursor{Time: v.WaitingSince, PublicID: v.PublicID}
 }
 
 func (cfg *ApiConfig) HandlerGetVisitors(w http.ResponseWriter, r *http.Request) { // GET /api/visitors
-	// only accessible to logged in users
+	// only accessible to logged in users, who see the visitors of their own locations (admins: of all locations)
 	// 1. get user authentication from request context
-	_, err := auth.UserFromContext(w, r, cfg.DB) // not interested in actual information about the user
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusUnauthorized, err, "user authentication required to access GET /api/visitors")
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, err, "user authentication required to access GET /api/visitors")
 		return
 	}
 	var visitors []database.Visitor
@@ -172,13 +325,15 @@ func (cfg *ApiConfig) HandlerGetVisitors(w http.ResponseWriter, r *http.Request)
 	// 2. check for query parameters (purpose, status)
 	q := r.URL.Query()
 	params := database.ListVisitorsParams{
-		PurposePublicID: strutils.QueryParameterToNullString(q.Get("purpose")),
+		PurposePublicID:  strutils.QueryParameterToNullString(q.Get("purpose")),
+		LocationPublicID: strutils.QueryParameterToNullString(r.URL.Query().Get("location")),
+		MemberPublicID:   auth.LocationMemberFilter(accessingUser),
 	}
 
 	// 2.1 status as string to status as int32
 	status, err := strutils.QueryParameterToNullInt(q.Get("status"))
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "query parameter 'status' takes integers")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'status' takes integers")
 		return
 	}
 	params.Status = status
@@ -187,28 +342,49 @@ func (cfg *ApiConfig) HandlerGetVisitors(w http.ResponseWriter, r *http.Request)
 	var t sql.NullTime
 	t, err = strutils.QueryParameterToNullTime(q.Get("start_date"))
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "query parameter 'start_date' takes ISO 8601 format (YYYY-MM-DD)")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'start_date' takes ISO 8601 format (YYYY-MM-DD)")
 		return
 	}
 	params.StartDate = t
 
 	t, err = strutils.QueryParameterToNullTime(q.Get("end_date"))
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "query parameter 'end_date' takes ISO 8601 format (YYYY-MM-DD)")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'end_date' takes ISO 8601 format (YYYY-MM-DD)")
 		return
 	}
 	params.EndDate = t
 
+	// 2.4 pagination and sorting
+	page, err := strutils.QueryParametersToPage(r, visitorSorts)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+	params.AfterPublicID, params.AfterTime, params.AfterInt = page.AfterPublicID(), page.AfterTime(), page.AfterInt()
+	params.Sort, params.Descending, params.RowLimit = page.Sort, page.Descending, page.RowLimit()
+
 	// 3. query database
 	visitors, err = cfg.DB.ListVisitors(r.Context(), params)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, "no visitors found under specified query parameters")
-			return
-		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (ListVisitors in HandlerGetVisitors)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (ListVisitors in HandlerGetVisitors)")
+		return
+	}
+	visitors, next := strutils.NextPage(page, visitors, visitorCursor)
+	var total sql.NullInt64
+	if page.Total {
+		total.Int64, err = cfg.DB.CountVisitors(r.Context(), database.CountVisitorsParams{
+			Status:           params.Status,
+			PurposePublicID:  params.PurposePublicID,
+			StartDate:        params.StartDate,
+			EndDate:          params.EndDate,
+			LocationPublicID: params.LocationPublicID,
+			MemberPublicID:   params.MemberPublicID,
+		})
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CountVisitors in HandlerGetVisitors)")
 			return
 		}
+		total.Valid = true
 	}
 
 	// 4. write response
@@ -216,30 +392,48 @@ func (cfg *ApiConfig) HandlerGetVisitors(w http.ResponseWriter, r *http.Request)
 	for i, u := range visitors {
 		response[i].Populate(u)
 	}
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	strutils.SetPageHeaders(w, r, next, total)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
 }
 
 func (cfg *ApiConfig) HandlerGetVisitorsByPublicID(w http.ResponseWriter, r *http.Request) { // GET /api/visitors/{visitor_public_id}
 	// 1. get visitor ID from endpoint
 	pvid, err := strutils.GetPublicIDFromPathValue("visitor_public_id", cfg.PublicIDLength, r)
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusBadRequest, err, "incorrect path value length")
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
 		return
 	}
 
 	// 2. run query
 	visitor, err := cfg.DB.GetVisitorsByPublicID(r.Context(), pvid)
 	if errors.Is(err, sql.ErrNoRows) {
-		jsonutils.WriteError(w, http.StatusNotFound, err, "visitor not found in database")
+		jsonutils.WriteError(w, r, http.StatusNotFound, auth.ErrVisitorNotFound.Wrap(err), "visitor not found in database")
 		return
 	} else if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetVisitorByID)")
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetVisitorByID)")
 		return
 	}
 
-	// 3. write response
-	response := VisitorsResponseParameters{}
+	// 3. write response. only users of the location of the visitor get its contact details
+	etag := jsonutils.ETag(visitor.UpdatedAt)
+	w.Header().Add("Vary", "Authorization, Cookie")
+	if userPublicID, _ := r.Context().Value(auth.UserIDContextKey).(string); userPublicID != "" {
+		accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+		if err != nil {
+			return // auth.UserFromContext already writes an error response
+		}
+		if ok, err := auth.UserInLocation(r.Context(), cfg.DB, accessingUser, visitor.LocationPublicID); err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (UserInLocation in HandlerGetVisitorsByPublicID)")
+			return
+		} else if ok {
+			response := VisitorsResponseParameters{}
+			response.Populate(visitor)
+			jsonutils.WriteJSONWithETag(w, r, http.StatusOK, etag, response)
+			return
+		}
+	}
+	response := VisitorsPublicResponseParameters{}
 	response.Populate(visitor)
-	jsonutils.WriteJSON(w, http.StatusOK, response)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, etag, response)
 
 }
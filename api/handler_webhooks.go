@@ -0,0 +1,368 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/dcrauwels/goqueue/webhook"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidWebhookURL       = jsonutils.NewError(jsonutils.CodeInvalidWebhookURL, "webhook url must be an absolute http or https URL")
+	ErrWebhookNotFound         = jsonutils.NewError(jsonutils.CodeWebhookNotFound, "webhook not found")
+	ErrWebhookDeliveryNotFound = jsonutils.NewError(jsonutils.CodeWebhookDeliveryNotFound, "webhook delivery not found")
+)
+
+type WebhooksRequestParameters struct {
+	URL       string `json:"url" validate:"required,max=2048"`
+	EventType string `json:"event_type" validate:"required,oneof=visitor.created visitor.called visitor.served"` // webhook.EventTypes
+	Secret    string `json:"secret" validate:"max=256"`                                                          // optional, generated when empty
+}
+
+type WebhooksResponseParameters struct {
+	ID        uuid.UUID `json:"id"`
+	PublicID  string    `json:"public_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	URL       string    `json:"url"`
+	EventType string    `json:"event_type"`
+	Secret    string    `json:"secret,omitempty"` // only returned by POST /api/webhooks
+}
+
+func (wrp *WebhooksResponseParameters) Populate(s database.WebhookSubscription) {
+	// leaves out the secret, see WebhooksResponseParameters.Secret
+	wrp.ID = s.ID
+	wrp.PublicID = s.PublicID
+	wrp.CreatedAt = s.CreatedAt
+	wrp.UpdatedAt = s.UpdatedAt
+	wrp.URL = s.Url
+	wrp.EventType = s.EventType
+}
+
+type WebhookDeliveriesResponseParameters struct {
+	ID                   uuid.UUID       `json:"id"`
+	PublicID             string          `json:"public_id"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+	SubscriptionPublicID string          `json:"webhook_public_id"`
+	EventType            string          `json:"event_type"`
+	Payload              json.RawMessage `json:"payload"`
+	Status               string          `json:"status"`
+	Attempts             int32           `json:"attempts"`
+	NextAttemptAt        time.Time       `json:"next_attempt_at"`
+	ResponseStatus       sql.NullInt32   `json:"response_status"`
+	LastError            sql.NullString  `json:"last_error"`
+	DeliveredAt          sql.NullTime    `json:"delivered_at"`
+}
+
+func (wdrp *WebhookDeliveriesResponseParameters) Populate(d database.WebhookDelivery) {
+	wdrp.ID = d.ID
+	wdrp.PublicID = d.PublicID
+	wdrp.CreatedAt = d.CreatedAt
+	wdrp.UpdatedAt = d.UpdatedAt
+	wdrp.SubscriptionPublicID = d.SubscriptionPublicID
+	wdrp.EventType = d.EventType
+	wdrp.Payload = d.Payload
+	wdrp.Status = d.Status
+	wdrp.Attempts = d.Attempts
+	wdrp.NextAttemptAt = d.NextAttemptAt
+	wdrp.ResponseStatus = d.ResponseStatus
+	wdrp.LastError = d.LastError
+	wdrp.DeliveredAt = d.DeliveredAt
+}
+
+func webhookDeliveryAuditState(d database.WebhookDelivery) WebhookDeliveriesResponseParameters {
+	// payloads are a copy of data that is audited where it changes
+	state := WebhookDeliveriesResponseParameters{}
+	state.Populate(d)
+	state.Payload = nil
+	return state
+}
+
+func (cfg *ApiConfig) enqueueWebhooks(r *http.Request, q storage.Store, eventType string, data any) error {
+	// queues the event for every subscription to it. Pass the q of the transaction making the change (cfg.DB.InTx)
+	_, err := webhook.Enqueue(r.Context(), q, webhook.Event{
+		ID:        cfg.PublicIDGenerator(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}, cfg.PublicIDGenerator)
+	return err
+}
+
+func validateWebhookURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// POST /api/webhooks (admin only)
+func (cfg *ApiConfig) HandlerPostWebhooks(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get request data: url, event type and optionally the secret
+	request := WebhooksRequestParameters{}
+	if !cfg.readRequest(w, r, &request) {
+		return
+	}
+	if err = validateWebhookURL(request.URL); err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, jsonutils.InvalidField("url", err), "url must be an absolute http or https URL")
+		return
+	}
+	if request.Secret == "" {
+		request.Secret, err = webhook.MakeSecret()
+		if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error generating webhook secret")
+			return
+		}
+	}
+
+	// 3. run query CreateWebhookSubscription. the secret is kept out of the audit log
+	response := WebhooksResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		subscription, err := q.CreateWebhookSubscription(r.Context(), database.CreateWebhookSubscriptionParams{
+			PublicID:  cfg.PublicIDGenerator(),
+			Url:       request.URL,
+			Secret:    request.Secret,
+			EventType: request.EventType,
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(subscription)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionWebhookCreate, audit.EntityWebhook, subscription.PublicID, nil, response)
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateWebhookSubscription in HandlerPostWebhooks)")
+		return
+	}
+
+	// 4. return result, the only time the secret is shown
+	response.Secret = request.Secret
+	jsonutils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// GET /api/webhooks (admin only)
+func (cfg *ApiConfig) HandlerGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. run query GetWebhookSubscriptions
+	subscriptions, err := cfg.DB.GetWebhookSubscriptions(r.Context())
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetWebhookSubscriptions in HandlerGetWebhooks)")
+		return
+	}
+
+	// 3. return result
+	response := make([]WebhooksResponseParameters, len(subscriptions))
+	for i, s := range subscriptions {
+		response[i].Populate(s)
+	}
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
+
+// GET /api/webhooks/{webhook_public_id} (admin only)
+func (cfg *ApiConfig) HandlerGetWebhooksByPublicID(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	wpid, err := strutils.GetPublicIDFromPathValue("webhook_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. run query GetWebhookSubscriptionByPublicID
+	subscription, err := cfg.DB.GetWebhookSubscriptionByPublicID(r.Context(), wpid)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrWebhookNotFound.Wrap(err), "no webhooks found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetWebhookSubscriptionByPublicID in HandlerGetWebhooksByPublicID)")
+		return
+	}
+
+	// 4. return result
+	response := WebhooksResponseParameters{}
+	response.Populate(subscription)
+	jsonutils.WriteJSONWithETag(w, r, http.StatusOK, "", response)
+}
+
+// DELETE /api/webhooks/{webhook_public_id} (admin only)
+func (cfg *ApiConfig) HandlerDeleteWebhooksByPublicID(w http.ResponseWriter, r *http.Request) {
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	wpid, err := strutils.GetPublicIDFromPathValue("webhook_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. run query DeleteWebhookSubscriptionByPublicID, which deletes its deliveries along with it
+	response := WebhooksResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		subscription, err := q.GetWebhookSubscriptionByPublicID(r.Context(), wpid)
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteWebhookSubscriptionByPublicID(r.Context(), wpid); err != nil {
+			return err
+		}
+		response.Populate(subscription)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionWebhookDelete, audit.EntityWebhook, subscription.PublicID, response, nil)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrWebhookNotFound.Wrap(err), "no webhooks found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (DeleteWebhookSubscriptionByPublicID in HandlerDeleteWebhooksByPublicID)")
+		return
+	}
+
+	// 4. return the deleted webhook
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GET /api/webhooks/{webhook_public_id}/deliveries (admin only)
+func (cfg *ApiConfig) HandlerGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	// the delivery log of a webhook, newest first
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value and query parameter limit (default 50, at most 500)
+	wpid, err := strutils.GetPublicIDFromPathValue("webhook_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+	limit, err := strutils.QueryParameterToNullInt(r.URL.Query().Get("limit"))
+	if err != nil || (limit.Valid && (limit.Int32 < 1 || limit.Int32 > 500)) {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "query parameter 'limit' takes integers from 1 to 500")
+		return
+	} else if !limit.Valid {
+		limit.Int32 = 50
+	}
+
+	// 3. run queries GetWebhookSubscriptionByPublicID and GetWebhookDeliveriesBySubscriptionPublicID
+	if _, err := cfg.DB.GetWebhookSubscriptionByPublicID(r.Context(), wpid); errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrWebhookNotFound.Wrap(err), "no webhooks found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetWebhookSubscriptionByPublicID in HandlerGetWebhookDeliveries)")
+		return
+	}
+	deliveries, err := cfg.DB.GetWebhookDeliveriesBySubscriptionPublicID(r.Context(), database.GetWebhookDeliveriesBySubscriptionPublicIDParams{
+		SubscriptionPublicID: wpid,
+		Limit:                limit.Int32,
+	})
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetWebhookDeliveriesBySubscriptionPublicID in HandlerGetWebhookDeliveries)")
+		return
+	}
+
+	// 4. return result
+	response := make([]WebhookDeliveriesResponseParameters, len(deliveries))
+	for i, d := range deliveries {
+		response[i].Populate(d)
+	}
+	jsonutils.WriteJSON(w, http.StatusOK, response)
+}
+
+// POST /api/webhooks/deliveries/{delivery_public_id}/redeliver (admin only)
+func (cfg *ApiConfig) HandlerPostWebhookRedelivery(w http.ResponseWriter, r *http.Request) {
+	/*
+		Queues a delivery again, whatever its status, with a fresh number of attempts. It is sent by the next run of
+		the dispatcher with the payload of the original delivery, so receivers see the same event ID.
+	*/
+	// 1. check auth -> admin only
+	accessingUser, err := auth.UserFromContext(w, r, cfg.DB)
+	if err != nil {
+		return // auth.UserFromContext already writes an error response
+	} else if !accessingUser.IsAdmin {
+		jsonutils.WriteError(w, r, http.StatusForbidden, auth.ErrUserNotAdmin, "user requires admin status for this endpoint")
+		return
+	}
+
+	// 2. get path value
+	dpid, err := strutils.GetPublicIDFromPathValue("delivery_public_id", cfg.PublicIDLength, r)
+	if err != nil {
+		jsonutils.WriteError(w, r, http.StatusBadRequest, err, "incorrect path value length")
+		return
+	}
+
+	// 3. run query RedeliverWebhookDelivery
+	response := WebhookDeliveriesResponseParameters{}
+	err = cfg.DB.InTx(r.Context(), func(q storage.Store) error {
+		oldDelivery, err := q.GetWebhookDeliveryByPublicID(r.Context(), dpid)
+		if err != nil {
+			return err
+		}
+		delivery, err := q.RedeliverWebhookDelivery(r.Context(), database.RedeliverWebhookDeliveryParams{
+			PublicID:      dpid,
+			NextAttemptAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		response.Populate(delivery)
+		return cfg.recordAudit(r, q, accessingUser.PublicID, audit.ActionWebhookRedeliver, audit.EntityWebhook, delivery.SubscriptionPublicID, webhookDeliveryAuditState(oldDelivery), webhookDeliveryAuditState(delivery))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonutils.WriteError(w, r, http.StatusNotFound, ErrWebhookDeliveryNotFound.Wrap(err), "no webhook deliveries found at specified public id")
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (RedeliverWebhookDelivery in HandlerPostWebhookRedelivery)")
+		return
+	}
+
+	// 4. return the queued delivery
+	jsonutils.WriteJSON(w, http.StatusAccepted, response)
+}
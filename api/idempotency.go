@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/validate"
+)
+
+var (
+	ErrIdempotencyKeyInvalid  = jsonutils.NewError(jsonutils.CodeIdempotencyKeyInvalid, "Idempotency-Key header must be 1 to 255 characters")
+	ErrIdempotencyKeyReused   = jsonutils.NewError(jsonutils.CodeIdempotencyKeyReused, "Idempotency-Key was used before with a different request body")
+	ErrIdempotencyKeyInFlight = jsonutils.NewError(jsonutils.CodeIdempotencyKeyInFlight, "request with this Idempotency-Key is still being handled")
+)
+
+const maxIdempotencyKeyLength = 255
+
+// idempotencyLease is how long a claimed key without a response stays in flight. It is longer than the write timeout of
+// the server, so a request still being handled by then has lost its client anyway; usually it crashed.
+const idempotencyLease = 2 * time.Minute
+
+// the response headers stored with the idempotency key and replayed along with the body
+var replayedHeaders = []string{"Content-Type", "Content-Language", "ETag", "Location"}
+
+// idempotencyRecorder passes the response on to the client and keeps a copy of it to store with the idempotency key.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.record(status)
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.record(http.StatusOK)
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+func (rec *idempotencyRecorder) record(status int) {
+	// the headers are final once the status is written
+	rec.status = status
+	rec.header = http.Header{}
+	for _, name := range replayedHeaders {
+		if values := rec.ResponseWriter.Header().Values(name); len(values) > 0 {
+			rec.header[name] = values
+		}
+	}
+}
+
+func isReplayable(status int) bool {
+	// server errors, authentication failures and rate limiting may well go away on a retry, so they are not stored
+	return status < 500 && status != http.StatusUnauthorized && status != http.StatusForbidden && status != http.StatusTooManyRequests
+}
+
+func (cfg *ApiConfig) IdempotencyMiddleware(next http.Handler) http.Handler {
+	/*
+		Middleware for POST endpoints that makes retries with the same Idempotency-Key header safe: the first response is
+		stored in the database for IdempotencyKeyDuration hours and replayed to retries, marked with an
+		Idempotent-Replayed header, instead of handling them again. A retry with a different body gets a 422, a retry
+		while the first request is still being handled a 409. If that request never stores its response, a retry takes
+		over its claim after idempotencyLease. Requests without the header are handled as usual.
+		Keys are scoped by endpoint and, behind AuthUserMiddleware, by user.
+	*/
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		} else if len(key) > maxIdempotencyKeyLength {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, ErrIdempotencyKeyInvalid, ErrIdempotencyKeyInvalid.Error())
+			return
+		}
+
+		// 1. hash the body, which is then passed on unread
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, validate.MaxBodyBytes))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			jsonutils.WriteError(w, r, http.StatusRequestEntityTooLarge, err, "request body too large")
+			return
+		} else if err != nil {
+			jsonutils.WriteError(w, r, http.StatusBadRequest, err, "could not read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+		scope := r.Method + " " + r.URL.Path
+		if userPublicID, _ := r.Context().Value(auth.UserIDContextKey).(string); userPublicID != "" {
+			scope += " " + userPublicID
+		}
+
+		// 2. claim the key. expired keys are cleared first, so that they can be claimed again
+		now := time.Now()
+		if err := cfg.DB.DeleteExpiredIdempotencyKeys(r.Context(), now); err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (DeleteExpiredIdempotencyKeys in IdempotencyMiddleware)")
+			return
+		}
+		_, err = cfg.DB.CreateIdempotencyKey(r.Context(), database.CreateIdempotencyKeyParams{
+			Scope:          scope,
+			IdempotencyKey: key,
+			RequestHash:    requestHash,
+			CreatedAt:      now,
+			ExpiresAt:      now.Add(time.Duration(cfg.IdempotencyKeyDuration) * time.Hour),
+			LockedUntil:    sql.NullTime{Time: now.Add(idempotencyLease), Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// 3a. the key was claimed before: replay its response
+			cfg.replayIdempotencyKey(w, r, scope, key, requestHash)
+			return
+		} else if err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (CreateIdempotencyKey in IdempotencyMiddleware)")
+			return
+		}
+
+		// 3b. handle the request and store the response. that should happen even if the client is gone by now, since
+		// its retry is what the response is stored for. a handler that panics releases the key right away
+		ctx := context.WithoutCancel(r.Context())
+		handled := false
+		defer func() {
+			if !handled {
+				if err := cfg.DB.DeleteIdempotencyKey(ctx, database.DeleteIdempotencyKeyParams{Scope: scope, IdempotencyKey: key}); err != nil {
+					log.Printf("could not release idempotency key %q: %v", key, err)
+				}
+			}
+		}()
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		handled = true
+		if !isReplayable(rec.status) {
+			err = cfg.DB.DeleteIdempotencyKey(ctx, database.DeleteIdempotencyKeyParams{Scope: scope, IdempotencyKey: key})
+		} else {
+			var header []byte
+			if header, err = json.Marshal(rec.header); err == nil {
+				err = cfg.DB.SetIdempotencyKeyResponse(ctx, database.SetIdempotencyKeyResponseParams{
+					Scope:           scope,
+					IdempotencyKey:  key,
+					ResponseStatus:  sql.NullInt32{Int32: int32(rec.status), Valid: true},
+					ResponseBody:    rec.body.Bytes(),
+					ResponseHeaders: header,
+				})
+			}
+		}
+		if err != nil {
+			log.Printf("could not store the response for idempotency key %q: %v", key, err)
+		}
+	})
+}
+
+func (cfg *ApiConfig) replayIdempotencyKey(w http.ResponseWriter, r *http.Request, scope, key, requestHash string) {
+	stored, err := cfg.DB.GetIdempotencyKey(r.Context(), database.GetIdempotencyKeyParams{Scope: scope, IdempotencyKey: key})
+	if errors.Is(err, sql.ErrNoRows) {
+		// the first request failed and released the key in the meantime
+		jsonutils.WriteError(w, r, http.StatusConflict, ErrIdempotencyKeyInFlight, ErrIdempotencyKeyInFlight.Error())
+		return
+	} else if err != nil {
+		jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetIdempotencyKey in IdempotencyMiddleware)")
+		return
+	}
+	if stored.RequestHash != requestHash {
+		jsonutils.WriteError(w, r, http.StatusUnprocessableEntity, ErrIdempotencyKeyReused, ErrIdempotencyKeyReused.Error())
+		return
+	} else if !stored.ResponseStatus.Valid {
+		w.Header().Set("Retry-After", "1")
+		jsonutils.WriteError(w, r, http.StatusConflict, ErrIdempotencyKeyInFlight, ErrIdempotencyKeyInFlight.Error())
+		return
+	}
+	header := http.Header{"Content-Type": {"application/json"}} // for responses stored before their headers were
+	if len(stored.ResponseHeaders) > 0 {
+		if err := json.Unmarshal(stored.ResponseHeaders, &header); err != nil {
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "could not read the stored response headers")
+			return
+		}
+	}
+	for name, values := range header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.Header().Set("Content-Length", strconv.Itoa(len(stored.ResponseBody)))
+	w.WriteHeader(int(stored.ResponseStatus.Int32))
+	w.Write(stored.ResponseBody)
+}
@@ -0,0 +1,92 @@
+package api
+
+import "net/http"
+
+func (cfg *ApiConfig) RegisterRoutes(mux *http.ServeMux) {
+	// registers the handlers of the /api endpoints on mux. The admin endpoints and the frontend are registered in main.go
+	//handler_status.go
+	mux.HandleFunc("GET /api/healthz", cfg.ReadinessHandler) // ok
+	//handler_users.go
+	mux.Handle("POST /api/users", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostUsers)))                    // ok
+	mux.Handle("PUT /api/users", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutUsers)))                      // ok
+	mux.Handle("PUT /api/users/{user_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutUsersByID))) // ok
+	mux.Handle("GET /api/users", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetUsers)))                      // ok
+	mux.HandleFunc("GET /api/users/{user_public_id}", cfg.HandlerGetUsersByID)                                       // ok
+	mux.Handle("POST /api/users/{user_public_id}/unlock", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerUnlockUser)))
+	//mux.HandleFunc("DELETE /api/users", cfg.HandlerDeleteUsers) NYI do I even want this
+	//handler_locations.go
+	mux.Handle("POST /api/locations", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostLocations)))
+	mux.Handle("PUT /api/locations/{location_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutLocationsByPublicID)))
+	mux.HandleFunc("GET /api/locations", cfg.HandlerGetLocations)
+	mux.HandleFunc("GET /api/locations/{location_public_id}", cfg.HandlerGetLocationsByPublicID)
+	mux.Handle("GET /api/users/{user_public_id}/locations", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetUserLocations)))
+	mux.Handle("PUT /api/users/{user_public_id}/locations", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutUserLocations)))
+	//handler_openinghours.go
+	mux.HandleFunc("GET /api/locations/{location_public_id}/opening-hours", cfg.HandlerGetOpeningHours)
+	mux.Handle("POST /api/locations/{location_public_id}/opening-hours", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostOpeningHours)))
+	mux.Handle("DELETE /api/locations/{location_public_id}/opening-hours/{opening_hour_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerDeleteOpeningHoursByPublicID)))
+	mux.HandleFunc("GET /api/locations/{location_public_id}/holidays", cfg.HandlerGetHolidays)
+	mux.Handle("POST /api/locations/{location_public_id}/holidays", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostHolidays)))
+	mux.Handle("DELETE /api/locations/{location_public_id}/holidays/{holiday_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerDeleteHolidaysByPublicID)))
+	//handler_announcements.go
+	mux.HandleFunc("GET /api/locations/{location_public_id}/announcement-templates", cfg.HandlerGetAnnouncementTemplates)
+	mux.Handle("POST /api/locations/{location_public_id}/announcement-templates", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostAnnouncementTemplates)))
+	mux.Handle("DELETE /api/locations/{location_public_id}/announcement-templates/{announcement_template_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerDeleteAnnouncementTemplatesByPublicID)))
+	mux.HandleFunc("GET /api/locations/{location_public_id}/display", cfg.HandlerGetDisplay)
+	//handler_auth.go
+	mux.HandleFunc("POST /api/login", cfg.HandlerLoginUser)                                                                  // ok
+	mux.HandleFunc("GET /api/refresh", cfg.HandlerGetRefreshTokens)                                                          // ok (requires dev environment)
+	mux.HandleFunc("POST /api/refresh", cfg.HandlerRefreshUser)                                                              // ok (authenticates with the refresh token cookie only)
+	mux.Handle("POST /api/logout", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerLogoutUser)))                          // ok
+	mux.Handle("POST /api/revoke", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerRevokeAllRefreshTokens)))              // ok
+	mux.Handle("POST /api/revoke/{user_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerRevokeRefreshToken))) // ok
+	mux.HandleFunc("GET /api/csrf", cfg.HandlerGetCSRFToken)
+	mux.Handle("GET /api/sessions", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetSessions)))
+	mux.Handle("DELETE /api/sessions/{session_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerDeleteSessionsByPublicID)))
+	//handler_invitations.go
+	mux.Handle("POST /api/invitations", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostInvitations)))
+	mux.HandleFunc("POST /api/invitations/accept", cfg.HandlerAcceptInvitation)
+	mux.HandleFunc("POST /api/password-reset", cfg.HandlerPostPasswordReset)
+	mux.HandleFunc("POST /api/password-reset/confirm", cfg.HandlerConfirmPasswordReset)
+	//handler_visitors.go
+	mux.Handle("POST /api/visitors", cfg.IdempotencyMiddleware(http.HandlerFunc(cfg.HandlerPostVisitors)))                          // ok
+	mux.Handle("PUT /api/visitors/{visitor_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutVisitorsByPublicID))) // ok
+	mux.Handle("GET /api/visitors", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetVisitors)))                               // ok
+	mux.Handle("GET /api/visitors/{visitor_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetVisitorsByPublicID))) // ok
+	//handler_tickets.go
+	mux.HandleFunc("GET /api/visitors/{visitor_public_id}/ticket", cfg.HandlerGetVisitorTicket)
+	mux.HandleFunc("GET /api/purposes/{purpose_public_id}/ticket-layout", cfg.HandlerGetTicketLayout)
+	mux.Handle("PUT /api/purposes/{purpose_public_id}/ticket-layout", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutTicketLayout)))
+	//handler_qrcodes.go
+	mux.HandleFunc("GET /api/visitors/{visitor_public_id}/qrcode", cfg.HandlerGetVisitorQRCode)
+	//handler_privacy.go
+	mux.Handle("GET /api/visitors/{visitor_public_id}/export", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetVisitorExport)))
+	mux.Handle("POST /api/visitors/{visitor_public_id}/erase", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostVisitorErase)))
+	//handler_desks.go
+	mux.Handle("POST /api/desks", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostDesks)))                          // ok
+	mux.Handle("PUT /api/desks/{desk_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutDesksByPublicID))) // ok
+	mux.Handle("GET /api/desks", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetDesks)))                            // ok
+	mux.HandleFunc("GET /api/desks/{desk_public_id}", cfg.HandlerGetDesksByPublicID)                                       // ok
+	//handler_purposes.go
+	mux.Handle("POST /api/purposes", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostPurposes)))                       // ok
+	mux.Handle("PUT /api/purposes/{purpose_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutPurposesByID))) // ok
+	mux.HandleFunc("GET /api/purposes", cfg.HandlerGetPurposes)                                                               // ok no auth needed
+	mux.Handle("PUT /api/purposes/{purpose_public_id}/translations", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutPurposeTranslations)))
+	mux.HandleFunc("GET /api/purposes/{purpose_public_id}", cfg.HandlerGetPurposesByID) // NYI is this needed? Maybe GetPurposesByName instead?
+	//handler_audit.go
+	mux.Handle("GET /api/audit", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetAudit)))
+	//handler_retention.go
+	mux.Handle("POST /api/retention", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostRetention)))
+	//handler_webhooks.go
+	mux.Handle("POST /api/webhooks", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostWebhooks)))
+	mux.Handle("GET /api/webhooks", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetWebhooks)))
+	mux.Handle("GET /api/webhooks/{webhook_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetWebhooksByPublicID)))
+	mux.Handle("DELETE /api/webhooks/{webhook_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerDeleteWebhooksByPublicID)))
+	mux.Handle("GET /api/webhooks/{webhook_public_id}/deliveries", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetWebhookDeliveries)))
+	mux.Handle("POST /api/webhooks/deliveries/{delivery_public_id}/redeliver", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPostWebhookRedelivery)))
+	//handler_servicelogs.go
+	mux.Handle("POST /api/servicelogs", cfg.AuthUserMiddleware(cfg.IdempotencyMiddleware(http.HandlerFunc(cfg.HandlerPostServicelogs)))) // NYI
+	mux.Handle("PUT /api/servicelogs/{servicelog_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerPutServicelogsByID)))   // NYI
+	mux.Handle("GET /api/servicelogs", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetServicelogs)))                              // NYI
+	mux.Handle("GET /api/servicelogs/{servicelog_public_id}", cfg.AuthUserMiddleware(http.HandlerFunc(cfg.HandlerGetServicelogsByPublicID)))
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/validate"
+)
+
+func (cfg *ApiConfig) validator() *validate.Validator {
+	// the validator of request bodies, whose ref rules look up locations, purposes, desks, visitors and users
+	return &validate.Validator{Refs: map[string]validate.Lookup{
+		"location": func(ctx context.Context, publicID string) error {
+			_, err := cfg.DB.GetLocationByPublicID(ctx, publicID)
+			return notFound(ErrLocationNotFound, err)
+		},
+		"purpose": func(ctx context.Context, publicID string) error {
+			_, err := cfg.DB.GetPurposesByPublicID(ctx, publicID)
+			return notFound(ErrPurposeNotFound, err)
+		},
+		"desk": func(ctx context.Context, publicID string) error {
+			_, err := cfg.DB.GetDesksByPublicID(ctx, publicID)
+			return notFound(ErrDeskNotFound, err)
+		},
+		"visitor": func(ctx context.Context, publicID string) error {
+			_, err := cfg.DB.GetVisitorsByPublicID(ctx, publicID)
+			return notFound(auth.ErrVisitorNotFound, err)
+		},
+		"user": func(ctx context.Context, publicID string) error {
+			_, err := cfg.DB.GetUserByPublicID(ctx, publicID)
+			return notFound(auth.ErrUserNotFound, err)
+		},
+	}}
+}
+
+func (cfg *ApiConfig) readRequest(w http.ResponseWriter, r *http.Request, request any) bool {
+	// readRequestLimited with the default size limit of request bodies
+	return cfg.readRequestLimited(w, r, request, validate.MaxBodyBytes)
+}
+
+func (cfg *ApiConfig) readRequestLimited(w http.ResponseWriter, r *http.Request, request any, maxBytes int64) bool {
+	/*
+		Decodes the JSON body of r into request, a pointer to a request parameters struct, and checks it against the
+		rules of its validate tags. Returns false after writing an error response if the body is too large, is not JSON,
+		has fields request lacks or breaks rules; every field breaking a rule is listed in that response.
+	*/
+	if err := cfg.validator().Decode(w, r, request, maxBytes); err != nil {
+		status := validate.Status(err)
+		msg := "invalid request body"
+		if status == http.StatusInternalServerError {
+			msg = "error querying database (validator in readRequest)"
+		}
+		jsonutils.WriteError(w, r, status, err, msg)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+)
+
+// entity types
+const (
+	EntityUser                 = "user"
+	EntitySession              = "session"
+	EntityLogin                = "login"
+	EntityVisitor              = "visitor"
+	EntityDesk                 = "desk"
+	EntityPurpose              = "purpose"
+	EntityServiceLog           = "servicelog"
+	EntityLocation             = "location"
+	EntityOpeningHour          = "opening_hour"
+	EntityHoliday              = "holiday"
+	EntityWebhook              = "webhook"
+	EntityAnnouncementTemplate = "announcement_template"
+)
+
+// actions are named <entity type>.<verb>
+const (
+	ActionUserCreate                 = "user.create"
+	ActionUserUpdate                 = "user.update"
+	ActionUserPromote                = "user.promote"
+	ActionUserInvite                 = "user.invite"
+	ActionUserUnlock                 = "user.unlock"
+	ActionUserPasswordReset          = "user.password_reset"
+	ActionUserAcceptInvite           = "user.accept_invitation"
+	ActionUserSetLocations           = "user.set_locations"
+	ActionSessionRevoke              = "session.revoke"
+	ActionSessionRevokeAll           = "session.revoke_all"
+	ActionLoginLockout               = "login.lockout"
+	ActionVisitorCreate              = "visitor.create"
+	ActionVisitorUpdate              = "visitor.update"
+	ActionVisitorRetention           = "visitor.retention"
+	ActionVisitorErase               = "visitor.erase"
+	ActionDeskCreate                 = "desk.create"
+	ActionDeskUpdate                 = "desk.update"
+	ActionPurposeCreate              = "purpose.create"
+	ActionPurposeUpdate              = "purpose.update"
+	ActionPurposeSetTicketLayout     = "purpose.set_ticket_layout"
+	ActionPurposeSetTranslations     = "purpose.set_translations"
+	ActionServiceLogCreate           = "servicelog.create"
+	ActionServiceLogUpdate           = "servicelog.update"
+	ActionLocationCreate             = "location.create"
+	ActionLocationUpdate             = "location.update"
+	ActionOpeningHourCreate          = "opening_hour.create"
+	ActionOpeningHourDelete          = "opening_hour.delete"
+	ActionHolidayCreate              = "holiday.create"
+	ActionAnnouncementTemplateCreate = "announcement_template.create"
+	ActionAnnouncementTemplateDelete = "announcement_template.delete"
+	ActionHolidayDelete              = "holiday.delete"
+	ActionWebhookCreate              = "webhook.create"
+	ActionWebhookDelete              = "webhook.delete"
+	ActionWebhookRedeliver           = "webhook.redeliver"
+)
+
+type Event struct {
+	ActorPublicID  string // empty for anonymous (e.g. a visitor at a kiosk) and system actions
+	Action         string
+	EntityType     string
+	EntityPublicID string
+	Before         any // state before the change, nil for creations
+	After          any // state after the change
+	RequestID      string
+	IPAddress      string
+}
+
+type databaseQueryer interface {
+	CreateAuditEvent(context.Context, database.CreateAuditEventParams) (database.AuditEvent, error)
+}
+
+func Record(ctx context.Context, db databaseQueryer, e Event) (database.AuditEvent, error) {
+	/*
+		Appends an event to the audit log. Pass the storage.Store bound to the transaction of the change itself
+		(storage.Store.InTx), so the change and its audit event are committed or rolled back together.
+	*/
+	diff, err := Diff(e.Before, e.After)
+	if err != nil {
+		return database.AuditEvent{}, err
+	}
+	return db.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		ActorPublicID:  e.ActorPublicID,
+		Action:         e.Action,
+		EntityType:     e.EntityType,
+		EntityPublicID: e.EntityPublicID,
+		Diff:           diff,
+		RequestID:      e.RequestID,
+		IpAddress:      e.IPAddress,
+	})
+}
+
+type fieldChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+func Diff(before, after any) (json.RawMessage, error) {
+	/*
+		Marshals before and after to JSON objects and returns only the fields that differ, as
+		{"field": {"before": ..., "after": ...}}. A nil before or after counts as an object without fields, so for a
+		creation every field shows up with a null before value. Fields named like passwords or tokens are left out.
+	*/
+	b, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	diff := make(map[string]fieldChange)
+	for _, k := range keys {
+		if isSensitive(k) || bytes.Equal(b[k], a[k]) {
+			continue
+		}
+		diff[k] = fieldChange{Before: nullIfEmpty(b[k]), After: nullIfEmpty(a[k])}
+	}
+	return json.Marshal(diff)
+}
+
+func toFields(v any) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if v == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+func isSensitive(field string) bool {
+	field = strings.ToLower(field)
+	return strings.Contains(field, "password") || strings.Contains(field, "token")
+}
+
+func nullIfEmpty(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type deskState struct {
+	Name           string `json:"name"`
+	IsActive       bool   `json:"is_active"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func TestDiff(t *testing.T) {
+	// update: only changed fields, never passwords
+	before := deskState{Name: "F1", IsActive: true, HashedPassword: "old"}
+	after := deskState{Name: "F1", IsActive: false, HashedPassword: "new"}
+	diff, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf(`Diff(update) = %s, %v; expected diff, nil`, diff, err)
+	}
+	expected := `{"is_active":{"before":true,"after":false}}`
+	if string(diff) != expected {
+		t.Errorf(`Diff(update) = %s; expected %s`, diff, expected)
+	}
+
+	// creation: every field with a null before value
+	diff, _ = Diff(nil, deskState{Name: "F2"})
+	var changes map[string]fieldChange
+	if err := json.Unmarshal(diff, &changes); err != nil {
+		t.Fatalf(`Diff(creation) = %s, not a JSON object: %v`, diff, err)
+	}
+	if string(changes["name"].Before) != "null" || string(changes["name"].After) != `"F2"` {
+		t.Errorf(`Diff(creation)["name"] = %+v; expected null before, "F2" after`, changes["name"])
+	}
+	if _, ok := changes["hashed_password"]; ok {
+		t.Errorf(`Diff(creation) contains hashed_password; expected it to be left out`)
+	}
+
+	// no changes: empty object
+	diff, _ = Diff(before, before)
+	if string(diff) != `{}` {
+		t.Errorf(`Diff(no changes) = %s; expected {}`, diff)
+	}
+}
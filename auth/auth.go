@@ -2,18 +2,29 @@ package auth
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
 )
 
-var ErrWrongUserType = errors.New("usertype supplied in JWT is not valid")
-var ErrVisitorMismatch = errors.New("accessing visitor is not visitor identified in endpoint URI")
-var ErrUserInactive = errors.New("user account is inactive")
-var ErrUserNotAdmin = errors.New("user account is not an admin")
+var ErrWrongUserType = jsonutils.NewError(jsonutils.CodeWrongUserType, "usertype supplied in JWT is not valid")
+var ErrVisitorMismatch = jsonutils.NewError(jsonutils.CodeVisitorMismatch, "accessing visitor is not visitor identified in endpoint URI")
+var ErrUserInactive = jsonutils.NewError(jsonutils.CodeUserInactive, "user account is inactive")
+var ErrUserNotAdmin = jsonutils.NewError(jsonutils.CodeNotAdmin, "user account is not an admin")
+var ErrSessionEnded = jsonutils.NewError(jsonutils.CodeSessionEnded, "auth: session has been ended")
+var ErrUserNotFound = jsonutils.NewError(jsonutils.CodeUserNotFound, "user not found")
+var ErrVisitorNotFound = jsonutils.NewError(jsonutils.CodeVisitorNotFound, "visitor not found")
+
+func errNotFound(userType string) *jsonutils.Error {
+	// the not found error of a user type, user or visitor
+	if userType == "visitor" {
+		return ErrVisitorNotFound
+	}
+	return ErrUserNotFound
+}
 
 type configReader interface {
 	GetSecret() string
@@ -22,8 +33,7 @@ type configReader interface {
 type databaseQueryer interface {
 	GetUserByPublicID(context.Context, string) (database.User, error)
 	GetVisitorsByPublicID(context.Context, string) (database.Visitor, error)
-	CreateRefreshToken(context.Context, database.CreateRefreshTokenParams) (database.RefreshToken, error)
-	RevokeRefreshTokenByToken(context.Context, string) (database.RefreshToken, error)
+	RotateRefreshTokenByToken(context.Context, database.RotateRefreshTokenByTokenParams) (database.RefreshToken, error)
 }
 
 type ContextKey string
@@ -36,6 +46,7 @@ const UserIDContextKey ContextKey = "userID"
 const VisitorIDContextKey ContextKey = "visitorID"
 const UserPublicIDContextKey ContextKey = "userPublicID"
 const VisitorPublicIDContextKey ContextKey = "visitorPublicID"
+const SessionPublicIDContextKey ContextKey = "sessionPublicID"
 
 func SetAuthCookies(w http.ResponseWriter, accessToken, refreshToken, expectedAuthType string, accessTokenMinuteDuration, refreshTokenDayDuration int) {
 	// Access Token Cookie
@@ -35,6 +35,54 @@ func TestPassword(t *testing.T) {
 
 }
 
+func TestPasswordHasher(t *testing.T) {
+	// cheap parameters, these tests are about formats and not about strength
+	bcryptHasher := PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: 4}
+	argonHasher := PasswordHasher{Algorithm: PasswordAlgorithmArgon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1}
+
+	argonHash, err := argonHasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf(`argonHasher.Hash() = %s, %v; expected hash, nil`, argonHash, err)
+	}
+	bcryptHash, err := bcryptHasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf(`bcryptHasher.Hash() = %s, %v; expected hash, nil`, bcryptHash, err)
+	}
+
+	// both hashers can check both formats
+	for _, h := range []PasswordHasher{bcryptHasher, argonHasher} {
+		if err := h.Check(argonHash, "correct horse battery staple"); err != nil {
+			t.Errorf(`%s Check(argonHash, correct) = %v; expected nil`, h.Algorithm, err)
+		}
+		if err := h.Check(bcryptHash, "correct horse battery staple"); err != nil {
+			t.Errorf(`%s Check(bcryptHash, correct) = %v; expected nil`, h.Algorithm, err)
+		}
+		if h.Check(argonHash, "incorrect horse") == nil {
+			t.Errorf(`%s Check(argonHash, incorrect) = nil; expected err`, h.Algorithm)
+		}
+	}
+
+	// rehash when algorithm or parameters change
+	if bcryptHasher.NeedsRehash(bcryptHash) {
+		t.Errorf(`bcryptHasher.NeedsRehash(bcryptHash) = true; expected false`)
+	}
+	if !argonHasher.NeedsRehash(bcryptHash) {
+		t.Errorf(`argonHasher.NeedsRehash(bcryptHash) = false; expected true`)
+	}
+	if argonHasher.NeedsRehash(argonHash) {
+		t.Errorf(`argonHasher.NeedsRehash(argonHash) = true; expected false`)
+	}
+	strongerArgonHasher := argonHasher
+	strongerArgonHasher.Argon2Time = 2
+	if !strongerArgonHasher.NeedsRehash(argonHash) {
+		t.Errorf(`strongerArgonHasher.NeedsRehash(argonHash) = false; expected true`)
+	}
+	strongerBcryptHasher := PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: 5}
+	if !strongerBcryptHasher.NeedsRehash(bcryptHash) {
+		t.Errorf(`strongerBcryptHasher.NeedsRehash(bcryptHash) = false; expected true`)
+	}
+}
+
 func TestUserJWT(t *testing.T) {
 	// arguments
 	pidGenerator, err := nanoid.Standard(8)
@@ -64,3 +112,25 @@ func TestUserJWT(t *testing.T) {
 		t.Errorf(`ValidateJWT(jwt, "zasxzasx") = %v, %v; expected "", err`, wrongID, err)
 	}
 }
+
+func TestSessionJWT(t *testing.T) {
+	// access tokens carry the session they were issued for, plain MakeJWT tokens carry none
+	sessionToken, err := MakeSessionJWT("user1234", "session1", "user", "secret", 60)
+	if err != nil {
+		t.Fatalf(`MakeSessionJWT() = %s, %v; expected token, nil`, sessionToken, err)
+	}
+	claims, err := ValidateJWTClaims(sessionToken, "secret")
+	if err != nil || claims.Subject != "user1234" || claims.SessionID != "session1" {
+		t.Errorf(`ValidateJWTClaims(sessionToken) = %+v, %v; expected subject user1234 and session session1`, claims, err)
+	}
+
+	plainToken, _ := MakeJWT("user1234", "user", "secret", 60)
+	claims, err = ValidateJWTClaims(plainToken, "secret")
+	if err != nil || claims.SessionID != "" {
+		t.Errorf(`ValidateJWTClaims(plainToken) = %+v, %v; expected empty session, nil`, claims, err)
+	}
+
+	if _, err = ValidateJWTClaims(sessionToken, "other secret"); err == nil {
+		t.Errorf(`ValidateJWTClaims(sessionToken, "other secret") = nil error; expected signature error`)
+	}
+}
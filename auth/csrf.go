@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+var ErrCSRFTokenInvalid = jsonutils.NewError(jsonutils.CodeCSRFTokenInvalid, "auth: CSRF token missing or invalid")
+var ErrCSRFOriginInvalid = jsonutils.NewError(jsonutils.CodeCSRFOriginInvalid, "auth: request origin not allowed")
+
+const CSRFCookieName = "csrf_token"
+const CSRFHeaderName = "X-CSRF-Token"
+
+func MakeCSRFToken(tokenSecret string) (string, error) {
+	/*
+		Makes a token for the signed double-submit pattern: a random nonce plus an HMAC of that nonce. The signature
+		means a cookie planted by another (sub)domain does not pass CheckCSRFToken, as it was not issued by this server.
+	*/
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(key)
+	return nonce + "." + csrfSignature(nonce, tokenSecret), nil
+}
+
+func csrfSignature(nonce, tokenSecret string) string {
+	mac := hmac.New(sha256.New, []byte(tokenSecret))
+	mac.Write([]byte("csrf:" + nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func CheckCSRFToken(r *http.Request, tokenSecret string) error {
+	/*
+		Checks that the X-CSRF-Token header matches the csrf_token cookie and carries a valid signature. A cross-site
+		request can make the browser send the cookie, but cannot read it to copy it into the header.
+	*/
+	cookie, err := r.Cookie(CSRFCookieName)
+	if err != nil || cookie.Value == "" {
+		return ErrCSRFTokenInvalid
+	}
+	header := r.Header.Get(CSRFHeaderName)
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+		return ErrCSRFTokenInvalid
+	}
+	nonce, signature, ok := strings.Cut(header, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(csrfSignature(nonce, tokenSecret))) {
+		return ErrCSRFTokenInvalid
+	}
+	return nil
+}
+
+func CheckOrigin(r *http.Request, trustedOrigins []string) error {
+	/*
+		Checks the Origin header, falling back to the Referer header, against the host the request was sent to and
+		the trusted origins (e.g. "https://queue.example.org"). Requests carrying neither header, such as those from
+		non-browser clients, pass: the CSRF token check still applies to them.
+	*/
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			return nil
+		}
+		u, err := url.Parse(referer)
+		if err != nil {
+			return ErrCSRFOriginInvalid
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" { // also catches the "null" origin sent by sandboxed documents
+		return ErrCSRFOriginInvalid
+	}
+	if strings.EqualFold(u.Host, r.Host) { // scheme is not compared, as TLS may be terminated by a proxy in front of goqueue
+		return nil
+	}
+	for _, trusted := range trustedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(trusted, "/"), u.Scheme+"://"+u.Host) {
+			return nil
+		}
+	}
+	return ErrCSRFOriginInvalid
+}
+
+func IsSafeMethod(method string) bool {
+	// safe methods do not change state and are therefore not subject to CSRF checks
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func SetCSRFCookie(w http.ResponseWriter, token string, refreshTokenDayDuration int) {
+	// not HttpOnly: client side scripts read this cookie to copy the token into the X-CSRF-Token header
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(time.Duration(refreshTokenDayDuration) * 24 * time.Hour), // lives as long as a session
+		HttpOnly: false,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
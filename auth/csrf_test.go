@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckCSRFToken(t *testing.T) {
+	token, err := MakeCSRFToken("secret")
+	if err != nil {
+		t.Fatalf(`MakeCSRFToken() = %s, %v; expected token, nil`, token, err)
+	}
+	forged, _ := MakeCSRFToken("other secret")
+
+	tests := []struct {
+		name     string
+		cookie   string
+		header   string
+		expected error
+	}{
+		{"matching", token, token, nil},
+		{"no header", token, "", ErrCSRFTokenInvalid},
+		{"no cookie", "", token, ErrCSRFTokenInvalid},
+		{"mismatch", token, forged, ErrCSRFTokenInvalid},
+		{"planted cookie signed elsewhere", forged, forged, ErrCSRFTokenInvalid},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/api/servicelogs", nil)
+		if tc.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tc.cookie})
+		}
+		if tc.header != "" {
+			r.Header.Set(CSRFHeaderName, tc.header)
+		}
+		if err := CheckCSRFToken(r, "secret"); err != tc.expected {
+			t.Errorf(`CheckCSRFToken(%s) = %v; expected %v`, tc.name, err, tc.expected)
+		}
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	trusted := []string{"https://queue.example.org"}
+	tests := []struct {
+		name     string
+		origin   string
+		referer  string
+		expected error
+	}{
+		{"same host", "http://example.com", "", nil},
+		{"trusted origin", "https://queue.example.org", "", nil},
+		{"trusted origin from referer", "", "https://queue.example.org/desks?x=1", nil},
+		{"no headers", "", "", nil},
+		{"foreign origin", "https://evil.example.net", "", ErrCSRFOriginInvalid},
+		{"foreign referer", "", "https://evil.example.net/form", ErrCSRFOriginInvalid},
+		{"null origin", "null", "", ErrCSRFOriginInvalid},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest(http.MethodPost, "http://example.com/api/desks", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if tc.referer != "" {
+			r.Header.Set("Referer", tc.referer)
+		}
+		if err := CheckOrigin(r, trusted); err != tc.expected {
+			t.Errorf(`CheckOrigin(%s) = %v; expected %v`, tc.name, err, tc.expected)
+		}
+	}
+}
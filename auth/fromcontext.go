@@ -4,14 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"net/http"
 
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
 )
 
-var ErrNoIDInContext = errors.New("auth: no ID provided in context")
+var ErrNoIDInContext = jsonutils.NewError(jsonutils.CodeNotAuthenticated, "auth: no ID provided in context")
 
 func authFromContext[T any](
 	w http.ResponseWriter,
@@ -32,8 +31,8 @@ func authFromContext[T any](
 
 	// 2. get contextKey value from context
 	IDString, ok := r.Context().Value(ck).(string)
-	if !ok {
-		jsonutils.WriteError(w, http.StatusUnauthorized, ErrNoIDInContext, fmt.Sprintf("no %s ID provided in context (in auth.AuthFromContext)", expectedAuthType))
+	if !ok || IDString == "" { // the auth middleware sets an empty ID for unauthenticated requests
+		jsonutils.WriteError(w, r, http.StatusUnauthorized, ErrNoIDInContext, "not authenticated as "+expectedAuthType)
 		return accessor, ErrNoIDInContext
 	}
 
@@ -41,9 +40,9 @@ func authFromContext[T any](
 	accessor, err := GetByID(r.Context(), IDString)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, err, fmt.Sprintf("%s not found in database (GetByID in auth.AuthFromContext)", expectedAuthType))
+			jsonutils.WriteError(w, r, http.StatusNotFound, errNotFound(expectedAuthType).Wrap(err), expectedAuthType+" of access token not found")
 		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (GetByID in auth.AuthFromContext)")
+			jsonutils.WriteError(w, r, http.StatusInternalServerError, err, "error querying database (GetByID in auth.AuthFromContext)")
 		}
 		return accessor, err
 	}
@@ -64,6 +63,15 @@ func UserFromContext(w http.ResponseWriter, r *http.Request, db databaseQueryer)
 	return user, err
 }
 
+func SessionPublicIDFromContext(r *http.Request) string {
+	/*
+		Returns the public ID of the session (refresh token) the request was authenticated with, or an empty string if
+		the access token predates session management or the request is not authenticated.
+	*/
+	sessionPublicID, _ := r.Context().Value(SessionPublicIDContextKey).(string)
+	return sessionPublicID
+}
+
 func VisitorFromContext(w http.ResponseWriter, r *http.Request, db databaseQueryer) (database.Visitor, error) {
 	/*
 		Implements auth.authFromContext for visitor authentication from cookie.
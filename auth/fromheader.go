@@ -13,12 +13,12 @@ func UserTypeFromHeader(w http.ResponseWriter, r *http.Request, cfg configReader
 	// short function to retrieve purely the usertype from the JWT in the header authorization field
 	accessToken, err := GetBearerToken(r.Header)
 	if err != nil {
-		jsonutils.WriteError(w, 401, err, "no authorization field in request header")
+		jsonutils.WriteError(w, r, 401, err, "no authorization field in request header")
 		return "", err
 	}
 	_, userType, err := ValidateJWT(accessToken, cfg.GetSecret())
 	if err != nil {
-		jsonutils.WriteError(w, 403, err, "access token invalid")
+		jsonutils.WriteError(w, r, 403, err, "access token invalid")
 		return "", err
 	}
 	return userType, nil
@@ -35,27 +35,27 @@ func authFromHeader[T any](
 	var zero T
 	accessToken, err := GetBearerToken(r.Header)
 	if err != nil {
-		jsonutils.WriteError(w, 401, err, "no authorization field in request header")
+		jsonutils.WriteError(w, r, 401, err, "no authorization field in request header")
 		return zero, err
 	}
 
 	//validate token
 	id, userType, err := ValidateJWT(accessToken, cfg.GetSecret())
 	if err != nil {
-		jsonutils.WriteError(w, 403, err, "access token invalid")
+		jsonutils.WriteError(w, r, 403, err, "access token invalid")
 		return zero, err
 	} else if userType != "user" {
-		jsonutils.WriteError(w, 400, err, "not logged in as "+expectedUserType)
+		jsonutils.WriteError(w, r, 400, err, "not logged in as "+expectedUserType)
 		return zero, ErrWrongUserType
 	}
 
 	//query for user by ID and run checks
 	entity, err := queryFunc(r.Context(), id)
 	if err == sql.ErrNoRows {
-		jsonutils.WriteError(w, 404, err, expectedUserType+" not found")
+		jsonutils.WriteError(w, r, 404, errNotFound(expectedUserType).Wrap(err), expectedUserType+" not found")
 		return zero, err
 	} else if err != nil {
-		jsonutils.WriteError(w, 500, err, "error querying database")
+		jsonutils.WriteError(w, r, 500, err, "error querying database")
 		return zero, err
 	}
 
@@ -12,12 +12,18 @@ import (
 
 type ClaimsWithUserType struct {
 	jwt.RegisteredClaims
-	UserType string `json:"usertype"`
+	UserType  string `json:"usertype"`
+	SessionID string `json:"sid,omitempty"` // public ID of the refresh token (session) the access token was issued for
 }
 
 var ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
 
 func MakeJWT(publicID string, userType string, tokenSecret string, expirationMinutes int) (string, error) { // returns JWT as string and error
+	return MakeSessionJWT(publicID, "", userType, tokenSecret, expirationMinutes)
+}
+
+func MakeSessionJWT(publicID, sessionPublicID, userType, tokenSecret string, expirationMinutes int) (string, error) {
+	// like MakeJWT, but ties the access token to a session so it stops working once the session is ended
 	expiresIn := time.Duration(expirationMinutes) * time.Minute
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, ClaimsWithUserType{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -26,12 +32,21 @@ func MakeJWT(publicID string, userType string, tokenSecret string, expirationMin
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 			Subject:   publicID,
 		},
-		UserType: userType,
+		UserType:  userType,
+		SessionID: sessionPublicID,
 	})
 	return token.SignedString([]byte(tokenSecret))
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (string, string, error) { //returns public ID, type (visitor/user) and error
+	claims, err := ValidateJWTClaims(tokenString, tokenSecret)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.Subject, claims.UserType, nil
+}
+
+func ValidateJWTClaims(tokenString, tokenSecret string) (*ClaimsWithUserType, error) { // returns all claims, including the session ID
 	// define claims to unpack into and keyfunc
 	claims := &ClaimsWithUserType{}
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
@@ -45,29 +60,29 @@ func ValidateJWT(tokenString, tokenSecret string) (string, string, error) { //re
 	// parse the token
 	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	// token checks
 	// check if token is valid
 	if !token.Valid {
-		return "", "", fmt.Errorf("token is invalid")
+		return nil, fmt.Errorf("token is invalid")
 	}
 	// check if token is expired
 	if claims.ExpiresAt.Time.Before(time.Now()) {
-		return "", "", jwt.ErrTokenExpired
+		return nil, jwt.ErrTokenExpired
 	}
 	// check if token is issued in the future
 	if claims.IssuedAt != nil && claims.IssuedAt.Time.After(time.Now()) {
-		return "", "", jwt.ErrTokenUsedBeforeIssued
+		return nil, jwt.ErrTokenUsedBeforeIssued
 	}
 	// check if token is issued by the correct issuer
 	if claims.Issuer != "goqueue" {
-		return "", "", jwt.ErrTokenInvalidIssuer
+		return nil, jwt.ErrTokenInvalidIssuer
 	}
 
-	// return ID
-	return claims.Subject, claims.UserType, nil
+	// return claims
+	return claims, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
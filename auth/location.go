@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+var ErrUserNotInLocation = jsonutils.NewError(jsonutils.CodeUserNotInLocation, "auth: user account is not assigned to this location")
+
+type locationQueryer interface {
+	GetLocationsByUserPublicID(context.Context, string) ([]database.Location, error)
+}
+
+func UserInLocation(ctx context.Context, db locationQueryer, user database.User, locationPublicID string) (bool, error) {
+	/*
+		Reports whether user may work with the desks, purposes, visitors and service logs of a location. Admins may
+		access every location, other users only the locations they are assigned to (PUT /api/users/{id}/locations).
+	*/
+	if user.IsAdmin {
+		return true, nil
+	}
+	locations, err := db.GetLocationsByUserPublicID(ctx, user.PublicID)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(locations, func(l database.Location) bool { return l.PublicID == locationPublicID }), nil
+}
+
+func LocationMemberFilter(user database.User) sql.NullString {
+	// the member_public_id argument of the List and Count queries: unset for admins, who see every location
+	if user.IsAdmin {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: user.PublicID, Valid: true}
+}
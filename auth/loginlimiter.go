@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+var ErrLoginThrottled = jsonutils.NewError(jsonutils.CodeLoginThrottled, "auth: too many failed login attempts")
+
+type LoginLimiter interface {
+	/*
+		Tracks failed login attempts per key. Keys are built with LoginAccountKey and LoginIPKey so that a single
+		limiter backend can hold both kinds of counters. Implemented by MemoryLoginLimiter (single instance) and
+		PostgresLoginLimiter (shared between instances).
+	*/
+	// Check returns how long the caller has to wait before the next attempt for key is allowed. Zero means allowed.
+	Check(ctx context.Context, key string) (time.Duration, error)
+	// Attempt is Check and Fail in one: if the next attempt for key is allowed it is registered as failed up front,
+	// so that parallel attempts cannot all pass before the first one fails. Reset the key if the attempt succeeds.
+	Attempt(ctx context.Context, key string) (time.Duration, error)
+	// Fail registers a failed attempt for key and returns the resulting state.
+	Fail(ctx context.Context, key string) (LoginAttemptState, error)
+	// Reset clears all failed attempts for key, e.g. after a successful login or an admin unlock.
+	Reset(ctx context.Context, key string) error
+}
+
+type LoginLimiterPolicy struct {
+	MaxAttempts     int           // failed attempts after which the key is locked out
+	BaseDelay       time.Duration // backoff after the first failure, doubled for every subsequent failure
+	MaxDelay        time.Duration // upper bound for the backoff
+	LockoutDuration time.Duration // lockout length, also the time after which old failures are forgotten
+	OnLockout       func(key string, lockedUntil time.Time)
+}
+
+type LoginAttemptState struct {
+	FailedAttempts int
+	LastFailedAt   time.Time
+	BlockedUntil   time.Time
+	IsLockedOut    bool
+}
+
+func LoginAccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func LoginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (p LoginLimiterPolicy) wait(s LoginAttemptState, now time.Time) time.Duration {
+	if s.BlockedUntil.After(now) {
+		return s.BlockedUntil.Sub(now)
+	}
+	return 0
+}
+
+func (p LoginLimiterPolicy) fail(key string, s LoginAttemptState, now time.Time) LoginAttemptState {
+	/*
+		Computes the state after one more failed attempt. Failures older than the lockout duration are forgotten,
+		as is a lockout that has run out. Reaching MaxAttempts locks the key out for LockoutDuration; below that
+		every failure blocks the key for an exponentially growing delay.
+	*/
+	if s.IsLockedOut && s.BlockedUntil.After(now) { // already locked out, e.g. by a concurrent request
+		return s
+	} else if s.IsLockedOut || now.Sub(s.LastFailedAt) > p.LockoutDuration {
+		s = LoginAttemptState{}
+	}
+	s.FailedAttempts++
+	s.LastFailedAt = now
+
+	if p.MaxAttempts > 0 && s.FailedAttempts >= p.MaxAttempts {
+		s.IsLockedOut = true
+		s.BlockedUntil = now.Add(p.LockoutDuration)
+		if p.OnLockout != nil {
+			p.OnLockout(key, s.BlockedUntil)
+		}
+		return s
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < s.FailedAttempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	s.BlockedUntil = now.Add(delay)
+	return s
+}
+
+// MemoryLoginLimiter keeps attempt counters in process memory. Only suitable when running a single instance.
+type MemoryLoginLimiter struct {
+	policy    LoginLimiterPolicy
+	mu        sync.Mutex
+	attempts  map[string]LoginAttemptState
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLoginLimiter(policy LoginLimiterPolicy) *MemoryLoginLimiter {
+	return &MemoryLoginLimiter{
+		policy:   policy,
+		attempts: make(map[string]LoginAttemptState),
+		now:      time.Now,
+	}
+}
+
+func (l *MemoryLoginLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.policy.wait(l.attempts[key], l.now()), nil
+}
+
+func (l *MemoryLoginLimiter) Attempt(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if wait := l.policy.wait(l.attempts[key], now); wait > 0 {
+		return wait, nil
+	}
+	l.attempts[key] = l.policy.fail(key, l.attempts[key], now)
+	l.sweep(now)
+	return 0, nil
+}
+
+func (l *MemoryLoginLimiter) Fail(ctx context.Context, key string) (LoginAttemptState, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	s := l.policy.fail(key, l.attempts[key], now)
+	l.attempts[key] = s
+	l.sweep(now)
+	return s, nil
+}
+
+func (l *MemoryLoginLimiter) sweep(now time.Time) {
+	/*
+		Removes the keys that are neither blocked nor have failures left to count, which a new failure would start from
+		scratch anyway. Otherwise attempts with made-up emails or from ever new IPs would grow the map for as long as
+		the process runs. Sweeps at most once per lockout duration, so the cost per attempt stays constant.
+	*/
+	if now.Sub(l.lastSweep) < l.policy.LockoutDuration {
+		return
+	}
+	l.lastSweep = now
+	for key, s := range l.attempts {
+		if !s.BlockedUntil.After(now) && now.Sub(s.LastFailedAt) > l.policy.LockoutDuration {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+func (l *MemoryLoginLimiter) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+	return nil
+}
+
+type loginAttemptQueryer interface {
+	GetLoginAttempt(context.Context, string) (database.LoginAttempt, error)
+	FailLoginAttempt(context.Context, database.FailLoginAttemptParams) (database.LoginAttempt, error)
+	DeleteLoginAttempt(context.Context, string) error
+}
+
+// PostgresLoginLimiter keeps attempt counters in the login_attempts table so they are shared between instances. Every
+// failure is registered in a single statement, which applies the policy in the database itself.
+type PostgresLoginLimiter struct {
+	policy LoginLimiterPolicy
+	db     loginAttemptQueryer
+	now    func() time.Time
+}
+
+func NewPostgresLoginLimiter(db loginAttemptQueryer, policy LoginLimiterPolicy) *PostgresLoginLimiter {
+	return &PostgresLoginLimiter{
+		policy: policy,
+		db:     db,
+		now:    func() time.Time { return time.Now().UTC() }, // timestamps are stored without time zone
+	}
+}
+
+func (l *PostgresLoginLimiter) get(ctx context.Context, key string) (LoginAttemptState, error) {
+	a, err := l.db.GetLoginAttempt(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return LoginAttemptState{}, nil
+	} else if err != nil {
+		return LoginAttemptState{}, err
+	}
+	return loginAttemptState(a), nil
+}
+
+func loginAttemptState(a database.LoginAttempt) LoginAttemptState {
+	return LoginAttemptState{
+		FailedAttempts: int(a.FailedAttempts),
+		LastFailedAt:   a.LastFailedAt,
+		BlockedUntil:   a.BlockedUntil,
+		IsLockedOut:    a.IsLockedOut,
+	}
+}
+
+func (l *PostgresLoginLimiter) fail(ctx context.Context, key string, onlyUnblocked bool, now time.Time) (LoginAttemptState, bool, error) {
+	/*
+		Registers a failed attempt for key, unless key is locked out or, if onlyUnblocked is set, blocked at all.
+		Returns the resulting state and whether the attempt was registered.
+	*/
+	maxDelay := l.policy.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = l.policy.BaseDelay // no backoff without MaxDelay, see LoginLimiterPolicy.fail
+	}
+	a, err := l.db.FailLoginAttempt(ctx, database.FailLoginAttemptParams{
+		AttemptKey:    key,
+		Now:           now,
+		MaxAttempts:   int32(l.policy.MaxAttempts),
+		LockedUntil:   now.Add(l.policy.LockoutDuration),
+		BaseDelay:     l.policy.BaseDelay.Seconds(),
+		MaxDelay:      maxDelay.Seconds(),
+		ForgetBefore:  now.Add(-l.policy.LockoutDuration),
+		OnlyUnblocked: onlyUnblocked,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		s, err := l.get(ctx, key)
+		return s, false, err
+	} else if err != nil {
+		return LoginAttemptState{}, false, err
+	}
+	s := loginAttemptState(a)
+	if s.IsLockedOut && l.policy.OnLockout != nil { // locked out by this attempt: running lockouts are left alone
+		l.policy.OnLockout(key, s.BlockedUntil)
+	}
+	return s, true, nil
+}
+
+func (l *PostgresLoginLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	s, err := l.get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return l.policy.wait(s, l.now()), nil
+}
+
+func (l *PostgresLoginLimiter) Attempt(ctx context.Context, key string) (time.Duration, error) {
+	now := l.now()
+	s, registered, err := l.fail(ctx, key, true, now)
+	if err != nil || registered {
+		return 0, err
+	}
+	return l.policy.wait(s, now), nil
+}
+
+func (l *PostgresLoginLimiter) Fail(ctx context.Context, key string) (LoginAttemptState, error) {
+	s, _, err := l.fail(ctx, key, false, l.now())
+	return s, err
+}
+
+func (l *PostgresLoginLimiter) Reset(ctx context.Context, key string) error {
+	return l.db.DeleteLoginAttempt(ctx, key)
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dcrauwels/goqueue/migrate"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+func TestMemoryLoginLimiter(t *testing.T) {
+	// preliminary setup: fake clock and lockout hook
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	var lockedKey string
+	policy := LoginLimiterPolicy{
+		MaxAttempts:     3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutDuration: 15 * time.Minute,
+		OnLockout:       func(key string, _ time.Time) { lockedKey = key },
+	}
+	l := NewMemoryLoginLimiter(policy)
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+	key := LoginAccountKey("JDoe@provider.tld ")
+
+	// fresh key
+	if wait, _ := l.Check(ctx, key); wait != 0 {
+		t.Errorf(`Check(fresh key) = %v; expected 0`, wait)
+	}
+
+	// first failure: base delay
+	l.Fail(ctx, key)
+	if wait, _ := l.Check(ctx, key); wait != time.Second {
+		t.Errorf(`Check after 1 failure = %v; expected 1s`, wait)
+	}
+
+	// second failure: doubled delay
+	now = now.Add(time.Second)
+	l.Fail(ctx, key)
+	if wait, _ := l.Check(ctx, key); wait != 2*time.Second {
+		t.Errorf(`Check after 2 failures = %v; expected 2s`, wait)
+	}
+
+	// third failure: lockout
+	now = now.Add(2 * time.Second)
+	s, _ := l.Fail(ctx, key)
+	if !s.IsLockedOut {
+		t.Errorf(`Fail() after 3 failures returned IsLockedOut = false; expected true`)
+	}
+	if lockedKey != "account:jdoe@provider.tld" {
+		t.Errorf(`OnLockout called with %q; expected "account:jdoe@provider.tld"`, lockedKey)
+	}
+	if wait, _ := l.Check(ctx, key); wait != 15*time.Minute {
+		t.Errorf(`Check after lockout = %v; expected 15m`, wait)
+	}
+
+	// lockout expires and the next failure starts counting from scratch
+	now = now.Add(15 * time.Minute)
+	if wait, _ := l.Check(ctx, key); wait != 0 {
+		t.Errorf(`Check after lockout expired = %v; expected 0`, wait)
+	}
+	s, _ = l.Fail(ctx, key)
+	if s.FailedAttempts != 1 || s.IsLockedOut {
+		t.Errorf(`Fail() after expired lockout = %+v; expected 1 failed attempt, not locked out`, s)
+	}
+
+	// reset (admin unlock / successful login)
+	l.Reset(ctx, key)
+	if wait, _ := l.Check(ctx, key); wait != 0 {
+		t.Errorf(`Check after Reset = %v; expected 0`, wait)
+	}
+}
+
+func TestMemoryLoginLimiterSweep(t *testing.T) {
+	// keys nobody tries again are forgotten once their failures no longer count
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	l := NewMemoryLoginLimiter(LoginLimiterPolicy{MaxAttempts: 3, BaseDelay: time.Second, LockoutDuration: 15 * time.Minute})
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+	for i := range 100 {
+		l.Fail(ctx, LoginIPKey("192.0.2."+strconv.Itoa(i)))
+	}
+	now = now.Add(10 * time.Minute)
+	for range 3 {
+		l.Fail(ctx, LoginAccountKey("locked@provider.tld"))
+	}
+
+	// a lockout running when the others expire is kept
+	now = now.Add(5*time.Minute + time.Second)
+	l.Fail(ctx, LoginAccountKey("new@provider.tld"))
+	if len(l.attempts) != 2 {
+		t.Errorf(`MemoryLoginLimiter holds %d keys after their failures expired, expected 2`, len(l.attempts))
+	}
+	if wait, _ := l.Check(ctx, LoginAccountKey("locked@provider.tld")); wait == 0 {
+		t.Errorf(`Check of a locked out key returned 0 after a sweep, expected it to still be locked out`)
+	}
+}
+
+func TestLoginLimiterBackoffCap(t *testing.T) {
+	policy := LoginLimiterPolicy{
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Second,
+		LockoutDuration: time.Hour,
+	}
+	now := time.Now()
+	var s LoginAttemptState
+	for range 10 {
+		s = policy.fail("ip:127.0.0.1", s, now)
+	}
+	if s.IsLockedOut {
+		t.Errorf(`policy without MaxAttempts locked out; expected only backoff`)
+	}
+	if wait := policy.wait(s, now); wait != 5*time.Second {
+		t.Errorf(`wait after 10 failures = %v; expected 5s (MaxDelay)`, wait)
+	}
+}
+
+func TestLoginLimiterConcurrentFailures(t *testing.T) {
+	// preliminary setup: the in-memory limiter and the database one on both kinds of store
+	policy := LoginLimiterPolicy{
+		MaxAttempts:     100,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutDuration: 15 * time.Minute,
+	}
+	db, engine, err := storage.OpenDB("sqlite:" + filepath.Join(t.TempDir(), "goqueue.db"))
+	if err != nil {
+		t.Fatalf(`OpenDB: %v`, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, engine)
+	if err != nil {
+		t.Fatalf(`migrate.New: %v`, err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf(`Up: %v`, err)
+	}
+	limiters := map[string]LoginLimiter{
+		"memory":          NewMemoryLoginLimiter(policy),
+		"postgres/memory": NewPostgresLoginLimiter(storage.NewMemory(), policy),
+		"postgres/sqlite": NewPostgresLoginLimiter(storage.New(db, engine), policy),
+	}
+	ctx := context.Background()
+	const n = 20
+
+	for name, l := range limiters {
+		// every one of a burst of failures counts
+		key := LoginAccountKey("burst@provider.tld")
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := l.Fail(ctx, key); err != nil {
+					t.Errorf(`%s: Fail() returned %v`, name, err)
+				}
+			}()
+		}
+		wg.Wait()
+		if s, err := l.Fail(ctx, key); err != nil || s.FailedAttempts != n+1 {
+			t.Errorf(`%s: Fail() after %d concurrent failures = %+v, %v; expected %d failed attempts`, name, n, s, err, n+1)
+		}
+
+		// of a burst of attempts only the first gets in, the others have to wait for it to fail
+		l.Reset(ctx, key)
+		var allowed atomic.Int32
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				wait, err := l.Attempt(ctx, key)
+				if err != nil {
+					t.Errorf(`%s: Attempt() returned %v`, name, err)
+				} else if wait == 0 {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if allowed.Load() != 1 {
+			t.Errorf(`%s: %d of %d concurrent attempts were allowed; expected 1`, name, allowed.Load(), n)
+		}
+	}
+}
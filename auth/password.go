@@ -1,15 +1,168 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+var ErrUnknownPasswordHash = errors.New("auth: unknown password hash format")
+
+type PasswordHasher struct {
+	/*
+		Hashes and checks passwords. Zero values fall back to the defaults of DefaultPasswordHasher, so the zero
+		PasswordHasher hashes with bcrypt. Checking works for both bcrypt and argon2id hashes regardless of the
+		configured algorithm, so existing hashes keep working after switching algorithms.
+	*/
+	Algorithm     string // PasswordAlgorithmBcrypt or PasswordAlgorithmArgon2id
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32 // in KiB
+	Argon2Threads uint8
+}
+
+var DefaultPasswordHasher = PasswordHasher{
+	Algorithm:     PasswordAlgorithmBcrypt,
+	BcryptCost:    12,
+	Argon2Time:    2,
+	Argon2Memory:  19 * 1024,
+	Argon2Threads: 1,
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+func (h PasswordHasher) withDefaults() PasswordHasher {
+	if h.Algorithm == "" {
+		h.Algorithm = DefaultPasswordHasher.Algorithm
+	}
+	if h.BcryptCost == 0 {
+		h.BcryptCost = DefaultPasswordHasher.BcryptCost
+	}
+	if h.Argon2Time == 0 {
+		h.Argon2Time = DefaultPasswordHasher.Argon2Time
+	}
+	if h.Argon2Memory == 0 {
+		h.Argon2Memory = DefaultPasswordHasher.Argon2Memory
+	}
+	if h.Argon2Threads == 0 {
+		h.Argon2Threads = DefaultPasswordHasher.Argon2Threads
+	}
+	return h
+}
+
+func (h PasswordHasher) MaxPasswordBytes() int {
+	// bcrypt ignores everything after 72 bytes, so longer passwords are refused rather than silently truncated
+	if h.withDefaults().Algorithm == PasswordAlgorithmBcrypt {
+		return 72
+	}
+	return 1024
+}
+
+func (h PasswordHasher) Hash(password string) (string, error) {
+	h = h.withDefaults()
+	switch h.Algorithm {
+	case PasswordAlgorithmBcrypt:
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashedPassword), nil
+	case PasswordAlgorithmArgon2id:
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, h.Argon2Time, h.Argon2Memory, h.Argon2Threads, argon2KeyLength)
+		// PHC string format, as used by the reference implementation
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, h.Argon2Memory, h.Argon2Time, h.Argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	default:
+		return "", fmt.Errorf("auth: unknown password algorithm %q", h.Algorithm)
+	}
+}
+
+func (h PasswordHasher) Check(hash, password string) error {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	}
+	p, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, p.Argon2Time, p.Argon2Memory, p.Argon2Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword // same error as a bcrypt mismatch, so callers need not care about the algorithm
+	}
+	return nil
+}
+
+func (h PasswordHasher) NeedsRehash(hash string) bool {
+	/*
+		Reports whether hash was made with a different algorithm or with different parameters than currently
+		configured. Used to transparently upgrade hashes when a user logs in.
+	*/
+	h = h.withDefaults()
+	if strings.HasPrefix(hash, "$argon2id$") {
+		if h.Algorithm != PasswordAlgorithmArgon2id {
+			return true
+		}
+		p, _, _, err := parseArgon2idHash(hash)
+		return err != nil || p.Argon2Time != h.Argon2Time || p.Argon2Memory != h.Argon2Memory || p.Argon2Threads != h.Argon2Threads
+	}
+	if h.Algorithm != PasswordAlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.BcryptCost
+}
+
+func parseArgon2idHash(hash string) (PasswordHasher, []byte, []byte, error) {
+	// expects $argon2id$v=19$m=...,t=...,p=...$salt$key
+	var p PasswordHasher
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Argon2Memory, &p.Argon2Time, &p.Argon2Threads); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return password, err
+		return p, nil, nil, ErrUnknownPasswordHash
 	}
-	return string(hashedPassword), nil
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	p.Algorithm = PasswordAlgorithmArgon2id
+	return p, salt, key, nil
+}
+
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher.Hash(password)
 }
 
 func CheckPasswordHash(hash, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return DefaultPasswordHasher.Check(hash, password)
 }
@@ -6,12 +6,14 @@ import (
 	"encoding/hex"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/dcrauwels/goqueue/internal/database"
 	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/strutils"
 )
 
-var ErrRefreshTokenInvalid = errors.New("could not revoke old refresh token as it was not found in database")
+var ErrRefreshTokenInvalid = jsonutils.NewError(jsonutils.CodeRefreshTokenInvalid, "auth: refresh token not found, expired or revoked")
 
 func MakeRefreshToken() (string, error) {
 	// get the hex
@@ -26,44 +28,34 @@ func MakeRefreshToken() (string, error) {
 	return encodedKey, nil
 }
 
-func RotateRefreshToken(db databaseQueryer, w http.ResponseWriter, r *http.Request, oldRefreshTokenCookie *http.Cookie) (database.RefreshToken, error) {
+func RotateRefreshToken(db databaseQueryer, r *http.Request, oldRefreshTokenCookie *http.Cookie, refreshTokenDayDuration int) (database.RefreshToken, error) {
 	/*
-		Function to rotate (revoke, then create anew) a refresh token.
+		Function to rotate a refresh token: the token value is replaced in place, so the session keeps its public ID and
+		creation time while the old value stops working. The expiry is extended and the last use, user agent and IP of the
+		session are updated. Returns ErrRefreshTokenInvalid if the old token is unknown, expired or revoked. Does not write
+		a response: callers decide how to report errors.
 	*/
 
-	// 1. init
-	result := database.RefreshToken{}
-
-	// 2. get and revoke old refresh token from cookie
-	oldRefreshToken, err := db.RevokeRefreshTokenByToken(r.Context(), oldRefreshTokenCookie.Value)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			jsonutils.WriteError(w, http.StatusNotFound, ErrRefreshTokenInvalid, "unable to revoke token: not found in database.")
-			return result, err
-		} else {
-			jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database(RevokeRefreshTokenByToken in RotateRefreshToken)")
-			return result, err
-		}
-	}
-
-	// 3. make new refresh token
+	// 1. make new refresh token
 	newRefreshToken, err := MakeRefreshToken()
 	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error creating refresh token (in MakeRefreshToken in RotateRefreshToken)")
-		return result, err
+		return database.RefreshToken{}, err
 	}
 
-	// 4. query database to create new refresh token
-	rtParams := database.CreateRefreshTokenParams{
-		Token:        newRefreshToken,
-		UserPublicID: oldRefreshToken.UserPublicID,
-	}
-	result, err = db.CreateRefreshToken(r.Context(), rtParams)
-	if err != nil {
-		jsonutils.WriteError(w, http.StatusInternalServerError, err, "error querying database (CreateRefreshToken in makeAuthMiddleware)")
+	// 2. replace old refresh token from cookie
+	result, err := db.RotateRefreshTokenByToken(r.Context(), database.RotateRefreshTokenByTokenParams{
+		NewToken:  newRefreshToken,
+		ExpiresAt: time.Now().Add(time.Duration(refreshTokenDayDuration) * 24 * time.Hour),
+		UserAgent: r.UserAgent(),
+		IpAddress: strutils.GetIPFromRequest(r),
+		OldToken:  oldRefreshTokenCookie.Value,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, ErrRefreshTokenInvalid
+	} else if err != nil {
 		return result, err
 	}
 
-	//5. return
+	// 3. return
 	return result, nil
 }
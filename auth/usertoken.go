@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+// kinds of single-use user tokens, stored in the kind column of the user_tokens table
+const (
+	UserTokenKindInvitation    = "invitation"
+	UserTokenKindPasswordReset = "password_reset"
+)
+
+var ErrUserTokenInvalid = jsonutils.NewError(jsonutils.CodeUserTokenInvalid, "auth: token is invalid, expired or already used")
+
+func MakeUserToken() (string, string, error) {
+	/*
+		Generates a single-use token for invitations and password resets. Returns the raw token, which is sent to the
+		user and never stored, and its hash, which is stored in the database.
+	*/
+	token, err := MakeRefreshToken() // same format: hex encoding of 32 random bytes
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashUserToken(token), nil
+}
+
+func HashUserToken(token string) string {
+	// sha256 is sufficient here (unlike for passwords) because tokens are long and random
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
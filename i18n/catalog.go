@@ -0,0 +1,361 @@
+package i18n
+
+// catalogs holds the messages of every language, keyed by language and then by message key. English must have every
+// key. Messages with a %d verb are formatted by the caller.
+var catalogs = map[string]map[string]string{
+	"de": {
+		"error.announcement_template_exists":    "Für diesen Standort oder dieses Anliegen gibt es bereits eine Ansage in dieser Sprache.",
+		"error.announcement_template_not_found": "Die Ansage wurde nicht gefunden.",
+		"error.bad_request":                     "Die Anfrage ist ungültig.",
+		"error.body_too_large":                  "Der Anfragetext ist zu groß.",
+		"error.conflict":                        "Die Anfrage steht im Konflikt mit dem aktuellen Zustand.",
+		"error.csrf_origin_invalid":             "Anfragen von dieser Website sind nicht erlaubt.",
+		"error.csrf_token_invalid":              "Das CSRF-Token fehlt oder ist ungültig. Laden Sie die Seite neu.",
+		"error.desk_not_found":                  "Der Schalter wurde nicht gefunden.",
+		"error.forbidden":                       "Sie dürfen diese Aktion nicht ausführen.",
+		"error.holiday_exists":                  "An diesem Datum gibt es bereits einen Feiertag.",
+		"error.holiday_not_found":               "Der Feiertag wurde nicht gefunden.",
+		"error.idempotency_key_in_flight":       "Eine Anfrage mit diesem Idempotency-Key wird noch bearbeitet. Versuchen Sie es gleich noch einmal.",
+		"error.idempotency_key_invalid":         "Der Idempotency-Key muss 1 bis 255 Zeichen lang sein.",
+		"error.idempotency_key_reused":          "Dieser Idempotency-Key wurde bereits für eine andere Anfrage verwendet.",
+		"error.internal_error":                  "Etwas ist schiefgelaufen. Bitte versuchen Sie es später erneut.",
+		"error.invalid_choice":                  "Muss einer der erlaubten Werte sein.",
+		"error.invalid_cursor":                  "Der Seitencursor ist ungültig.",
+		"error.invalid_daily_capacity":          "Die Tageskapazität darf nicht negativ sein.",
+		"error.invalid_email":                   "Die E-Mail-Adresse ist ungültig.",
+		"error.invalid_json":                    "Der Anfragetext ist kein gültiges JSON.",
+		"error.invalid_logo":                    "Das Logo muss ein GIF-, JPEG- oder PNG-Bild sein.",
+		"error.invalid_phone_number":            "Die Telefonnummer muss mit + beginnen, gefolgt von 8 bis 15 Ziffern.",
+		"error.invalid_public_id":               "Die ID in der Adresse hat die falsche Länge.",
+		"error.invalid_time_zone":               "Die Zeitzone ist unbekannt.",
+		"error.invalid_translation":             "Übersetzungen brauchen ein Sprachkürzel wie nl oder pt-BR und dürfen nicht leer sein.",
+		"error.invalid_type":                    "Dieses Feld hat den falschen Typ.",
+		"error.invalid_webhook_event_type":      "Der Ereignistyp des Webhooks ist unbekannt.",
+		"error.invalid_webhook_url":             "Die Webhook-URL muss eine absolute http- oder https-URL sein.",
+		"error.location_mismatch":               "Die angegebenen Elemente gehören zu verschiedenen Standorten.",
+		"error.location_not_found":              "Der Standort wurde nicht gefunden.",
+		"error.login_throttled":                 "Zu viele fehlgeschlagene Anmeldeversuche. Versuchen Sie es später erneut.",
+		"error.method_not_allowed":              "Diese Methode ist für diese Adresse nicht erlaubt.",
+		"error.not_admin":                       "Für diese Aktion sind Administratorrechte erforderlich.",
+		"error.not_authenticated":               "Sie müssen angemeldet sein.",
+		"error.not_found":                       "Nicht gefunden.",
+		"error.opening_hour_not_found":          "Die Öffnungszeit wurde nicht gefunden.",
+		"error.password_denied":                 "Dieses Passwort ist zu häufig. Wählen Sie ein anderes.",
+		"error.precondition_failed":             "Das Element wurde inzwischen geändert. Laden Sie es neu und versuchen Sie es erneut.",
+		"error.precondition_required":           "Für diese Änderung ist ein If-Match-Header erforderlich.",
+		"error.purpose_not_found":               "Das Anliegen wurde nicht gefunden.",
+		"error.queue_closed":                    "Für dieses Anliegen werden derzeit keine Nummern ausgegeben.",
+		"error.queue_full":                      "Für dieses Anliegen wurden heute alle Nummern ausgegeben.",
+		"error.refresh_token_invalid":           "Ihre Sitzung ist abgelaufen. Bitte melden Sie sich erneut an.",
+		"error.required":                        "Dieses Feld ist erforderlich.",
+		"error.service_log_not_found":           "Der Aufruf wurde nicht gefunden.",
+		"error.session_ended":                   "Ihre Sitzung wurde beendet. Bitte melden Sie sich erneut an.",
+		"error.session_not_found":               "Die Sitzung wurde nicht gefunden oder ist bereits beendet.",
+		"error.ticket_layout_not_found":         "Für dieses Anliegen gibt es kein Ticketlayout.",
+		"error.too_large":                       "Darf höchstens %d sein.",
+		"error.too_long":                        "Darf höchstens %d Zeichen lang sein.",
+		"error.too_many_requests":               "Zu viele Anfragen. Versuchen Sie es später erneut.",
+		"error.too_short":                       "Muss mindestens %d Zeichen lang sein.",
+		"error.too_small":                       "Muss mindestens %d sein.",
+		"error.unauthorized":                    "Sie müssen angemeldet sein.",
+		"error.unknown_field":                   "Dieses Feld ist unbekannt.",
+		"error.unprocessable_entity":            "Die Anfrage kann nicht verarbeitet werden.",
+		"error.user_inactive":                   "Dieses Konto ist deaktiviert.",
+		"error.user_not_found":                  "Das Benutzerkonto wurde nicht gefunden.",
+		"error.user_not_in_location":            "Ihr Konto ist diesem Standort nicht zugeordnet.",
+		"error.user_token_invalid":              "Dieser Link ist ungültig, abgelaufen oder wurde bereits verwendet.",
+		"error.validation_failed":               "Einige Felder der Anfrage sind ungültig.",
+		"error.visitor_mismatch":                "Sie haben keinen Zugriff auf diese Nummer.",
+		"error.visitor_not_found":               "Die Nummer wurde nicht gefunden.",
+		"error.webhook_delivery_not_found":      "Die Webhook-Zustellung wurde nicht gefunden.",
+		"error.webhook_not_found":               "Der Webhook wurde nicht gefunden.",
+		"error.wrong_user_type":                 "Das Token gehört zur falschen Kontoart.",
+		"ticket.scan_caption":                   "Scannen, um Ihre Wartezeit zu verfolgen",
+		"ticket.wait_one":                       "Geschätzte Wartezeit: etwa 1 Minute",
+		"ticket.wait_other":                     "Geschätzte Wartezeit: etwa %d Minuten",
+	},
+	"en": {
+		"error.announcement_template_exists":    "This location or purpose already has an announcement in this language.",
+		"error.announcement_template_not_found": "The announcement template was not found.",
+		"error.bad_request":                     "The request is invalid.",
+		"error.body_too_large":                  "The request body is too large.",
+		"error.conflict":                        "The request conflicts with the current state.",
+		"error.csrf_origin_invalid":             "Requests from this website are not allowed.",
+		"error.csrf_token_invalid":              "The CSRF token is missing or invalid. Reload the page.",
+		"error.desk_not_found":                  "The desk was not found.",
+		"error.forbidden":                       "You are not allowed to do this.",
+		"error.holiday_exists":                  "There already is a holiday on this date.",
+		"error.holiday_not_found":               "The holiday was not found.",
+		"error.idempotency_key_in_flight":       "A request with this Idempotency-Key is still being handled. Try again in a moment.",
+		"error.idempotency_key_invalid":         "The Idempotency-Key must be 1 to 255 characters long.",
+		"error.idempotency_key_reused":          "This Idempotency-Key was already used for a different request.",
+		"error.internal_error":                  "Something went wrong. Please try again later.",
+		"error.invalid_choice":                  "Must be one of the allowed values.",
+		"error.invalid_cursor":                  "The page cursor is invalid.",
+		"error.invalid_daily_capacity":          "The daily capacity cannot be negative.",
+		"error.invalid_email":                   "The email address is invalid.",
+		"error.invalid_json":                    "The request body is not valid JSON.",
+		"error.invalid_logo":                    "The logo must be a GIF, JPEG or PNG image.",
+		"error.invalid_phone_number":            "The phone number must be a + followed by 8 to 15 digits.",
+		"error.invalid_public_id":               "The ID in the address has the wrong length.",
+		"error.invalid_time_zone":               "The time zone is unknown.",
+		"error.invalid_translation":             "Translations need a language tag such as nl or pt-BR and cannot be empty.",
+		"error.invalid_type":                    "This field has the wrong type.",
+		"error.invalid_webhook_event_type":      "The webhook event type is unknown.",
+		"error.invalid_webhook_url":             "The webhook URL must be an absolute http or https URL.",
+		"error.location_mismatch":               "The referenced items belong to different locations.",
+		"error.location_not_found":              "The location was not found.",
+		"error.login_throttled":                 "Too many failed login attempts. Try again later.",
+		"error.method_not_allowed":              "This method is not allowed for this address.",
+		"error.not_admin":                       "This requires admin rights.",
+		"error.not_authenticated":               "You need to log in.",
+		"error.not_found":                       "Not found.",
+		"error.opening_hour_not_found":          "The opening hours were not found.",
+		"error.password_denied":                 "This password is too common. Choose another one.",
+		"error.precondition_failed":             "This item was changed in the meantime. Reload it and try again.",
+		"error.precondition_required":           "This change requires an If-Match header.",
+		"error.purpose_not_found":               "The purpose was not found.",
+		"error.queue_closed":                    "No tickets are issued for this purpose at this time.",
+		"error.queue_full":                      "All tickets for this purpose have been issued today.",
+		"error.refresh_token_invalid":           "Your session has expired. Please log in again.",
+		"error.required":                        "This field is required.",
+		"error.service_log_not_found":           "The service log was not found.",
+		"error.session_ended":                   "Your session has ended. Please log in again.",
+		"error.session_not_found":               "The session was not found or has already ended.",
+		"error.ticket_layout_not_found":         "This purpose has no ticket layout.",
+		"error.too_large":                       "Must be at most %d.",
+		"error.too_long":                        "Must be at most %d characters long.",
+		"error.too_many_requests":               "Too many requests. Try again later.",
+		"error.too_short":                       "Must be at least %d characters long.",
+		"error.too_small":                       "Must be at least %d.",
+		"error.unauthorized":                    "You need to log in.",
+		"error.unknown_field":                   "This field is unknown.",
+		"error.unprocessable_entity":            "The request cannot be processed.",
+		"error.user_inactive":                   "This account is deactivated.",
+		"error.user_not_found":                  "The user was not found.",
+		"error.user_not_in_location":            "Your account is not assigned to this location.",
+		"error.user_token_invalid":              "This link is invalid, has expired or was already used.",
+		"error.validation_failed":               "Some fields of the request are invalid.",
+		"error.visitor_mismatch":                "You do not have access to this ticket.",
+		"error.visitor_not_found":               "The visitor was not found.",
+		"error.webhook_delivery_not_found":      "The webhook delivery was not found.",
+		"error.webhook_not_found":               "The webhook was not found.",
+		"error.wrong_user_type":                 "The token belongs to the wrong kind of account.",
+		"ticket.scan_caption":                   "Scan to follow your turn",
+		"ticket.wait_one":                       "Estimated wait: about 1 minute",
+		"ticket.wait_other":                     "Estimated wait: about %d minutes",
+	},
+	"es": {
+		"error.announcement_template_exists":    "Esta sede o este trámite ya tiene un aviso en este idioma.",
+		"error.announcement_template_not_found": "No se encontró la plantilla de anuncio.",
+		"error.bad_request":                     "La solicitud no es válida.",
+		"error.body_too_large":                  "El cuerpo de la solicitud es demasiado grande.",
+		"error.conflict":                        "La solicitud entra en conflicto con el estado actual.",
+		"error.csrf_origin_invalid":             "No se permiten solicitudes desde este sitio web.",
+		"error.csrf_token_invalid":              "Falta el token CSRF o no es válido. Vuelva a cargar la página.",
+		"error.desk_not_found":                  "No se encontró el mostrador.",
+		"error.forbidden":                       "No tiene permiso para hacer esto.",
+		"error.holiday_exists":                  "Ya hay un día festivo en esta fecha.",
+		"error.holiday_not_found":               "No se encontró el día festivo.",
+		"error.idempotency_key_in_flight":       "Todavía se está procesando una solicitud con esta Idempotency-Key. Inténtelo de nuevo en un momento.",
+		"error.idempotency_key_invalid":         "La Idempotency-Key debe tener entre 1 y 255 caracteres.",
+		"error.idempotency_key_reused":          "Esta Idempotency-Key ya se usó para otra solicitud.",
+		"error.internal_error":                  "Algo salió mal. Inténtelo de nuevo más tarde.",
+		"error.invalid_choice":                  "Debe ser uno de los valores permitidos.",
+		"error.invalid_cursor":                  "El cursor de página no es válido.",
+		"error.invalid_daily_capacity":          "La capacidad diaria no puede ser negativa.",
+		"error.invalid_email":                   "La dirección de correo electrónico no es válida.",
+		"error.invalid_json":                    "El cuerpo de la solicitud no es JSON válido.",
+		"error.invalid_logo":                    "El logotipo debe ser una imagen GIF, JPEG o PNG.",
+		"error.invalid_phone_number":            "El número de teléfono debe ser un + seguido de 8 a 15 dígitos.",
+		"error.invalid_public_id":               "El ID de la dirección tiene una longitud incorrecta.",
+		"error.invalid_time_zone":               "La zona horaria es desconocida.",
+		"error.invalid_translation":             "Las traducciones necesitan una etiqueta de idioma como nl o pt-BR y no pueden estar vacías.",
+		"error.invalid_type":                    "Este campo tiene un tipo incorrecto.",
+		"error.invalid_webhook_event_type":      "El tipo de evento del webhook es desconocido.",
+		"error.invalid_webhook_url":             "La URL del webhook debe ser una URL http o https absoluta.",
+		"error.location_mismatch":               "Los elementos indicados pertenecen a sedes distintas.",
+		"error.location_not_found":              "No se encontró la ubicación.",
+		"error.login_throttled":                 "Demasiados intentos de inicio de sesión fallidos. Inténtelo más tarde.",
+		"error.method_not_allowed":              "Este método no está permitido para esta dirección.",
+		"error.not_admin":                       "Esto requiere permisos de administrador.",
+		"error.not_authenticated":               "Debe iniciar sesión.",
+		"error.not_found":                       "No encontrado.",
+		"error.opening_hour_not_found":          "No se encontró el horario.",
+		"error.password_denied":                 "Esta contraseña es demasiado común. Elija otra.",
+		"error.precondition_failed":             "Este elemento cambió mientras tanto. Vuelva a cargarlo e inténtelo de nuevo.",
+		"error.precondition_required":           "Este cambio requiere un encabezado If-Match.",
+		"error.purpose_not_found":               "No se encontró el trámite.",
+		"error.queue_closed":                    "En este momento no se emiten números para este trámite.",
+		"error.queue_full":                      "Ya se han emitido todos los números de hoy para este trámite.",
+		"error.refresh_token_invalid":           "Su sesión ha caducado. Vuelva a iniciar sesión.",
+		"error.required":                        "Este campo es obligatorio.",
+		"error.service_log_not_found":           "No se encontró el registro de atención.",
+		"error.session_ended":                   "Su sesión ha terminado. Vuelva a iniciar sesión.",
+		"error.session_not_found":               "No se encontró la sesión o ya ha finalizado.",
+		"error.ticket_layout_not_found":         "Este trámite no tiene diseño de ticket.",
+		"error.too_large":                       "Debe ser como máximo %d.",
+		"error.too_long":                        "Debe tener como máximo %d caracteres.",
+		"error.too_many_requests":               "Demasiadas solicitudes. Inténtelo más tarde.",
+		"error.too_short":                       "Debe tener al menos %d caracteres.",
+		"error.too_small":                       "Debe ser al menos %d.",
+		"error.unauthorized":                    "Debe iniciar sesión.",
+		"error.unknown_field":                   "Este campo es desconocido.",
+		"error.unprocessable_entity":            "No se puede procesar la solicitud.",
+		"error.user_inactive":                   "Esta cuenta está desactivada.",
+		"error.user_not_found":                  "No se encontró el usuario.",
+		"error.user_not_in_location":            "Su cuenta no está asignada a esta sede.",
+		"error.user_token_invalid":              "Este enlace no es válido, ha caducado o ya se ha utilizado.",
+		"error.validation_failed":               "Algunos campos de la solicitud no son válidos.",
+		"error.visitor_mismatch":                "No tiene acceso a este número.",
+		"error.visitor_not_found":               "No se encontró el visitante.",
+		"error.webhook_delivery_not_found":      "No se encontró el envío del webhook.",
+		"error.webhook_not_found":               "No se encontró el webhook.",
+		"error.wrong_user_type":                 "El token pertenece a otro tipo de cuenta.",
+		"ticket.scan_caption":                   "Escanee para seguir su turno",
+		"ticket.wait_one":                       "Espera estimada: 1 minuto aprox.",
+		"ticket.wait_other":                     "Espera estimada: %d minutos aprox.",
+	},
+	"fr": {
+		"error.announcement_template_exists":    "Ce site ou ce motif a déjà une annonce dans cette langue.",
+		"error.announcement_template_not_found": "Le modèle d'annonce est introuvable.",
+		"error.bad_request":                     "La requête n'est pas valide.",
+		"error.body_too_large":                  "Le corps de la requête est trop volumineux.",
+		"error.conflict":                        "La requête est en conflit avec l'état actuel.",
+		"error.csrf_origin_invalid":             "Les requêtes provenant de ce site ne sont pas autorisées.",
+		"error.csrf_token_invalid":              "Le jeton CSRF est absent ou invalide. Rechargez la page.",
+		"error.desk_not_found":                  "Le guichet est introuvable.",
+		"error.forbidden":                       "Vous n'êtes pas autorisé à faire cela.",
+		"error.holiday_exists":                  "Il y a déjà un jour férié à cette date.",
+		"error.holiday_not_found":               "Le jour férié est introuvable.",
+		"error.idempotency_key_in_flight":       "Une requête avec cette Idempotency-Key est encore en cours de traitement. Réessayez dans un instant.",
+		"error.idempotency_key_invalid":         "L'Idempotency-Key doit comporter de 1 à 255 caractères.",
+		"error.idempotency_key_reused":          "Cette Idempotency-Key a déjà été utilisée pour une autre requête.",
+		"error.internal_error":                  "Une erreur s'est produite. Veuillez réessayer plus tard.",
+		"error.invalid_choice":                  "Doit être l'une des valeurs autorisées.",
+		"error.invalid_cursor":                  "Le curseur de page n'est pas valide.",
+		"error.invalid_daily_capacity":          "La capacité journalière ne peut pas être négative.",
+		"error.invalid_email":                   "L'adresse e-mail n'est pas valide.",
+		"error.invalid_json":                    "Le corps de la requête n'est pas du JSON valide.",
+		"error.invalid_logo":                    "Le logo doit être une image GIF, JPEG ou PNG.",
+		"error.invalid_phone_number":            "Le numéro de téléphone doit être un + suivi de 8 à 15 chiffres.",
+		"error.invalid_public_id":               "L'identifiant dans l'adresse n'a pas la bonne longueur.",
+		"error.invalid_time_zone":               "Le fuseau horaire est inconnu.",
+		"error.invalid_translation":             "Les traductions nécessitent une balise de langue comme nl ou pt-BR et ne peuvent pas être vides.",
+		"error.invalid_type":                    "Ce champ n'a pas le bon type.",
+		"error.invalid_webhook_event_type":      "Le type d'événement du webhook est inconnu.",
+		"error.invalid_webhook_url":             "L'URL du webhook doit être une URL http ou https absolue.",
+		"error.location_mismatch":               "Les éléments indiqués appartiennent à des sites différents.",
+		"error.location_not_found":              "Le site est introuvable.",
+		"error.login_throttled":                 "Trop de tentatives de connexion échouées. Réessayez plus tard.",
+		"error.method_not_allowed":              "Cette méthode n'est pas autorisée pour cette adresse.",
+		"error.not_admin":                       "Cela nécessite des droits d'administrateur.",
+		"error.not_authenticated":               "Vous devez vous connecter.",
+		"error.not_found":                       "Introuvable.",
+		"error.opening_hour_not_found":          "L'horaire d'ouverture est introuvable.",
+		"error.password_denied":                 "Ce mot de passe est trop courant. Choisissez-en un autre.",
+		"error.precondition_failed":             "Cet élément a été modifié entre-temps. Rechargez-le et réessayez.",
+		"error.precondition_required":           "Cette modification nécessite un en-tête If-Match.",
+		"error.purpose_not_found":               "Le motif est introuvable.",
+		"error.queue_closed":                    "Aucun ticket n'est délivré pour ce motif en ce moment.",
+		"error.queue_full":                      "Tous les tickets de ce motif ont été délivrés aujourd'hui.",
+		"error.refresh_token_invalid":           "Votre session a expiré. Veuillez vous reconnecter.",
+		"error.required":                        "Ce champ est obligatoire.",
+		"error.service_log_not_found":           "Le journal de service est introuvable.",
+		"error.session_ended":                   "Votre session est terminée. Veuillez vous reconnecter.",
+		"error.session_not_found":               "La session est introuvable ou déjà terminée.",
+		"error.ticket_layout_not_found":         "Ce motif n'a pas de mise en page de ticket.",
+		"error.too_large":                       "Doit être au plus %d.",
+		"error.too_long":                        "Doit contenir au plus %d caractères.",
+		"error.too_many_requests":               "Trop de requêtes. Réessayez plus tard.",
+		"error.too_short":                       "Doit contenir au moins %d caractères.",
+		"error.too_small":                       "Doit être au moins %d.",
+		"error.unauthorized":                    "Vous devez vous connecter.",
+		"error.unknown_field":                   "Ce champ est inconnu.",
+		"error.unprocessable_entity":            "La requête ne peut pas être traitée.",
+		"error.user_inactive":                   "Ce compte est désactivé.",
+		"error.user_not_found":                  "L'utilisateur est introuvable.",
+		"error.user_not_in_location":            "Votre compte n'est pas affecté à ce site.",
+		"error.user_token_invalid":              "Ce lien n'est pas valide, a expiré ou a déjà été utilisé.",
+		"error.validation_failed":               "Certains champs de la requête ne sont pas valides.",
+		"error.visitor_mismatch":                "Vous n'avez pas accès à ce ticket.",
+		"error.visitor_not_found":               "Le visiteur est introuvable.",
+		"error.webhook_delivery_not_found":      "L'envoi du webhook est introuvable.",
+		"error.webhook_not_found":               "Le webhook est introuvable.",
+		"error.wrong_user_type":                 "Le jeton appartient à un autre type de compte.",
+		"ticket.scan_caption":                   "Scannez pour suivre votre tour",
+		"ticket.wait_one":                       "Attente estimée : environ 1 minute",
+		"ticket.wait_other":                     "Attente estimée : environ %d minutes",
+	},
+	"nl": {
+		"error.announcement_template_exists":    "Deze locatie of dit doel heeft al een omroepbericht in deze taal.",
+		"error.announcement_template_not_found": "De omroeptekst is niet gevonden.",
+		"error.bad_request":                     "Het verzoek is ongeldig.",
+		"error.body_too_large":                  "De inhoud van het verzoek is te groot.",
+		"error.conflict":                        "Het verzoek is in strijd met de huidige toestand.",
+		"error.csrf_origin_invalid":             "Verzoeken vanaf deze website zijn niet toegestaan.",
+		"error.csrf_token_invalid":              "Het CSRF-token ontbreekt of is ongeldig. Herlaad de pagina.",
+		"error.desk_not_found":                  "De balie is niet gevonden.",
+		"error.forbidden":                       "U mag dit niet doen.",
+		"error.holiday_exists":                  "Er is al een feestdag op deze datum.",
+		"error.holiday_not_found":               "De feestdag is niet gevonden.",
+		"error.idempotency_key_in_flight":       "Een verzoek met deze Idempotency-Key wordt nog verwerkt. Probeer het zo opnieuw.",
+		"error.idempotency_key_invalid":         "De Idempotency-Key moet 1 tot 255 tekens lang zijn.",
+		"error.idempotency_key_reused":          "Deze Idempotency-Key is al gebruikt voor een ander verzoek.",
+		"error.internal_error":                  "Er ging iets mis. Probeer het later opnieuw.",
+		"error.invalid_choice":                  "Moet een van de toegestane waarden zijn.",
+		"error.invalid_cursor":                  "De paginacursor is ongeldig.",
+		"error.invalid_daily_capacity":          "De dagcapaciteit kan niet negatief zijn.",
+		"error.invalid_email":                   "Het e-mailadres is ongeldig.",
+		"error.invalid_json":                    "De inhoud van het verzoek is geen geldige JSON.",
+		"error.invalid_logo":                    "Het logo moet een GIF-, JPEG- of PNG-afbeelding zijn.",
+		"error.invalid_phone_number":            "Het telefoonnummer moet een + zijn gevolgd door 8 tot 15 cijfers.",
+		"error.invalid_public_id":               "Het ID in het adres heeft de verkeerde lengte.",
+		"error.invalid_time_zone":               "De tijdzone is onbekend.",
+		"error.invalid_translation":             "Vertalingen hebben een taalcode zoals nl of pt-BR nodig en kunnen niet leeg zijn.",
+		"error.invalid_type":                    "Dit veld heeft het verkeerde type.",
+		"error.invalid_webhook_event_type":      "Het gebeurtenistype van de webhook is onbekend.",
+		"error.invalid_webhook_url":             "De webhook-URL moet een absolute http- of https-URL zijn.",
+		"error.location_mismatch":               "De opgegeven onderdelen horen bij verschillende locaties.",
+		"error.location_not_found":              "De locatie is niet gevonden.",
+		"error.login_throttled":                 "Te veel mislukte inlogpogingen. Probeer het later opnieuw.",
+		"error.method_not_allowed":              "Deze methode is niet toegestaan voor dit adres.",
+		"error.not_admin":                       "Hiervoor zijn beheerdersrechten nodig.",
+		"error.not_authenticated":               "U moet inloggen.",
+		"error.not_found":                       "Niet gevonden.",
+		"error.opening_hour_not_found":          "De openingstijd is niet gevonden.",
+		"error.password_denied":                 "Dit wachtwoord komt te vaak voor. Kies een ander.",
+		"error.precondition_failed":             "Dit onderdeel is intussen gewijzigd. Laad het opnieuw en probeer het nog eens.",
+		"error.precondition_required":           "Voor deze wijziging is een If-Match-header nodig.",
+		"error.purpose_not_found":               "Het doel is niet gevonden.",
+		"error.queue_closed":                    "Voor dit doel worden op dit moment geen nummers uitgegeven.",
+		"error.queue_full":                      "Alle nummers voor dit doel zijn vandaag uitgegeven.",
+		"error.refresh_token_invalid":           "Uw sessie is verlopen. Log opnieuw in.",
+		"error.required":                        "Dit veld is verplicht.",
+		"error.service_log_not_found":           "De servicelog is niet gevonden.",
+		"error.session_ended":                   "Uw sessie is beëindigd. Log opnieuw in.",
+		"error.session_not_found":               "De sessie is niet gevonden of al beëindigd.",
+		"error.ticket_layout_not_found":         "Dit doel heeft geen ticketopmaak.",
+		"error.too_large":                       "Mag hoogstens %d zijn.",
+		"error.too_long":                        "Mag hoogstens %d tekens lang zijn.",
+		"error.too_many_requests":               "Te veel verzoeken. Probeer het later opnieuw.",
+		"error.too_short":                       "Moet minstens %d tekens lang zijn.",
+		"error.too_small":                       "Moet minstens %d zijn.",
+		"error.unauthorized":                    "U moet inloggen.",
+		"error.unknown_field":                   "Dit veld is onbekend.",
+		"error.unprocessable_entity":            "Het verzoek kan niet worden verwerkt.",
+		"error.user_inactive":                   "Dit account is gedeactiveerd.",
+		"error.user_not_found":                  "De gebruiker is niet gevonden.",
+		"error.user_not_in_location":            "Uw account is niet aan deze locatie toegewezen.",
+		"error.user_token_invalid":              "Deze link is ongeldig, verlopen of al gebruikt.",
+		"error.validation_failed":               "Sommige velden van het verzoek zijn ongeldig.",
+		"error.visitor_mismatch":                "U hebt geen toegang tot dit nummer.",
+		"error.visitor_not_found":               "De bezoeker is niet gevonden.",
+		"error.webhook_delivery_not_found":      "De webhookaflevering is niet gevonden.",
+		"error.webhook_not_found":               "De webhook is niet gevonden.",
+		"error.wrong_user_type":                 "Het token hoort bij een ander soort account.",
+		"ticket.scan_caption":                   "Scan om uw beurt te volgen",
+		"ticket.wait_one":                       "Geschatte wachttijd: ongeveer 1 minuut",
+		"ticket.wait_other":                     "Geschatte wachttijd: ongeveer %d minuten",
+	},
+}
@@ -0,0 +1,122 @@
+// Package i18n holds the message catalogs of the text goqueue writes for people rather than programs: error messages
+// of the API and the visitor-facing text of tickets. Languages are chosen from the Accept-Language header of the
+// request. Keys are stable identifiers such as "error.queue_closed"; a key missing from the catalog of a language is
+// looked up in English instead.
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when a request asks for no language goqueue has a catalog for
+const DefaultLanguage = "en"
+
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+func ValidTag(tag string) bool {
+	// whether tag is a language tag like nl or pt-BR, with a lowercase primary language
+	return languageTag.MatchString(tag)
+}
+
+func Languages() []string {
+	// the languages with a catalog, sorted
+	languages := make([]string, 0, len(catalogs))
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func Lookup(language, key string) (string, bool) {
+	// the message of key in language, or in English if the language lacks it
+	if message, ok := catalogs[language][key]; ok {
+		return message, true
+	}
+	message, ok := catalogs[DefaultLanguage][key]
+	return message, ok
+}
+
+func Message(language, key string) string {
+	// like Lookup, but returns the key itself if no catalog has it, so a missing translation shows rather than hides
+	if message, ok := Lookup(language, key); ok {
+		return message
+	}
+	return key
+}
+
+func Negotiate(acceptLanguage string) string {
+	// the language with a catalog that fits an Accept-Language header best, DefaultLanguage if none does
+	if language, ok := Match(acceptLanguage, Languages()); ok {
+		return language
+	}
+	return DefaultLanguage
+}
+
+func Match(acceptLanguage string, available []string) (string, bool) {
+	/*
+		The available language tag that fits an Accept-Language header best (RFC 9110 section 12.5.4). Requested
+		languages are tried in order of their weight, the first one listed winning ties. A requested language matches an
+		available tag of the same language (nl-BE matches nl-BE, then nl) and, failing that, one of its regional variants
+		(pt matches pt-BR). Tags compare case-insensitively. The wildcard * is ignored: a client accepting anything gets
+		the default of the caller.
+	*/
+	for _, requested := range parseAcceptLanguage(acceptLanguage) {
+		for tag := requested; tag != ""; tag = parent(tag) {
+			for _, a := range available {
+				if strings.EqualFold(a, tag) {
+					return a, true
+				}
+			}
+		}
+		for _, a := range available {
+			if strings.HasPrefix(strings.ToLower(a), requested+"-") {
+				return a, true
+			}
+		}
+	}
+	return "", false
+}
+
+func parent(tag string) string {
+	// the tag without its last subtag, e.g. nl for nl-be. Empty for a tag of a single subtag
+	i := strings.LastIndexByte(tag, '-')
+	if i < 0 {
+		return ""
+	}
+	return tag[:i]
+}
+
+func parseAcceptLanguage(header string) []string {
+	// the lowercased language tags of an Accept-Language header, highest weight first, leaving out * and q=0
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			w, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = w
+		}
+		if tag == "" || tag == "*" || weight <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag, weight})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].weight > tags[j].weight })
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
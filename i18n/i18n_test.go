@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	available := []string{"en", "nl", "pt-BR"}
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{"nl", "nl", true},
+		{"NL-be, en;q=0.5", "nl", true},
+		{"de, en;q=0.8, nl;q=0.9", "nl", true},
+		{"en;q=0.5, nl;q=0.5", "en", true},
+		{"pt", "pt-BR", true},
+		{"nl;q=0, en", "en", true},
+		{"de, *", "", false},
+		{"nl;q=abc", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := Match(tt.header, available); got != tt.want || ok != tt.ok {
+			t.Errorf(`Match(%q) returned %q, %v, expected %q, %v`, tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+	if got := Negotiate("tr, de-AT;q=0.7"); got != "de" {
+		t.Errorf(`Negotiate returned %q, expected de`, got)
+	}
+	if got := Negotiate("tr"); got != DefaultLanguage {
+		t.Errorf(`Negotiate returned %q, expected the default language`, got)
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	// every language translates every key, keeping the formatting verbs
+	for language, catalog := range catalogs {
+		for key, english := range catalogs[DefaultLanguage] {
+			message, ok := catalog[key]
+			if !ok {
+				t.Errorf(`catalog %s lacks %s`, language, key)
+			} else if strings.Count(message, "%d") != strings.Count(english, "%d") {
+				t.Errorf(`catalog %s has %q for %s, expected the verbs of %q`, language, message, key, english)
+			}
+		}
+		for key := range catalog {
+			if _, ok := catalogs[DefaultLanguage][key]; !ok {
+				t.Errorf(`catalog %s has %s, which English lacks`, language, key)
+			}
+		}
+	}
+	if got := Message("nl", "error.unknown"); got != "error.unknown" {
+		t.Errorf(`Message returned %q for an unknown key, expected the key`, got)
+	}
+}
@@ -10,26 +10,55 @@ import (
 	"database/sql"
 )
 
+const countDesks = `-- name: CountDesks :one
+SELECT COUNT(*) FROM desks
+WHERE ($1::boolean IS NULL OR is_active = $1)
+    AND ($2::text IS NULL OR location_public_id = $2)
+    AND ($3::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = $3))
+`
+
+type CountDesksParams struct {
+	IsActive         sql.NullBool
+	LocationPublicID sql.NullString
+	MemberPublicID   sql.NullString
+}
+
+func (q *Queries) CountDesks(ctx context.Context, arg CountDesksParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDesks, arg.IsActive, arg.LocationPublicID, arg.MemberPublicID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createDesks = `-- name: CreateDesks :one
-INSERT INTO desks (id, public_id, name, description, is_active)
+INSERT INTO desks (id, public_id, created_at, updated_at, name, description, is_active, location_public_id)
 VALUES (
     gen_random_uuid(),
     $1,
+    NOW(),
+    NOW(),
     $2,
     $3,
-    TRUE
+    TRUE,
+    $4
 )
-RETURNING id, description, is_active, public_id, name
+RETURNING id, description, is_active, public_id, name, created_at, updated_at, location_public_id
 `
 
 type CreateDesksParams struct {
-	PublicID    string
-	Name        string
-	Description sql.NullString
+	PublicID         string
+	Name             string
+	Description      sql.NullString
+	LocationPublicID string
 }
 
 func (q *Queries) CreateDesks(ctx context.Context, arg CreateDesksParams) (Desk, error) {
-	row := q.db.QueryRowContext(ctx, createDesks, arg.PublicID, arg.Name, arg.Description)
+	row := q.db.QueryRowContext(ctx, createDesks,
+		arg.PublicID,
+		arg.Name,
+		arg.Description,
+		arg.LocationPublicID,
+	)
 	var i Desk
 	err := row.Scan(
 		&i.ID,
@@ -37,12 +66,15 @@ func (q *Queries) CreateDesks(ctx context.Context, arg CreateDesksParams) (Desk,
 		&i.IsActive,
 		&i.PublicID,
 		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LocationPublicID,
 	)
 	return i, err
 }
 
 const getActiveDesks = `-- name: GetActiveDesks :many
-SELECT id, description, is_active, public_id, name FROM desks
+SELECT id, description, is_active, public_id, name, created_at, updated_at, location_public_id FROM desks
 WHERE is_active = TRUE
 `
 
@@ -61,6 +93,9 @@ func (q *Queries) GetActiveDesks(ctx context.Context) ([]Desk, error) {
 			&i.IsActive,
 			&i.PublicID,
 			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LocationPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -76,7 +111,7 @@ func (q *Queries) GetActiveDesks(ctx context.Context) ([]Desk, error) {
 }
 
 const getDesks = `-- name: GetDesks :many
-SELECT id, description, is_active, public_id, name FROM desks
+SELECT id, description, is_active, public_id, name, created_at, updated_at, location_public_id FROM desks
 `
 
 func (q *Queries) GetDesks(ctx context.Context) ([]Desk, error) {
@@ -94,6 +129,9 @@ func (q *Queries) GetDesks(ctx context.Context) ([]Desk, error) {
 			&i.IsActive,
 			&i.PublicID,
 			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LocationPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -109,7 +147,7 @@ func (q *Queries) GetDesks(ctx context.Context) ([]Desk, error) {
 }
 
 const getDesksByPublicID = `-- name: GetDesksByPublicID :one
-SELECT id, description, is_active, public_id, name FROM desks
+SELECT id, description, is_active, public_id, name, created_at, updated_at, location_public_id FROM desks
 WHERE public_id = $1
 `
 
@@ -122,18 +160,63 @@ func (q *Queries) GetDesksByPublicID(ctx context.Context, publicID string) (Desk
 		&i.IsActive,
 		&i.PublicID,
 		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LocationPublicID,
 	)
 	return i, err
 }
 
 const listDesks = `-- name: ListDesks :many
-SELECT id, description, is_active, public_id, name FROM desks
+SELECT id, description, is_active, public_id, name, created_at, updated_at, location_public_id FROM desks
 WHERE ($1::boolean IS NULL OR is_active = $1)
-ORDER BY name ASC
+    AND ($2::text IS NULL OR location_public_id = $2)
+    AND ($3::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = $3))
+    AND ($4::text IS NULL
+        OR ($5::text = 'name' AND (
+            CASE WHEN $6::boolean THEN name < $7::text ELSE name > $7::text END
+            OR (name = $7::text AND public_id > $4::text)))
+        OR ($5::text = 'created_at' AND (
+            CASE WHEN $6::boolean THEN created_at < $8::timestamp ELSE created_at > $8::timestamp END
+            OR (created_at = $8::timestamp AND public_id > $4::text)))
+        OR ($5::text = 'updated_at' AND (
+            CASE WHEN $6::boolean THEN updated_at < $8::timestamp ELSE updated_at > $8::timestamp END
+            OR (updated_at = $8::timestamp AND public_id > $4::text))))
+ORDER BY
+    CASE WHEN $5::text = 'name' AND NOT $6::boolean THEN name END ASC,
+    CASE WHEN $5::text = 'name' AND $6::boolean THEN name END DESC,
+    CASE WHEN $5::text = 'created_at' AND NOT $6::boolean THEN created_at END ASC,
+    CASE WHEN $5::text = 'created_at' AND $6::boolean THEN created_at END DESC,
+    CASE WHEN $5::text = 'updated_at' AND NOT $6::boolean THEN updated_at END ASC,
+    CASE WHEN $5::text = 'updated_at' AND $6::boolean THEN updated_at END DESC,
+    public_id ASC
+LIMIT $9::int
 `
 
-func (q *Queries) ListDesks(ctx context.Context, isActive sql.NullBool) ([]Desk, error) {
-	rows, err := q.db.QueryContext(ctx, listDesks, isActive)
+type ListDesksParams struct {
+	IsActive         sql.NullBool
+	LocationPublicID sql.NullString
+	MemberPublicID   sql.NullString
+	AfterPublicID    sql.NullString
+	Sort             string
+	Descending       bool
+	AfterText        sql.NullString
+	AfterTime        sql.NullTime
+	RowLimit         int32
+}
+
+func (q *Queries) ListDesks(ctx context.Context, arg ListDesksParams) ([]Desk, error) {
+	rows, err := q.db.QueryContext(ctx, listDesks,
+		arg.IsActive,
+		arg.LocationPublicID,
+		arg.MemberPublicID,
+		arg.AfterPublicID,
+		arg.Sort,
+		arg.Descending,
+		arg.AfterText,
+		arg.AfterTime,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -147,6 +230,9 @@ func (q *Queries) ListDesks(ctx context.Context, isActive sql.NullBool) ([]Desk,
 			&i.IsActive,
 			&i.PublicID,
 			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LocationPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -164,8 +250,8 @@ func (q *Queries) ListDesks(ctx context.Context, isActive sql.NullBool) ([]Desk,
 const setDesksByPublicID = `-- name: SetDesksByPublicID :one
 UPDATE desks
 SET name = $2, description = $3, is_active = $4, updated_at = NOW()
-WHERE public_id = $1
-RETURNING id, description, is_active, public_id, name
+WHERE public_id = $1 AND ($5::timestamp IS NULL OR updated_at = $5)
+RETURNING id, description, is_active, public_id, name, created_at, updated_at, location_public_id
 `
 
 type SetDesksByPublicIDParams struct {
@@ -173,6 +259,7 @@ type SetDesksByPublicIDParams struct {
 	Name        string
 	Description sql.NullString
 	IsActive    bool
+	IfUpdatedAt sql.NullTime
 }
 
 func (q *Queries) SetDesksByPublicID(ctx context.Context, arg SetDesksByPublicIDParams) (Desk, error) {
@@ -181,6 +268,7 @@ func (q *Queries) SetDesksByPublicID(ctx context.Context, arg SetDesksByPublicID
 		arg.Name,
 		arg.Description,
 		arg.IsActive,
+		arg.IfUpdatedAt,
 	)
 	var i Desk
 	err := row.Scan(
@@ -189,6 +277,9 @@ func (q *Queries) SetDesksByPublicID(ctx context.Context, arg SetDesksByPublicID
 		&i.IsActive,
 		&i.PublicID,
 		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LocationPublicID,
 	)
 	return i, err
 }
@@ -6,26 +6,115 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AnnouncementTemplate struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	PublicID         string
+	LocationPublicID string
+	PurposePublicID  sql.NullString
+	Language         string
+	Template         string
+}
+
+type AuditEvent struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ActorPublicID  string
+	Action         string
+	EntityType     string
+	EntityPublicID string
+	Diff           json.RawMessage
+	RequestID      string
+	IpAddress      string
+}
+
 type Desk struct {
-	ID          uuid.UUID
-	Description sql.NullString
-	IsActive    bool
-	PublicID    string
-	Name        string
+	ID               uuid.UUID
+	Description      sql.NullString
+	IsActive         bool
+	PublicID         string
+	Name             string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	LocationPublicID string
+}
+
+type Holiday struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	PublicID         string
+	LocationPublicID string
+	HolidayDate      time.Time
+	Name             string
+}
+
+type IdempotencyKey struct {
+	Scope           string
+	IdempotencyKey  string
+	RequestHash     string
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+	ResponseStatus  sql.NullInt32
+	ResponseBody    []byte
+	LockedUntil     sql.NullTime
+	ResponseHeaders []byte
+}
+
+type Location struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	PublicID           string
+	Name               string
+	TimeZone           string
+	StopIssuingMinutes int32
+}
+
+type LoginAttempt struct {
+	AttemptKey     string
+	FailedAttempts int32
+	LastFailedAt   time.Time
+	BlockedUntil   time.Time
+	IsLockedOut    bool
+}
+
+type OpeningHour struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	PublicID         string
+	LocationPublicID string
+	PurposePublicID  sql.NullString
+	Weekday          int32
+	OpensAt          int32
+	ClosesAt         int32
 }
 
 type Purpose struct {
-	ID              uuid.UUID
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	PurposeName      string
+	ParentPurposeID  uuid.NullUUID
+	PublicID         string
+	LocationPublicID string
+	DailyCapacity    sql.NullInt32
+}
+
+type PurposeTranslation struct {
+	PurposePublicID string
+	Language        string
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
-	PurposeName     string
-	ParentPurposeID uuid.NullUUID
-	PublicID        string
+	Name            string
 }
 
 type RefreshToken struct {
@@ -36,23 +125,37 @@ type RefreshToken struct {
 	RevokedAt    sql.NullTime
 	PublicID     string
 	UserPublicID string
+	LastUsedAt   time.Time
+	UserAgent    string
+	IpAddress    string
 }
 
 type ServiceLog struct {
-	ID              uuid.UUID
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	CalledAt        time.Time
-	IsActive        bool
-	PublicID        string
-	UserPublicID    string
-	VisitorPublicID string
-	DeskPublicID    string
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	CalledAt         time.Time
+	IsActive         bool
+	PublicID         string
+	UserPublicID     string
+	VisitorPublicID  string
+	DeskPublicID     string
+	LocationPublicID string
 }
 
 type TicketCounter struct {
-	Date             time.Time
+	CounterDate      time.Time
 	LastTicketNumber int32
+	LocationPublicID string
+}
+
+type TicketLayout struct {
+	PurposePublicID string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Header          string
+	Footer          string
+	Logo            []byte
 }
 
 type User struct {
@@ -68,6 +171,23 @@ type User struct {
 	PublicID       string
 }
 
+type UserLocation struct {
+	UserPublicID     string
+	LocationPublicID string
+}
+
+type UserToken struct {
+	ID           uuid.UUID
+	PublicID     string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserPublicID string
+	Kind         string
+	TokenHash    string
+	ExpiresAt    time.Time
+	UsedAt       sql.NullTime
+}
+
 type Visitor struct {
 	ID                uuid.UUID
 	CreatedAt         time.Time
@@ -78,4 +198,34 @@ type Visitor struct {
 	DailyTicketNumber int32
 	PublicID          string
 	PurposePublicID   string
+	LocationPublicID  string
+	PhoneNumber       sql.NullString
+	Email             sql.NullString
+	NotifiedAt        sql.NullTime
+}
+
+type WebhookDelivery struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	PublicID             string
+	SubscriptionPublicID string
+	EventType            string
+	Payload              []byte
+	Status               string
+	Attempts             int32
+	NextAttemptAt        time.Time
+	ResponseStatus       sql.NullInt32
+	LastError            sql.NullString
+	DeliveredAt          sql.NullTime
+}
+
+type WebhookSubscription struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	PublicID  string
+	Url       string
+	Secret    string
+	EventType string
 }
@@ -7,31 +7,42 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 const createPurpose = `-- name: CreatePurpose :one
-INSERT INTO purposes (id, public_id, created_at, updated_at, purpose_name, parent_purpose_id)
+INSERT INTO purposes (id, public_id, created_at, updated_at, purpose_name, parent_purpose_id, location_public_id, daily_capacity)
 VALUES (
     gen_random_uuid(),
     $1,
     NOW(),
     NOW(),
     $2,
-    $3
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id
+RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity
 `
 
 type CreatePurposeParams struct {
-	PublicID        string
-	PurposeName     string
-	ParentPurposeID uuid.NullUUID
+	PublicID         string
+	PurposeName      string
+	ParentPurposeID  uuid.NullUUID
+	LocationPublicID string
+	DailyCapacity    sql.NullInt32
 }
 
 func (q *Queries) CreatePurpose(ctx context.Context, arg CreatePurposeParams) (Purpose, error) {
-	row := q.db.QueryRowContext(ctx, createPurpose, arg.PublicID, arg.PurposeName, arg.ParentPurposeID)
+	row := q.db.QueryRowContext(ctx, createPurpose,
+		arg.PublicID,
+		arg.PurposeName,
+		arg.ParentPurposeID,
+		arg.LocationPublicID,
+		arg.DailyCapacity,
+	)
 	var i Purpose
 	err := row.Scan(
 		&i.ID,
@@ -40,12 +51,14 @@ func (q *Queries) CreatePurpose(ctx context.Context, arg CreatePurposeParams) (P
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
 
 const getPurposes = `-- name: GetPurposes :many
-SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id FROM purposes
+SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity FROM purposes
 `
 
 func (q *Queries) GetPurposes(ctx context.Context) ([]Purpose, error) {
@@ -64,6 +77,8 @@ func (q *Queries) GetPurposes(ctx context.Context) ([]Purpose, error) {
 			&i.PurposeName,
 			&i.ParentPurposeID,
 			&i.PublicID,
+			&i.LocationPublicID,
+			&i.DailyCapacity,
 		); err != nil {
 			return nil, err
 		}
@@ -79,7 +94,7 @@ func (q *Queries) GetPurposes(ctx context.Context) ([]Purpose, error) {
 }
 
 const getPurposesByID = `-- name: GetPurposesByID :one
-SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id FROM purposes
+SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity FROM purposes
 WHERE id = $1
 `
 
@@ -93,12 +108,51 @@ func (q *Queries) GetPurposesByID(ctx context.Context, id uuid.UUID) (Purpose, e
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
 
+const getPurposesByLocationPublicID = `-- name: GetPurposesByLocationPublicID :many
+SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity FROM purposes
+WHERE location_public_id = $1
+`
+
+func (q *Queries) GetPurposesByLocationPublicID(ctx context.Context, locationPublicID string) ([]Purpose, error) {
+	rows, err := q.db.QueryContext(ctx, getPurposesByLocationPublicID, locationPublicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Purpose
+	for rows.Next() {
+		var i Purpose
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PurposeName,
+			&i.ParentPurposeID,
+			&i.PublicID,
+			&i.LocationPublicID,
+			&i.DailyCapacity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPurposesByName = `-- name: GetPurposesByName :one
-SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id FROM purposes
+SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity FROM purposes
 WHERE purpose_name = $1
 `
 
@@ -112,12 +166,14 @@ func (q *Queries) GetPurposesByName(ctx context.Context, purposeName string) (Pu
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
 
 const getPurposesByParent = `-- name: GetPurposesByParent :many
-SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id FROM purposes
+SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity FROM purposes
 WHERE parent_purpose_id = $1
 `
 
@@ -137,6 +193,8 @@ func (q *Queries) GetPurposesByParent(ctx context.Context, parentPurposeID uuid.
 			&i.PurposeName,
 			&i.ParentPurposeID,
 			&i.PublicID,
+			&i.LocationPublicID,
+			&i.DailyCapacity,
 		); err != nil {
 			return nil, err
 		}
@@ -152,7 +210,7 @@ func (q *Queries) GetPurposesByParent(ctx context.Context, parentPurposeID uuid.
 }
 
 const getPurposesByPublicID = `-- name: GetPurposesByPublicID :one
-SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id FROM purposes
+SELECT id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity FROM purposes
 WHERE public_id = $1
 `
 
@@ -166,6 +224,8 @@ func (q *Queries) GetPurposesByPublicID(ctx context.Context, publicID string) (P
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
@@ -174,7 +234,7 @@ const setPurpose = `-- name: SetPurpose :one
 UPDATE purposes
 SET purpose_name = $2, parent_purpose_id = $3, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id
+RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity
 `
 
 type SetPurposeParams struct {
@@ -193,25 +253,35 @@ func (q *Queries) SetPurpose(ctx context.Context, arg SetPurposeParams) (Purpose
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
 
 const setPurposeByPublicID = `-- name: SetPurposeByPublicID :one
 UPDATE purposes
-SET purpose_name = $2, parent_purpose_id = $3, updated_at = NOW()
-WHERE public_id = $1
-RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id
+SET purpose_name = $2, parent_purpose_id = $3, daily_capacity = $4, updated_at = NOW()
+WHERE public_id = $1 AND ($5::timestamp IS NULL OR updated_at = $5)
+RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity
 `
 
 type SetPurposeByPublicIDParams struct {
 	PublicID        string
 	PurposeName     string
 	ParentPurposeID uuid.NullUUID
+	DailyCapacity   sql.NullInt32
+	IfUpdatedAt     sql.NullTime
 }
 
 func (q *Queries) SetPurposeByPublicID(ctx context.Context, arg SetPurposeByPublicIDParams) (Purpose, error) {
-	row := q.db.QueryRowContext(ctx, setPurposeByPublicID, arg.PublicID, arg.PurposeName, arg.ParentPurposeID)
+	row := q.db.QueryRowContext(ctx, setPurposeByPublicID,
+		arg.PublicID,
+		arg.PurposeName,
+		arg.ParentPurposeID,
+		arg.DailyCapacity,
+		arg.IfUpdatedAt,
+	)
 	var i Purpose
 	err := row.Scan(
 		&i.ID,
@@ -220,6 +290,8 @@ func (q *Queries) SetPurposeByPublicID(ctx context.Context, arg SetPurposeByPubl
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
@@ -228,7 +300,7 @@ const setPurposeName = `-- name: SetPurposeName :one
 UPDATE purposes
 SET purpose_name = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id
+RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity
 `
 
 type SetPurposeNameParams struct {
@@ -246,6 +318,8 @@ func (q *Queries) SetPurposeName(ctx context.Context, arg SetPurposeNameParams)
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
@@ -254,7 +328,7 @@ const setPurposeParentID = `-- name: SetPurposeParentID :one
 UPDATE purposes
 SET parent_purpose_id = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id
+RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity
 `
 
 type SetPurposeParentIDParams struct {
@@ -272,6 +346,8 @@ func (q *Queries) SetPurposeParentID(ctx context.Context, arg SetPurposeParentID
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
@@ -280,7 +356,7 @@ const setPurposeParentIDByParentPurposeName = `-- name: SetPurposeParentIDByPare
 UPDATE purposes
 SET parent_purpose_id = (SELECT purposes.id FROM purposes WHERE purposes.purpose_name = $2), updated_at = NOW()
 WHERE purposes.id = $1
-RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id
+RETURNING id, created_at, updated_at, purpose_name, parent_purpose_id, public_id, location_public_id, daily_capacity
 `
 
 type SetPurposeParentIDByParentPurposeNameParams struct {
@@ -298,6 +374,8 @@ func (q *Queries) SetPurposeParentIDByParentPurposeName(ctx context.Context, arg
 		&i.PurposeName,
 		&i.ParentPurposeID,
 		&i.PublicID,
+		&i.LocationPublicID,
+		&i.DailyCapacity,
 	)
 	return i, err
 }
@@ -11,7 +11,7 @@ import (
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, public_id, created_at, updated_at, user_public_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, public_id, created_at, updated_at, user_public_id, expires_at, revoked_at, last_used_at, user_agent, ip_address)
 VALUES (
     $1,
     $2,
@@ -19,9 +19,12 @@ VALUES (
     NOW(),
     $3,
     $4,
-    NULL
+    NULL,
+    NOW(),
+    $5,
+    $6
 )
-RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id
+RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address
 `
 
 type CreateRefreshTokenParams struct {
@@ -29,6 +32,8 @@ type CreateRefreshTokenParams struct {
 	PublicID     string
 	UserPublicID string
 	ExpiresAt    time.Time
+	UserAgent    string
+	IpAddress    string
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
@@ -37,6 +42,8 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		arg.PublicID,
 		arg.UserPublicID,
 		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.IpAddress,
 	)
 	var i RefreshToken
 	err := row.Scan(
@@ -47,12 +54,15 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.RevokedAt,
 		&i.PublicID,
 		&i.UserPublicID,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
 	)
 	return i, err
 }
 
 const getRefreshTokenByToken = `-- name: GetRefreshTokenByToken :one
-SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id FROM refresh_tokens
+SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address FROM refresh_tokens
 WHERE token = $1
 `
 
@@ -67,12 +77,15 @@ func (q *Queries) GetRefreshTokenByToken(ctx context.Context, token string) (Ref
 		&i.RevokedAt,
 		&i.PublicID,
 		&i.UserPublicID,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
 	)
 	return i, err
 }
 
 const getRefreshTokens = `-- name: GetRefreshTokens :many
-SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id FROM refresh_tokens
+SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address FROM refresh_tokens
 `
 
 func (q *Queries) GetRefreshTokens(ctx context.Context) ([]RefreshToken, error) {
@@ -92,6 +105,9 @@ func (q *Queries) GetRefreshTokens(ctx context.Context) ([]RefreshToken, error)
 			&i.RevokedAt,
 			&i.PublicID,
 			&i.UserPublicID,
+			&i.LastUsedAt,
+			&i.UserAgent,
+			&i.IpAddress,
 		); err != nil {
 			return nil, err
 		}
@@ -107,7 +123,7 @@ func (q *Queries) GetRefreshTokens(ctx context.Context) ([]RefreshToken, error)
 }
 
 const getRefreshTokensByPublicID = `-- name: GetRefreshTokensByPublicID :one
-SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id FROM refresh_tokens
+SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address FROM refresh_tokens
 WHERE public_id = $1
 `
 
@@ -122,13 +138,17 @@ func (q *Queries) GetRefreshTokensByPublicID(ctx context.Context, publicID strin
 		&i.RevokedAt,
 		&i.PublicID,
 		&i.UserPublicID,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
 	)
 	return i, err
 }
 
 const getRefreshTokensByUserPublicID = `-- name: GetRefreshTokensByUserPublicID :many
-SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id FROM refresh_tokens
+SELECT token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address FROM refresh_tokens
 WHERE user_public_id = $1 AND expires_at > NOW() AND revoked_at IS NULL
+ORDER BY last_used_at DESC
 `
 
 func (q *Queries) GetRefreshTokensByUserPublicID(ctx context.Context, userPublicID string) ([]RefreshToken, error) {
@@ -148,6 +168,9 @@ func (q *Queries) GetRefreshTokensByUserPublicID(ctx context.Context, userPublic
 			&i.RevokedAt,
 			&i.PublicID,
 			&i.UserPublicID,
+			&i.LastUsedAt,
+			&i.UserAgent,
+			&i.IpAddress,
 		); err != nil {
 			return nil, err
 		}
@@ -162,11 +185,36 @@ func (q *Queries) GetRefreshTokensByUserPublicID(ctx context.Context, userPublic
 	return items, nil
 }
 
+const revokeRefreshTokenByPublicID = `-- name: RevokeRefreshTokenByPublicID :one
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE public_id = $1 AND expires_at > NOW() AND revoked_at IS NULL
+RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address
+`
+
+func (q *Queries) RevokeRefreshTokenByPublicID(ctx context.Context, publicID string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, revokeRefreshTokenByPublicID, publicID)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.PublicID,
+		&i.UserPublicID,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+	)
+	return i, err
+}
+
 const revokeRefreshTokenByToken = `-- name: RevokeRefreshTokenByToken :one
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
 WHERE token = $1
-RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id
+RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address
 `
 
 func (q *Queries) RevokeRefreshTokenByToken(ctx context.Context, token string) (RefreshToken, error) {
@@ -180,6 +228,9 @@ func (q *Queries) RevokeRefreshTokenByToken(ctx context.Context, token string) (
 		&i.RevokedAt,
 		&i.PublicID,
 		&i.UserPublicID,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
 	)
 	return i, err
 }
@@ -188,7 +239,7 @@ const revokeRefreshTokenByUserPublicID = `-- name: RevokeRefreshTokenByUserPubli
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
 WHERE user_public_id = $1 AND expires_at > NOW() AND revoked_at IS NULL
-RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id
+RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address
 `
 
 func (q *Queries) RevokeRefreshTokenByUserPublicID(ctx context.Context, userPublicID string) ([]RefreshToken, error) {
@@ -208,6 +259,9 @@ func (q *Queries) RevokeRefreshTokenByUserPublicID(ctx context.Context, userPubl
 			&i.RevokedAt,
 			&i.PublicID,
 			&i.UserPublicID,
+			&i.LastUsedAt,
+			&i.UserAgent,
+			&i.IpAddress,
 		); err != nil {
 			return nil, err
 		}
@@ -226,7 +280,7 @@ const revokeRefreshTokens = `-- name: RevokeRefreshTokens :many
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
 WHERE expires_at > NOW() AND revoked_at IS NULL
-returning token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id
+returning token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address
 `
 
 func (q *Queries) RevokeRefreshTokens(ctx context.Context) ([]RefreshToken, error) {
@@ -246,6 +300,9 @@ func (q *Queries) RevokeRefreshTokens(ctx context.Context) ([]RefreshToken, erro
 			&i.RevokedAt,
 			&i.PublicID,
 			&i.UserPublicID,
+			&i.LastUsedAt,
+			&i.UserAgent,
+			&i.IpAddress,
 		); err != nil {
 			return nil, err
 		}
@@ -259,3 +316,42 @@ func (q *Queries) RevokeRefreshTokens(ctx context.Context) ([]RefreshToken, erro
 	}
 	return items, nil
 }
+
+const rotateRefreshTokenByToken = `-- name: RotateRefreshTokenByToken :one
+UPDATE refresh_tokens
+SET token = $1, expires_at = $2, user_agent = $3, ip_address = $4, last_used_at = NOW(), updated_at = NOW()
+WHERE token = $5 AND expires_at > NOW() AND revoked_at IS NULL
+RETURNING token, created_at, updated_at, expires_at, revoked_at, public_id, user_public_id, last_used_at, user_agent, ip_address
+`
+
+type RotateRefreshTokenByTokenParams struct {
+	NewToken  string
+	ExpiresAt time.Time
+	UserAgent string
+	IpAddress string
+	OldToken  string
+}
+
+func (q *Queries) RotateRefreshTokenByToken(ctx context.Context, arg RotateRefreshTokenByTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, rotateRefreshTokenByToken,
+		arg.NewToken,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.OldToken,
+	)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.PublicID,
+		&i.UserPublicID,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+	)
+	return i, err
+}
@@ -8,29 +8,69 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
+const countServiceLogs = `-- name: CountServiceLogs :one
+SELECT COUNT(*) FROM service_logs
+WHERE ($1::text IS NULL OR user_public_id = $1)
+    AND ($2::text IS NULL OR visitor_public_id = $2)
+    AND ($3::text IS NULL OR desk_public_id = $3)
+    AND ($4::timestamp IS NULL OR created_at >= $4)
+    AND ($5::timestamp IS NULL OR created_at < $5)
+    AND ($6::text IS NULL OR location_public_id = $6)
+    AND ($7::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = $7))
+`
+
+type CountServiceLogsParams struct {
+	UserPublicID     sql.NullString
+	VisitorPublicID  sql.NullString
+	DeskPublicID     sql.NullString
+	StartDate        sql.NullTime
+	EndDate          sql.NullTime
+	LocationPublicID sql.NullString
+	MemberPublicID   sql.NullString
+}
+
+func (q *Queries) CountServiceLogs(ctx context.Context, arg CountServiceLogsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countServiceLogs,
+		arg.UserPublicID,
+		arg.VisitorPublicID,
+		arg.DeskPublicID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.LocationPublicID,
+		arg.MemberPublicID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createServiceLogs = `-- name: CreateServiceLogs :one
-INSERT INTO service_logs (id, public_id, created_at, updated_at, visitor_public_id, user_public_id, desk_public_id, called_at, is_active)
+INSERT INTO service_logs (id, public_id, created_at, updated_at, visitor_public_id, user_public_id, desk_public_id, called_at, is_active, location_public_id)
 VALUES (
     gen_random_uuid(),
     $1,
-    NOW(),
-    NOW(),
+    $6, -- stamped by the caller in UTC, like the times it is compared with
+    $6,
     $2,
     $3,
     $4,
-    NOW(),
-    true
+    $6,
+    true,
+    $5
 )
-RETURNING id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id
+RETURNING id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id
 `
 
 type CreateServiceLogsParams struct {
-	PublicID        string
-	VisitorPublicID string
-	UserPublicID    string
-	DeskPublicID    string
+	PublicID         string
+	VisitorPublicID  string
+	UserPublicID     string
+	DeskPublicID     string
+	LocationPublicID string
+	CreatedAt        time.Time
 }
 
 func (q *Queries) CreateServiceLogs(ctx context.Context, arg CreateServiceLogsParams) (ServiceLog, error) {
@@ -39,6 +79,8 @@ func (q *Queries) CreateServiceLogs(ctx context.Context, arg CreateServiceLogsPa
 		arg.VisitorPublicID,
 		arg.UserPublicID,
 		arg.DeskPublicID,
+		arg.LocationPublicID,
+		arg.CreatedAt,
 	)
 	var i ServiceLog
 	err := row.Scan(
@@ -51,12 +93,26 @@ func (q *Queries) CreateServiceLogs(ctx context.Context, arg CreateServiceLogsPa
 		&i.UserPublicID,
 		&i.VisitorPublicID,
 		&i.DeskPublicID,
+		&i.LocationPublicID,
 	)
 	return i, err
 }
 
+const deleteServiceLogsOfVisitorsCreatedBefore = `-- name: DeleteServiceLogsOfVisitorsCreatedBefore :execrows
+DELETE FROM service_logs
+WHERE visitor_public_id IN (SELECT public_id FROM visitors WHERE created_at < $1::timestamp)
+`
+
+func (q *Queries) DeleteServiceLogsOfVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteServiceLogsOfVisitorsCreatedBefore, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getActiveServiceLogs = `-- name: GetActiveServiceLogs :many
-SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id FROM service_logs
+SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id FROM service_logs
 WHERE is_active = true
 `
 
@@ -79,6 +135,54 @@ func (q *Queries) GetActiveServiceLogs(ctx context.Context) ([]ServiceLog, error
 			&i.UserPublicID,
 			&i.VisitorPublicID,
 			&i.DeskPublicID,
+			&i.LocationPublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveServiceLogsByLocationPublicID = `-- name: GetActiveServiceLogsByLocationPublicID :many
+SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id FROM service_logs
+WHERE location_public_id = $1 AND is_active = TRUE AND called_at >= $2
+ORDER BY called_at DESC, public_id DESC
+LIMIT $3
+`
+
+type GetActiveServiceLogsByLocationPublicIDParams struct {
+	LocationPublicID string
+	CalledAt         time.Time
+	Limit            int32
+}
+
+func (q *Queries) GetActiveServiceLogsByLocationPublicID(ctx context.Context, arg GetActiveServiceLogsByLocationPublicIDParams) ([]ServiceLog, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveServiceLogsByLocationPublicID, arg.LocationPublicID, arg.CalledAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ServiceLog
+	for rows.Next() {
+		var i ServiceLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CalledAt,
+			&i.IsActive,
+			&i.PublicID,
+			&i.UserPublicID,
+			&i.VisitorPublicID,
+			&i.DeskPublicID,
+			&i.LocationPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -94,7 +198,7 @@ func (q *Queries) GetActiveServiceLogs(ctx context.Context) ([]ServiceLog, error
 }
 
 const getActiveServiceLogsByUserID = `-- name: GetActiveServiceLogsByUserID :many
-SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id FROM service_logs
+SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id FROM service_logs
 where is_active = true AND user_public_id = $1
 `
 
@@ -117,6 +221,7 @@ func (q *Queries) GetActiveServiceLogsByUserID(ctx context.Context, userPublicID
 			&i.UserPublicID,
 			&i.VisitorPublicID,
 			&i.DeskPublicID,
+			&i.LocationPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -131,8 +236,45 @@ func (q *Queries) GetActiveServiceLogsByUserID(ctx context.Context, userPublicID
 	return items, nil
 }
 
+const getCallTimesByPurposePublicID = `-- name: GetCallTimesByPurposePublicID :many
+SELECT service_logs.called_at FROM service_logs
+JOIN visitors ON visitors.public_id = service_logs.visitor_public_id
+WHERE visitors.purpose_public_id = $1 AND service_logs.called_at >= $2
+ORDER BY service_logs.called_at DESC
+LIMIT $3
+`
+
+type GetCallTimesByPurposePublicIDParams struct {
+	PurposePublicID string
+	CalledAt        time.Time
+	Limit           int32
+}
+
+func (q *Queries) GetCallTimesByPurposePublicID(ctx context.Context, arg GetCallTimesByPurposePublicIDParams) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, getCallTimesByPurposePublicID, arg.PurposePublicID, arg.CalledAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var called_at time.Time
+		if err := rows.Scan(&called_at); err != nil {
+			return nil, err
+		}
+		items = append(items, called_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getServiceLogs = `-- name: GetServiceLogs :many
-SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id FROM service_logs
+SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id FROM service_logs
 `
 
 func (q *Queries) GetServiceLogs(ctx context.Context) ([]ServiceLog, error) {
@@ -154,6 +296,7 @@ func (q *Queries) GetServiceLogs(ctx context.Context) ([]ServiceLog, error) {
 			&i.UserPublicID,
 			&i.VisitorPublicID,
 			&i.DeskPublicID,
+			&i.LocationPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -169,7 +312,7 @@ func (q *Queries) GetServiceLogs(ctx context.Context) ([]ServiceLog, error) {
 }
 
 const getServiceLogsByPublicID = `-- name: GetServiceLogsByPublicID :one
-SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id FROM service_logs
+SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id FROM service_logs
 WHERE public_id = $1
 `
 
@@ -186,26 +329,94 @@ func (q *Queries) GetServiceLogsByPublicID(ctx context.Context, publicID string)
 		&i.UserPublicID,
 		&i.VisitorPublicID,
 		&i.DeskPublicID,
+		&i.LocationPublicID,
 	)
 	return i, err
 }
 
+const getServiceLogsByVisitorPublicID = `-- name: GetServiceLogsByVisitorPublicID :many
+SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id FROM service_logs
+WHERE visitor_public_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetServiceLogsByVisitorPublicID(ctx context.Context, visitorPublicID string) ([]ServiceLog, error) {
+	rows, err := q.db.QueryContext(ctx, getServiceLogsByVisitorPublicID, visitorPublicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ServiceLog
+	for rows.Next() {
+		var i ServiceLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CalledAt,
+			&i.IsActive,
+			&i.PublicID,
+			&i.UserPublicID,
+			&i.VisitorPublicID,
+			&i.DeskPublicID,
+			&i.LocationPublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listServiceLogs = `-- name: ListServiceLogs :many
-SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id FROM service_logs
+SELECT id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id FROM service_logs
 WHERE ($1::text IS NULL OR user_public_id = $1)
-AND ($2::text IS NULL OR visitor_public_id = $2)
-AND ($3::text IS NULL OR desk_public_id = $3)
-AND ($4::timestamp IS NULL OR created_at >= $4)
-AND ($5::timestamp IS NULL OR created_at < $5)
-ORDER BY created_at ASC
+    AND ($2::text IS NULL OR visitor_public_id = $2)
+    AND ($3::text IS NULL OR desk_public_id = $3)
+    AND ($4::timestamp IS NULL OR created_at >= $4)
+    AND ($5::timestamp IS NULL OR created_at < $5)
+    AND ($6::text IS NULL OR location_public_id = $6)
+    AND ($7::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = $7))
+    AND ($8::text IS NULL
+        OR ($9::text = 'created_at' AND (
+            CASE WHEN $10::boolean THEN created_at < $11::timestamp ELSE created_at > $11::timestamp END
+            OR (created_at = $11::timestamp AND public_id > $8::text)))
+        OR ($9::text = 'called_at' AND (
+            CASE WHEN $10::boolean THEN called_at < $11::timestamp ELSE called_at > $11::timestamp END
+            OR (called_at = $11::timestamp AND public_id > $8::text)))
+        OR ($9::text = 'updated_at' AND (
+            CASE WHEN $10::boolean THEN updated_at < $11::timestamp ELSE updated_at > $11::timestamp END
+            OR (updated_at = $11::timestamp AND public_id > $8::text))))
+ORDER BY
+    CASE WHEN $9::text = 'created_at' AND NOT $10::boolean THEN created_at END ASC,
+    CASE WHEN $9::text = 'created_at' AND $10::boolean THEN created_at END DESC,
+    CASE WHEN $9::text = 'called_at' AND NOT $10::boolean THEN called_at END ASC,
+    CASE WHEN $9::text = 'called_at' AND $10::boolean THEN called_at END DESC,
+    CASE WHEN $9::text = 'updated_at' AND NOT $10::boolean THEN updated_at END ASC,
+    CASE WHEN $9::text = 'updated_at' AND $10::boolean THEN updated_at END DESC,
+    public_id ASC
+LIMIT $12::int
 `
 
 type ListServiceLogsParams struct {
-	UserPublicID    sql.NullString
-	VisitorPublicID sql.NullString
-	DeskPublicID    sql.NullString
-	StartDate       sql.NullTime
-	EndDate         sql.NullTime
+	UserPublicID     sql.NullString
+	VisitorPublicID  sql.NullString
+	DeskPublicID     sql.NullString
+	StartDate        sql.NullTime
+	EndDate          sql.NullTime
+	LocationPublicID sql.NullString
+	MemberPublicID   sql.NullString
+	AfterPublicID    sql.NullString
+	Sort             string
+	Descending       bool
+	AfterTime        sql.NullTime
+	RowLimit         int32
 }
 
 func (q *Queries) ListServiceLogs(ctx context.Context, arg ListServiceLogsParams) ([]ServiceLog, error) {
@@ -215,6 +426,13 @@ func (q *Queries) ListServiceLogs(ctx context.Context, arg ListServiceLogsParams
 		arg.DeskPublicID,
 		arg.StartDate,
 		arg.EndDate,
+		arg.LocationPublicID,
+		arg.MemberPublicID,
+		arg.AfterPublicID,
+		arg.Sort,
+		arg.Descending,
+		arg.AfterTime,
+		arg.RowLimit,
 	)
 	if err != nil {
 		return nil, err
@@ -233,6 +451,7 @@ func (q *Queries) ListServiceLogs(ctx context.Context, arg ListServiceLogsParams
 			&i.UserPublicID,
 			&i.VisitorPublicID,
 			&i.DeskPublicID,
+			&i.LocationPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -249,17 +468,18 @@ func (q *Queries) ListServiceLogs(ctx context.Context, arg ListServiceLogsParams
 
 const setServiceLogsByPublicID = `-- name: SetServiceLogsByPublicID :one
 UPDATE service_logs
-SET visitor_public_id = $2, user_public_id = $3, desk_public_id = $4, is_active = $5, updated_at = NOW()
+SET visitor_public_id = $2, user_public_id = $3, desk_public_id = $4, is_active = $5, location_public_id = $6, updated_at = NOW()
 WHERE public_id = $1
-RETURNING id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id
+RETURNING id, created_at, updated_at, called_at, is_active, public_id, user_public_id, visitor_public_id, desk_public_id, location_public_id
 `
 
 type SetServiceLogsByPublicIDParams struct {
-	PublicID        string
-	VisitorPublicID string
-	UserPublicID    string
-	DeskPublicID    string
-	IsActive        bool
+	PublicID         string
+	VisitorPublicID  string
+	UserPublicID     string
+	DeskPublicID     string
+	IsActive         bool
+	LocationPublicID string
 }
 
 func (q *Queries) SetServiceLogsByPublicID(ctx context.Context, arg SetServiceLogsByPublicIDParams) (ServiceLog, error) {
@@ -269,6 +489,7 @@ func (q *Queries) SetServiceLogsByPublicID(ctx context.Context, arg SetServiceLo
 		arg.UserPublicID,
 		arg.DeskPublicID,
 		arg.IsActive,
+		arg.LocationPublicID,
 	)
 	var i ServiceLog
 	err := row.Scan(
@@ -281,6 +502,7 @@ func (q *Queries) SetServiceLogsByPublicID(ctx context.Context, arg SetServiceLo
 		&i.UserPublicID,
 		&i.VisitorPublicID,
 		&i.DeskPublicID,
+		&i.LocationPublicID,
 	)
 	return i, err
 }
@@ -7,19 +7,25 @@ package database
 
 import (
 	"context"
+	"time"
 )
 
 const updateTicketCounter = `-- name: UpdateTicketCounter :one
-INSERT INTO ticket_counter (counter_date, last_ticket_number)
-VALUES (CURRENT_DATE, 1)
-ON CONFLICT (counter_date)
+INSERT INTO ticket_counter (location_public_id, counter_date, last_ticket_number)
+VALUES ($1, $2::date, 1)
+ON CONFLICT (location_public_id, counter_date)
 DO UPDATE SET
   last_ticket_number = ticket_counter.last_ticket_number + 1
 RETURNING last_ticket_number
 `
 
-func (q *Queries) UpdateTicketCounter(ctx context.Context) (int32, error) {
-	row := q.db.QueryRowContext(ctx, updateTicketCounter)
+type UpdateTicketCounterParams struct {
+	LocationPublicID string
+	CounterDate      time.Time
+}
+
+func (q *Queries) UpdateTicketCounter(ctx context.Context, arg UpdateTicketCounterParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, updateTicketCounter, arg.LocationPublicID, arg.CounterDate)
 	var last_ticket_number int32
 	err := row.Scan(&last_ticket_number)
 	return last_ticket_number, err
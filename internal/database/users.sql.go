@@ -7,10 +7,29 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+WHERE ($1::boolean IS NULL OR is_active = $1)
+    AND ($2::boolean IS NULL OR is_admin = $2)
+`
+
+type CountUsersParams struct {
+	IsActive sql.NullBool
+	IsAdmin  sql.NullBool
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers, arg.IsActive, arg.IsAdmin)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, public_id,created_at, updated_at, email, hashed_password, full_name, is_admin, is_active)
 VALUES (
@@ -188,6 +207,85 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const listUsers = `-- name: ListUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_admin, is_active, desk_id, full_name, public_id FROM users
+WHERE ($1::boolean IS NULL OR is_active = $1)
+    AND ($2::boolean IS NULL OR is_admin = $2)
+    AND ($3::text IS NULL
+        OR ($4::text = 'created_at' AND (
+            CASE WHEN $5::boolean THEN created_at < $6::timestamp ELSE created_at > $6::timestamp END
+            OR (created_at = $6::timestamp AND public_id > $3::text)))
+        OR ($4::text = 'email' AND (
+            CASE WHEN $5::boolean THEN email < $7::text ELSE email > $7::text END
+            OR (email = $7::text AND public_id > $3::text)))
+        OR ($4::text = 'full_name' AND (
+            CASE WHEN $5::boolean THEN full_name < $7::text ELSE full_name > $7::text END
+            OR (full_name = $7::text AND public_id > $3::text))))
+ORDER BY
+    CASE WHEN $4::text = 'created_at' AND NOT $5::boolean THEN created_at END ASC,
+    CASE WHEN $4::text = 'created_at' AND $5::boolean THEN created_at END DESC,
+    CASE WHEN $4::text = 'email' AND NOT $5::boolean THEN email END ASC,
+    CASE WHEN $4::text = 'email' AND $5::boolean THEN email END DESC,
+    CASE WHEN $4::text = 'full_name' AND NOT $5::boolean THEN full_name END ASC,
+    CASE WHEN $4::text = 'full_name' AND $5::boolean THEN full_name END DESC,
+    public_id ASC
+LIMIT $8::int
+`
+
+type ListUsersParams struct {
+	IsActive      sql.NullBool
+	IsAdmin       sql.NullBool
+	AfterPublicID sql.NullString
+	Sort          string
+	Descending    bool
+	AfterTime     sql.NullTime
+	AfterText     sql.NullString
+	RowLimit      int32
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers,
+		arg.IsActive,
+		arg.IsAdmin,
+		arg.AfterPublicID,
+		arg.Sort,
+		arg.Descending,
+		arg.AfterTime,
+		arg.AfterText,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsAdmin,
+			&i.IsActive,
+			&i.DeskID,
+			&i.FullName,
+			&i.PublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setUserByPublicID = `-- name: SetUserByPublicID :one
 UPDATE users
 SET email = $2, full_name = $3, is_admin = $4, is_active = $5, updated_at = NOW()
@@ -347,3 +445,33 @@ func (q *Queries) SetUserIsAdminByID(ctx context.Context, arg SetUserIsAdminByID
 	)
 	return i, err
 }
+
+const setUserPasswordByPublicID = `-- name: SetUserPasswordByPublicID :one
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE public_id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_admin, is_active, desk_id, full_name, public_id
+`
+
+type SetUserPasswordByPublicIDParams struct {
+	PublicID       string
+	HashedPassword string
+}
+
+func (q *Queries) SetUserPasswordByPublicID(ctx context.Context, arg SetUserPasswordByPublicIDParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserPasswordByPublicID, arg.PublicID, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.IsActive,
+		&i.DeskID,
+		&i.FullName,
+		&i.PublicID,
+	)
+	return i, err
+}
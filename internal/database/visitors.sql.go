@@ -8,24 +8,140 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const anonymizeVisitorByPublicID = `-- name: AnonymizeVisitorByPublicID :one
+UPDATE visitors
+SET name = NULL, phone_number = NULL, email = NULL, updated_at = NOW()
+WHERE public_id = $1
+RETURNING id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at
+`
+
+func (q *Queries) AnonymizeVisitorByPublicID(ctx context.Context, publicID string) (Visitor, error) {
+	row := q.db.QueryRowContext(ctx, anonymizeVisitorByPublicID, publicID)
+	var i Visitor
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WaitingSince,
+		&i.Name,
+		&i.Status,
+		&i.DailyTicketNumber,
+		&i.PublicID,
+		&i.PurposePublicID,
+		&i.LocationPublicID,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.NotifiedAt,
+	)
+	return i, err
+}
+
+const anonymizeVisitorsCreatedBefore = `-- name: AnonymizeVisitorsCreatedBefore :execrows
+UPDATE visitors
+SET name = NULL, phone_number = NULL, email = NULL, updated_at = NOW()
+WHERE created_at < $1::timestamp AND (name IS NOT NULL OR phone_number IS NOT NULL OR email IS NOT NULL)
+`
+
+func (q *Queries) AnonymizeVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, anonymizeVisitorsCreatedBefore, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countQueuedVisitorsAhead = `-- name: CountQueuedVisitorsAhead :one
+SELECT COUNT(*) FROM visitors
+WHERE purpose_public_id = $1 AND status = 0
+    AND NOT EXISTS (SELECT 1 FROM service_logs WHERE service_logs.visitor_public_id = visitors.public_id)
+    AND (waiting_since < $2 OR (waiting_since = $2 AND public_id < $3))
+`
+
+type CountQueuedVisitorsAheadParams struct {
+	PurposePublicID string
+	WaitingSince    time.Time
+	PublicID        string
+}
+
+func (q *Queries) CountQueuedVisitorsAhead(ctx context.Context, arg CountQueuedVisitorsAheadParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countQueuedVisitorsAhead, arg.PurposePublicID, arg.WaitingSince, arg.PublicID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countVisitors = `-- name: CountVisitors :one
+SELECT COUNT(*) FROM visitors
+WHERE ($1::int IS NULL OR status = $1)
+    AND ($2::text IS NULL OR purpose_public_id = $2)
+    AND ($3::timestamp IS NULL OR created_at >= $3)
+    AND ($4::timestamp IS NULL OR created_at < $4)
+    AND ($5::text IS NULL OR location_public_id = $5)
+    AND ($6::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = $6))
+`
+
+type CountVisitorsParams struct {
+	Status           sql.NullInt32
+	PurposePublicID  sql.NullString
+	StartDate        sql.NullTime
+	EndDate          sql.NullTime
+	LocationPublicID sql.NullString
+	MemberPublicID   sql.NullString
+}
+
+func (q *Queries) CountVisitors(ctx context.Context, arg CountVisitorsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countVisitors,
+		arg.Status,
+		arg.PurposePublicID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.LocationPublicID,
+		arg.MemberPublicID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countVisitorsByPurposePublicIDSince = `-- name: CountVisitorsByPurposePublicIDSince :one
+SELECT COUNT(*) FROM visitors
+WHERE purpose_public_id = $1 AND created_at >= $2
+`
+
+type CountVisitorsByPurposePublicIDSinceParams struct {
+	PurposePublicID string
+	CreatedAt       time.Time
+}
+
+func (q *Queries) CountVisitorsByPurposePublicIDSince(ctx context.Context, arg CountVisitorsByPurposePublicIDSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countVisitorsByPurposePublicIDSince, arg.PurposePublicID, arg.CreatedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createVisitor = `-- name: CreateVisitor :one
-INSERT INTO visitors (id, public_id, created_at, updated_at, waiting_since, name, purpose_public_id, status, daily_ticket_number)
+INSERT INTO visitors (id, public_id, created_at, updated_at, waiting_since, name, purpose_public_id, status, daily_ticket_number, location_public_id, phone_number, email)
 VALUES (
     gen_random_uuid(),
     $1,
-    NOW(),
-    NOW(),
-    NOW(),
+    $8, -- stamped by the caller in UTC, like the times it is compared with
+    $8,
+    $8,
     $2,
     $3,
     0, --status 
-    $4
+    $4,
+    $5,
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id
+RETURNING id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at
 `
 
 type CreateVisitorParams struct {
@@ -33,6 +149,10 @@ type CreateVisitorParams struct {
 	Name              sql.NullString
 	PurposePublicID   string
 	DailyTicketNumber int32
+	LocationPublicID  string
+	PhoneNumber       sql.NullString
+	Email             sql.NullString
+	CreatedAt         time.Time
 }
 
 func (q *Queries) CreateVisitor(ctx context.Context, arg CreateVisitorParams) (Visitor, error) {
@@ -41,6 +161,10 @@ func (q *Queries) CreateVisitor(ctx context.Context, arg CreateVisitorParams) (V
 		arg.Name,
 		arg.PurposePublicID,
 		arg.DailyTicketNumber,
+		arg.LocationPublicID,
+		arg.PhoneNumber,
+		arg.Email,
+		arg.CreatedAt,
 	)
 	var i Visitor
 	err := row.Scan(
@@ -53,12 +177,79 @@ func (q *Queries) CreateVisitor(ctx context.Context, arg CreateVisitorParams) (V
 		&i.DailyTicketNumber,
 		&i.PublicID,
 		&i.PurposePublicID,
+		&i.LocationPublicID,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.NotifiedAt,
 	)
 	return i, err
 }
 
+const deleteVisitorsCreatedBefore = `-- name: DeleteVisitorsCreatedBefore :execrows
+DELETE FROM visitors
+WHERE created_at < $1::timestamp
+`
+
+func (q *Queries) DeleteVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteVisitorsCreatedBefore, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getQueuedVisitorsByPurposePublicID = `-- name: GetQueuedVisitorsByPurposePublicID :many
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
+WHERE purpose_public_id = $1 AND status = 0
+    AND NOT EXISTS (SELECT 1 FROM service_logs WHERE service_logs.visitor_public_id = visitors.public_id)
+ORDER BY waiting_since ASC, public_id ASC
+LIMIT $2
+`
+
+type GetQueuedVisitorsByPurposePublicIDParams struct {
+	PurposePublicID string
+	Limit           int32
+}
+
+func (q *Queries) GetQueuedVisitorsByPurposePublicID(ctx context.Context, arg GetQueuedVisitorsByPurposePublicIDParams) ([]Visitor, error) {
+	rows, err := q.db.QueryContext(ctx, getQueuedVisitorsByPurposePublicID, arg.PurposePublicID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Visitor
+	for rows.Next() {
+		var i Visitor
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WaitingSince,
+			&i.Name,
+			&i.Status,
+			&i.DailyTicketNumber,
+			&i.PublicID,
+			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getVisitorByID = `-- name: GetVisitorByID :one
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
 WHERE visitors.id = $1
 `
 
@@ -75,12 +266,16 @@ func (q *Queries) GetVisitorByID(ctx context.Context, id uuid.UUID) (Visitor, er
 		&i.DailyTicketNumber,
 		&i.PublicID,
 		&i.PurposePublicID,
+		&i.LocationPublicID,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.NotifiedAt,
 	)
 	return i, err
 }
 
 const getVisitors = `-- name: GetVisitors :many
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
 `
 
 func (q *Queries) GetVisitors(ctx context.Context) ([]Visitor, error) {
@@ -102,6 +297,10 @@ func (q *Queries) GetVisitors(ctx context.Context) ([]Visitor, error) {
 			&i.DailyTicketNumber,
 			&i.PublicID,
 			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -117,7 +316,7 @@ func (q *Queries) GetVisitors(ctx context.Context) ([]Visitor, error) {
 }
 
 const getVisitorsByPublicID = `-- name: GetVisitorsByPublicID :one
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
 WHERE public_id = $1
 `
 
@@ -134,12 +333,16 @@ func (q *Queries) GetVisitorsByPublicID(ctx context.Context, publicID string) (V
 		&i.DailyTicketNumber,
 		&i.PublicID,
 		&i.PurposePublicID,
+		&i.LocationPublicID,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.NotifiedAt,
 	)
 	return i, err
 }
 
 const getVisitorsByPurposePublicID = `-- name: GetVisitorsByPurposePublicID :many
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
 WHERE purpose_public_id = $1
 ORDER BY waiting_since ASC
 `
@@ -163,6 +366,10 @@ func (q *Queries) GetVisitorsByPurposePublicID(ctx context.Context, purposePubli
 			&i.DailyTicketNumber,
 			&i.PublicID,
 			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -178,7 +385,7 @@ func (q *Queries) GetVisitorsByPurposePublicID(ctx context.Context, purposePubli
 }
 
 const getVisitorsByPurposePublicIDAndStatus = `-- name: GetVisitorsByPurposePublicIDAndStatus :many
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
 WHERE purpose_public_id = $1 AND status = $2
 ORDER BY waiting_since ASC
 `
@@ -207,6 +414,10 @@ func (q *Queries) GetVisitorsByPurposePublicIDAndStatus(ctx context.Context, arg
 			&i.DailyTicketNumber,
 			&i.PublicID,
 			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -222,7 +433,7 @@ func (q *Queries) GetVisitorsByPurposePublicIDAndStatus(ctx context.Context, arg
 }
 
 const getVisitorsByStatus = `-- name: GetVisitorsByStatus :many
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
 WHERE status = $1 -- status
 ORDER BY waiting_since ASC
 `
@@ -246,6 +457,10 @@ func (q *Queries) GetVisitorsByStatus(ctx context.Context, status int32) ([]Visi
 			&i.DailyTicketNumber,
 			&i.PublicID,
 			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -260,14 +475,20 @@ func (q *Queries) GetVisitorsByStatus(ctx context.Context, status int32) ([]Visi
 	return items, nil
 }
 
-const getVisitorsForToday = `-- name: GetVisitorsForToday :many
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
-WHERE waiting_since::date = CURRENT_DATE
+const getVisitorsForDay = `-- name: GetVisitorsForDay :many
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
+WHERE location_public_id = $1 AND waiting_since >= $2::timestamp AND waiting_since < $3::timestamp
 ORDER BY waiting_since ASC
 `
 
-func (q *Queries) GetVisitorsForToday(ctx context.Context) ([]Visitor, error) {
-	rows, err := q.db.QueryContext(ctx, getVisitorsForToday)
+type GetVisitorsForDayParams struct {
+	LocationPublicID string
+	DayStart         time.Time
+	DayEnd           time.Time
+}
+
+func (q *Queries) GetVisitorsForDay(ctx context.Context, arg GetVisitorsForDayParams) ([]Visitor, error) {
+	rows, err := q.db.QueryContext(ctx, getVisitorsForDay, arg.LocationPublicID, arg.DayStart, arg.DayEnd)
 	if err != nil {
 		return nil, err
 	}
@@ -285,6 +506,10 @@ func (q *Queries) GetVisitorsForToday(ctx context.Context) ([]Visitor, error) {
 			&i.DailyTicketNumber,
 			&i.PublicID,
 			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -300,7 +525,7 @@ func (q *Queries) GetVisitorsForToday(ctx context.Context) ([]Visitor, error) {
 }
 
 const getWaitingVisitorsByPurposePublicID = `-- name: GetWaitingVisitorsByPurposePublicID :many
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors 
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors 
 WHERE purpose_public_id = $1 AND status = 1 -- NOTE that statuses are still not properly implemented
 ORDER BY waiting_since ASC
 `
@@ -324,6 +549,10 @@ func (q *Queries) GetWaitingVisitorsByPurposePublicID(ctx context.Context, purpo
 			&i.DailyTicketNumber,
 			&i.PublicID,
 			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -339,19 +568,52 @@ func (q *Queries) GetWaitingVisitorsByPurposePublicID(ctx context.Context, purpo
 }
 
 const listVisitors = `-- name: ListVisitors :many
-SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id FROM visitors
+SELECT id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at FROM visitors
 WHERE ($1::int IS NULL OR status = $1)
     AND ($2::text IS NULL OR purpose_public_id = $2)
     AND ($3::timestamp IS NULL OR created_at >= $3)
     AND ($4::timestamp IS NULL OR created_at < $4)
-ORDER BY waiting_since ASC
+    AND ($5::text IS NULL OR location_public_id = $5)
+    AND ($6::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = $6))
+    AND ($7::text IS NULL
+        OR ($8::text = 'waiting_since' AND (
+            CASE WHEN $9::boolean THEN waiting_since < $10::timestamp ELSE waiting_since > $10::timestamp END
+            OR (waiting_since = $10::timestamp AND public_id > $7::text)))
+        OR ($8::text = 'created_at' AND (
+            CASE WHEN $9::boolean THEN created_at < $10::timestamp ELSE created_at > $10::timestamp END
+            OR (created_at = $10::timestamp AND public_id > $7::text)))
+        OR ($8::text = 'updated_at' AND (
+            CASE WHEN $9::boolean THEN updated_at < $10::timestamp ELSE updated_at > $10::timestamp END
+            OR (updated_at = $10::timestamp AND public_id > $7::text)))
+        OR ($8::text = 'daily_ticket_number' AND (
+            CASE WHEN $9::boolean THEN daily_ticket_number < $11::int ELSE daily_ticket_number > $11::int END
+            OR (daily_ticket_number = $11::int AND public_id > $7::text))))
+ORDER BY
+    CASE WHEN $8::text = 'waiting_since' AND NOT $9::boolean THEN waiting_since END ASC,
+    CASE WHEN $8::text = 'waiting_since' AND $9::boolean THEN waiting_since END DESC,
+    CASE WHEN $8::text = 'created_at' AND NOT $9::boolean THEN created_at END ASC,
+    CASE WHEN $8::text = 'created_at' AND $9::boolean THEN created_at END DESC,
+    CASE WHEN $8::text = 'updated_at' AND NOT $9::boolean THEN updated_at END ASC,
+    CASE WHEN $8::text = 'updated_at' AND $9::boolean THEN updated_at END DESC,
+    CASE WHEN $8::text = 'daily_ticket_number' AND NOT $9::boolean THEN daily_ticket_number END ASC,
+    CASE WHEN $8::text = 'daily_ticket_number' AND $9::boolean THEN daily_ticket_number END DESC,
+    public_id ASC
+LIMIT $12::int
 `
 
 type ListVisitorsParams struct {
-	Status          sql.NullInt32
-	PurposePublicID sql.NullString
-	StartDate       sql.NullTime
-	EndDate         sql.NullTime
+	Status           sql.NullInt32
+	PurposePublicID  sql.NullString
+	StartDate        sql.NullTime
+	EndDate          sql.NullTime
+	LocationPublicID sql.NullString
+	MemberPublicID   sql.NullString
+	AfterPublicID    sql.NullString
+	Sort             string
+	Descending       bool
+	AfterTime        sql.NullTime
+	AfterInt         sql.NullInt32
+	RowLimit         int32
 }
 
 func (q *Queries) ListVisitors(ctx context.Context, arg ListVisitorsParams) ([]Visitor, error) {
@@ -360,6 +622,14 @@ func (q *Queries) ListVisitors(ctx context.Context, arg ListVisitorsParams) ([]V
 		arg.PurposePublicID,
 		arg.StartDate,
 		arg.EndDate,
+		arg.LocationPublicID,
+		arg.MemberPublicID,
+		arg.AfterPublicID,
+		arg.Sort,
+		arg.Descending,
+		arg.AfterTime,
+		arg.AfterInt,
+		arg.RowLimit,
 	)
 	if err != nil {
 		return nil, err
@@ -378,6 +648,10 @@ func (q *Queries) ListVisitors(ctx context.Context, arg ListVisitorsParams) ([]V
 			&i.DailyTicketNumber,
 			&i.PublicID,
 			&i.PurposePublicID,
+			&i.LocationPublicID,
+			&i.PhoneNumber,
+			&i.Email,
+			&i.NotifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -396,7 +670,7 @@ const setVisitorByPublicID = `-- name: SetVisitorByPublicID :one
 UPDATE visitors
 SET name = $2, purpose_public_id = $3, status = $4, updated_at = NOW() -- status
 WHERE public_id = $1
-RETURNING id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id
+RETURNING id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at
 `
 
 type SetVisitorByPublicIDParams struct {
@@ -424,15 +698,38 @@ func (q *Queries) SetVisitorByPublicID(ctx context.Context, arg SetVisitorByPubl
 		&i.DailyTicketNumber,
 		&i.PublicID,
 		&i.PurposePublicID,
+		&i.LocationPublicID,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.NotifiedAt,
 	)
 	return i, err
 }
 
+const setVisitorNotifiedAt = `-- name: SetVisitorNotifiedAt :execrows
+UPDATE visitors
+SET notified_at = $2
+WHERE public_id = $1 AND notified_at IS NULL
+`
+
+type SetVisitorNotifiedAtParams struct {
+	PublicID   string
+	NotifiedAt sql.NullTime
+}
+
+func (q *Queries) SetVisitorNotifiedAt(ctx context.Context, arg SetVisitorNotifiedAtParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setVisitorNotifiedAt, arg.PublicID, arg.NotifiedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const setVisitorStatusByID = `-- name: SetVisitorStatusByID :one
 UPDATE visitors
 SET status = $2, updated_at = NOW() --status 
 WHERE id = $1
-RETURNING id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id
+RETURNING id, created_at, updated_at, waiting_since, name, status, daily_ticket_number, public_id, purpose_public_id, location_public_id, phone_number, email, notified_at
 `
 
 type SetVisitorStatusByIDParams struct {
@@ -453,6 +750,10 @@ func (q *Queries) SetVisitorStatusByID(ctx context.Context, arg SetVisitorStatus
 		&i.DailyTicketNumber,
 		&i.PublicID,
 		&i.PurposePublicID,
+		&i.LocationPublicID,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.NotifiedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,86 @@
+package jsonutils
+
+// The codes of error responses, see Problem. Codes are part of the API: clients branch on them, so they are never
+// renamed or reused for another problem, only added. Each has a message under "error." + code in the i18n catalogs
+// and a section in docs/errors.md.
+const (
+	// codes of errors without a code of their own, by status (see ErrorCode)
+	CodeBadRequest           = "bad_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeBodyTooLarge         = "body_too_large"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeUnprocessableEntity  = "unprocessable_entity"
+	CodePreconditionRequired = "precondition_required"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeInternalError        = "internal_error"
+
+	// request bodies, parameters and path values
+	CodeInvalidJSON             = "invalid_json"
+	CodeInvalidType             = "invalid_type"
+	CodeValidationFailed        = "validation_failed"
+	CodeUnknownField            = "unknown_field"
+	CodeRequired                = "required"
+	CodeTooShort                = "too_short"
+	CodeTooLong                 = "too_long"
+	CodeTooSmall                = "too_small"
+	CodeTooLarge                = "too_large"
+	CodeInvalidChoice           = "invalid_choice"
+	CodeInvalidPublicID         = "invalid_public_id"
+	CodeInvalidCursor           = "invalid_cursor"
+	CodeInvalidEmail            = "invalid_email"
+	CodeInvalidPhoneNumber      = "invalid_phone_number"
+	CodeInvalidTimeZone         = "invalid_time_zone"
+	CodeInvalidDailyCapacity    = "invalid_daily_capacity"
+	CodeInvalidLogo             = "invalid_logo"
+	CodeInvalidTranslation      = "invalid_translation"
+	CodeInvalidWebhookURL       = "invalid_webhook_url"
+	CodeInvalidWebhookEventType = "invalid_webhook_event_type"
+	CodePasswordDenied          = "password_denied"
+	CodeLocationMismatch        = "location_mismatch"
+
+	// resources that do not exist
+	CodeLocationNotFound             = "location_not_found"
+	CodePurposeNotFound              = "purpose_not_found"
+	CodeVisitorNotFound              = "visitor_not_found"
+	CodeDeskNotFound                 = "desk_not_found"
+	CodeUserNotFound                 = "user_not_found"
+	CodeServiceLogNotFound           = "service_log_not_found"
+	CodeSessionNotFound              = "session_not_found"
+	CodeWebhookNotFound              = "webhook_not_found"
+	CodeWebhookDeliveryNotFound      = "webhook_delivery_not_found"
+	CodeAnnouncementTemplateNotFound = "announcement_template_not_found"
+	CodeOpeningHourNotFound          = "opening_hour_not_found"
+	CodeHolidayNotFound              = "holiday_not_found"
+	CodeTicketLayoutNotFound         = "ticket_layout_not_found"
+
+	// authentication
+	CodeNotAuthenticated    = "not_authenticated"
+	CodeWrongUserType       = "wrong_user_type"
+	CodeRefreshTokenInvalid = "refresh_token_invalid"
+	CodeSessionEnded        = "session_ended"
+	CodeUserTokenInvalid    = "user_token_invalid"
+	CodeCSRFTokenInvalid    = "csrf_token_invalid"
+	CodeCSRFOriginInvalid   = "csrf_origin_invalid"
+	CodeLoginThrottled      = "login_throttled"
+
+	// authorization
+	CodeNotAdmin          = "not_admin"
+	CodeUserInactive      = "user_inactive"
+	CodeUserNotInLocation = "user_not_in_location"
+	CodeVisitorMismatch   = "visitor_mismatch"
+
+	// state of the queue and its resources
+	CodeQueueClosed                = "queue_closed"
+	CodeQueueFull                  = "queue_full"
+	CodeHolidayExists              = "holiday_exists"
+	CodeAnnouncementTemplateExists = "announcement_template_exists"
+
+	// idempotency keys
+	CodeIdempotencyKeyInvalid  = "idempotency_key_invalid"
+	CodeIdempotencyKeyReused   = "idempotency_key_reused"
+	CodeIdempotencyKeyInFlight = "idempotency_key_in_flight"
+)
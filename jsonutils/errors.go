@@ -0,0 +1,200 @@
+package jsonutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/dcrauwels/goqueue/i18n"
+)
+
+// Debug adds the message of the handler and the error itself to error responses. Only for development: they name
+// queries and handlers and may contain anything the error does. Set from ENV at startup
+var Debug bool
+
+// ProblemTypeBase is the start of the type URI of every problem: the code follows it, making the URI point to the
+// section about the code in docs/errors.md
+const ProblemTypeBase = "https://github.com/dcrauwels/goqueue/blob/main/docs/errors.md#"
+
+// Error is an error with a stable code, which clients can rely on where the message of the error may change. The code
+// also picks the message in the language of the client: the i18n catalogs have it under "error." + Code
+type Error struct {
+	Code string
+	text string
+}
+
+func NewError(code, text string) *Error {
+	return &Error{Code: code, text: text}
+}
+
+func (e *Error) Error() string {
+	return e.text
+}
+
+func (e *Error) Wrap(err error) error {
+	// err with the code of e, e.g. ErrPurposeNotFound.Wrap(sql.ErrNoRows). errors.Is and errors.As still find err
+	if err == nil {
+		return e
+	}
+	return fmt.Errorf("%w: %w", e, err)
+}
+
+// FieldError is an invalid field of a request body. Field is its JSON name, dotted for nested fields and elements
+type FieldError struct {
+	Field   string   `json:"field"`
+	Code    string   `json:"code"`
+	Limit   *int64   `json:"limit,omitempty"`   // the bound the field is beyond, for too_short, too_long, too_small and too_large
+	Allowed []string `json:"allowed,omitempty"` // the values the field may have, for invalid_choice
+	Detail  string   `json:"detail"`            // for people: the message of the code in the language of the request, if it has one
+}
+
+// ValidationError is a request body with one or more invalid fields. Its code is validation_failed; the fields are
+// listed in the errors member of the problem
+type ValidationError struct {
+	Fields []FieldError
+	errs   []error
+}
+
+func InvalidField(field string, err error) *ValidationError {
+	// a ValidationError of a single field, e.g. InvalidField("phone_number", strutils.ErrInvalidPhoneNumber)
+	e := &ValidationError{}
+	e.Add(field, err)
+	return e
+}
+
+func (e *ValidationError) Add(field string, err error) {
+	// adds field as invalid, with the code of err. The detail is the text of err until NewProblem localizes it
+	e.AddField(FieldError{Field: field, Code: ErrorCode(http.StatusBadRequest, err), Detail: err.Error()}, err)
+}
+
+func (e *ValidationError) AddField(f FieldError, err error) {
+	// adds f, which err made invalid
+	e.Fields = append(e.Fields, f)
+	e.errs = append(e.errs, err)
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.Field + ": " + e.errs[i].Error()
+	}
+	return "invalid fields: " + strings.Join(fields, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.errs
+}
+
+// the codes of errors without one of their own, by response status
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodeBodyTooLarge,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusUnprocessableEntity:   CodeUnprocessableEntity,
+	http.StatusPreconditionRequired:  CodePreconditionRequired,
+	http.StatusTooManyRequests:       CodeTooManyRequests,
+}
+
+func ErrorCode(respCode int, err error) string {
+	/*
+		The code of an error response: internal_error for every 5xx status, whatever went wrong, so clients learn nothing
+		about the server. Otherwise validation_failed for a ValidationError, the code of err if it has one (see Error),
+		invalid_json for request bodies that cannot be decoded, and else the code of the status.
+	*/
+	var validationErr *ValidationError
+	var e *Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case respCode >= 500:
+		return CodeInternalError
+	case errors.As(err, &validationErr):
+		return CodeValidationFailed
+	case errors.As(err, &e):
+		return e.Code
+	case respCode == http.StatusBadRequest && (errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)):
+		return CodeInvalidJSON
+	}
+	if code, ok := statusCodes[respCode]; ok {
+		return code
+	}
+	return CodeInternalError
+}
+
+// Problem is the body of error responses: problem details as in RFC 9457, extended with a stable code and the invalid
+// fields of the request body
+type Problem struct {
+	Type     string       `json:"type"`             // ProblemTypeBase followed by the code
+	Title    string       `json:"title"`            // the message of the code, in the language of the request
+	Status   int          `json:"status"`           // the status of the response
+	Detail   string       `json:"detail,omitempty"` // what went wrong with this request, in English. Never for 5xx
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`             // stable, see ErrorCode and codes.go
+	Errors   []FieldError `json:"errors,omitempty"` // the invalid fields of the request body, if any
+	Debug    string       `json:"debug,omitempty"`  // only if Debug is set
+}
+
+func NewProblem(w http.ResponseWriter, r *http.Request, respCode int, err error, msg string) Problem {
+	/*
+		The body of an error response to r, for handlers that add fields of their own to it; WriteError writes it as it
+		is. The error is logged. The language of the response is negotiated from the Accept-Language header of r and
+		set in the Content-Language header of w. The instance is the ID of the request, taken from the X-Request-ID
+		header already set on w by the request ID middleware.
+	*/
+	if err != nil {
+		log.Println(err)
+	}
+	code := ErrorCode(respCode, err)
+	language := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", language)
+	w.Header().Add("Vary", "Accept-Language")
+	problem := Problem{
+		Type:     ProblemTypeBase + code,
+		Title:    errorMessage(language, code, respCode),
+		Status:   respCode,
+		Instance: w.Header().Get("X-Request-ID"),
+		Code:     code,
+	}
+	if respCode < 500 {
+		problem.Detail = msg
+		var validationErr *ValidationError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &validationErr) {
+			problem.Errors = append(problem.Errors, validationErr.Fields...)
+		} else if errors.As(err, &typeErr) && typeErr.Field != "" {
+			problem.Errors = []FieldError{{Field: typeErr.Field, Code: CodeInvalidType}}
+		}
+		for i, f := range problem.Errors {
+			if message, ok := i18n.Lookup(language, "error."+f.Code); ok && f.Code != CodeBadRequest {
+				if f.Limit != nil {
+					message = fmt.Sprintf(message, *f.Limit)
+				}
+				problem.Errors[i].Detail = message
+			}
+		}
+	}
+	if Debug {
+		problem.Debug = msg
+		if err != nil {
+			problem.Debug += ": " + err.Error()
+		}
+	}
+	return problem
+}
+
+func errorMessage(language, code string, respCode int) string {
+	// the message of code in language, or that of the status for a code without a message of its own
+	if message, ok := i18n.Lookup(language, "error."+code); ok {
+		return message
+	}
+	return i18n.Message(language, "error."+ErrorCode(respCode, nil))
+}
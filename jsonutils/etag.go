@@ -0,0 +1,88 @@
+package jsonutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPreconditionFailed   = NewError(CodePreconditionFailed, "resource was changed since the version in If-Match")
+	ErrPreconditionRequired = NewError(CodePreconditionRequired, "If-Match header required")
+)
+
+// ETag is the entity tag of a version of a resource, derived from its updated_at column. It is a strong tag, because
+// If-Match only accepts those.
+func ETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 36) + `"`
+}
+
+func IfMatch(r *http.Request, etag string) bool {
+	/*
+		Whether the If-Match header of r allows changing the version of the resource tagged etag: without the header,
+		for * and if etag is one of the listed tags. Weak tags (W/"...") never match, see RFC 9110 section 13.1.1.
+	*/
+	header := r.Header.Get("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func ifNoneMatch(r *http.Request, etag string) bool {
+	// whether the If-None-Match header of r lists etag, comparing weakly (i.e. ignoring the W/ prefix)
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func WriteJSONWithETag(w http.ResponseWriter, r *http.Request, respCode int, etag string, payload any) {
+	/*
+		WriteJSON with an ETag header. A GET or HEAD request that already has this version (If-None-Match) gets a 304
+		Not Modified without a body, so that clients can poll cheaply.
+		An empty etag is derived from the response instead: a weak tag over the body and the pagination headers, for
+		lists, which have no single updated_at.
+	*/
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling JSON: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if etag == "" {
+		hash := sha256.New()
+		hash.Write(dat)
+		for _, header := range []string{"Link", "X-Total-Count"} {
+			hash.Write([]byte(strings.Join(w.Header().Values(header), "\n") + "\n"))
+		}
+		etag = `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	}
+	w.Header().Set("ETag", etag)
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && respCode == http.StatusOK && ifNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(respCode)
+	w.Write(dat)
+}
@@ -6,19 +6,22 @@ import (
 	"net/http"
 )
 
-func WriteError(w http.ResponseWriter, respCode int, err error, msg string) {
-	if err != nil {
-		log.Println(err)
-	}
-	type errorResponse struct {
-		Error string `json:"error"`
-	}
-	e := errorResponse{Error: msg}
-	WriteJSON(w, respCode, e)
+func WriteError(w http.ResponseWriter, r *http.Request, respCode int, err error, msg string) {
+	// writes a problem with a stable code and a message in the language of r, see NewProblem
+	WriteProblem(w, respCode, NewProblem(w, r, respCode, err, msg))
+}
+
+func WriteProblem(w http.ResponseWriter, respCode int, problem any) {
+	// writes problem, a Problem or a struct embedding one, as application/problem+json
+	writeJSON(w, respCode, "application/problem+json", problem)
 }
 
 func WriteJSON(w http.ResponseWriter, respCode int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, respCode, "application/json", payload)
+}
+
+func writeJSON(w http.ResponseWriter, respCode int, contentType string, payload any) {
+	w.Header().Set("Content-Type", contentType)
 	dat, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("Error marshalling JSON: %s", err)
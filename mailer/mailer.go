@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	/*
+		Sends a single message. SMTPSender delivers mail; WriterSender is a stand-in for development that writes
+		messages to stdout or a file.
+	*/
+	Send(ctx context.Context, msg Message) error
+}
+
+// WriterSender writes messages in a human readable format to an io.Writer instead of sending them.
+type WriterSender struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSender(w io.Writer) *WriterSender {
+	return &WriterSender{w: w}
+}
+
+func NewStdoutSender() *WriterSender {
+	return NewWriterSender(os.Stdout)
+}
+
+func NewFileSender(path string) (*WriterSender, error) {
+	// appends messages to the file at path, creating it if needed. The file is kept open for the lifetime of the process.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSender(f), nil
+}
+
+func (s *WriterSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.w, "--- mail %s ---\nTo: %s\nSubject: %s\n\n%s\n%s\n",
+		time.Now().Format(time.RFC3339),
+		msg.To,
+		msg.Subject,
+		msg.Body,
+		strings.Repeat("-", 40),
+	)
+	return err
+}
+
+var ErrInvalidHeader = errors.New("mailer: line break in recipient or subject")
+
+// SMTPSender sends messages as plain text mail through an SMTP server, upgrading the connection with STARTTLS when
+// the server offers it.
+type SMTPSender struct {
+	Addr     string // host:port of the server
+	From     string // sender address
+	Username string // empty to send without authentication
+	Password string
+	Timeout  time.Duration // for the whole conversation with the server, zero for none
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return ErrInvalidHeader
+	}
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		// smtp.PlainAuth refuses to send the password over a connection without TLS, other than to localhost
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n%s\r\n",
+		s.From,
+		msg.To,
+		mime.QEncoding.Encode("utf-8", msg.Subject),
+		time.Now().Format(time.RFC1123Z),
+		strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"),
+	)
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterSender(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewWriterSender(&buf)
+	err := s.Send(context.Background(), Message{To: "jdoe@provider.tld", Subject: "hello", Body: "link: http://localhost/x"})
+	if err != nil {
+		t.Errorf(`Send() = %v; expected nil`, err)
+	}
+	out := buf.String()
+	for _, want := range []string{"To: jdoe@provider.tld", "Subject: hello", "link: http://localhost/x"} {
+		if !strings.Contains(out, want) {
+			t.Errorf(`Send() output does not contain %q:\n%s`, want, out)
+		}
+	}
+}
+
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	// accepts a single SMTP conversation without TLS or authentication and sends the received mail on the channel
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(`net.Listen: %v`, err)
+	}
+	t.Cleanup(func() { l.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost")
+		var data strings.Builder
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch verb, _, _ := strings.Cut(line, " "); strings.ToUpper(verb) {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				data.WriteString(line + "\n")
+				tp.PrintfLine("250 ok")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				body, _ := tp.ReadDotBytes()
+				data.Write(body)
+				tp.PrintfLine("250 ok")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				received <- data.String()
+				return
+			default:
+				tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return l.Addr().String(), received
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	s := &SMTPSender{Addr: addr, From: "goqueue@example.org", Timeout: 5 * time.Second}
+	err := s.Send(context.Background(), Message{To: "jdoe@provider.tld", Subject: "Ticket 12", Body: "please go to desk F2\nthank you"})
+	if err != nil {
+		t.Fatalf(`Send() = %v; expected nil`, err)
+	}
+	var mail string
+	select {
+	case mail = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf(`the SMTP server received no mail`)
+	}
+	for _, want := range []string{"MAIL FROM:<goqueue@example.org>", "RCPT TO:<jdoe@provider.tld>", "Subject: Ticket 12", "please go to desk F2\nthank you"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf(`the SMTP server received no %q:\n%s`, want, mail)
+		}
+	}
+
+	if err := s.Send(context.Background(), Message{To: "jdoe@provider.tld", Subject: "hi\r\nBcc: everyone@provider.tld"}); err != ErrInvalidHeader {
+		t.Errorf(`Send() with a line break in the subject = %v; expected ErrInvalidHeader`, err)
+	}
+}
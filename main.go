@@ -1,16 +1,28 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata" // location time zones must load on hosts without a time zone database
 
 	"github.com/dcrauwels/goqueue/admin"
 	"github.com/dcrauwels/goqueue/api"
-	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/auth"
+	"github.com/dcrauwels/goqueue/jsonutils"
+	"github.com/dcrauwels/goqueue/mailer"
+	"github.com/dcrauwels/goqueue/migrate"
+	"github.com/dcrauwels/goqueue/notify"
+	"github.com/dcrauwels/goqueue/retention"
+	"github.com/dcrauwels/goqueue/storage"
 	"github.com/dcrauwels/goqueue/strutils"
+	"github.com/dcrauwels/goqueue/webhook"
 	"github.com/jaevor/go-nanoid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -21,12 +33,30 @@ func main() {
 	godotenv.Load()
 	//opendb
 	dbURL := os.Getenv("DB_URL")
-	db, err := sql.Open("postgres", dbURL)
+	db, engine, err := storage.OpenDB(dbURL)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	dbQueries := database.New(db)
+
+	// schema migrations: the migrate subcommand only migrates, otherwise the server migrates on startup
+	migrator, err := migrate.New(db, engine)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(migrator, os.Args[2:]); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := migrateOnStartup(migrator); err != nil {
+		log.Println(err)
+		return
+	}
+	store := storage.New(db, engine)
 
 	// set up ApiConfig
 	accessTokenDuration, err := strutils.GetIntegerEnvironmentVariable("ACCESSTOKENDURATION")
@@ -51,56 +81,289 @@ func main() {
 		panic(err)
 	}
 
+	// login throttling
+	loginMaxAttempts, err := strutils.GetIntegerEnvironmentVariableWithDefault("LOGINMAXATTEMPTS", 5)
+	if err != nil {
+		log.Printf("Environment variable LOGINMAXATTEMPTS invalid: %v", err)
+		panic(err)
+	}
+	loginMaxAttemptsPerIP, err := strutils.GetIntegerEnvironmentVariableWithDefault("LOGINMAXATTEMPTSPERIP", 50)
+	if err != nil {
+		log.Printf("Environment variable LOGINMAXATTEMPTSPERIP invalid: %v", err)
+		panic(err)
+	}
+	loginLockoutDuration, err := strutils.GetIntegerEnvironmentVariableWithDefault("LOGINLOCKOUTDURATION", 15)
+	if err != nil {
+		log.Printf("Environment variable LOGINLOCKOUTDURATION invalid: %v", err)
+		panic(err)
+	}
+	logLockout := func(key string, lockedUntil time.Time) {
+		// lockouts happen outside of any user's doing, so they are recorded without actor or request
+		_, err := audit.Record(context.Background(), store, audit.Event{
+			Action:         audit.ActionLoginLockout,
+			EntityType:     audit.EntityLogin,
+			EntityPublicID: key,
+			After:          map[string]time.Time{"locked_until": lockedUntil},
+		})
+		if err != nil {
+			log.Printf("error writing audit event for login lockout of %s until %s: %v", key, lockedUntil.Format(time.RFC3339), err)
+		}
+	}
+	accountPolicy := auth.LoginLimiterPolicy{
+		MaxAttempts:     loginMaxAttempts,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutDuration: time.Duration(loginLockoutDuration) * time.Minute,
+		OnLockout:       logLockout,
+	}
+	ipPolicy := accountPolicy
+	ipPolicy.MaxAttempts = loginMaxAttemptsPerIP
+	var accountLimiter, ipLimiter auth.LoginLimiter
+	switch os.Getenv("LOGINLIMITER") {
+	case "", "memory":
+		accountLimiter = auth.NewMemoryLoginLimiter(accountPolicy)
+		ipLimiter = auth.NewMemoryLoginLimiter(ipPolicy)
+	case "postgres":
+		accountLimiter = auth.NewPostgresLoginLimiter(store, accountPolicy)
+		ipLimiter = auth.NewPostgresLoginLimiter(store, ipPolicy)
+	default:
+		log.Printf("Environment variable LOGINLIMITER must be either memory or postgres")
+		panic("invalid LOGINLIMITER")
+	}
+
+	// mail sender and links in mails
+	var mailSender mailer.Sender
+	switch os.Getenv("MAILSENDER") {
+	case "", "stdout":
+		mailSender = mailer.NewStdoutSender()
+	case "file":
+		mailSender, err = mailer.NewFileSender(os.Getenv("MAILFILE"))
+		if err != nil {
+			log.Printf("Could not open MAILFILE: %v", err)
+			panic(err)
+		}
+	case "smtp":
+		mailSender = &mailer.SMTPSender{
+			Addr:     os.Getenv("SMTPADDR"),
+			From:     os.Getenv("SMTPFROM"),
+			Username: os.Getenv("SMTPUSERNAME"),
+			Password: os.Getenv("SMTPPASSWORD"),
+			Timeout:  30 * time.Second,
+		}
+	default:
+		log.Printf("Environment variable MAILSENDER must be either stdout, file or smtp")
+		panic("invalid MAILSENDER")
+	}
+	publicBaseURL := os.Getenv("PUBLICBASEURL")
+	if publicBaseURL == "" {
+		publicBaseURL = "http://localhost:8080"
+	}
+	// visitors follow their turn on the status page, which QR codes link to: the visitor's public ID is appended to this
+	statusPageURL := os.Getenv("STATUSPAGEURL")
+	if statusPageURL != "" {
+		if parsed, err := url.Parse(statusPageURL); err != nil || parsed.Host == "" {
+			log.Printf("Environment variable STATUSPAGEURL invalid: %v", err)
+			panic("invalid STATUSPAGEURL")
+		}
+	}
+	invitationTokenDuration, err := strutils.GetIntegerEnvironmentVariableWithDefault("INVITATIONTOKENDURATION", 72)
+	if err != nil {
+		log.Printf("Environment variable INVITATIONTOKENDURATION invalid: %v", err)
+		panic(err)
+	}
+	passwordResetTokenDuration, err := strutils.GetIntegerEnvironmentVariableWithDefault("PASSWORDRESETTOKENDURATION", 60)
+	if err != nil {
+		log.Printf("Environment variable PASSWORDRESETTOKENDURATION invalid: %v", err)
+		panic(err)
+	}
+
+	// password policy and hashing
+	passwordPolicy := strutils.DefaultPasswordPolicy()
+	passwordPolicy.MinLength, err = strutils.GetIntegerEnvironmentVariableWithDefault("PASSWORDMINLENGTH", passwordPolicy.MinLength)
+	if err != nil {
+		log.Printf("Environment variable PASSWORDMINLENGTH invalid: %v", err)
+		panic(err)
+	}
+	passwordPolicy.RequiredClasses, err = strutils.ParseCharacterClasses(os.Getenv("PASSWORDREQUIREDCLASSES"))
+	if err != nil {
+		log.Printf("Environment variable PASSWORDREQUIREDCLASSES invalid: %v", err)
+		panic(err)
+	}
+	if denyListPath := os.Getenv("PASSWORDDENYLIST"); denyListPath != "" {
+		f, err := os.Open(denyListPath)
+		if err != nil {
+			log.Printf("Could not open PASSWORDDENYLIST: %v", err)
+			panic(err)
+		}
+		err = passwordPolicy.AddToDenyList(f)
+		f.Close()
+		if err != nil {
+			log.Printf("Could not read PASSWORDDENYLIST: %v", err)
+			panic(err)
+		}
+	}
+	passwordHasher := auth.DefaultPasswordHasher
+	if algorithm := os.Getenv("PASSWORDHASHER"); algorithm != "" {
+		passwordHasher.Algorithm = algorithm
+	}
+	if passwordHasher.Algorithm != auth.PasswordAlgorithmBcrypt && passwordHasher.Algorithm != auth.PasswordAlgorithmArgon2id {
+		log.Printf("Environment variable PASSWORDHASHER must be either bcrypt or argon2id")
+		panic("invalid PASSWORDHASHER")
+	}
+	passwordHasher.BcryptCost, err = strutils.GetIntegerEnvironmentVariableWithDefault("PASSWORDBCRYPTCOST", passwordHasher.BcryptCost)
+	if err != nil {
+		log.Printf("Environment variable PASSWORDBCRYPTCOST invalid: %v", err)
+		panic(err)
+	}
+	argon2Memory, err := strutils.GetIntegerEnvironmentVariableWithDefault("PASSWORDARGON2MEMORY", int(passwordHasher.Argon2Memory))
+	if err != nil {
+		log.Printf("Environment variable PASSWORDARGON2MEMORY invalid: %v", err)
+		panic(err)
+	}
+	argon2Time, err := strutils.GetIntegerEnvironmentVariableWithDefault("PASSWORDARGON2TIME", int(passwordHasher.Argon2Time))
+	if err != nil {
+		log.Printf("Environment variable PASSWORDARGON2TIME invalid: %v", err)
+		panic(err)
+	}
+	passwordHasher.Argon2Memory = uint32(argon2Memory)
+	passwordHasher.Argon2Time = uint32(argon2Time)
+	// origins allowed to send cookie authenticated requests: the public base URL plus any extra frontends
+	parsedBaseURL, err := url.Parse(publicBaseURL)
+	if err != nil || parsedBaseURL.Host == "" {
+		log.Printf("Environment variable PUBLICBASEURL invalid: %v", err)
+		panic("invalid PUBLICBASEURL")
+	}
+	trustedOrigins := []string{parsedBaseURL.Scheme + "://" + parsedBaseURL.Host}
+	for _, origin := range strings.Split(os.Getenv("CSRFTRUSTEDORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			trustedOrigins = append(trustedOrigins, origin)
+		}
+	}
+	idempotencyKeyDuration, err := strutils.GetIntegerEnvironmentVariableWithDefault("IDEMPOTENCYKEYDURATION", 24)
+	if err != nil {
+		log.Printf("Environment variable IDEMPOTENCYKEYDURATION invalid: %v", err)
+		panic(err)
+	}
+	// visitor data retention: names and contact details are removed after RETENTIONANONYMIZEDAYS, visitors deleted after RETENTIONDELETEDAYS
+	retentionPolicy := retention.Policy{}
+	retentionPolicy.AnonymizeAfterDays, err = strutils.GetIntegerEnvironmentVariableWithDefault("RETENTIONANONYMIZEDAYS", 0)
+	if err != nil {
+		log.Printf("Environment variable RETENTIONANONYMIZEDAYS invalid: %v", err)
+		panic(err)
+	}
+	retentionPolicy.DeleteAfterDays, err = strutils.GetIntegerEnvironmentVariableWithDefault("RETENTIONDELETEDAYS", 0)
+	if err != nil {
+		log.Printf("Environment variable RETENTIONDELETEDAYS invalid: %v", err)
+		panic(err)
+	}
+	if err = retentionPolicy.Validate(); err != nil {
+		log.Printf("Retention policy invalid: %v", err)
+		panic(err)
+	}
+	retentionInterval, err := strutils.GetIntegerEnvironmentVariableWithDefault("RETENTIONINTERVAL", 24)
+	if err != nil || retentionInterval < 1 {
+		log.Printf("Environment variable RETENTIONINTERVAL invalid: %v", err)
+		panic("invalid RETENTIONINTERVAL")
+	}
+	// webhook deliveries: due deliveries are sent every WEBHOOKINTERVAL seconds, failed ones retried up to WEBHOOKMAXATTEMPTS times
+	webhookPolicy := webhook.DefaultPolicy()
+	webhookPolicy.MaxAttempts, err = strutils.GetIntegerEnvironmentVariableWithDefault("WEBHOOKMAXATTEMPTS", webhookPolicy.MaxAttempts)
+	if err != nil || webhookPolicy.MaxAttempts < 1 {
+		log.Printf("Environment variable WEBHOOKMAXATTEMPTS invalid: %v", err)
+		panic("invalid WEBHOOKMAXATTEMPTS")
+	}
+	webhookInterval, err := strutils.GetIntegerEnvironmentVariableWithDefault("WEBHOOKINTERVAL", 10)
+	if err != nil || webhookInterval < 1 {
+		log.Printf("Environment variable WEBHOOKINTERVAL invalid: %v", err)
+		panic("invalid WEBHOOKINTERVAL")
+	}
+	// visitor notifications: email through the mail sender, text messages through an HTTP SMS gateway, either logged instead
+	var notifier *notify.Notifier
+	notifyEmail, notifySMS := os.Getenv("NOTIFYEMAIL"), os.Getenv("NOTIFYSMS")
+	if notifyEmail != "" || notifySMS != "" {
+		notifier = &notify.Notifier{Timeout: 10 * time.Second}
+		notifier.Threshold, err = strutils.GetIntegerEnvironmentVariableWithDefault("NOTIFYTHRESHOLD", 1)
+		if err != nil {
+			log.Printf("Environment variable NOTIFYTHRESHOLD invalid: %v", err)
+			panic(err)
+		}
+	}
+	switch notifyEmail {
+	case "":
+	case "log":
+		notifier.Email = notify.LogProvider{}
+	case "mail":
+		notifier.Email = notify.EmailProvider{Mailer: mailSender}
+	default:
+		log.Printf("Environment variable NOTIFYEMAIL must be either log or mail")
+		panic("invalid NOTIFYEMAIL")
+	}
+	switch notifySMS {
+	case "":
+	case "log":
+		notifier.SMS = notify.LogProvider{}
+	case "http":
+		notifier.SMS = notify.HTTPSMSProvider{
+			URL:    os.Getenv("SMSURL"),
+			Token:  os.Getenv("SMSTOKEN"),
+			From:   os.Getenv("SMSFROM"),
+			Client: &http.Client{},
+		}
+	default:
+		log.Printf("Environment variable NOTIFYSMS must be either log or http")
+		panic("invalid NOTIFYSMS")
+	}
+
+	// optimistic concurrency: whether PUT requests must send If-Match, instead of only honouring it
+	requireIfMatch := false
+	if value := os.Getenv("REQUIREIFMATCH"); value != "" {
+		requireIfMatch, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("Environment variable REQUIREIFMATCH invalid: %v", err)
+			panic(err)
+		}
+	}
+
 	apiCfg := api.ApiConfig{
-		DB:                   dbQueries,
-		Secret:               os.Getenv("SECRET"),
-		Env:                  os.Getenv("ENV"),
-		AccessTokenDuration:  accessTokenDuration,
-		RefreshTokenDuration: refreshTokenDuration,
-		PublicIDGenerator:    pidGenerator,
-		PublicIDLength:       publicIDLength,
+		DB:                         store,
+		Secret:                     os.Getenv("SECRET"),
+		Env:                        os.Getenv("ENV"),
+		AccessTokenDuration:        accessTokenDuration,
+		RefreshTokenDuration:       refreshTokenDuration,
+		PublicIDGenerator:          pidGenerator,
+		PublicIDLength:             publicIDLength,
+		AccountLoginLimiter:        accountLimiter,
+		IPLoginLimiter:             ipLimiter,
+		Mailer:                     mailSender,
+		PublicBaseURL:              strings.TrimSuffix(publicBaseURL, "/"),
+		StatusPageURL:              statusPageURL,
+		InvitationTokenDuration:    invitationTokenDuration,
+		PasswordResetTokenDuration: passwordResetTokenDuration,
+		PasswordPolicy:             passwordPolicy,
+		PasswordHasher:             passwordHasher,
+		TrustedOrigins:             trustedOrigins,
+		RequireIfMatch:             requireIfMatch,
+		IdempotencyKeyDuration:     idempotencyKeyDuration,
+		RetentionPolicy:            retentionPolicy,
+		Notifier:                   notifier,
 	}
 
+	// error responses explain what went wrong on the server in development only
+	jsonutils.Debug = apiCfg.Env == "dev"
+
+	// scheduled retention runs
+	if retentionPolicy.Enabled() {
+		go retention.Schedule(context.Background(), store, retentionPolicy, time.Duration(retentionInterval)*time.Hour)
+	}
+
+	// webhook deliveries
+	go webhook.Schedule(context.Background(), webhook.NewDispatcher(store, webhookPolicy), time.Duration(webhookInterval)*time.Second)
+
 	// servemux
 	mux := http.NewServeMux()
 
 	/// register handlers from api package
-	//handler_status.go
-	mux.HandleFunc("GET /api/healthz", apiCfg.ReadinessHandler) // ok
-	//handler_users.go
-	mux.Handle("POST /api/users", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPostUsers)))                    // ok
-	mux.Handle("PUT /api/users", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPutUsers)))                      // ok
-	mux.Handle("PUT /api/users/{user_public_id}", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPutUsersByID))) // ok
-	mux.Handle("GET /api/users", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerGetUsers)))                      // ok
-	mux.HandleFunc("GET /api/users/{user_public_id}", apiCfg.HandlerGetUsersByID)                                          // ok
-	//mux.HandleFunc("DELETE /api/users", apiCfg.HandlerDeleteUsers) NYI do I even want this
-	//handler_auth.go
-	mux.HandleFunc("POST /api/login", apiCfg.HandlerLoginUser)                                                                     // ok
-	mux.HandleFunc("GET /api/refresh", apiCfg.HandlerGetRefreshTokens)                                                             // ok (requires dev environment)
-	mux.Handle("POST /api/refresh", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerRefreshUser)))                        // not ok! I need to think about how this is going to work in relation to the auth middleware which already implements token rotation and access token generation from refresh tokens
-	mux.Handle("POST /api/logout", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerLogoutUser)))                          // ok
-	mux.Handle("POST /api/revoke", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerRevokeAllRefreshTokens)))              // ok
-	mux.Handle("POST /api/revoke/{user_public_id}", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerRevokeRefreshToken))) // ok
-	//handler_visitors.go
-	mux.HandleFunc("POST /api/visitors", apiCfg.HandlerPostVisitors)                                                                      // ok
-	mux.Handle("PUT /api/visitors/{visitor_public_id}", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPutVisitorsByPublicID))) // ok
-	mux.Handle("GET /api/visitors", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerGetVisitors)))                               // ok
-	mux.HandleFunc("GET /api/visitors/{visitor_public_id}", apiCfg.HandlerGetVisitorsByPublicID)                                          // ok
-	//handler_desks.go
-	mux.Handle("POST /api/desks", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPostDesks)))                          // ok
-	mux.Handle("PUT /api/desks/{desk_public_id}", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPutDesksByPublicID))) // ok
-	mux.Handle("GET /api/desks", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerGetDesks)))                            // ok
-	mux.HandleFunc("GET /api/desks/{desk_public_id}", apiCfg.HandlerGetDesksByPublicID)                                          // ok
-	//handler_purposes.go
-	mux.Handle("POST /api/purposes", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPostPurposes)))                       // ok
-	mux.Handle("PUT /api/purposes/{purpose_public_id}", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPutPurposesByID))) // ok
-	mux.HandleFunc("GET /api/purposes", apiCfg.HandlerGetPurposes)                                                                  // ok no auth needed
-	mux.HandleFunc("GET /api/purposes/{purpose_public_id}", apiCfg.HandlerGetPurposesByID)                                          // NYI is this needed? Maybe GetPurposesByName instead?
-	//handler_servicelogs.go
-	mux.Handle("POST /api/servicelogs", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPostServicelogs)))                          // NYI
-	mux.Handle("PUT /api/servicelogs/{servicelog_public_id}", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerPutServicelogsByID))) // NYI
-	mux.Handle("GET /api/servicelogs", apiCfg.AuthUserMiddleware(http.HandlerFunc(apiCfg.HandlerGetServicelogs)))                            // NYI
-	mux.HandleFunc("GET /api/servicelogs/{servicelog_public_id}", apiCfg.HandlerGetServicelogsByPublicID)
+	apiCfg.RegisterRoutes(mux)
 
 	/// register handlers from the admin package
 	//handler_admin.go
@@ -115,7 +378,7 @@ func main() {
 	// server
 	s := http.Server{
 		Addr:                         ":8080",
-		Handler:                      mux,
+		Handler:                      api.RequestIDMiddleware(mux),
 		DisableGeneralOptionsHandler: false,
 		ReadTimeout:                  30 * time.Second,
 		WriteTimeout:                 60 * time.Second,
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/dcrauwels/goqueue/migrate"
+)
+
+func runMigrate(migrator *migrate.Migrator, args []string) error {
+	/*
+		The migrate subcommand: goqueue migrate [-dry-run]. Applies the pending migrations, or with -dry-run only lists
+		them, and exits.
+	*/
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the pending migrations without applying them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		status, err := migrator.Plan(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("database schema is at version %d, latest version is %d\n", status.Current, status.Latest)
+		for _, migration := range status.Pending {
+			fmt.Printf("would apply %d_%s\n", migration.Version, migration.Name)
+		}
+		return nil
+	}
+	status, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, migration := range status.Pending {
+		fmt.Printf("applied %d_%s\n", migration.Version, migration.Name)
+	}
+	fmt.Printf("database schema is at version %d\n", status.Latest)
+	return nil
+}
+
+func migrateOnStartup(migrator *migrate.Migrator) error {
+	/*
+		Applies the pending migrations before serving, unless AUTOMIGRATE is false, in which case the schema must be
+		up to date already. Either way a database migrated by a newer version of goqueue is refused.
+	*/
+	autoMigrate := true
+	if value := os.Getenv("AUTOMIGRATE"); value != "" {
+		var err error
+		autoMigrate, err = strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("environment variable AUTOMIGRATE invalid: %w", err)
+		}
+	}
+
+	if !autoMigrate {
+		status, err := migrator.Plan(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(status.Pending) > 0 {
+			return fmt.Errorf("database schema is at version %d and %d migrations are pending: run goqueue migrate first", status.Current, len(status.Pending))
+		}
+		return nil
+	}
+	status, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, migration := range status.Pending {
+		log.Printf("applied migration %d_%s", migration.Version, migration.Name)
+	}
+	return nil
+}
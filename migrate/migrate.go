@@ -0,0 +1,309 @@
+package migrate
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	schema "github.com/dcrauwels/goqueue/sql"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+// ErrSchemaTooNew is returned when the database has migrations applied that this binary does not know about, i.e. it
+// was migrated by a newer version of goqueue. Serving (or migrating) against it could corrupt data.
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of goqueue")
+
+// Migration is one goose annotated .sql file: NNN_name.sql with an -- +goose Up and optionally an -- +goose Down section.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Current int64       // highest applied version, 0 for an empty database
+	Latest  int64       // highest version known to this binary
+	Pending []Migration // known migrations that are not applied, in order
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// dialect holds the engine specific statements
+type dialect struct {
+	createVersionTable string
+	insertVersion      string // parameters: version, name
+	gooseTableExists   string
+	// lock and unlock take a session level lock on conn, so that only one instance migrates at a time. nil if the
+	// migration transaction already excludes other instances.
+	lock   func(ctx context.Context, conn *sql.Conn) error
+	unlock func(ctx context.Context, conn *sql.Conn) error
+}
+
+// advisoryLockKey identifies the goqueue migration lock among the advisory locks of the database.
+const advisoryLockKey = 0x67717565756531 // "gqueue1"
+
+var postgresDialect = dialect{
+	createVersionTable: `CREATE TABLE IF NOT EXISTS schema_version (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`,
+	insertVersion:    `INSERT INTO schema_version (version, name, applied_at) VALUES ($1, $2, NOW())`,
+	gooseTableExists: `SELECT to_regclass('goose_db_version') IS NOT NULL`,
+	lock: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockKey)
+		return err
+	},
+	unlock: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, advisoryLockKey)
+		return err
+	},
+}
+
+// SQLite has no advisory locks, but storage.OpenDB begins every transaction by taking the write lock of the database
+// file, which excludes other instances just the same.
+var sqliteDialect = dialect{
+	createVersionTable: `CREATE TABLE IF NOT EXISTS schema_version (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`,
+	insertVersion:    `INSERT INTO schema_version (version, name, applied_at) VALUES (?1, ?2, NOW())`,
+	gooseTableExists: `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'goose_db_version')`,
+}
+
+func New(db *sql.DB, engine storage.Engine) (*Migrator, error) {
+	// a Migrator for the migrations embedded for engine, i.e. sql/schema or sql/sqlite/schema
+	var (
+		d    dialect
+		fsys fs.FS
+		err  error
+	)
+	switch engine {
+	case storage.EnginePostgres:
+		d = postgresDialect
+		fsys, err = fs.Sub(schema.PostgresMigrations, "schema")
+	case storage.EngineSQLite:
+		d = sqliteDialect
+		fsys, err = fs.Sub(schema.SQLiteMigrations, "sqlite/schema")
+	default:
+		return nil, fmt.Errorf("no migrations for database engine %q", engine)
+	}
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := ParseMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+func ParseMigrations(fsys fs.FS) ([]Migration, error) {
+	// reads the *.sql files in the root of fsys, ordered by version
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations := []Migration{}
+	for _, name := range names {
+		versionString, migrationName, found := strings.Cut(strings.TrimSuffix(name, path.Ext(name)), "_")
+		version, err := strconv.ParseInt(versionString, 10, 64)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s is not named NNN_name.sql", name)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := parseSections(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: migrationName, Up: up, Down: down})
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
+
+func parseSections(data string) (up string, down string, err error) {
+	/*
+		Splits a migration into its Up and Down sections. Each section is executed as a whole, so the statement
+		annotations that goose needs for statements containing semicolons (e.g. triggers) are simply dropped.
+	*/
+	var section *strings.Builder
+	var upBuilder, downBuilder strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section = &upBuilder
+		case "-- +goose Down":
+			section = &downBuilder
+		case "-- +goose StatementBegin", "-- +goose StatementEnd":
+		default:
+			if strings.HasPrefix(strings.TrimSpace(line), "-- +goose") {
+				return "", "", fmt.Errorf("unsupported annotation %q", strings.TrimSpace(line))
+			}
+			if section != nil {
+				section.WriteString(line)
+				section.WriteByte('\n')
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(upBuilder.String()) == "" {
+		return "", "", errors.New("no -- +goose Up section")
+	}
+	return upBuilder.String(), downBuilder.String(), nil
+}
+
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+func (m *Migrator) Plan(ctx context.Context) (Status, error) {
+	// the migrations Up would apply, without applying them (the dry run); ErrSchemaTooNew if the database is too new
+	return m.run(ctx, false)
+}
+
+func (m *Migrator) Up(ctx context.Context) (Status, error) {
+	/*
+		Applies all pending migrations in a single transaction, so either all of them are applied or none. The
+		returned Status is that from before migrating, i.e. its Pending migrations are the ones that were applied.
+	*/
+	return m.run(ctx, true)
+}
+
+func (m *Migrator) run(ctx context.Context, apply bool) (Status, error) {
+	// 1. lock out other instances
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+	defer conn.Close()
+	if m.dialect.lock != nil {
+		if err := m.dialect.lock(ctx, conn); err != nil {
+			return Status{}, fmt.Errorf("could not lock database for migrating: %w", err)
+		}
+		defer m.dialect.unlock(context.Background(), conn)
+	}
+
+	// 2. read the applied versions. a dry run rolls back the creation of schema_version as well
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return Status{}, err
+	}
+	defer tx.Rollback() // no-op after a successful commit
+	applied, err := m.appliedVersions(ctx, tx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	// 3. compare with the known migrations
+	status := Status{}
+	for version := range applied {
+		status.Current = max(status.Current, version)
+	}
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if !applied[migration.Version] {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	if status.Current > status.Latest {
+		return status, fmt.Errorf("%w: database is at version %d, latest known version is %d", ErrSchemaTooNew, status.Current, status.Latest)
+	}
+	if !apply {
+		return status, nil
+	}
+
+	// 4. apply
+	for _, migration := range status.Pending {
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return status, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, m.dialect.insertVersion, migration.Version, migration.Name); err != nil {
+			return status, err
+		}
+	}
+	return status, tx.Commit()
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, tx *sql.Tx) (map[int64]bool, error) {
+	/*
+		Reads schema_version, creating it if needed. Databases that were migrated by hand with goose before there was
+		a schema_version table start out with the versions goose applied.
+	*/
+	if _, err := tx.ExecContext(ctx, m.dialect.createVersionTable); err != nil {
+		return nil, err
+	}
+	applied, err := queryVersions(ctx, tx, `SELECT version FROM schema_version`)
+	if err != nil || len(applied) > 0 {
+		return applied, err
+	}
+
+	var gooseTableExists bool
+	if err := tx.QueryRowContext(ctx, m.dialect.gooseTableExists).Scan(&gooseTableExists); err != nil {
+		return nil, err
+	}
+	if !gooseTableExists {
+		return applied, nil
+	}
+	// goose appends a row for every up and down migration, so a version is applied if its last row says so
+	applied, err = queryVersions(ctx, tx, `SELECT version_id FROM goose_db_version g
+WHERE version_id > 0 AND is_applied AND id = (SELECT MAX(id) FROM goose_db_version WHERE version_id = g.version_id)`)
+	if err != nil {
+		return nil, err
+	}
+	for version := range applied {
+		name := "goose"
+		if i := slices.IndexFunc(m.migrations, func(m Migration) bool { return m.Version == version }); i >= 0 {
+			name = m.migrations[i].Name
+		}
+		if _, err := tx.ExecContext(ctx, m.dialect.insertVersion, version, name); err != nil {
+			return nil, err
+		}
+	}
+	return applied, nil
+}
+
+func queryVersions(ctx context.Context, tx *sql.Tx, query string) (map[int64]bool, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions[version] = true
+	}
+	return versions, rows.Err()
+}
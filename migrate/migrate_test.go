@@ -0,0 +1,178 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	schema "github.com/dcrauwels/goqueue/sql"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+func newSQLiteMigrator(t *testing.T, path string) (*Migrator, *sql.DB) {
+	t.Helper()
+	db, engine, err := storage.OpenDB("sqlite:" + path)
+	if err != nil {
+		t.Fatalf(`OpenDB: %v`, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := New(db, engine)
+	if err != nil {
+		t.Fatalf(`New: %v`, err)
+	}
+	return m, db
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)`, table).Scan(&exists); err != nil {
+		t.Fatalf(`QueryRow: %v`, err)
+	}
+	return exists
+}
+
+func TestParseMigrations(t *testing.T) {
+	fsys, err := fs.Sub(schema.PostgresMigrations, "schema")
+	if err != nil {
+		t.Fatalf(`fs.Sub: %v`, err)
+	}
+	migrations, err := ParseMigrations(fsys)
+	if err != nil {
+		t.Fatalf(`ParseMigrations(sql/schema) returned error: %v`, err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf(`migration %s has version %d, expected %d`, migration.Name, migration.Version, i+1)
+		}
+	}
+
+	migrations, err = ParseMigrations(fstest.MapFS{
+		"002_second.sql": {Data: []byte("-- +goose Up\n-- +goose StatementBegin\nCREATE TRIGGER t;\n-- +goose StatementEnd\n\n-- +goose Down\nDROP TRIGGER t;")},
+		"001_first.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE a (b TEXT);")},
+		"README.md":      {Data: []byte("not a migration")},
+	})
+	if err != nil {
+		t.Fatalf(`ParseMigrations returned error: %v`, err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Name != "second" {
+		t.Fatalf(`ParseMigrations returned %+v, expected first and second`, migrations)
+	}
+	if migrations[1].Up != "CREATE TRIGGER t;\n\n" || migrations[1].Down != "DROP TRIGGER t;\n" {
+		t.Errorf(`ParseMigrations returned sections %q and %q`, migrations[1].Up, migrations[1].Down)
+	}
+
+	invalid := map[string]string{
+		"first.sql":     "-- +goose Up\nSELECT 1;",
+		"001_first.sql": "SELECT 1;",
+		"002_first.sql": "-- +goose Up\n-- +goose NO TRANSACTION\nSELECT 1;",
+	}
+	for name, data := range invalid {
+		if _, err := ParseMigrations(fstest.MapFS{name: {Data: []byte(data)}}); err == nil {
+			t.Errorf(`ParseMigrations accepted %s: %q`, name, data)
+		}
+	}
+}
+
+func TestUp(t *testing.T) {
+	ctx := context.Background()
+	m, db := newSQLiteMigrator(t, filepath.Join(t.TempDir(), "goqueue.db"))
+
+	// a dry run changes nothing
+	status, err := m.Plan(ctx)
+	if err != nil {
+		t.Fatalf(`Plan returned error: %v`, err)
+	}
+	if status.Current != 0 || len(status.Pending) != len(m.Migrations()) {
+		t.Errorf(`Plan returned %+v on an empty database, expected all migrations pending`, status)
+	}
+	if tableExists(t, db, "schema_version") || tableExists(t, db, "users") {
+		t.Errorf(`Plan created tables`)
+	}
+
+	status, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf(`Up returned error: %v`, err)
+	}
+	if len(status.Pending) != len(m.Migrations()) || !tableExists(t, db, "users") {
+		t.Errorf(`Up applied %d migrations, expected %d`, len(status.Pending), len(m.Migrations()))
+	}
+	status, err = m.Up(ctx)
+	if err != nil || status.Current != status.Latest || len(status.Pending) != 0 {
+		t.Errorf(`second Up returned %+v, %v, expected nothing to apply`, status, err)
+	}
+
+	// a database migrated by a newer binary is refused
+	if _, err := db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'future', NOW())`, status.Latest+1); err != nil {
+		t.Fatalf(`Exec: %v`, err)
+	}
+	if _, err := m.Plan(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf(`Plan returned %v, expected ErrSchemaTooNew`, err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf(`Up returned %v, expected ErrSchemaTooNew`, err)
+	}
+}
+
+func TestUpConcurrently(t *testing.T) {
+	// two instances starting together on the same database apply every migration exactly once
+	path := filepath.Join(t.TempDir(), "goqueue.db")
+	migrators := []*Migrator{}
+	for range 2 {
+		m, _ := newSQLiteMigrator(t, path)
+		migrators = append(migrators, m)
+	}
+	applied := make([]int, len(migrators))
+	var wg sync.WaitGroup
+	for i, m := range migrators {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := m.Up(context.Background())
+			if err != nil {
+				t.Errorf(`Up returned error: %v`, err)
+			}
+			applied[i] = len(status.Pending)
+		}()
+	}
+	wg.Wait()
+	if applied[0]+applied[1] != len(migrators[0].Migrations()) {
+		t.Errorf(`Up applied %v migrations, expected %d in total`, applied, len(migrators[0].Migrations()))
+	}
+}
+
+func TestUpAfterGoose(t *testing.T) {
+	// a database migrated by hand with goose keeps the versions goose applied
+	ctx := context.Background()
+	m, db := newSQLiteMigrator(t, filepath.Join(t.TempDir(), "goqueue.db"))
+	first := m.Migrations()[0]
+	_, err := db.Exec(`CREATE TABLE goose_db_version (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL,
+    is_applied INTEGER NOT NULL,
+    tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`)
+	if err != nil {
+		t.Fatalf(`Exec: %v`, err)
+	}
+	_, err = db.Exec(`INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, 1), (?1, 1), (?1, 0), (?1, 1);`, first.Version)
+	if err != nil {
+		t.Fatalf(`Exec: %v`, err)
+	}
+	if _, err := db.Exec(first.Up); err != nil {
+		t.Fatalf(`Exec: %v`, err)
+	}
+
+	status, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf(`Up returned error: %v`, err)
+	}
+	if status.Current != first.Version || len(status.Pending) != len(m.Migrations())-1 {
+		t.Errorf(`Up returned %+v, expected version %d to be applied already`, status, first.Version)
+	}
+}
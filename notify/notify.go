@@ -0,0 +1,132 @@
+// Package notify tells visitors who left a phone number or email address that their turn is near and which desk
+// to go to when they are called, so they can wait outside. Notifications are composed in the transaction of the
+// change that causes them and sent once it has committed. They are not retried: an hour late, they are of no use.
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+// channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+type Message struct {
+	Channel string
+	To      string // email address or phone number in international format
+	Subject string // email only
+	Body    string
+}
+
+type Provider interface {
+	/*
+		Sends a single message over one channel. Implemented by EmailProvider (mail through a mailer.Sender, e.g.
+		SMTP), HTTPSMSProvider (a generic SMS gateway) and LogProvider, a stand-in for development.
+	*/
+	Send(ctx context.Context, msg Message) error
+}
+
+type Notifier struct {
+	Email     Provider      // nil to send no email
+	SMS       Provider      // nil to send no text messages
+	Threshold int           // visitors are told their turn is near once at most this many visitors wait ahead of them. Negative to never tell them
+	Timeout   time.Duration // for sending a single message, zero for none
+}
+
+func (n *Notifier) messages(v database.Visitor, subject, body string) []Message {
+	// one message per channel the visitor left contact details for and that has a provider
+	var msgs []Message
+	if v.Email.Valid && n.Email != nil {
+		msgs = append(msgs, Message{Channel: ChannelEmail, To: v.Email.String, Subject: subject, Body: body})
+	}
+	if v.PhoneNumber.Valid && n.SMS != nil {
+		msgs = append(msgs, Message{Channel: ChannelSMS, To: v.PhoneNumber.String, Body: subject + ": " + body})
+	}
+	return msgs
+}
+
+func (n *Notifier) Called(v database.Visitor, desk database.Desk) []Message {
+	// the messages for visitor v being called to desk
+	return n.messages(v, fmt.Sprintf("Ticket %d", v.DailyTicketNumber), fmt.Sprintf("please go to desk %s.", desk.Name))
+}
+
+func (n *Notifier) QueueMoved(ctx context.Context, q storage.Store, purposePublicID string, now time.Time) ([]Message, error) {
+	/*
+		Returns the messages for the visitors of the purpose whose turn has come near, after a visitor ahead of them
+		left the queue. Every visitor is told once: they are marked as notified with q, which should be the storage
+		of the transaction making the change, so concurrent calls do not tell anyone twice.
+	*/
+	if n.Threshold < 0 {
+		return nil, nil
+	}
+	queued, err := q.GetQueuedVisitorsByPurposePublicID(ctx, database.GetQueuedVisitorsByPurposePublicIDParams{
+		PurposePublicID: purposePublicID,
+		Limit:           int32(n.Threshold) + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var msgs []Message
+	for ahead, v := range queued {
+		if v.NotifiedAt.Valid {
+			continue
+		}
+		body := "you are next."
+		if ahead == 1 {
+			body = "1 visitor is ahead of you."
+		} else if ahead > 1 {
+			body = fmt.Sprintf("%d visitors are ahead of you.", ahead)
+		}
+		vMsgs := n.messages(v, fmt.Sprintf("Ticket %d", v.DailyTicketNumber), body)
+		if len(vMsgs) == 0 {
+			continue
+		}
+		claimed, err := q.SetVisitorNotifiedAt(ctx, database.SetVisitorNotifiedAtParams{
+			PublicID:   v.PublicID,
+			NotifiedAt: sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		} else if claimed == 1 {
+			msgs = append(msgs, vMsgs...)
+		}
+	}
+	return msgs, nil
+}
+
+func (n *Notifier) Send(ctx context.Context, msgs []Message) {
+	/*
+		Sends msgs one by one with the provider of their channel. Failures are logged rather than returned, as the
+		change they are about has been committed already. Meant to be started in its own goroutine.
+	*/
+	for _, msg := range msgs {
+		if err := n.send(ctx, msg); err != nil {
+			log.Printf("error sending %s notification: %v", msg.Channel, err)
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, msg Message) error {
+	provider := n.Email
+	if msg.Channel == ChannelSMS {
+		provider = n.SMS
+	}
+	if provider == nil {
+		return nil
+	}
+	if n.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.Timeout)
+		defer cancel()
+	}
+	return provider.Send(ctx, msg)
+}
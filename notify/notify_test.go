@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+// recorder is a Provider that keeps what it is sent
+type recorder struct {
+	msgs []Message
+	err  error
+}
+
+func (r *recorder) Send(ctx context.Context, msg Message) error {
+	r.msgs = append(r.msgs, msg)
+	return r.err
+}
+
+func createVisitors(t *testing.T, s storage.Store, contact ...database.CreateVisitorParams) []database.Visitor {
+	// creates a visitor for every set of contact details, in a single purpose
+	t.Helper()
+	ctx := context.Background()
+	location, err := s.CreateLocation(ctx, database.CreateLocationParams{PublicID: "location0001", Name: "Head office", TimeZone: "UTC"})
+	if err != nil {
+		t.Fatalf(`CreateLocation: %v`, err)
+	}
+	purpose, err := s.CreatePurpose(ctx, database.CreatePurposeParams{PublicID: "purpose00001", PurposeName: "passports", LocationPublicID: location.PublicID})
+	if err != nil {
+		t.Fatalf(`CreatePurpose: %v`, err)
+	}
+	var visitors []database.Visitor
+	for i, c := range contact {
+		c.PublicID = "visitor0000" + string(rune('1'+i))
+		c.PurposePublicID, c.LocationPublicID, c.DailyTicketNumber = purpose.PublicID, location.PublicID, int32(i+1)
+		c.CreatedAt = time.Now().UTC()
+		v, err := s.CreateVisitor(ctx, c)
+		if err != nil {
+			t.Fatalf(`CreateVisitor: %v`, err)
+		}
+		visitors = append(visitors, v)
+		time.Sleep(time.Millisecond) // distinct waiting_since
+	}
+	return visitors
+}
+
+func phone(number string) database.CreateVisitorParams {
+	return database.CreateVisitorParams{PhoneNumber: sql.NullString{String: number, Valid: true}}
+}
+
+func TestCalled(t *testing.T) {
+	email, sms := &recorder{}, &recorder{}
+	n := &Notifier{Email: email, SMS: sms}
+	v := database.Visitor{
+		DailyTicketNumber: 12,
+		PhoneNumber:       sql.NullString{String: "+31201234567", Valid: true},
+		Email:             sql.NullString{String: "visitor@example.org", Valid: true},
+	}
+	msgs := n.Called(v, database.Desk{Name: "F2"})
+	if len(msgs) != 2 || msgs[0].To != "visitor@example.org" || msgs[0].Subject != "Ticket 12" || msgs[1].To != "+31201234567" || !strings.Contains(msgs[1].Body, "desk F2") {
+		t.Errorf(`Called returned %+v, expected an email and a text message about desk F2`, msgs)
+	}
+
+	// channels without provider are left out
+	n.SMS = nil
+	if msgs := n.Called(v, database.Desk{Name: "F2"}); len(msgs) != 1 || msgs[0].Channel != ChannelEmail {
+		t.Errorf(`Called without SMS provider returned %+v, expected only the email`, msgs)
+	}
+}
+
+func TestQueueMoved(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemory()
+	visitors := createVisitors(t, s, phone("+31200000001"), database.CreateVisitorParams{}, phone("+31200000003"), phone("+31200000004"))
+	n := &Notifier{SMS: &recorder{}, Threshold: 2}
+
+	// the first three visitors are near, the second left no contact details
+	msgs, err := n.QueueMoved(ctx, s, visitors[0].PurposePublicID, time.Now())
+	if err != nil || len(msgs) != 2 || msgs[0].To != "+31200000001" || !strings.Contains(msgs[0].Body, "you are next") || msgs[1].To != "+31200000003" || !strings.Contains(msgs[1].Body, "2 visitors are ahead") {
+		t.Errorf(`QueueMoved returned %+v, %v; expected messages for the first and third visitor`, msgs, err)
+	}
+
+	// nobody is told twice, the fourth visitor is told once the queue moves
+	if msgs, err := n.QueueMoved(ctx, s, visitors[0].PurposePublicID, time.Now()); err != nil || len(msgs) != 0 {
+		t.Errorf(`second QueueMoved returned %+v, %v; expected no messages`, msgs, err)
+	}
+	if _, err := s.SetVisitorStatusByID(ctx, database.SetVisitorStatusByIDParams{ID: visitors[0].ID, Status: 2}); err != nil {
+		t.Fatalf(`SetVisitorStatusByID: %v`, err)
+	}
+	if msgs, err := n.QueueMoved(ctx, s, visitors[0].PurposePublicID, time.Now()); err != nil || len(msgs) != 1 || msgs[0].To != "+31200000004" {
+		t.Errorf(`QueueMoved returned %+v, %v; expected a message for the fourth visitor`, msgs, err)
+	}
+
+	n.Threshold = -1
+	if msgs, err := n.QueueMoved(ctx, s, visitors[0].PurposePublicID, time.Now()); err != nil || msgs != nil {
+		t.Errorf(`QueueMoved with a negative threshold returned %+v, %v`, msgs, err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	email, sms := &recorder{err: errors.New("mail server down")}, &recorder{}
+	n := &Notifier{Email: email, SMS: sms, Timeout: time.Second}
+	n.Send(context.Background(), []Message{
+		{Channel: ChannelEmail, To: "visitor@example.org", Body: "first"},
+		{Channel: ChannelSMS, To: "+31201234567", Body: "second"},
+	})
+	if len(email.msgs) != 1 || len(sms.msgs) != 1 || sms.msgs[0].Body != "second" {
+		t.Errorf(`Send sent %+v by email and %+v by SMS, expected one each despite the failing email`, email.msgs, sms.msgs)
+	}
+}
+
+func TestHTTPSMSProvider(t *testing.T) {
+	var got httpSMSRequest
+	var authorization string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer gateway.Close()
+
+	p := HTTPSMSProvider{URL: gateway.URL, Token: "token", From: "goqueue"}
+	if err := p.Send(context.Background(), Message{Channel: ChannelSMS, To: "+31201234567", Body: "Ticket 12: you are next."}); err != nil {
+		t.Fatalf(`Send: %v`, err)
+	}
+	if got.From != "goqueue" || got.To != "+31201234567" || got.Body != "Ticket 12: you are next." || authorization != "Bearer token" {
+		t.Errorf(`gateway received %+v with authorization %q`, got, authorization)
+	}
+
+	p.URL = gateway.URL + "/\x00"
+	if err := p.Send(context.Background(), Message{}); err == nil {
+		t.Errorf(`Send to an invalid URL succeeded`)
+	}
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+	p.URL = failing.URL
+	if err := p.Send(context.Background(), Message{}); err == nil {
+		t.Errorf(`Send succeeded although the gateway responded 429`)
+	}
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/dcrauwels/goqueue/mailer"
+)
+
+// EmailProvider sends notifications as mail, e.g. through a mailer.SMTPSender.
+type EmailProvider struct {
+	Mailer mailer.Sender
+}
+
+func (p EmailProvider) Send(ctx context.Context, msg Message) error {
+	return p.Mailer.Send(ctx, mailer.Message{To: msg.To, Subject: msg.Subject, Body: msg.Body})
+}
+
+// HTTPSMSProvider sends text messages through the HTTP API of an SMS gateway. Every message is a POST of the JSON
+// object {"from": From, "to": <phone number>, "body": <text>} to URL, with Token as bearer token if set. Gateways that
+// expect another format can be put behind a small adapter.
+type HTTPSMSProvider struct {
+	URL    string
+	Token  string
+	From   string // sender ID or phone number, as the gateway allows
+	Client *http.Client
+}
+
+type httpSMSRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Body string `json:"body"`
+}
+
+func (p HTTPSMSProvider) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(httpSMSRequest{From: p.From, To: msg.To, Body: msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // lets the connection be reused
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("SMS gateway responded %s", resp.Status)
+	}
+	return nil
+}
+
+// LogProvider logs notifications instead of sending them, a stand-in for development.
+type LogProvider struct {
+	Logger *log.Logger // nil for the standard logger
+}
+
+func (p LogProvider) Send(ctx context.Context, msg Message) error {
+	logf := log.Printf
+	if p.Logger != nil {
+		logf = p.Logger.Printf
+	}
+	logf("notification by %s to %s: %s %s", msg.Channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
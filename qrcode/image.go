@@ -0,0 +1,73 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// MaxScale is the largest number of pixels per module Image, PNG and SVG take, which keeps images of the largest
+// codes below 6000 pixels square
+const MaxScale = 32
+
+func clampScale(scale int) int {
+	return min(max(scale, 1), MaxScale)
+}
+
+func (c *Code) Image(scale int) *image.Paletted {
+	/*
+		Renders the code with its quiet zone as a black and white image of scale by scale pixels per module. Scale is
+		clamped to 1 to MaxScale.
+	*/
+	scale = clampScale(scale)
+	size := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := range size {
+		for x := range size {
+			if c.Dark(x/scale-QuietZone, y/scale-QuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+func (c *Code) PNG(scale int) ([]byte, error) {
+	// the Image of the code as a PNG file
+	var b bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&b, c.Image(scale)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (c *Code) SVG(scale int) []byte {
+	/*
+		Renders the code with its quiet zone as an SVG image of scale by scale pixels per module, clamped like Image.
+		The dark modules form a single path in a view box of one unit per module, so the image stays sharp at any size.
+	*/
+	scale = clampScale(scale)
+	size := c.Size + 2*QuietZone
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size*scale, size*scale, size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, size, size)
+	for y := range c.Size {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			// one rectangle per horizontal run of dark modules
+			run := 1
+			for c.Dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x+QuietZone, y+QuietZone, run, run)
+			x += run - 1
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
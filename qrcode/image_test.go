@@ -0,0 +1,70 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	c, err := Encode([]byte("https://queue.example.org/visitors/V1StGXR8_Z5j"), Medium)
+	if err != nil {
+		t.Fatalf(`Encode: %v`, err)
+	}
+	out, err := c.PNG(3)
+	if err != nil {
+		t.Fatalf(`PNG: %v`, err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf(`PNG returned an invalid image: %v`, err)
+	}
+	size := (c.Size + 2*QuietZone) * 3
+	if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+		t.Fatalf(`PNG is %dx%d, expected %dx%d`, b.Dx(), b.Dy(), size, size)
+	}
+	for y := range size {
+		for x := range size {
+			r, _, _, _ := img.At(x, y).RGBA()
+			if dark := r == 0; dark != c.Dark(x/3-QuietZone, y/3-QuietZone) {
+				t.Fatalf(`pixel (%d, %d) is dark: %v, expected the module it is in`, x, y, dark)
+			}
+		}
+	}
+
+	if b := c.Image(1000).Bounds(); b.Dx() != (c.Size+2*QuietZone)*MaxScale {
+		t.Errorf(`Image of scale 1000 is %d wide, expected scale %d`, b.Dx(), MaxScale)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	c, err := Encode([]byte("goqueue"), Low)
+	if err != nil {
+		t.Fatalf(`Encode: %v`, err)
+	}
+	out := c.SVG(10)
+	size := strconv.Itoa((c.Size + 2*QuietZone) * 10)
+	if !bytes.HasPrefix(out, []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="`+size+`" height="`+size+`"`)) || !bytes.HasSuffix(out, []byte("</svg>")) {
+		t.Errorf(`SVG returned %s, expected an svg element of %s pixels`, out, size)
+	}
+
+	// the runs of the path cover exactly the dark modules
+	covered := map[[2]int]bool{}
+	for _, run := range regexp.MustCompile(`M(\d+) (\d+)h(\d+)v1h-(\d+)z`).FindAllSubmatch(out, -1) {
+		x, _ := strconv.Atoi(string(run[1]))
+		y, _ := strconv.Atoi(string(run[2]))
+		n, _ := strconv.Atoi(string(run[3]))
+		for i := range n {
+			covered[[2]int{x + i - QuietZone, y - QuietZone}] = true
+		}
+	}
+	for y := range c.Size {
+		for x := range c.Size {
+			if covered[[2]int{x, y}] != c.Dark(x, y) {
+				t.Fatalf(`module (%d, %d) is dark: %v, but covered by the path: %v`, x, y, c.Dark(x, y), covered[[2]int{x, y}])
+			}
+		}
+	}
+}
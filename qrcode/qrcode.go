@@ -0,0 +1,461 @@
+// Package qrcode encodes short texts, such as the URL of a visitor's status page, as QR codes (ISO/IEC 18004) in byte
+// mode, so tickets and screens can show them without an external service. Codes can be rendered as PNG and SVG images
+// (image.go) or read module by module with Dark, e.g. to print them.
+package qrcode
+
+import (
+	"errors"
+)
+
+type Level int
+
+// error correction levels: the share of the code that may be damaged and still be read
+const (
+	Low      Level = iota // 7%
+	Medium                // 15%
+	Quartile              // 25%
+	High                  // 30%
+)
+
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// QuietZone is the width of the light border, in modules, scanners need around a code
+const QuietZone = 4
+
+// Code is an encoded QR code: a square of Size by Size modules, without the quiet zone of QuietZone light modules
+// callers should leave around it.
+type Code struct {
+	Size    int
+	Version int // 1 to 40
+	Level   Level
+	Mask    int
+	modules [][]bool // [y][x], true for dark
+	isFunc  [][]bool // finder, timing, alignment, format and version modules, which are never masked
+}
+
+func (c *Code) Dark(x, y int) bool {
+	// reports whether the module at column x and row y is dark. Coordinates outside the code are light
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x]
+}
+
+func Encode(data []byte, level Level) (*Code, error) {
+	// encodes data in the smallest version that holds it at the given level
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if 4+charCountBits(v)+8*len(data) <= numDataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	// mode indicator, character count, data, terminator and padding up to the capacity of the version
+	capacity := numDataCodewords(version, level) * 8
+	bits := &bitBuffer{}
+	bits.append(0x4, 4) // byte mode
+	bits.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacity-bits.n))
+	bits.append(0, (8-bits.n%8)%8)
+	for pad := 0xEC; bits.n < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	c := &Code{Size: version*4 + 17, Version: version, Level: level}
+	c.modules = make([][]bool, c.Size)
+	c.isFunc = make([][]bool, c.Size)
+	for y := range c.Size {
+		c.modules[y] = make([]bool, c.Size)
+		c.isFunc[y] = make([]bool, c.Size)
+	}
+	c.drawFunctionPatterns()
+	c.drawCodewords(addECCAndInterleave(bits.bytes, version, level))
+
+	// the mask with the lowest penalty makes the code easiest to read
+	best := -1
+	for mask := range 8 {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if penalty := c.penalty(); best < 0 || penalty < best {
+			best, c.Mask = penalty, mask
+		}
+		c.applyMask(mask) // masking twice undoes it
+	}
+	c.applyMask(c.Mask)
+	c.drawFormatBits(c.Mask)
+	return c, nil
+}
+
+type bitBuffer struct {
+	bytes []byte
+	n     int // number of bits
+}
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if value>>i&1 == 1 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// per level and version (index 0 unused): error correction codewords per block and number of blocks
+var (
+	eccCodewordsPerBlock = [4][41]int{
+		{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	numErrorCorrectionBlocks = [4][41]int{
+		{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// format information bits of the levels, which are not in the order of the Level constants
+var levelFormatBits = [4]int{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+func numRawDataModules(version int) int {
+	// modules available for data and error correction, i.e. not taken by function patterns
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+func addECCAndInterleave(data []byte, version int, level Level) []byte {
+	/*
+		Splits data into blocks, appends the Reed-Solomon error correction codewords to each and interleaves the
+		blocks. The first blocks are one data codeword shorter than the others when the codewords do not divide evenly.
+	*/
+	numBlocks := numErrorCorrectionBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		dataLen := shortBlockLen - eccLen
+		if i >= numShortBlocks {
+			dataLen++
+		}
+		block := make([]byte, shortBlockLen+1) // short blocks leave a gap, skipped when interleaving
+		copy(block, data[k:k+dataLen])
+		copy(block[len(block)-eccLen:], reedSolomonRemainder(data[k:k+dataLen], divisor))
+		k += dataLen
+		blocks[i] = block
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range shortBlockLen + 1 {
+		for j, block := range blocks {
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+func reedSolomonDivisor(degree int) []byte {
+	// the generator polynomial of the given degree, highest coefficient (always 1) left out
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+func gfMultiply(x, y byte) byte {
+	// multiplication in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func (c *Code) setFunc(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunc[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	// timing patterns
+	for i := range c.Size {
+		c.setFunc(6, i, i%2 == 0)
+		c.setFunc(i, 6, i%2 == 0)
+	}
+
+	// finder patterns with their separators, in three corners
+	for _, p := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x >= 0 && y >= 0 && x < c.Size && y < c.Size {
+					dist := max(abs(dx), abs(dy))
+					c.setFunc(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+
+	// alignment patterns, except where they would overlap the finder patterns
+	positions := alignmentPositions(c.Version)
+	n := len(positions)
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.setFunc(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format bits, drawn for real once the mask is chosen, and draw the version
+	c.drawFormatBits(0)
+	if c.Version >= 7 {
+		rem := c.Version
+		for range 12 {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := c.Version<<12 | rem
+		for i := range 18 {
+			dark := bits>>i&1 == 1
+			a, b := c.Size-11+i%3, i/3
+			c.setFunc(a, b, dark)
+			c.setFunc(b, a, dark)
+		}
+	}
+}
+
+func alignmentPositions(version int) []int {
+	// the centre coordinates of the alignment patterns, the same for rows and columns
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	// the level and mask with their BCH error correction bits, twice
+	data := levelFormatBits[c.Level]<<3 | mask
+	rem := data
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.setFunc(8, i, bit(i))
+	}
+	c.setFunc(8, 7, bit(6))
+	c.setFunc(8, 8, bit(7))
+	c.setFunc(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunc(14-i, 8, bit(i))
+	}
+
+	for i := range 8 {
+		c.setFunc(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunc(8, c.Size-15+i, bit(i))
+	}
+	c.setFunc(8, c.Size-8, true) // always dark
+}
+
+func (c *Code) drawCodewords(data []byte) {
+	// fills the remaining modules in the zigzag order of the standard: pairs of columns, right to left, alternately
+	// upwards and downwards, skipping the vertical timing pattern
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range c.Size {
+			for j := range 2 {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert // upwards
+				}
+				if !c.isFunc[y][x] && i < len(data)*8 {
+					c.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := range c.Size {
+		for x := range c.Size {
+			if !c.isFunc[y][x] && maskBit(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func (c *Code) penalty() int {
+	/*
+		Scores the code by the four rules of the standard: runs of five or more modules of the same colour, 2x2
+		blocks of the same colour, patterns that look like finder patterns and an imbalance of dark and light modules.
+	*/
+	result := 0
+	line := func(get func(i int) bool) {
+		run := 0
+		for i := range c.Size {
+			if i > 0 && get(i) == get(i-1) {
+				run++
+			} else {
+				run = 1
+			}
+			if run == 5 {
+				result += 3
+			} else if run > 5 {
+				result++
+			}
+		}
+		// dark-light-dark-dark-dark-light-dark with four light modules on either side, outside the code counting as light
+		for i := -4; i < c.Size; i++ {
+			pattern := true
+			for k, dark := range [11]bool{false, false, false, false, true, false, true, true, true, false, true} {
+				if (i+k >= 0 && i+k < c.Size && get(i+k)) != dark {
+					pattern = false
+					break
+				}
+			}
+			mirrored := true
+			for k, dark := range [11]bool{true, false, true, true, true, false, true, false, false, false, false} {
+				if (i+k >= 0 && i+k < c.Size && get(i+k)) != dark {
+					mirrored = false
+					break
+				}
+			}
+			if pattern {
+				result += 40
+			}
+			if mirrored {
+				result += 40
+			}
+		}
+	}
+	for y := range c.Size {
+		line(func(i int) bool { return c.modules[y][i] })
+	}
+	for x := range c.Size {
+		line(func(i int) bool { return c.modules[i][x] })
+	}
+
+	dark := 0
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				v := c.modules[y][x]
+				if v == c.modules[y-1][x] && v == c.modules[y][x-1] && v == c.modules[y-1][x-1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + k*10
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,186 @@
+package qrcode
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func readCodewords(c *Code) []byte {
+	// reads the codewords back in the order drawCodewords put them, undoing the mask
+	var bits bitBuffer
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range c.Size {
+			for j := range 2 {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.isFunc[y][x] {
+					dark := c.modules[y][x] != maskBit(c.Mask, x, y)
+					if dark {
+						bits.append(1, 1)
+					} else {
+						bits.append(0, 1)
+					}
+				}
+			}
+		}
+	}
+	return bits.bytes[:numRawDataModules(c.Version)/8]
+}
+
+func deinterleave(c *Code, codewords []byte) (data []byte, blocks [][]byte) {
+	// splits the codewords into their blocks, the reverse of addECCAndInterleave, and returns the data codewords too
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	eccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	numShortBlocks := numBlocks - len(codewords)%numBlocks
+	shortBlockLen := len(codewords) / numBlocks
+	blocks = make([][]byte, numBlocks)
+	k := 0
+	for i := range shortBlockLen + 1 {
+		for j := range blocks {
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	for _, block := range blocks {
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+	return data, blocks
+}
+
+func syndromesZero(block []byte, eccLen int) bool {
+	// a valid Reed-Solomon codeword evaluates to zero at the first eccLen powers of the generator
+	x := byte(1)
+	for range eccLen {
+		v := byte(0)
+		for _, b := range block {
+			v = gfMultiply(v, x) ^ b
+		}
+		if v != 0 {
+			return false
+		}
+		x = gfMultiply(x, 0x02)
+	}
+	return true
+}
+
+func TestEncode(t *testing.T) {
+	inputs := []string{
+		"",
+		"https://queue.example.org/visitors/V1StGXR8_Z5j",
+		strings.Repeat("goqueue ", 40),
+		strings.Repeat("x", 1000),
+	}
+	for _, input := range inputs {
+		for level := Low; level <= High; level++ {
+			c, err := Encode([]byte(input), level)
+			if err != nil {
+				t.Fatalf(`Encode of %d bytes at level %d: %v`, len(input), level, err)
+			}
+			if c.Size != c.Version*4+17 {
+				t.Errorf(`version %d has size %d`, c.Version, c.Size)
+			}
+			if c.Version > 1 && 4+charCountBits(c.Version-1)+8*len(input) <= numDataCodewords(c.Version-1, level)*8 {
+				t.Errorf(`%d bytes at level %d were encoded in version %d, expected a smaller version`, len(input), level, c.Version)
+			}
+
+			// every block must be a valid Reed-Solomon codeword
+			data, blocks := deinterleave(c, readCodewords(c))
+			for i, block := range blocks {
+				if !syndromesZero(block, eccCodewordsPerBlock[level][c.Version]) {
+					t.Errorf(`version %d level %d: block %d is not a valid codeword`, c.Version, level, i)
+				}
+			}
+
+			// byte mode, length and the data itself
+			shift := (4 + charCountBits(c.Version)) % 8 // the data starts in the middle of a byte
+			var decoded []byte
+			start := (4 + charCountBits(c.Version)) / 8
+			for i := range len(input) {
+				decoded = append(decoded, data[start+i]<<shift|data[start+i+1]>>(8-shift))
+			}
+			if data[0]>>4 != 0x4 || !bytes.Equal(decoded, []byte(input)) {
+				t.Errorf(`version %d level %d: read back %q, expected %q`, c.Version, level, decoded, input)
+			}
+		}
+	}
+
+	if _, err := Encode(make([]byte, 3000), Low); err != ErrTooLong {
+		t.Errorf(`Encode of 3000 bytes returned %v, expected ErrTooLong`, err)
+	}
+}
+
+func TestFunctionPatterns(t *testing.T) {
+	c, err := Encode([]byte("01234567"), Medium)
+	if err != nil {
+		t.Fatalf(`Encode: %v`, err)
+	}
+	// finder pattern in the top left corner: dark outer ring, light ring, dark 3x3 centre, light separator
+	for y := range 8 {
+		for x := range 8 {
+			dist := max(abs(x-3), abs(y-3))
+			if want := dist != 2 && dist != 4; c.Dark(x, y) != want {
+				t.Errorf(`module (%d, %d) is dark: %v, expected %v`, x, y, c.Dark(x, y), want)
+			}
+		}
+	}
+
+	// the format bits of level M and mask 0 are 101010000010010
+	c.drawFormatBits(0)
+	var bits int
+	for i := 0; i <= 5; i++ {
+		if c.Dark(8, i) {
+			bits |= 1 << i
+		}
+	}
+	if c.Dark(8, 7) {
+		bits |= 1 << 6
+	}
+	if c.Dark(8, 8) {
+		bits |= 1 << 7
+	}
+	if c.Dark(7, 8) {
+		bits |= 1 << 8
+	}
+	for i := 9; i < 15; i++ {
+		if c.Dark(14-i, 8) {
+			bits |= 1 << i
+		}
+	}
+	if bits != 0b101010000010010 {
+		t.Errorf(`format bits of level M mask 0 are %015b`, bits)
+	}
+	if c.Dark(-1, 0) || c.Dark(c.Size, 0) {
+		t.Errorf(`modules outside the code are dark`)
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	for version, want := range map[int][]int{2: {6, 18}, 7: {6, 22, 38}, 32: {6, 34, 60, 86, 112, 138}, 40: {6, 30, 58, 86, 114, 142, 170}} {
+		if got := alignmentPositions(version); !slices.Equal(got, want) {
+			t.Errorf(`alignment positions of version %d are %v, expected %v`, version, got, want)
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	// byte mode capacities from the tables of the standard
+	for _, tc := range []struct {
+		version int
+		level   Level
+		bytes   int
+	}{{1, Low, 17}, {1, High, 7}, {10, Medium, 213}, {40, Low, 2953}, {40, High, 1273}} {
+		capacity := (numDataCodewords(tc.version, tc.level)*8 - 4 - charCountBits(tc.version)) / 8
+		if capacity != tc.bytes {
+			t.Errorf(`version %d level %d holds %d bytes, expected %d`, tc.version, tc.level, capacity, tc.bytes)
+		}
+	}
+}
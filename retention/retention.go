@@ -0,0 +1,125 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dcrauwels/goqueue/audit"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+// Policy says how long visitor data is kept, counted in days from the registration of the visitor. Zero keeps the
+// data forever.
+type Policy struct {
+	AnonymizeAfterDays int // after this, the name and contact details of the visitor are removed. Ticket numbers, purposes and times are kept for statistics
+	DeleteAfterDays    int // after this, the visitor and its service logs are deleted altogether
+}
+
+func (p Policy) Enabled() bool {
+	return p.AnonymizeAfterDays > 0 || p.DeleteAfterDays > 0
+}
+
+func (p Policy) Validate() error {
+	if p.AnonymizeAfterDays < 0 || p.DeleteAfterDays < 0 {
+		return errors.New("retention periods cannot be negative")
+	}
+	return nil
+}
+
+// Report is what a run changed or, for a dry run, would have changed. It is returned by POST /api/retention and
+// recorded in the audit log as is.
+type Report struct {
+	DryRun             bool       `json:"dry_run"`
+	AnonymizeBefore    *time.Time `json:"anonymize_before,omitempty"` // nil if the policy does not anonymize
+	DeleteBefore       *time.Time `json:"delete_before,omitempty"`    // nil if the policy does not delete
+	AnonymizedVisitors int64      `json:"anonymized_visitors"`
+	DeletedVisitors    int64      `json:"deleted_visitors"`
+	DeletedServiceLogs int64      `json:"deleted_service_logs"`
+}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+func Run(ctx context.Context, db storage.Store, p Policy, now time.Time, dryRun bool, record func(q storage.Store, report Report) error) (Report, error) {
+	/*
+		Applies p to the visitors registered before now, in a single transaction. Pass now in UTC, like the created_at it
+		is compared with. A dry run rolls that transaction back, so its Report counts the rows that would be affected
+		without changing them. record is called in the transaction of a real run with the Report, to write the audit
+		event; it may be nil.
+	*/
+	report := Report{DryRun: dryRun}
+	err := db.InTx(ctx, func(q storage.Store) error {
+		report = Report{DryRun: dryRun} // InTx may retry
+		var err error
+
+		// 1. delete, which makes anonymizing those visitors first unnecessary
+		if p.DeleteAfterDays > 0 {
+			deleteBefore := now.AddDate(0, 0, -p.DeleteAfterDays)
+			report.DeleteBefore = &deleteBefore
+			report.DeletedServiceLogs, err = q.DeleteServiceLogsOfVisitorsCreatedBefore(ctx, deleteBefore)
+			if err != nil {
+				return err
+			}
+			report.DeletedVisitors, err = q.DeleteVisitorsCreatedBefore(ctx, deleteBefore)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 2. anonymize
+		if p.AnonymizeAfterDays > 0 {
+			anonymizeBefore := now.AddDate(0, 0, -p.AnonymizeAfterDays)
+			report.AnonymizeBefore = &anonymizeBefore
+			report.AnonymizedVisitors, err = q.AnonymizeVisitorsCreatedBefore(ctx, anonymizeBefore)
+			if err != nil {
+				return err
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		} else if record != nil {
+			return record(q, report)
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return report, err
+}
+
+func Schedule(ctx context.Context, db storage.Store, p Policy, interval time.Duration) {
+	/*
+		Runs p right away and then every interval until ctx is done. Meant to be started in its own goroutine. Every
+		instance of goqueue can run it: runs on the same database only repeat each other's work.
+	*/
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := Run(ctx, db, p, time.Now().UTC(), false, func(q storage.Store, report Report) error {
+			if report.AnonymizedVisitors == 0 && report.DeletedVisitors == 0 {
+				return nil
+			}
+			_, err := audit.Record(ctx, q, audit.Event{
+				Action:     audit.ActionVisitorRetention,
+				EntityType: audit.EntityVisitor,
+				After:      report,
+			})
+			return err
+		})
+		if err != nil {
+			log.Printf("visitor retention failed: %v", err)
+		} else if report.AnonymizedVisitors > 0 || report.DeletedVisitors > 0 {
+			log.Printf("visitor retention: anonymized %d visitors, deleted %d visitors and %d service logs", report.AnonymizedVisitors, report.DeletedVisitors, report.DeletedServiceLogs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
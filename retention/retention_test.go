@@ -0,0 +1,104 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+func createPurpose(t *testing.T, s storage.Store) database.Purpose {
+	t.Helper()
+	location, err := s.CreateLocation(context.Background(), database.CreateLocationParams{PublicID: "location0001", Name: "Head office", TimeZone: "UTC"})
+	if err != nil {
+		t.Fatalf(`CreateLocation: %v`, err)
+	}
+	purpose, err := s.CreatePurpose(context.Background(), database.CreatePurposeParams{PublicID: "purpose00001", PurposeName: "passports", LocationPublicID: location.PublicID})
+	if err != nil {
+		t.Fatalf(`CreatePurpose: %v`, err)
+	}
+	return purpose
+}
+
+func createVisitor(t *testing.T, s storage.Store, purpose database.Purpose, publicID string) database.Visitor {
+	t.Helper()
+	v, err := s.CreateVisitor(context.Background(), database.CreateVisitorParams{
+		PublicID:          publicID,
+		Name:              sql.NullString{String: "Alice", Valid: true},
+		PurposePublicID:   purpose.PublicID,
+		DailyTicketNumber: 1,
+		LocationPublicID:  purpose.LocationPublicID,
+		CreatedAt:         time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf(`CreateVisitor: %v`, err)
+	}
+	return v
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemory()
+	purpose := createPurpose(t, s)
+	visitor := createVisitor(t, s, purpose, "visitor00001")
+	policy := Policy{AnonymizeAfterDays: 30, DeleteAfterDays: 365}
+
+	// nothing is old enough yet
+	report, err := Run(ctx, s, policy, time.Now(), false, nil)
+	if err != nil || report.AnonymizedVisitors != 0 || report.DeletedVisitors != 0 {
+		t.Errorf(`Run returned %+v, %v; expected nothing to be changed`, report, err)
+	}
+
+	// a month later, a dry run reports the visitor without anonymizing it
+	recorded := 0
+	record := func(q storage.Store, report Report) error {
+		recorded++
+		return nil
+	}
+	later := time.Now().AddDate(0, 0, 31)
+	report, err = Run(ctx, s, policy, later, true, record)
+	if err != nil || !report.DryRun || report.AnonymizedVisitors != 1 || report.DeletedVisitors != 0 || recorded != 0 {
+		t.Errorf(`dry Run returned %+v, %v (recorded %d times); expected one visitor to anonymize`, report, err, recorded)
+	}
+	if got, _ := s.GetVisitorsByPublicID(ctx, visitor.PublicID); !got.Name.Valid {
+		t.Errorf(`dry Run anonymized visitor %v`, visitor.PublicID)
+	}
+
+	report, err = Run(ctx, s, policy, later, false, record)
+	if err != nil || report.AnonymizedVisitors != 1 || recorded != 1 {
+		t.Errorf(`Run returned %+v, %v (recorded %d times); expected one visitor to be anonymized`, report, err, recorded)
+	}
+	got, err := s.GetVisitorsByPublicID(ctx, visitor.PublicID)
+	if err != nil || got.Name.Valid || got.DailyTicketNumber != 1 || got.PurposePublicID != purpose.PublicID {
+		t.Errorf(`GetVisitorsByPublicID returned %+v, %v; expected the visitor without name`, got, err)
+	}
+
+	// a year later it is deleted
+	report, err = Run(ctx, s, policy, time.Now().AddDate(1, 0, 1), false, nil)
+	if err != nil || report.DeletedVisitors != 1 || report.AnonymizedVisitors != 0 {
+		t.Errorf(`Run returned %+v, %v; expected one visitor to be deleted`, report, err)
+	}
+	if _, err := s.GetVisitorsByPublicID(ctx, visitor.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetVisitorsByPublicID returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func TestRunRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemory()
+	purpose := createPurpose(t, s)
+	visitor := createVisitor(t, s, purpose, "visitor00001")
+
+	failed := errors.New("audit failed")
+	_, err := Run(ctx, s, Policy{AnonymizeAfterDays: 1}, time.Now().AddDate(0, 0, 2), false, func(storage.Store, Report) error { return failed })
+	if !errors.Is(err, failed) {
+		t.Errorf(`Run returned %v, expected the error of record`, err)
+	}
+	if got, _ := s.GetVisitorsByPublicID(ctx, visitor.PublicID); !got.Name.Valid {
+		t.Errorf(`Run anonymized visitor %v although recording the audit event failed`, visitor.PublicID)
+	}
+}
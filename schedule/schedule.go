@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var ErrInvalidClock = errors.New("time of day must be formatted as HH:MM, from 00:00 to 24:00")
+
+// Period is a span of opening hours on a weekday, in minutes after midnight in the time zone of its Calendar. Closes
+// is at most 24*60, the end of the day.
+type Period struct {
+	Weekday time.Weekday
+	Opens   int
+	Closes  int
+}
+
+// Calendar says when tickets are issued. It is open during its periods, except on holidays and in the last
+// StopBeforeClose of every period. A Calendar without periods is open all day, except on holidays.
+type Calendar struct {
+	Location        *time.Location
+	Periods         []Period
+	Holidays        []time.Time // only the calendar date counts, as held by the time itself
+	StopBeforeClose time.Duration
+}
+
+// searchDays is how far ahead NextOpening looks before giving up
+const searchDays = 366
+
+func ParseClock(s string) (int, error) {
+	// parses a time of day like 09:30 to minutes after midnight. 24:00 is the end of the day
+	var h, m int
+	if len(s) != 5 {
+		return 0, ErrInvalidClock
+	}
+	if _, err := fmt.Sscanf(s, "%02d:%02d", &h, &m); err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, ErrInvalidClock
+	}
+	return h*60 + m, nil
+}
+
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func Date(t time.Time, loc *time.Location) time.Time {
+	// the calendar date of t in loc, as midnight UTC: the form of the DATE arguments of the queries
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func DayStart(t time.Time, loc *time.Location) time.Time {
+	// the first instant of the day of t in loc
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+func (c Calendar) isHoliday(y int, m time.Month, d int) bool {
+	return slices.ContainsFunc(c.Holidays, func(h time.Time) bool {
+		hy, hm, hd := h.Date()
+		return hy == y && hm == m && hd == d
+	})
+}
+
+func (c Calendar) openings(day time.Time) [][2]time.Time {
+	/*
+		The spans in which tickets are issued on the day of day, which is in c.Location, ordered by opening time. Times
+		are built from the date and the minutes so a change to or from daylight saving time moves them with the clock.
+	*/
+	y, m, d := day.Date()
+	if c.isHoliday(y, m, d) {
+		return nil
+	}
+	if len(c.Periods) == 0 {
+		return [][2]time.Time{{time.Date(y, m, d, 0, 0, 0, 0, c.Location), time.Date(y, m, d+1, 0, 0, 0, 0, c.Location)}}
+	}
+	var spans [][2]time.Time
+	for _, p := range c.Periods {
+		if p.Weekday != day.Weekday() {
+			continue
+		}
+		opens := time.Date(y, m, d, 0, p.Opens, 0, 0, c.Location)
+		closes := time.Date(y, m, d, 0, p.Closes, 0, 0, c.Location).Add(-c.StopBeforeClose)
+		if closes.After(opens) {
+			spans = append(spans, [2]time.Time{opens, closes})
+		}
+	}
+	slices.SortFunc(spans, func(a, b [2]time.Time) int { return a[0].Compare(b[0]) })
+	return spans
+}
+
+func (c Calendar) IsOpen(t time.Time) bool {
+	for _, span := range c.openings(t.In(c.Location)) {
+		if !t.Before(span[0]) && t.Before(span[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Calendar) NextOpening(t time.Time) (time.Time, bool) {
+	// the first instant from t on at which c is open. false if it does not open within a year
+	if c.IsOpen(t) {
+		return t, true
+	}
+	start := DayStart(t, c.Location)
+	for i := range searchDays {
+		day := start.AddDate(0, 0, i)
+		for _, span := range c.openings(day) {
+			if span[0].After(t) {
+				return span[0], true
+			}
+		}
+	}
+	return time.Time{}, false
+}
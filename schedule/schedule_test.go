@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf(`LoadLocation(%q): %v`, name, err)
+	}
+	return loc
+}
+
+func TestCalendar(t *testing.T) {
+	amsterdam := mustLoadLocation(t, "Europe/Amsterdam")
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, amsterdam)
+		if err != nil {
+			t.Fatalf(`ParseInLocation(%q): %v`, s, err)
+		}
+		return v
+	}
+	// weekdays 9:00-12:00 and 13:00-17:00, no tickets in the last 15 minutes, closed on 2026-12-25 (a Friday)
+	c := Calendar{Location: amsterdam, StopBeforeClose: 15 * time.Minute, Holidays: []time.Time{time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)}}
+	for day := time.Monday; day <= time.Friday; day++ {
+		c.Periods = append(c.Periods, Period{Weekday: day, Opens: 13 * 60, Closes: 17 * 60}, Period{Weekday: day, Opens: 9 * 60, Closes: 12 * 60})
+	}
+
+	tests := []struct {
+		now  string
+		open bool
+		next string
+	}{
+		{"2026-12-21 08:59", false, "2026-12-21 09:00"}, // Monday, before opening
+		{"2026-12-21 09:00", true, "2026-12-21 09:00"},
+		{"2026-12-21 11:44", true, "2026-12-21 11:44"},
+		{"2026-12-21 11:45", false, "2026-12-21 13:00"}, // stopped issuing before the lunch break
+		{"2026-12-21 16:50", false, "2026-12-22 09:00"},
+		{"2026-12-24 18:00", false, "2026-12-28 09:00"}, // over the holiday and the weekend
+		{"2026-12-25 10:00", false, "2026-12-28 09:00"},
+		{"2026-12-26 10:00", false, "2026-12-28 09:00"}, // Saturday
+	}
+	for _, test := range tests {
+		now := at(test.now)
+		if open := c.IsOpen(now); open != test.open {
+			t.Errorf(`IsOpen(%s) returned %v, expected %v`, test.now, open, test.open)
+		}
+		next, ok := c.NextOpening(now)
+		if !ok || !next.Equal(at(test.next)) {
+			t.Errorf(`NextOpening(%s) returned %v, %v; expected %s`, test.now, next, ok, test.next)
+		}
+	}
+
+	// the same instant is another time of day in another time zone
+	if !c.IsOpen(time.Date(2026, 12, 21, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf(`IsOpen at 8:30 UTC returned false, expected true for 9:30 in Amsterdam`)
+	}
+}
+
+func TestCalendarAlwaysOpen(t *testing.T) {
+	c := Calendar{Location: time.UTC, Holidays: []time.Time{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	if !c.IsOpen(time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf(`IsOpen returned false for a calendar without periods`)
+	}
+	next, ok := c.NextOpening(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	if !ok || !next.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf(`NextOpening on a holiday returned %v, %v; expected the start of the next day`, next, ok)
+	}
+}
+
+func TestCalendarNeverOpen(t *testing.T) {
+	// every period is shorter than the time before closing in which no tickets are issued
+	c := Calendar{Location: time.UTC, Periods: []Period{{Weekday: time.Monday, Opens: 600, Closes: 610}}, StopBeforeClose: time.Hour}
+	if _, ok := c.NextOpening(time.Now()); ok {
+		t.Errorf(`NextOpening succeeded for a calendar that never opens`)
+	}
+}
+
+func TestDaylightSavingTime(t *testing.T) {
+	// clocks in Amsterdam went forward on 2026-03-29, so opening at 9:00 is an hour earlier in UTC than the day before
+	amsterdam := mustLoadLocation(t, "Europe/Amsterdam")
+	c := Calendar{Location: amsterdam, Periods: []Period{{Weekday: time.Saturday, Opens: 540, Closes: 600}, {Weekday: time.Sunday, Opens: 540, Closes: 600}}}
+	next, ok := c.NextOpening(time.Date(2026, 3, 28, 12, 0, 0, 0, time.UTC))
+	if !ok || !next.Equal(time.Date(2026, 3, 29, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf(`NextOpening returned %v, %v; expected 7:00 UTC`, next, ok)
+	}
+}
+
+func TestDate(t *testing.T) {
+	tokyo := mustLoadLocation(t, "Asia/Tokyo")
+	now := time.Date(2026, 5, 31, 20, 0, 0, 0, time.UTC) // June 1st in Tokyo
+	if date := Date(now, tokyo); !date.Equal(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf(`Date returned %v, expected 2026-06-01`, date)
+	}
+	if start := DayStart(now, tokyo); !start.Equal(time.Date(2026, 5, 31, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf(`DayStart returned %v, expected midnight in Tokyo`, start)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for s, expected := range map[string]int{"00:00": 0, "09:30": 570, "24:00": 1440} {
+		if minutes, err := ParseClock(s); err != nil || minutes != expected {
+			t.Errorf(`ParseClock(%q) returned %v, %v; expected %v`, s, minutes, err, expected)
+		} else if FormatClock(minutes) != s {
+			t.Errorf(`FormatClock(%v) returned %q, expected %q`, minutes, FormatClock(minutes), s)
+		}
+	}
+	for _, s := range []string{"", "9:30", "09:60", "24:01", "-1:00", "ab:cd", "09.30"} {
+		if _, err := ParseClock(s); err == nil {
+			t.Errorf(`ParseClock(%q) succeeded, expected an error`, s)
+		}
+	}
+}
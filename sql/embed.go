@@ -0,0 +1,14 @@
+// Package sql embeds the schema migrations, so that the binary can apply them itself (see package migrate).
+package sql
+
+import "embed"
+
+// PostgresMigrations holds schema/*.sql.
+//
+//go:embed schema/*.sql
+var PostgresMigrations embed.FS
+
+// SQLiteMigrations holds sqlite/schema/*.sql.
+//
+//go:embed sqlite/schema/*.sql
+var SQLiteMigrations embed.FS
@@ -0,0 +1,28 @@
+
+
+-- name: CreateAnnouncementTemplate :one
+INSERT INTO announcement_templates (id, public_id, created_at, updated_at, location_public_id, purpose_public_id, language, template)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetAnnouncementTemplateByPublicID :one
+SELECT * FROM announcement_templates
+WHERE public_id = $1;
+
+-- name: GetAnnouncementTemplatesByLocationPublicID :many
+SELECT * FROM announcement_templates
+WHERE location_public_id = $1
+ORDER BY created_at ASC, public_id ASC;
+
+-- name: DeleteAnnouncementTemplateByPublicID :exec
+DELETE FROM announcement_templates
+WHERE public_id = $1;
\ No newline at end of file
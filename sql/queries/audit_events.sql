@@ -0,0 +1,24 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (id, created_at, actor_public_id, action, entity_type, entity_public_id, diff, request_id, ip_address)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg('actor_public_id')::text IS NULL OR actor_public_id = sqlc.narg('actor_public_id'))
+AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+AND (sqlc.narg('entity_type')::text IS NULL OR entity_type = sqlc.narg('entity_type'))
+AND (sqlc.narg('entity_public_id')::text IS NULL OR entity_public_id = sqlc.narg('entity_public_id'))
+AND (sqlc.narg('start_date')::timestamp IS NULL OR created_at >= sqlc.narg('start_date'))
+AND (sqlc.narg('end_date')::timestamp IS NULL OR created_at < sqlc.narg('end_date'))
+ORDER BY created_at ASC;
\ No newline at end of file
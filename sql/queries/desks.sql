@@ -1,11 +1,14 @@
 -- name: CreateDesks :one
-INSERT INTO desks (id, public_id, name, description, is_active)
+INSERT INTO desks (id, public_id, created_at, updated_at, name, description, is_active, location_public_id)
 VALUES (
     gen_random_uuid(),
     $1,
+    NOW(),
+    NOW(),
     $2,
     $3,
-    TRUE
+    TRUE,
+    $4
 )
 RETURNING *;
 
@@ -23,10 +26,36 @@ WHERE is_active = TRUE;
 -- name: SetDesksByPublicID :one
 UPDATE desks
 SET name = $2, description = $3, is_active = $4, updated_at = NOW()
-WHERE public_id = $1
+WHERE public_id = $1 AND (sqlc.narg('if_updated_at')::timestamp IS NULL OR updated_at = sqlc.narg('if_updated_at'))
 RETURNING *;
 
 -- name: ListDesks :many
 SELECT * FROM desks
 WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
-ORDER BY name ASC;
\ No newline at end of file
+    AND (sqlc.narg('location_public_id')::text IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id')::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')))
+    AND (sqlc.narg('after_public_id')::text IS NULL
+        OR (sqlc.arg('sort')::text = 'name' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN name < sqlc.narg('after_text')::text ELSE name > sqlc.narg('after_text')::text END
+            OR (name = sqlc.narg('after_text')::text AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'created_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN created_at < sqlc.narg('after_time')::timestamp ELSE created_at > sqlc.narg('after_time')::timestamp END
+            OR (created_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'updated_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN updated_at < sqlc.narg('after_time')::timestamp ELSE updated_at > sqlc.narg('after_time')::timestamp END
+            OR (updated_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text))))
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'name' AND NOT sqlc.arg('descending')::boolean THEN name END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'name' AND sqlc.arg('descending')::boolean THEN name END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND NOT sqlc.arg('descending')::boolean THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND sqlc.arg('descending')::boolean THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'updated_at' AND NOT sqlc.arg('descending')::boolean THEN updated_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'updated_at' AND sqlc.arg('descending')::boolean THEN updated_at END DESC,
+    public_id ASC
+LIMIT sqlc.arg('row_limit')::int;
+
+-- name: CountDesks :one
+SELECT COUNT(*) FROM desks
+WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
+    AND (sqlc.narg('location_public_id')::text IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id')::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')));
\ No newline at end of file
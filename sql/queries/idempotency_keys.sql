@@ -0,0 +1,33 @@
+-- name: CreateIdempotencyKey :one
+-- claims the key, or takes over the claim of a request with the same body whose lease ran out without a response
+INSERT INTO idempotency_keys (scope, idempotency_key, request_hash, created_at, expires_at, locked_until)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (scope, idempotency_key) DO UPDATE
+SET created_at = excluded.created_at, expires_at = excluded.expires_at, locked_until = excluded.locked_until
+WHERE idempotency_keys.response_status IS NULL AND idempotency_keys.request_hash = excluded.request_hash
+    AND (idempotency_keys.locked_until IS NULL OR idempotency_keys.locked_until <= excluded.created_at)
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = $1 AND idempotency_key = $2;
+
+-- name: SetIdempotencyKeyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $3, response_body = $4, response_headers = $5
+WHERE scope = $1 AND idempotency_key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND idempotency_key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE expires_at <= $1;
\ No newline at end of file
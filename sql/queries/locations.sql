@@ -0,0 +1,43 @@
+
+
+-- name: CreateLocation :one
+INSERT INTO locations (id, public_id, created_at, updated_at, name, time_zone, stop_issuing_minutes)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetLocationByPublicID :one
+SELECT * FROM locations
+WHERE public_id = $1;
+
+-- name: GetLocations :many
+SELECT * FROM locations
+ORDER BY name ASC, public_id ASC;
+
+-- name: SetLocationByPublicID :one
+UPDATE locations
+SET name = $2, time_zone = $3, stop_issuing_minutes = $4, updated_at = NOW()
+WHERE public_id = $1
+RETURNING *;
+
+-- name: GetLocationsByUserPublicID :many
+SELECT locations.* FROM locations
+JOIN user_locations ON user_locations.location_public_id = locations.public_id
+WHERE user_locations.user_public_id = $1
+ORDER BY locations.name ASC, locations.public_id ASC;
+
+-- name: AddUserLocation :exec
+INSERT INTO user_locations (user_public_id, location_public_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteUserLocations :exec
+DELETE FROM user_locations
+WHERE user_public_id = $1;
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE attempt_key = $1;
+
+-- name: FailLoginAttempt :one
+-- registers a failed attempt in a single statement, so that concurrent failures all count. Failures before
+-- forget_before are forgotten, as is a lockout that has run out. The delay before the next attempt doubles with every
+-- failure, up to max_delay. An active lockout is left as it is, as is any block if only_unblocked is set: then no row
+-- is returned.
+INSERT INTO login_attempts (attempt_key, failed_attempts, last_failed_at, blocked_until, is_locked_out)
+VALUES (
+    sqlc.arg('attempt_key'),
+    1,
+    sqlc.arg('now'),
+    CASE WHEN sqlc.arg('max_attempts')::integer = 1 THEN sqlc.arg('locked_until')::timestamp
+        ELSE sqlc.arg('now')::timestamp + make_interval(secs => LEAST(sqlc.arg('base_delay')::float8, sqlc.arg('max_delay')::float8)) END,
+    sqlc.arg('max_attempts')::integer = 1
+)
+ON CONFLICT (attempt_key)
+DO UPDATE SET
+  failed_attempts = CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before')::timestamp THEN 1 ELSE login_attempts.failed_attempts + 1 END,
+  last_failed_at = sqlc.arg('now'),
+  blocked_until = CASE
+    WHEN sqlc.arg('max_attempts')::integer > 0 AND CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before')::timestamp THEN 1 ELSE login_attempts.failed_attempts + 1 END >= sqlc.arg('max_attempts')::integer THEN sqlc.arg('locked_until')::timestamp
+    ELSE sqlc.arg('now')::timestamp + make_interval(secs => LEAST(sqlc.arg('base_delay')::float8 * (1::bigint << LEAST(CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before')::timestamp THEN 0 ELSE login_attempts.failed_attempts END, 62)), sqlc.arg('max_delay')::float8))
+  END,
+  is_locked_out = sqlc.arg('max_attempts')::integer > 0 AND CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before')::timestamp THEN 1 ELSE login_attempts.failed_attempts + 1 END >= sqlc.arg('max_attempts')::integer
+WHERE login_attempts.blocked_until <= sqlc.arg('now') OR NOT (sqlc.arg('only_unblocked')::boolean OR login_attempts.is_locked_out)
+RETURNING *;
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE attempt_key = $1;
\ No newline at end of file
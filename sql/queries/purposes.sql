@@ -1,12 +1,14 @@
 -- name: CreatePurpose :one
-INSERT INTO purposes (id, public_id, created_at, updated_at, purpose_name, parent_purpose_id)
+INSERT INTO purposes (id, public_id, created_at, updated_at, purpose_name, parent_purpose_id, location_public_id, daily_capacity)
 VALUES (
     gen_random_uuid(),
     $1,
     NOW(),
     NOW(),
     $2,
-    $3
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
@@ -37,8 +39,8 @@ RETURNING *;
 
 -- name: SetPurposeByPublicID :one
 UPDATE purposes
-SET purpose_name = $2, parent_purpose_id = $3, updated_at = NOW()
-WHERE public_id = $1
+SET purpose_name = $2, parent_purpose_id = $3, daily_capacity = $4, updated_at = NOW()
+WHERE public_id = $1 AND (sqlc.narg('if_updated_at')::timestamp IS NULL OR updated_at = sqlc.narg('if_updated_at'))
 RETURNING *;
 
 -- name: SetPurposeName :one
@@ -57,4 +59,8 @@ RETURNING *;
 UPDATE purposes
 SET parent_purpose_id = (SELECT purposes.id FROM purposes WHERE purposes.purpose_name = $2), updated_at = NOW()
 WHERE purposes.id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetPurposesByLocationPublicID :many
+SELECT * FROM purposes
+WHERE location_public_id = $1;
\ No newline at end of file
@@ -1,5 +1,5 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, public_id, created_at, updated_at, user_public_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, public_id, created_at, updated_at, user_public_id, expires_at, revoked_at, last_used_at, user_agent, ip_address)
 VALUES (
     $1,
     $2,
@@ -7,7 +7,10 @@ VALUES (
     NOW(),
     $3,
     $4,
-    NULL
+    NULL,
+    NOW(),
+    $5,
+    $6
 )
 RETURNING *;
 
@@ -24,7 +27,14 @@ WHERE token = $1;
 
 -- name: GetRefreshTokensByUserPublicID :many
 SELECT * FROM refresh_tokens
-WHERE user_public_id = $1 AND expires_at > NOW() AND revoked_at IS NULL;
+WHERE user_public_id = $1 AND expires_at > NOW() AND revoked_at IS NULL
+ORDER BY last_used_at DESC;
+
+-- name: RotateRefreshTokenByToken :one
+UPDATE refresh_tokens
+SET token = sqlc.arg('new_token'), expires_at = sqlc.arg('expires_at'), user_agent = sqlc.arg('user_agent'), ip_address = sqlc.arg('ip_address'), last_used_at = NOW(), updated_at = NOW()
+WHERE token = sqlc.arg('old_token') AND expires_at > NOW() AND revoked_at IS NULL
+RETURNING *;
 
 -- name: RevokeRefreshTokenByToken :one
 UPDATE refresh_tokens
@@ -32,6 +42,12 @@ SET revoked_at = NOW(), updated_at = NOW()
 WHERE token = $1
 RETURNING *;
 
+-- name: RevokeRefreshTokenByPublicID :one
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE public_id = $1 AND expires_at > NOW() AND revoked_at IS NULL
+RETURNING *;
+
 -- name: RevokeRefreshTokenByUserPublicID :many 
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
@@ -14,31 +14,84 @@ SELECT * FROM service_logs
 where is_active = true AND user_public_id = $1;
 
 -- name: CreateServiceLogs :one
-INSERT INTO service_logs (id, public_id, created_at, updated_at, visitor_public_id, user_public_id, desk_public_id, called_at, is_active)
+INSERT INTO service_logs (id, public_id, created_at, updated_at, visitor_public_id, user_public_id, desk_public_id, called_at, is_active, location_public_id)
 VALUES (
     gen_random_uuid(),
     $1,
-    NOW(),
-    NOW(),
+    $6, -- stamped by the caller in UTC, like the times it is compared with
+    $6,
     $2,
     $3,
     $4,
-    NOW(),
-    true
+    $6,
+    true,
+    $5
 )
 RETURNING *;
 
 -- name: SetServiceLogsByPublicID :one
 UPDATE service_logs
-SET visitor_public_id = $2, user_public_id = $3, desk_public_id = $4, is_active = $5, updated_at = NOW()
+SET visitor_public_id = $2, user_public_id = $3, desk_public_id = $4, is_active = $5, location_public_id = $6, updated_at = NOW()
 WHERE public_id = $1
 RETURNING *;
 
 -- name: ListServiceLogs :many
 SELECT * FROM service_logs
 WHERE (sqlc.narg('user_public_id')::text IS NULL OR user_public_id = sqlc.narg('user_public_id'))
-AND (sqlc.narg('visitor_public_id')::text IS NULL OR visitor_public_id = sqlc.narg('visitor_public_id'))
-AND (sqlc.narg('desk_public_id')::text IS NULL OR desk_public_id = sqlc.narg('desk_public_id'))
-AND (sqlc.narg('start_date')::timestamp IS NULL OR created_at >= sqlc.narg('start_date'))
-AND (sqlc.narg('end_date')::timestamp IS NULL OR created_at < sqlc.narg('end_date'))
-ORDER BY created_at ASC;
\ No newline at end of file
+    AND (sqlc.narg('visitor_public_id')::text IS NULL OR visitor_public_id = sqlc.narg('visitor_public_id'))
+    AND (sqlc.narg('desk_public_id')::text IS NULL OR desk_public_id = sqlc.narg('desk_public_id'))
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR created_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR created_at < sqlc.narg('end_date'))
+    AND (sqlc.narg('location_public_id')::text IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id')::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')))
+    AND (sqlc.narg('after_public_id')::text IS NULL
+        OR (sqlc.arg('sort')::text = 'created_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN created_at < sqlc.narg('after_time')::timestamp ELSE created_at > sqlc.narg('after_time')::timestamp END
+            OR (created_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'called_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN called_at < sqlc.narg('after_time')::timestamp ELSE called_at > sqlc.narg('after_time')::timestamp END
+            OR (called_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'updated_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN updated_at < sqlc.narg('after_time')::timestamp ELSE updated_at > sqlc.narg('after_time')::timestamp END
+            OR (updated_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text))))
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND NOT sqlc.arg('descending')::boolean THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND sqlc.arg('descending')::boolean THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'called_at' AND NOT sqlc.arg('descending')::boolean THEN called_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'called_at' AND sqlc.arg('descending')::boolean THEN called_at END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'updated_at' AND NOT sqlc.arg('descending')::boolean THEN updated_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'updated_at' AND sqlc.arg('descending')::boolean THEN updated_at END DESC,
+    public_id ASC
+LIMIT sqlc.arg('row_limit')::int;
+
+-- name: CountServiceLogs :one
+SELECT COUNT(*) FROM service_logs
+WHERE (sqlc.narg('user_public_id')::text IS NULL OR user_public_id = sqlc.narg('user_public_id'))
+    AND (sqlc.narg('visitor_public_id')::text IS NULL OR visitor_public_id = sqlc.narg('visitor_public_id'))
+    AND (sqlc.narg('desk_public_id')::text IS NULL OR desk_public_id = sqlc.narg('desk_public_id'))
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR created_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR created_at < sqlc.narg('end_date'))
+    AND (sqlc.narg('location_public_id')::text IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id')::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')));
+
+-- name: DeleteServiceLogsOfVisitorsCreatedBefore :execrows
+DELETE FROM service_logs
+WHERE visitor_public_id IN (SELECT public_id FROM visitors WHERE created_at < sqlc.arg('created_before')::timestamp);
+
+-- name: GetServiceLogsByVisitorPublicID :many
+SELECT * FROM service_logs
+WHERE visitor_public_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetCallTimesByPurposePublicID :many
+SELECT service_logs.called_at FROM service_logs
+JOIN visitors ON visitors.public_id = service_logs.visitor_public_id
+WHERE visitors.purpose_public_id = $1 AND service_logs.called_at >= $2
+ORDER BY service_logs.called_at DESC
+LIMIT $3;
+
+-- name: GetActiveServiceLogsByLocationPublicID :many
+SELECT * FROM service_logs
+WHERE location_public_id = $1 AND is_active = TRUE AND called_at >= $2
+ORDER BY called_at DESC, public_id DESC
+LIMIT $3;
\ No newline at end of file
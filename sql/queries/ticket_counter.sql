@@ -1,7 +1,7 @@
 -- name: UpdateTicketCounter :one
-INSERT INTO ticket_counter (counter_date, last_ticket_number)
-VALUES (CURRENT_DATE, 1)
-ON CONFLICT (counter_date)
+INSERT INTO ticket_counter (location_public_id, counter_date, last_ticket_number)
+VALUES ($1, $2::date, 1)
+ON CONFLICT (location_public_id, counter_date)
 DO UPDATE SET
   last_ticket_number = ticket_counter.last_ticket_number + 1
 RETURNING last_ticket_number;
\ No newline at end of file
@@ -0,0 +1,19 @@
+
+
+-- name: GetTicketLayoutByPurposePublicID :one
+SELECT * FROM ticket_layouts
+WHERE purpose_public_id = $1;
+
+-- name: UpsertTicketLayout :one
+INSERT INTO ticket_layouts (purpose_public_id, created_at, updated_at, header, footer, logo)
+VALUES (
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (purpose_public_id) DO UPDATE
+SET header = excluded.header, footer = excluded.footer, logo = excluded.logo, updated_at = NOW()
+RETURNING *;
\ No newline at end of file
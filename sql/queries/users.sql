@@ -61,4 +61,39 @@ RETURNING *;
 -- name: DeleteUserByID :one
 DELETE FROM users
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: SetUserPasswordByPublicID :one
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE public_id = $1
+RETURNING *;
+
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
+    AND (sqlc.narg('is_admin')::boolean IS NULL OR is_admin = sqlc.narg('is_admin'))
+    AND (sqlc.narg('after_public_id')::text IS NULL
+        OR (sqlc.arg('sort')::text = 'created_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN created_at < sqlc.narg('after_time')::timestamp ELSE created_at > sqlc.narg('after_time')::timestamp END
+            OR (created_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'email' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN email < sqlc.narg('after_text')::text ELSE email > sqlc.narg('after_text')::text END
+            OR (email = sqlc.narg('after_text')::text AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'full_name' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN full_name < sqlc.narg('after_text')::text ELSE full_name > sqlc.narg('after_text')::text END
+            OR (full_name = sqlc.narg('after_text')::text AND public_id > sqlc.narg('after_public_id')::text))))
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND NOT sqlc.arg('descending')::boolean THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND sqlc.arg('descending')::boolean THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'email' AND NOT sqlc.arg('descending')::boolean THEN email END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'email' AND sqlc.arg('descending')::boolean THEN email END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'full_name' AND NOT sqlc.arg('descending')::boolean THEN full_name END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'full_name' AND sqlc.arg('descending')::boolean THEN full_name END DESC,
+    public_id ASC
+LIMIT sqlc.arg('row_limit')::int;
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+WHERE (sqlc.narg('is_active')::boolean IS NULL OR is_active = sqlc.narg('is_active'))
+    AND (sqlc.narg('is_admin')::boolean IS NULL OR is_admin = sqlc.narg('is_admin'));
\ No newline at end of file
@@ -1,15 +1,18 @@
 -- name: CreateVisitor :one
-INSERT INTO visitors (id, public_id, created_at, updated_at, waiting_since, name, purpose_public_id, status, daily_ticket_number)
+INSERT INTO visitors (id, public_id, created_at, updated_at, waiting_since, name, purpose_public_id, status, daily_ticket_number, location_public_id, phone_number, email)
 VALUES (
     gen_random_uuid(),
     $1,
-    NOW(),
-    NOW(),
-    NOW(),
+    $8, -- stamped by the caller in UTC, like the times it is compared with
+    $8,
+    $8,
     $2,
     $3,
     0, --status 
-    $4
+    $4,
+    $5,
+    $6,
+    $7
 )
 RETURNING *;
 
@@ -50,11 +53,6 @@ SELECT * FROM visitors
 WHERE purpose_public_id = $1 AND status = 1 -- NOTE that statuses are still not properly implemented
 ORDER BY waiting_since ASC;
 
--- name: GetVisitorsForToday :many
-SELECT * FROM visitors
-WHERE waiting_since::date = CURRENT_DATE
-ORDER BY waiting_since ASC;
-
 -- name: SetVisitorStatusByID :one
 UPDATE visitors
 SET status = $2, updated_at = NOW() --status 
@@ -67,4 +65,80 @@ WHERE (sqlc.narg('status')::int IS NULL OR status = sqlc.narg('status'))
     AND (sqlc.narg('purpose_public_id')::text IS NULL OR purpose_public_id = sqlc.narg('purpose_public_id'))
     AND (sqlc.narg('start_date')::timestamp IS NULL OR created_at >= sqlc.narg('start_date'))
     AND (sqlc.narg('end_date')::timestamp IS NULL OR created_at < sqlc.narg('end_date'))
-ORDER BY waiting_since ASC;
\ No newline at end of file
+    AND (sqlc.narg('location_public_id')::text IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id')::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')))
+    AND (sqlc.narg('after_public_id')::text IS NULL
+        OR (sqlc.arg('sort')::text = 'waiting_since' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN waiting_since < sqlc.narg('after_time')::timestamp ELSE waiting_since > sqlc.narg('after_time')::timestamp END
+            OR (waiting_since = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'created_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN created_at < sqlc.narg('after_time')::timestamp ELSE created_at > sqlc.narg('after_time')::timestamp END
+            OR (created_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'updated_at' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN updated_at < sqlc.narg('after_time')::timestamp ELSE updated_at > sqlc.narg('after_time')::timestamp END
+            OR (updated_at = sqlc.narg('after_time')::timestamp AND public_id > sqlc.narg('after_public_id')::text)))
+        OR (sqlc.arg('sort')::text = 'daily_ticket_number' AND (
+            CASE WHEN sqlc.arg('descending')::boolean THEN daily_ticket_number < sqlc.narg('after_int')::int ELSE daily_ticket_number > sqlc.narg('after_int')::int END
+            OR (daily_ticket_number = sqlc.narg('after_int')::int AND public_id > sqlc.narg('after_public_id')::text))))
+ORDER BY
+    CASE WHEN sqlc.arg('sort')::text = 'waiting_since' AND NOT sqlc.arg('descending')::boolean THEN waiting_since END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'waiting_since' AND sqlc.arg('descending')::boolean THEN waiting_since END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND NOT sqlc.arg('descending')::boolean THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'created_at' AND sqlc.arg('descending')::boolean THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'updated_at' AND NOT sqlc.arg('descending')::boolean THEN updated_at END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'updated_at' AND sqlc.arg('descending')::boolean THEN updated_at END DESC,
+    CASE WHEN sqlc.arg('sort')::text = 'daily_ticket_number' AND NOT sqlc.arg('descending')::boolean THEN daily_ticket_number END ASC,
+    CASE WHEN sqlc.arg('sort')::text = 'daily_ticket_number' AND sqlc.arg('descending')::boolean THEN daily_ticket_number END DESC,
+    public_id ASC
+LIMIT sqlc.arg('row_limit')::int;
+
+-- name: CountVisitors :one
+SELECT COUNT(*) FROM visitors
+WHERE (sqlc.narg('status')::int IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('purpose_public_id')::text IS NULL OR purpose_public_id = sqlc.narg('purpose_public_id'))
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR created_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR created_at < sqlc.narg('end_date'))
+    AND (sqlc.narg('location_public_id')::text IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id')::text IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')));
+
+-- name: AnonymizeVisitorsCreatedBefore :execrows
+UPDATE visitors
+SET name = NULL, phone_number = NULL, email = NULL, updated_at = NOW()
+WHERE created_at < sqlc.arg('created_before')::timestamp AND (name IS NOT NULL OR phone_number IS NOT NULL OR email IS NOT NULL);
+
+-- name: DeleteVisitorsCreatedBefore :execrows
+DELETE FROM visitors
+WHERE created_at < sqlc.arg('created_before')::timestamp;
+
+-- name: AnonymizeVisitorByPublicID :one
+UPDATE visitors
+SET name = NULL, phone_number = NULL, email = NULL, updated_at = NOW()
+WHERE public_id = $1
+RETURNING *;
+
+-- name: GetVisitorsForDay :many
+SELECT * FROM visitors
+WHERE location_public_id = $1 AND waiting_since >= sqlc.arg('day_start')::timestamp AND waiting_since < sqlc.arg('day_end')::timestamp
+ORDER BY waiting_since ASC;
+
+-- name: CountVisitorsByPurposePublicIDSince :one
+SELECT COUNT(*) FROM visitors
+WHERE purpose_public_id = $1 AND created_at >= $2;
+
+-- name: GetQueuedVisitorsByPurposePublicID :many
+SELECT * FROM visitors
+WHERE purpose_public_id = $1 AND status = 0
+    AND NOT EXISTS (SELECT 1 FROM service_logs WHERE service_logs.visitor_public_id = visitors.public_id)
+ORDER BY waiting_since ASC, public_id ASC
+LIMIT $2;
+
+-- name: SetVisitorNotifiedAt :execrows
+UPDATE visitors
+SET notified_at = $2
+WHERE public_id = $1 AND notified_at IS NULL;
+
+-- name: CountQueuedVisitorsAhead :one
+SELECT COUNT(*) FROM visitors
+WHERE purpose_public_id = $1 AND status = 0
+    AND NOT EXISTS (SELECT 1 FROM service_logs WHERE service_logs.visitor_public_id = visitors.public_id)
+    AND (waiting_since < $2 OR (waiting_since = $2 AND public_id < $3));
\ No newline at end of file
@@ -0,0 +1,81 @@
+
+
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (id, public_id, created_at, updated_at, url, secret, event_type)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+ORDER BY created_at ASC, public_id ASC;
+
+-- name: GetWebhookSubscriptionByPublicID :one
+SELECT * FROM webhook_subscriptions
+WHERE public_id = $1;
+
+-- name: GetWebhookSubscriptionsByEventType :many
+SELECT * FROM webhook_subscriptions
+WHERE event_type = $1
+ORDER BY created_at ASC, public_id ASC;
+
+-- name: DeleteWebhookSubscriptionByPublicID :exec
+DELETE FROM webhook_subscriptions
+WHERE public_id = $1;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (id, public_id, created_at, updated_at, subscription_public_id, event_type, payload, status, attempts, next_attempt_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4,
+    'pending',
+    0,
+    $5
+)
+RETURNING *;
+
+-- name: GetWebhookDeliveryByPublicID :one
+SELECT * FROM webhook_deliveries
+WHERE public_id = $1;
+
+-- name: GetWebhookDeliveriesBySubscriptionPublicID :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_public_id = $1
+ORDER BY created_at DESC, public_id DESC
+LIMIT $2;
+
+-- name: GetDueWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE status = 'pending' AND next_attempt_at <= $1
+ORDER BY next_attempt_at ASC, public_id ASC
+LIMIT $2;
+
+-- name: ClaimWebhookDelivery :one
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg('lease_until'), updated_at = NOW()
+WHERE public_id = sqlc.arg('public_id') AND status = 'pending' AND next_attempt_at <= sqlc.arg('now')
+RETURNING *;
+
+-- name: SetWebhookDeliveryResult :one
+UPDATE webhook_deliveries
+SET status = $2, attempts = attempts + 1, next_attempt_at = $3, response_status = $4, last_error = $5, delivered_at = $6, updated_at = NOW()
+WHERE public_id = $1
+RETURNING *;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = $2, updated_at = NOW()
+WHERE public_id = $1
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE login_attempts (
+    attempt_key TEXT PRIMARY KEY,
+    failed_attempts INTEGER NOT NULL,
+    last_failed_at TIMESTAMP NOT NULL,
+    blocked_until TIMESTAMP NOT NULL,
+    is_locked_out BOOLEAN NOT NULL
+);
+
+-- +goose Down
+DROP TABLE login_attempts;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE user_tokens (
+    id UUID PRIMARY KEY,
+    public_id TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_public_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    FOREIGN KEY (user_public_id) REFERENCES users (public_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_user_tokens_user_public_id ON user_tokens(user_public_id);
+
+-- +goose Down
+DROP TABLE user_tokens;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE refresh_tokens
+ADD COLUMN last_used_at TIMESTAMP,
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+
+UPDATE refresh_tokens
+SET last_used_at = updated_at;
+
+ALTER TABLE refresh_tokens
+ALTER COLUMN last_used_at SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE refresh_tokens
+DROP COLUMN last_used_at,
+DROP COLUMN user_agent,
+DROP COLUMN ip_address;
\ No newline at end of file
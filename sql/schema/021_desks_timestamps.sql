@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE desks
+ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+ALTER TABLE desks
+ALTER COLUMN created_at DROP DEFAULT,
+ALTER COLUMN updated_at DROP DEFAULT;
+
+-- +goose Down
+ALTER TABLE desks
+DROP COLUMN created_at,
+DROP COLUMN updated_at;
\ No newline at end of file
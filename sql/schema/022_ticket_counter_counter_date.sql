@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE ticket_counter
+RENAME COLUMN date TO counter_date;
+
+-- +goose Down
+ALTER TABLE ticket_counter
+RENAME COLUMN counter_date TO date;
\ No newline at end of file
@@ -0,0 +1,90 @@
+-- +goose Up
+CREATE TABLE locations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE user_locations (
+    user_public_id TEXT NOT NULL REFERENCES users (public_id) ON DELETE CASCADE,
+    location_public_id TEXT NOT NULL REFERENCES locations (public_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_public_id, location_public_id)
+);
+
+-- existing data moves into a default location, with a random public ID as long as the existing ones (PUBLICIDLENGTH).
+-- an empty database gets no default location: locations are then created with POST /api/locations.
+INSERT INTO locations (id, created_at, updated_at, public_id, name)
+SELECT gen_random_uuid(), NOW(), NOW(), left(repeat(md5(random()::text), 8), length(public_id)), 'Default'
+FROM (
+    SELECT public_id FROM users
+    UNION ALL SELECT public_id FROM desks
+    UNION ALL SELECT public_id FROM purposes
+) existing
+LIMIT 1;
+
+INSERT INTO user_locations (user_public_id, location_public_id)
+SELECT users.public_id, locations.public_id FROM users, locations;
+
+-- desks, purposes, visitors and service logs
+ALTER TABLE desks
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE desks SET location_public_id = (SELECT public_id FROM locations);
+ALTER TABLE desks
+ALTER COLUMN location_public_id SET NOT NULL;
+CREATE INDEX idx_desks_location_public_id ON desks(location_public_id);
+
+ALTER TABLE purposes
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE purposes SET location_public_id = (SELECT public_id FROM locations);
+ALTER TABLE purposes
+ALTER COLUMN location_public_id SET NOT NULL;
+CREATE INDEX idx_purposes_location_public_id ON purposes(location_public_id);
+
+ALTER TABLE visitors
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE visitors SET location_public_id = (SELECT public_id FROM locations);
+ALTER TABLE visitors
+ALTER COLUMN location_public_id SET NOT NULL;
+CREATE INDEX idx_visitors_location_public_id ON visitors(location_public_id);
+
+ALTER TABLE service_logs
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE service_logs SET location_public_id = (SELECT public_id FROM locations);
+ALTER TABLE service_logs
+ALTER COLUMN location_public_id SET NOT NULL;
+CREATE INDEX idx_service_logs_location_public_id ON service_logs(location_public_id);
+
+-- every location numbers its tickets from 1 each day
+ALTER TABLE ticket_counter
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE ticket_counter SET location_public_id = (SELECT public_id FROM locations);
+DELETE FROM ticket_counter WHERE location_public_id IS NULL;
+ALTER TABLE ticket_counter
+ALTER COLUMN location_public_id SET NOT NULL,
+DROP CONSTRAINT ticket_counter_pkey,
+ADD PRIMARY KEY (location_public_id, counter_date);
+
+-- +goose Down
+-- the highest counter of each day is kept, so that no ticket number is handed out twice
+DELETE FROM ticket_counter t
+USING ticket_counter u
+WHERE t.counter_date = u.counter_date
+    AND (t.last_ticket_number, t.location_public_id) < (u.last_ticket_number, u.location_public_id);
+ALTER TABLE ticket_counter
+DROP COLUMN location_public_id;
+ALTER TABLE ticket_counter
+ADD PRIMARY KEY (counter_date);
+
+ALTER TABLE service_logs
+DROP COLUMN location_public_id;
+ALTER TABLE visitors
+DROP COLUMN location_public_id;
+ALTER TABLE purposes
+DROP COLUMN location_public_id;
+ALTER TABLE desks
+DROP COLUMN location_public_id;
+
+DROP TABLE user_locations;
+DROP TABLE locations;
\ No newline at end of file
@@ -0,0 +1,46 @@
+-- +goose Up
+-- dates and opening hours are in the time zone of the location, an IANA name like Europe/Amsterdam
+ALTER TABLE locations
+ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC',
+ADD COLUMN stop_issuing_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- NULL for no limit
+ALTER TABLE purposes
+ADD COLUMN daily_capacity INTEGER;
+
+-- opens_at and closes_at are in minutes after midnight. rows without purpose apply to every purpose of the location
+-- that has no opening hours of its own. a location without opening hours is always open
+CREATE TABLE opening_hours (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    location_public_id TEXT NOT NULL REFERENCES locations (public_id) ON DELETE CASCADE,
+    purpose_public_id TEXT REFERENCES purposes (public_id) ON DELETE CASCADE,
+    weekday INTEGER NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_at INTEGER NOT NULL CHECK (opens_at >= 0),
+    closes_at INTEGER NOT NULL CHECK (closes_at > opens_at AND closes_at <= 1440)
+);
+CREATE INDEX idx_opening_hours_location_public_id ON opening_hours(location_public_id);
+
+CREATE TABLE holidays (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    location_public_id TEXT NOT NULL REFERENCES locations (public_id) ON DELETE CASCADE,
+    holiday_date DATE NOT NULL,
+    name TEXT NOT NULL,
+    UNIQUE (location_public_id, holiday_date)
+);
+
+-- +goose Down
+DROP TABLE holidays;
+DROP TABLE opening_hours;
+
+ALTER TABLE purposes
+DROP COLUMN daily_capacity;
+
+ALTER TABLE locations
+DROP COLUMN stop_issuing_minutes,
+DROP COLUMN time_zone;
\ No newline at end of file
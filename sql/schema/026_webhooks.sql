@@ -0,0 +1,37 @@
+-- +goose Up
+-- one subscription per event type, so a receiver subscribed to several event types has several subscriptions.
+-- the secret signs the payloads (HMAC-SHA256)
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_type TEXT NOT NULL
+);
+CREATE INDEX idx_webhook_subscriptions_event_type ON webhook_subscriptions(event_type);
+
+-- the delivery queue and log: a row per event per subscription, written in the transaction of the change that
+-- caused the event. status is pending until delivered or out of attempts (failed)
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    subscription_public_id TEXT NOT NULL REFERENCES webhook_subscriptions (public_id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL,
+    response_status INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMP
+);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX idx_webhook_deliveries_subscription_public_id ON webhook_deliveries(subscription_public_id);
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- +goose Up
+-- contact details visitors may leave to be told when they are called. personal data, like the name of the visitor
+ALTER TABLE visitors
+ADD COLUMN phone_number TEXT,
+ADD COLUMN email TEXT,
+ADD COLUMN notified_at TIMESTAMP; -- when the visitor was told its turn is near, NULL until then
+
+-- +goose Down
+ALTER TABLE visitors
+DROP COLUMN notified_at,
+DROP COLUMN email,
+DROP COLUMN phone_number;
\ No newline at end of file
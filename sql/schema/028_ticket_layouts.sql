@@ -0,0 +1,14 @@
+-- +goose Up
+-- what a purpose prints on its tickets besides the ticket itself: free text above and below it and a logo (PNG, JPEG
+-- or GIF). Purposes without a row print plain tickets
+CREATE TABLE ticket_layouts (
+    purpose_public_id TEXT PRIMARY KEY REFERENCES purposes (public_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    header TEXT NOT NULL,
+    footer TEXT NOT NULL,
+    logo BYTEA
+);
+
+-- +goose Down
+DROP TABLE ticket_layouts;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- +goose Up
+-- how display screens announce called visitors, per language. rows without purpose apply to every purpose of the
+-- location that has no template of its own in that language. an empty template stands for the built-in one of the
+-- language. a location without templates is announced in English
+CREATE TABLE announcement_templates (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    location_public_id TEXT NOT NULL REFERENCES locations (public_id) ON DELETE CASCADE,
+    purpose_public_id TEXT REFERENCES purposes (public_id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    template TEXT NOT NULL
+);
+CREATE INDEX idx_announcement_templates_location_public_id ON announcement_templates(location_public_id);
+
+-- +goose Down
+DROP TABLE announcement_templates;
\ No newline at end of file
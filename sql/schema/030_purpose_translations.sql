@@ -0,0 +1,14 @@
+-- +goose Up
+-- names of purposes in other languages than the one of purpose_name, for kiosks, tickets and display screens. one
+-- row per language
+CREATE TABLE purpose_translations (
+    purpose_public_id TEXT NOT NULL REFERENCES purposes (public_id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (purpose_public_id, language)
+);
+
+-- +goose Down
+DROP TABLE purpose_translations;
\ No newline at end of file
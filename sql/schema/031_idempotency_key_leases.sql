@@ -0,0 +1,11 @@
+-- +goose Up
+-- a claimed key without a response is in flight until locked_until, after which a retry may claim it again: the
+-- request that claimed it crashed. NULL for keys claimed before this migration
+ALTER TABLE idempotency_keys
+ADD COLUMN locked_until TIMESTAMP,
+ADD COLUMN response_headers BYTEA; -- JSON object of the replayed response headers, like Content-Type
+
+-- +goose Down
+ALTER TABLE idempotency_keys
+DROP COLUMN response_headers,
+DROP COLUMN locked_until;
@@ -0,0 +1,61 @@
+-- name: CreateDesks :one
+INSERT INTO desks (id, public_id, created_at, updated_at, name, description, is_active, location_public_id)
+VALUES (
+    gen_random_uuid(),
+    ?1,
+    NOW(),
+    NOW(),
+    ?2,
+    ?3,
+    TRUE,
+    ?4
+)
+RETURNING *;
+
+-- name: GetDesksByPublicID :one
+SELECT * FROM desks
+WHERE public_id = ?1;
+
+-- name: GetDesks :many
+SELECT * FROM desks;
+
+-- name: GetActiveDesks :many
+SELECT * FROM desks
+WHERE is_active = TRUE;
+
+-- name: SetDesksByPublicID :one
+UPDATE desks
+SET name = ?2, description = ?3, is_active = ?4, updated_at = NOW()
+WHERE public_id = ?1 AND (sqlc.narg('if_updated_at') IS NULL OR updated_at = sqlc.narg('if_updated_at'))
+RETURNING *;
+
+-- name: ListDesks :many
+SELECT * FROM desks
+WHERE (sqlc.narg('is_active') IS NULL OR is_active = sqlc.narg('is_active'))
+    AND (sqlc.narg('location_public_id') IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id') IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')))
+    AND (sqlc.narg('after_public_id') IS NULL
+        OR (sqlc.arg('sort') = 'name' AND (
+            CASE WHEN sqlc.arg('descending') THEN name < sqlc.narg('after_text') ELSE name > sqlc.narg('after_text') END
+            OR (name = sqlc.narg('after_text') AND public_id > sqlc.narg('after_public_id'))))
+        OR (sqlc.arg('sort') = 'created_at' AND (
+            CASE WHEN sqlc.arg('descending') THEN created_at < sqlc.narg('after_time') ELSE created_at > sqlc.narg('after_time') END
+            OR (created_at = sqlc.narg('after_time') AND public_id > sqlc.narg('after_public_id'))))
+        OR (sqlc.arg('sort') = 'updated_at' AND (
+            CASE WHEN sqlc.arg('descending') THEN updated_at < sqlc.narg('after_time') ELSE updated_at > sqlc.narg('after_time') END
+            OR (updated_at = sqlc.narg('after_time') AND public_id > sqlc.narg('after_public_id')))))
+ORDER BY
+    CASE WHEN sqlc.arg('sort') = 'name' AND NOT sqlc.arg('descending') THEN name END ASC,
+    CASE WHEN sqlc.arg('sort') = 'name' AND sqlc.arg('descending') THEN name END DESC,
+    CASE WHEN sqlc.arg('sort') = 'created_at' AND NOT sqlc.arg('descending') THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort') = 'created_at' AND sqlc.arg('descending') THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort') = 'updated_at' AND NOT sqlc.arg('descending') THEN updated_at END ASC,
+    CASE WHEN sqlc.arg('sort') = 'updated_at' AND sqlc.arg('descending') THEN updated_at END DESC,
+    public_id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: CountDesks :one
+SELECT COUNT(*) FROM desks
+WHERE (sqlc.narg('is_active') IS NULL OR is_active = sqlc.narg('is_active'))
+    AND (sqlc.narg('location_public_id') IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id') IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')));
\ No newline at end of file
@@ -0,0 +1,27 @@
+
+
+-- name: CreateHoliday :one
+INSERT INTO holidays (id, public_id, created_at, updated_at, location_public_id, holiday_date, name)
+VALUES (
+    gen_random_uuid(),
+    ?1,
+    NOW(),
+    NOW(),
+    ?2,
+    date(?3),
+    ?4
+)
+RETURNING *;
+
+-- name: GetHolidayByPublicID :one
+SELECT * FROM holidays
+WHERE public_id = ?1;
+
+-- name: GetHolidaysByLocationPublicID :many
+SELECT * FROM holidays
+WHERE location_public_id = ?1
+ORDER BY holiday_date ASC;
+
+-- name: DeleteHolidayByPublicID :exec
+DELETE FROM holidays
+WHERE public_id = ?1;
\ No newline at end of file
@@ -0,0 +1,30 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE attempt_key = ?1;
+
+-- name: FailLoginAttempt :one
+-- see sql/queries/login_attempts.sql. strftime only has milliseconds, padded to the microseconds of sqliteTimeFormat
+INSERT INTO login_attempts (attempt_key, failed_attempts, last_failed_at, blocked_until, is_locked_out)
+VALUES (
+    sqlc.arg('attempt_key'),
+    1,
+    sqlc.arg('now'),
+    CASE WHEN sqlc.arg('max_attempts') = 1 THEN sqlc.arg('locked_until')
+        ELSE strftime('%Y-%m-%d %H:%M:%f', sqlc.arg('now'), '+' || MIN(sqlc.arg('base_delay'), sqlc.arg('max_delay')) || ' seconds') || '000' END,
+    sqlc.arg('max_attempts') = 1
+)
+ON CONFLICT (attempt_key)
+DO UPDATE SET
+  failed_attempts = CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before') THEN 1 ELSE login_attempts.failed_attempts + 1 END,
+  last_failed_at = sqlc.arg('now'),
+  blocked_until = CASE
+    WHEN sqlc.arg('max_attempts') > 0 AND CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before') THEN 1 ELSE login_attempts.failed_attempts + 1 END >= sqlc.arg('max_attempts') THEN sqlc.arg('locked_until')
+    ELSE strftime('%Y-%m-%d %H:%M:%f', sqlc.arg('now'), '+' || MIN(sqlc.arg('base_delay') * (1 << MIN(CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before') THEN 0 ELSE login_attempts.failed_attempts END, 62)), sqlc.arg('max_delay')) || ' seconds') || '000'
+  END,
+  is_locked_out = sqlc.arg('max_attempts') > 0 AND CASE WHEN login_attempts.is_locked_out OR login_attempts.last_failed_at < sqlc.arg('forget_before') THEN 1 ELSE login_attempts.failed_attempts + 1 END >= sqlc.arg('max_attempts')
+WHERE login_attempts.blocked_until <= sqlc.arg('now') OR NOT (sqlc.arg('only_unblocked') OR login_attempts.is_locked_out)
+RETURNING *;
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE attempt_key = ?1;
\ No newline at end of file
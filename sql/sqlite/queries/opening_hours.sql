@@ -0,0 +1,29 @@
+
+
+-- name: CreateOpeningHour :one
+INSERT INTO opening_hours (id, public_id, created_at, updated_at, location_public_id, purpose_public_id, weekday, opens_at, closes_at)
+VALUES (
+    gen_random_uuid(),
+    ?1,
+    NOW(),
+    NOW(),
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6
+)
+RETURNING *;
+
+-- name: GetOpeningHourByPublicID :one
+SELECT * FROM opening_hours
+WHERE public_id = ?1;
+
+-- name: GetOpeningHoursByLocationPublicID :many
+SELECT * FROM opening_hours
+WHERE location_public_id = ?1
+ORDER BY weekday ASC, opens_at ASC, public_id ASC;
+
+-- name: DeleteOpeningHourByPublicID :exec
+DELETE FROM opening_hours
+WHERE public_id = ?1;
\ No newline at end of file
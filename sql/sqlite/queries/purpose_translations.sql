@@ -0,0 +1,25 @@
+
+
+-- name: CreatePurposeTranslation :one
+INSERT INTO purpose_translations (purpose_public_id, language, created_at, updated_at, name)
+VALUES (
+    ?1,
+    ?2,
+    NOW(),
+    NOW(),
+    ?3
+)
+RETURNING *;
+
+-- name: GetPurposeTranslations :many
+SELECT * FROM purpose_translations
+ORDER BY purpose_public_id ASC, language ASC;
+
+-- name: GetPurposeTranslationsByPurposePublicID :many
+SELECT * FROM purpose_translations
+WHERE purpose_public_id = ?1
+ORDER BY language ASC;
+
+-- name: DeletePurposeTranslationsByPurposePublicID :exec
+DELETE FROM purpose_translations
+WHERE purpose_public_id = ?1;
\ No newline at end of file
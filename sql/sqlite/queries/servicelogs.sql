@@ -0,0 +1,97 @@
+-- name: GetServiceLogs :many
+SELECT * FROM service_logs;
+
+-- name: GetServiceLogsByPublicID :one
+SELECT * FROM service_logs
+WHERE public_id = ?1;
+
+-- name: GetActiveServiceLogs :many
+SELECT * FROM service_logs
+WHERE is_active = true;
+
+-- name: GetActiveServiceLogsByUserID :many
+SELECT * FROM service_logs
+where is_active = true AND user_public_id = ?1;
+
+-- name: CreateServiceLogs :one
+INSERT INTO service_logs (id, public_id, created_at, updated_at, visitor_public_id, user_public_id, desk_public_id, called_at, is_active, location_public_id)
+VALUES (
+    gen_random_uuid(),
+    ?1,
+    ?6, -- stamped by the caller in UTC, like the times it is compared with
+    ?6,
+    ?2,
+    ?3,
+    ?4,
+    ?6,
+    true,
+    ?5
+)
+RETURNING *;
+
+-- name: SetServiceLogsByPublicID :one
+UPDATE service_logs
+SET visitor_public_id = ?2, user_public_id = ?3, desk_public_id = ?4, is_active = ?5, location_public_id = ?6, updated_at = NOW()
+WHERE public_id = ?1
+RETURNING *;
+
+-- name: ListServiceLogs :many
+SELECT * FROM service_logs
+WHERE (sqlc.narg('user_public_id') IS NULL OR user_public_id = sqlc.narg('user_public_id'))
+    AND (sqlc.narg('visitor_public_id') IS NULL OR visitor_public_id = sqlc.narg('visitor_public_id'))
+    AND (sqlc.narg('desk_public_id') IS NULL OR desk_public_id = sqlc.narg('desk_public_id'))
+    AND (sqlc.narg('start_date') IS NULL OR created_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date') IS NULL OR created_at < sqlc.narg('end_date'))
+    AND (sqlc.narg('location_public_id') IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id') IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')))
+    AND (sqlc.narg('after_public_id') IS NULL
+        OR (sqlc.arg('sort') = 'created_at' AND (
+            CASE WHEN sqlc.arg('descending') THEN created_at < sqlc.narg('after_time') ELSE created_at > sqlc.narg('after_time') END
+            OR (created_at = sqlc.narg('after_time') AND public_id > sqlc.narg('after_public_id'))))
+        OR (sqlc.arg('sort') = 'called_at' AND (
+            CASE WHEN sqlc.arg('descending') THEN called_at < sqlc.narg('after_time') ELSE called_at > sqlc.narg('after_time') END
+            OR (called_at = sqlc.narg('after_time') AND public_id > sqlc.narg('after_public_id'))))
+        OR (sqlc.arg('sort') = 'updated_at' AND (
+            CASE WHEN sqlc.arg('descending') THEN updated_at < sqlc.narg('after_time') ELSE updated_at > sqlc.narg('after_time') END
+            OR (updated_at = sqlc.narg('after_time') AND public_id > sqlc.narg('after_public_id')))))
+ORDER BY
+    CASE WHEN sqlc.arg('sort') = 'created_at' AND NOT sqlc.arg('descending') THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort') = 'created_at' AND sqlc.arg('descending') THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort') = 'called_at' AND NOT sqlc.arg('descending') THEN called_at END ASC,
+    CASE WHEN sqlc.arg('sort') = 'called_at' AND sqlc.arg('descending') THEN called_at END DESC,
+    CASE WHEN sqlc.arg('sort') = 'updated_at' AND NOT sqlc.arg('descending') THEN updated_at END ASC,
+    CASE WHEN sqlc.arg('sort') = 'updated_at' AND sqlc.arg('descending') THEN updated_at END DESC,
+    public_id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: CountServiceLogs :one
+SELECT COUNT(*) FROM service_logs
+WHERE (sqlc.narg('user_public_id') IS NULL OR user_public_id = sqlc.narg('user_public_id'))
+    AND (sqlc.narg('visitor_public_id') IS NULL OR visitor_public_id = sqlc.narg('visitor_public_id'))
+    AND (sqlc.narg('desk_public_id') IS NULL OR desk_public_id = sqlc.narg('desk_public_id'))
+    AND (sqlc.narg('start_date') IS NULL OR created_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date') IS NULL OR created_at < sqlc.narg('end_date'))
+    AND (sqlc.narg('location_public_id') IS NULL OR location_public_id = sqlc.narg('location_public_id'))
+    AND (sqlc.narg('member_public_id') IS NULL OR location_public_id IN (SELECT location_public_id FROM user_locations WHERE user_public_id = sqlc.narg('member_public_id')));
+
+-- name: DeleteServiceLogsOfVisitorsCreatedBefore :execrows
+DELETE FROM service_logs
+WHERE visitor_public_id IN (SELECT public_id FROM visitors WHERE created_at < sqlc.arg('created_before'));
+
+-- name: GetServiceLogsByVisitorPublicID :many
+SELECT * FROM service_logs
+WHERE visitor_public_id = ?1
+ORDER BY created_at ASC;
+
+-- name: GetCallTimesByPurposePublicID :many
+SELECT service_logs.called_at FROM service_logs
+JOIN visitors ON visitors.public_id = service_logs.visitor_public_id
+WHERE visitors.purpose_public_id = ?1 AND service_logs.called_at >= ?2
+ORDER BY service_logs.called_at DESC
+LIMIT ?3;
+
+-- name: GetActiveServiceLogsByLocationPublicID :many
+SELECT * FROM service_logs
+WHERE location_public_id = ?1 AND is_active = TRUE AND called_at >= ?2
+ORDER BY called_at DESC, public_id DESC
+LIMIT ?3;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- name: UpdateTicketCounter :one
+INSERT INTO ticket_counter (location_public_id, counter_date, last_ticket_number)
+VALUES (?1, date(?2), 1)
+ON CONFLICT (location_public_id, counter_date)
+DO UPDATE SET
+  last_ticket_number = ticket_counter.last_ticket_number + 1
+RETURNING last_ticket_number;
\ No newline at end of file
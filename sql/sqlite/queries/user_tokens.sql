@@ -0,0 +1,25 @@
+-- name: CreateUserToken :one
+INSERT INTO user_tokens (id, public_id, created_at, updated_at, user_public_id, kind, token_hash, expires_at, used_at)
+VALUES (
+    gen_random_uuid(),
+    ?1,
+    NOW(),
+    NOW(),
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    NULL
+)
+RETURNING *;
+
+-- name: ConsumeUserToken :one
+UPDATE user_tokens
+SET used_at = NOW(), updated_at = NOW()
+WHERE token_hash = ?1 AND kind = ?2 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: InvalidateUserTokens :exec
+UPDATE user_tokens
+SET used_at = NOW(), updated_at = NOW()
+WHERE user_public_id = ?1 AND kind = ?2 AND used_at IS NULL;
\ No newline at end of file
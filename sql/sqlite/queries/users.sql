@@ -0,0 +1,99 @@
+-- name: CreateUser :one
+INSERT INTO users (id, public_id,created_at, updated_at, email, hashed_password, full_name, is_admin, is_active)
+VALUES (
+    gen_random_uuid(),
+    ?1,
+    NOW(),
+    NOW(),
+    ?2,
+    ?3,
+    ?4,
+    FALSE,
+    TRUE
+)
+RETURNING *;
+
+-- name: GetUsers :many
+SELECT * FROM users;
+
+-- name: GetUserByPublicID :one
+SELECT * FROM users
+WHERE public_id = ?1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = ?1;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+where id = ?1;
+
+-- name: SetUserByPublicID :one
+UPDATE users
+SET email = ?2, full_name = ?3, is_admin = ?4, is_active = ?5, updated_at = NOW()
+WHERE public_id = ?1
+returning *;
+
+-- name: SetUserEmailPasswordByID :one
+UPDATE users
+SET email = ?2, hashed_password = ?3, updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
+
+-- name: SetUserFullNameByID :one
+UPDATE users
+SET full_name = ?2, updated_at = NOW()
+where id = ?1
+RETURNING *;
+
+-- name: SetUserIsAdminByID :one
+UPDATE users
+SET is_admin = ?2, updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
+
+-- name: SetUserInactiveByID :one
+UPDATE users
+SET is_active = ?2, updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
+
+-- name: DeleteUserByID :one
+DELETE FROM users
+WHERE id = ?1
+RETURNING *;
+
+-- name: SetUserPasswordByPublicID :one
+UPDATE users
+SET hashed_password = ?2, updated_at = NOW()
+WHERE public_id = ?1
+RETURNING *;
+
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE (sqlc.narg('is_active') IS NULL OR is_active = sqlc.narg('is_active'))
+    AND (sqlc.narg('is_admin') IS NULL OR is_admin = sqlc.narg('is_admin'))
+    AND (sqlc.narg('after_public_id') IS NULL
+        OR (sqlc.arg('sort') = 'created_at' AND (
+            CASE WHEN sqlc.arg('descending') THEN created_at < sqlc.narg('after_time') ELSE created_at > sqlc.narg('after_time') END
+            OR (created_at = sqlc.narg('after_time') AND public_id > sqlc.narg('after_public_id'))))
+        OR (sqlc.arg('sort') = 'email' AND (
+            CASE WHEN sqlc.arg('descending') THEN email < sqlc.narg('after_text') ELSE email > sqlc.narg('after_text') END
+            OR (email = sqlc.narg('after_text') AND public_id > sqlc.narg('after_public_id'))))
+        OR (sqlc.arg('sort') = 'full_name' AND (
+            CASE WHEN sqlc.arg('descending') THEN full_name < sqlc.narg('after_text') ELSE full_name > sqlc.narg('after_text') END
+            OR (full_name = sqlc.narg('after_text') AND public_id > sqlc.narg('after_public_id')))))
+ORDER BY
+    CASE WHEN sqlc.arg('sort') = 'created_at' AND NOT sqlc.arg('descending') THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort') = 'created_at' AND sqlc.arg('descending') THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort') = 'email' AND NOT sqlc.arg('descending') THEN email END ASC,
+    CASE WHEN sqlc.arg('sort') = 'email' AND sqlc.arg('descending') THEN email END DESC,
+    CASE WHEN sqlc.arg('sort') = 'full_name' AND NOT sqlc.arg('descending') THEN full_name END ASC,
+    CASE WHEN sqlc.arg('sort') = 'full_name' AND sqlc.arg('descending') THEN full_name END DESC,
+    public_id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+WHERE (sqlc.narg('is_active') IS NULL OR is_active = sqlc.narg('is_active'))
+    AND (sqlc.narg('is_admin') IS NULL OR is_admin = sqlc.narg('is_admin'));
\ No newline at end of file
@@ -0,0 +1,141 @@
+-- +goose Up
+-- the schema of sql/schema (PostgreSQL) up to 022_ticket_counter_counter_date.sql in a single migration. UUIDs and
+-- timestamps are stored as TEXT, timestamps in UTC as written by the NOW() function registered in storage/sqlite.go.
+CREATE TABLE desks (
+    id UUID PRIMARY KEY,
+    description TEXT,
+    is_active BOOLEAN NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    hashed_password TEXT NOT NULL,
+    is_admin BOOLEAN NOT NULL,
+    is_active BOOLEAN NOT NULL,
+    desk_id UUID REFERENCES desks (id) ON DELETE CASCADE,
+    full_name TEXT NOT NULL,
+    public_id TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE refresh_tokens (
+    token TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    public_id TEXT UNIQUE NOT NULL,
+    user_public_id TEXT NOT NULL REFERENCES users (public_id),
+    last_used_at TIMESTAMP NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE purposes (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    purpose_name TEXT NOT NULL,
+    parent_purpose_id UUID REFERENCES purposes (id) ON DELETE CASCADE,
+    public_id TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE visitors (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    waiting_since TIMESTAMP NOT NULL,
+    name TEXT,
+    status INTEGER NOT NULL,
+    daily_ticket_number INTEGER NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    purpose_public_id TEXT NOT NULL REFERENCES purposes (public_id)
+);
+
+CREATE TABLE service_logs (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    called_at TIMESTAMP NOT NULL,
+    is_active BOOLEAN NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    user_public_id TEXT NOT NULL REFERENCES users (public_id),
+    visitor_public_id TEXT NOT NULL REFERENCES visitors (public_id),
+    desk_public_id TEXT NOT NULL REFERENCES desks (public_id)
+);
+
+CREATE TABLE ticket_counter (
+    counter_date DATE PRIMARY KEY,
+    last_ticket_number INTEGER NOT NULL
+);
+
+CREATE TABLE login_attempts (
+    attempt_key TEXT PRIMARY KEY,
+    failed_attempts INTEGER NOT NULL,
+    last_failed_at TIMESTAMP NOT NULL,
+    blocked_until TIMESTAMP NOT NULL,
+    is_locked_out BOOLEAN NOT NULL
+);
+
+CREATE TABLE user_tokens (
+    id UUID PRIMARY KEY,
+    public_id TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_public_id TEXT NOT NULL REFERENCES users (public_id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+CREATE INDEX idx_user_tokens_user_public_id ON user_tokens(user_public_id);
+
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    actor_public_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_public_id TEXT NOT NULL,
+    diff BLOB NOT NULL, -- JSON
+    request_id TEXT NOT NULL,
+    ip_address TEXT NOT NULL
+);
+
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
+CREATE INDEX audit_events_actor_idx ON audit_events (actor_public_id);
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_public_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_append_only_update
+BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_append_only_delete
+BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE audit_events;
+DROP TABLE user_tokens;
+DROP TABLE login_attempts;
+DROP TABLE ticket_counter;
+DROP TABLE service_logs;
+DROP TABLE visitors;
+DROP TABLE purposes;
+DROP TABLE refresh_tokens;
+DROP TABLE users;
+DROP TABLE desks;
\ No newline at end of file
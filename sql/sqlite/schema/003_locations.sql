@@ -0,0 +1,86 @@
+-- +goose Up
+CREATE TABLE locations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE user_locations (
+    user_public_id TEXT NOT NULL REFERENCES users (public_id) ON DELETE CASCADE,
+    location_public_id TEXT NOT NULL REFERENCES locations (public_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_public_id, location_public_id)
+);
+
+-- see 024_locations.sql of the PostgreSQL schema
+INSERT INTO locations (id, created_at, updated_at, public_id, name)
+SELECT gen_random_uuid(), NOW(), NOW(), substr(lower(hex(randomblob(128))), 1, length(public_id)), 'Default'
+FROM (
+    SELECT public_id FROM users
+    UNION ALL SELECT public_id FROM desks
+    UNION ALL SELECT public_id FROM purposes
+) existing
+LIMIT 1;
+
+INSERT INTO user_locations (user_public_id, location_public_id)
+SELECT users.public_id, locations.public_id FROM users, locations;
+
+-- SQLite cannot add a NOT NULL column with a foreign key to an existing table. goqueue always sets it.
+ALTER TABLE desks
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE desks SET location_public_id = (SELECT public_id FROM locations);
+CREATE INDEX idx_desks_location_public_id ON desks(location_public_id);
+
+ALTER TABLE purposes
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE purposes SET location_public_id = (SELECT public_id FROM locations);
+CREATE INDEX idx_purposes_location_public_id ON purposes(location_public_id);
+
+ALTER TABLE visitors
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE visitors SET location_public_id = (SELECT public_id FROM locations);
+CREATE INDEX idx_visitors_location_public_id ON visitors(location_public_id);
+
+ALTER TABLE service_logs
+ADD COLUMN location_public_id TEXT REFERENCES locations (public_id);
+UPDATE service_logs SET location_public_id = (SELECT public_id FROM locations);
+CREATE INDEX idx_service_logs_location_public_id ON service_logs(location_public_id);
+
+-- the primary key changes, which takes a new table
+CREATE TABLE ticket_counter_by_location (
+    counter_date DATE NOT NULL,
+    last_ticket_number INTEGER NOT NULL,
+    location_public_id TEXT NOT NULL REFERENCES locations (public_id),
+    PRIMARY KEY (location_public_id, counter_date)
+);
+INSERT INTO ticket_counter_by_location (counter_date, last_ticket_number, location_public_id)
+SELECT counter_date, last_ticket_number, locations.public_id FROM ticket_counter, locations;
+DROP TABLE ticket_counter;
+ALTER TABLE ticket_counter_by_location RENAME TO ticket_counter;
+
+-- +goose Down
+CREATE TABLE ticket_counter_by_date (
+    counter_date DATE PRIMARY KEY,
+    last_ticket_number INTEGER NOT NULL
+);
+INSERT INTO ticket_counter_by_date (counter_date, last_ticket_number)
+SELECT counter_date, MAX(last_ticket_number) FROM ticket_counter GROUP BY counter_date;
+DROP TABLE ticket_counter;
+ALTER TABLE ticket_counter_by_date RENAME TO ticket_counter;
+
+DROP INDEX idx_service_logs_location_public_id;
+ALTER TABLE service_logs
+DROP COLUMN location_public_id;
+DROP INDEX idx_visitors_location_public_id;
+ALTER TABLE visitors
+DROP COLUMN location_public_id;
+DROP INDEX idx_purposes_location_public_id;
+ALTER TABLE purposes
+DROP COLUMN location_public_id;
+DROP INDEX idx_desks_location_public_id;
+ALTER TABLE desks
+DROP COLUMN location_public_id;
+
+DROP TABLE user_locations;
+DROP TABLE locations;
\ No newline at end of file
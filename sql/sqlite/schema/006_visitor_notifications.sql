@@ -0,0 +1,16 @@
+-- +goose Up
+-- see 027_visitor_notifications.sql of the PostgreSQL schema
+ALTER TABLE visitors
+ADD COLUMN phone_number TEXT;
+ALTER TABLE visitors
+ADD COLUMN email TEXT;
+ALTER TABLE visitors
+ADD COLUMN notified_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE visitors
+DROP COLUMN notified_at;
+ALTER TABLE visitors
+DROP COLUMN email;
+ALTER TABLE visitors
+DROP COLUMN phone_number;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+-- see 028_ticket_layouts.sql of the PostgreSQL schema
+CREATE TABLE ticket_layouts (
+    purpose_public_id TEXT PRIMARY KEY REFERENCES purposes (public_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    header TEXT NOT NULL,
+    footer TEXT NOT NULL,
+    logo BLOB
+);
+
+-- +goose Down
+DROP TABLE ticket_layouts;
\ No newline at end of file
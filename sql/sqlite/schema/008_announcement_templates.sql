@@ -0,0 +1,16 @@
+-- +goose Up
+-- see 029_announcement_templates.sql of the PostgreSQL schema
+CREATE TABLE announcement_templates (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    public_id TEXT UNIQUE NOT NULL,
+    location_public_id TEXT NOT NULL REFERENCES locations (public_id) ON DELETE CASCADE,
+    purpose_public_id TEXT REFERENCES purposes (public_id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    template TEXT NOT NULL
+);
+CREATE INDEX idx_announcement_templates_location_public_id ON announcement_templates(location_public_id);
+
+-- +goose Down
+DROP TABLE announcement_templates;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+-- see 030_purpose_translations.sql of the PostgreSQL schema
+CREATE TABLE purpose_translations (
+    purpose_public_id TEXT NOT NULL REFERENCES purposes (public_id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (purpose_public_id, language)
+);
+
+-- +goose Down
+DROP TABLE purpose_translations;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- +goose Up
+-- see 031_idempotency_key_leases.sql of the PostgreSQL schema
+ALTER TABLE idempotency_keys
+ADD COLUMN locked_until TIMESTAMP;
+ALTER TABLE idempotency_keys
+ADD COLUMN response_headers BLOB;
+
+-- +goose Down
+ALTER TABLE idempotency_keys
+DROP COLUMN response_headers;
+ALTER TABLE idempotency_keys
+DROP COLUMN locked_until;
@@ -0,0 +1,1897 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/google/uuid"
+)
+
+// Memory is an in-memory Store with the semantics of the queries in sql/queries: generated IDs and timestamps,
+// unique and foreign key constraints, filters, ordering and sql.ErrNoRows for :one queries without a result.
+// Transactions are serialized and rolled back by restoring a snapshot.
+type Memory struct {
+	mu   *sync.Mutex // shared with the transactions of this store
+	inTx bool        // true for the Store passed to an InTx callback, which already holds mu
+	data *memoryData
+	now  func() time.Time
+}
+
+type memoryData struct {
+	announcementTemplates []database.AnnouncementTemplate
+	auditEvents           []database.AuditEvent
+	desks                 []database.Desk
+	holidays              []database.Holiday
+	idempotencyKeys       []database.IdempotencyKey
+	locations             []database.Location
+	loginAttempts         []database.LoginAttempt
+	openingHours          []database.OpeningHour
+	purposes              []database.Purpose
+	purposeTranslations   []database.PurposeTranslation
+	refreshTokens         []database.RefreshToken
+	serviceLogs           []database.ServiceLog
+	ticketCounters        map[string]int32 // keyed by location public ID and date (YYYY-MM-DD)
+	ticketLayouts         []database.TicketLayout
+	users                 []database.User
+	userLocations         []database.UserLocation
+	userTokens            []database.UserToken
+	visitors              []database.Visitor
+	webhookDeliveries     []database.WebhookDelivery
+	webhookSubscriptions  []database.WebhookSubscription
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		mu:   &sync.Mutex{},
+		data: &memoryData{ticketCounters: make(map[string]int32)},
+		now: func() time.Time {
+			return time.Now().Truncate(time.Microsecond) // the precision of a postgres TIMESTAMP
+		},
+	}
+}
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		announcementTemplates: slices.Clone(d.announcementTemplates),
+		auditEvents:           slices.Clone(d.auditEvents),
+		desks:                 slices.Clone(d.desks),
+		holidays:              slices.Clone(d.holidays),
+		idempotencyKeys:       slices.Clone(d.idempotencyKeys),
+		locations:             slices.Clone(d.locations),
+		loginAttempts:         slices.Clone(d.loginAttempts),
+		openingHours:          slices.Clone(d.openingHours),
+		purposes:              slices.Clone(d.purposes),
+		purposeTranslations:   slices.Clone(d.purposeTranslations),
+		refreshTokens:         slices.Clone(d.refreshTokens),
+		serviceLogs:           slices.Clone(d.serviceLogs),
+		ticketCounters:        maps.Clone(d.ticketCounters),
+		ticketLayouts:         slices.Clone(d.ticketLayouts),
+		users:                 slices.Clone(d.users),
+		userLocations:         slices.Clone(d.userLocations),
+		userTokens:            slices.Clone(d.userTokens),
+		visitors:              slices.Clone(d.visitors),
+		webhookDeliveries:     slices.Clone(d.webhookDeliveries),
+		webhookSubscriptions:  slices.Clone(d.webhookSubscriptions),
+	}
+}
+
+func (m *Memory) InTx(ctx context.Context, fn func(s Store) error) error {
+	if m.inTx {
+		return fn(m)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := m.data.clone()
+	tx := &Memory{mu: m.mu, inTx: true, data: m.data, now: m.now}
+	if err := fn(tx); err != nil {
+		*m.data = *snapshot
+		return err
+	}
+	return nil
+}
+
+func (m *Memory) lock() (unlock func()) {
+	// usage: defer m.lock()()
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+func constraintError(constraint string) error {
+	return fmt.Errorf("storage: violates constraint %q", constraint)
+}
+
+func first[T any](rows []T, match func(T) bool) (int, error) {
+	for i, row := range rows {
+		if match(row) {
+			return i, nil
+		}
+	}
+	return -1, sql.ErrNoRows
+}
+
+func exists[T any](rows []T, match func(T) bool) bool {
+	_, err := first(rows, match)
+	return err == nil
+}
+
+func where[T any](rows []T, match func(T) bool) []T {
+	// like sqlc, an empty result is a nil slice
+	var items []T
+	for _, row := range rows {
+		if match(row) {
+			items = append(items, row)
+		}
+	}
+	return items
+}
+
+func all[T any](T) bool {
+	return true
+}
+
+func matchNullString(filter sql.NullString, value string) bool {
+	return !filter.Valid || filter.String == value
+}
+
+func matchDateRange(startDate, endDate sql.NullTime, value time.Time) bool {
+	return (!startDate.Valid || !value.Before(startDate.Time)) && (!endDate.Valid || value.Before(endDate.Time))
+}
+
+func matchNullBool(filter sql.NullBool, value bool) bool {
+	return !filter.Valid || filter.Bool == value
+}
+
+func matchNullTime(filter sql.NullTime, value time.Time) bool {
+	return !filter.Valid || filter.Time.Equal(value)
+}
+
+func (m *Memory) matchLocation(location, member sql.NullString, value string) bool {
+	// the location_public_id and member_public_id filters of the List and Count queries, the caller holds the lock
+	return matchNullString(location, value) && (!member.Valid || exists(m.data.userLocations, func(ul database.UserLocation) bool {
+		return ul.UserPublicID == member.String && ul.LocationPublicID == value
+	}))
+}
+
+func (m *Memory) locationExists(publicID string) bool {
+	return exists(m.data.locations, func(l database.Location) bool { return l.PublicID == publicID })
+}
+
+// sortKey is the value of the sort column of a row, or of the cursor of a List query. Only the field matching the
+// type of the column is set, so comparing all of them compares that one.
+type sortKey struct {
+	time time.Time
+	text string
+	int  int32
+}
+
+func (k sortKey) compare(other sortKey) int {
+	if c := k.time.Compare(other.time); c != 0 {
+		return c
+	}
+	if c := compareStrings(k.text, other.text); c != 0 {
+		return c
+	}
+	return cmp.Compare(k.int, other.int)
+}
+
+// keyset holds the pagination arguments of a List query
+type keyset struct {
+	afterPublicID sql.NullString
+	sort          string
+	descending    bool
+	after         sortKey
+	rowLimit      int32
+}
+
+func keysetPage[T any](rows []T, k keyset, publicID func(T) string, column func(row T, sort string) sortKey) []T {
+	/*
+		The keyset pagination of the List queries: rows ordered by the sort column and then by public ID, starting
+		after the row of the cursor, at most rowLimit of them.
+	*/
+	compare := func(a, b T) int {
+		c := column(a, k.sort).compare(column(b, k.sort))
+		if k.descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+		return compareStrings(publicID(a), publicID(b))
+	}
+	slices.SortFunc(rows, compare)
+	if k.afterPublicID.Valid {
+		rows = where(rows, func(row T) bool {
+			c := column(row, k.sort).compare(k.after)
+			if k.descending {
+				c = -c
+			}
+			return c > 0 || (c == 0 && publicID(row) > k.afterPublicID.String)
+		})
+	}
+	if len(rows) > int(k.rowLimit) {
+		rows = rows[:max(k.rowLimit, 0)]
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return rows
+}
+
+// announcement_templates
+
+func (m *Memory) CreateAnnouncementTemplate(ctx context.Context, arg database.CreateAnnouncementTemplateParams) (database.AnnouncementTemplate, error) {
+	defer m.lock()()
+	if exists(m.data.announcementTemplates, func(a database.AnnouncementTemplate) bool { return a.PublicID == arg.PublicID }) {
+		return database.AnnouncementTemplate{}, constraintError("announcement_templates_public_id_key")
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.AnnouncementTemplate{}, constraintError("announcement_templates_location_public_id_fkey")
+	}
+	if arg.PurposePublicID.Valid && !exists(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == arg.PurposePublicID.String }) {
+		return database.AnnouncementTemplate{}, constraintError("announcement_templates_purpose_public_id_fkey")
+	}
+	now := m.now()
+	a := database.AnnouncementTemplate{
+		ID:               uuid.New(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		PublicID:         arg.PublicID,
+		LocationPublicID: arg.LocationPublicID,
+		PurposePublicID:  arg.PurposePublicID,
+		Language:         arg.Language,
+		Template:         arg.Template,
+	}
+	m.data.announcementTemplates = append(m.data.announcementTemplates, a)
+	return a, nil
+}
+
+func (m *Memory) GetAnnouncementTemplateByPublicID(ctx context.Context, publicID string) (database.AnnouncementTemplate, error) {
+	defer m.lock()()
+	i, err := first(m.data.announcementTemplates, func(a database.AnnouncementTemplate) bool { return a.PublicID == publicID })
+	if err != nil {
+		return database.AnnouncementTemplate{}, err
+	}
+	return m.data.announcementTemplates[i], nil
+}
+
+func (m *Memory) GetAnnouncementTemplatesByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.AnnouncementTemplate, error) {
+	defer m.lock()()
+	items := where(m.data.announcementTemplates, func(a database.AnnouncementTemplate) bool { return a.LocationPublicID == locationPublicID })
+	slices.SortFunc(items, func(a, b database.AnnouncementTemplate) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), compareStrings(a.PublicID, b.PublicID))
+	})
+	return items, nil
+}
+
+func (m *Memory) DeleteAnnouncementTemplateByPublicID(ctx context.Context, publicID string) error {
+	defer m.lock()()
+	m.data.announcementTemplates = slices.DeleteFunc(m.data.announcementTemplates, func(a database.AnnouncementTemplate) bool { return a.PublicID == publicID })
+	return nil
+}
+
+// audit_events
+
+func (m *Memory) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) (database.AuditEvent, error) {
+	defer m.lock()()
+	i := database.AuditEvent{
+		ID:             uuid.New(),
+		CreatedAt:      m.now(),
+		ActorPublicID:  arg.ActorPublicID,
+		Action:         arg.Action,
+		EntityType:     arg.EntityType,
+		EntityPublicID: arg.EntityPublicID,
+		Diff:           slices.Clone(arg.Diff),
+		RequestID:      arg.RequestID,
+		IpAddress:      arg.IpAddress,
+	}
+	m.data.auditEvents = append(m.data.auditEvents, i)
+	return i, nil
+}
+
+func (m *Memory) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	defer m.lock()()
+	items := where(m.data.auditEvents, func(e database.AuditEvent) bool {
+		return matchNullString(arg.ActorPublicID, e.ActorPublicID) &&
+			matchNullString(arg.Action, e.Action) &&
+			matchNullString(arg.EntityType, e.EntityType) &&
+			matchNullString(arg.EntityPublicID, e.EntityPublicID) &&
+			matchDateRange(arg.StartDate, arg.EndDate, e.CreatedAt)
+	})
+	slices.SortStableFunc(items, func(a, b database.AuditEvent) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return items, nil
+}
+
+// desks
+
+func (m *Memory) CreateDesks(ctx context.Context, arg database.CreateDesksParams) (database.Desk, error) {
+	defer m.lock()()
+	if exists(m.data.desks, func(d database.Desk) bool { return d.PublicID == arg.PublicID }) {
+		return database.Desk{}, constraintError("desks_public_id_key")
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.Desk{}, constraintError("desks_location_public_id_fkey")
+	}
+	now := m.now()
+	i := database.Desk{
+		ID:               uuid.New(),
+		Description:      arg.Description,
+		IsActive:         true,
+		PublicID:         arg.PublicID,
+		Name:             arg.Name,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		LocationPublicID: arg.LocationPublicID,
+	}
+	m.data.desks = append(m.data.desks, i)
+	return i, nil
+}
+
+func (m *Memory) GetActiveDesks(ctx context.Context) ([]database.Desk, error) {
+	defer m.lock()()
+	return where(m.data.desks, func(d database.Desk) bool { return d.IsActive }), nil
+}
+
+func (m *Memory) GetDesks(ctx context.Context) ([]database.Desk, error) {
+	defer m.lock()()
+	return where(m.data.desks, all), nil
+}
+
+func (m *Memory) GetDesksByPublicID(ctx context.Context, publicID string) (database.Desk, error) {
+	defer m.lock()()
+	i, err := first(m.data.desks, func(d database.Desk) bool { return d.PublicID == publicID })
+	if err != nil {
+		return database.Desk{}, err
+	}
+	return m.data.desks[i], nil
+}
+
+func (m *Memory) matchDesk(arg database.CountDesksParams) func(database.Desk) bool {
+	// the filters shared by ListDesks and CountDesks
+	return func(d database.Desk) bool {
+		return matchNullBool(arg.IsActive, d.IsActive) && m.matchLocation(arg.LocationPublicID, arg.MemberPublicID, d.LocationPublicID)
+	}
+}
+
+func (m *Memory) ListDesks(ctx context.Context, arg database.ListDesksParams) ([]database.Desk, error) {
+	defer m.lock()()
+	items := where(m.data.desks, m.matchDesk(database.CountDesksParams{
+		IsActive:         arg.IsActive,
+		LocationPublicID: arg.LocationPublicID,
+		MemberPublicID:   arg.MemberPublicID,
+	}))
+	k := keyset{arg.AfterPublicID, arg.Sort, arg.Descending, sortKey{time: arg.AfterTime.Time, text: arg.AfterText.String}, arg.RowLimit}
+	return keysetPage(items, k, func(d database.Desk) string { return d.PublicID }, func(d database.Desk, sort string) sortKey {
+		switch sort {
+		case "name":
+			return sortKey{text: d.Name}
+		case "created_at":
+			return sortKey{time: d.CreatedAt}
+		case "updated_at":
+			return sortKey{time: d.UpdatedAt}
+		}
+		return sortKey{}
+	}), nil
+}
+
+func (m *Memory) CountDesks(ctx context.Context, arg database.CountDesksParams) (int64, error) {
+	defer m.lock()()
+	return int64(len(where(m.data.desks, m.matchDesk(arg)))), nil
+}
+
+func (m *Memory) SetDesksByPublicID(ctx context.Context, arg database.SetDesksByPublicIDParams) (database.Desk, error) {
+	defer m.lock()()
+	i, err := first(m.data.desks, func(d database.Desk) bool {
+		return d.PublicID == arg.PublicID && matchNullTime(arg.IfUpdatedAt, d.UpdatedAt)
+	})
+	if err != nil {
+		return database.Desk{}, err
+	}
+	d := &m.data.desks[i]
+	d.Name = arg.Name
+	d.Description = arg.Description
+	d.IsActive = arg.IsActive
+	d.UpdatedAt = m.now()
+	return *d, nil
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// holidays
+
+func (m *Memory) CreateHoliday(ctx context.Context, arg database.CreateHolidayParams) (database.Holiday, error) {
+	defer m.lock()()
+	if exists(m.data.holidays, func(h database.Holiday) bool { return h.PublicID == arg.PublicID }) {
+		return database.Holiday{}, constraintError("holidays_public_id_key")
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.Holiday{}, constraintError("holidays_location_public_id_fkey")
+	}
+	// like the ::date cast, keep the calendar date only
+	y, mo, d := arg.HolidayDate.Date()
+	date := time.Date(y, mo, d, 0, 0, 0, 0, time.UTC)
+	if exists(m.data.holidays, func(h database.Holiday) bool {
+		return h.LocationPublicID == arg.LocationPublicID && h.HolidayDate.Equal(date)
+	}) {
+		return database.Holiday{}, constraintError("holidays_location_public_id_holiday_date_key")
+	}
+	now := m.now()
+	h := database.Holiday{
+		ID:               uuid.New(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		PublicID:         arg.PublicID,
+		LocationPublicID: arg.LocationPublicID,
+		HolidayDate:      date,
+		Name:             arg.Name,
+	}
+	m.data.holidays = append(m.data.holidays, h)
+	return h, nil
+}
+
+func (m *Memory) GetHolidayByPublicID(ctx context.Context, publicID string) (database.Holiday, error) {
+	defer m.lock()()
+	i, err := first(m.data.holidays, func(h database.Holiday) bool { return h.PublicID == publicID })
+	if err != nil {
+		return database.Holiday{}, err
+	}
+	return m.data.holidays[i], nil
+}
+
+func (m *Memory) GetHolidaysByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.Holiday, error) {
+	defer m.lock()()
+	items := where(m.data.holidays, func(h database.Holiday) bool { return h.LocationPublicID == locationPublicID })
+	slices.SortFunc(items, func(a, b database.Holiday) int { return a.HolidayDate.Compare(b.HolidayDate) })
+	return items, nil
+}
+
+func (m *Memory) DeleteHolidayByPublicID(ctx context.Context, publicID string) error {
+	defer m.lock()()
+	m.data.holidays = slices.DeleteFunc(m.data.holidays, func(h database.Holiday) bool { return h.PublicID == publicID })
+	return nil
+}
+
+// idempotency_keys
+
+func (m *Memory) CreateIdempotencyKey(ctx context.Context, arg database.CreateIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	defer m.lock()()
+	i, err := first(m.data.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return k.Scope == arg.Scope && k.IdempotencyKey == arg.IdempotencyKey
+	})
+	if err != nil {
+		k := database.IdempotencyKey{
+			Scope:          arg.Scope,
+			IdempotencyKey: arg.IdempotencyKey,
+			RequestHash:    arg.RequestHash,
+			CreatedAt:      arg.CreatedAt,
+			ExpiresAt:      arg.ExpiresAt,
+			LockedUntil:    arg.LockedUntil,
+		}
+		m.data.idempotencyKeys = append(m.data.idempotencyKeys, k)
+		return k, nil
+	}
+	k := &m.data.idempotencyKeys[i]
+	if k.ResponseStatus.Valid || k.RequestHash != arg.RequestHash || (k.LockedUntil.Valid && k.LockedUntil.Time.After(arg.CreatedAt)) {
+		return database.IdempotencyKey{}, sql.ErrNoRows // ON CONFLICT DO UPDATE ... WHERE
+	}
+	k.CreatedAt, k.ExpiresAt, k.LockedUntil = arg.CreatedAt, arg.ExpiresAt, arg.LockedUntil
+	return *k, nil
+}
+
+func (m *Memory) GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	defer m.lock()()
+	i, err := first(m.data.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return k.Scope == arg.Scope && k.IdempotencyKey == arg.IdempotencyKey
+	})
+	if err != nil {
+		return database.IdempotencyKey{}, err
+	}
+	return m.data.idempotencyKeys[i], nil
+}
+
+func (m *Memory) SetIdempotencyKeyResponse(ctx context.Context, arg database.SetIdempotencyKeyResponseParams) error {
+	defer m.lock()()
+	for i, k := range m.data.idempotencyKeys {
+		if k.Scope == arg.Scope && k.IdempotencyKey == arg.IdempotencyKey {
+			m.data.idempotencyKeys[i].ResponseStatus = arg.ResponseStatus
+			m.data.idempotencyKeys[i].ResponseBody = slices.Clone(arg.ResponseBody)
+			m.data.idempotencyKeys[i].ResponseHeaders = slices.Clone(arg.ResponseHeaders)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) DeleteIdempotencyKey(ctx context.Context, arg database.DeleteIdempotencyKeyParams) error {
+	defer m.lock()()
+	m.data.idempotencyKeys = slices.DeleteFunc(m.data.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return k.Scope == arg.Scope && k.IdempotencyKey == arg.IdempotencyKey
+	})
+	return nil
+}
+
+func (m *Memory) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) error {
+	defer m.lock()()
+	m.data.idempotencyKeys = slices.DeleteFunc(m.data.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return !k.ExpiresAt.After(now)
+	})
+	return nil
+}
+
+// locations
+
+func (m *Memory) CreateLocation(ctx context.Context, arg database.CreateLocationParams) (database.Location, error) {
+	defer m.lock()()
+	if m.locationExists(arg.PublicID) {
+		return database.Location{}, constraintError("locations_public_id_key")
+	}
+	now := m.now()
+	i := database.Location{
+		ID:                 uuid.New(),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		PublicID:           arg.PublicID,
+		Name:               arg.Name,
+		TimeZone:           arg.TimeZone,
+		StopIssuingMinutes: arg.StopIssuingMinutes,
+	}
+	m.data.locations = append(m.data.locations, i)
+	return i, nil
+}
+
+func (m *Memory) GetLocationByPublicID(ctx context.Context, publicID string) (database.Location, error) {
+	defer m.lock()()
+	i, err := first(m.data.locations, func(l database.Location) bool { return l.PublicID == publicID })
+	if err != nil {
+		return database.Location{}, err
+	}
+	return m.data.locations[i], nil
+}
+
+func sortLocations(items []database.Location) []database.Location {
+	slices.SortFunc(items, func(a, b database.Location) int {
+		return cmp.Or(compareStrings(a.Name, b.Name), compareStrings(a.PublicID, b.PublicID))
+	})
+	return items
+}
+
+func (m *Memory) GetLocations(ctx context.Context) ([]database.Location, error) {
+	defer m.lock()()
+	return sortLocations(where(m.data.locations, all)), nil
+}
+
+func (m *Memory) SetLocationByPublicID(ctx context.Context, arg database.SetLocationByPublicIDParams) (database.Location, error) {
+	defer m.lock()()
+	i, err := first(m.data.locations, func(l database.Location) bool { return l.PublicID == arg.PublicID })
+	if err != nil {
+		return database.Location{}, err
+	}
+	l := &m.data.locations[i]
+	l.Name = arg.Name
+	l.TimeZone = arg.TimeZone
+	l.StopIssuingMinutes = arg.StopIssuingMinutes
+	l.UpdatedAt = m.now()
+	return *l, nil
+}
+
+func (m *Memory) GetLocationsByUserPublicID(ctx context.Context, userPublicID string) ([]database.Location, error) {
+	defer m.lock()()
+	return sortLocations(where(m.data.locations, func(l database.Location) bool {
+		return exists(m.data.userLocations, func(ul database.UserLocation) bool {
+			return ul.UserPublicID == userPublicID && ul.LocationPublicID == l.PublicID
+		})
+	})), nil
+}
+
+func (m *Memory) AddUserLocation(ctx context.Context, arg database.AddUserLocationParams) error {
+	defer m.lock()()
+	if !exists(m.data.users, func(u database.User) bool { return u.PublicID == arg.UserPublicID }) {
+		return constraintError("user_locations_user_public_id_fkey")
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return constraintError("user_locations_location_public_id_fkey")
+	}
+	ul := database.UserLocation(arg)
+	if !exists(m.data.userLocations, func(other database.UserLocation) bool { return other == ul }) { // ON CONFLICT DO NOTHING
+		m.data.userLocations = append(m.data.userLocations, ul)
+	}
+	return nil
+}
+
+func (m *Memory) DeleteUserLocations(ctx context.Context, userPublicID string) error {
+	defer m.lock()()
+	m.data.userLocations = slices.DeleteFunc(m.data.userLocations, func(ul database.UserLocation) bool { return ul.UserPublicID == userPublicID })
+	return nil
+}
+
+// login_attempts
+
+func (m *Memory) DeleteLoginAttempt(ctx context.Context, attemptKey string) error {
+	defer m.lock()()
+	m.data.loginAttempts = slices.DeleteFunc(m.data.loginAttempts, func(a database.LoginAttempt) bool { return a.AttemptKey == attemptKey })
+	return nil
+}
+
+func (m *Memory) FailLoginAttempt(ctx context.Context, arg database.FailLoginAttemptParams) (database.LoginAttempt, error) {
+	defer m.lock()()
+	attempt := database.LoginAttempt{AttemptKey: arg.AttemptKey}
+	i, err := first(m.data.loginAttempts, func(a database.LoginAttempt) bool { return a.AttemptKey == arg.AttemptKey })
+	if err == nil {
+		attempt = m.data.loginAttempts[i]
+		if attempt.BlockedUntil.After(arg.Now) && (arg.OnlyUnblocked || attempt.IsLockedOut) { // ON CONFLICT DO UPDATE ... WHERE
+			return database.LoginAttempt{}, sql.ErrNoRows
+		}
+		if attempt.IsLockedOut || attempt.LastFailedAt.Before(arg.ForgetBefore) {
+			attempt.FailedAttempts = 0
+		}
+	}
+	attempt.FailedAttempts++
+	attempt.LastFailedAt = arg.Now
+	attempt.IsLockedOut = arg.MaxAttempts > 0 && attempt.FailedAttempts >= arg.MaxAttempts
+	if attempt.IsLockedOut {
+		attempt.BlockedUntil = arg.LockedUntil
+	} else {
+		delay := min(arg.BaseDelay*float64(int64(1)<<min(attempt.FailedAttempts-1, 62)), arg.MaxDelay)
+		attempt.BlockedUntil = arg.Now.Add(time.Duration(delay * float64(time.Second)))
+	}
+	if err != nil {
+		m.data.loginAttempts = append(m.data.loginAttempts, attempt)
+	} else {
+		m.data.loginAttempts[i] = attempt
+	}
+	return attempt, nil
+}
+
+func (m *Memory) GetLoginAttempt(ctx context.Context, attemptKey string) (database.LoginAttempt, error) {
+	defer m.lock()()
+	i, err := first(m.data.loginAttempts, func(a database.LoginAttempt) bool { return a.AttemptKey == attemptKey })
+	if err != nil {
+		return database.LoginAttempt{}, err
+	}
+	return m.data.loginAttempts[i], nil
+}
+
+// opening_hours
+
+func (m *Memory) CreateOpeningHour(ctx context.Context, arg database.CreateOpeningHourParams) (database.OpeningHour, error) {
+	defer m.lock()()
+	switch {
+	case arg.Weekday < 0 || arg.Weekday > 6:
+		return database.OpeningHour{}, constraintError("opening_hours_weekday_check")
+	case arg.OpensAt < 0:
+		return database.OpeningHour{}, constraintError("opening_hours_opens_at_check")
+	case arg.ClosesAt <= arg.OpensAt || arg.ClosesAt > 1440:
+		return database.OpeningHour{}, constraintError("opening_hours_closes_at_check")
+	}
+	if exists(m.data.openingHours, func(o database.OpeningHour) bool { return o.PublicID == arg.PublicID }) {
+		return database.OpeningHour{}, constraintError("opening_hours_public_id_key")
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.OpeningHour{}, constraintError("opening_hours_location_public_id_fkey")
+	}
+	if arg.PurposePublicID.Valid && !exists(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == arg.PurposePublicID.String }) {
+		return database.OpeningHour{}, constraintError("opening_hours_purpose_public_id_fkey")
+	}
+	now := m.now()
+	o := database.OpeningHour{
+		ID:               uuid.New(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		PublicID:         arg.PublicID,
+		LocationPublicID: arg.LocationPublicID,
+		PurposePublicID:  arg.PurposePublicID,
+		Weekday:          arg.Weekday,
+		OpensAt:          arg.OpensAt,
+		ClosesAt:         arg.ClosesAt,
+	}
+	m.data.openingHours = append(m.data.openingHours, o)
+	return o, nil
+}
+
+func (m *Memory) GetOpeningHourByPublicID(ctx context.Context, publicID string) (database.OpeningHour, error) {
+	defer m.lock()()
+	i, err := first(m.data.openingHours, func(o database.OpeningHour) bool { return o.PublicID == publicID })
+	if err != nil {
+		return database.OpeningHour{}, err
+	}
+	return m.data.openingHours[i], nil
+}
+
+func (m *Memory) GetOpeningHoursByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.OpeningHour, error) {
+	defer m.lock()()
+	items := where(m.data.openingHours, func(o database.OpeningHour) bool { return o.LocationPublicID == locationPublicID })
+	slices.SortFunc(items, func(a, b database.OpeningHour) int {
+		return cmp.Or(cmp.Compare(a.Weekday, b.Weekday), cmp.Compare(a.OpensAt, b.OpensAt), compareStrings(a.PublicID, b.PublicID))
+	})
+	return items, nil
+}
+
+func (m *Memory) DeleteOpeningHourByPublicID(ctx context.Context, publicID string) error {
+	defer m.lock()()
+	m.data.openingHours = slices.DeleteFunc(m.data.openingHours, func(o database.OpeningHour) bool { return o.PublicID == publicID })
+	return nil
+}
+
+// purposes
+
+func (m *Memory) CreatePurpose(ctx context.Context, arg database.CreatePurposeParams) (database.Purpose, error) {
+	defer m.lock()()
+	if exists(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == arg.PublicID }) {
+		return database.Purpose{}, constraintError("purposes_public_id_key")
+	}
+	if !m.purposeExists(arg.ParentPurposeID) {
+		return database.Purpose{}, constraintError("fk_parent_purpose_id")
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.Purpose{}, constraintError("purposes_location_public_id_fkey")
+	}
+	now := m.now()
+	i := database.Purpose{
+		ID:               uuid.New(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		PurposeName:      arg.PurposeName,
+		ParentPurposeID:  arg.ParentPurposeID,
+		PublicID:         arg.PublicID,
+		LocationPublicID: arg.LocationPublicID,
+		DailyCapacity:    arg.DailyCapacity,
+	}
+	m.data.purposes = append(m.data.purposes, i)
+	return i, nil
+}
+
+func (m *Memory) purposeExists(id uuid.NullUUID) bool {
+	// a NULL foreign key is always valid
+	return !id.Valid || exists(m.data.purposes, func(p database.Purpose) bool { return p.ID == id.UUID })
+}
+
+func (m *Memory) GetPurposes(ctx context.Context) ([]database.Purpose, error) {
+	defer m.lock()()
+	return where(m.data.purposes, all), nil
+}
+
+func (m *Memory) GetPurposesByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.Purpose, error) {
+	defer m.lock()()
+	return where(m.data.purposes, func(p database.Purpose) bool { return p.LocationPublicID == locationPublicID }), nil
+}
+
+func (m *Memory) GetPurposesByID(ctx context.Context, id uuid.UUID) (database.Purpose, error) {
+	defer m.lock()()
+	i, err := first(m.data.purposes, func(p database.Purpose) bool { return p.ID == id })
+	if err != nil {
+		return database.Purpose{}, err
+	}
+	return m.data.purposes[i], nil
+}
+
+func (m *Memory) GetPurposesByName(ctx context.Context, purposeName string) (database.Purpose, error) {
+	defer m.lock()()
+	i, err := first(m.data.purposes, func(p database.Purpose) bool { return p.PurposeName == purposeName })
+	if err != nil {
+		return database.Purpose{}, err
+	}
+	return m.data.purposes[i], nil
+}
+
+func (m *Memory) GetPurposesByParent(ctx context.Context, parentPurposeID uuid.NullUUID) ([]database.Purpose, error) {
+	defer m.lock()()
+	// parent_purpose_id = NULL is never true in SQL, so a NULL argument matches nothing
+	return where(m.data.purposes, func(p database.Purpose) bool {
+		return parentPurposeID.Valid && p.ParentPurposeID.Valid && p.ParentPurposeID.UUID == parentPurposeID.UUID
+	}), nil
+}
+
+func (m *Memory) GetPurposesByPublicID(ctx context.Context, publicID string) (database.Purpose, error) {
+	defer m.lock()()
+	i, err := first(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == publicID })
+	if err != nil {
+		return database.Purpose{}, err
+	}
+	return m.data.purposes[i], nil
+}
+
+func (m *Memory) setPurpose(match func(database.Purpose) bool, set func(p *database.Purpose)) (database.Purpose, error) {
+	// shared by the SetPurpose* queries, the caller holds the lock
+	i, err := first(m.data.purposes, match)
+	if err != nil {
+		return database.Purpose{}, err
+	}
+	p := m.data.purposes[i]
+	set(&p)
+	if !m.purposeExists(p.ParentPurposeID) {
+		return database.Purpose{}, constraintError("fk_parent_purpose_id")
+	}
+	p.UpdatedAt = m.now()
+	m.data.purposes[i] = p
+	return p, nil
+}
+
+func (m *Memory) SetPurpose(ctx context.Context, arg database.SetPurposeParams) (database.Purpose, error) {
+	defer m.lock()()
+	return m.setPurpose(func(p database.Purpose) bool { return p.ID == arg.ID }, func(p *database.Purpose) {
+		p.PurposeName = arg.PurposeName
+		p.ParentPurposeID = arg.ParentPurposeID
+	})
+}
+
+func (m *Memory) SetPurposeByPublicID(ctx context.Context, arg database.SetPurposeByPublicIDParams) (database.Purpose, error) {
+	defer m.lock()()
+	return m.setPurpose(func(p database.Purpose) bool {
+		return p.PublicID == arg.PublicID && matchNullTime(arg.IfUpdatedAt, p.UpdatedAt)
+	}, func(p *database.Purpose) {
+		p.PurposeName = arg.PurposeName
+		p.ParentPurposeID = arg.ParentPurposeID
+		p.DailyCapacity = arg.DailyCapacity
+	})
+}
+
+func (m *Memory) SetPurposeName(ctx context.Context, arg database.SetPurposeNameParams) (database.Purpose, error) {
+	defer m.lock()()
+	return m.setPurpose(func(p database.Purpose) bool { return p.ID == arg.ID }, func(p *database.Purpose) {
+		p.PurposeName = arg.PurposeName
+	})
+}
+
+func (m *Memory) SetPurposeParentID(ctx context.Context, arg database.SetPurposeParentIDParams) (database.Purpose, error) {
+	defer m.lock()()
+	return m.setPurpose(func(p database.Purpose) bool { return p.ID == arg.ID }, func(p *database.Purpose) {
+		p.ParentPurposeID = arg.ParentPurposeID
+	})
+}
+
+func (m *Memory) SetPurposeParentIDByParentPurposeName(ctx context.Context, arg database.SetPurposeParentIDByParentPurposeNameParams) (database.Purpose, error) {
+	defer m.lock()()
+	// like the subquery: no parent with that name sets NULL, more than one is an error
+	parents := where(m.data.purposes, func(p database.Purpose) bool { return p.PurposeName == arg.PurposeName })
+	if len(parents) > 1 {
+		return database.Purpose{}, fmt.Errorf("storage: more than one row returned by a subquery used as an expression")
+	}
+	parentPurposeID := uuid.NullUUID{}
+	if len(parents) == 1 {
+		parentPurposeID = uuid.NullUUID{UUID: parents[0].ID, Valid: true}
+	}
+	return m.setPurpose(func(p database.Purpose) bool { return p.ID == arg.ID }, func(p *database.Purpose) {
+		p.ParentPurposeID = parentPurposeID
+	})
+}
+
+// purpose_translations
+
+func (m *Memory) CreatePurposeTranslation(ctx context.Context, arg database.CreatePurposeTranslationParams) (database.PurposeTranslation, error) {
+	defer m.lock()()
+	if !exists(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == arg.PurposePublicID }) {
+		return database.PurposeTranslation{}, constraintError("purpose_translations_purpose_public_id_fkey")
+	}
+	if exists(m.data.purposeTranslations, func(t database.PurposeTranslation) bool {
+		return t.PurposePublicID == arg.PurposePublicID && t.Language == arg.Language
+	}) {
+		return database.PurposeTranslation{}, constraintError("purpose_translations_pkey")
+	}
+	now := m.now()
+	t := database.PurposeTranslation{
+		PurposePublicID: arg.PurposePublicID,
+		Language:        arg.Language,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Name:            arg.Name,
+	}
+	m.data.purposeTranslations = append(m.data.purposeTranslations, t)
+	return t, nil
+}
+
+func sortPurposeTranslations(items []database.PurposeTranslation) {
+	slices.SortFunc(items, func(a, b database.PurposeTranslation) int {
+		return cmp.Or(compareStrings(a.PurposePublicID, b.PurposePublicID), compareStrings(a.Language, b.Language))
+	})
+}
+
+func (m *Memory) GetPurposeTranslations(ctx context.Context) ([]database.PurposeTranslation, error) {
+	defer m.lock()()
+	items := slices.Clone(m.data.purposeTranslations)
+	sortPurposeTranslations(items)
+	return items, nil
+}
+
+func (m *Memory) GetPurposeTranslationsByPurposePublicID(ctx context.Context, purposePublicID string) ([]database.PurposeTranslation, error) {
+	defer m.lock()()
+	items := where(m.data.purposeTranslations, func(t database.PurposeTranslation) bool { return t.PurposePublicID == purposePublicID })
+	sortPurposeTranslations(items)
+	return items, nil
+}
+
+func (m *Memory) DeletePurposeTranslationsByPurposePublicID(ctx context.Context, purposePublicID string) error {
+	defer m.lock()()
+	m.data.purposeTranslations = slices.DeleteFunc(m.data.purposeTranslations, func(t database.PurposeTranslation) bool {
+		return t.PurposePublicID == purposePublicID
+	})
+	return nil
+}
+
+// refresh_tokens
+
+func (m *Memory) isActiveRefreshToken(t database.RefreshToken) bool {
+	return t.ExpiresAt.After(m.now()) && !t.RevokedAt.Valid
+}
+
+func (m *Memory) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	defer m.lock()()
+	if exists(m.data.refreshTokens, func(t database.RefreshToken) bool { return t.Token == arg.Token }) {
+		return database.RefreshToken{}, constraintError("refresh_tokens_pkey")
+	}
+	if exists(m.data.refreshTokens, func(t database.RefreshToken) bool { return t.PublicID == arg.PublicID }) {
+		return database.RefreshToken{}, constraintError("refresh_tokens_public_id_key")
+	}
+	if !exists(m.data.users, func(u database.User) bool { return u.PublicID == arg.UserPublicID }) {
+		return database.RefreshToken{}, constraintError("fk_user_public_id")
+	}
+	now := m.now()
+	i := database.RefreshToken{
+		Token:        arg.Token,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		ExpiresAt:    arg.ExpiresAt,
+		PublicID:     arg.PublicID,
+		UserPublicID: arg.UserPublicID,
+		LastUsedAt:   now,
+		UserAgent:    arg.UserAgent,
+		IpAddress:    arg.IpAddress,
+	}
+	m.data.refreshTokens = append(m.data.refreshTokens, i)
+	return i, nil
+}
+
+func (m *Memory) GetRefreshTokenByToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	defer m.lock()()
+	i, err := first(m.data.refreshTokens, func(t database.RefreshToken) bool { return t.Token == token })
+	if err != nil {
+		return database.RefreshToken{}, err
+	}
+	return m.data.refreshTokens[i], nil
+}
+
+func (m *Memory) GetRefreshTokens(ctx context.Context) ([]database.RefreshToken, error) {
+	defer m.lock()()
+	return where(m.data.refreshTokens, all), nil
+}
+
+func (m *Memory) GetRefreshTokensByPublicID(ctx context.Context, publicID string) (database.RefreshToken, error) {
+	defer m.lock()()
+	i, err := first(m.data.refreshTokens, func(t database.RefreshToken) bool { return t.PublicID == publicID })
+	if err != nil {
+		return database.RefreshToken{}, err
+	}
+	return m.data.refreshTokens[i], nil
+}
+
+func (m *Memory) GetRefreshTokensByUserPublicID(ctx context.Context, userPublicID string) ([]database.RefreshToken, error) {
+	defer m.lock()()
+	items := where(m.data.refreshTokens, func(t database.RefreshToken) bool {
+		return t.UserPublicID == userPublicID && m.isActiveRefreshToken(t)
+	})
+	slices.SortStableFunc(items, func(a, b database.RefreshToken) int { return b.LastUsedAt.Compare(a.LastUsedAt) })
+	return items, nil
+}
+
+func (m *Memory) revokeRefreshTokens(match func(database.RefreshToken) bool) []database.RefreshToken {
+	// shared by the RevokeRefreshToken* queries, the caller holds the lock
+	var items []database.RefreshToken
+	now := m.now()
+	for i, t := range m.data.refreshTokens {
+		if match(t) {
+			t.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			t.UpdatedAt = now
+			m.data.refreshTokens[i] = t
+			items = append(items, t)
+		}
+	}
+	return items
+}
+
+func (m *Memory) RevokeRefreshTokenByPublicID(ctx context.Context, publicID string) (database.RefreshToken, error) {
+	defer m.lock()()
+	items := m.revokeRefreshTokens(func(t database.RefreshToken) bool { return t.PublicID == publicID && m.isActiveRefreshToken(t) })
+	if len(items) == 0 {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return items[0], nil
+}
+
+func (m *Memory) RevokeRefreshTokenByToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	defer m.lock()()
+	items := m.revokeRefreshTokens(func(t database.RefreshToken) bool { return t.Token == token })
+	if len(items) == 0 {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return items[0], nil
+}
+
+func (m *Memory) RevokeRefreshTokenByUserPublicID(ctx context.Context, userPublicID string) ([]database.RefreshToken, error) {
+	defer m.lock()()
+	return m.revokeRefreshTokens(func(t database.RefreshToken) bool {
+		return t.UserPublicID == userPublicID && m.isActiveRefreshToken(t)
+	}), nil
+}
+
+func (m *Memory) RevokeRefreshTokens(ctx context.Context) ([]database.RefreshToken, error) {
+	defer m.lock()()
+	return m.revokeRefreshTokens(m.isActiveRefreshToken), nil
+}
+
+func (m *Memory) RotateRefreshTokenByToken(ctx context.Context, arg database.RotateRefreshTokenByTokenParams) (database.RefreshToken, error) {
+	defer m.lock()()
+	i, err := first(m.data.refreshTokens, func(t database.RefreshToken) bool {
+		return t.Token == arg.OldToken && m.isActiveRefreshToken(t)
+	})
+	if err != nil {
+		return database.RefreshToken{}, err
+	}
+	if arg.NewToken != arg.OldToken && exists(m.data.refreshTokens, func(t database.RefreshToken) bool { return t.Token == arg.NewToken }) {
+		return database.RefreshToken{}, constraintError("refresh_tokens_pkey")
+	}
+	now := m.now()
+	t := &m.data.refreshTokens[i]
+	t.Token = arg.NewToken
+	t.ExpiresAt = arg.ExpiresAt
+	t.UserAgent = arg.UserAgent
+	t.IpAddress = arg.IpAddress
+	t.LastUsedAt = now
+	t.UpdatedAt = now
+	return *t, nil
+}
+
+// service_logs
+
+func (m *Memory) checkServiceLogReferences(visitorPublicID, userPublicID, deskPublicID string) error {
+	if !exists(m.data.visitors, func(v database.Visitor) bool { return v.PublicID == visitorPublicID }) {
+		return constraintError("fk_visitor_public_id")
+	}
+	if !exists(m.data.users, func(u database.User) bool { return u.PublicID == userPublicID }) {
+		return constraintError("fk_user_public_id")
+	}
+	if !exists(m.data.desks, func(d database.Desk) bool { return d.PublicID == deskPublicID }) {
+		return constraintError("fk_desk_public_id")
+	}
+	return nil
+}
+
+func (m *Memory) DeleteServiceLogsOfVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	defer m.lock()()
+	n := len(m.data.serviceLogs)
+	m.data.serviceLogs = slices.DeleteFunc(m.data.serviceLogs, func(s database.ServiceLog) bool {
+		return exists(m.data.visitors, func(v database.Visitor) bool {
+			return v.PublicID == s.VisitorPublicID && v.CreatedAt.Before(createdBefore)
+		})
+	})
+	return int64(n - len(m.data.serviceLogs)), nil
+}
+
+func (m *Memory) CreateServiceLogs(ctx context.Context, arg database.CreateServiceLogsParams) (database.ServiceLog, error) {
+	defer m.lock()()
+	if exists(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.PublicID == arg.PublicID }) {
+		return database.ServiceLog{}, constraintError("service_logs_public_id_key")
+	}
+	if err := m.checkServiceLogReferences(arg.VisitorPublicID, arg.UserPublicID, arg.DeskPublicID); err != nil {
+		return database.ServiceLog{}, err
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.ServiceLog{}, constraintError("service_logs_location_public_id_fkey")
+	}
+	now := arg.CreatedAt.Truncate(time.Microsecond)
+	i := database.ServiceLog{
+		ID:               uuid.New(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		CalledAt:         now,
+		IsActive:         true,
+		PublicID:         arg.PublicID,
+		UserPublicID:     arg.UserPublicID,
+		VisitorPublicID:  arg.VisitorPublicID,
+		DeskPublicID:     arg.DeskPublicID,
+		LocationPublicID: arg.LocationPublicID,
+	}
+	m.data.serviceLogs = append(m.data.serviceLogs, i)
+	return i, nil
+}
+
+func (m *Memory) GetActiveServiceLogs(ctx context.Context) ([]database.ServiceLog, error) {
+	defer m.lock()()
+	return where(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.IsActive }), nil
+}
+
+func (m *Memory) GetActiveServiceLogsByUserID(ctx context.Context, userPublicID string) ([]database.ServiceLog, error) {
+	defer m.lock()()
+	return where(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.IsActive && s.UserPublicID == userPublicID }), nil
+}
+
+func (m *Memory) GetServiceLogs(ctx context.Context) ([]database.ServiceLog, error) {
+	defer m.lock()()
+	return where(m.data.serviceLogs, all), nil
+}
+
+func (m *Memory) GetServiceLogsByPublicID(ctx context.Context, publicID string) (database.ServiceLog, error) {
+	defer m.lock()()
+	i, err := first(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.PublicID == publicID })
+	if err != nil {
+		return database.ServiceLog{}, err
+	}
+	return m.data.serviceLogs[i], nil
+}
+
+func (m *Memory) GetServiceLogsByVisitorPublicID(ctx context.Context, visitorPublicID string) ([]database.ServiceLog, error) {
+	defer m.lock()()
+	// service logs are kept in order of creation
+	return where(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.VisitorPublicID == visitorPublicID }), nil
+}
+
+func (m *Memory) GetCallTimesByPurposePublicID(ctx context.Context, arg database.GetCallTimesByPurposePublicIDParams) ([]time.Time, error) {
+	defer m.lock()()
+	var items []time.Time
+	for _, s := range m.data.serviceLogs {
+		called := !s.CalledAt.Before(arg.CalledAt) && exists(m.data.visitors, func(v database.Visitor) bool {
+			return v.PublicID == s.VisitorPublicID && v.PurposePublicID == arg.PurposePublicID
+		})
+		if called {
+			items = append(items, s.CalledAt)
+		}
+	}
+	slices.SortFunc(items, func(a, b time.Time) int { return b.Compare(a) })
+	return limitRows(items, arg.Limit), nil
+}
+
+func (m *Memory) GetActiveServiceLogsByLocationPublicID(ctx context.Context, arg database.GetActiveServiceLogsByLocationPublicIDParams) ([]database.ServiceLog, error) {
+	defer m.lock()()
+	items := where(m.data.serviceLogs, func(s database.ServiceLog) bool {
+		return s.LocationPublicID == arg.LocationPublicID && s.IsActive && !s.CalledAt.Before(arg.CalledAt)
+	})
+	slices.SortFunc(items, func(a, b database.ServiceLog) int {
+		return cmp.Or(b.CalledAt.Compare(a.CalledAt), compareStrings(b.PublicID, a.PublicID))
+	})
+	return limitRows(items, arg.Limit), nil
+}
+
+func (m *Memory) matchServiceLog(arg database.CountServiceLogsParams) func(database.ServiceLog) bool {
+	// the filters shared by ListServiceLogs and CountServiceLogs
+	return func(s database.ServiceLog) bool {
+		return matchNullString(arg.UserPublicID, s.UserPublicID) &&
+			matchNullString(arg.VisitorPublicID, s.VisitorPublicID) &&
+			matchNullString(arg.DeskPublicID, s.DeskPublicID) &&
+			matchDateRange(arg.StartDate, arg.EndDate, s.CreatedAt) &&
+			m.matchLocation(arg.LocationPublicID, arg.MemberPublicID, s.LocationPublicID)
+	}
+}
+
+func (m *Memory) ListServiceLogs(ctx context.Context, arg database.ListServiceLogsParams) ([]database.ServiceLog, error) {
+	defer m.lock()()
+	items := where(m.data.serviceLogs, m.matchServiceLog(database.CountServiceLogsParams{
+		UserPublicID:     arg.UserPublicID,
+		VisitorPublicID:  arg.VisitorPublicID,
+		DeskPublicID:     arg.DeskPublicID,
+		StartDate:        arg.StartDate,
+		EndDate:          arg.EndDate,
+		LocationPublicID: arg.LocationPublicID,
+		MemberPublicID:   arg.MemberPublicID,
+	}))
+	k := keyset{arg.AfterPublicID, arg.Sort, arg.Descending, sortKey{time: arg.AfterTime.Time}, arg.RowLimit}
+	return keysetPage(items, k, func(s database.ServiceLog) string { return s.PublicID }, func(s database.ServiceLog, sort string) sortKey {
+		switch sort {
+		case "created_at":
+			return sortKey{time: s.CreatedAt}
+		case "called_at":
+			return sortKey{time: s.CalledAt}
+		case "updated_at":
+			return sortKey{time: s.UpdatedAt}
+		}
+		return sortKey{}
+	}), nil
+}
+
+func (m *Memory) CountServiceLogs(ctx context.Context, arg database.CountServiceLogsParams) (int64, error) {
+	defer m.lock()()
+	return int64(len(where(m.data.serviceLogs, m.matchServiceLog(arg)))), nil
+}
+
+func (m *Memory) SetServiceLogsByPublicID(ctx context.Context, arg database.SetServiceLogsByPublicIDParams) (database.ServiceLog, error) {
+	defer m.lock()()
+	i, err := first(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.PublicID == arg.PublicID })
+	if err != nil {
+		return database.ServiceLog{}, err
+	}
+	if err := m.checkServiceLogReferences(arg.VisitorPublicID, arg.UserPublicID, arg.DeskPublicID); err != nil {
+		return database.ServiceLog{}, err
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.ServiceLog{}, constraintError("service_logs_location_public_id_fkey")
+	}
+	s := &m.data.serviceLogs[i]
+	s.VisitorPublicID = arg.VisitorPublicID
+	s.UserPublicID = arg.UserPublicID
+	s.DeskPublicID = arg.DeskPublicID
+	s.IsActive = arg.IsActive
+	s.LocationPublicID = arg.LocationPublicID
+	s.UpdatedAt = m.now()
+	return *s, nil
+}
+
+// ticket_counter
+
+func (m *Memory) UpdateTicketCounter(ctx context.Context, arg database.UpdateTicketCounterParams) (int32, error) {
+	defer m.lock()()
+	if !m.locationExists(arg.LocationPublicID) {
+		return 0, constraintError("ticket_counter_location_public_id_fkey")
+	}
+	// like the ::date cast, keep the calendar date only
+	key := arg.LocationPublicID + " " + arg.CounterDate.Format(time.DateOnly)
+	m.data.ticketCounters[key]++
+	return m.data.ticketCounters[key], nil
+}
+
+// ticket_layouts
+
+func (m *Memory) GetTicketLayoutByPurposePublicID(ctx context.Context, purposePublicID string) (database.TicketLayout, error) {
+	defer m.lock()()
+	i, err := first(m.data.ticketLayouts, func(l database.TicketLayout) bool { return l.PurposePublicID == purposePublicID })
+	if err != nil {
+		return database.TicketLayout{}, err
+	}
+	return m.data.ticketLayouts[i], nil
+}
+
+func (m *Memory) UpsertTicketLayout(ctx context.Context, arg database.UpsertTicketLayoutParams) (database.TicketLayout, error) {
+	defer m.lock()()
+	if !exists(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == arg.PurposePublicID }) {
+		return database.TicketLayout{}, constraintError("ticket_layouts_purpose_public_id_fkey")
+	}
+	now := m.now()
+	i, err := first(m.data.ticketLayouts, func(l database.TicketLayout) bool { return l.PurposePublicID == arg.PurposePublicID })
+	if err != nil {
+		m.data.ticketLayouts = append(m.data.ticketLayouts, database.TicketLayout{PurposePublicID: arg.PurposePublicID, CreatedAt: now})
+		i = len(m.data.ticketLayouts) - 1
+	}
+	l := &m.data.ticketLayouts[i]
+	l.UpdatedAt, l.Header, l.Footer, l.Logo = now, arg.Header, arg.Footer, arg.Logo
+	return *l, nil
+}
+
+// user_tokens
+
+func (m *Memory) ConsumeUserToken(ctx context.Context, arg database.ConsumeUserTokenParams) (database.UserToken, error) {
+	defer m.lock()()
+	now := m.now()
+	i, err := first(m.data.userTokens, func(t database.UserToken) bool {
+		return t.TokenHash == arg.TokenHash && t.Kind == arg.Kind && !t.UsedAt.Valid && t.ExpiresAt.After(now)
+	})
+	if err != nil {
+		return database.UserToken{}, err
+	}
+	t := &m.data.userTokens[i]
+	t.UsedAt = sql.NullTime{Time: now, Valid: true}
+	t.UpdatedAt = now
+	return *t, nil
+}
+
+func (m *Memory) CreateUserToken(ctx context.Context, arg database.CreateUserTokenParams) (database.UserToken, error) {
+	defer m.lock()()
+	if exists(m.data.userTokens, func(t database.UserToken) bool { return t.PublicID == arg.PublicID }) {
+		return database.UserToken{}, constraintError("user_tokens_public_id_key")
+	}
+	if exists(m.data.userTokens, func(t database.UserToken) bool { return t.TokenHash == arg.TokenHash }) {
+		return database.UserToken{}, constraintError("user_tokens_token_hash_key")
+	}
+	if !exists(m.data.users, func(u database.User) bool { return u.PublicID == arg.UserPublicID }) {
+		return database.UserToken{}, constraintError("user_tokens_user_public_id_fkey")
+	}
+	now := m.now()
+	i := database.UserToken{
+		ID:           uuid.New(),
+		PublicID:     arg.PublicID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		UserPublicID: arg.UserPublicID,
+		Kind:         arg.Kind,
+		TokenHash:    arg.TokenHash,
+		ExpiresAt:    arg.ExpiresAt,
+	}
+	m.data.userTokens = append(m.data.userTokens, i)
+	return i, nil
+}
+
+func (m *Memory) InvalidateUserTokens(ctx context.Context, arg database.InvalidateUserTokensParams) error {
+	defer m.lock()()
+	now := m.now()
+	for i, t := range m.data.userTokens {
+		if t.UserPublicID == arg.UserPublicID && t.Kind == arg.Kind && !t.UsedAt.Valid {
+			m.data.userTokens[i].UsedAt = sql.NullTime{Time: now, Valid: true}
+			m.data.userTokens[i].UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+// users
+
+func (m *Memory) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	defer m.lock()()
+	if exists(m.data.users, func(u database.User) bool { return u.PublicID == arg.PublicID }) {
+		return database.User{}, constraintError("users_public_id_key")
+	}
+	if exists(m.data.users, func(u database.User) bool { return u.Email == arg.Email }) {
+		return database.User{}, constraintError("users_email_key")
+	}
+	now := m.now()
+	i := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		IsAdmin:        false,
+		IsActive:       true,
+		FullName:       arg.FullName,
+		PublicID:       arg.PublicID,
+	}
+	m.data.users = append(m.data.users, i)
+	return i, nil
+}
+
+func (m *Memory) DeleteUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer m.lock()()
+	i, err := first(m.data.users, func(u database.User) bool { return u.ID == id })
+	if err != nil {
+		return database.User{}, err
+	}
+	u := m.data.users[i]
+	if exists(m.data.refreshTokens, func(t database.RefreshToken) bool { return t.UserPublicID == u.PublicID }) {
+		return database.User{}, constraintError("fk_user_public_id")
+	}
+	if exists(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.UserPublicID == u.PublicID }) {
+		return database.User{}, constraintError("fk_user_public_id")
+	}
+	m.data.userTokens = slices.DeleteFunc(m.data.userTokens, func(t database.UserToken) bool { return t.UserPublicID == u.PublicID }) // ON DELETE CASCADE
+	m.data.userLocations = slices.DeleteFunc(m.data.userLocations, func(ul database.UserLocation) bool { return ul.UserPublicID == u.PublicID })
+	m.data.users = slices.Delete(m.data.users, i, i+1)
+	return u, nil
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	defer m.lock()()
+	i, err := first(m.data.users, func(u database.User) bool { return u.Email == email })
+	if err != nil {
+		return database.User{}, err
+	}
+	return m.data.users[i], nil
+}
+
+func (m *Memory) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer m.lock()()
+	i, err := first(m.data.users, func(u database.User) bool { return u.ID == id })
+	if err != nil {
+		return database.User{}, err
+	}
+	return m.data.users[i], nil
+}
+
+func (m *Memory) GetUserByPublicID(ctx context.Context, publicID string) (database.User, error) {
+	defer m.lock()()
+	i, err := first(m.data.users, func(u database.User) bool { return u.PublicID == publicID })
+	if err != nil {
+		return database.User{}, err
+	}
+	return m.data.users[i], nil
+}
+
+func (m *Memory) GetUsers(ctx context.Context) ([]database.User, error) {
+	defer m.lock()()
+	return where(m.data.users, all), nil
+}
+
+func matchUser(arg database.CountUsersParams) func(database.User) bool {
+	// the filters shared by ListUsers and CountUsers
+	return func(u database.User) bool {
+		return matchNullBool(arg.IsActive, u.IsActive) && matchNullBool(arg.IsAdmin, u.IsAdmin)
+	}
+}
+
+func (m *Memory) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	defer m.lock()()
+	items := where(m.data.users, matchUser(database.CountUsersParams{IsActive: arg.IsActive, IsAdmin: arg.IsAdmin}))
+	k := keyset{arg.AfterPublicID, arg.Sort, arg.Descending, sortKey{time: arg.AfterTime.Time, text: arg.AfterText.String}, arg.RowLimit}
+	return keysetPage(items, k, func(u database.User) string { return u.PublicID }, func(u database.User, sort string) sortKey {
+		switch sort {
+		case "created_at":
+			return sortKey{time: u.CreatedAt}
+		case "email":
+			return sortKey{text: u.Email}
+		case "full_name":
+			return sortKey{text: u.FullName}
+		}
+		return sortKey{}
+	}), nil
+}
+
+func (m *Memory) CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error) {
+	defer m.lock()()
+	return int64(len(where(m.data.users, matchUser(arg)))), nil
+}
+
+func (m *Memory) setUser(match func(database.User) bool, set func(u *database.User)) (database.User, error) {
+	// shared by the SetUser* queries, the caller holds the lock
+	i, err := first(m.data.users, match)
+	if err != nil {
+		return database.User{}, err
+	}
+	u := m.data.users[i]
+	set(&u)
+	if exists(m.data.users, func(other database.User) bool { return other.ID != u.ID && other.Email == u.Email }) {
+		return database.User{}, constraintError("users_email_key")
+	}
+	u.UpdatedAt = m.now()
+	m.data.users[i] = u
+	return u, nil
+}
+
+func (m *Memory) SetUserByPublicID(ctx context.Context, arg database.SetUserByPublicIDParams) (database.User, error) {
+	defer m.lock()()
+	return m.setUser(func(u database.User) bool { return u.PublicID == arg.PublicID }, func(u *database.User) {
+		u.Email = arg.Email
+		u.FullName = arg.FullName
+		u.IsAdmin = arg.IsAdmin
+		u.IsActive = arg.IsActive
+	})
+}
+
+func (m *Memory) SetUserEmailPasswordByID(ctx context.Context, arg database.SetUserEmailPasswordByIDParams) (database.User, error) {
+	defer m.lock()()
+	return m.setUser(func(u database.User) bool { return u.ID == arg.ID }, func(u *database.User) {
+		u.Email = arg.Email
+		u.HashedPassword = arg.HashedPassword
+	})
+}
+
+func (m *Memory) SetUserFullNameByID(ctx context.Context, arg database.SetUserFullNameByIDParams) (database.User, error) {
+	defer m.lock()()
+	return m.setUser(func(u database.User) bool { return u.ID == arg.ID }, func(u *database.User) {
+		u.FullName = arg.FullName
+	})
+}
+
+func (m *Memory) SetUserInactiveByID(ctx context.Context, arg database.SetUserInactiveByIDParams) (database.User, error) {
+	defer m.lock()()
+	return m.setUser(func(u database.User) bool { return u.ID == arg.ID }, func(u *database.User) {
+		u.IsActive = arg.IsActive
+	})
+}
+
+func (m *Memory) SetUserIsAdminByID(ctx context.Context, arg database.SetUserIsAdminByIDParams) (database.User, error) {
+	defer m.lock()()
+	return m.setUser(func(u database.User) bool { return u.ID == arg.ID }, func(u *database.User) {
+		u.IsAdmin = arg.IsAdmin
+	})
+}
+
+func (m *Memory) SetUserPasswordByPublicID(ctx context.Context, arg database.SetUserPasswordByPublicIDParams) (database.User, error) {
+	defer m.lock()()
+	return m.setUser(func(u database.User) bool { return u.PublicID == arg.PublicID }, func(u *database.User) {
+		u.HashedPassword = arg.HashedPassword
+	})
+}
+
+// visitors
+
+func (m *Memory) AnonymizeVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	defer m.lock()()
+	var n int64
+	now := m.now()
+	for i, v := range m.data.visitors {
+		if v.CreatedAt.Before(createdBefore) && (v.Name.Valid || v.PhoneNumber.Valid || v.Email.Valid) {
+			m.data.visitors[i].Name = sql.NullString{}
+			m.data.visitors[i].PhoneNumber = sql.NullString{}
+			m.data.visitors[i].Email = sql.NullString{}
+			m.data.visitors[i].UpdatedAt = now
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) AnonymizeVisitorByPublicID(ctx context.Context, publicID string) (database.Visitor, error) {
+	defer m.lock()()
+	return m.setVisitor(func(v database.Visitor) bool { return v.PublicID == publicID }, func(v *database.Visitor) {
+		v.Name = sql.NullString{}
+		v.PhoneNumber = sql.NullString{}
+		v.Email = sql.NullString{}
+	})
+}
+
+func (m *Memory) DeleteVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	defer m.lock()()
+	deleted := func(v database.Visitor) bool { return v.CreatedAt.Before(createdBefore) }
+	if exists(m.data.serviceLogs, func(s database.ServiceLog) bool {
+		return exists(m.data.visitors, func(v database.Visitor) bool { return v.PublicID == s.VisitorPublicID && deleted(v) })
+	}) {
+		return 0, constraintError("fk_visitor_public_id")
+	}
+	n := len(m.data.visitors)
+	m.data.visitors = slices.DeleteFunc(m.data.visitors, deleted)
+	return int64(n - len(m.data.visitors)), nil
+}
+
+func (m *Memory) CreateVisitor(ctx context.Context, arg database.CreateVisitorParams) (database.Visitor, error) {
+	defer m.lock()()
+	if exists(m.data.visitors, func(v database.Visitor) bool { return v.PublicID == arg.PublicID }) {
+		return database.Visitor{}, constraintError("visitors_public_id_key")
+	}
+	if !exists(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == arg.PurposePublicID }) {
+		return database.Visitor{}, constraintError("fk_public_purpose_id")
+	}
+	if !m.locationExists(arg.LocationPublicID) {
+		return database.Visitor{}, constraintError("visitors_location_public_id_fkey")
+	}
+	now := arg.CreatedAt.Truncate(time.Microsecond)
+	i := database.Visitor{
+		ID:                uuid.New(),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		WaitingSince:      now,
+		Name:              arg.Name,
+		Status:            0,
+		DailyTicketNumber: arg.DailyTicketNumber,
+		PublicID:          arg.PublicID,
+		PurposePublicID:   arg.PurposePublicID,
+		LocationPublicID:  arg.LocationPublicID,
+		PhoneNumber:       arg.PhoneNumber,
+		Email:             arg.Email,
+	}
+	m.data.visitors = append(m.data.visitors, i)
+	return i, nil
+}
+
+func (m *Memory) visitorsByWaitingSince(match func(database.Visitor) bool) []database.Visitor {
+	// shared by the visitor queries ordered by waiting_since, the caller holds the lock
+	items := where(m.data.visitors, match)
+	slices.SortStableFunc(items, func(a, b database.Visitor) int { return a.WaitingSince.Compare(b.WaitingSince) })
+	return items
+}
+
+func (m *Memory) GetVisitorByID(ctx context.Context, id uuid.UUID) (database.Visitor, error) {
+	defer m.lock()()
+	i, err := first(m.data.visitors, func(v database.Visitor) bool { return v.ID == id })
+	if err != nil {
+		return database.Visitor{}, err
+	}
+	return m.data.visitors[i], nil
+}
+
+func (m *Memory) GetVisitors(ctx context.Context) ([]database.Visitor, error) {
+	defer m.lock()()
+	return where(m.data.visitors, all), nil
+}
+
+func (m *Memory) GetVisitorsByPublicID(ctx context.Context, publicID string) (database.Visitor, error) {
+	defer m.lock()()
+	i, err := first(m.data.visitors, func(v database.Visitor) bool { return v.PublicID == publicID })
+	if err != nil {
+		return database.Visitor{}, err
+	}
+	return m.data.visitors[i], nil
+}
+
+func (m *Memory) GetVisitorsByPurposePublicID(ctx context.Context, purposePublicID string) ([]database.Visitor, error) {
+	defer m.lock()()
+	return m.visitorsByWaitingSince(func(v database.Visitor) bool { return v.PurposePublicID == purposePublicID }), nil
+}
+
+func (m *Memory) GetVisitorsByPurposePublicIDAndStatus(ctx context.Context, arg database.GetVisitorsByPurposePublicIDAndStatusParams) ([]database.Visitor, error) {
+	defer m.lock()()
+	return m.visitorsByWaitingSince(func(v database.Visitor) bool {
+		return v.PurposePublicID == arg.PurposePublicID && v.Status == arg.Status
+	}), nil
+}
+
+func (m *Memory) GetVisitorsByStatus(ctx context.Context, status int32) ([]database.Visitor, error) {
+	defer m.lock()()
+	return m.visitorsByWaitingSince(func(v database.Visitor) bool { return v.Status == status }), nil
+}
+
+func (m *Memory) GetVisitorsForDay(ctx context.Context, arg database.GetVisitorsForDayParams) ([]database.Visitor, error) {
+	defer m.lock()()
+	return m.visitorsByWaitingSince(func(v database.Visitor) bool {
+		return v.LocationPublicID == arg.LocationPublicID && !v.WaitingSince.Before(arg.DayStart) && v.WaitingSince.Before(arg.DayEnd)
+	}), nil
+}
+
+func (m *Memory) CountVisitorsByPurposePublicIDSince(ctx context.Context, arg database.CountVisitorsByPurposePublicIDSinceParams) (int64, error) {
+	defer m.lock()()
+	return int64(len(where(m.data.visitors, func(v database.Visitor) bool {
+		return v.PurposePublicID == arg.PurposePublicID && !v.CreatedAt.Before(arg.CreatedAt)
+	}))), nil
+}
+
+func (m *Memory) GetWaitingVisitorsByPurposePublicID(ctx context.Context, purposePublicID string) ([]database.Visitor, error) {
+	defer m.lock()()
+	return m.visitorsByWaitingSince(func(v database.Visitor) bool { return v.PurposePublicID == purposePublicID && v.Status == 1 }), nil
+}
+
+func (m *Memory) GetQueuedVisitorsByPurposePublicID(ctx context.Context, arg database.GetQueuedVisitorsByPurposePublicIDParams) ([]database.Visitor, error) {
+	defer m.lock()()
+	items := where(m.data.visitors, func(v database.Visitor) bool {
+		return v.PurposePublicID == arg.PurposePublicID && v.Status == 0 &&
+			!exists(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.VisitorPublicID == v.PublicID })
+	})
+	slices.SortFunc(items, func(a, b database.Visitor) int {
+		return cmp.Or(a.WaitingSince.Compare(b.WaitingSince), compareStrings(a.PublicID, b.PublicID))
+	})
+	return limitRows(items, arg.Limit), nil
+}
+
+func (m *Memory) CountQueuedVisitorsAhead(ctx context.Context, arg database.CountQueuedVisitorsAheadParams) (int64, error) {
+	defer m.lock()()
+	ahead := where(m.data.visitors, func(v database.Visitor) bool {
+		return v.PurposePublicID == arg.PurposePublicID && v.Status == 0 &&
+			!exists(m.data.serviceLogs, func(s database.ServiceLog) bool { return s.VisitorPublicID == v.PublicID }) &&
+			cmp.Or(v.WaitingSince.Compare(arg.WaitingSince), compareStrings(v.PublicID, arg.PublicID)) < 0
+	})
+	return int64(len(ahead)), nil
+}
+
+func (m *Memory) matchVisitor(arg database.CountVisitorsParams) func(database.Visitor) bool {
+	// the filters shared by ListVisitors and CountVisitors
+	return func(v database.Visitor) bool {
+		return (!arg.Status.Valid || v.Status == arg.Status.Int32) &&
+			matchNullString(arg.PurposePublicID, v.PurposePublicID) &&
+			matchDateRange(arg.StartDate, arg.EndDate, v.CreatedAt) &&
+			m.matchLocation(arg.LocationPublicID, arg.MemberPublicID, v.LocationPublicID)
+	}
+}
+
+func (m *Memory) ListVisitors(ctx context.Context, arg database.ListVisitorsParams) ([]database.Visitor, error) {
+	defer m.lock()()
+	items := where(m.data.visitors, m.matchVisitor(database.CountVisitorsParams{
+		Status:           arg.Status,
+		PurposePublicID:  arg.PurposePublicID,
+		StartDate:        arg.StartDate,
+		EndDate:          arg.EndDate,
+		LocationPublicID: arg.LocationPublicID,
+		MemberPublicID:   arg.MemberPublicID,
+	}))
+	k := keyset{arg.AfterPublicID, arg.Sort, arg.Descending, sortKey{time: arg.AfterTime.Time, int: arg.AfterInt.Int32}, arg.RowLimit}
+	return keysetPage(items, k, func(v database.Visitor) string { return v.PublicID }, func(v database.Visitor, sort string) sortKey {
+		switch sort {
+		case "waiting_since":
+			return sortKey{time: v.WaitingSince}
+		case "created_at":
+			return sortKey{time: v.CreatedAt}
+		case "updated_at":
+			return sortKey{time: v.UpdatedAt}
+		case "daily_ticket_number":
+			return sortKey{int: v.DailyTicketNumber}
+		}
+		return sortKey{}
+	}), nil
+}
+
+func (m *Memory) CountVisitors(ctx context.Context, arg database.CountVisitorsParams) (int64, error) {
+	defer m.lock()()
+	return int64(len(where(m.data.visitors, m.matchVisitor(arg)))), nil
+}
+
+func (m *Memory) SetVisitorNotifiedAt(ctx context.Context, arg database.SetVisitorNotifiedAtParams) (int64, error) {
+	defer m.lock()()
+	i, err := first(m.data.visitors, func(v database.Visitor) bool { return v.PublicID == arg.PublicID && !v.NotifiedAt.Valid })
+	if err != nil { // unknown or notified before
+		return 0, nil
+	}
+	m.data.visitors[i].NotifiedAt = arg.NotifiedAt
+	return 1, nil
+}
+
+func (m *Memory) setVisitor(match func(database.Visitor) bool, set func(v *database.Visitor)) (database.Visitor, error) {
+	// shared by the SetVisitor* queries, the caller holds the lock
+	i, err := first(m.data.visitors, match)
+	if err != nil {
+		return database.Visitor{}, err
+	}
+	v := m.data.visitors[i]
+	set(&v)
+	if !exists(m.data.purposes, func(p database.Purpose) bool { return p.PublicID == v.PurposePublicID }) {
+		return database.Visitor{}, constraintError("fk_public_purpose_id")
+	}
+	v.UpdatedAt = m.now()
+	m.data.visitors[i] = v
+	return v, nil
+}
+
+func (m *Memory) SetVisitorByPublicID(ctx context.Context, arg database.SetVisitorByPublicIDParams) (database.Visitor, error) {
+	defer m.lock()()
+	return m.setVisitor(func(v database.Visitor) bool { return v.PublicID == arg.PublicID }, func(v *database.Visitor) {
+		v.Name = arg.Name
+		v.PurposePublicID = arg.PurposePublicID
+		v.Status = arg.Status
+	})
+}
+
+func (m *Memory) SetVisitorStatusByID(ctx context.Context, arg database.SetVisitorStatusByIDParams) (database.Visitor, error) {
+	defer m.lock()()
+	return m.setVisitor(func(v database.Visitor) bool { return v.ID == arg.ID }, func(v *database.Visitor) {
+		v.Status = arg.Status
+	})
+}
+
+// webhook_deliveries
+
+func (m *Memory) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	defer m.lock()()
+	if exists(m.data.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.PublicID == arg.PublicID }) {
+		return database.WebhookDelivery{}, constraintError("webhook_deliveries_public_id_key")
+	}
+	if !exists(m.data.webhookSubscriptions, func(w database.WebhookSubscription) bool { return w.PublicID == arg.SubscriptionPublicID }) {
+		return database.WebhookDelivery{}, constraintError("webhook_deliveries_subscription_public_id_fkey")
+	}
+	now := m.now()
+	d := database.WebhookDelivery{
+		ID:                   uuid.New(),
+		CreatedAt:            now,
+		UpdatedAt:            now,
+		PublicID:             arg.PublicID,
+		SubscriptionPublicID: arg.SubscriptionPublicID,
+		EventType:            arg.EventType,
+		Payload:              slices.Clone(arg.Payload),
+		Status:               "pending",
+		NextAttemptAt:        arg.NextAttemptAt,
+	}
+	m.data.webhookDeliveries = append(m.data.webhookDeliveries, d)
+	return d, nil
+}
+
+func (m *Memory) GetWebhookDeliveryByPublicID(ctx context.Context, publicID string) (database.WebhookDelivery, error) {
+	defer m.lock()()
+	i, err := first(m.data.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.PublicID == publicID })
+	if err != nil {
+		return database.WebhookDelivery{}, err
+	}
+	return m.data.webhookDeliveries[i], nil
+}
+
+func limitRows[T any](items []T, limit int32) []T {
+	if int(limit) < len(items) {
+		return items[:max(limit, 0)]
+	}
+	return items
+}
+
+func (m *Memory) GetWebhookDeliveriesBySubscriptionPublicID(ctx context.Context, arg database.GetWebhookDeliveriesBySubscriptionPublicIDParams) ([]database.WebhookDelivery, error) {
+	defer m.lock()()
+	items := where(m.data.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.SubscriptionPublicID == arg.SubscriptionPublicID })
+	slices.SortFunc(items, func(a, b database.WebhookDelivery) int {
+		return -cmp.Or(a.CreatedAt.Compare(b.CreatedAt), compareStrings(a.PublicID, b.PublicID))
+	})
+	return limitRows(items, arg.Limit), nil
+}
+
+func (m *Memory) GetDueWebhookDeliveries(ctx context.Context, arg database.GetDueWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	defer m.lock()()
+	items := where(m.data.webhookDeliveries, func(d database.WebhookDelivery) bool {
+		return d.Status == "pending" && !d.NextAttemptAt.After(arg.NextAttemptAt)
+	})
+	slices.SortFunc(items, func(a, b database.WebhookDelivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), compareStrings(a.PublicID, b.PublicID))
+	})
+	return limitRows(items, arg.Limit), nil
+}
+
+func (m *Memory) setWebhookDelivery(match func(database.WebhookDelivery) bool, set func(d *database.WebhookDelivery)) (database.WebhookDelivery, error) {
+	// shared by the webhook delivery updates, the caller holds the lock
+	i, err := first(m.data.webhookDeliveries, match)
+	if err != nil {
+		return database.WebhookDelivery{}, err
+	}
+	d := &m.data.webhookDeliveries[i]
+	set(d)
+	d.UpdatedAt = m.now()
+	return *d, nil
+}
+
+func (m *Memory) ClaimWebhookDelivery(ctx context.Context, arg database.ClaimWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	defer m.lock()()
+	return m.setWebhookDelivery(func(d database.WebhookDelivery) bool {
+		return d.PublicID == arg.PublicID && d.Status == "pending" && !d.NextAttemptAt.After(arg.Now)
+	}, func(d *database.WebhookDelivery) {
+		d.NextAttemptAt = arg.LeaseUntil
+	})
+}
+
+func (m *Memory) SetWebhookDeliveryResult(ctx context.Context, arg database.SetWebhookDeliveryResultParams) (database.WebhookDelivery, error) {
+	defer m.lock()()
+	switch arg.Status {
+	case "pending", "delivered", "failed":
+	default:
+		return database.WebhookDelivery{}, constraintError("webhook_deliveries_status_check")
+	}
+	return m.setWebhookDelivery(func(d database.WebhookDelivery) bool { return d.PublicID == arg.PublicID }, func(d *database.WebhookDelivery) {
+		d.Status = arg.Status
+		d.Attempts++
+		d.NextAttemptAt = arg.NextAttemptAt
+		d.ResponseStatus = arg.ResponseStatus
+		d.LastError = arg.LastError
+		d.DeliveredAt = arg.DeliveredAt
+	})
+}
+
+func (m *Memory) RedeliverWebhookDelivery(ctx context.Context, arg database.RedeliverWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	defer m.lock()()
+	return m.setWebhookDelivery(func(d database.WebhookDelivery) bool { return d.PublicID == arg.PublicID }, func(d *database.WebhookDelivery) {
+		d.Status = "pending"
+		d.Attempts = 0
+		d.NextAttemptAt = arg.NextAttemptAt
+	})
+}
+
+// webhook_subscriptions
+
+func (m *Memory) CreateWebhookSubscription(ctx context.Context, arg database.CreateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	defer m.lock()()
+	if exists(m.data.webhookSubscriptions, func(w database.WebhookSubscription) bool { return w.PublicID == arg.PublicID }) {
+		return database.WebhookSubscription{}, constraintError("webhook_subscriptions_public_id_key")
+	}
+	now := m.now()
+	w := database.WebhookSubscription{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		PublicID:  arg.PublicID,
+		Url:       arg.Url,
+		Secret:    arg.Secret,
+		EventType: arg.EventType,
+	}
+	m.data.webhookSubscriptions = append(m.data.webhookSubscriptions, w)
+	return w, nil
+}
+
+func sortWebhookSubscriptions(items []database.WebhookSubscription) []database.WebhookSubscription {
+	slices.SortFunc(items, func(a, b database.WebhookSubscription) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), compareStrings(a.PublicID, b.PublicID))
+	})
+	return items
+}
+
+func (m *Memory) GetWebhookSubscriptions(ctx context.Context) ([]database.WebhookSubscription, error) {
+	defer m.lock()()
+	return sortWebhookSubscriptions(where(m.data.webhookSubscriptions, all)), nil
+}
+
+func (m *Memory) GetWebhookSubscriptionByPublicID(ctx context.Context, publicID string) (database.WebhookSubscription, error) {
+	defer m.lock()()
+	i, err := first(m.data.webhookSubscriptions, func(w database.WebhookSubscription) bool { return w.PublicID == publicID })
+	if err != nil {
+		return database.WebhookSubscription{}, err
+	}
+	return m.data.webhookSubscriptions[i], nil
+}
+
+func (m *Memory) GetWebhookSubscriptionsByEventType(ctx context.Context, eventType string) ([]database.WebhookSubscription, error) {
+	defer m.lock()()
+	return sortWebhookSubscriptions(where(m.data.webhookSubscriptions, func(w database.WebhookSubscription) bool { return w.EventType == eventType })), nil
+}
+
+func (m *Memory) DeleteWebhookSubscriptionByPublicID(ctx context.Context, publicID string) error {
+	defer m.lock()()
+	m.data.webhookSubscriptions = slices.DeleteFunc(m.data.webhookSubscriptions, func(w database.WebhookSubscription) bool { return w.PublicID == publicID })
+	// ON DELETE CASCADE
+	m.data.webhookDeliveries = slices.DeleteFunc(m.data.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.SubscriptionPublicID == publicID })
+	return nil
+}
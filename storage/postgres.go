@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	*database.Queries
+	db *sql.DB // nil when bound to a transaction
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{
+		Queries: database.New(db),
+		db:      db,
+	}
+}
+
+func (p *Postgres) InTx(ctx context.Context, fn func(s Store) error) error {
+	if p.db == nil {
+		return fn(p)
+	}
+	return retryTx(ctx, isPostgresConflict, func() error {
+		// serializable, like SQLite: otherwise postgres lets conflicting transactions both commit instead of failing one
+		tx, err := p.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback() // no-op after a successful commit
+		if err = fn(&Postgres{Queries: p.Queries.WithTx(tx)}); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+func isPostgresConflict(err error) bool {
+	// serialization_failure and deadlock_detected: postgres aborted the transaction in favour of a concurrent one
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01")
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/sqlitedb"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLite is a Store on an SQLite database with the schema of sql/sqlite/schema, meant for small single-office
+// deployments. The queries in sql/sqlite/queries mirror those in sql/queries, and the sqlitedb types are identical to
+// the database types, so every method converts its arguments and results one-to-one (see sqlite_queries.go).
+type SQLite struct {
+	q  *sqlitedb.Queries
+	db *sql.DB // nil when bound to a transaction
+}
+
+// sqliteTimeFormat is the format timestamps are stored in: always UTC and of fixed width, so that comparing them as
+// text (which is what SQLite does) orders them in time. Microseconds, like a postgres TIMESTAMP.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000"
+
+func init() {
+	// the postgres functions used in the queries
+	sqlite.MustRegisterScalarFunction("now", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format(sqliteTimeFormat), nil
+	})
+	sqlite.MustRegisterScalarFunction("gen_random_uuid", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return uuid.NewString(), nil
+	})
+}
+
+func openSQLiteDB(path string) (*sql.DB, error) {
+	/*
+		Opens the SQLite database file at path, creating it if it does not exist yet. Foreign keys are enforced and transactions take the write lock up front, so concurrent requests (and
+		migrations, see package migrate) wait for each other instead of failing.
+	*/
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{
+		q:  sqlitedb.New(sqliteConn{db}),
+		db: db,
+	}
+}
+
+func (s *SQLite) InTx(ctx context.Context, fn func(s Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+	return retryTx(ctx, isSQLiteConflict, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback() // no-op after a successful commit
+		if err = fn(&SQLite{q: sqlitedb.New(sqliteConn{tx})}); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+func isSQLiteConflict(err error) bool {
+	// the database file stayed locked by another connection for longer than the busy timeout
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+}
+
+// sqliteConn passes queries on to the database or transaction, with time arguments converted to sqliteTimeFormat.
+type sqliteConn struct {
+	db sqlitedb.DBTX
+}
+
+func (c sqliteConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(ctx, query, sqliteArgs(args)...)
+}
+
+func (c sqliteConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(ctx, query)
+}
+
+func (c sqliteConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(ctx, query, sqliteArgs(args)...)
+}
+
+func (c sqliteConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(ctx, query, sqliteArgs(args)...)
+}
+
+func sqliteArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = v.UTC().Format(sqliteTimeFormat)
+		case sql.NullTime:
+			if v.Valid {
+				converted[i] = v.Time.UTC().Format(sqliteTimeFormat)
+			} else {
+				converted[i] = nil
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+func convertRows[T, U any](items []T, err error, convert func(T) U) ([]U, error) {
+	if err != nil {
+		return nil, err
+	}
+	var converted []U
+	for _, i := range items {
+		converted = append(converted, convert(i))
+	}
+	return converted, nil
+}
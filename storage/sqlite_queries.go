@@ -0,0 +1,624 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/internal/sqlitedb"
+	"github.com/google/uuid"
+)
+
+// one method per query, converting between the identical database and sqlitedb types
+
+func (s *SQLite) AddUserLocation(ctx context.Context, arg database.AddUserLocationParams) error {
+	return s.q.AddUserLocation(ctx, sqlitedb.AddUserLocationParams(arg))
+}
+
+func (s *SQLite) AnonymizeVisitorByPublicID(ctx context.Context, publicID string) (database.Visitor, error) {
+	i, err := s.q.AnonymizeVisitorByPublicID(ctx, publicID)
+	return database.Visitor(i), err
+}
+
+func (s *SQLite) AnonymizeVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	return s.q.AnonymizeVisitorsCreatedBefore(ctx, createdBefore)
+}
+
+func (s *SQLite) ClaimWebhookDelivery(ctx context.Context, arg database.ClaimWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	i, err := s.q.ClaimWebhookDelivery(ctx, sqlitedb.ClaimWebhookDeliveryParams(arg))
+	return database.WebhookDelivery(i), err
+}
+
+func (s *SQLite) ConsumeUserToken(ctx context.Context, arg database.ConsumeUserTokenParams) (database.UserToken, error) {
+	i, err := s.q.ConsumeUserToken(ctx, sqlitedb.ConsumeUserTokenParams(arg))
+	return database.UserToken(i), err
+}
+
+func (s *SQLite) CountDesks(ctx context.Context, arg database.CountDesksParams) (int64, error) {
+	return s.q.CountDesks(ctx, sqlitedb.CountDesksParams(arg))
+}
+
+func (s *SQLite) CountQueuedVisitorsAhead(ctx context.Context, arg database.CountQueuedVisitorsAheadParams) (int64, error) {
+	return s.q.CountQueuedVisitorsAhead(ctx, sqlitedb.CountQueuedVisitorsAheadParams(arg))
+}
+
+func (s *SQLite) CountServiceLogs(ctx context.Context, arg database.CountServiceLogsParams) (int64, error) {
+	return s.q.CountServiceLogs(ctx, sqlitedb.CountServiceLogsParams(arg))
+}
+
+func (s *SQLite) CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error) {
+	return s.q.CountUsers(ctx, sqlitedb.CountUsersParams(arg))
+}
+
+func (s *SQLite) CountVisitors(ctx context.Context, arg database.CountVisitorsParams) (int64, error) {
+	return s.q.CountVisitors(ctx, sqlitedb.CountVisitorsParams(arg))
+}
+
+func (s *SQLite) CountVisitorsByPurposePublicIDSince(ctx context.Context, arg database.CountVisitorsByPurposePublicIDSinceParams) (int64, error) {
+	return s.q.CountVisitorsByPurposePublicIDSince(ctx, sqlitedb.CountVisitorsByPurposePublicIDSinceParams(arg))
+}
+
+func (s *SQLite) CreateAnnouncementTemplate(ctx context.Context, arg database.CreateAnnouncementTemplateParams) (database.AnnouncementTemplate, error) {
+	i, err := s.q.CreateAnnouncementTemplate(ctx, sqlitedb.CreateAnnouncementTemplateParams(arg))
+	return database.AnnouncementTemplate(i), err
+}
+
+func (s *SQLite) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) (database.AuditEvent, error) {
+	i, err := s.q.CreateAuditEvent(ctx, sqlitedb.CreateAuditEventParams(arg))
+	return database.AuditEvent(i), err
+}
+
+func (s *SQLite) CreateDesks(ctx context.Context, arg database.CreateDesksParams) (database.Desk, error) {
+	i, err := s.q.CreateDesks(ctx, sqlitedb.CreateDesksParams(arg))
+	return database.Desk(i), err
+}
+
+func (s *SQLite) CreateHoliday(ctx context.Context, arg database.CreateHolidayParams) (database.Holiday, error) {
+	i, err := s.q.CreateHoliday(ctx, sqlitedb.CreateHolidayParams(arg))
+	return database.Holiday(i), err
+}
+
+func (s *SQLite) CreateIdempotencyKey(ctx context.Context, arg database.CreateIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	i, err := s.q.CreateIdempotencyKey(ctx, sqlitedb.CreateIdempotencyKeyParams(arg))
+	return database.IdempotencyKey(i), err
+}
+
+func (s *SQLite) CreateLocation(ctx context.Context, arg database.CreateLocationParams) (database.Location, error) {
+	i, err := s.q.CreateLocation(ctx, sqlitedb.CreateLocationParams(arg))
+	return database.Location(i), err
+}
+
+func (s *SQLite) CreateOpeningHour(ctx context.Context, arg database.CreateOpeningHourParams) (database.OpeningHour, error) {
+	i, err := s.q.CreateOpeningHour(ctx, sqlitedb.CreateOpeningHourParams(arg))
+	return database.OpeningHour(i), err
+}
+
+func (s *SQLite) CreatePurpose(ctx context.Context, arg database.CreatePurposeParams) (database.Purpose, error) {
+	i, err := s.q.CreatePurpose(ctx, sqlitedb.CreatePurposeParams(arg))
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) CreatePurposeTranslation(ctx context.Context, arg database.CreatePurposeTranslationParams) (database.PurposeTranslation, error) {
+	i, err := s.q.CreatePurposeTranslation(ctx, sqlitedb.CreatePurposeTranslationParams(arg))
+	return database.PurposeTranslation(i), err
+}
+
+func (s *SQLite) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	i, err := s.q.CreateRefreshToken(ctx, sqlitedb.CreateRefreshTokenParams(arg))
+	return database.RefreshToken(i), err
+}
+
+func (s *SQLite) CreateServiceLogs(ctx context.Context, arg database.CreateServiceLogsParams) (database.ServiceLog, error) {
+	i, err := s.q.CreateServiceLogs(ctx, sqlitedb.CreateServiceLogsParams(arg))
+	return database.ServiceLog(i), err
+}
+
+func (s *SQLite) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	i, err := s.q.CreateUser(ctx, sqlitedb.CreateUserParams(arg))
+	return database.User(i), err
+}
+
+func (s *SQLite) CreateUserToken(ctx context.Context, arg database.CreateUserTokenParams) (database.UserToken, error) {
+	i, err := s.q.CreateUserToken(ctx, sqlitedb.CreateUserTokenParams(arg))
+	return database.UserToken(i), err
+}
+
+func (s *SQLite) CreateVisitor(ctx context.Context, arg database.CreateVisitorParams) (database.Visitor, error) {
+	i, err := s.q.CreateVisitor(ctx, sqlitedb.CreateVisitorParams(arg))
+	return database.Visitor(i), err
+}
+
+func (s *SQLite) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	i, err := s.q.CreateWebhookDelivery(ctx, sqlitedb.CreateWebhookDeliveryParams(arg))
+	return database.WebhookDelivery(i), err
+}
+
+func (s *SQLite) CreateWebhookSubscription(ctx context.Context, arg database.CreateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	i, err := s.q.CreateWebhookSubscription(ctx, sqlitedb.CreateWebhookSubscriptionParams(arg))
+	return database.WebhookSubscription(i), err
+}
+
+func (s *SQLite) DeleteAnnouncementTemplateByPublicID(ctx context.Context, publicID string) error {
+	return s.q.DeleteAnnouncementTemplateByPublicID(ctx, publicID)
+}
+
+func (s *SQLite) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) error {
+	return s.q.DeleteExpiredIdempotencyKeys(ctx, now)
+}
+
+func (s *SQLite) DeleteHolidayByPublicID(ctx context.Context, publicID string) error {
+	return s.q.DeleteHolidayByPublicID(ctx, publicID)
+}
+
+func (s *SQLite) DeleteIdempotencyKey(ctx context.Context, arg database.DeleteIdempotencyKeyParams) error {
+	return s.q.DeleteIdempotencyKey(ctx, sqlitedb.DeleteIdempotencyKeyParams(arg))
+}
+
+func (s *SQLite) DeleteLoginAttempt(ctx context.Context, attemptKey string) error {
+	return s.q.DeleteLoginAttempt(ctx, attemptKey)
+}
+
+func (s *SQLite) DeleteOpeningHourByPublicID(ctx context.Context, publicID string) error {
+	return s.q.DeleteOpeningHourByPublicID(ctx, publicID)
+}
+
+func (s *SQLite) DeletePurposeTranslationsByPurposePublicID(ctx context.Context, purposePublicID string) error {
+	return s.q.DeletePurposeTranslationsByPurposePublicID(ctx, purposePublicID)
+}
+
+func (s *SQLite) DeleteServiceLogsOfVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	return s.q.DeleteServiceLogsOfVisitorsCreatedBefore(ctx, createdBefore)
+}
+
+func (s *SQLite) DeleteUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	i, err := s.q.DeleteUserByID(ctx, id)
+	return database.User(i), err
+}
+
+func (s *SQLite) DeleteUserLocations(ctx context.Context, userPublicID string) error {
+	return s.q.DeleteUserLocations(ctx, userPublicID)
+}
+
+func (s *SQLite) DeleteVisitorsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	return s.q.DeleteVisitorsCreatedBefore(ctx, createdBefore)
+}
+
+func (s *SQLite) DeleteWebhookSubscriptionByPublicID(ctx context.Context, publicID string) error {
+	return s.q.DeleteWebhookSubscriptionByPublicID(ctx, publicID)
+}
+
+func (s *SQLite) FailLoginAttempt(ctx context.Context, arg database.FailLoginAttemptParams) (database.LoginAttempt, error) {
+	i, err := s.q.FailLoginAttempt(ctx, sqlitedb.FailLoginAttemptParams(arg))
+	return database.LoginAttempt(i), err
+}
+
+func (s *SQLite) GetActiveDesks(ctx context.Context) ([]database.Desk, error) {
+	items, err := s.q.GetActiveDesks(ctx)
+	return convertRows(items, err, func(i sqlitedb.Desk) database.Desk { return database.Desk(i) })
+}
+
+func (s *SQLite) GetActiveServiceLogs(ctx context.Context) ([]database.ServiceLog, error) {
+	items, err := s.q.GetActiveServiceLogs(ctx)
+	return convertRows(items, err, func(i sqlitedb.ServiceLog) database.ServiceLog { return database.ServiceLog(i) })
+}
+
+func (s *SQLite) GetActiveServiceLogsByLocationPublicID(ctx context.Context, arg database.GetActiveServiceLogsByLocationPublicIDParams) ([]database.ServiceLog, error) {
+	items, err := s.q.GetActiveServiceLogsByLocationPublicID(ctx, sqlitedb.GetActiveServiceLogsByLocationPublicIDParams(arg))
+	return convertRows(items, err, func(i sqlitedb.ServiceLog) database.ServiceLog { return database.ServiceLog(i) })
+}
+
+func (s *SQLite) GetActiveServiceLogsByUserID(ctx context.Context, userPublicID string) ([]database.ServiceLog, error) {
+	items, err := s.q.GetActiveServiceLogsByUserID(ctx, userPublicID)
+	return convertRows(items, err, func(i sqlitedb.ServiceLog) database.ServiceLog { return database.ServiceLog(i) })
+}
+
+func (s *SQLite) GetAnnouncementTemplateByPublicID(ctx context.Context, publicID string) (database.AnnouncementTemplate, error) {
+	i, err := s.q.GetAnnouncementTemplateByPublicID(ctx, publicID)
+	return database.AnnouncementTemplate(i), err
+}
+
+func (s *SQLite) GetAnnouncementTemplatesByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.AnnouncementTemplate, error) {
+	items, err := s.q.GetAnnouncementTemplatesByLocationPublicID(ctx, locationPublicID)
+	return convertRows(items, err, func(i sqlitedb.AnnouncementTemplate) database.AnnouncementTemplate {
+		return database.AnnouncementTemplate(i)
+	})
+}
+
+func (s *SQLite) GetCallTimesByPurposePublicID(ctx context.Context, arg database.GetCallTimesByPurposePublicIDParams) ([]time.Time, error) {
+	return s.q.GetCallTimesByPurposePublicID(ctx, sqlitedb.GetCallTimesByPurposePublicIDParams(arg))
+}
+
+func (s *SQLite) GetDesks(ctx context.Context) ([]database.Desk, error) {
+	items, err := s.q.GetDesks(ctx)
+	return convertRows(items, err, func(i sqlitedb.Desk) database.Desk { return database.Desk(i) })
+}
+
+func (s *SQLite) GetDesksByPublicID(ctx context.Context, publicID string) (database.Desk, error) {
+	i, err := s.q.GetDesksByPublicID(ctx, publicID)
+	return database.Desk(i), err
+}
+
+func (s *SQLite) GetDueWebhookDeliveries(ctx context.Context, arg database.GetDueWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	items, err := s.q.GetDueWebhookDeliveries(ctx, sqlitedb.GetDueWebhookDeliveriesParams(arg))
+	return convertRows(items, err, func(i sqlitedb.WebhookDelivery) database.WebhookDelivery { return database.WebhookDelivery(i) })
+}
+
+func (s *SQLite) GetHolidayByPublicID(ctx context.Context, publicID string) (database.Holiday, error) {
+	i, err := s.q.GetHolidayByPublicID(ctx, publicID)
+	return database.Holiday(i), err
+}
+
+func (s *SQLite) GetHolidaysByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.Holiday, error) {
+	items, err := s.q.GetHolidaysByLocationPublicID(ctx, locationPublicID)
+	return convertRows(items, err, func(i sqlitedb.Holiday) database.Holiday { return database.Holiday(i) })
+}
+
+func (s *SQLite) GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	i, err := s.q.GetIdempotencyKey(ctx, sqlitedb.GetIdempotencyKeyParams(arg))
+	return database.IdempotencyKey(i), err
+}
+
+func (s *SQLite) GetLocationByPublicID(ctx context.Context, publicID string) (database.Location, error) {
+	i, err := s.q.GetLocationByPublicID(ctx, publicID)
+	return database.Location(i), err
+}
+
+func (s *SQLite) GetLocations(ctx context.Context) ([]database.Location, error) {
+	items, err := s.q.GetLocations(ctx)
+	return convertRows(items, err, func(i sqlitedb.Location) database.Location { return database.Location(i) })
+}
+
+func (s *SQLite) GetLocationsByUserPublicID(ctx context.Context, userPublicID string) ([]database.Location, error) {
+	items, err := s.q.GetLocationsByUserPublicID(ctx, userPublicID)
+	return convertRows(items, err, func(i sqlitedb.Location) database.Location { return database.Location(i) })
+}
+
+func (s *SQLite) GetLoginAttempt(ctx context.Context, attemptKey string) (database.LoginAttempt, error) {
+	i, err := s.q.GetLoginAttempt(ctx, attemptKey)
+	return database.LoginAttempt(i), err
+}
+
+func (s *SQLite) GetOpeningHourByPublicID(ctx context.Context, publicID string) (database.OpeningHour, error) {
+	i, err := s.q.GetOpeningHourByPublicID(ctx, publicID)
+	return database.OpeningHour(i), err
+}
+
+func (s *SQLite) GetOpeningHoursByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.OpeningHour, error) {
+	items, err := s.q.GetOpeningHoursByLocationPublicID(ctx, locationPublicID)
+	return convertRows(items, err, func(i sqlitedb.OpeningHour) database.OpeningHour { return database.OpeningHour(i) })
+}
+
+func (s *SQLite) GetPurposeTranslations(ctx context.Context) ([]database.PurposeTranslation, error) {
+	items, err := s.q.GetPurposeTranslations(ctx)
+	return convertRows(items, err, func(i sqlitedb.PurposeTranslation) database.PurposeTranslation { return database.PurposeTranslation(i) })
+}
+
+func (s *SQLite) GetPurposeTranslationsByPurposePublicID(ctx context.Context, purposePublicID string) ([]database.PurposeTranslation, error) {
+	items, err := s.q.GetPurposeTranslationsByPurposePublicID(ctx, purposePublicID)
+	return convertRows(items, err, func(i sqlitedb.PurposeTranslation) database.PurposeTranslation { return database.PurposeTranslation(i) })
+}
+
+func (s *SQLite) GetPurposes(ctx context.Context) ([]database.Purpose, error) {
+	items, err := s.q.GetPurposes(ctx)
+	return convertRows(items, err, func(i sqlitedb.Purpose) database.Purpose { return database.Purpose(i) })
+}
+
+func (s *SQLite) GetPurposesByID(ctx context.Context, id uuid.UUID) (database.Purpose, error) {
+	i, err := s.q.GetPurposesByID(ctx, id)
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) GetPurposesByLocationPublicID(ctx context.Context, locationPublicID string) ([]database.Purpose, error) {
+	items, err := s.q.GetPurposesByLocationPublicID(ctx, locationPublicID)
+	return convertRows(items, err, func(i sqlitedb.Purpose) database.Purpose { return database.Purpose(i) })
+}
+
+func (s *SQLite) GetPurposesByName(ctx context.Context, purposeName string) (database.Purpose, error) {
+	i, err := s.q.GetPurposesByName(ctx, purposeName)
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) GetPurposesByParent(ctx context.Context, parentPurposeID uuid.NullUUID) ([]database.Purpose, error) {
+	items, err := s.q.GetPurposesByParent(ctx, parentPurposeID)
+	return convertRows(items, err, func(i sqlitedb.Purpose) database.Purpose { return database.Purpose(i) })
+}
+
+func (s *SQLite) GetPurposesByPublicID(ctx context.Context, publicID string) (database.Purpose, error) {
+	i, err := s.q.GetPurposesByPublicID(ctx, publicID)
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) GetQueuedVisitorsByPurposePublicID(ctx context.Context, arg database.GetQueuedVisitorsByPurposePublicIDParams) ([]database.Visitor, error) {
+	items, err := s.q.GetQueuedVisitorsByPurposePublicID(ctx, sqlitedb.GetQueuedVisitorsByPurposePublicIDParams(arg))
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) GetRefreshTokenByToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	i, err := s.q.GetRefreshTokenByToken(ctx, token)
+	return database.RefreshToken(i), err
+}
+
+func (s *SQLite) GetRefreshTokens(ctx context.Context) ([]database.RefreshToken, error) {
+	items, err := s.q.GetRefreshTokens(ctx)
+	return convertRows(items, err, func(i sqlitedb.RefreshToken) database.RefreshToken { return database.RefreshToken(i) })
+}
+
+func (s *SQLite) GetRefreshTokensByPublicID(ctx context.Context, publicID string) (database.RefreshToken, error) {
+	i, err := s.q.GetRefreshTokensByPublicID(ctx, publicID)
+	return database.RefreshToken(i), err
+}
+
+func (s *SQLite) GetRefreshTokensByUserPublicID(ctx context.Context, userPublicID string) ([]database.RefreshToken, error) {
+	items, err := s.q.GetRefreshTokensByUserPublicID(ctx, userPublicID)
+	return convertRows(items, err, func(i sqlitedb.RefreshToken) database.RefreshToken { return database.RefreshToken(i) })
+}
+
+func (s *SQLite) GetServiceLogs(ctx context.Context) ([]database.ServiceLog, error) {
+	items, err := s.q.GetServiceLogs(ctx)
+	return convertRows(items, err, func(i sqlitedb.ServiceLog) database.ServiceLog { return database.ServiceLog(i) })
+}
+
+func (s *SQLite) GetServiceLogsByPublicID(ctx context.Context, publicID string) (database.ServiceLog, error) {
+	i, err := s.q.GetServiceLogsByPublicID(ctx, publicID)
+	return database.ServiceLog(i), err
+}
+
+func (s *SQLite) GetServiceLogsByVisitorPublicID(ctx context.Context, visitorPublicID string) ([]database.ServiceLog, error) {
+	items, err := s.q.GetServiceLogsByVisitorPublicID(ctx, visitorPublicID)
+	return convertRows(items, err, func(i sqlitedb.ServiceLog) database.ServiceLog { return database.ServiceLog(i) })
+}
+
+func (s *SQLite) GetTicketLayoutByPurposePublicID(ctx context.Context, purposePublicID string) (database.TicketLayout, error) {
+	i, err := s.q.GetTicketLayoutByPurposePublicID(ctx, purposePublicID)
+	return database.TicketLayout(i), err
+}
+
+func (s *SQLite) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	i, err := s.q.GetUserByEmail(ctx, email)
+	return database.User(i), err
+}
+
+func (s *SQLite) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	i, err := s.q.GetUserByID(ctx, id)
+	return database.User(i), err
+}
+
+func (s *SQLite) GetUserByPublicID(ctx context.Context, publicID string) (database.User, error) {
+	i, err := s.q.GetUserByPublicID(ctx, publicID)
+	return database.User(i), err
+}
+
+func (s *SQLite) GetUsers(ctx context.Context) ([]database.User, error) {
+	items, err := s.q.GetUsers(ctx)
+	return convertRows(items, err, func(i sqlitedb.User) database.User { return database.User(i) })
+}
+
+func (s *SQLite) GetVisitorByID(ctx context.Context, id uuid.UUID) (database.Visitor, error) {
+	i, err := s.q.GetVisitorByID(ctx, id)
+	return database.Visitor(i), err
+}
+
+func (s *SQLite) GetVisitors(ctx context.Context) ([]database.Visitor, error) {
+	items, err := s.q.GetVisitors(ctx)
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) GetVisitorsByPublicID(ctx context.Context, publicID string) (database.Visitor, error) {
+	i, err := s.q.GetVisitorsByPublicID(ctx, publicID)
+	return database.Visitor(i), err
+}
+
+func (s *SQLite) GetVisitorsByPurposePublicID(ctx context.Context, purposePublicID string) ([]database.Visitor, error) {
+	items, err := s.q.GetVisitorsByPurposePublicID(ctx, purposePublicID)
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) GetVisitorsByPurposePublicIDAndStatus(ctx context.Context, arg database.GetVisitorsByPurposePublicIDAndStatusParams) ([]database.Visitor, error) {
+	items, err := s.q.GetVisitorsByPurposePublicIDAndStatus(ctx, sqlitedb.GetVisitorsByPurposePublicIDAndStatusParams(arg))
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) GetVisitorsByStatus(ctx context.Context, status int32) ([]database.Visitor, error) {
+	items, err := s.q.GetVisitorsByStatus(ctx, status)
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) GetVisitorsForDay(ctx context.Context, arg database.GetVisitorsForDayParams) ([]database.Visitor, error) {
+	items, err := s.q.GetVisitorsForDay(ctx, sqlitedb.GetVisitorsForDayParams(arg))
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) GetWaitingVisitorsByPurposePublicID(ctx context.Context, purposePublicID string) ([]database.Visitor, error) {
+	items, err := s.q.GetWaitingVisitorsByPurposePublicID(ctx, purposePublicID)
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) GetWebhookDeliveriesBySubscriptionPublicID(ctx context.Context, arg database.GetWebhookDeliveriesBySubscriptionPublicIDParams) ([]database.WebhookDelivery, error) {
+	items, err := s.q.GetWebhookDeliveriesBySubscriptionPublicID(ctx, sqlitedb.GetWebhookDeliveriesBySubscriptionPublicIDParams(arg))
+	return convertRows(items, err, func(i sqlitedb.WebhookDelivery) database.WebhookDelivery { return database.WebhookDelivery(i) })
+}
+
+func (s *SQLite) GetWebhookDeliveryByPublicID(ctx context.Context, publicID string) (database.WebhookDelivery, error) {
+	i, err := s.q.GetWebhookDeliveryByPublicID(ctx, publicID)
+	return database.WebhookDelivery(i), err
+}
+
+func (s *SQLite) GetWebhookSubscriptionByPublicID(ctx context.Context, publicID string) (database.WebhookSubscription, error) {
+	i, err := s.q.GetWebhookSubscriptionByPublicID(ctx, publicID)
+	return database.WebhookSubscription(i), err
+}
+
+func (s *SQLite) GetWebhookSubscriptions(ctx context.Context) ([]database.WebhookSubscription, error) {
+	items, err := s.q.GetWebhookSubscriptions(ctx)
+	return convertRows(items, err, func(i sqlitedb.WebhookSubscription) database.WebhookSubscription {
+		return database.WebhookSubscription(i)
+	})
+}
+
+func (s *SQLite) GetWebhookSubscriptionsByEventType(ctx context.Context, eventType string) ([]database.WebhookSubscription, error) {
+	items, err := s.q.GetWebhookSubscriptionsByEventType(ctx, eventType)
+	return convertRows(items, err, func(i sqlitedb.WebhookSubscription) database.WebhookSubscription {
+		return database.WebhookSubscription(i)
+	})
+}
+
+func (s *SQLite) InvalidateUserTokens(ctx context.Context, arg database.InvalidateUserTokensParams) error {
+	return s.q.InvalidateUserTokens(ctx, sqlitedb.InvalidateUserTokensParams(arg))
+}
+
+func (s *SQLite) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	items, err := s.q.ListAuditEvents(ctx, sqlitedb.ListAuditEventsParams(arg))
+	return convertRows(items, err, func(i sqlitedb.AuditEvent) database.AuditEvent { return database.AuditEvent(i) })
+}
+
+func (s *SQLite) ListDesks(ctx context.Context, arg database.ListDesksParams) ([]database.Desk, error) {
+	items, err := s.q.ListDesks(ctx, sqlitedb.ListDesksParams(arg))
+	return convertRows(items, err, func(i sqlitedb.Desk) database.Desk { return database.Desk(i) })
+}
+
+func (s *SQLite) ListServiceLogs(ctx context.Context, arg database.ListServiceLogsParams) ([]database.ServiceLog, error) {
+	items, err := s.q.ListServiceLogs(ctx, sqlitedb.ListServiceLogsParams(arg))
+	return convertRows(items, err, func(i sqlitedb.ServiceLog) database.ServiceLog { return database.ServiceLog(i) })
+}
+
+func (s *SQLite) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	items, err := s.q.ListUsers(ctx, sqlitedb.ListUsersParams(arg))
+	return convertRows(items, err, func(i sqlitedb.User) database.User { return database.User(i) })
+}
+
+func (s *SQLite) ListVisitors(ctx context.Context, arg database.ListVisitorsParams) ([]database.Visitor, error) {
+	items, err := s.q.ListVisitors(ctx, sqlitedb.ListVisitorsParams(arg))
+	return convertRows(items, err, func(i sqlitedb.Visitor) database.Visitor { return database.Visitor(i) })
+}
+
+func (s *SQLite) RedeliverWebhookDelivery(ctx context.Context, arg database.RedeliverWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	i, err := s.q.RedeliverWebhookDelivery(ctx, sqlitedb.RedeliverWebhookDeliveryParams(arg))
+	return database.WebhookDelivery(i), err
+}
+
+func (s *SQLite) RevokeRefreshTokenByPublicID(ctx context.Context, publicID string) (database.RefreshToken, error) {
+	i, err := s.q.RevokeRefreshTokenByPublicID(ctx, publicID)
+	return database.RefreshToken(i), err
+}
+
+func (s *SQLite) RevokeRefreshTokenByToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	i, err := s.q.RevokeRefreshTokenByToken(ctx, token)
+	return database.RefreshToken(i), err
+}
+
+func (s *SQLite) RevokeRefreshTokenByUserPublicID(ctx context.Context, userPublicID string) ([]database.RefreshToken, error) {
+	items, err := s.q.RevokeRefreshTokenByUserPublicID(ctx, userPublicID)
+	return convertRows(items, err, func(i sqlitedb.RefreshToken) database.RefreshToken { return database.RefreshToken(i) })
+}
+
+func (s *SQLite) RevokeRefreshTokens(ctx context.Context) ([]database.RefreshToken, error) {
+	items, err := s.q.RevokeRefreshTokens(ctx)
+	return convertRows(items, err, func(i sqlitedb.RefreshToken) database.RefreshToken { return database.RefreshToken(i) })
+}
+
+func (s *SQLite) RotateRefreshTokenByToken(ctx context.Context, arg database.RotateRefreshTokenByTokenParams) (database.RefreshToken, error) {
+	i, err := s.q.RotateRefreshTokenByToken(ctx, sqlitedb.RotateRefreshTokenByTokenParams(arg))
+	return database.RefreshToken(i), err
+}
+
+func (s *SQLite) SetDesksByPublicID(ctx context.Context, arg database.SetDesksByPublicIDParams) (database.Desk, error) {
+	i, err := s.q.SetDesksByPublicID(ctx, sqlitedb.SetDesksByPublicIDParams(arg))
+	return database.Desk(i), err
+}
+
+func (s *SQLite) SetIdempotencyKeyResponse(ctx context.Context, arg database.SetIdempotencyKeyResponseParams) error {
+	return s.q.SetIdempotencyKeyResponse(ctx, sqlitedb.SetIdempotencyKeyResponseParams(arg))
+}
+
+func (s *SQLite) SetLocationByPublicID(ctx context.Context, arg database.SetLocationByPublicIDParams) (database.Location, error) {
+	i, err := s.q.SetLocationByPublicID(ctx, sqlitedb.SetLocationByPublicIDParams(arg))
+	return database.Location(i), err
+}
+
+func (s *SQLite) SetPurpose(ctx context.Context, arg database.SetPurposeParams) (database.Purpose, error) {
+	i, err := s.q.SetPurpose(ctx, sqlitedb.SetPurposeParams(arg))
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) SetPurposeByPublicID(ctx context.Context, arg database.SetPurposeByPublicIDParams) (database.Purpose, error) {
+	i, err := s.q.SetPurposeByPublicID(ctx, sqlitedb.SetPurposeByPublicIDParams(arg))
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) SetPurposeName(ctx context.Context, arg database.SetPurposeNameParams) (database.Purpose, error) {
+	i, err := s.q.SetPurposeName(ctx, sqlitedb.SetPurposeNameParams(arg))
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) SetPurposeParentID(ctx context.Context, arg database.SetPurposeParentIDParams) (database.Purpose, error) {
+	i, err := s.q.SetPurposeParentID(ctx, sqlitedb.SetPurposeParentIDParams(arg))
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) SetPurposeParentIDByParentPurposeName(ctx context.Context, arg database.SetPurposeParentIDByParentPurposeNameParams) (database.Purpose, error) {
+	i, err := s.q.SetPurposeParentIDByParentPurposeName(ctx, sqlitedb.SetPurposeParentIDByParentPurposeNameParams(arg))
+	return database.Purpose(i), err
+}
+
+func (s *SQLite) SetServiceLogsByPublicID(ctx context.Context, arg database.SetServiceLogsByPublicIDParams) (database.ServiceLog, error) {
+	i, err := s.q.SetServiceLogsByPublicID(ctx, sqlitedb.SetServiceLogsByPublicIDParams(arg))
+	return database.ServiceLog(i), err
+}
+
+func (s *SQLite) SetUserByPublicID(ctx context.Context, arg database.SetUserByPublicIDParams) (database.User, error) {
+	i, err := s.q.SetUserByPublicID(ctx, sqlitedb.SetUserByPublicIDParams(arg))
+	return database.User(i), err
+}
+
+func (s *SQLite) SetUserEmailPasswordByID(ctx context.Context, arg database.SetUserEmailPasswordByIDParams) (database.User, error) {
+	i, err := s.q.SetUserEmailPasswordByID(ctx, sqlitedb.SetUserEmailPasswordByIDParams(arg))
+	return database.User(i), err
+}
+
+func (s *SQLite) SetUserFullNameByID(ctx context.Context, arg database.SetUserFullNameByIDParams) (database.User, error) {
+	i, err := s.q.SetUserFullNameByID(ctx, sqlitedb.SetUserFullNameByIDParams(arg))
+	return database.User(i), err
+}
+
+func (s *SQLite) SetUserInactiveByID(ctx context.Context, arg database.SetUserInactiveByIDParams) (database.User, error) {
+	i, err := s.q.SetUserInactiveByID(ctx, sqlitedb.SetUserInactiveByIDParams(arg))
+	return database.User(i), err
+}
+
+func (s *SQLite) SetUserIsAdminByID(ctx context.Context, arg database.SetUserIsAdminByIDParams) (database.User, error) {
+	i, err := s.q.SetUserIsAdminByID(ctx, sqlitedb.SetUserIsAdminByIDParams(arg))
+	return database.User(i), err
+}
+
+func (s *SQLite) SetUserPasswordByPublicID(ctx context.Context, arg database.SetUserPasswordByPublicIDParams) (database.User, error) {
+	i, err := s.q.SetUserPasswordByPublicID(ctx, sqlitedb.SetUserPasswordByPublicIDParams(arg))
+	return database.User(i), err
+}
+
+func (s *SQLite) SetVisitorByPublicID(ctx context.Context, arg database.SetVisitorByPublicIDParams) (database.Visitor, error) {
+	i, err := s.q.SetVisitorByPublicID(ctx, sqlitedb.SetVisitorByPublicIDParams(arg))
+	return database.Visitor(i), err
+}
+
+func (s *SQLite) SetVisitorNotifiedAt(ctx context.Context, arg database.SetVisitorNotifiedAtParams) (int64, error) {
+	return s.q.SetVisitorNotifiedAt(ctx, sqlitedb.SetVisitorNotifiedAtParams(arg))
+}
+
+func (s *SQLite) SetVisitorStatusByID(ctx context.Context, arg database.SetVisitorStatusByIDParams) (database.Visitor, error) {
+	i, err := s.q.SetVisitorStatusByID(ctx, sqlitedb.SetVisitorStatusByIDParams(arg))
+	return database.Visitor(i), err
+}
+
+func (s *SQLite) SetWebhookDeliveryResult(ctx context.Context, arg database.SetWebhookDeliveryResultParams) (database.WebhookDelivery, error) {
+	i, err := s.q.SetWebhookDeliveryResult(ctx, sqlitedb.SetWebhookDeliveryResultParams(arg))
+	return database.WebhookDelivery(i), err
+}
+
+func (s *SQLite) UpdateTicketCounter(ctx context.Context, arg database.UpdateTicketCounterParams) (int32, error) {
+	return s.q.UpdateTicketCounter(ctx, sqlitedb.UpdateTicketCounterParams(arg))
+}
+
+func (s *SQLite) UpsertTicketLayout(ctx context.Context, arg database.UpsertTicketLayoutParams) (database.TicketLayout, error) {
+	i, err := s.q.UpsertTicketLayout(ctx, sqlitedb.UpsertTicketLayoutParams(arg))
+	return database.TicketLayout(i), err
+}
@@ -0,0 +1,31 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/dcrauwels/goqueue/migrate"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/dcrauwels/goqueue/storage/storagetest"
+)
+
+func TestSQLite(t *testing.T) {
+	db, engine, err := storage.OpenDB("sqlite:" + filepath.Join(t.TempDir(), "goqueue.db"))
+	if err != nil {
+		t.Fatalf(`OpenDB: %v`, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, engine)
+	if err != nil {
+		t.Fatalf(`migrate.New: %v`, err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf(`Up: %v`, err)
+	}
+	store := storage.New(db, engine)
+
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		return store
+	})
+}
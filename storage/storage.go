@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+)
+
+// Store is the repository behind the api handlers: every query of database.Querier plus transactions. Postgres is the
+// production implementation, SQLite one for small deployments and Memory a complete in-memory one for tests.
+type Store interface {
+	database.Querier
+	// InTx runs fn with a Store bound to a single transaction, which is committed if fn returns nil and rolled back
+	// otherwise. Calling InTx on a Store that is already bound to a transaction runs fn in that same transaction.
+	// Transactions are serializable. Those that fail on a conflict with a concurrent transaction are retried, so fn
+	// may run more than once and should do nothing but query q (and set the variables it returns its results in).
+	InTx(ctx context.Context, fn func(s Store) error) error
+}
+
+var _ Store = (*Postgres)(nil)
+var _ Store = (*Memory)(nil)
+var _ Store = (*SQLite)(nil)
+
+// Engine is the kind of database a Store runs on.
+type Engine string
+
+const (
+	EnginePostgres Engine = "postgres"
+	EngineSQLite   Engine = "sqlite"
+)
+
+func OpenDB(dbURL string) (*sql.DB, Engine, error) {
+	/*
+		Opens the database for DB_URL, chosen by its scheme:
+		- postgres:// or postgresql:// is a PostgreSQL connection string (requires the lib/pq driver to be imported).
+		- sqlite:path (or sqlite://path) is an SQLite database file, relative to the working directory unless path is
+		absolute, e.g. sqlite:goqueue.db or sqlite:///var/lib/goqueue/goqueue.db.
+	*/
+	scheme, rest, found := strings.Cut(dbURL, ":")
+	if !found {
+		return nil, "", fmt.Errorf("DB_URL has no scheme, expected postgres:// or sqlite:")
+	}
+	switch scheme {
+	case "postgres", "postgresql":
+		db, err := sql.Open("postgres", dbURL)
+		return db, EnginePostgres, err
+	case "sqlite":
+		path := strings.TrimPrefix(rest, "//")
+		if path == "" {
+			return nil, "", fmt.Errorf("DB_URL has no SQLite database file")
+		}
+		db, err := openSQLiteDB(path)
+		return db, EngineSQLite, err
+	default:
+		return nil, "", fmt.Errorf("DB_URL has unsupported scheme %q, expected postgres:// or sqlite:", scheme)
+	}
+}
+
+func New(db *sql.DB, engine Engine) Store {
+	// the Store for a database opened by OpenDB
+	if engine == EngineSQLite {
+		return NewSQLite(db)
+	}
+	return NewPostgres(db)
+}
+
+// maxTxAttempts is how often InTx runs a transaction that keeps failing on conflicts with concurrent transactions.
+const maxTxAttempts = 3
+
+func retryTx(ctx context.Context, isConflict func(error) bool, run func() error) error {
+	/*
+		Runs the transaction run until it succeeds, fails on something other than a conflict, or has failed
+		maxTxAttempts times. Waits a little longer between every attempt, to let the other transaction finish.
+	*/
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = run()
+		if err == nil || !isConflict(err) || attempt == maxTxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+		}
+	}
+	return err
+}
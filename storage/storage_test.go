@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestRetryTx(t *testing.T) {
+	errConflict := &pq.Error{Code: "40001"}
+	errUnique := &pq.Error{Code: "23505"}
+	errOther := errors.New("other")
+	tests := []struct {
+		name         string
+		errs         []error // returned by consecutive attempts
+		wantErr      error
+		wantAttempts int
+	}{
+		{"success", []error{nil}, nil, 1},
+		{"conflict then success", []error{errConflict, fmt.Errorf("wrapped: %w", &pq.Error{Code: "40P01"}), nil}, nil, 3},
+		{"other error", []error{errOther}, errOther, 1},
+		{"conflict every attempt", []error{errConflict, errConflict, errConflict, nil}, errConflict, maxTxAttempts},
+		{"constraint violation", []error{errUnique}, errUnique, 1},
+	}
+	for _, tt := range tests {
+		attempts := 0
+		err := retryTx(context.Background(), isPostgresConflict, func() error {
+			attempts++
+			return tt.errs[attempts-1]
+		})
+		if attempts != tt.wantAttempts {
+			t.Errorf(`%s: retryTx ran %d attempts, expected %d`, tt.name, attempts, tt.wantAttempts)
+		}
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf(`%s: retryTx returned %v, expected %v`, tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,1398 @@
+// Package storagetest is the conformance test suite for storage.Store implementations. Every backend runs it from its
+// own tests, so the in-memory store used by the api tests cannot drift from the SQL queries.
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/google/uuid"
+)
+
+// Run runs the suite against the stores made by newStore. A store may be shared between tests and may already hold
+// rows, as is the case for a test database: tests only look at the rows they created themselves.
+func Run(t *testing.T, newStore func(t *testing.T) storage.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Store)
+	}{
+		{"Users", testUsers},
+		{"Locations", testLocations},
+		{"Purposes", testPurposes},
+		{"PurposeTranslations", testPurposeTranslations},
+		{"TicketLayouts", testTicketLayouts},
+		{"Desks", testDesks},
+		{"TicketCounter", testTicketCounter},
+		{"OpeningHours", testOpeningHours},
+		{"Holidays", testHolidays},
+		{"AnnouncementTemplates", testAnnouncementTemplates},
+		{"Visitors", testVisitors},
+		{"Pagination", testPagination},
+		{"ServiceLogs", testServiceLogs},
+		{"Retention", testRetention},
+		{"Erasure", testErasure},
+		{"RefreshTokens", testRefreshTokens},
+		{"UserTokens", testUserTokens},
+		{"LoginAttempts", testLoginAttempts},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"AuditEvents", testAuditEvents},
+		{"Webhooks", testWebhooks},
+		{"Transactions", testTransactions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+func newPublicID() string {
+	return uuid.NewString()
+}
+
+func createUser(t *testing.T, s storage.Store) database.User {
+	t.Helper()
+	u, err := s.CreateUser(context.Background(), database.CreateUserParams{
+		PublicID:       newPublicID(),
+		Email:          newPublicID() + "@example.org",
+		HashedPassword: "hash",
+		FullName:       "Test User",
+	})
+	if err != nil {
+		t.Fatalf(`CreateUser: %v`, err)
+	}
+	return u
+}
+
+func createLocation(t *testing.T, s storage.Store) database.Location {
+	t.Helper()
+	l, err := s.CreateLocation(context.Background(), database.CreateLocationParams{
+		PublicID: newPublicID(),
+		Name:     newPublicID(),
+		TimeZone: "UTC",
+	})
+	if err != nil {
+		t.Fatalf(`CreateLocation: %v`, err)
+	}
+	return l
+}
+
+// createPurpose and createDesk create a location of their own, createVisitor uses the location of its purpose.
+
+func createPurpose(t *testing.T, s storage.Store, parentPurposeID uuid.NullUUID) database.Purpose {
+	t.Helper()
+	p, err := s.CreatePurpose(context.Background(), database.CreatePurposeParams{
+		PublicID:         newPublicID(),
+		PurposeName:      newPublicID(),
+		ParentPurposeID:  parentPurposeID,
+		LocationPublicID: createLocation(t, s).PublicID,
+	})
+	if err != nil {
+		t.Fatalf(`CreatePurpose: %v`, err)
+	}
+	return p
+}
+
+func createDesk(t *testing.T, s storage.Store) database.Desk {
+	t.Helper()
+	d, err := s.CreateDesks(context.Background(), database.CreateDesksParams{
+		PublicID:         newPublicID(),
+		Name:             newPublicID(),
+		LocationPublicID: createLocation(t, s).PublicID,
+	})
+	if err != nil {
+		t.Fatalf(`CreateDesks: %v`, err)
+	}
+	return d
+}
+
+func createVisitor(t *testing.T, s storage.Store, purposePublicID string) database.Visitor {
+	t.Helper()
+	purpose, err := s.GetPurposesByPublicID(context.Background(), purposePublicID)
+	if err != nil {
+		t.Fatalf(`GetPurposesByPublicID: %v`, err)
+	}
+	v, err := s.CreateVisitor(context.Background(), database.CreateVisitorParams{
+		PublicID:          newPublicID(),
+		Name:              sql.NullString{String: "Visitor", Valid: true},
+		PurposePublicID:   purposePublicID,
+		DailyTicketNumber: 1,
+		LocationPublicID:  purpose.LocationPublicID,
+		PhoneNumber:       sql.NullString{String: "+31201234567", Valid: true},
+		Email:             sql.NullString{String: "visitor@example.org", Valid: true},
+		CreatedAt:         time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf(`CreateVisitor: %v`, err)
+	}
+	return v
+}
+
+func publicIDs[T any](rows []T, publicID func(T) string) []string {
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = publicID(row)
+	}
+	return ids
+}
+
+func equalIDs(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testUsers(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	u := createUser(t, s)
+	if !u.IsActive || u.IsAdmin || u.ID == uuid.Nil || u.CreatedAt.IsZero() {
+		t.Errorf(`CreateUser returned %+v, expected an active non-admin with ID and timestamps`, u)
+	}
+
+	for name, get := range map[string]func() (database.User, error){
+		"GetUserByID":       func() (database.User, error) { return s.GetUserByID(ctx, u.ID) },
+		"GetUserByPublicID": func() (database.User, error) { return s.GetUserByPublicID(ctx, u.PublicID) },
+		"GetUserByEmail":    func() (database.User, error) { return s.GetUserByEmail(ctx, u.Email) },
+	} {
+		got, err := get()
+		if err != nil || got.ID != u.ID {
+			t.Errorf(`%s returned %v, %v; expected user %v`, name, got.ID, err, u.ID)
+		}
+	}
+	if _, err := s.GetUserByPublicID(ctx, newPublicID()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetUserByPublicID for an unknown user returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	_, err := s.CreateUser(ctx, database.CreateUserParams{PublicID: newPublicID(), Email: u.Email, FullName: "Duplicate"})
+	if err == nil {
+		t.Errorf(`CreateUser with a duplicate email succeeded, expected a constraint violation`)
+	}
+
+	updated, err := s.SetUserByPublicID(ctx, database.SetUserByPublicIDParams{
+		PublicID: u.PublicID,
+		Email:    u.Email,
+		FullName: "Renamed",
+		IsAdmin:  true,
+		IsActive: false,
+	})
+	if err != nil || updated.FullName != "Renamed" || !updated.IsAdmin || updated.IsActive {
+		t.Errorf(`SetUserByPublicID returned %+v, %v`, updated, err)
+	}
+	if _, err := s.SetUserIsAdminByID(ctx, database.SetUserIsAdminByIDParams{ID: uuid.New(), IsAdmin: true}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`SetUserIsAdminByID for an unknown user returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	deleted, err := s.DeleteUserByID(ctx, u.ID)
+	if err != nil || deleted.ID != u.ID {
+		t.Errorf(`DeleteUserByID returned %v, %v`, deleted.ID, err)
+	}
+	if _, err := s.GetUserByID(ctx, u.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetUserByID after DeleteUserByID returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testLocations(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	location := createLocation(t, s)
+	if _, err := s.CreateLocation(ctx, database.CreateLocationParams{PublicID: location.PublicID, Name: "duplicate"}); err == nil {
+		t.Errorf(`CreateLocation with a duplicate public ID succeeded, expected a constraint violation`)
+	}
+	renamed, err := s.SetLocationByPublicID(ctx, database.SetLocationByPublicIDParams{
+		PublicID:           location.PublicID,
+		Name:               "renamed " + location.Name,
+		TimeZone:           "Europe/Amsterdam",
+		StopIssuingMinutes: 15,
+	})
+	if err != nil || renamed.Name != "renamed "+location.Name || renamed.TimeZone != "Europe/Amsterdam" || renamed.StopIssuingMinutes != 15 || renamed.UpdatedAt.Before(location.UpdatedAt) {
+		t.Errorf(`SetLocationByPublicID returned %+v, %v`, renamed, err)
+	}
+	if got, err := s.GetLocationByPublicID(ctx, location.PublicID); err != nil || got.Name != renamed.Name {
+		t.Errorf(`GetLocationByPublicID returned %+v, %v; expected %+v`, got, err, renamed)
+	}
+	if _, err := s.GetLocationByPublicID(ctx, newPublicID()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetLocationByPublicID for an unknown location returned %v, expected sql.ErrNoRows`, err)
+	}
+	all, err := s.GetLocations(ctx)
+	if err != nil || !slices.ContainsFunc(all, func(l database.Location) bool { return l.PublicID == location.PublicID }) {
+		t.Errorf(`GetLocations returned %v, %v; expected it to include %v`, all, err, location.PublicID)
+	}
+
+	// users are assigned to locations, assigning twice is harmless
+	user, other := createUser(t, s), createLocation(t, s)
+	for _, l := range []database.Location{location, other, location} {
+		if err := s.AddUserLocation(ctx, database.AddUserLocationParams{UserPublicID: user.PublicID, LocationPublicID: l.PublicID}); err != nil {
+			t.Fatalf(`AddUserLocation: %v`, err)
+		}
+	}
+	if err := s.AddUserLocation(ctx, database.AddUserLocationParams{UserPublicID: user.PublicID, LocationPublicID: newPublicID()}); err == nil {
+		t.Errorf(`AddUserLocation with an unknown location succeeded, expected a constraint violation`)
+	}
+	locations, err := s.GetLocationsByUserPublicID(ctx, user.PublicID)
+	want := []database.Location{renamed, other}
+	slices.SortFunc(want, func(a, b database.Location) int { return strings.Compare(a.Name, b.Name) })
+	byPublicID := func(l database.Location) string { return l.PublicID }
+	if err != nil || !equalIDs(publicIDs(locations, byPublicID), publicIDs(want, byPublicID)) {
+		t.Errorf(`GetLocationsByUserPublicID returned %v, %v; expected %v ordered by name`, locations, err, want)
+	}
+
+	// the List and Count queries filter by location, or by the locations of a user
+	desk, err := s.CreateDesks(ctx, database.CreateDesksParams{PublicID: newPublicID(), Name: newPublicID(), LocationPublicID: location.PublicID})
+	if err != nil || desk.LocationPublicID != location.PublicID {
+		t.Fatalf(`CreateDesks returned %+v, %v`, desk, err)
+	}
+	elsewhere := createDesk(t, s)
+	if _, err := s.CreateDesks(ctx, database.CreateDesksParams{PublicID: newPublicID(), Name: newPublicID(), LocationPublicID: newPublicID()}); err == nil {
+		t.Errorf(`CreateDesks in an unknown location succeeded, expected a constraint violation`)
+	}
+	deskIDs := func(params database.CountDesksParams) []string {
+		t.Helper()
+		desks, err := s.ListDesks(ctx, database.ListDesksParams{
+			LocationPublicID: params.LocationPublicID,
+			MemberPublicID:   params.MemberPublicID,
+			Sort:             "name",
+			RowLimit:         1000,
+		})
+		if err != nil {
+			t.Fatalf(`ListDesks: %v`, err)
+		}
+		count, err := s.CountDesks(ctx, params)
+		if err != nil || count != int64(len(desks)) {
+			t.Errorf(`CountDesks returned %d, %v; expected %d`, count, err, len(desks))
+		}
+		return publicIDs(desks, func(d database.Desk) string { return d.PublicID })
+	}
+	if got := deskIDs(database.CountDesksParams{LocationPublicID: sql.NullString{String: location.PublicID, Valid: true}}); !equalIDs(got, []string{desk.PublicID}) {
+		t.Errorf(`ListDesks in location %v returned %v, expected only %v`, location.PublicID, got, desk.PublicID)
+	}
+	if got := deskIDs(database.CountDesksParams{MemberPublicID: sql.NullString{String: user.PublicID, Valid: true}}); !equalIDs(got, []string{desk.PublicID}) {
+		t.Errorf(`ListDesks for member %v returned %v, expected only %v`, user.PublicID, got, desk.PublicID)
+	}
+	if got := deskIDs(database.CountDesksParams{}); !slices.Contains(got, elsewhere.PublicID) {
+		t.Errorf(`unfiltered ListDesks returned %v, expected it to include %v`, got, elsewhere.PublicID)
+	}
+
+	if err := s.DeleteUserLocations(ctx, user.PublicID); err != nil {
+		t.Fatalf(`DeleteUserLocations: %v`, err)
+	}
+	if locations, err := s.GetLocationsByUserPublicID(ctx, user.PublicID); err != nil || len(locations) != 0 {
+		t.Errorf(`GetLocationsByUserPublicID after DeleteUserLocations returned %v, %v; expected none`, locations, err)
+	}
+	if got := deskIDs(database.CountDesksParams{MemberPublicID: sql.NullString{String: user.PublicID, Valid: true}}); len(got) != 0 {
+		t.Errorf(`ListDesks for a member of no location returned %v, expected none`, got)
+	}
+}
+
+func testPurposes(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	parent := createPurpose(t, s, uuid.NullUUID{})
+	child := createPurpose(t, s, uuid.NullUUID{UUID: parent.ID, Valid: true})
+
+	children, err := s.GetPurposesByParent(ctx, uuid.NullUUID{UUID: parent.ID, Valid: true})
+	if err != nil || !equalIDs(publicIDs(children, func(p database.Purpose) string { return p.PublicID }), []string{child.PublicID}) {
+		t.Errorf(`GetPurposesByParent returned %v, %v; expected only the child purpose`, children, err)
+	}
+	if _, err := s.CreatePurpose(ctx, database.CreatePurposeParams{
+		PublicID:        newPublicID(),
+		PurposeName:     newPublicID(),
+		ParentPurposeID: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+	}); err == nil {
+		t.Errorf(`CreatePurpose with an unknown parent succeeded, expected a constraint violation`)
+	}
+
+	got, err := s.GetPurposesByName(ctx, child.PurposeName)
+	if err != nil || got.PublicID != child.PublicID {
+		t.Errorf(`GetPurposesByName returned %v, %v`, got.PublicID, err)
+	}
+
+	// setting the parent by name, an unknown name clears the parent
+	got, err = s.SetPurposeParentIDByParentPurposeName(ctx, database.SetPurposeParentIDByParentPurposeNameParams{ID: child.ID, PurposeName: newPublicID()})
+	if err != nil || got.ParentPurposeID.Valid {
+		t.Errorf(`SetPurposeParentIDByParentPurposeName with an unknown name returned %+v, %v; expected no parent`, got, err)
+	}
+	got, err = s.SetPurposeParentIDByParentPurposeName(ctx, database.SetPurposeParentIDByParentPurposeNameParams{ID: child.ID, PurposeName: parent.PurposeName})
+	if err != nil || got.ParentPurposeID.UUID != parent.ID {
+		t.Errorf(`SetPurposeParentIDByParentPurposeName returned %+v, %v; expected parent %v`, got, err, parent.ID)
+	}
+
+	capacity := sql.NullInt32{Int32: 25, Valid: true}
+	got, err = s.SetPurposeByPublicID(ctx, database.SetPurposeByPublicIDParams{PublicID: child.PublicID, PurposeName: "renamed", DailyCapacity: capacity})
+	if err != nil || got.PurposeName != "renamed" || got.ParentPurposeID.Valid || got.DailyCapacity != capacity {
+		t.Errorf(`SetPurposeByPublicID returned %+v, %v`, got, err)
+	}
+	if _, err := s.SetPurposeByPublicID(ctx, database.SetPurposeByPublicIDParams{PublicID: newPublicID()}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`SetPurposeByPublicID for an unknown purpose returned %v, expected sql.ErrNoRows`, err)
+	}
+	stale := sql.NullTime{Time: got.UpdatedAt.Add(-time.Second), Valid: true}
+	if _, err := s.SetPurposeByPublicID(ctx, database.SetPurposeByPublicIDParams{PublicID: child.PublicID, PurposeName: "stale", IfUpdatedAt: stale}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`SetPurposeByPublicID for an outdated version returned %v, expected sql.ErrNoRows`, err)
+	}
+	current := sql.NullTime{Time: got.UpdatedAt, Valid: true}
+	if got, err := s.SetPurposeByPublicID(ctx, database.SetPurposeByPublicIDParams{PublicID: child.PublicID, PurposeName: "renamed again", IfUpdatedAt: current}); err != nil || got.PurposeName != "renamed again" || got.DailyCapacity.Valid {
+		t.Errorf(`SetPurposeByPublicID for the current version returned %+v, %v`, got, err)
+	}
+}
+
+func testAnnouncementTemplates(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	purpose := createPurpose(t, s, uuid.NullUUID{})
+	create := func(purposePublicID sql.NullString, language, template string) (database.AnnouncementTemplate, error) {
+		return s.CreateAnnouncementTemplate(ctx, database.CreateAnnouncementTemplateParams{
+			PublicID:         newPublicID(),
+			LocationPublicID: purpose.LocationPublicID,
+			PurposePublicID:  purposePublicID,
+			Language:         language,
+			Template:         template,
+		})
+	}
+	english, err := create(sql.NullString{}, "en", "")
+	if err != nil {
+		t.Fatalf(`CreateAnnouncementTemplate: %v`, err)
+	}
+	dutch, err := create(sql.NullString{String: purpose.PublicID, Valid: true}, "nl", "Nummer {ticket}, balie {desk}")
+	if err != nil || dutch.PurposePublicID.String != purpose.PublicID || dutch.Template != "Nummer {ticket}, balie {desk}" {
+		t.Fatalf(`CreateAnnouncementTemplate for a purpose returned %+v, %v`, dutch, err)
+	}
+	if _, err := create(sql.NullString{String: newPublicID(), Valid: true}, "de", ""); err == nil {
+		t.Errorf(`CreateAnnouncementTemplate for an unknown purpose succeeded, expected a constraint violation`)
+	}
+
+	byPublicID := func(a database.AnnouncementTemplate) string { return a.PublicID }
+	got, err := s.GetAnnouncementTemplatesByLocationPublicID(ctx, purpose.LocationPublicID)
+	if want := []string{english.PublicID, dutch.PublicID}; err != nil || !slices.Equal(publicIDs(got, byPublicID), want) {
+		t.Errorf(`GetAnnouncementTemplatesByLocationPublicID returned %v, %v; expected %v in order of creation`, publicIDs(got, byPublicID), err, want)
+	}
+	if a, err := s.GetAnnouncementTemplateByPublicID(ctx, dutch.PublicID); err != nil || a.Language != "nl" {
+		t.Errorf(`GetAnnouncementTemplateByPublicID returned %+v, %v`, a, err)
+	}
+	if err := s.DeleteAnnouncementTemplateByPublicID(ctx, dutch.PublicID); err != nil {
+		t.Fatalf(`DeleteAnnouncementTemplateByPublicID: %v`, err)
+	}
+	if _, err := s.GetAnnouncementTemplateByPublicID(ctx, dutch.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetAnnouncementTemplateByPublicID after DeleteAnnouncementTemplateByPublicID returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testPurposeTranslations(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	purpose := createPurpose(t, s, uuid.NullUUID{})
+	create := func(purposePublicID, language, name string) error {
+		_, err := s.CreatePurposeTranslation(ctx, database.CreatePurposeTranslationParams{PurposePublicID: purposePublicID, Language: language, Name: name})
+		return err
+	}
+	for _, language := range []string{"nl", "de"} {
+		if err := create(purpose.PublicID, language, "name in "+language); err != nil {
+			t.Fatalf(`CreatePurposeTranslation: %v`, err)
+		}
+	}
+	if err := create(purpose.PublicID, "nl", "other name"); err == nil {
+		t.Errorf(`CreatePurposeTranslation for a language twice succeeded, expected a constraint violation`)
+	}
+	if err := create(newPublicID(), "nl", "name"); err == nil {
+		t.Errorf(`CreatePurposeTranslation for an unknown purpose succeeded, expected a constraint violation`)
+	}
+
+	byLanguage := func(t database.PurposeTranslation) string { return t.Language }
+	got, err := s.GetPurposeTranslationsByPurposePublicID(ctx, purpose.PublicID)
+	if want := []string{"de", "nl"}; err != nil || !slices.Equal(publicIDs(got, byLanguage), want) || got[0].Name != "name in de" {
+		t.Errorf(`GetPurposeTranslationsByPurposePublicID returned %+v, %v; expected %v`, got, err, want)
+	}
+	all, err := s.GetPurposeTranslations(ctx)
+	if err != nil || len(slices.DeleteFunc(all, func(t database.PurposeTranslation) bool { return t.PurposePublicID != purpose.PublicID })) != 2 {
+		t.Errorf(`GetPurposeTranslations returned %+v, %v; expected both translations among them`, all, err)
+	}
+	if err := s.DeletePurposeTranslationsByPurposePublicID(ctx, purpose.PublicID); err != nil {
+		t.Fatalf(`DeletePurposeTranslationsByPurposePublicID: %v`, err)
+	}
+	if got, err := s.GetPurposeTranslationsByPurposePublicID(ctx, purpose.PublicID); err != nil || len(got) != 0 {
+		t.Errorf(`GetPurposeTranslationsByPurposePublicID after DeletePurposeTranslationsByPurposePublicID returned %+v, %v`, got, err)
+	}
+}
+
+func testTicketLayouts(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	purpose := createPurpose(t, s, uuid.NullUUID{})
+	if _, err := s.GetTicketLayoutByPurposePublicID(ctx, purpose.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetTicketLayoutByPurposePublicID without layout returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	// the first upsert creates the layout, the second replaces it
+	layout := database.UpsertTicketLayoutParams{PurposePublicID: purpose.PublicID, Header: "Welcome", Footer: "Goodbye", Logo: []byte{0x89, 'P', 'N', 'G'}}
+	created, err := s.UpsertTicketLayout(ctx, layout)
+	if err != nil || created.Header != "Welcome" || created.Footer != "Goodbye" || string(created.Logo) != "\x89PNG" {
+		t.Fatalf(`UpsertTicketLayout returned %+v, %v`, created, err)
+	}
+	layout.Header, layout.Logo = "Hello", nil
+	updated, err := s.UpsertTicketLayout(ctx, layout)
+	if err != nil || updated.Header != "Hello" || updated.Logo != nil || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf(`second UpsertTicketLayout returned %+v, %v; expected the same layout changed`, updated, err)
+	}
+	if got, err := s.GetTicketLayoutByPurposePublicID(ctx, purpose.PublicID); err != nil || got.Header != "Hello" || got.Footer != "Goodbye" {
+		t.Errorf(`GetTicketLayoutByPurposePublicID returned %+v, %v`, got, err)
+	}
+	if _, err := s.UpsertTicketLayout(ctx, database.UpsertTicketLayoutParams{PurposePublicID: newPublicID()}); err == nil {
+		t.Errorf(`UpsertTicketLayout for an unknown purpose succeeded`)
+	}
+}
+
+func testDesks(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	d := createDesk(t, s)
+	if !d.IsActive {
+		t.Errorf(`CreateDesks returned an inactive desk`)
+	}
+
+	updated, err := s.SetDesksByPublicID(ctx, database.SetDesksByPublicIDParams{
+		PublicID:    d.PublicID,
+		Name:        d.Name,
+		Description: sql.NullString{String: "by the window", Valid: true},
+		IsActive:    false,
+	})
+	if err != nil || updated.IsActive || updated.Description.String != "by the window" || updated.UpdatedAt.Before(d.UpdatedAt) {
+		t.Errorf(`SetDesksByPublicID returned %+v, %v`, updated, err)
+	}
+
+	// a conditional update only applies to the version it was made for
+	stale := sql.NullTime{Time: updated.UpdatedAt.Add(-time.Second), Valid: true}
+	if _, err := s.SetDesksByPublicID(ctx, database.SetDesksByPublicIDParams{PublicID: d.PublicID, Name: "stale", IfUpdatedAt: stale}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`SetDesksByPublicID for an outdated version returned %v, expected sql.ErrNoRows`, err)
+	}
+	current := sql.NullTime{Time: updated.UpdatedAt, Valid: true}
+	if got, err := s.SetDesksByPublicID(ctx, database.SetDesksByPublicIDParams{PublicID: d.PublicID, Name: d.Name, Description: updated.Description, IfUpdatedAt: current}); err != nil || got.Name != d.Name {
+		t.Errorf(`SetDesksByPublicID for the current version returned %+v, %v`, got, err)
+	}
+
+	inactive, err := s.ListDesks(ctx, database.ListDesksParams{IsActive: sql.NullBool{Bool: false, Valid: true}, Sort: "name", RowLimit: 1000})
+	if err != nil {
+		t.Fatalf(`ListDesks: %v`, err)
+	}
+	found := false
+	for _, desk := range inactive {
+		found = found || desk.PublicID == d.PublicID
+		if desk.IsActive {
+			t.Errorf(`ListDesks for inactive desks returned active desk %v`, desk.PublicID)
+		}
+	}
+	if !found {
+		t.Errorf(`ListDesks for inactive desks did not return the deactivated desk`)
+	}
+	for i := 1; i < len(inactive); i++ {
+		if inactive[i-1].Name > inactive[i].Name {
+			t.Errorf(`ListDesks is not ordered by name: %q before %q`, inactive[i-1].Name, inactive[i].Name)
+		}
+	}
+}
+
+func testTicketCounter(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	location := createLocation(t, s)
+	today := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	params := database.UpdateTicketCounterParams{LocationPublicID: location.PublicID, CounterDate: today}
+	first, err := s.UpdateTicketCounter(ctx, params)
+	if err != nil || first != 1 {
+		t.Fatalf(`UpdateTicketCounter returned %v, %v for a new location; expected 1`, first, err)
+	}
+	second, err := s.UpdateTicketCounter(ctx, params)
+	if err != nil || second != 2 {
+		t.Errorf(`UpdateTicketCounter returned %v, %v after %v; expected 2`, second, err, first)
+	}
+
+	// every location and every date has a counter of its own
+	if other, err := s.UpdateTicketCounter(ctx, database.UpdateTicketCounterParams{LocationPublicID: createLocation(t, s).PublicID, CounterDate: today}); err != nil || other != 1 {
+		t.Errorf(`UpdateTicketCounter returned %v, %v for another location; expected 1`, other, err)
+	}
+	if tomorrow, err := s.UpdateTicketCounter(ctx, database.UpdateTicketCounterParams{LocationPublicID: location.PublicID, CounterDate: today.AddDate(0, 0, 1)}); err != nil || tomorrow != 1 {
+		t.Errorf(`UpdateTicketCounter returned %v, %v for the next day; expected 1`, tomorrow, err)
+	}
+	if _, err := s.UpdateTicketCounter(ctx, database.UpdateTicketCounterParams{LocationPublicID: newPublicID(), CounterDate: today}); err == nil {
+		t.Errorf(`UpdateTicketCounter for an unknown location succeeded, expected a constraint violation`)
+	}
+}
+
+func testOpeningHours(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	purpose := createPurpose(t, s, uuid.NullUUID{})
+	create := func(purposePublicID sql.NullString, weekday, opensAt, closesAt int32) (database.OpeningHour, error) {
+		return s.CreateOpeningHour(ctx, database.CreateOpeningHourParams{
+			PublicID:         newPublicID(),
+			LocationPublicID: purpose.LocationPublicID,
+			PurposePublicID:  purposePublicID,
+			Weekday:          weekday,
+			OpensAt:          opensAt,
+			ClosesAt:         closesAt,
+		})
+	}
+	afternoon, err := create(sql.NullString{}, 1, 780, 1020)
+	if err != nil {
+		t.Fatalf(`CreateOpeningHour: %v`, err)
+	}
+	morning, err := create(sql.NullString{String: purpose.PublicID, Valid: true}, 1, 540, 720)
+	if err != nil || morning.PurposePublicID.String != purpose.PublicID {
+		t.Fatalf(`CreateOpeningHour for a purpose returned %+v, %v`, morning, err)
+	}
+	sunday, err := create(sql.NullString{}, 0, 600, 1440)
+	if err != nil {
+		t.Fatalf(`CreateOpeningHour until midnight: %v`, err)
+	}
+
+	invalid := []struct {
+		name                       string
+		purposePublicID            sql.NullString
+		weekday, opensAt, closesAt int32
+	}{
+		{"weekday", sql.NullString{}, 7, 540, 600},
+		{"closing before opening", sql.NullString{}, 1, 600, 540},
+		{"closing after midnight", sql.NullString{}, 1, 600, 1441},
+		{"unknown purpose", sql.NullString{String: newPublicID(), Valid: true}, 1, 540, 600},
+	}
+	for _, tt := range invalid {
+		if _, err := create(tt.purposePublicID, tt.weekday, tt.opensAt, tt.closesAt); err == nil {
+			t.Errorf(`CreateOpeningHour with an invalid %s succeeded, expected a constraint violation`, tt.name)
+		}
+	}
+
+	byPublicID := func(o database.OpeningHour) string { return o.PublicID }
+	got, err := s.GetOpeningHoursByLocationPublicID(ctx, purpose.LocationPublicID)
+	if want := []string{sunday.PublicID, morning.PublicID, afternoon.PublicID}; err != nil || !slices.Equal(publicIDs(got, byPublicID), want) {
+		t.Errorf(`GetOpeningHoursByLocationPublicID returned %v, %v; expected %v ordered by weekday and opening time`, publicIDs(got, byPublicID), err, want)
+	}
+	if o, err := s.GetOpeningHourByPublicID(ctx, morning.PublicID); err != nil || o.OpensAt != 540 || o.ClosesAt != 720 {
+		t.Errorf(`GetOpeningHourByPublicID returned %+v, %v`, o, err)
+	}
+	if err := s.DeleteOpeningHourByPublicID(ctx, morning.PublicID); err != nil {
+		t.Fatalf(`DeleteOpeningHourByPublicID: %v`, err)
+	}
+	if _, err := s.GetOpeningHourByPublicID(ctx, morning.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetOpeningHourByPublicID after DeleteOpeningHourByPublicID returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testHolidays(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	location := createLocation(t, s)
+	create := func(date time.Time) (database.Holiday, error) {
+		return s.CreateHoliday(ctx, database.CreateHolidayParams{PublicID: newPublicID(), LocationPublicID: location.PublicID, HolidayDate: date, Name: "holiday"})
+	}
+	christmas, err := create(time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC))
+	if err != nil || christmas.HolidayDate.Format(time.DateOnly) != "2026-12-25" {
+		t.Fatalf(`CreateHoliday returned %+v, %v`, christmas, err)
+	}
+	newYear, err := create(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf(`CreateHoliday: %v`, err)
+	}
+	if _, err := create(time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf(`CreateHoliday on a date that already is a holiday succeeded, expected a constraint violation`)
+	}
+	if _, err := s.CreateHoliday(ctx, database.CreateHolidayParams{PublicID: newPublicID(), LocationPublicID: newPublicID(), HolidayDate: time.Now(), Name: "holiday"}); err == nil {
+		t.Errorf(`CreateHoliday in an unknown location succeeded, expected a constraint violation`)
+	}
+
+	byPublicID := func(h database.Holiday) string { return h.PublicID }
+	got, err := s.GetHolidaysByLocationPublicID(ctx, location.PublicID)
+	if want := []string{newYear.PublicID, christmas.PublicID}; err != nil || !slices.Equal(publicIDs(got, byPublicID), want) {
+		t.Errorf(`GetHolidaysByLocationPublicID returned %v, %v; expected %v ordered by date`, publicIDs(got, byPublicID), err, want)
+	}
+	if err := s.DeleteHolidayByPublicID(ctx, newYear.PublicID); err != nil {
+		t.Fatalf(`DeleteHolidayByPublicID: %v`, err)
+	}
+	if _, err := s.GetHolidayByPublicID(ctx, newYear.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetHolidayByPublicID after DeleteHolidayByPublicID returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testVisitors(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	purpose := createPurpose(t, s, uuid.NullUUID{})
+	first := createVisitor(t, s, purpose.PublicID)
+	second := createVisitor(t, s, purpose.PublicID)
+	if first.Status != 0 || first.WaitingSince.IsZero() {
+		t.Errorf(`CreateVisitor returned %+v, expected status 0 and waiting_since set`, first)
+	}
+	if _, err := s.CreateVisitor(ctx, database.CreateVisitorParams{PublicID: newPublicID(), PurposePublicID: newPublicID()}); err == nil {
+		t.Errorf(`CreateVisitor with an unknown purpose succeeded, expected a constraint violation`)
+	}
+
+	second, err := s.SetVisitorByPublicID(ctx, database.SetVisitorByPublicIDParams{
+		PublicID:        second.PublicID,
+		Name:            second.Name,
+		PurposePublicID: purpose.PublicID,
+		Status:          1,
+	})
+	if err != nil || second.Status != 1 {
+		t.Fatalf(`SetVisitorByPublicID returned %+v, %v`, second, err)
+	}
+
+	byPublicID := func(v database.Visitor) string { return v.PublicID }
+	day := 24 * time.Hour // generous margins, TIMESTAMP columns do not store a time zone
+	tests := []struct {
+		name   string
+		params database.ListVisitorsParams
+		want   []string
+	}{
+		{"purpose", database.ListVisitorsParams{}, []string{first.PublicID, second.PublicID}},
+		{"status", database.ListVisitorsParams{Status: sql.NullInt32{Int32: 1, Valid: true}}, []string{second.PublicID}},
+		{"start date", database.ListVisitorsParams{StartDate: sql.NullTime{Time: time.Now().Add(-day), Valid: true}}, []string{first.PublicID, second.PublicID}},
+		{"end date", database.ListVisitorsParams{EndDate: sql.NullTime{Time: time.Now().Add(-day), Valid: true}}, []string{}},
+	}
+	for _, tt := range tests {
+		tt.params.PurposePublicID = sql.NullString{String: purpose.PublicID, Valid: true}
+		tt.params.Sort, tt.params.RowLimit = "waiting_since", 100
+		got, err := s.ListVisitors(ctx, tt.params)
+		if err != nil || !equalIDs(publicIDs(got, byPublicID), tt.want) {
+			t.Errorf(`ListVisitors filtered by %s returned %v, %v; expected %v`, tt.name, publicIDs(got, byPublicID), err, tt.want)
+		}
+	}
+
+	waiting, err := s.GetWaitingVisitorsByPurposePublicID(ctx, purpose.PublicID)
+	if err != nil || !equalIDs(publicIDs(waiting, byPublicID), []string{second.PublicID}) {
+		t.Errorf(`GetWaitingVisitorsByPurposePublicID returned %v, %v`, publicIDs(waiting, byPublicID), err)
+	}
+	if _, err := s.GetVisitorsByPublicID(ctx, newPublicID()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetVisitorsByPublicID for an unknown visitor returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	// the day of a location is passed as a range, so it can be in any time zone
+	forDay := database.GetVisitorsForDayParams{LocationPublicID: purpose.LocationPublicID, DayStart: time.Now().Add(-day), DayEnd: time.Now().Add(day)}
+	if got, err := s.GetVisitorsForDay(ctx, forDay); err != nil || !equalIDs(publicIDs(got, byPublicID), []string{first.PublicID, second.PublicID}) {
+		t.Errorf(`GetVisitorsForDay returned %v, %v`, publicIDs(got, byPublicID), err)
+	}
+	forDay.DayStart, forDay.DayEnd = forDay.DayEnd, forDay.DayEnd.Add(day)
+	if got, err := s.GetVisitorsForDay(ctx, forDay); err != nil || len(got) != 0 {
+		t.Errorf(`GetVisitorsForDay for another day returned %v, %v; expected none`, publicIDs(got, byPublicID), err)
+	}
+	since := database.CountVisitorsByPurposePublicIDSinceParams{PurposePublicID: purpose.PublicID, CreatedAt: time.Now().Add(-day)}
+	if count, err := s.CountVisitorsByPurposePublicIDSince(ctx, since); err != nil || count != 2 {
+		t.Errorf(`CountVisitorsByPurposePublicIDSince returned %d, %v; expected 2`, count, err)
+	}
+	since.CreatedAt = time.Now().Add(day)
+	if count, err := s.CountVisitorsByPurposePublicIDSince(ctx, since); err != nil || count != 0 {
+		t.Errorf(`CountVisitorsByPurposePublicIDSince in the future returned %d, %v; expected 0`, count, err)
+	}
+
+	// the queue holds visitors with status 0 that have not been called to a desk yet
+	third := createVisitor(t, s, purpose.PublicID)
+	if third.PhoneNumber.String != "+31201234567" || third.Email.String != "visitor@example.org" || third.NotifiedAt.Valid {
+		t.Errorf(`CreateVisitor returned %+v, expected its contact details and no notification`, third)
+	}
+	queued := database.GetQueuedVisitorsByPurposePublicIDParams{PurposePublicID: purpose.PublicID, Limit: 10}
+	if got, err := s.GetQueuedVisitorsByPurposePublicID(ctx, queued); err != nil || !slices.Equal(publicIDs(got, byPublicID), []string{first.PublicID, third.PublicID}) {
+		t.Errorf(`GetQueuedVisitorsByPurposePublicID returned %v, %v; expected the visitors with status 0 in order`, publicIDs(got, byPublicID), err)
+	}
+	ahead := database.CountQueuedVisitorsAheadParams{PurposePublicID: purpose.PublicID, WaitingSince: third.WaitingSince, PublicID: third.PublicID}
+	if n, err := s.CountQueuedVisitorsAhead(ctx, ahead); err != nil || n != 1 {
+		t.Errorf(`CountQueuedVisitorsAhead returned %d, %v; expected 1`, n, err)
+	}
+	_, err = s.CreateServiceLogs(ctx, database.CreateServiceLogsParams{
+		PublicID:         newPublicID(),
+		VisitorPublicID:  first.PublicID,
+		UserPublicID:     createUser(t, s).PublicID,
+		DeskPublicID:     createDesk(t, s).PublicID,
+		LocationPublicID: first.LocationPublicID,
+		CreatedAt:        time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf(`CreateServiceLogs: %v`, err)
+	}
+	queued.Limit = 1
+	if n, err := s.CountQueuedVisitorsAhead(ctx, ahead); err != nil || n != 0 {
+		t.Errorf(`CountQueuedVisitorsAhead after calling %s returned %d, %v; expected 0`, first.PublicID, n, err)
+	}
+	calls := database.GetCallTimesByPurposePublicIDParams{PurposePublicID: purpose.PublicID, CalledAt: time.Now().Add(-time.Hour), Limit: 10}
+	if got, err := s.GetCallTimesByPurposePublicID(ctx, calls); err != nil || len(got) != 1 {
+		t.Errorf(`GetCallTimesByPurposePublicID returned %v, %v; expected one call`, got, err)
+	}
+	calls.CalledAt = time.Now().Add(time.Hour)
+	if got, err := s.GetCallTimesByPurposePublicID(ctx, calls); err != nil || len(got) != 0 {
+		t.Errorf(`GetCallTimesByPurposePublicID after the call returned %v, %v; expected none`, got, err)
+	}
+	if got, err := s.GetQueuedVisitorsByPurposePublicID(ctx, queued); err != nil || !slices.Equal(publicIDs(got, byPublicID), []string{third.PublicID}) {
+		t.Errorf(`GetQueuedVisitorsByPurposePublicID after calling %s returned %v, %v; expected only %s`, first.PublicID, publicIDs(got, byPublicID), err, third.PublicID)
+	}
+
+	// a visitor is notified once
+	notified := database.SetVisitorNotifiedAtParams{PublicID: third.PublicID, NotifiedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+	if n, err := s.SetVisitorNotifiedAt(ctx, notified); err != nil || n != 1 {
+		t.Errorf(`SetVisitorNotifiedAt returned %d, %v; expected 1`, n, err)
+	}
+	if n, err := s.SetVisitorNotifiedAt(ctx, notified); err != nil || n != 0 {
+		t.Errorf(`second SetVisitorNotifiedAt returned %d, %v; expected 0`, n, err)
+	}
+	if got, err := s.GetVisitorsByPublicID(ctx, third.PublicID); err != nil || !got.NotifiedAt.Valid {
+		t.Errorf(`GetVisitorsByPublicID returned %+v, %v after SetVisitorNotifiedAt`, got, err)
+	}
+}
+
+func testPagination(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	purpose := createPurpose(t, s, uuid.NullUUID{})
+	visitors := map[string]int32{}
+	for _, ticketNumber := range []int32{3, 1, 2, 2, 5} {
+		v, err := s.CreateVisitor(ctx, database.CreateVisitorParams{
+			PublicID:          newPublicID(),
+			PurposePublicID:   purpose.PublicID,
+			DailyTicketNumber: ticketNumber,
+			LocationPublicID:  purpose.LocationPublicID,
+			CreatedAt:         time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf(`CreateVisitor: %v`, err)
+		}
+		visitors[v.PublicID] = ticketNumber
+	}
+
+	// pages of two in descending ticket number, ties in ascending public ID
+	params := database.ListVisitorsParams{
+		PurposePublicID: sql.NullString{String: purpose.PublicID, Valid: true},
+		Sort:            "daily_ticket_number",
+		Descending:      true,
+		RowLimit:        2,
+	}
+	var got []database.Visitor
+	for range len(visitors) {
+		page, err := s.ListVisitors(ctx, params)
+		if err != nil {
+			t.Fatalf(`ListVisitors: %v`, err)
+		}
+		if len(page) > 2 {
+			t.Errorf(`ListVisitors returned %d visitors, expected at most the row limit of 2`, len(page))
+		}
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, page...)
+		last := page[len(page)-1]
+		params.AfterPublicID = sql.NullString{String: last.PublicID, Valid: true}
+		params.AfterInt = sql.NullInt32{Int32: last.DailyTicketNumber, Valid: true}
+	}
+	if len(got) != len(visitors) {
+		t.Fatalf(`paging through ListVisitors returned %d visitors, expected %d`, len(got), len(visitors))
+	}
+	for i := 1; i < len(got); i++ {
+		a, b := got[i-1], got[i]
+		if a.DailyTicketNumber < b.DailyTicketNumber || (a.DailyTicketNumber == b.DailyTicketNumber && a.PublicID > b.PublicID) {
+			t.Errorf(`ListVisitors returned ticket %d (%s) before %d (%s)`, a.DailyTicketNumber, a.PublicID, b.DailyTicketNumber, b.PublicID)
+		}
+	}
+
+	count, err := s.CountVisitors(ctx, database.CountVisitorsParams{PurposePublicID: params.PurposePublicID})
+	if err != nil || count != int64(len(visitors)) {
+		t.Errorf(`CountVisitors returned %d, %v; expected %d`, count, err, len(visitors))
+	}
+
+	// a cursor in a time column
+	first, err := s.ListVisitors(ctx, database.ListVisitorsParams{PurposePublicID: params.PurposePublicID, Sort: "waiting_since", RowLimit: 1})
+	if err != nil || len(first) != 1 {
+		t.Fatalf(`ListVisitors returned %v, %v; expected one visitor`, first, err)
+	}
+	rest, err := s.ListVisitors(ctx, database.ListVisitorsParams{
+		PurposePublicID: params.PurposePublicID,
+		AfterPublicID:   sql.NullString{String: first[0].PublicID, Valid: true},
+		Sort:            "waiting_since",
+		AfterTime:       sql.NullTime{Time: first[0].WaitingSince, Valid: true},
+		RowLimit:        100,
+	})
+	if err != nil || len(rest) != len(visitors)-1 || slices.ContainsFunc(rest, func(v database.Visitor) bool { return v.PublicID == first[0].PublicID }) {
+		t.Errorf(`ListVisitors after the first visitor returned %d visitors, %v; expected the other %d`, len(rest), err, len(visitors)-1)
+	}
+
+	before, err := s.CountUsers(ctx, database.CountUsersParams{})
+	if err != nil {
+		t.Fatalf(`CountUsers: %v`, err)
+	}
+	createUser(t, s)
+	after, err := s.CountUsers(ctx, database.CountUsersParams{IsActive: sql.NullBool{Bool: true, Valid: true}})
+	if err != nil || after < 1 || after > before+1 {
+		t.Errorf(`CountUsers of active users returned %d, %v after creating one, with %d users before`, after, err, before)
+	}
+}
+
+func testServiceLogs(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := createUser(t, s)
+	desk := createDesk(t, s)
+	visitor := createVisitor(t, s, createPurpose(t, s, uuid.NullUUID{}).PublicID)
+
+	log, err := s.CreateServiceLogs(ctx, database.CreateServiceLogsParams{
+		PublicID:         newPublicID(),
+		VisitorPublicID:  visitor.PublicID,
+		UserPublicID:     user.PublicID,
+		DeskPublicID:     desk.PublicID,
+		LocationPublicID: visitor.LocationPublicID,
+		CreatedAt:        time.Now().UTC(),
+	})
+	if err != nil || !log.IsActive || log.CalledAt.IsZero() {
+		t.Fatalf(`CreateServiceLogs returned %+v, %v`, log, err)
+	}
+	if _, err := s.CreateServiceLogs(ctx, database.CreateServiceLogsParams{
+		PublicID:         newPublicID(),
+		VisitorPublicID:  visitor.PublicID,
+		UserPublicID:     user.PublicID,
+		DeskPublicID:     newPublicID(),
+		LocationPublicID: visitor.LocationPublicID,
+		CreatedAt:        time.Now().UTC(),
+	}); err == nil {
+		t.Errorf(`CreateServiceLogs with an unknown desk succeeded, expected a constraint violation`)
+	}
+
+	logs, err := s.ListServiceLogs(ctx, database.ListServiceLogsParams{
+		UserPublicID: sql.NullString{String: user.PublicID, Valid: true},
+		DeskPublicID: sql.NullString{String: desk.PublicID, Valid: true},
+		Sort:         "created_at",
+		RowLimit:     100,
+	})
+	if err != nil || len(logs) != 1 || logs[0].PublicID != log.PublicID {
+		t.Errorf(`ListServiceLogs returned %v, %v; expected only %v`, logs, err, log.PublicID)
+	}
+
+	log, err = s.SetServiceLogsByPublicID(ctx, database.SetServiceLogsByPublicIDParams{
+		PublicID:         log.PublicID,
+		VisitorPublicID:  visitor.PublicID,
+		UserPublicID:     user.PublicID,
+		DeskPublicID:     desk.PublicID,
+		IsActive:         false,
+		LocationPublicID: log.LocationPublicID,
+	})
+	if err != nil || log.IsActive {
+		t.Errorf(`SetServiceLogsByPublicID returned %+v, %v`, log, err)
+	}
+	active, err := s.GetActiveServiceLogsByUserID(ctx, user.PublicID)
+	if err != nil || len(active) != 0 {
+		t.Errorf(`GetActiveServiceLogsByUserID returned %v, %v; expected no active logs`, active, err)
+	}
+
+	// the active calls of a location, most recent first
+	second, err := s.CreateServiceLogs(ctx, database.CreateServiceLogsParams{
+		PublicID:         newPublicID(),
+		VisitorPublicID:  createVisitor(t, s, visitor.PurposePublicID).PublicID,
+		UserPublicID:     user.PublicID,
+		DeskPublicID:     desk.PublicID,
+		LocationPublicID: visitor.LocationPublicID,
+		CreatedAt:        time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf(`CreateServiceLogs: %v`, err)
+	}
+	calls, err := s.GetActiveServiceLogsByLocationPublicID(ctx, database.GetActiveServiceLogsByLocationPublicIDParams{
+		LocationPublicID: visitor.LocationPublicID,
+		CalledAt:         log.CalledAt.Add(-time.Hour),
+		Limit:            10,
+	})
+	if err != nil || len(calls) != 1 || calls[0].PublicID != second.PublicID {
+		t.Errorf(`GetActiveServiceLogsByLocationPublicID returned %v, %v; expected only the active %v`, calls, err, second.PublicID)
+	}
+	calls, err = s.GetActiveServiceLogsByLocationPublicID(ctx, database.GetActiveServiceLogsByLocationPublicIDParams{
+		LocationPublicID: visitor.LocationPublicID,
+		CalledAt:         second.CalledAt.Add(time.Hour),
+		Limit:            10,
+	})
+	if err != nil || len(calls) != 0 {
+		t.Errorf(`GetActiveServiceLogsByLocationPublicID returned %v, %v; expected no calls after the cutoff`, calls, err)
+	}
+}
+
+func testRetention(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	visitor := createVisitor(t, s, createPurpose(t, s, uuid.NullUUID{}).PublicID)
+	log, err := s.CreateServiceLogs(ctx, database.CreateServiceLogsParams{
+		PublicID:         newPublicID(),
+		VisitorPublicID:  visitor.PublicID,
+		UserPublicID:     createUser(t, s).PublicID,
+		DeskPublicID:     createDesk(t, s).PublicID,
+		LocationPublicID: visitor.LocationPublicID,
+		CreatedAt:        time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf(`CreateServiceLogs: %v`, err)
+	}
+
+	// visitors registered after the cutoff are left alone
+	past := visitor.CreatedAt.Add(-time.Hour)
+	if _, err := s.AnonymizeVisitorsCreatedBefore(ctx, past); err != nil {
+		t.Fatalf(`AnonymizeVisitorsCreatedBefore: %v`, err)
+	}
+	if got, err := s.GetVisitorsByPublicID(ctx, visitor.PublicID); err != nil || got.Name != visitor.Name {
+		t.Errorf(`GetVisitorsByPublicID returned %+v, %v after anonymizing older visitors`, got, err)
+	}
+
+	future := visitor.CreatedAt.Add(time.Hour)
+	n, err := s.AnonymizeVisitorsCreatedBefore(ctx, future)
+	if err != nil || n < 1 {
+		t.Errorf(`AnonymizeVisitorsCreatedBefore returned %d, %v`, n, err)
+	}
+	got, err := s.GetVisitorsByPublicID(ctx, visitor.PublicID)
+	if err != nil || got.Name.Valid || got.PhoneNumber.Valid || got.Email.Valid || got.DailyTicketNumber != visitor.DailyTicketNumber || !got.CreatedAt.Equal(visitor.CreatedAt) {
+		t.Errorf(`GetVisitorsByPublicID returned %+v, %v; expected the visitor without name and contact details`, got, err)
+	}
+	if n, err := s.AnonymizeVisitorsCreatedBefore(ctx, future); err != nil || n != 0 {
+		t.Errorf(`second AnonymizeVisitorsCreatedBefore returned %d, %v; expected nothing left to anonymize`, n, err)
+	}
+
+	// visitors can only be deleted after their service logs
+	if _, err := s.DeleteVisitorsCreatedBefore(ctx, future); err == nil {
+		t.Errorf(`DeleteVisitorsCreatedBefore with service logs left succeeded, expected a constraint violation`)
+	}
+	if n, err := s.DeleteServiceLogsOfVisitorsCreatedBefore(ctx, future); err != nil || n < 1 {
+		t.Errorf(`DeleteServiceLogsOfVisitorsCreatedBefore returned %d, %v`, n, err)
+	}
+	if _, err := s.GetServiceLogsByPublicID(ctx, log.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetServiceLogsByPublicID after DeleteServiceLogsOfVisitorsCreatedBefore returned %v, expected sql.ErrNoRows`, err)
+	}
+	if n, err := s.DeleteVisitorsCreatedBefore(ctx, future); err != nil || n < 1 {
+		t.Errorf(`DeleteVisitorsCreatedBefore returned %d, %v`, n, err)
+	}
+	if _, err := s.GetVisitorsByPublicID(ctx, visitor.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetVisitorsByPublicID after DeleteVisitorsCreatedBefore returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testErasure(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	visitor := createVisitor(t, s, createPurpose(t, s, uuid.NullUUID{}).PublicID)
+	other := createVisitor(t, s, visitor.PurposePublicID)
+	user, desk := createUser(t, s), createDesk(t, s)
+	var logs []database.ServiceLog
+	for _, v := range []database.Visitor{visitor, other, visitor} {
+		log, err := s.CreateServiceLogs(ctx, database.CreateServiceLogsParams{
+			PublicID:         newPublicID(),
+			VisitorPublicID:  v.PublicID,
+			UserPublicID:     user.PublicID,
+			DeskPublicID:     desk.PublicID,
+			LocationPublicID: v.LocationPublicID,
+			CreatedAt:        time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf(`CreateServiceLogs: %v`, err)
+		}
+		logs = append(logs, log)
+	}
+
+	got, err := s.GetServiceLogsByVisitorPublicID(ctx, visitor.PublicID)
+	if err != nil || len(got) != 2 || got[0].PublicID != logs[0].PublicID || got[1].PublicID != logs[2].PublicID {
+		t.Errorf(`GetServiceLogsByVisitorPublicID returned %+v, %v; expected the first and last service log`, got, err)
+	}
+
+	anonymized, err := s.AnonymizeVisitorByPublicID(ctx, visitor.PublicID)
+	if err != nil || anonymized.Name.Valid || anonymized.PhoneNumber.Valid || anonymized.Email.Valid || anonymized.DailyTicketNumber != visitor.DailyTicketNumber || anonymized.PurposePublicID != visitor.PurposePublicID {
+		t.Errorf(`AnonymizeVisitorByPublicID returned %+v, %v; expected the visitor without name and contact details`, anonymized, err)
+	}
+	if got, err := s.GetVisitorsByPublicID(ctx, other.PublicID); err != nil || got.Name != other.Name {
+		t.Errorf(`GetVisitorsByPublicID returned %+v, %v after anonymizing another visitor`, got, err)
+	}
+	if _, err := s.AnonymizeVisitorByPublicID(ctx, newPublicID()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`AnonymizeVisitorByPublicID of an unknown visitor returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testRefreshTokens(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := createUser(t, s)
+	create := func() database.RefreshToken {
+		token, err := s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			Token:        newPublicID(),
+			PublicID:     newPublicID(),
+			UserPublicID: user.PublicID,
+			ExpiresAt:    time.Now().Add(30 * 24 * time.Hour),
+			UserAgent:    "test",
+		})
+		if err != nil {
+			t.Fatalf(`CreateRefreshToken: %v`, err)
+		}
+		return token
+	}
+	first := create()
+	second := create()
+
+	rotated, err := s.RotateRefreshTokenByToken(ctx, database.RotateRefreshTokenByTokenParams{
+		NewToken:  newPublicID(),
+		ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
+		UserAgent: "rotated",
+		OldToken:  first.Token,
+	})
+	if err != nil || rotated.PublicID != first.PublicID || rotated.Token == first.Token {
+		t.Errorf(`RotateRefreshTokenByToken returned %+v, %v; expected a new token for the same session`, rotated, err)
+	}
+	if _, err := s.GetRefreshTokenByToken(ctx, first.Token); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetRefreshTokenByToken for a rotated token returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	revoked, err := s.RevokeRefreshTokenByPublicID(ctx, second.PublicID)
+	if err != nil || !revoked.RevokedAt.Valid {
+		t.Errorf(`RevokeRefreshTokenByPublicID returned %+v, %v`, revoked, err)
+	}
+	if _, err := s.RevokeRefreshTokenByPublicID(ctx, second.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`RevokeRefreshTokenByPublicID for a revoked session returned %v, expected sql.ErrNoRows`, err)
+	}
+	if _, err := s.RotateRefreshTokenByToken(ctx, database.RotateRefreshTokenByTokenParams{NewToken: newPublicID(), OldToken: second.Token}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`RotateRefreshTokenByToken for a revoked token returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	active, err := s.GetRefreshTokensByUserPublicID(ctx, user.PublicID)
+	byPublicID := func(t database.RefreshToken) string { return t.PublicID }
+	if err != nil || !equalIDs(publicIDs(active, byPublicID), []string{first.PublicID}) {
+		t.Errorf(`GetRefreshTokensByUserPublicID returned %v, %v; expected only the rotated session`, publicIDs(active, byPublicID), err)
+	}
+
+	revokedAll, err := s.RevokeRefreshTokenByUserPublicID(ctx, user.PublicID)
+	if err != nil || len(revokedAll) != 1 {
+		t.Errorf(`RevokeRefreshTokenByUserPublicID returned %v, %v; expected one revoked session`, revokedAll, err)
+	}
+}
+
+func testUserTokens(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := createUser(t, s)
+	create := func(kind string, expiresAt time.Time) database.UserToken {
+		token, err := s.CreateUserToken(ctx, database.CreateUserTokenParams{
+			PublicID:     newPublicID(),
+			UserPublicID: user.PublicID,
+			Kind:         kind,
+			TokenHash:    newPublicID(),
+			ExpiresAt:    expiresAt,
+		})
+		if err != nil {
+			t.Fatalf(`CreateUserToken: %v`, err)
+		}
+		return token
+	}
+	valid := create("password_reset", time.Now().Add(time.Hour))
+	expired := create("password_reset", time.Now().Add(-time.Hour))
+	other := create("invitation", time.Now().Add(time.Hour))
+
+	consumed, err := s.ConsumeUserToken(ctx, database.ConsumeUserTokenParams{TokenHash: valid.TokenHash, Kind: valid.Kind})
+	if err != nil || !consumed.UsedAt.Valid {
+		t.Errorf(`ConsumeUserToken returned %+v, %v`, consumed, err)
+	}
+	for name, arg := range map[string]database.ConsumeUserTokenParams{
+		"used":       {TokenHash: valid.TokenHash, Kind: valid.Kind},
+		"expired":    {TokenHash: expired.TokenHash, Kind: expired.Kind},
+		"wrong kind": {TokenHash: other.TokenHash, Kind: "password_reset"},
+	} {
+		if _, err := s.ConsumeUserToken(ctx, arg); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf(`ConsumeUserToken for a %s token returned %v, expected sql.ErrNoRows`, name, err)
+		}
+	}
+
+	if err := s.InvalidateUserTokens(ctx, database.InvalidateUserTokensParams{UserPublicID: user.PublicID, Kind: other.Kind}); err != nil {
+		t.Fatalf(`InvalidateUserTokens: %v`, err)
+	}
+	if _, err := s.ConsumeUserToken(ctx, database.ConsumeUserTokenParams{TokenHash: other.TokenHash, Kind: other.Kind}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`ConsumeUserToken for an invalidated token returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testLoginAttempts(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	key := "account:" + newPublicID()
+	now := time.Now().UTC().Truncate(time.Millisecond) // SQLite computes blocked_until in milliseconds
+	fail := func(now time.Time, onlyUnblocked bool) (database.LoginAttempt, error) {
+		return s.FailLoginAttempt(ctx, database.FailLoginAttemptParams{
+			AttemptKey:    key,
+			Now:           now,
+			MaxAttempts:   3,
+			LockedUntil:   now.Add(time.Hour),
+			BaseDelay:     1.5,
+			MaxDelay:      5,
+			ForgetBefore:  now.Add(-time.Hour),
+			OnlyUnblocked: onlyUnblocked,
+		})
+	}
+
+	// the delay doubles with every failure, up to the lockout on the third
+	want := []struct {
+		blockedUntil time.Time
+		isLockedOut  bool
+	}{
+		{now.Add(1500 * time.Millisecond), false},
+		{now.Add(3 * time.Second), false},
+		{now.Add(time.Hour), true},
+	}
+	for i, w := range want {
+		attempt, err := fail(now, false)
+		if err != nil || attempt.FailedAttempts != int32(i+1) || !attempt.BlockedUntil.Equal(w.blockedUntil) || attempt.IsLockedOut != w.isLockedOut {
+			t.Errorf(`FailLoginAttempt %d returned %+v, %v; expected blocked until %v, locked out %v`, i+1, attempt, err, w.blockedUntil, w.isLockedOut)
+		}
+	}
+	// a running lockout is left as it is
+	if _, err := fail(now.Add(time.Minute), false); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`FailLoginAttempt during a lockout returned %v, expected sql.ErrNoRows`, err)
+	}
+	attempt, err := s.GetLoginAttempt(ctx, key)
+	if err != nil || attempt.FailedAttempts != 3 || !attempt.IsLockedOut {
+		t.Errorf(`GetLoginAttempt returned %+v, %v`, attempt, err)
+	}
+	// and one that has run out starts over
+	later := now.Add(2 * time.Hour)
+	if attempt, err := fail(later, false); err != nil || attempt.FailedAttempts != 1 || attempt.IsLockedOut || !attempt.BlockedUntil.Equal(later.Add(1500*time.Millisecond)) {
+		t.Errorf(`FailLoginAttempt after a lockout returned %+v, %v; expected a first failure`, attempt, err)
+	}
+	// blocked keys are left alone if only unblocked ones count, and counted otherwise
+	if _, err := fail(later, true); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`FailLoginAttempt for a blocked key returned %v, expected sql.ErrNoRows`, err)
+	}
+	if attempt, err := fail(later, false); err != nil || attempt.FailedAttempts != 2 {
+		t.Errorf(`FailLoginAttempt returned %+v, %v; expected 2 failed attempts`, attempt, err)
+	}
+
+	if err := s.DeleteLoginAttempt(ctx, key); err != nil {
+		t.Fatalf(`DeleteLoginAttempt: %v`, err)
+	}
+	if _, err := s.GetLoginAttempt(ctx, key); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetLoginAttempt after DeleteLoginAttempt returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	key := database.CreateIdempotencyKeyParams{
+		Scope:          "POST /api/visitors",
+		IdempotencyKey: newPublicID(),
+		RequestHash:    "hash",
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(time.Hour),
+		LockedUntil:    sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	}
+	created, err := s.CreateIdempotencyKey(ctx, key)
+	if err != nil || created.ResponseStatus.Valid {
+		t.Fatalf(`CreateIdempotencyKey returned %+v, %v; expected a key without response`, created, err)
+	}
+	if _, err := s.CreateIdempotencyKey(ctx, key); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`CreateIdempotencyKey for an existing key returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	// once the lease has run out, a request with the same body takes the claim over
+	later := key
+	later.CreatedAt, later.LockedUntil.Time = now.Add(2*time.Minute), now.Add(3*time.Minute)
+	later.RequestHash = "other hash"
+	if _, err := s.CreateIdempotencyKey(ctx, later); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`CreateIdempotencyKey with another body after the lease returned %v, expected sql.ErrNoRows`, err)
+	}
+	later.RequestHash = key.RequestHash
+	created, err = s.CreateIdempotencyKey(ctx, later)
+	if err != nil || !created.LockedUntil.Time.After(later.CreatedAt) {
+		t.Errorf(`CreateIdempotencyKey after the lease returned %+v, %v; expected the claim to be taken over`, created, err)
+	}
+
+	err = s.SetIdempotencyKeyResponse(ctx, database.SetIdempotencyKeyResponseParams{
+		Scope:           key.Scope,
+		IdempotencyKey:  key.IdempotencyKey,
+		ResponseStatus:  sql.NullInt32{Int32: 201, Valid: true},
+		ResponseBody:    []byte(`{"id":1}`),
+		ResponseHeaders: []byte(`{"Content-Type":["application/json"]}`),
+	})
+	if err != nil {
+		t.Fatalf(`SetIdempotencyKeyResponse: %v`, err)
+	}
+	got, err := s.GetIdempotencyKey(ctx, database.GetIdempotencyKeyParams{Scope: key.Scope, IdempotencyKey: key.IdempotencyKey})
+	if err != nil || got.ResponseStatus.Int32 != 201 || string(got.ResponseBody) != `{"id":1}` || string(got.ResponseHeaders) != `{"Content-Type":["application/json"]}` || got.RequestHash != "hash" {
+		t.Errorf(`GetIdempotencyKey returned %+v, %v`, got, err)
+	}
+	later.CreatedAt = now.Add(time.Hour)
+	if _, err := s.CreateIdempotencyKey(ctx, later); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`CreateIdempotencyKey for a key with a response returned %v, expected sql.ErrNoRows`, err)
+	}
+	if _, err := s.GetIdempotencyKey(ctx, database.GetIdempotencyKeyParams{Scope: "POST /api/servicelogs", IdempotencyKey: key.IdempotencyKey}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetIdempotencyKey in another scope returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	// expired keys are deleted, live ones are kept
+	if err := s.DeleteExpiredIdempotencyKeys(ctx, now); err != nil {
+		t.Fatalf(`DeleteExpiredIdempotencyKeys: %v`, err)
+	}
+	if _, err := s.GetIdempotencyKey(ctx, database.GetIdempotencyKeyParams{Scope: key.Scope, IdempotencyKey: key.IdempotencyKey}); err != nil {
+		t.Errorf(`GetIdempotencyKey returned %v for a key that has not expired`, err)
+	}
+	if err := s.DeleteExpiredIdempotencyKeys(ctx, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf(`DeleteExpiredIdempotencyKeys: %v`, err)
+	}
+	if _, err := s.GetIdempotencyKey(ctx, database.GetIdempotencyKeyParams{Scope: key.Scope, IdempotencyKey: key.IdempotencyKey}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetIdempotencyKey after DeleteExpiredIdempotencyKeys returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	if _, err := s.CreateIdempotencyKey(ctx, key); err != nil {
+		t.Fatalf(`CreateIdempotencyKey: %v`, err)
+	}
+	if err := s.DeleteIdempotencyKey(ctx, database.DeleteIdempotencyKeyParams{Scope: key.Scope, IdempotencyKey: key.IdempotencyKey}); err != nil {
+		t.Fatalf(`DeleteIdempotencyKey: %v`, err)
+	}
+	if _, err := s.CreateIdempotencyKey(ctx, key); err != nil {
+		t.Errorf(`CreateIdempotencyKey after DeleteIdempotencyKey returned %v`, err)
+	}
+}
+
+func testAuditEvents(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	entityPublicID := newPublicID()
+	for _, action := range []string{"desk.create", "desk.update"} {
+		_, err := s.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+			ActorPublicID:  "actor",
+			Action:         action,
+			EntityType:     "desk",
+			EntityPublicID: entityPublicID,
+			Diff:           []byte(`{}`),
+		})
+		if err != nil {
+			t.Fatalf(`CreateAuditEvent: %v`, err)
+		}
+	}
+	events, err := s.ListAuditEvents(ctx, database.ListAuditEventsParams{
+		EntityPublicID: sql.NullString{String: entityPublicID, Valid: true},
+		Action:         sql.NullString{String: "desk.update", Valid: true},
+	})
+	if err != nil || len(events) != 1 || events[0].Action != "desk.update" {
+		t.Errorf(`ListAuditEvents returned %v, %v; expected the desk.update event`, events, err)
+	}
+}
+
+func testWebhooks(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	eventType := "test." + newPublicID()
+	subscription, err := s.CreateWebhookSubscription(ctx, database.CreateWebhookSubscriptionParams{
+		PublicID:  newPublicID(),
+		Url:       "https://example.org/hook",
+		Secret:    "secret",
+		EventType: eventType,
+	})
+	if err != nil {
+		t.Fatalf(`CreateWebhookSubscription: %v`, err)
+	}
+	bySubscription := func(w database.WebhookSubscription) string { return w.PublicID }
+	if got, err := s.GetWebhookSubscriptionsByEventType(ctx, eventType); err != nil || !slices.Equal(publicIDs(got, bySubscription), []string{subscription.PublicID}) {
+		t.Errorf(`GetWebhookSubscriptionsByEventType returned %v, %v; expected only %s`, publicIDs(got, bySubscription), err, subscription.PublicID)
+	}
+	if w, err := s.GetWebhookSubscriptionByPublicID(ctx, subscription.PublicID); err != nil || w.Secret != "secret" || w.Url != "https://example.org/hook" {
+		t.Errorf(`GetWebhookSubscriptionByPublicID returned %+v, %v`, w, err)
+	}
+
+	// deliveries that are due an hour ago, so they are picked up by GetDueWebhookDeliveries whatever the clock
+	due := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	create := func(subscriptionPublicID string, nextAttemptAt time.Time) (database.WebhookDelivery, error) {
+		return s.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			PublicID:             newPublicID(),
+			SubscriptionPublicID: subscriptionPublicID,
+			EventType:            eventType,
+			Payload:              []byte(`{"event":"test"}`),
+			NextAttemptAt:        nextAttemptAt,
+		})
+	}
+	delivery, err := create(subscription.PublicID, due)
+	if err != nil || delivery.Status != "pending" || delivery.Attempts != 0 || string(delivery.Payload) != `{"event":"test"}` {
+		t.Fatalf(`CreateWebhookDelivery returned %+v, %v`, delivery, err)
+	}
+	later, err := create(subscription.PublicID, due.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf(`CreateWebhookDelivery: %v`, err)
+	}
+	if _, err := create(newPublicID(), due); err == nil {
+		t.Errorf(`CreateWebhookDelivery for an unknown subscription succeeded, expected a constraint violation`)
+	}
+
+	byDelivery := func(d database.WebhookDelivery) string { return d.PublicID }
+	dueDeliveries, err := s.GetDueWebhookDeliveries(ctx, database.GetDueWebhookDeliveriesParams{NextAttemptAt: time.Now(), Limit: 1000})
+	if err != nil || !slices.Contains(publicIDs(dueDeliveries, byDelivery), delivery.PublicID) || slices.Contains(publicIDs(dueDeliveries, byDelivery), later.PublicID) {
+		t.Errorf(`GetDueWebhookDeliveries returned %v, %v; expected %s and not %s`, publicIDs(dueDeliveries, byDelivery), err, delivery.PublicID, later.PublicID)
+	}
+
+	// a delivery can be claimed once, the lease moves it out of the due deliveries
+	claim := database.ClaimWebhookDeliveryParams{LeaseUntil: time.Now().Add(time.Minute), PublicID: delivery.PublicID, Now: time.Now()}
+	if _, err := s.ClaimWebhookDelivery(ctx, claim); err != nil {
+		t.Fatalf(`ClaimWebhookDelivery: %v`, err)
+	}
+	if _, err := s.ClaimWebhookDelivery(ctx, claim); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`ClaimWebhookDelivery of a claimed delivery returned %v, expected sql.ErrNoRows`, err)
+	}
+
+	result, err := s.SetWebhookDeliveryResult(ctx, database.SetWebhookDeliveryResultParams{
+		PublicID:       delivery.PublicID,
+		Status:         "delivered",
+		NextAttemptAt:  due,
+		ResponseStatus: sql.NullInt32{Int32: 204, Valid: true},
+		DeliveredAt:    sql.NullTime{Time: due, Valid: true},
+	})
+	if err != nil || result.Status != "delivered" || result.Attempts != 1 || result.ResponseStatus.Int32 != 204 || !result.DeliveredAt.Valid {
+		t.Errorf(`SetWebhookDeliveryResult returned %+v, %v`, result, err)
+	}
+	if _, err := s.ClaimWebhookDelivery(ctx, claim); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`ClaimWebhookDelivery of a delivered delivery returned %v, expected sql.ErrNoRows`, err)
+	}
+	if _, err := s.SetWebhookDeliveryResult(ctx, database.SetWebhookDeliveryResultParams{PublicID: delivery.PublicID, Status: "unknown", NextAttemptAt: due}); err == nil {
+		t.Errorf(`SetWebhookDeliveryResult with an unknown status succeeded, expected a constraint violation`)
+	}
+
+	redelivered, err := s.RedeliverWebhookDelivery(ctx, database.RedeliverWebhookDeliveryParams{PublicID: delivery.PublicID, NextAttemptAt: due})
+	if err != nil || redelivered.Status != "pending" || redelivered.Attempts != 0 || redelivered.ResponseStatus.Int32 != 204 {
+		t.Errorf(`RedeliverWebhookDelivery returned %+v, %v; expected a pending delivery that keeps its last response`, redelivered, err)
+	}
+	if d, err := s.GetWebhookDeliveryByPublicID(ctx, delivery.PublicID); err != nil || d.Status != "pending" {
+		t.Errorf(`GetWebhookDeliveryByPublicID returned %+v, %v`, d, err)
+	}
+
+	log, err := s.GetWebhookDeliveriesBySubscriptionPublicID(ctx, database.GetWebhookDeliveriesBySubscriptionPublicIDParams{SubscriptionPublicID: subscription.PublicID, Limit: 1})
+	if err != nil || len(log) != 1 {
+		t.Errorf(`GetWebhookDeliveriesBySubscriptionPublicID with limit 1 returned %v, %v`, publicIDs(log, byDelivery), err)
+	}
+
+	// deleting a subscription deletes its deliveries
+	if err := s.DeleteWebhookSubscriptionByPublicID(ctx, subscription.PublicID); err != nil {
+		t.Fatalf(`DeleteWebhookSubscriptionByPublicID: %v`, err)
+	}
+	if _, err := s.GetWebhookSubscriptionByPublicID(ctx, subscription.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetWebhookSubscriptionByPublicID after DeleteWebhookSubscriptionByPublicID returned %v, expected sql.ErrNoRows`, err)
+	}
+	if _, err := s.GetWebhookDeliveryByPublicID(ctx, later.PublicID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf(`GetWebhookDeliveryByPublicID after deleting its subscription returned %v, expected sql.ErrNoRows`, err)
+	}
+}
+
+func testTransactions(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	// rolled back: neither the change nor the nested change is visible afterwards
+	var rolledBack, nested database.User
+	err := s.InTx(ctx, func(q storage.Store) error {
+		rolledBack = createUser(t, q)
+		err := q.InTx(ctx, func(q storage.Store) error {
+			nested = createUser(t, q)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Errorf(`InTx returned %v, expected the error of fn`, err)
+	}
+	for _, u := range []database.User{rolledBack, nested} {
+		if _, err := s.GetUserByPublicID(ctx, u.PublicID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf(`GetUserByPublicID after a rolled back transaction returned %v, expected sql.ErrNoRows`, err)
+		}
+	}
+
+	// committed
+	var committed database.User
+	err = s.InTx(ctx, func(q storage.Store) error {
+		committed = createUser(t, q)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf(`InTx: %v`, err)
+	}
+	if _, err := s.GetUserByPublicID(ctx, committed.PublicID); err != nil {
+		t.Errorf(`GetUserByPublicID after a committed transaction returned %v`, err)
+	}
+}
@@ -0,0 +1,162 @@
+package strutils
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+var ErrInvalidCursor = jsonutils.NewError(jsonutils.CodeInvalidCursor, "invalid cursor")
+
+// Cursor is the position after the last row of a page: the value of the column the list is sorted by and the public
+// ID of the row, which breaks ties. Only the field matching the type of the sort column is set. Clients only see it
+// encoded, as an opaque string.
+type Cursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Time       time.Time `json:"t"`
+	Text       string    `json:"x,omitempty"`
+	Int        int32     `json:"i,omitempty"`
+	PublicID   string    `json:"p"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c) // cannot fail for this struct
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	c := Cursor{}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.PublicID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Page holds the pagination query parameters of a list endpoint, e.g. ?limit=20&sort=-created_at&total=true, and
+// the cursor of the previous page as ?cursor=.
+type Page struct {
+	Limit      int
+	Sort       string
+	Descending bool
+	After      Cursor // zero for the first page
+	Total      bool   // whether to count all matching rows
+}
+
+func QueryParametersToPage(r *http.Request, sorts []string) (Page, error) {
+	/*
+		Parses the pagination query parameters of r. sorts is the allow-list of columns the endpoint can be sorted by,
+		the first one being the default. A minus sign in front of the column sorts descending. A cursor can only be
+		used with the sort it was made for, which is also the sort when the sort parameter is left out.
+	*/
+	q := r.URL.Query()
+	p := Page{Limit: DefaultPageLimit, Sort: sorts[0]}
+
+	// 1. limit
+	if s := q.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return Page{}, fmt.Errorf("query parameter 'limit' takes integers from 1 to %d", MaxPageLimit)
+		}
+		p.Limit = limit
+	}
+
+	// 2. sort
+	sortSet := false
+	if s := q.Get("sort"); s != "" {
+		p.Sort, p.Descending = strings.CutPrefix(s, "-")
+		if !slices.Contains(sorts, p.Sort) {
+			return Page{}, fmt.Errorf("query parameter 'sort' takes one of %s, optionally preceded by '-' for descending order", strings.Join(sorts, ", "))
+		}
+		sortSet = true
+	}
+
+	// 3. cursor
+	if s := q.Get("cursor"); s != "" {
+		c, err := DecodeCursor(s)
+		if err != nil {
+			return Page{}, err
+		}
+		if !slices.Contains(sorts, c.Sort) || (sortSet && (c.Sort != p.Sort || c.Descending != p.Descending)) {
+			return Page{}, fmt.Errorf("%w: cursor belongs to a different sort", ErrInvalidCursor)
+		}
+		p.Sort, p.Descending, p.After = c.Sort, c.Descending, c
+	}
+
+	// 4. total
+	total, err := QueryParameterToNullBool(q.Get("total"))
+	if err != nil {
+		return Page{}, errors.New("query parameter 'total' takes booleans")
+	}
+	p.Total = total.Bool
+	return p, nil
+}
+
+// the arguments of the keyset pagination in the List queries
+
+func (p Page) AfterPublicID() sql.NullString {
+	return QueryParameterToNullString(p.After.PublicID)
+}
+
+func (p Page) AfterTime() sql.NullTime {
+	return sql.NullTime{Time: p.After.Time, Valid: p.After.PublicID != ""}
+}
+
+func (p Page) AfterText() sql.NullString {
+	return sql.NullString{String: p.After.Text, Valid: p.After.PublicID != ""}
+}
+
+func (p Page) AfterInt() sql.NullInt32 {
+	return sql.NullInt32{Int32: p.After.Int, Valid: p.After.PublicID != ""}
+}
+
+func (p Page) RowLimit() int32 {
+	// one more row than the limit, which tells whether there is a next page
+	return int32(p.Limit) + 1
+}
+
+func NextPage[T any](p Page, rows []T, cursor func(row T, sort string) Cursor) ([]T, string) {
+	/*
+		Cuts the rows queried with p.RowLimit() down to p.Limit and returns them with the encoded cursor of the next
+		page, or "" on the last page. cursor returns the sort column value and public ID of a row.
+	*/
+	if len(rows) <= p.Limit {
+		return rows, ""
+	}
+	rows = rows[:p.Limit]
+	c := cursor(rows[len(rows)-1], p.Sort)
+	c.Sort, c.Descending = p.Sort, p.Descending
+	return rows, c.Encode()
+}
+
+func SetPageHeaders(w http.ResponseWriter, r *http.Request, next string, total sql.NullInt64) {
+	/*
+		Link: the URL of the next page, i.e. the request URL with its cursor replaced, as rel="next". Also in
+		X-Next-Cursor, for clients that would rather not parse Link headers.
+		X-Total-Count: the number of matching rows on all pages, if requested.
+	*/
+	if next != "" {
+		q := r.URL.Query()
+		q.Set("cursor", next)
+		w.Header().Add("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, q.Encode()))
+		w.Header().Set("X-Next-Cursor", next)
+	}
+	if total.Valid {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(total.Int64, 10))
+	}
+}
@@ -0,0 +1,76 @@
+package strutils
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	c := Cursor{Sort: "created_at", Descending: true, Time: time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC), PublicID: "abc"}
+	decoded, err := DecodeCursor(c.Encode())
+	if err != nil || decoded != c {
+		t.Errorf(`DecodeCursor(Encode()) = %+v, %v; expected %+v`, decoded, err, c)
+	}
+	for _, s := range []string{"", "not base64!", "bm90IGpzb24", "e30"} { // "not json", "{}"
+		if _, err := DecodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf(`DecodeCursor(%q) = %v; expected ErrInvalidCursor`, s, err)
+		}
+	}
+}
+
+func TestQueryParametersToPage(t *testing.T) {
+	sorts := []string{"name", "created_at"}
+	cursor := Cursor{Sort: "created_at", Descending: true, PublicID: "abc"}.Encode()
+	tests := []struct {
+		query string
+		want  Page
+		valid bool
+	}{
+		{"", Page{Limit: DefaultPageLimit, Sort: "name"}, true},
+		{"?limit=10&sort=-created_at&total=true", Page{Limit: 10, Sort: "created_at", Descending: true, Total: true}, true},
+		{"?cursor=" + cursor, Page{Limit: DefaultPageLimit, Sort: "created_at", Descending: true, After: Cursor{Sort: "created_at", Descending: true, PublicID: "abc"}}, true},
+		{"?sort=-created_at&cursor=" + cursor, Page{Limit: DefaultPageLimit, Sort: "created_at", Descending: true, After: Cursor{Sort: "created_at", Descending: true, PublicID: "abc"}}, true},
+		{"?sort=created_at&cursor=" + cursor, Page{}, false}, // cursor of a different sort order
+		{"?sort=hashed_password", Page{}, false}, // not in the allow-list
+		{"?limit=0", Page{}, false},
+		{"?limit=501", Page{}, false},
+		{"?limit=ten", Page{}, false},
+		{"?total=maybe", Page{}, false},
+		{"?cursor=garbage", Page{}, false},
+	}
+	for _, tc := range tests {
+		got, err := QueryParametersToPage(httptest.NewRequest("GET", "/api/desks"+tc.query, nil), sorts)
+		if tc.valid && (err != nil || got != tc.want) {
+			t.Errorf(`QueryParametersToPage(%q) = %+v, %v; expected %+v`, tc.query, got, err, tc.want)
+		} else if !tc.valid && err == nil {
+			t.Errorf(`QueryParametersToPage(%q) = %+v; expected err`, tc.query, got)
+		}
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	p := Page{Limit: 2, Sort: "name", Descending: true}
+	cursor := func(row string, sort string) Cursor { return Cursor{Text: row, PublicID: row} }
+
+	rows, next := NextPage(p, []string{"c", "b"}, cursor)
+	if len(rows) != 2 || next != "" {
+		t.Errorf(`NextPage of a full last page = %v, %q; expected both rows and no next page`, rows, next)
+	}
+	rows, next = NextPage(p, []string{"c", "b", "a"}, cursor)
+	c, err := DecodeCursor(next)
+	if len(rows) != 2 || err != nil || c != (Cursor{Sort: "name", Descending: true, Text: "b", PublicID: "b"}) {
+		t.Errorf(`NextPage = %v, %+v, %v; expected two rows and a cursor after b`, rows, c, err)
+	}
+
+	w := httptest.NewRecorder()
+	SetPageHeaders(w, httptest.NewRequest("GET", "/api/desks?limit=2&cursor=old", nil), next, sql.NullInt64{Int64: 3, Valid: true})
+	if link := w.Header().Get("Link"); link != `</api/desks?cursor=`+next+`&limit=2>; rel="next"` {
+		t.Errorf(`SetPageHeaders set Link %q`, link)
+	}
+	if total := w.Header().Get("X-Total-Count"); total != "3" {
+		t.Errorf(`SetPageHeaders set X-Total-Count %q, expected 3`, total)
+	}
+}
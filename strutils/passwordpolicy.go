@@ -0,0 +1,121 @@
+package strutils
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// character classes that can be required by a PasswordPolicy
+const (
+	CharacterClassLower  = "lower"
+	CharacterClassUpper  = "upper"
+	CharacterClassDigit  = "digit"
+	CharacterClassSymbol = "symbol"
+)
+
+var ErrPasswordDenied = jsonutils.NewError(jsonutils.CodePasswordDenied, "password is too common")
+
+type PasswordPolicy struct {
+	MinLength       int // in characters
+	MaxBytes        int // in bytes, as that is what the hashing algorithm limits. 0 means no limit of its own
+	RequiredClasses []string
+	DenyList        map[string]struct{} // lowercase passwords that are always refused
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	// minimum of 8 characters, any characters allowed, and the embedded list of common passwords denied. The maximum
+	// is left to the password hashing algorithm (see api.ProcessPassword)
+	p := PasswordPolicy{
+		MinLength: 8,
+		DenyList:  make(map[string]struct{}),
+	}
+	p.AddToDenyList(strings.NewReader(commonPasswords))
+	return p
+}
+
+func (p PasswordPolicy) AddToDenyList(r io.Reader) error {
+	// reads one password per line into the deny list. Empty lines and lines starting with # are skipped.
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.DenyList[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+func ParseCharacterClasses(s string) ([]string, error) {
+	// parses a comma separated list of character classes, e.g. "lower,upper,digit". Used for environment variables.
+	var classes []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(strings.ToLower(c))
+		switch c {
+		case "":
+			continue
+		case CharacterClassLower, CharacterClassUpper, CharacterClassDigit, CharacterClassSymbol:
+			classes = append(classes, c)
+		default:
+			return nil, fmt.Errorf("unknown character class %q", c)
+		}
+	}
+	return classes, nil
+}
+
+func (p PasswordPolicy) Validate(password string) error {
+	/*
+		Checks password against the policy. The returned errors are meant to be shown to the user.
+	*/
+	if !utf8.ValidString(password) {
+		return errors.New("password contains invalid characters")
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		return fmt.Errorf("password must be at most %d bytes long", p.MaxBytes)
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsLetter(r):
+			// caseless letters (e.g. CJK) do not count towards any class
+		case unicode.IsControl(r):
+			return errors.New("password contains invalid characters")
+		default:
+			hasSymbol = true
+		}
+	}
+	for _, c := range p.RequiredClasses {
+		if (c == CharacterClassLower && !hasLower) ||
+			(c == CharacterClassUpper && !hasUpper) ||
+			(c == CharacterClassDigit && !hasDigit) ||
+			(c == CharacterClassSymbol && !hasSymbol) {
+			return fmt.Errorf("password must contain at least one %s character", c)
+		}
+	}
+
+	if _, denied := p.DenyList[strings.ToLower(password)]; denied {
+		return ErrPasswordDenied
+	}
+	return nil
+}
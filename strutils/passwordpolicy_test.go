@@ -0,0 +1,50 @@
+package strutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	p := DefaultPasswordPolicy()
+	p.RequiredClasses = []string{CharacterClassLower, CharacterClassDigit}
+	p.MaxBytes = 72 // the limit of bcrypt
+
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"short1", false},                       // too short
+		{"correct horse battery 9", true},       // passphrase with spaces
+		{"sym!bols&more#1", true},               // symbols allowed
+		{"nodigitshere", false},                 // missing required class
+		{"Password123", false},                  // deny list, case-insensitive
+		{strings.Repeat("a1", 36), true},        // exactly 72 bytes
+		{strings.Repeat("a1", 36) + "x", false}, // 73 bytes
+		{"tab\tinside1", false},                 // control characters
+	}
+	for _, tc := range tests {
+		err := p.Validate(tc.password)
+		if tc.valid && err != nil {
+			t.Errorf(`Validate(%q) = %v; expected nil`, tc.password, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf(`Validate(%q) = nil; expected err`, tc.password)
+		}
+	}
+
+	// additional deny list entries
+	p.AddToDenyList(strings.NewReader("# comment\nsupersecret1\n"))
+	if p.Validate("SuperSecret1") == nil {
+		t.Errorf(`Validate("SuperSecret1") = nil after adding to deny list; expected err`)
+	}
+}
+
+func TestParseCharacterClasses(t *testing.T) {
+	classes, err := ParseCharacterClasses(" Lower, digit ,")
+	if err != nil || len(classes) != 2 || classes[0] != CharacterClassLower || classes[1] != CharacterClassDigit {
+		t.Errorf(`ParseCharacterClasses(" Lower, digit ,") = %v, %v; expected [lower digit], nil`, classes, err)
+	}
+	if _, err := ParseCharacterClasses("emoji"); err == nil {
+		t.Errorf(`ParseCharacterClasses("emoji") = nil error; expected err`)
+	}
+}
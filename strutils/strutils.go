@@ -3,31 +3,51 @@ package strutils
 import (
 	"database/sql"
 	"errors"
+	"net"
 	"net/http"
 	"net/mail"
 	"os"
 	"strconv"
+	"strings"
 	"time"
-	"unicode"
+
+	"github.com/dcrauwels/goqueue/jsonutils"
 )
 
+var ErrInvalidEmail = jsonutils.NewError(jsonutils.CodeInvalidEmail, "email address is invalid")
+
 func ValidateEmail(email string) error {
-	_, err := mail.ParseAddress(email)
-	return err
+	if _, err := mail.ParseAddress(email); err != nil {
+		return ErrInvalidEmail.Wrap(err)
+	}
+	return nil
 }
 
-func ValidatePassword(password string) error {
-	if len(password) < 8 || len(password) > 30 {
-		return errors.New("password length invalid")
+var ErrIncorrectPublicIDLength = jsonutils.NewError(jsonutils.CodeInvalidPublicID, "path value public ID has incorrect length")
+
+var ErrInvalidPhoneNumber = jsonutils.NewError(jsonutils.CodeInvalidPhoneNumber, "phone number must be a plus sign followed by 8 to 15 digits")
+
+func ValidatePhoneNumber(phoneNumber string) error {
+	// phone numbers are stored in international (E.164) format, e.g. +31201234567, as SMS gateways expect them
+	digits, ok := strings.CutPrefix(phoneNumber, "+")
+	if !ok || len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return ErrInvalidPhoneNumber
 	}
-	for _, r := range password {
-		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
-			return errors.New("password contains invalid (non-alphanumeric) characters")
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return ErrInvalidPhoneNumber
 		}
 	}
 	return nil
 }
 
+func ValidatePassword(password string) error {
+	// validates against DefaultPasswordPolicy, with the 72 byte limit of bcrypt. Use a configured PasswordPolicy where available.
+	p := DefaultPasswordPolicy()
+	p.MaxBytes = 72
+	return p.Validate(password)
+}
+
 func InitNullString(s string) sql.NullString {
 	// shortform for initializing a nullstring. relevant when querying a DB with a nullable string as parameter
 	r := sql.NullString{
@@ -59,13 +79,29 @@ func GetIntegerEnvironmentVariable(s string) (int, error) {
 	return r, nil
 }
 
+func GetIntegerEnvironmentVariableWithDefault(s string, d int) (int, error) {
+	/* Like GetIntegerEnvironmentVariable, but returns d if no value is set for keystring s. A value that is set but invalid still returns an error. */
+	if _, ok := os.LookupEnv(s); !ok {
+		return d, nil
+	}
+	return GetIntegerEnvironmentVariable(s)
+}
+
+func GetIPFromRequest(r *http.Request) string {
+	// returns the client IP address from the request's remote address, stripping the port.
+	// Note that X-Forwarded-For is deliberately not trusted here, as any client can set it.
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func GetPublicIDFromPathValue(path string, publicIDLength int, r *http.Request) (string, error) {
 	// used for retrieving public IDs from path values
 	// e.g. the value for 'user_public_id' in GET /api/users/{user_public_id}
 	// checks if the public ID provided in the path is of the correct length as specified in the .env config file
 
-	ErrIncorrectPublicIDLength := errors.New("path value public ID has incorrect length")
-
 	result := r.PathValue(path)
 	if len(result) != publicIDLength {
 		return "", ErrIncorrectPublicIDLength
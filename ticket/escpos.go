@@ -0,0 +1,167 @@
+package ticket
+
+import (
+	"bytes"
+	"image"
+	"strconv"
+
+	"github.com/dcrauwels/goqueue/qrcode"
+)
+
+// ESC/POS commands, as supported by practically every thermal receipt printer
+var (
+	escInit       = []byte{0x1B, '@'}
+	escCodePage   = []byte{0x1B, 't', 16} // Windows-1252
+	escCenter     = []byte{0x1B, 'a', 1}
+	escBoldOn     = []byte{0x1B, 'E', 1}
+	escBoldOff    = []byte{0x1B, 'E', 0}
+	escSizeNormal = []byte{0x1D, '!', 0x00}
+	escSizeLarge  = []byte{0x1D, '!', 0x11} // double width and height
+	escSizeHuge   = []byte{0x1D, '!', 0x44} // five times width and height
+	escFeedAndCut = []byte{0x1D, 'V', 66, 0}
+)
+
+func printableDots(paperWidth int) (int, error) {
+	// printable width at 203 dpi (8 dots per millimetre)
+	switch paperWidth {
+	case Paper58:
+		return 384, nil
+	case Paper80:
+		return 576, nil
+	}
+	return 0, ErrInvalidPaperWidth
+}
+
+func (t Ticket) ESCPOS(paperWidth int) ([]byte, error) {
+	/*
+		Renders the ticket as ESC/POS commands for a printer with paper of paperWidth millimetres, ending with a paper
+		cut. The logo and the QR code are sent as raster images rather than with the printer's own QR code command,
+		which not all printers support.
+	*/
+	dots, err := printableDots(paperWidth)
+	if err != nil {
+		return nil, err
+	}
+	columns := dots / 12 // font A is 12 dots wide
+	text := func(s string) float64 { return float64(len(winANSI(s))) }
+
+	var b bytes.Buffer
+	b.Write(escInit)
+	b.Write(escCodePage)
+	b.Write(escCenter)
+	if t.Logo != nil {
+		writeRaster(&b, logoBitmap(t.Logo, dots*2/3, dots/2))
+		b.WriteByte('\n')
+	}
+	for _, line := range wrap(t.Header, text, float64(columns)) {
+		b.Write(winANSI(line))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+
+	b.Write(escSizeHuge)
+	b.WriteString(strconv.Itoa(int(t.Number)))
+	b.WriteByte('\n')
+	b.Write(escSizeLarge)
+	b.Write(escBoldOn)
+	for _, line := range wrap(t.Purpose, text, float64(columns/2)) {
+		b.Write(winANSI(line))
+		b.WriteByte('\n')
+	}
+	b.Write(escBoldOff)
+	b.Write(escSizeNormal)
+	issued, wait := t.lines()
+	b.Write(winANSI(issued))
+	b.WriteByte('\n')
+	if wait != "" {
+		b.Write(winANSI(wait))
+		b.WriteByte('\n')
+	}
+
+	code, err := t.qrCode()
+	if err != nil {
+		return nil, err
+	} else if code != nil {
+		b.WriteByte('\n')
+		writeRaster(&b, qrBitmap(code, dots/2))
+		b.Write(winANSI(t.scanCaption()))
+		b.WriteByte('\n')
+	}
+
+	if lines := wrap(t.Footer, text, float64(columns)); len(lines) > 0 {
+		b.WriteByte('\n')
+		for _, line := range lines {
+			b.Write(winANSI(line))
+			b.WriteByte('\n')
+		}
+	}
+	b.Write(escFeedAndCut)
+	return b.Bytes(), nil
+}
+
+func writeRaster(b *bytes.Buffer, bm bitmap) {
+	// GS v 0: a raster image of bm.width dots, padded to whole bytes, in bands the printer's buffer can take
+	rowBytes := (bm.width + 7) / 8
+	for top := 0; top < bm.height; top += 256 {
+		height := min(256, bm.height-top)
+		b.Write([]byte{0x1D, 'v', '0', 0, byte(rowBytes), byte(rowBytes >> 8), byte(height), byte(height >> 8)})
+		for y := top; y < top+height; y++ {
+			for xb := range rowBytes {
+				var v byte
+				for bit := range 8 {
+					if x := xb*8 + bit; x < bm.width && bm.at(x, y) {
+						v |= 0x80 >> bit
+					}
+				}
+				b.WriteByte(v)
+			}
+		}
+	}
+}
+
+func logoBitmap(img image.Image, maxWidth, maxHeight int) bitmap {
+	// thermal printers print black or nothing, so the logo is dithered (Floyd-Steinberg) to keep grey areas grey
+	width, height, pixels := grayscale(img, maxWidth, maxHeight)
+	errs := make([]int, len(pixels))
+	for i, p := range pixels {
+		errs[i] = int(p)
+	}
+	bm := bitmap{width: width, height: height, dark: make([]bool, width*height)}
+	for y := range height {
+		for x := range width {
+			i := y*width + x
+			value := 255
+			if errs[i] < 128 {
+				value = 0
+				bm.dark[i] = true
+			}
+			e := errs[i] - value
+			if x+1 < width {
+				errs[i+1] += e * 7 / 16
+			}
+			if y+1 < height {
+				if x > 0 {
+					errs[i+width-1] += e * 3 / 16
+				}
+				errs[i+width] += e * 5 / 16
+				if x+1 < width {
+					errs[i+width+1] += e / 16
+				}
+			}
+		}
+	}
+	return bm
+}
+
+func qrBitmap(code *qrcode.Code, maxWidth int) bitmap {
+	// the code with its quiet zone, each module the same whole number of dots wide
+	scale := max(1, maxWidth/(code.Size+2*qrcode.QuietZone))
+	size := (code.Size + 2*qrcode.QuietZone) * scale
+	bm := bitmap{width: size, height: size, dark: make([]bool, size*size)}
+	for y := range size {
+		for x := range size {
+			bm.dark[y*size+x] = code.Dark(x/scale-qrcode.QuietZone, y/scale-qrcode.QuietZone)
+		}
+	}
+	return bm
+}
@@ -0,0 +1,192 @@
+package ticket
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/dcrauwels/goqueue/qrcode"
+)
+
+// widths of the printable ASCII characters (from the space) in the standard Helvetica font, in 1/1000 of the font
+// size. Other characters are taken as 556 wide, which is close enough to centre text
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func textWidth(s string, size float64) float64 {
+	// the width of s in points in Helvetica. Bold text is slightly wider, which centring can live with
+	w := 0
+	for _, c := range winANSI(s) {
+		if c >= 32 && c < 127 {
+			w += helveticaWidths[c-32]
+		} else {
+			w += 556
+		}
+	}
+	return float64(w) * size / 1000
+}
+
+const mm = 72 / 25.4 // points per millimetre
+
+func pdfString(s string) string {
+	// a PDF literal string of s in WinAnsiEncoding
+	var b bytes.Buffer
+	b.WriteByte('(')
+	for _, c := range winANSI(s) {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func (t Ticket) PDF(paperWidth int) ([]byte, error) {
+	/*
+		Renders the ticket as a PDF of a single page paperWidth millimetres wide and as long as the ticket needs, like
+		a receipt. Text is set in the standard Helvetica fonts, which every PDF reader has, so no fonts are embedded.
+	*/
+	if paperWidth != Paper58 && paperWidth != Paper80 {
+		return nil, ErrInvalidPaperWidth
+	}
+	pageWidth := float64(paperWidth) * mm
+	margin := 4 * mm
+	contentWidth := pageWidth - 2*margin
+
+	// the page is laid out top down and turned around once its height is known, as PDF measures from the bottom
+	var ops []func(pageHeight float64) string
+	y := margin
+	centred := func(line, font string, size float64) {
+		x := (pageWidth - textWidth(line, size)) / 2
+		baseline := y + size*0.8
+		ops = append(ops, func(h float64) string {
+			return fmt.Sprintf("BT /%s %s Tf %s %s Td %s Tj ET\n", font, num(size), num(x), num(h-baseline), pdfString(line))
+		})
+		y += size * 1.25
+	}
+	paragraph := func(text, font string, size float64) {
+		for _, line := range wrap(text, func(s string) float64 { return textWidth(s, size) }, contentWidth) {
+			centred(line, font, size)
+		}
+	}
+
+	var logo []byte
+	var logoWidth, logoHeight int
+	if t.Logo != nil {
+		logoWidth, logoHeight, logo = grayscale(t.Logo, 600, 600)
+		w := contentWidth * 2 / 3
+		h := w * float64(logoHeight) / float64(logoWidth)
+		if h > 30*mm {
+			w, h = w*30*mm/h, 30*mm
+		}
+		x, top := (pageWidth-w)/2, y
+		ops = append(ops, func(pageHeight float64) string {
+			return fmt.Sprintf("q %s 0 0 %s %s %s cm /Logo Do Q\n", num(w), num(h), num(x), num(pageHeight-top-h))
+		})
+		y += h + 3*mm
+	}
+	paragraph(t.Header, "F1", 9)
+	y += 2 * mm
+	centred(strconv.Itoa(int(t.Number)), "F2", 48)
+	paragraph(t.Purpose, "F2", 14)
+	issued, wait := t.lines()
+	centred(issued, "F1", 9)
+	if wait != "" {
+		centred(wait, "F1", 9)
+	}
+
+	code, err := t.qrCode()
+	if err != nil {
+		return nil, err
+	} else if code != nil {
+		y += 2 * mm
+		module := contentWidth / 2 / float64(code.Size+2*qrcode.QuietZone)
+		left, top := (pageWidth-float64(code.Size)*module)/2, y+qrcode.QuietZone*module
+		ops = append(ops, func(pageHeight float64) string {
+			// one rectangle per horizontal run of dark modules
+			var b bytes.Buffer
+			b.WriteString("0 g\n")
+			for row := range code.Size {
+				for col := 0; col < code.Size; col++ {
+					if !code.Dark(col, row) {
+						continue
+					}
+					run := 1
+					for code.Dark(col+run, row) {
+						run++
+					}
+					fmt.Fprintf(&b, "%s %s %s %s re\n", num(left+float64(col)*module), num(pageHeight-top-float64(row+1)*module), num(float64(run)*module), num(module))
+					col += run - 1
+				}
+			}
+			b.WriteString("f\n")
+			return b.String()
+		})
+		y += float64(code.Size+2*qrcode.QuietZone) * module
+		centred(t.scanCaption(), "F1", 8)
+	}
+	if t.Footer != "" {
+		y += 2 * mm
+		paragraph(t.Footer, "F1", 9)
+	}
+	pageHeight := y + margin
+
+	var content bytes.Buffer
+	for _, op := range ops {
+		content.WriteString(op(pageHeight))
+	}
+
+	// the document: catalog, page tree, page, fonts, content and the logo, followed by the cross-reference table
+	var doc bytes.Buffer
+	var offsets []int
+	object := func(dict string, stream []byte) {
+		offsets = append(offsets, doc.Len())
+		fmt.Fprintf(&doc, "%d 0 obj\n", len(offsets))
+		if stream == nil {
+			doc.WriteString(dict + "\nendobj\n")
+			return
+		}
+		fmt.Fprintf(&doc, "%s\nstream\n", dict)
+		doc.Write(stream)
+		doc.WriteString("\nendstream\nendobj\n")
+	}
+	doc.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	resources := "/Font << /F1 4 0 R /F2 5 0 R >>"
+	if logo != nil {
+		resources += " /XObject << /Logo 7 0 R >>"
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents 6 0 R >>", num(pageWidth), num(pageHeight), resources), nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+	object(fmt.Sprintf("<< /Length %d >>", content.Len()), content.Bytes())
+	if logo != nil {
+		var compressed bytes.Buffer
+		z := zlib.NewWriter(&compressed)
+		z.Write(logo)
+		z.Close()
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>", logoWidth, logoHeight, compressed.Len()), compressed.Bytes())
+	}
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return doc.Bytes(), nil
+}
+
+func num(f float64) string {
+	// numbers in PDF content are written with two decimals at most
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
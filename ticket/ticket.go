@@ -0,0 +1,151 @@
+// Package ticket renders the ticket a visitor takes from a kiosk: as ESC/POS commands for thermal receipt printers
+// and as a single page PDF for everything else. Both show the same content, top to bottom: the logo and header text
+// of the purpose, the ticket number, the purpose, when the ticket was issued, the estimated wait, a QR code linking to
+// the visitor's status page and the footer text of the purpose.
+package ticket
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dcrauwels/goqueue/i18n"
+	"github.com/dcrauwels/goqueue/qrcode"
+)
+
+// paper widths in millimetres, the two sizes of receipt rolls
+const (
+	Paper58 = 58
+	Paper80 = 80
+)
+
+var ErrInvalidPaperWidth = errors.New("paper width must be 58 or 80 millimetres")
+
+type Ticket struct {
+	Number        int32
+	Purpose       string
+	IssuedAt      time.Time     // shown in its own time zone, so pass it in the time zone of the location
+	EstimatedWait time.Duration // zero if unknown
+	StatusURL     string        // encoded in the QR code, which is left out if this is empty
+	Header        string        // free text of the purpose above the ticket number, may span several lines
+	Footer        string        // free text of the purpose at the bottom
+	Logo          image.Image   // nil for none
+	Language      string        // of the estimated wait and the caption of the QR code, see i18n. Empty for English
+}
+
+func (t Ticket) lines() (issued, wait string) {
+	issued = t.IssuedAt.Format("2006-01-02 15:04")
+	if t.EstimatedWait > 0 {
+		minutes := int(math.Ceil(t.EstimatedWait.Minutes()))
+		if minutes == 1 {
+			wait = i18n.Message(t.language(), "ticket.wait_one")
+		} else {
+			wait = fmt.Sprintf(i18n.Message(t.language(), "ticket.wait_other"), minutes)
+		}
+	}
+	return issued, wait
+}
+
+func (t Ticket) scanCaption() string {
+	return i18n.Message(t.language(), "ticket.scan_caption")
+}
+
+func (t Ticket) language() string {
+	return cmp.Or(t.Language, i18n.DefaultLanguage)
+}
+
+func (t Ticket) qrCode() (*qrcode.Code, error) {
+	if t.StatusURL == "" {
+		return nil, nil
+	}
+	return qrcode.Encode([]byte(t.StatusURL), qrcode.Medium)
+}
+
+func winANSI(s string) []byte {
+	/*
+		Encodes s in Windows-1252, the character set of both the ESC/POS code page selected by the renderer and the
+		WinAnsiEncoding of the standard PDF fonts. Characters it lacks become question marks.
+	*/
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case r == '€':
+			out = append(out, 0x80)
+		case r == '‘', r == '’':
+			out = append(out, '\'')
+		case r == '“', r == '”':
+			out = append(out, '"')
+		case r == '–', r == '—':
+			out = append(out, '-')
+		case r < 0x20:
+			// control characters are dropped, line breaks are handled by the callers
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+func wrap(text string, width func(line string) float64, maxWidth float64) []string {
+	// splits text into lines at its line breaks and between words, so no line is wider than maxWidth if possible
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && width(line+" "+word) > maxWidth {
+				lines = append(lines, line)
+				line = word
+			} else if line != "" {
+				line += " " + word
+			} else {
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	// trailing empty lines are of no use on paper
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type bitmap struct {
+	width, height int
+	dark          []bool
+}
+
+func (b bitmap) at(x, y int) bool {
+	return b.dark[y*b.width+x]
+}
+
+func luminance(c color.Color) uint32 {
+	// 0 (black) to 0xFFFF (white), transparent pixels counting as white paper
+	r, g, b, a := c.RGBA()
+	l := (299*r + 587*g + 114*b) / 1000
+	return l + 0xFFFF - a
+}
+
+func grayscale(img image.Image, maxWidth, maxHeight int) (width, height int, pixels []byte) {
+	// scales img down to fit within maxWidth by maxHeight, keeping its proportions, and returns its grey values
+	bounds := img.Bounds()
+	scale := min(1, float64(maxWidth)/float64(bounds.Dx()), float64(maxHeight)/float64(bounds.Dy()))
+	width, height = max(1, int(float64(bounds.Dx())*scale)), max(1, int(float64(bounds.Dy())*scale))
+	pixels = make([]byte, width*height)
+	for y := range height {
+		for x := range width {
+			c := img.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale))
+			pixels[y*width+x] = byte(min(luminance(c), 0xFFFF) >> 8)
+		}
+	}
+	return width, height, pixels
+}
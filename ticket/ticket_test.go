@@ -0,0 +1,127 @@
+package ticket
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTicket() Ticket {
+	logo := image.NewRGBA(image.Rect(0, 0, 100, 40))
+	for x := range 50 {
+		for y := range 40 {
+			logo.Set(x, y, color.Black)
+		}
+	}
+	return Ticket{
+		Number:        12,
+		Purpose:       "Passports (renewal)",
+		IssuedAt:      time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC),
+		EstimatedWait: 14*time.Minute + 10*time.Second,
+		StatusURL:     "https://queue.example.org/visitors/V1StGXR8_Z5j",
+		Header:        "City Hall\nWelcome",
+		Footer:        "Please have your old passport ready.",
+		Logo:          logo,
+	}
+}
+
+func TestESCPOS(t *testing.T) {
+	out, err := testTicket().ESCPOS(Paper58)
+	if err != nil {
+		t.Fatalf(`ESCPOS: %v`, err)
+	}
+	if !bytes.HasPrefix(out, append(escInit, escCodePage...)) || !bytes.HasSuffix(out, escFeedAndCut) {
+		t.Errorf(`ESCPOS does not start with initialisation and end with a cut`)
+	}
+	for _, want := range []string{"City Hall\nWelcome\n", "12\n", "Passports\n(renewal)\n", "2026-03-02 09:30\n", "Estimated wait: about 15 minutes\n", "Scan to follow your turn\n", "Please have your old passport\nready.\n"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf(`ESCPOS output lacks %q`, want)
+		}
+	}
+
+	// the logo and the QR code, as raster images no wider than the paper
+	rasters := regexp.MustCompile("(?s)\x1Dv0\x00(..)(..)").FindAllSubmatch(out, -1)
+	if len(rasters) != 2 {
+		t.Fatalf(`ESCPOS output has %d raster images, expected 2`, len(rasters))
+	}
+	for _, raster := range rasters {
+		if width := int(raster[1][0]) + int(raster[1][1])<<8; width == 0 || width*8 > 384 {
+			t.Errorf(`raster image is %d bytes wide, expected at most 48`, width)
+		}
+	}
+
+	// in the language of the visitor
+	dutch := testTicket()
+	dutch.Language = "nl"
+	out, _ = dutch.ESCPOS(Paper58)
+	for _, want := range []string{"Geschatte wachttijd: ongeveer 15 minuten\n", "Scan om uw beurt te volgen\n"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf(`ESCPOS output in Dutch lacks %q`, want)
+		}
+	}
+	german := testTicket()
+	german.Language = "de"
+	out, _ = german.ESCPOS(Paper58)
+	if want := "Gesch\xE4tzte Wartezeit: etwa 15 Minuten\n"; !bytes.Contains(out, []byte(want)) || bytes.Contains(out, []byte("ä")) {
+		t.Errorf(`ESCPOS output in German lacks %q in Windows-1252`, want)
+	}
+
+	if _, err := testTicket().ESCPOS(70); err != ErrInvalidPaperWidth {
+		t.Errorf(`ESCPOS for 70 mm paper returned %v, expected ErrInvalidPaperWidth`, err)
+	}
+}
+
+func TestPDF(t *testing.T) {
+	for _, ticket := range []Ticket{testTicket(), {Number: 3, Purpose: "Permits", IssuedAt: time.Now()}} {
+		out, err := ticket.PDF(Paper80)
+		if err != nil {
+			t.Fatalf(`PDF: %v`, err)
+		}
+		if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+			t.Errorf(`PDF lacks header or trailer`)
+		}
+
+		// every entry of the cross-reference table points at its object
+		xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+		offset, _ := strconv.Atoi(string(xref[1]))
+		entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[offset:], -1)
+		for i, entry := range entries {
+			objectOffset, _ := strconv.Atoi(string(entry[1]))
+			if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[objectOffset:], []byte(want)) {
+				t.Errorf(`cross-reference entry %d points at %q`, i+1, out[objectOffset:objectOffset+10])
+			}
+		}
+		if hasLogo := bytes.Contains(out, []byte("/Logo Do")); hasLogo != (ticket.Logo != nil) || (len(entries) == 7) != hasLogo {
+			t.Errorf(`PDF has %d objects and logo %v, expected the logo only if the ticket has one`, len(entries), hasLogo)
+		}
+		if want := pdfString(strconv.Itoa(int(ticket.Number))) + " Tj"; !bytes.Contains(out, []byte(want)) {
+			t.Errorf(`PDF lacks the ticket number %s`, want)
+		}
+		if hasQR := bytes.Contains(out, []byte(" re\n")); hasQR != (ticket.StatusURL != "") {
+			t.Errorf(`PDF has a QR code: %v, expected one only if the ticket has a status URL`, hasQR)
+		}
+	}
+
+	if _, err := testTicket().PDF(0); err != ErrInvalidPaperWidth {
+		t.Errorf(`PDF for 0 mm paper returned %v, expected ErrInvalidPaperWidth`, err)
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := string(winANSI("Café “€5”\tok\x07 ✓")); got != "Caf\xe9 \"\x805\" ok ?" {
+		t.Errorf(`winANSI returned %q`, got)
+	}
+	if got := pdfString(`a (b) \c`); got != `(a \(b\) \\c)` {
+		t.Errorf(`pdfString returned %s`, got)
+	}
+	length := func(s string) float64 { return float64(len(s)) }
+	if got := wrap("one two three\n\nfour five\n\n", length, 8); strings.Join(got, "|") != "one two|three||four|five" {
+		t.Errorf(`wrap returned %q`, got)
+	}
+}
@@ -0,0 +1,381 @@
+// Package validate decodes JSON request bodies and checks them against rules declared on the fields of the request
+// parameter structs, in a validate tag:
+//
+//	Name            string `json:"name" validate:"required,max=64"`
+//	PurposePublicID string `json:"purpose_public_id" validate:"required,ref=purpose"`
+//
+// The rules are
+//   - required: not empty. Strings of only spaces are empty, as are null sql.Null* values and empty slices and maps
+//   - min=N, max=N: the length in characters of a string, or the value of a number
+//   - oneof=a b c: one of the values listed, separated by spaces
+//   - ref=name: the public ID of an existing resource, looked up by the Lookup of that name (see Validator)
+//
+// Rules other than required are not checked on empty strings and null values, so optional fields only need to be
+// valid when given. Rules on a slice apply to each of its elements. All fields are checked, and every invalid one
+// is listed in the *jsonutils.ValidationError returned. Validator.Decode lists the unknown fields of the body there too.
+package validate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+// MaxBodyBytes is the size limit of request bodies, unless a handler sets its own
+const MaxBodyBytes = 64 << 10
+
+var (
+	ErrRequired      = jsonutils.NewError(jsonutils.CodeRequired, "field is required")
+	ErrTooShort      = jsonutils.NewError(jsonutils.CodeTooShort, "field is too short")
+	ErrTooLong       = jsonutils.NewError(jsonutils.CodeTooLong, "field is too long")
+	ErrTooSmall      = jsonutils.NewError(jsonutils.CodeTooSmall, "field is too small")
+	ErrTooLarge      = jsonutils.NewError(jsonutils.CodeTooLarge, "field is too large")
+	ErrInvalidChoice = jsonutils.NewError(jsonutils.CodeInvalidChoice, "field is not one of the allowed values")
+	ErrUnknownField  = jsonutils.NewError(jsonutils.CodeUnknownField, "field is unknown")
+	ErrTrailingData  = errors.New("request body has data after the JSON value")
+)
+
+func Decode(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) error {
+	/*
+		Decodes the JSON body of r into dst. Bodies larger than maxBytes are cut off with an *http.MaxBytesError, fields
+		dst does not have are a *jsonutils.ValidationError listing each of them with code unknown_field and anything
+		after the JSON value is an error too. See Status for the response status of each.
+	*/
+	e, err := decode(w, r, dst, maxBytes)
+	if err != nil {
+		return err
+	} else if len(e.Fields) > 0 {
+		return e
+	}
+	return nil
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) (*jsonutils.ValidationError, error) {
+	// Decode, with the unknown fields in a ValidationError of their own. dst is decoded even if there are any
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(dst); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, ErrTrailingData
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, err
+	}
+	e := &jsonutils.ValidationError{}
+	unknownFields(reflect.TypeOf(dst), value, "", e)
+	return e, nil
+}
+
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+func unknownFields(t reflect.Type, value any, path string, e *jsonutils.ValidationError) {
+	/*
+		Adds the members of value, a decoded JSON value at path, that encoding/json finds no field of t for: like it
+		does, names match case-insensitively and objects in fields, slices and maps are checked as well. Types that
+		decode themselves take anything.
+	*/
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, _ := value.(map[string]any)
+		fields := jsonFields(t)
+		for _, name := range slices.Sorted(maps.Keys(object)) {
+			i := slices.IndexFunc(fields, func(f reflect.StructField) bool { return strings.EqualFold(jsonName(f), name) })
+			if i < 0 {
+				e.Add(join(path, name), ErrUnknownField)
+				continue
+			}
+			unknownFields(fields[i].Type, object[name], join(path, name), e)
+		}
+	case reflect.Slice, reflect.Array:
+		array, _ := value.([]any)
+		for i, element := range array {
+			unknownFields(t.Elem(), element, join(path, strconv.Itoa(i)), e)
+		}
+	case reflect.Map:
+		object, _ := value.(map[string]any)
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			unknownFields(t.Elem(), object[key], join(path, key), e)
+		}
+	}
+}
+
+func jsonFields(t reflect.Type) []reflect.StructField {
+	// the fields of struct type t that encoding/json decodes into, with those of embedded structs
+	var fields []reflect.StructField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(field.Type)...)
+		} else if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func jsonName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func join(path, name string) string {
+	// the path of member name of the JSON value at path, e.g. translations.nl
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func Status(err error) int {
+	// the status of the response to a request whose body Decode or Validator.Struct returned err for
+	var maxBytesErr *http.MaxBytesError
+	var validationErr *jsonutils.ValidationError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &validationErr), errors.As(err, &syntaxErr), errors.As(err, &typeErr),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, ErrTrailingData):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Lookup checks that a resource with publicID exists. It returns an error wrapping sql.ErrNoRows if there is none,
+// preferably with the code of its kind of resource, e.g. purpose_not_found; that becomes the code of the field
+type Lookup func(ctx context.Context, publicID string) error
+
+// Validator checks structs against the rules of their validate tags. Refs holds the lookups of ref rules by name
+type Validator struct {
+	Refs map[string]Lookup
+}
+
+func (v *Validator) Decode(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) error {
+	/*
+		Decode and Struct in one go: the *jsonutils.ValidationError returned lists both the unknown fields of the body
+		and the fields of dst that break their rules, so a client learns everything that is wrong with it at once.
+	*/
+	e, err := decode(w, r, dst, maxBytes)
+	if err != nil {
+		return err
+	}
+	if err := v.fields(r.Context(), reflect.Indirect(reflect.ValueOf(dst)), "", e); err != nil {
+		return err
+	}
+	if len(e.Fields) > 0 {
+		return e
+	}
+	return nil
+}
+
+func (v *Validator) Struct(ctx context.Context, s any) error {
+	/*
+		Checks the fields of s, a struct or a pointer to one, against their rules. Returns a *jsonutils.ValidationError
+		listing every invalid field, nil if all are valid, or the error of a lookup that failed for another reason than
+		a missing resource. Panics on rules it does not know, like regexp.MustCompile on invalid expressions: those are
+		mistakes in the code, not in the request.
+	*/
+	e := &jsonutils.ValidationError{}
+	if err := v.fields(ctx, reflect.Indirect(reflect.ValueOf(s)), "", e); err != nil {
+		return err
+	}
+	if len(e.Fields) > 0 {
+		return e
+	}
+	return nil
+}
+
+func (v *Validator) fields(ctx context.Context, s reflect.Value, prefix string, e *jsonutils.ValidationError) error {
+	// checks the fields of struct s, whose JSON names get prefix. Embedded structs are checked as part of s
+	for i := range s.NumField() {
+		field := s.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			// like encoding/json, even for embedded structs of unexported types
+			if err := v.fields(ctx, s.Field(i), prefix, e); err != nil {
+				return err
+			}
+			continue
+		} else if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		if err := v.field(ctx, s.Field(i), prefix+name, rules, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Validator) field(ctx context.Context, value reflect.Value, name, rules string, e *jsonutils.ValidationError) error {
+	// checks a single field against its rules, adding it to e if it breaks one. Only the first broken rule counts
+	value, empty := unwrap(value)
+	for _, rule := range strings.Split(rules, ",") {
+		rule, arg, _ := strings.Cut(rule, "=")
+		if rule == "required" {
+			if empty {
+				e.Add(name, ErrRequired)
+				return nil
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8 {
+			// the rule applies to the elements, which are checked on their own
+			for j := range value.Len() {
+				if err := v.field(ctx, value.Index(j), name+"."+strconv.Itoa(j), rule+"="+arg, e); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		broken, err := v.rule(ctx, value, rule, arg)
+		if err != nil {
+			return err
+		} else if broken != nil {
+			e.AddField(broken.field(name), broken.err)
+			return nil
+		}
+	}
+	return nil
+}
+
+// brokenRule is a rule a field breaks, with the limit of min and max rules and the values of oneof rules
+type brokenRule struct {
+	err     error
+	limit   *int64
+	allowed []string
+}
+
+func (b *brokenRule) field(name string) jsonutils.FieldError {
+	return jsonutils.FieldError{Field: name, Code: jsonutils.ErrorCode(http.StatusBadRequest, b.err), Limit: b.limit, Allowed: b.allowed, Detail: b.err.Error()}
+}
+
+func (v *Validator) rule(ctx context.Context, value reflect.Value, rule, arg string) (*brokenRule, error) {
+	// the way value breaks rule, nil if it does not. Errors are those of lookups
+	switch rule {
+	case "min", "max":
+		limit, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: rule %s has no integer limit: %q", rule, arg))
+		}
+		if value.Kind() == reflect.String {
+			length := int64(utf8.RuneCountInString(value.String()))
+			if rule == "min" && length < limit {
+				return &brokenRule{err: ErrTooShort, limit: &limit}, nil
+			} else if rule == "max" && length > limit {
+				return &brokenRule{err: ErrTooLong, limit: &limit}, nil
+			}
+			return nil, nil
+		}
+		n, ok := integer(value)
+		if !ok {
+			panic(fmt.Sprintf("validate: rule %s on a field of kind %s", rule, value.Kind()))
+		}
+		if rule == "min" && n < limit {
+			return &brokenRule{err: ErrTooSmall, limit: &limit}, nil
+		} else if rule == "max" && n > limit {
+			return &brokenRule{err: ErrTooLarge, limit: &limit}, nil
+		}
+		return nil, nil
+	case "oneof":
+		allowed := strings.Fields(arg)
+		s := value.String()
+		if n, ok := integer(value); ok {
+			s = strconv.FormatInt(n, 10)
+		}
+		for _, a := range allowed {
+			if s == a {
+				return nil, nil
+			}
+		}
+		return &brokenRule{err: ErrInvalidChoice, allowed: allowed}, nil
+	case "ref":
+		lookup, ok := v.Refs[arg]
+		if !ok {
+			panic(fmt.Sprintf("validate: no lookup for ref %q", arg))
+		}
+		if err := lookup(ctx, value.String()); errors.Is(err, sql.ErrNoRows) {
+			return &brokenRule{err: err}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	panic(fmt.Sprintf("validate: unknown rule %q", rule))
+}
+
+func unwrap(value reflect.Value) (reflect.Value, bool) {
+	// the value of a sql.Null* field, or value itself, and whether it is empty (see required)
+	switch v := value.Interface().(type) {
+	case sql.NullString:
+		return reflect.ValueOf(v.String), !v.Valid || strings.TrimSpace(v.String) == ""
+	case sql.NullInt32:
+		return reflect.ValueOf(v.Int32), !v.Valid
+	case sql.NullInt64:
+		return reflect.ValueOf(v.Int64), !v.Valid
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value, strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value, value.Len() == 0
+	case reflect.Pointer:
+		if value.IsNil() {
+			return value, true
+		}
+		return unwrap(value.Elem())
+	}
+	return value, false
+}
+
+func integer(value reflect.Value) (int64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), true
+	}
+	return 0, false
+}
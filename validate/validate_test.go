@@ -0,0 +1,158 @@
+package validate
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dcrauwels/goqueue/jsonutils"
+)
+
+type embedded struct {
+	DeskPublicID string `json:"desk_public_id" validate:"required"`
+}
+
+type request struct {
+	embedded
+	Name        string         `json:"name" validate:"required,max=5"`
+	Description sql.NullString `json:"description" validate:"min=3"`
+	Weekday     int32          `json:"weekday" validate:"min=0,max=6"`
+	Kind        string         `json:"kind" validate:"oneof=a b"`
+	PurposeIDs  []string       `json:"purpose_ids" validate:"ref=purpose"`
+	Capacity    sql.NullInt32  `json:"capacity" validate:"min=1"`
+	Ignored     string         `json:"-"`
+}
+
+var errNotFound = jsonutils.NewError(jsonutils.CodePurposeNotFound, "purpose not found")
+
+func TestStruct(t *testing.T) {
+	v := &Validator{Refs: map[string]Lookup{"purpose": func(ctx context.Context, publicID string) error {
+		if publicID != "known" {
+			return errNotFound.Wrap(sql.ErrNoRows)
+		}
+		return nil
+	}}}
+
+	// optional fields are only checked when given
+	if err := v.Struct(context.Background(), &request{Name: "ok", embedded: embedded{DeskPublicID: "d"}}); err != nil {
+		t.Errorf(`Struct of a valid request returned %v`, err)
+	}
+
+	// every invalid field is listed, with the first rule it breaks
+	err := v.Struct(context.Background(), request{
+		Name:        "toolong",
+		Description: sql.NullString{String: "ab", Valid: true},
+		Weekday:     7,
+		Kind:        "c",
+		PurposeIDs:  []string{"known", "unknown"},
+		Capacity:    sql.NullInt32{Int32: 0, Valid: true},
+	})
+	var validationErr *jsonutils.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf(`Struct returned %v, expected a ValidationError`, err)
+	}
+	want := map[string]string{
+		"desk_public_id": jsonutils.CodeRequired,
+		"name":           jsonutils.CodeTooLong,
+		"description":    jsonutils.CodeTooShort,
+		"weekday":        jsonutils.CodeTooLarge,
+		"kind":           jsonutils.CodeInvalidChoice,
+		"purpose_ids.1":  jsonutils.CodePurposeNotFound,
+		"capacity":       jsonutils.CodeTooSmall,
+	}
+	if len(validationErr.Fields) != len(want) {
+		t.Errorf(`Struct returned %+v, expected %d fields`, validationErr.Fields, len(want))
+	}
+	for _, f := range validationErr.Fields {
+		if want[f.Field] != f.Code {
+			t.Errorf(`Struct returned %s for %s, expected %s`, f.Code, f.Field, want[f.Field])
+		}
+	}
+	if !errors.Is(err, sql.ErrNoRows) || !errors.Is(err, ErrTooLong) {
+		t.Errorf(`Struct returned %v, expected it to wrap the errors of its fields`, err)
+	}
+
+	// lookups failing for another reason fail the validation
+	failing := &Validator{Refs: map[string]Lookup{"purpose": func(ctx context.Context, publicID string) error { return sql.ErrConnDone }}}
+	if err := failing.Struct(context.Background(), request{Name: "ok", embedded: embedded{DeskPublicID: "d"}, PurposeIDs: []string{"x"}}); !errors.Is(err, sql.ErrConnDone) || Status(err) != http.StatusInternalServerError {
+		t.Errorf(`Struct returned %v, expected the error of the lookup`, err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		body   string
+		status int // 0 if the body decodes
+		field  string
+	}{
+		{`{"name": "ok"}`, 0, ""},
+		{`{"name": "ok"}` + "\n", 0, ""},
+		{`{"name": "ok", "is_admin": true}`, http.StatusBadRequest, "is_admin"},
+		{`{"name": "ok"} {}`, http.StatusBadRequest, ""},
+		{`{"name": 5}`, http.StatusBadRequest, ""},
+		{`{"name": "` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+		err := Decode(httptest.NewRecorder(), r, &request{}, 64)
+		if tt.status == 0 {
+			if err != nil {
+				t.Errorf(`Decode(%s) returned %v`, tt.body, err)
+			}
+			continue
+		}
+		if Status(err) != tt.status {
+			t.Errorf(`Decode(%s) returned %v with status %d, expected %d`, tt.body, err, Status(err), tt.status)
+		}
+		var validationErr *jsonutils.ValidationError
+		if tt.field != "" && (!errors.As(err, &validationErr) || validationErr.Fields[0].Field != tt.field || validationErr.Fields[0].Code != jsonutils.CodeUnknownField) {
+			t.Errorf(`Decode(%s) returned %v, expected %s to be unknown`, tt.body, err, tt.field)
+		}
+	}
+}
+
+func TestValidatorDecode(t *testing.T) {
+	// unknown fields are listed along with the fields breaking a rule, matched like encoding/json does
+	body := `{"NAME": "ok", "desk_public_id": "d", "kind": "c", "is_admin": true, "Ignored": "x"}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	err := (&Validator{}).Decode(httptest.NewRecorder(), r, &request{}, 1024)
+	var validationErr *jsonutils.ValidationError
+	if !errors.As(err, &validationErr) || Status(err) != http.StatusBadRequest {
+		t.Fatalf(`Decode(%s) returned %v, expected a ValidationError`, body, err)
+	}
+	want := map[string]string{
+		"Ignored":  jsonutils.CodeUnknownField,
+		"is_admin": jsonutils.CodeUnknownField,
+		"kind":     jsonutils.CodeInvalidChoice,
+	}
+	if len(validationErr.Fields) != len(want) {
+		t.Errorf(`Decode(%s) returned %+v, expected %d fields`, body, validationErr.Fields, len(want))
+	}
+	for _, f := range validationErr.Fields {
+		if want[f.Field] != f.Code {
+			t.Errorf(`Decode(%s) returned %s for %s, expected %s`, body, f.Code, f.Field, want[f.Field])
+		}
+	}
+
+	// nested objects are checked too, except for types decoding themselves
+	type nested struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+		Translations map[string]struct {
+			Name string `json:"name"`
+		} `json:"translations"`
+		Raw json.RawMessage `json:"raw"`
+	}
+	body = `{"items": [{"name": "a"}, {"nme": "b"}], "translations": {"nl": {"name": "c", "x": 1}}, "raw": {"y": 2}}`
+	r = httptest.NewRequest("POST", "/", strings.NewReader(body))
+	err = (&Validator{}).Decode(httptest.NewRecorder(), r, &nested{}, 1024)
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 2 || validationErr.Fields[0].Field != "items.1.nme" || validationErr.Fields[1].Field != "translations.nl.x" {
+		t.Errorf(`Decode(%s) returned %v, expected items.1.nme and translations.nl.x to be unknown`, body, err)
+	}
+}
@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+type Policy struct {
+	MaxAttempts int           // attempts after which a delivery is given up on and marked failed
+	BaseDelay   time.Duration // wait after the first failed attempt, doubled for every subsequent failure
+	MaxDelay    time.Duration // upper bound for the wait
+	Timeout     time.Duration // for a single attempt, including reading the response
+}
+
+func DefaultPolicy() Policy {
+	// retries for about a day before giving up
+	return Policy{
+		MaxAttempts: 10,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    6 * time.Hour,
+		Timeout:     10 * time.Second,
+	}
+}
+
+func (p Policy) Backoff(attempts int) time.Duration {
+	// returns the wait before the next attempt of a delivery that failed attempts times
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+type Dispatcher struct {
+	DB        storage.Store
+	Client    *http.Client
+	Policy    Policy
+	BatchSize int32 // deliveries attempted per RunOnce
+}
+
+func NewDispatcher(db storage.Store, p Policy) *Dispatcher {
+	return &Dispatcher{
+		DB:        db,
+		Client:    &http.Client{Timeout: p.Timeout},
+		Policy:    p,
+		BatchSize: 100,
+	}
+}
+
+func (d *Dispatcher) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	/*
+		Attempts the deliveries that are due at now, oldest first, and returns how many were attempted. Each delivery is
+		claimed before it is sent, which keeps it from being sent twice by dispatchers of other instances of goqueue.
+		A claim lasts twice the timeout of an attempt, after which the delivery is due again should this instance have
+		died while sending it.
+	*/
+	due, err := d.DB.GetDueWebhookDeliveries(ctx, database.GetDueWebhookDeliveriesParams{NextAttemptAt: now, Limit: d.BatchSize})
+	if err != nil {
+		return 0, err
+	}
+	attempted := 0
+	for _, delivery := range due {
+		_, err := d.DB.ClaimWebhookDelivery(ctx, database.ClaimWebhookDeliveryParams{
+			LeaseUntil: now.Add(2 * d.Policy.Timeout),
+			PublicID:   delivery.PublicID,
+			Now:        now,
+		})
+		if errors.Is(err, sql.ErrNoRows) { // claimed by another dispatcher
+			continue
+		} else if err != nil {
+			return attempted, err
+		}
+		subscription, err := d.DB.GetWebhookSubscriptionByPublicID(ctx, delivery.SubscriptionPublicID)
+		if errors.Is(err, sql.ErrNoRows) { // deleted since, which deletes the delivery as well
+			continue
+		} else if err != nil {
+			return attempted, err
+		}
+		if _, err := d.DB.SetWebhookDeliveryResult(ctx, d.attempt(ctx, subscription, delivery, now)); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, s database.WebhookSubscription, delivery database.WebhookDelivery, now time.Time) database.SetWebhookDeliveryResultParams {
+	// posts delivery to s and returns the outcome to store. Any 2xx response counts as delivered
+	result := database.SetWebhookDeliveryResultParams{PublicID: delivery.PublicID, NextAttemptAt: now}
+	statusCode, err := d.post(ctx, s, delivery, now)
+	if statusCode != 0 {
+		result.ResponseStatus = sql.NullInt32{Int32: int32(statusCode), Valid: true}
+	}
+	if err == nil {
+		result.Status = StatusDelivered
+		result.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+		return result
+	}
+
+	result.LastError = sql.NullString{String: err.Error(), Valid: true}
+	attempts := int(delivery.Attempts) + 1
+	if attempts >= d.Policy.MaxAttempts {
+		result.Status = StatusFailed
+	} else {
+		result.Status = StatusPending
+		result.NextAttemptAt = now.Add(d.Policy.Backoff(attempts))
+	}
+	return result
+}
+
+func (d *Dispatcher) post(ctx context.Context, s database.WebhookSubscription, delivery database.WebhookDelivery, now time.Time) (int, error) {
+	// returns the response status code, zero if there was no response
+	ctx, cancel := context.WithTimeout(ctx, d.Policy.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "goqueue-webhook")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.PublicID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // lets the connection be reused
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func Schedule(ctx context.Context, d *Dispatcher, interval time.Duration) {
+	/*
+		Runs d every interval until ctx is done. Meant to be started in its own goroutine. Every instance of goqueue can
+		run it, as deliveries are claimed before they are sent.
+	*/
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.RunOnce(ctx, time.Now().UTC()); err != nil { // deliveries are due in UTC, like every stored time
+			log.Printf("webhook delivery failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package webhook sends queue events to the URLs admins subscribed to them. Events are written to a delivery queue in
+// the transaction of the change itself, so no event is lost or sent for a change that was rolled back, and a
+// Dispatcher posts them from there with retries.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+)
+
+// event types
+const (
+	EventVisitorCreated = "visitor.created" // a visitor took a ticket
+	EventVisitorCalled  = "visitor.called"  // a visitor was called to a desk, i.e. a service log was created
+	EventVisitorServed  = "visitor.served"  // the service of a visitor ended, i.e. a service log became inactive
+)
+
+var EventTypes = []string{EventVisitorCreated, EventVisitorCalled, EventVisitorServed}
+
+func ValidEventType(eventType string) bool {
+	return slices.Contains(EventTypes, eventType)
+}
+
+// delivery statuses, as in the webhook_deliveries table
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// request headers of a delivery
+const (
+	HeaderEvent     = "X-Goqueue-Event"
+	HeaderDelivery  = "X-Goqueue-Delivery"
+	HeaderTimestamp = "X-Goqueue-Timestamp"
+	HeaderSignature = "X-Goqueue-Signature"
+)
+
+// Event is the JSON body of a delivery. Redeliveries send the same body, so receivers can use ID to skip events
+// they have already handled.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+func Enqueue(ctx context.Context, q storage.Store, e Event, newPublicID func() string) (int, error) {
+	/*
+		Queues a delivery of e to every subscription to its type, due right away. Pass the q of the transaction making
+		the change the event is about (storage.Store.InTx). Returns the number of deliveries queued.
+	*/
+	subscriptions, err := q.GetWebhookSubscriptionsByEventType(ctx, e.Type)
+	if err != nil || len(subscriptions) == 0 {
+		return 0, err
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range subscriptions {
+		_, err := q.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			PublicID:             newPublicID(),
+			SubscriptionPublicID: s.PublicID,
+			EventType:            e.Type,
+			Payload:              payload,
+			NextAttemptAt:        e.CreatedAt,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(subscriptions), nil
+}
+
+func MakeSecret() (string, error) {
+	// returns a random secret for a subscription that was registered without one
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func Sign(secret string, timestamp int64, body []byte) string {
+	/*
+		Returns the X-Goqueue-Signature of body sent at timestamp (Unix seconds): "sha256=" followed by the hex encoded
+		HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret of the subscription. Signing the
+		timestamp along lets receivers reject replays of old deliveries.
+	*/
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	// reports whether signature is the Sign of body, in constant time. For receivers written in Go and for tests
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dcrauwels/goqueue/internal/database"
+	"github.com/dcrauwels/goqueue/storage"
+	"github.com/google/uuid"
+)
+
+// receiver records the requests posted to it and responds with status
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	w.WriteHeader(rc.status)
+}
+
+func subscribe(t *testing.T, s storage.Store, url, eventType string) database.WebhookSubscription {
+	t.Helper()
+	subscription, err := s.CreateWebhookSubscription(context.Background(), database.CreateWebhookSubscriptionParams{
+		PublicID:  uuid.NewString(),
+		Url:       url,
+		Secret:    "secret",
+		EventType: eventType,
+	})
+	if err != nil {
+		t.Fatalf(`CreateWebhookSubscription: %v`, err)
+	}
+	return subscription
+}
+
+func enqueue(t *testing.T, s storage.Store, now time.Time) {
+	t.Helper()
+	_, err := Enqueue(context.Background(), s, Event{ID: "event00001", Type: EventVisitorCreated, CreatedAt: now, Data: map[string]string{"ticket": "A1"}}, uuid.NewString)
+	if err != nil {
+		t.Fatalf(`Enqueue: %v`, err)
+	}
+}
+
+func testPolicy() Policy {
+	return Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour, Timeout: time.Second}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"event00001"}`)
+	signature := Sign("secret", 1700000000, body)
+	if !Verify("secret", 1700000000, body, signature) {
+		t.Errorf(`Verify rejected signature %s`, signature)
+	}
+	tampered := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+	}{
+		{"secret", "other", 1700000000, body},
+		{"timestamp", "secret", 1700000001, body},
+		{"body", "secret", 1700000000, []byte(`{"id":"event00002"}`)},
+	}
+	for _, tt := range tampered {
+		if Verify(tt.secret, tt.timestamp, tt.body, signature) {
+			t.Errorf(`Verify accepted the signature with another %s`, tt.name)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := testPolicy()
+	want := map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 7: time.Hour, 30: time.Hour}
+	for attempts, delay := range want {
+		if got := p.Backoff(attempts); got != delay {
+			t.Errorf(`Backoff(%d) returned %v, expected %v`, attempts, got, delay)
+		}
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemory()
+	first := subscribe(t, s, "https://example.org/first", EventVisitorCreated)
+	subscribe(t, s, "https://example.org/second", EventVisitorCreated)
+	other := subscribe(t, s, "https://example.org/other", EventVisitorServed)
+
+	n, err := Enqueue(ctx, s, Event{ID: "event00001", Type: EventVisitorCreated, CreatedAt: time.Now()}, uuid.NewString)
+	if err != nil || n != 2 {
+		t.Errorf(`Enqueue returned %d, %v; expected a delivery for both subscriptions to the event`, n, err)
+	}
+	if got, _ := s.GetWebhookDeliveriesBySubscriptionPublicID(ctx, database.GetWebhookDeliveriesBySubscriptionPublicIDParams{SubscriptionPublicID: first.PublicID, Limit: 10}); len(got) != 1 || got[0].Status != StatusPending {
+		t.Errorf(`GetWebhookDeliveriesBySubscriptionPublicID returned %+v, expected one pending delivery`, got)
+	}
+	if got, _ := s.GetWebhookDeliveriesBySubscriptionPublicID(ctx, database.GetWebhookDeliveriesBySubscriptionPublicIDParams{SubscriptionPublicID: other.PublicID, Limit: 10}); len(got) != 0 {
+		t.Errorf(`Enqueue queued %d deliveries for a subscription to another event`, len(got))
+	}
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemory()
+	rc := &receiver{status: http.StatusNoContent}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	subscription := subscribe(t, s, server.URL, EventVisitorCreated)
+	now := time.Now()
+	enqueue(t, s, now)
+
+	d := NewDispatcher(s, testPolicy())
+	if n, err := d.RunOnce(ctx, now); err != nil || n != 1 {
+		t.Fatalf(`RunOnce returned %d, %v; expected one delivery`, n, err)
+	}
+	if len(rc.requests) != 1 {
+		t.Fatalf(`receiver got %d requests, expected 1`, len(rc.requests))
+	}
+	req, body := rc.requests[0], rc.bodies[0]
+	timestamp, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil || !Verify("secret", timestamp, body, req.Header.Get(HeaderSignature)) {
+		t.Errorf(`signature %q of timestamp %q does not verify`, req.Header.Get(HeaderSignature), req.Header.Get(HeaderTimestamp))
+	}
+	if req.Header.Get(HeaderEvent) != EventVisitorCreated || req.Header.Get(HeaderDelivery) == "" {
+		t.Errorf(`delivery sent with headers %v`, req.Header)
+	}
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil || e.ID != "event00001" || e.Type != EventVisitorCreated {
+		t.Errorf(`delivery sent body %s (%v)`, body, err)
+	}
+
+	deliveries, _ := s.GetWebhookDeliveriesBySubscriptionPublicID(ctx, database.GetWebhookDeliveriesBySubscriptionPublicIDParams{SubscriptionPublicID: subscription.PublicID, Limit: 10})
+	if len(deliveries) != 1 || deliveries[0].Status != StatusDelivered || deliveries[0].ResponseStatus.Int32 != http.StatusNoContent || deliveries[0].Attempts != 1 {
+		t.Errorf(`delivery stored as %+v, expected delivered with response 204`, deliveries)
+	}
+
+	// nothing is sent twice
+	if n, err := d.RunOnce(ctx, now.Add(time.Hour)); err != nil || n != 0 {
+		t.Errorf(`second RunOnce returned %d, %v; expected nothing to deliver`, n, err)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemory()
+	rc := &receiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	subscription := subscribe(t, s, server.URL, EventVisitorCreated)
+	now := time.Now()
+	enqueue(t, s, now)
+	d := NewDispatcher(s, testPolicy())
+	delivery := func() database.WebhookDelivery {
+		deliveries, _ := s.GetWebhookDeliveriesBySubscriptionPublicID(ctx, database.GetWebhookDeliveriesBySubscriptionPublicIDParams{SubscriptionPublicID: subscription.PublicID, Limit: 1})
+		return deliveries[0]
+	}
+
+	// the first failure waits BaseDelay, the second twice that
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if n, err := d.RunOnce(ctx, now); err != nil || n != 1 {
+			t.Fatalf(`RunOnce of attempt %d returned %d, %v`, attempt+1, n, err)
+		}
+		got := delivery()
+		if got.Status != StatusPending || got.ResponseStatus.Int32 != 500 || !got.LastError.Valid || !got.NextAttemptAt.Equal(now.Add(wait)) {
+			t.Errorf(`delivery after attempt %d is %+v, expected it pending until %v`, attempt+1, got, now.Add(wait))
+		}
+		if n, _ := d.RunOnce(ctx, now.Add(wait-time.Second)); n != 0 {
+			t.Errorf(`RunOnce attempted the delivery before its backoff ran out`)
+		}
+		now = now.Add(wait)
+	}
+
+	// the last attempt gives up
+	if _, err := d.RunOnce(ctx, now); err != nil {
+		t.Fatalf(`RunOnce: %v`, err)
+	}
+	if got := delivery(); got.Status != StatusFailed || got.Attempts != 3 {
+		t.Errorf(`delivery after %d attempts is %+v, expected it failed`, len(rc.requests), got)
+	}
+	if n, _ := d.RunOnce(ctx, now.Add(24*time.Hour)); n != 0 || len(rc.requests) != 3 {
+		t.Errorf(`a failed delivery was attempted again`)
+	}
+
+	// until it is redelivered, and the receiver is back
+	rc.status = http.StatusOK
+	if _, err := s.RedeliverWebhookDelivery(ctx, database.RedeliverWebhookDeliveryParams{PublicID: delivery().PublicID, NextAttemptAt: now}); err != nil {
+		t.Fatalf(`RedeliverWebhookDelivery: %v`, err)
+	}
+	if _, err := d.RunOnce(ctx, now); err != nil {
+		t.Fatalf(`RunOnce: %v`, err)
+	}
+	if got := delivery(); got.Status != StatusDelivered || got.ResponseStatus.Int32 != 200 || string(rc.bodies[3]) != string(rc.bodies[0]) {
+		t.Errorf(`redelivery is %+v, expected it delivered with the original body`, got)
+	}
+}
+
+func TestDispatcherUnreachable(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemory()
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close() // nothing listens at its URL anymore
+	subscription := subscribe(t, s, server.URL, EventVisitorCreated)
+	now := time.Now()
+	enqueue(t, s, now)
+
+	if _, err := NewDispatcher(s, testPolicy()).RunOnce(ctx, now); err != nil {
+		t.Fatalf(`RunOnce: %v`, err)
+	}
+	deliveries, _ := s.GetWebhookDeliveriesBySubscriptionPublicID(ctx, database.GetWebhookDeliveriesBySubscriptionPublicIDParams{SubscriptionPublicID: subscription.PublicID, Limit: 1})
+	if got := deliveries[0]; got.Status != StatusPending || got.ResponseStatus.Valid || !got.LastError.Valid {
+		t.Errorf(`delivery to an unreachable URL is %+v, expected it pending without response status`, got)
+	}
+}
+
+// timestampStore compares due times like a TIMESTAMP column of Postgres does: by their wall clock, offset dropped
+type timestampStore struct {
+	storage.Store
+}
+
+func (s timestampStore) GetDueWebhookDeliveries(ctx context.Context, arg database.GetDueWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	t := arg.NextAttemptAt
+	arg.NextAttemptAt = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return s.Store.GetDueWebhookDeliveries(ctx, arg)
+}
+
+func TestScheduleLocalTime(t *testing.T) {
+	// deliveries are due in UTC, so west of UTC the local wall clock lags behind them
+	local := time.Local
+	time.Local = time.FixedZone("UTC-10", -10*60*60)
+	t.Cleanup(func() { time.Local = local })
+	s := timestampStore{storage.NewMemory()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	delivered := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+		close(delivered)
+	}))
+	defer server.Close()
+	subscribe(t, s, server.URL, EventVisitorCreated)
+	enqueue(t, s, time.Now().UTC())
+
+	go Schedule(ctx, NewDispatcher(s, testPolicy()), time.Hour)
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Errorf(`Schedule did not send a delivery that was due`)
+	}
+}